@@ -64,7 +64,9 @@ func NewClusternetAgentCmd(ctx context.Context) *cobra.Command {
 			if err != nil {
 				klog.Exit(err)
 			}
-			agent.Run()
+			if err := agent.Run(); err != nil {
+				klog.Exit(err)
+			}
 		},
 	}
 
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/dryrun"
+	"github.com/clusternet/clusternet/pkg/version"
+)
+
+var (
+	// the command name
+	cmdName = "clusternet-dryrun"
+)
+
+// NewClusternetDryRunCmd creates a *cobra.Command object with default parameters
+func NewClusternetDryRunCmd(ctx context.Context) *cobra.Command {
+	opts := dryrun.NewDryRunOptions()
+
+	cmd := &cobra.Command{
+		Use: cmdName,
+		Long: `Render the Descriptions of a Subscription for each target cluster, and perform server-side
+dry-run applies against the child clusters, reporting admission/validation failures per cluster
+before anything real is changed`,
+		Example: `  # dry-run Subscription app-demo in namespace default
+  clusternet-dryrun --parent-kubeconfig=parent.config -n default --subscription-name=app-demo
+
+  # print the final manifests of Subscription app-demo rendered for each cluster
+  clusternet-dryrun --parent-kubeconfig=parent.config -n default --subscription-name=app-demo --render-only`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := version.PrintAndExitIfRequested(cmdName); err != nil {
+				klog.Exit(err)
+			}
+
+			if err := utilerrors.NewAggregate(opts.Validate()); err != nil {
+				klog.Exit(err)
+			}
+
+			cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+				klog.V(1).Infof("FLAG: --%s=%q", flag.Name, flag.Value)
+			})
+
+			dr, err := dryrun.NewDryRunner(ctx, opts, os.Stdout)
+			if err != nil {
+				klog.Exit(err)
+			}
+			if err := dr.Run(ctx); err != nil {
+				klog.Exit(err)
+			}
+		},
+	}
+
+	version.AddVersionFlag(cmd.Flags())
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
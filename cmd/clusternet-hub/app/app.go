@@ -18,15 +18,23 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/kubernetes"
+	componentbaseoptions "k8s.io/component-base/config/options"
 	"k8s.io/klog/v2"
 
 	_ "github.com/clusternet/clusternet/pkg/features"
 	"github.com/clusternet/clusternet/pkg/hub"
 	"github.com/clusternet/clusternet/pkg/hub/options"
+	"github.com/clusternet/clusternet/pkg/hub/selfcheck"
+	"github.com/clusternet/clusternet/pkg/utils"
 	"github.com/clusternet/clusternet/pkg/version"
 )
 
@@ -72,10 +80,234 @@ func NewClusternetHubCmd(ctx context.Context) *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.BoolVar(&opts.TunnelLogging, "enable-tunnel-logging", opts.TunnelLogging, "Enable tunnel logging")
+	flags.BoolVar(&opts.RequireProxyGrants, "require-proxy-grants", opts.RequireProxyGrants,
+		"Deny all proxied requests to child clusters unless the requester is explicitly granted by Grant objects")
+	flags.IntVar(&opts.MaxProxiedRequestsPerCluster, "max-proxied-requests-per-cluster", opts.MaxProxiedRequestsPerCluster,
+		"The max number of concurrent in-flight requests proxied to a single child cluster, beyond which requests will be "+
+			"rejected with 429. Long-running requests, such as watch and exec, are not counted. 0 means no limit")
+	flags.IntVar(&opts.MaxProxiedRequestsPerUser, "max-proxied-requests-per-user", opts.MaxProxiedRequestsPerUser,
+		"The max number of concurrent in-flight requests proxied from a single user, beyond which requests will be "+
+			"rejected with 429. Long-running requests, such as watch and exec, are not counted. 0 means no limit")
+	flags.BoolVar(&opts.ProxyImpersonation, "proxy-impersonation", opts.ProxyImpersonation,
+		"Send proxied requests to child clusters with the credentials of clusternet-agent impersonating the requesters, "+
+			"so that they are authorized and audited per user in child clusters. Requests carrying their own credentials "+
+			"of child clusters are passed through as they are")
+	flags.StringSliceVar(&opts.ProxyImpersonationGroups, "proxy-impersonation-groups", opts.ProxyImpersonationGroups,
+		"The groups of requesters forwarded to child clusters with --proxy-impersonation, such as dev,ops. "+
+			"Other groups are dropped, and none are forwarded if not specified")
+	flags.DurationVar(&opts.ProxySessionIdleTimeout, "proxy-session-idle-timeout", opts.ProxySessionIdleTimeout,
+		"How long a streaming session with a pod in child clusters, such as exec, attach, port-forward and logs, "+
+			"could stay without any data transferred before being closed. 0 means no limit")
+	flags.DurationVar(&opts.ProxySessionMaxDuration, "proxy-session-max-duration", opts.ProxySessionMaxDuration,
+		"How long a streaming session with a pod in child clusters, such as exec, attach, port-forward and logs, "+
+			"could last before being closed. 0 means no limit")
+	flags.StringVar(&opts.TunnelGRPCBindAddress, "tunnel-grpc-bind-address", opts.TunnelGRPCBindAddress,
+		"The address, such as ':8123', serving gRPC tunnels set up by clusternet-agents with mutual TLS, for "+
+			"environments where websocket connections are blocked or unstable. The serving certificate of "+
+			"clusternet-hub is used, and clusternet-agents are authenticated with client certificates signed by "+
+			"--cluster-signing-cert-file. Requires feature gates SocketConnection and CertificateSigning. Empty means disabled")
+	flags.StringVar(&opts.ShadowAdmissionCluster, "shadow-admission-cluster", opts.ShadowAdmissionCluster,
+		"The id of a child cluster, whose admission webhooks will be invoked with dry-run before persisting objects from shadow APIs")
+	flags.StringVar(&opts.ShadowAuditWebhook, "shadow-audit-webhook", opts.ShadowAuditWebhook,
+		"The url where the creations, updates and deletions of shadow resources are posted to in JSON, along with the "+
+			"requesting users. The users are always annotated on the Manifests and logged at verbosity 2")
+	flags.StringSliceVar(&opts.ShadowExcludeResources, "shadow-exclude-resources", opts.ShadowExcludeResources,
+		"A list of resources in the format of <group>/<resource> that will not be shadowed, such as secrets,coordination.k8s.io/leases,events.k8s.io/*. "+
+			"Resources in core group can be specified without group, and \"*\" matches all groups or resources")
+	flags.StringVar(&opts.PlacementWebhook, "placement-webhook", opts.PlacementWebhook,
+		"The url where placement changes of Subscriptions are posted to in JSON, for audit and chatops. "+
+			"Only events will be recorded if not specified")
+	flags.StringVar(&opts.LifecycleEventWebhook, "lifecycle-event-webhook", opts.LifecycleEventWebhook,
+		"The url where lifecycle events of Subscriptions, such as being scheduled, Descriptions being applied or failed, "+
+			"rollouts being paused and rolled back, are posted to in JSON. Only events will be recorded if not specified")
+	flags.StringVar(&opts.ResourceInterpreterWebhooks, "resource-interpreter-webhooks", opts.ResourceInterpreterWebhooks,
+		"The path of a YAML file declaring the webhooks that interpret custom kinds, such as judging their health and "+
+			"extracting their replicas. Kinds without webhooks are interpreted by the built-in interpreters")
+	flags.StringVar(&opts.NotificationConfig, "notification-config", opts.NotificationConfig,
+		"The path of a YAML file declaring the sinks, such as webhooks, Slack and emails, where the condition changes "+
+			"of Subscriptions, Descriptions and ManagedClusters are delivered to, along with the routes selecting them. "+
+			"No notifications are delivered if not specified")
+	flags.StringVar(&opts.RolloutPrometheusAddress, "rollout-prometheus-address", opts.RolloutPrometheusAddress,
+		"The address of Prometheus, such as http://prometheus.monitoring:9090, where the metrics in the analysis "+
+			"of Subscription rollouts are queried from")
+	flags.DurationVar(&opts.ClusterMonitorPeriod, "cluster-monitor-period", opts.ClusterMonitorPeriod,
+		"How often the heartbeats of ManagedClusters are checked")
+	flags.DurationVar(&opts.ClusterHeartbeatGracePeriod, "cluster-heartbeat-grace-period", opts.ClusterHeartbeatGracePeriod,
+		"How long a cluster can go without heartbeats before all its conditions are marked as Unknown. "+
+			"Both posting status and renewing Leases are taken as heartbeats. It should be larger than "+
+			"--cluster-lease-duration of clusternet-agent, or --cluster-status-update-frequency if Leases are disabled")
+	flags.DurationVar(&opts.ClusterEvictionTimeout, "cluster-eviction-timeout", opts.ClusterEvictionTimeout,
+		"How long a cluster can stay Unknown before its workloads are evicted, until the heartbeats recover. "+
+			"0 disables eviction")
+	flags.DurationVar(&opts.ClusterFailoverTolerance, "cluster-failover-tolerance", opts.ClusterFailoverTolerance,
+		"How long a cluster can stay not ready before the replicas divided to it fail over to other healthy clusters "+
+			"matched by the same Subscription, until the cluster gets ready again. 0 disables failover")
+	flags.IntVar(&opts.MaxManifestsPerDescription, "max-manifests-per-description", opts.MaxManifestsPerDescription,
+		"The max number of manifests carried by a single Description, beyond which manifests are split into "+
+			"multiple Descriptions. 0 means no limit")
+	flags.IntVar(&opts.MaxDescriptionBytes, "max-description-bytes", opts.MaxDescriptionBytes,
+		"The max total bytes of manifests carried by a single Description, beyond which manifests are split into "+
+			"multiple Descriptions. A single manifest larger than it is rejected. It should be less than the request "+
+			"size limit of etcd. 0 means no limit")
+	flags.DurationVar(&opts.DynamicSchedulingInterval, "dynamic-scheduling-interval", opts.DynamicSchedulingInterval,
+		"How often the replicas of Subscriptions with Dynamic dividing scheduling are re-divided across clusters "+
+			"by their available resources")
+	flags.IntVar(&opts.DescriptionRollbackAttempts, "description-rollback-attempts", opts.DescriptionRollbackAttempts,
+		"The number of failed attempts to deploy a Description, such as being rejected by admission webhooks, after which "+
+			"the Description is rolled back to its last successfully deployed spec, until the feeds change. 0 disables rolling back")
+	flags.DurationVar(&opts.HelmDriftCheckInterval, "helm-drift-check-interval", opts.HelmDriftCheckInterval,
+		"How often the objects of HelmReleases in child clusters are compared with the rendered charts for drifts, "+
+			"which are remediated by the driftRemediation of HelmCharts. 0 disables checking drifts")
+	flags.IntVar(&opts.Threadiness, "threadiness", opts.Threadiness,
+		"The number of workers of each controller, such as the ones deploying Descriptions to child clusters")
+	flags.Float32Var(&opts.DeployerClusterQPS, "deployer-cluster-qps", opts.DeployerClusterQPS,
+		"How many Descriptions of a single child cluster could be deployed per second. Descriptions beyond it are "+
+			"deferred, so that a change to a popular Base does not overload the deployer. 0 means no limit")
+	flags.IntVar(&opts.DeployerClusterBurst, "deployer-cluster-burst", opts.DeployerClusterBurst,
+		"The burst of --deployer-cluster-qps")
+	flags.Float32Var(&opts.DeployerSubscriptionQPS, "deployer-subscription-qps", opts.DeployerSubscriptionQPS,
+		"How many Descriptions of a single Subscription could be deployed per second across child clusters. "+
+			"Descriptions beyond it are deferred. 0 means no limit")
+	flags.IntVar(&opts.DeployerSubscriptionBurst, "deployer-subscription-burst", opts.DeployerSubscriptionBurst,
+		"The burst of --deployer-subscription-qps")
+	flags.DurationVar(&opts.DeployerRetryBaseDelay, "deployer-retry-base-delay", opts.DeployerRetryBaseDelay,
+		"The initial delay of retrying a Description failed to be deployed, which doubles on every failure")
+	flags.DurationVar(&opts.DeployerRetryMaxDelay, "deployer-retry-max-delay", opts.DeployerRetryMaxDelay,
+		"The max delay of retrying a Description failed to be deployed")
+	flags.StringVar(&opts.ShardLeaseNamespace, "shard-lease-namespace", opts.ShardLeaseNamespace,
+		"The namespace of the Leases renewed by the replicas of clusternet-hub as their memberships, "+
+			"when feature gate DeployerSharding is enabled")
+	flags.DurationVar(&opts.ShardLeaseDuration, "shard-lease-duration", opts.ShardLeaseDuration,
+		"How long a replica of clusternet-hub is taken as alive after renewing its Lease. The child clusters of "+
+			"a replica failing to renew are taken over by other replicas after it")
+	flags.StringVar(&opts.ClusterSigningCertFile, "cluster-signing-cert-file", opts.ClusterSigningCertFile,
+		"Filename containing a PEM-encoded X509 CA certificate used to sign client certificates of child clusters, "+
+			"which should be trusted by the parent cluster, such as the cluster CA. "+
+			"Required when feature gate CertificateSigning is enabled")
+	flags.StringVar(&opts.ClusterSigningKeyFile, "cluster-signing-key-file", opts.ClusterSigningKeyFile,
+		"Filename containing a PEM-encoded RSA or ECDSA private key of --cluster-signing-cert-file. "+
+			"Required when feature gate CertificateSigning is enabled")
+	flags.DurationVar(&opts.ClusterSigningDuration, "cluster-signing-duration", opts.ClusterSigningDuration,
+		"How long the signed client certificates of child clusters are valid for. "+
+			"clusternet-agent rotates its certificate before it expires")
+	flags.StringVar(&opts.ClusterRegistrationPolicy, "cluster-registration-policy", opts.ClusterRegistrationPolicy,
+		"The path of a YAML file declaring the rules approving ClusterRegistrationRequests automatically, which match "+
+			"the labels, node CIDRs and bootstrap token groups of the requests. The requests matching no rules are held "+
+			"for manual approval. All the requests are approved automatically if not specified")
+	flags.StringVar(&opts.ClusterRegistrationWebhook, "cluster-registration-webhook", opts.ClusterRegistrationWebhook,
+		"The url where the ClusterRegistrationRequests approved by --cluster-registration-policy are posted to in JSON "+
+			"for review, such as checking the clusters against a CMDB. The webhook answers with allow, deny or hold")
+	flags.StringVar(&opts.ClusterRegistrationWebhookCAFile, "cluster-registration-webhook-ca-file", opts.ClusterRegistrationWebhookCAFile,
+		"The PEM-encoded CA verifying the serving certificate of --cluster-registration-webhook. "+
+			"The system roots are used if not specified")
+	flags.DurationVar(&opts.RegistrationRenewalMinInterval, "registration-renewal-min-interval", opts.RegistrationRenewalMinInterval,
+		"The min interval between two renewals of the dedicated token requested by the same child cluster. "+
+			"Renewals requested more often are approved once the interval passes")
+	flags.BoolVar(&opts.Simulation, "simulation", opts.Simulation,
+		"Run the controllers in observe-only mode. All the writes, such as approvals, renderings and dispatching, "+
+			"are sent as server-side dry-run requests, which are logged and exposed as metrics "+
+			"clusternet_hub_simulation_writes_total, while nothing gets persisted")
+
+	componentbaseoptions.BindLeaderElectionFlags(&opts.LeaderElection, flags)
 
 	version.AddVersionFlag(flags)
 	opts.AddFlags(flags)
 	utilfeature.DefaultMutableFeatureGate.AddFlag(flags)
 
+	cmd.AddCommand(newCheckCmd(ctx, opts, flags))
+	cmd.AddCommand(newTokenCmd(ctx))
+	return cmd
+}
+
+// newCheckCmd creates a command that validates the environment prerequisites of clusternet-hub,
+// sharing the same flags with clusternet-hub.
+func newCheckCmd(ctx context.Context, opts *options.HubServerOptions, hubFlags *pflag.FlagSet) *cobra.Command {
+	serviceAccount := "clusternet-system/clusternet-hub"
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Validate the environment prerequisites of clusternet-hub",
+		Long: `Validate APIService registration, webhook reachability, RBAC of the service account of clusternet-hub,
+feature gate consistency and installed CRDs, with the same flags as clusternet-hub.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := opts.Validate(args); err != nil {
+				klog.Exit(err)
+			}
+
+			config, err := utils.LoadsKubeConfig(opts.RecommendedOptions.CoreAPI.CoreAPIKubeconfigPath, 10)
+			if err != nil {
+				klog.Exit(err)
+			}
+			checker, err := selfcheck.NewChecker(config, opts, serviceAccount)
+			if err != nil {
+				klog.Exit(err)
+			}
+			if err = selfcheck.PrintFindings(os.Stdout, checker.Run(ctx)); err != nil {
+				klog.Exit(err)
+			}
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.AddFlagSet(hubFlags)
+	flags.StringVar(&serviceAccount, "service-account", serviceAccount,
+		"The service account that clusternet-hub runs as, in the format of <namespace>/<name>")
+	return cmd
+}
+
+// newTokenCmd creates a command that manages the bootstrap tokens used by clusternet-agent for cluster registration.
+func newTokenCmd(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage bootstrap tokens for cluster registration",
+	}
+	cmd.AddCommand(newTokenCreateCmd(ctx))
+	return cmd
+}
+
+// newTokenCreateCmd creates a command that creates a short-lived bootstrap token, which is only allowed
+// to create ClusterRegistrationRequests. The token is no longer needed once a child cluster gets registered.
+func newTokenCreateCmd(ctx context.Context) *cobra.Command {
+	var kubeconfig, description string
+	ttl := 24 * time.Hour
+
+	cmd := &cobra.Command{
+		Use:   "create [token]",
+		Short: "Create a bootstrap token for clusternet-agent to register child clusters",
+		Long: `Create a bootstrap token for clusternet-agent to register child clusters. A random token is generated
+if not specified. The token expires after --ttl, and gets deleted by the token cleaner of kube-controller-manager.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var token string
+			var err error
+			if len(args) > 0 {
+				token = args[0]
+			} else if token, err = utils.GenerateBootstrapToken(); err != nil {
+				klog.Exit(err)
+			}
+
+			secret, err := utils.NewBootstrapTokenSecret(token, ttl, description)
+			if err != nil {
+				klog.Exit(err)
+			}
+			config, err := utils.LoadsKubeConfig(kubeconfig, 1)
+			if err != nil {
+				klog.Exit(err)
+			}
+			client := kubernetes.NewForConfigOrDie(config)
+			if _, err = client.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+				klog.Exit(err)
+			}
+			fmt.Fprintln(os.Stdout, token)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&kubeconfig, "kubeconfig", kubeconfig,
+		"Path to the kubeconfig of parent cluster. Use in-cluster config if not specified")
+	flags.DurationVar(&ttl, "ttl", ttl,
+		"The duration before the token gets deleted automatically. 0 means the token never expires")
+	flags.StringVar(&description, "description", "The bootstrap token used by clusternet cluster registration.",
+		"The human readable description of the token")
 	return cmd
 }
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package app
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/importer"
+	"github.com/clusternet/clusternet/pkg/version"
+)
+
+var (
+	// the command name
+	cmdName = "clusternet-import"
+)
+
+// NewClusternetImportCmd creates a *cobra.Command object with default parameters
+func NewClusternetImportCmd(ctx context.Context) *cobra.Command {
+	opts := importer.NewImportOptions()
+
+	cmd := &cobra.Command{
+		Use: cmdName,
+		Long: `Import live objects from an existing cluster into Clusternet as shadow resources,
+and generate a Subscription distributing them to chosen child clusters`,
+		Example: `  # import all deployments and services labeled with app=nginx in namespace demo
+  clusternet-import --source-kubeconfig=source.config --parent-kubeconfig=parent.config \
+    -n demo -l app=nginx --resources=deployments,services \
+    --subscription-name=nginx --cluster-selector=clusters.clusternet.io/cluster-name=cluster-a`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := version.PrintAndExitIfRequested(cmdName); err != nil {
+				klog.Exit(err)
+			}
+
+			if err := utilerrors.NewAggregate(opts.Validate()); err != nil {
+				klog.Exit(err)
+			}
+
+			cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+				klog.V(1).Infof("FLAG: --%s=%q", flag.Name, flag.Value)
+			})
+
+			im, err := importer.NewImporter(opts, os.Stdout)
+			if err != nil {
+				klog.Exit(err)
+			}
+			if err := im.Run(ctx); err != nil {
+				klog.Exit(err)
+			}
+		},
+	}
+
+	version.AddVersionFlag(cmd.Flags())
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
@@ -20,25 +20,27 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/leaderelection"
-	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
 	"github.com/clusternet/clusternet/pkg/controllers/proxies/sockets"
 	"github.com/clusternet/clusternet/pkg/features"
 	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	"github.com/clusternet/clusternet/pkg/interpreter"
 	"github.com/clusternet/clusternet/pkg/known"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
@@ -65,6 +67,8 @@ type Agent struct {
 	parentDedicatedKubeConfig *rest.Config
 	// secret that stores credentials from parent cluster
 	secretFromParentCluster *corev1.Secret
+	// local file of the dedicated token, which gets reloaded by the clients once it is renewed
+	tokenFile string
 
 	// report cluster status
 	statusManager *Manager
@@ -90,55 +94,88 @@ func NewAgent(ctx context.Context, childKubeConfigFile string, regOpts *ClusterR
 	// create clientset for child cluster
 	childKubeClientSet := kubernetes.NewForConfigOrDie(childKubeConfig)
 
+	statusManager, err := NewStatusManager(ctx, childKubeConfig.Host, regOpts.ParentURL, childKubeClientSet,
+		regOpts.ClusterStatusCollectFrequency, regOpts.ClusterStatusReportFrequency, regOpts.ClusterStatusCollectors,
+		regOpts.FeedbackQueueSize, clusterapi.PodSecurityLevel(regOpts.PodSecurityLevel), regOpts.ClusterLeaseDuration,
+		regOpts.ClusterMetadataFile, regOpts.ClusterLabelAllowlist)
+	if err != nil {
+		return nil, err
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.DriftReport) {
+		statusManager.driftScanner, err = NewDriftScanner(childKubeConfig, childKubeClientSet, regOpts.DriftScanFrequency.Duration)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(regOpts.ResourceInterpreterWebhooks) > 0 {
+		if err = interpreter.LoadWebhooks(regOpts.ResourceInterpreterWebhooks); err != nil {
+			return nil, err
+		}
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.ResourceFeedback) {
+		statusManager.resourceReporter, err = NewResourceReporter(childKubeConfig, childKubeClientSet,
+			regOpts.ResourceFeedbackFrequency.Duration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	agent := &Agent{
 		AgentContext:       ctx,
 		Identity:           identity,
 		childKubeClientSet: childKubeClientSet,
 		Options:            regOpts,
-		statusManager:      NewStatusManager(ctx, childKubeConfig.Host, regOpts.ParentURL, childKubeClientSet, regOpts.ClusterStatusCollectFrequency, regOpts.ClusterStatusReportFrequency),
+		statusManager:      statusManager,
 		deployer:           NewDeployer(regOpts.ClusterSyncMode, childKubeConfig.Host, childKubeClientSet),
 	}
 	return agent, nil
 }
 
-func (agent *Agent) Run() {
+func (agent *Agent) Run() error {
 	klog.Info("starting agent controller ...")
 
 	// start the leader election code loop
-	leaderelection.RunOrDie(agent.AgentContext, *newLeaderElectionConfigWithDefaultValue(agent.Identity, agent.childKubeClientSet,
-		leaderelection.LeaderCallbacks{
-			OnStartedLeading: func(ctx context.Context) {
-				// we're notified when we start - this is where you would
-				// usually put your code
-				agent.registerSelfCluster(ctx)
-
-				// setup websocket connection
-				if utilfeature.DefaultFeatureGate.Enabled(features.SocketConnection) {
-					klog.Infof("featuregate %s is enabled, preparing setting up socket connection...", features.SocketConnection)
-					socketConn, err := sockets.NewController(agent.parentDedicatedKubeConfig, agent.Options.TunnelLogging)
-					if err != nil {
-						klog.Exitf("failed to setup websocket connection: %v", err)
+	return utils.RunWithLeaderElection(agent.AgentContext, agent.childKubeClientSet, agent.Options.LeaderElection,
+		agent.Identity, agent.run)
+}
 
-					}
-					go socketConn.Run(ctx, agent.ClusterID)
-				}
+// run runs the agent as the leader, and blocks until the context is done.
+func (agent *Agent) run() {
+	ctx := agent.AgentContext
+	agent.registerSelfCluster(ctx)
 
-				go agent.statusManager.Run(ctx, agent.parentDedicatedKubeConfig, agent.secretFromParentCluster)
-				go agent.deployer.Run(ctx, agent.parentDedicatedKubeConfig, agent.secretFromParentCluster, agent.ClusterID)
-			},
-			OnStoppedLeading: func() {
-				klog.Error("leader election got lost")
-			},
-			OnNewLeader: func(identity string) {
-				// we're notified when new leader elected
-				if identity == agent.Identity {
-					// I just got the lock
-					return
-				}
-				klog.Infof("new leader elected: %s", identity)
-			},
-		},
-	))
+	if utilfeature.DefaultFeatureGate.Enabled(features.CertificateSigning) {
+		klog.Infof("featuregate %s is enabled, switching to client certificate...", features.CertificateSigning)
+		agent.useClientCertificate(ctx)
+	}
+
+	var wg wait.Group
+	wg.Start(func() {
+		agent.renewCredentials(ctx)
+	})
+
+	// setup websocket connection
+	if utilfeature.DefaultFeatureGate.Enabled(features.SocketConnection) {
+		klog.Infof("featuregate %s is enabled, preparing setting up socket connection...", features.SocketConnection)
+		socketConn, err := sockets.NewController(agent.parentDedicatedKubeConfig, agent.Options.TunnelLogging,
+			agent.Options.TunnelConnections, agent.Options.TunnelTransport,
+			agent.Options.TunnelGRPCAddress, agent.Options.TunnelGRPCCAFile)
+		if err != nil {
+			klog.Exitf("failed to setup websocket connection: %v", err)
+
+		}
+		wg.Start(func() {
+			socketConn.Run(ctx, agent.ClusterID)
+		})
+	}
+
+	wg.Start(func() {
+		agent.statusManager.Run(ctx, agent.parentDedicatedKubeConfig, agent.secretFromParentCluster)
+	})
+	wg.Start(func() {
+		agent.deployer.Run(ctx, agent.parentDedicatedKubeConfig, agent.secretFromParentCluster, agent.ClusterID)
+	})
+	wg.Wait()
 }
 
 // registerSelfCluster begins registering. It starts registering and blocked until the context is done.
@@ -147,6 +184,9 @@ func (agent *Agent) registerSelfCluster(ctx context.Context) {
 	klog.Info("start registering current cluster as a child cluster...")
 
 	tryToUseSecret := true
+	// the client certificate stored before is tried when the dedicated token gets revoked,
+	// so that the bootstrap token, which may have expired, won't be needed
+	tryToUseCertificate := false
 
 	registerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -179,11 +219,17 @@ func (agent *Agent) registerSelfCluster(ctx context.Context) {
 					klog.Warningf("the parent url got changed from %q to %q", secret.Data[known.ClusterAPIServerURLKey], agent.Options.ParentURL)
 					klog.Warningf("will try to re-register current cluster")
 				} else {
-					parentDedicatedKubeConfig, err := utils.GenerateKubeConfigFromToken(agent.Options.ParentURL,
-						string(secret.Data[corev1.ServiceAccountTokenKey]), secret.Data[corev1.ServiceAccountRootCAKey], 2)
+					parentDedicatedKubeConfig, err := agent.kubeConfigFromToken(secret.Data[corev1.ServiceAccountTokenKey],
+						secret.Data[corev1.ServiceAccountRootCAKey])
 					if err == nil {
 						agent.parentDedicatedKubeConfig = parentDedicatedKubeConfig
 					}
+					if err == nil && tryToUseCertificate {
+						agent.parentDedicatedKubeConfig, err = agent.kubeConfigFromStoredCertificate(registerCtx, parentDedicatedKubeConfig)
+						if err != nil {
+							klog.Warningf("unable to use stored client certificate: %v", err)
+						}
+					}
 				}
 			}
 		}
@@ -191,6 +237,13 @@ func (agent *Agent) registerSelfCluster(ctx context.Context) {
 		// bootstrap cluster registration
 		if err := agent.bootstrapClusterRegistrationIfNeeded(registerCtx); err != nil {
 			klog.Error(err)
+			if tryToUseSecret && !tryToUseCertificate && agent.parentDedicatedKubeConfig != nil &&
+				utilfeature.DefaultFeatureGate.Enabled(features.CertificateSigning) {
+				klog.Warning("something went wrong when using existing parent cluster credentials, switch to use stored client certificate instead")
+				tryToUseCertificate = true
+				agent.parentDedicatedKubeConfig = nil
+				return
+			}
 			klog.Warning("something went wrong when using existing parent cluster credentials, switch to use bootstrap token instead")
 			tryToUseSecret = false
 			agent.parentDedicatedKubeConfig = nil
@@ -203,9 +256,10 @@ func (agent *Agent) registerSelfCluster(ctx context.Context) {
 }
 
 func (agent *Agent) getClusterID(ctx context.Context, childClientSet kubernetes.Interface) (types.UID, error) {
-	lease, err := childClientSet.CoordinationV1().Leases(ClusternetSystemNamespace).Get(ctx, SelfClusterLeaseName, metav1.GetOptions{})
+	namespace, name := agent.Options.LeaderElection.ResourceNamespace, agent.Options.LeaderElection.ResourceName
+	lease, err := childClientSet.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
-		klog.Errorf("unable to retrieve %s/%s Lease object: %v", ClusternetSystemNamespace, SelfClusterLeaseName, err)
+		klog.Errorf("unable to retrieve %s/%s Lease object: %v", namespace, name, err)
 		return "", err
 	}
 	return lease.UID, nil
@@ -214,17 +268,18 @@ func (agent *Agent) getClusterID(ctx context.Context, childClientSet kubernetes.
 func (agent *Agent) bootstrapClusterRegistrationIfNeeded(ctx context.Context) error {
 	klog.Infof("try to bootstrap cluster registration if needed")
 
+	useBootstrapToken := agent.parentDedicatedKubeConfig == nil
 	clientConfig, err := agent.getBootstrapKubeConfigForParentCluster()
 	if err != nil {
 		return err
 	}
 	// create ClusterRegistrationRequest
 	client := clusternetClientSet.NewForConfigOrDie(clientConfig)
-	crr, err := client.ClustersV1beta1().ClusterRegistrationRequests().Create(ctx,
-		newClusterRegistrationRequest(*agent.ClusterID, agent.Options.ClusterType,
-			generateClusterName(agent.Options.ClusterName, agent.Options.ClusterNamePrefix),
-			agent.Options.ClusterSyncMode),
-		metav1.CreateOptions{})
+	crr := newClusterRegistrationRequest(*agent.ClusterID, agent.Options.ClusterType,
+		generateClusterName(agent.Options.ClusterName, agent.Options.ClusterNamePrefix),
+		agent.Options.ClusterSyncMode)
+	agent.describeClusterRegistrationRequest(ctx, crr, useBootstrapToken)
+	crr, err = client.ClustersV1beta1().ClusterRegistrationRequests().Create(ctx, crr, metav1.CreateOptions{})
 
 	if err != nil {
 		if !apierrors.IsAlreadyExists(err) {
@@ -267,6 +322,8 @@ func (agent *Agent) getBootstrapKubeConfigForParentCluster() (*rest.Config, erro
 func (agent *Agent) waitingForApproval(ctx context.Context, client clusternetClientSet.Interface) error {
 	var crr *clusterapi.ClusterRegistrationRequest
 	var err error
+	var deleted bool
+	var lastMessage string
 
 	// wait until stopCh is closed or request is approved
 	waitingCtx, cancel := context.WithCancel(ctx)
@@ -275,6 +332,12 @@ func (agent *Agent) waitingForApproval(ctx context.Context, client clusternetCli
 		crrName := generateClusterRegistrationRequestName(*agent.ClusterID)
 		crr, err = client.ClustersV1beta1().ClusterRegistrationRequests().Get(waitingCtx, crrName, metav1.GetOptions{})
 		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// the request may get deleted after denied, which is requested again
+				deleted = true
+				cancel()
+				return
+			}
 			klog.Errorf("failed to get ClusterRegistrationRequest %s: %v", crrName, err)
 			return
 		}
@@ -283,19 +346,31 @@ func (agent *Agent) waitingForApproval(ctx context.Context, client clusternetCli
 			klog.V(5).Infof("found existing cluster name %q, reuse it", clusterName)
 		}
 
-		if crr.Status.Result != nil && *crr.Status.Result == clusterapi.RequestApproved {
+		if crr.Status.Result != nil && *crr.Status.Result == clusterapi.RequestApproved && len(crr.Status.DedicatedToken) > 0 {
 			klog.Infof("the registration request for cluster %q gets approved", *agent.ClusterID)
 			// cancel on success
 			cancel()
 			return
 		}
 
+		if crr.Status.Result != nil && *crr.Status.Result != clusterapi.RequestApproved {
+			if crr.Status.ErrorMessage != lastMessage {
+				klog.Warningf("the registration request for cluster %q is %s: %s. Delete ClusterRegistrationRequest %s "+
+					"in parent cluster to request again", *agent.ClusterID, *crr.Status.Result, crr.Status.ErrorMessage, crrName)
+			}
+		} else if len(crr.Status.ErrorMessage) > 0 && crr.Status.ErrorMessage != lastMessage {
+			klog.Infof("the registration request for cluster %q is held: %s", *agent.ClusterID, crr.Status.ErrorMessage)
+		}
+		lastMessage = crr.Status.ErrorMessage
+
 		klog.V(4).Infof("the registration request for cluster %q (%q) is still waiting for approval...",
 			*agent.ClusterID, agent.Options.ClusterName)
 	}, DefaultRetryPeriod, 0.4, true, waitingCtx.Done())
+	if deleted {
+		return fmt.Errorf("ClusterRegistrationRequest for cluster %q has been deleted, will request again", *agent.ClusterID)
+	}
 
-	parentDedicatedKubeConfig, err := utils.GenerateKubeConfigFromToken(agent.Options.ParentURL,
-		string(crr.Status.DedicatedToken), crr.Status.CACertificate, 2)
+	parentDedicatedKubeConfig, err := agent.kubeConfigFromToken(crr.Status.DedicatedToken, crr.Status.CACertificate)
 	if err != nil {
 		return err
 	}
@@ -316,6 +391,9 @@ func (agent *Agent) storeParentClusterCredentials(ctx context.Context, crr *clus
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: ParentClusterSecretName,
+			Annotations: map[string]string{
+				known.CredentialsIssuedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
 			Labels: map[string]string{
 				known.ClusterBootstrappingLabel: known.CredentialsAuto,
 				known.ClusterIDLabel:            string(*agent.ClusterID),
@@ -350,32 +428,6 @@ func (agent *Agent) storeParentClusterCredentials(ctx context.Context, crr *clus
 	}, DefaultRetryPeriod, 0.4, true, secretCtx.Done())
 }
 
-func newLeaderElectionConfigWithDefaultValue(identity string, clientset kubernetes.Interface, callbacks leaderelection.LeaderCallbacks) *leaderelection.LeaderElectionConfig {
-	return &leaderelection.LeaderElectionConfig{
-		Lock: &resourcelock.LeaseLock{
-			LeaseMeta: metav1.ObjectMeta{
-				Name:      SelfClusterLeaseName,
-				Namespace: ClusternetSystemNamespace,
-			},
-			Client: clientset.CoordinationV1(),
-			LockConfig: resourcelock.ResourceLockConfig{
-				Identity: identity,
-			},
-		},
-		// IMPORTANT: you MUST ensure that any code you have that
-		// is protected by the lease must terminate **before**
-		// you call cancel. Otherwise, you could have a background
-		// loop still running and another process could
-		// get elected before your background loop finished, violating
-		// the stated goal of the lease.
-		ReleaseOnCancel: true,
-		LeaseDuration:   DefaultLeaseDuration,
-		RenewDeadline:   DefaultRenewDeadline,
-		RetryPeriod:     DefaultRetryPeriod,
-		Callbacks:       callbacks,
-	}
-}
-
 func newClusterRegistrationRequest(clusterID types.UID, clusterType, clusterName, clusterSyncMode string) *clusterapi.ClusterRegistrationRequest {
 	return &clusterapi.ClusterRegistrationRequest{
 		ObjectMeta: metav1.ObjectMeta{
@@ -395,6 +447,54 @@ func newClusterRegistrationRequest(clusterID types.UID, clusterType, clusterName
 	}
 }
 
+// describeClusterRegistrationRequest attaches the attributes of current cluster matched by the approval policies of
+// parent cluster, which are the labels of ManagedCluster, the internal IPs of nodes, and the signature of the
+// bootstrap token if used.
+func (agent *Agent) describeClusterRegistrationRequest(ctx context.Context, crr *clusterapi.ClusterRegistrationRequest,
+	useBootstrapToken bool) {
+	metadata, err := agent.statusManager.getPropagatedMetadata(ctx)
+	if err != nil {
+		klog.Warningf("failed to get labels of current cluster for registration: %v", err)
+	} else {
+		for key, value := range metadata.Labels {
+			if _, ok := crr.Labels[key]; !ok {
+				crr.Labels[key] = value
+			}
+		}
+	}
+
+	crr.Annotations = map[string]string{}
+	nodes, err := agent.childKubeClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		klog.Warningf("failed to list nodes for registration: %v", err)
+	} else {
+		addresses := sets.NewString()
+		for _, node := range nodes.Items {
+			for _, address := range node.Status.Addresses {
+				if address.Type == corev1.NodeInternalIP {
+					addresses.Insert(address.Address)
+				}
+			}
+		}
+		if addresses.Len() > 0 {
+			list := addresses.List()
+			if len(list) > maxRegistrationAddresses {
+				list = list[:maxRegistrationAddresses]
+			}
+			crr.Annotations[known.RegistrationAddressesAnnotation] = strings.Join(list, ",")
+		}
+	}
+
+	if useBootstrapToken {
+		signature, err := utils.SignWithBootstrapToken(agent.Options.BootstrapToken, string(*agent.ClusterID))
+		if err != nil {
+			klog.Warningf("failed to sign registration request with bootstrap token: %v", err)
+		} else {
+			crr.Annotations[known.BootstrapTokenSignatureAnnotation] = signature
+		}
+	}
+}
+
 func generateClusterRegistrationRequestName(clusterID types.UID) string {
 	return fmt.Sprintf("%s%s", known.NamePrefixForClusternetObjects, string(clusterID))
 }
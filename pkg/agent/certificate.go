@@ -0,0 +1,282 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/certificate/csr"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// certificateWaitTimeout is how long to wait for a CertificateSigningRequest to be issued
+	certificateWaitTimeout = 15 * time.Minute
+)
+
+// useClientCertificate switches the credentials of accessing parent cluster from the dedicated token to
+// a client certificate, which is requested with a CertificateSigningRequest and stored in Secret
+// "parent-cluster-certificate" for later use. It blocks until a valid certificate is got, and then rotates
+// the certificate in the background before it expires.
+func (agent *Agent) useClientCertificate(ctx context.Context) {
+	certDir, err := ioutil.TempDir("", "clusternet-pki")
+	if err != nil {
+		klog.Exitf("failed to create directory for client certificates: %v", err)
+	}
+	certFile := filepath.Join(certDir, corev1.TLSCertKey)
+	keyFile := filepath.Join(certDir, corev1.TLSPrivateKeyKey)
+
+	var cert *x509.Certificate
+	certCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wait.JitterUntil(func() {
+		certPEM, keyPEM, err := agent.loadClientCertificate(certCtx)
+		if err != nil {
+			klog.Errorf("failed to load client certificate: %v", err)
+			return
+		}
+		// a stored certificate is reused until it expires, which gets rotated with itself at once if needed
+		cert, err = parseClientCertificate(certPEM, keyPEM)
+		if err != nil || !time.Now().Before(cert.NotAfter) {
+			klog.Infof("requesting a new client certificate from parent cluster")
+			certPEM, keyPEM, err = agent.requestClientCertificate(certCtx, agent.parentDedicatedKubeConfig)
+			if err != nil {
+				klog.Errorf("failed to request client certificate: %v", err)
+				return
+			}
+			if cert, err = parseClientCertificate(certPEM, keyPEM); err != nil {
+				klog.Errorf("got an invalid client certificate: %v", err)
+				return
+			}
+		}
+		if err = agent.storeClientCertificate(certCtx, certFile, keyFile, certPEM, keyPEM); err != nil {
+			klog.Errorf("failed to store client certificate: %v", err)
+			return
+		}
+		cancel()
+	}, DefaultRetryPeriod, 0.4, true, certCtx.Done())
+	if ctx.Err() != nil {
+		return
+	}
+
+	tokenKubeConfig := agent.parentDedicatedKubeConfig
+	parentDedicatedKubeConfig, err := utils.GenerateKubeConfigFromClientCertificate(agent.Options.ParentURL,
+		certFile, keyFile, agent.secretFromParentCluster.Data[corev1.ServiceAccountRootCAKey], 2)
+	if err != nil {
+		klog.Exitf("failed to create kubeconfig with client certificate: %v", err)
+	}
+	agent.parentDedicatedKubeConfig = parentDedicatedKubeConfig
+	klog.Infof("switched to client certificate %q to access parent cluster, which expires at %s",
+		cert.Subject.CommonName, cert.NotAfter)
+
+	go agent.rotateClientCertificate(ctx, cert, certFile, keyFile, tokenKubeConfig)
+}
+
+// rotateClientCertificate requests a new client certificate with the current one, when 70%~90% of its lifetime passes.
+// client-go reloads the certificate files once they get changed. The dedicated token is only used when the current
+// certificate has expired.
+func (agent *Agent) rotateClientCertificate(ctx context.Context, cert *x509.Certificate, certFile, keyFile string,
+	tokenKubeConfig *rest.Config) {
+	for {
+		deadline := nextRotationDeadline(cert)
+		klog.V(4).Infof("client certificate will be rotated at %s", deadline)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(deadline)):
+		}
+
+		rotateCtx, cancel := context.WithCancel(ctx)
+		wait.JitterUntil(func() {
+			config := agent.parentDedicatedKubeConfig
+			if time.Now().After(cert.NotAfter) {
+				config = tokenKubeConfig
+			}
+			certPEM, keyPEM, err := agent.requestClientCertificate(rotateCtx, config)
+			if err != nil {
+				klog.Errorf("failed to rotate client certificate: %v", err)
+				return
+			}
+			newCert, err := parseClientCertificate(certPEM, keyPEM)
+			if err != nil {
+				klog.Errorf("got an invalid client certificate: %v", err)
+				return
+			}
+			if err = agent.storeClientCertificate(rotateCtx, certFile, keyFile, certPEM, keyPEM); err != nil {
+				klog.Errorf("failed to store client certificate: %v", err)
+				return
+			}
+			klog.Infof("client certificate gets rotated, which expires at %s", newCert.NotAfter)
+			cert = newCert
+			cancel()
+		}, DefaultRetryPeriod, 0.4, true, rotateCtx.Done())
+		cancel()
+	}
+}
+
+// kubeConfigFromStoredCertificate returns a copy of the given kubeconfig, which authenticates with the client
+// certificate stored before instead. An error is returned if the certificate is not found or has expired.
+func (agent *Agent) kubeConfigFromStoredCertificate(ctx context.Context, config *rest.Config) (*rest.Config, error) {
+	certPEM, keyPEM, err := agent.loadClientCertificate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseClientCertificate(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(cert.NotAfter) {
+		return nil, fmt.Errorf("client certificate %q has expired at %s", cert.Subject.CommonName, cert.NotAfter)
+	}
+
+	certConfig := rest.AnonymousClientConfig(config)
+	certConfig.CertData = certPEM
+	certConfig.KeyData = keyPEM
+	return certConfig, nil
+}
+
+// requestClientCertificate generates a new private key, and requests a client certificate for current cluster
+// with a CertificateSigningRequest, which gets approved and signed by parent cluster.
+func (agent *Agent) requestClientCertificate(ctx context.Context, config *rest.Config) ([]byte, []byte, error) {
+	keyPEM, err := keyutil.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	csrPEM, err := certutil.MakeCSR(privateKey, &pkix.Name{
+		CommonName:   known.ClusterAgentUserPrefix + string(*agent.ClusterID),
+		Organization: []string{known.ClusterAgentGroup},
+	}, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client := kubernetes.NewForConfigOrDie(config)
+	name := fmt.Sprintf("%s%s-%s", known.NamePrefixForClusternetObjects, *agent.ClusterID, utilrand.String(DefaultRandomUIDLength))
+	reqName, reqUID, err := csr.RequestCertificate(client, csrPEM, name, known.ClusterAgentSignerName,
+		[]certificatesv1.KeyUsage{
+			certificatesv1.UsageDigitalSignature,
+			certificatesv1.UsageKeyEncipherment,
+			certificatesv1.UsageClientAuth,
+		}, privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	klog.V(4).Infof("created CertificateSigningRequest %s, waiting for the certificate to be issued", reqName)
+
+	waitCtx, cancel := context.WithTimeout(ctx, certificateWaitTimeout)
+	defer cancel()
+	certPEM, err := csr.WaitForCertificate(waitCtx, client, reqName, reqUID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, keyPEM, nil
+}
+
+// loadClientCertificate loads the client certificate stored before. Empty values are returned if not found.
+func (agent *Agent) loadClientCertificate(ctx context.Context) ([]byte, []byte, error) {
+	secret, err := agent.childKubeClientSet.CoreV1().Secrets(ClusternetSystemNamespace).Get(ctx,
+		ParentClusterCertificateSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret.Labels[known.ClusterIDLabel] != string(*agent.ClusterID) ||
+		string(secret.Data[known.ClusterAPIServerURLKey]) != agent.Options.ParentURL {
+		// the certificate is issued for another cluster or by another parent cluster
+		return nil, nil, nil
+	}
+	return secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], nil
+}
+
+// storeClientCertificate writes the client certificate to local files used by the clients,
+// as well as Secret "parent-cluster-certificate" in "clusternet-system" namespace.
+func (agent *Agent) storeClientCertificate(ctx context.Context, certFile, keyFile string, certPEM, keyPEM []byte) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ParentClusterCertificateSecretName,
+			Namespace: ClusternetSystemNamespace,
+			Labels: map[string]string{
+				known.ClusterBootstrappingLabel: known.CredentialsAuto,
+				known.ClusterIDLabel:            string(*agent.ClusterID),
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:            certPEM,
+			corev1.TLSPrivateKeyKey:      keyPEM,
+			known.ClusterAPIServerURLKey: []byte(agent.Options.ParentURL),
+		},
+	}
+	_, err := agent.childKubeClientSet.CoreV1().Secrets(ClusternetSystemNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = agent.childKubeClientSet.CoreV1().Secrets(ClusternetSystemNamespace).Update(ctx, secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return err
+	}
+
+	// the private key is written first, so that the pair gets reloaded once the certificate changes
+	if err = keyutil.WriteKey(keyFile, keyPEM); err != nil {
+		return err
+	}
+	return certutil.WriteCert(certFile, certPEM)
+}
+
+// parseClientCertificate verifies the key pair, and returns the parsed certificate.
+func parseClientCertificate(certPEM, keyPEM []byte) (*x509.Certificate, error) {
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, fmt.Errorf("no client certificate found")
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(pair.Certificate[0])
+}
+
+// nextRotationDeadline returns a random time between 70% and 90% of the certificate lifetime,
+// so that clusters won't rotate their certificates all at once.
+func nextRotationDeadline(cert *x509.Certificate) time.Time {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return cert.NotBefore.Add(wait.Jitter(time.Duration(float64(lifetime)*0.7), 2.0/7))
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+)
+
+func TestNextRotationDeadline(t *testing.T) {
+	notBefore := time.Now()
+	cert := &x509.Certificate{
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(100 * time.Hour),
+	}
+	for i := 0; i < 100; i++ {
+		deadline := nextRotationDeadline(cert)
+		if deadline.Before(notBefore.Add(70*time.Hour)) || deadline.After(notBefore.Add(90*time.Hour)) {
+			t.Fatalf("deadline %s is not within 70%%~90%% of the certificate lifetime", deadline.Sub(notBefore))
+		}
+	}
+}
+
+func TestParseClientCertificate(t *testing.T) {
+	keyPEM, err := keyutil.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "clusternet:cluster:abc"}, key.(crypto.Signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, err := certutil.EncodeCertificates(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parseClientCertificate(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Subject.CommonName != "clusternet:cluster:abc" {
+		t.Errorf("unexpected common name %s", got.Subject.CommonName)
+	}
+
+	otherKeyPEM, err := keyutil.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parseClientCertificate(certPEM, otherKeyPEM); err == nil {
+		t.Errorf("expected error for mismatched key pair")
+	}
+	if _, err = parseClientCertificate(nil, nil); err == nil {
+		t.Errorf("expected error for empty certificate")
+	}
+}
@@ -46,6 +46,48 @@ const (
 
 	// ClusterStatusCollectFrequency flag specifies the cluster status collecting frequency
 	ClusterStatusCollectFrequency = "cluster-status-collect-frequency"
+
+	// ClusterStatusCollectors flag specifies the collectors to enable for collecting cluster status
+	ClusterStatusCollectors = "cluster-status-collectors"
+
+	// FeedbackQueueSize flag specifies the max number of status updates buffered when parent cluster is unreachable
+	FeedbackQueueSize = "feedback-queue-size"
+
+	// PodSecurityLevel flag specifies the Pod Security admission level enforced in child cluster
+	PodSecurityLevel = "pod-security-level"
+
+	// ClusterLeaseDuration flag specifies the duration of the Lease renewed in parent cluster as heartbeats
+	ClusterLeaseDuration = "cluster-lease-duration"
+
+	// ClusterMetadataFile flag specifies the file of labels and taints propagated to ManagedCluster
+	ClusterMetadataFile = "cluster-metadata-file"
+
+	// ClusterLabelAllowlist flag specifies the node and namespace labels propagated to ManagedCluster
+	ClusterLabelAllowlist = "cluster-label-allowlist"
+
+	// DriftScanFrequency flag specifies how often the objects deployed by Descriptions are scanned for drifts
+	DriftScanFrequency = "drift-scan-frequency"
+
+	// ResourceFeedbackFrequency flag specifies how often the feedback of objects deployed by Descriptions is refreshed
+	ResourceFeedbackFrequency = "resource-feedback-frequency"
+
+	// ResourceInterpreterWebhooks flag specifies the file declaring the webhooks that interpret custom kinds
+	ResourceInterpreterWebhooks = "resource-interpreter-webhooks"
+
+	// TunnelConnections flag specifies the number of tunnels set up to parent cluster
+	TunnelConnections = "tunnel-connections"
+
+	// TunnelTransport flag specifies the transport of tunnels set up to parent cluster
+	TunnelTransport = "tunnel-transport"
+
+	// TunnelGRPCAddress flag specifies the address of gRPC tunnels served by parent cluster
+	TunnelGRPCAddress = "tunnel-grpc-address"
+
+	// TunnelGRPCCAFile flag specifies the CA verifying the serving certificate of gRPC tunnels
+	TunnelGRPCCAFile = "tunnel-grpc-ca-file"
+
+	// CredentialRenewalPeriod flag specifies how long the dedicated token is used before getting renewed
+	CredentialRenewalPeriod = "credential-renewal-period"
 )
 
 // default values
@@ -54,6 +96,12 @@ const (
 	ClusternetSystemNamespace = "clusternet-system"
 	ParentClusterSecretName   = "parent-cluster"
 
+	// ParentClusterCertificateSecretName is the name of Secret storing the client certificate signed by parent cluster
+	ParentClusterCertificateSecretName = "parent-cluster-certificate"
+
+	// FeedbackQueueConfigMapName is the name of ConfigMap persisting status updates that fail to be sent to parent cluster
+	FeedbackQueueConfigMapName = "clusternet-feedback-queue"
+
 	// ServiceAccountNameKey is the key of the required annotation for SecretTypeServiceAccountToken secrets
 	ServiceAccountNameKey = "service-account.name"
 	// ServiceAccountUIDKey is the key of the required annotation for SecretTypeServiceAccountToken secrets
@@ -73,6 +121,27 @@ const (
 
 	DefaultClusterStatusCollectFrequency = 20 * time.Second
 	DefaultClusterStatusReportFrequency  = 3 * time.Minute
+
+	// DefaultFeedbackQueueSize is the default max number of buffered status updates
+	DefaultFeedbackQueueSize = 50
+
+	// DefaultClusterLeaseDuration is the default duration of the heartbeat Lease, which is renewed every quarter of it
+	DefaultClusterLeaseDuration = 40 * time.Second
+
+	// DefaultDriftScanFrequency is the default frequency of scanning drifts
+	DefaultDriftScanFrequency = 5 * time.Minute
+
+	// DefaultResourceFeedbackFrequency is the default frequency of refreshing the feedback of Descriptions
+	DefaultResourceFeedbackFrequency = time.Minute
+
+	// DefaultTunnelConnections is the default number of tunnels set up to parent cluster
+	DefaultTunnelConnections = 1
+
+	// DefaultCredentialRenewalPeriod is the default period of renewing the dedicated token
+	DefaultCredentialRenewalPeriod = 30 * 24 * time.Hour
+
+	// maxRegistrationAddresses is the max number of node addresses reported in ClusterRegistrationRequests
+	maxRegistrationAddresses = 256
 )
 
 // lease lock
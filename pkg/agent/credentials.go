@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// registrationRenewalTimeout is how long to wait for a renewal of the dedicated token to be approved
+	registrationRenewalTimeout = 15 * time.Minute
+)
+
+// kubeConfigFromToken writes the dedicated token to a local file, and returns a kubeconfig reading the token
+// from this file, so that the clients pick up the renewed token without restarting.
+func (agent *Agent) kubeConfigFromToken(token, caCert []byte) (*rest.Config, error) {
+	if len(agent.tokenFile) == 0 {
+		tokenDir, err := ioutil.TempDir("", "clusternet-token")
+		if err != nil {
+			return nil, err
+		}
+		agent.tokenFile = filepath.Join(tokenDir, corev1.ServiceAccountTokenKey)
+	}
+
+	// write to a temporary file first, so that the token won't be read partially
+	tmpFile := agent.tokenFile + ".tmp"
+	if err := ioutil.WriteFile(tmpFile, token, 0600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpFile, agent.tokenFile); err != nil {
+		return nil, err
+	}
+	return utils.GenerateKubeConfigFromTokenFile(agent.Options.ParentURL, agent.tokenFile, caCert, 2)
+}
+
+// renewCredentials renews the dedicated token through a RenewRegistration flow before it expires,
+// or when it has been used for CredentialRenewalPeriod. It blocks until the context is done.
+func (agent *Agent) renewCredentials(ctx context.Context) {
+	for {
+		deadline, ok := nextCredentialsRenewal(agent.secretFromParentCluster, agent.Options.CredentialRenewalPeriod.Duration)
+		if !ok {
+			klog.V(4).Infof("the dedicated token never expires, and won't be renewed")
+			return
+		}
+		klog.V(4).Infof("the dedicated token will be renewed at %s", deadline)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(deadline)):
+		}
+
+		renewCtx, cancel := context.WithCancel(ctx)
+		wait.JitterUntil(func() {
+			if err := agent.renewRegistration(renewCtx); err != nil {
+				klog.Errorf("failed to renew the dedicated token: %v", err)
+				return
+			}
+			cancel()
+		}, DefaultRetryPeriod, 0.4, true, renewCtx.Done())
+		cancel()
+	}
+}
+
+// renewRegistration requests a new dedicated token by annotating the ClusterRegistrationRequest of current cluster,
+// and waits for parent cluster approving it. The new token is stored without re-registering current cluster.
+func (agent *Agent) renewRegistration(ctx context.Context) error {
+	client := clusternetClientSet.NewForConfigOrDie(agent.parentDedicatedKubeConfig)
+	crrName := generateClusterRegistrationRequestName(*agent.ClusterID)
+	requested := time.Now().UTC().Format(time.RFC3339)
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				known.RenewRegistrationAnnotation: requested,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.ClustersV1beta1().ClusterRegistrationRequests().Patch(ctx, crrName, types.MergePatchType,
+		patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to request renewing registration: %v", err)
+	}
+	klog.Infof("requested renewing the registration of cluster %q", *agent.ClusterID)
+
+	var crr *clusterapi.ClusterRegistrationRequest
+	waitCtx, cancel := context.WithTimeout(ctx, registrationRenewalTimeout)
+	defer cancel()
+	err = wait.PollImmediateUntil(DefaultRetryPeriod, func() (bool, error) {
+		crr, err = client.ClustersV1beta1().ClusterRegistrationRequests().Get(waitCtx, crrName, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("failed to get ClusterRegistrationRequest %s: %v", crrName, err)
+			return false, nil
+		}
+		return crr.Annotations[known.RegistrationRenewedAnnotation] == requested, nil
+	}, waitCtx.Done())
+	if err != nil {
+		return fmt.Errorf("the renewal requested at %s is not approved: %v", requested, err)
+	}
+	if crr.Status.Result == nil || *crr.Status.Result != clusterapi.RequestApproved || len(crr.Status.DedicatedToken) == 0 {
+		return fmt.Errorf("no dedicated token found in ClusterRegistrationRequest %s", crrName)
+	}
+
+	if _, err = agent.kubeConfigFromToken(crr.Status.DedicatedToken, crr.Status.CACertificate); err != nil {
+		return err
+	}
+	agent.storeParentClusterCredentials(ctx, crr)
+	klog.Infof("the dedicated token of cluster %q gets renewed", *agent.ClusterID)
+	return nil
+}
+
+// nextCredentialsRenewal returns when to renew the dedicated token stored in the secret, which is at 80% of its
+// lifetime if it carries an expiry, or after it has been used for the renewal period. False is returned if the
+// token never needs renewing.
+func nextCredentialsRenewal(secret *corev1.Secret, period time.Duration) (time.Time, bool) {
+	if secret == nil {
+		return time.Time{}, false
+	}
+	issuedAt := secret.CreationTimestamp.Time
+	if t, err := time.Parse(time.RFC3339, secret.Annotations[known.CredentialsIssuedAtAnnotation]); err == nil {
+		issuedAt = t
+	}
+
+	var deadline time.Time
+	if iat, exp, ok := parseTokenLifetime(secret.Data[corev1.ServiceAccountTokenKey]); ok {
+		if !iat.IsZero() {
+			issuedAt = iat
+		}
+		deadline = issuedAt.Add(time.Duration(float64(exp.Sub(issuedAt)) * 0.8))
+	}
+	if period > 0 {
+		if renewal := issuedAt.Add(period); deadline.IsZero() || renewal.Before(deadline) {
+			deadline = renewal
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+// parseTokenLifetime returns the issued time and the expiry of a JSON web token, without verifying it.
+// False is returned if the token doesn't expire.
+func parseTokenLifetime(token []byte) (time.Time, time.Time, bool) {
+	parts := strings.Split(string(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	claims := struct {
+		IssuedAt  int64 `json:"iat"`
+		ExpiresAt int64 `json:"exp"`
+	}{}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	var iat time.Time
+	if claims.IssuedAt > 0 {
+		iat = time.Unix(claims.IssuedAt, 0)
+	}
+	return iat, time.Unix(claims.ExpiresAt, 0), true
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func newJWT(claims string) []byte {
+	return []byte(fmt.Sprintf("%s.%s.signature",
+		base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(claims))))
+}
+
+func TestNextCredentialsRenewal(t *testing.T) {
+	issuedAt := time.Date(2021, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		secret   *corev1.Secret
+		period   time.Duration
+		want     time.Time
+		wantNone bool
+	}{
+		{
+			name:     "no secret",
+			period:   time.Hour,
+			wantNone: true,
+		},
+		{
+			name: "token never expires",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(issuedAt)},
+				Data:       map[string][]byte{corev1.ServiceAccountTokenKey: newJWT(`{"sub":"foo"}`)},
+			},
+			wantNone: true,
+		},
+		{
+			name: "renewal period since creation",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(issuedAt)},
+				Data:       map[string][]byte{corev1.ServiceAccountTokenKey: []byte("opaque-token")},
+			},
+			period: 24 * time.Hour,
+			want:   issuedAt.Add(24 * time.Hour),
+		},
+		{
+			name: "renewal period since last renewal",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(issuedAt),
+					Annotations: map[string]string{
+						known.CredentialsIssuedAtAnnotation: issuedAt.Add(time.Hour).Format(time.RFC3339),
+					},
+				},
+				Data: map[string][]byte{corev1.ServiceAccountTokenKey: []byte("opaque-token")},
+			},
+			period: 24 * time.Hour,
+			want:   issuedAt.Add(25 * time.Hour),
+		},
+		{
+			name: "token expires before renewal period",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{corev1.ServiceAccountTokenKey: newJWT(fmt.Sprintf(`{"iat":%d,"exp":%d}`,
+					issuedAt.Unix(), issuedAt.Add(10*time.Hour).Unix()))},
+			},
+			period: 24 * time.Hour,
+			want:   issuedAt.Add(8 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextCredentialsRenewal(tt.secret, tt.period)
+			if ok == tt.wantNone {
+				t.Fatalf("expected renewal %v, but got %v", !tt.wantNone, ok)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("expected renewal at %s, but got %s", tt.want, got)
+			}
+		})
+	}
+}
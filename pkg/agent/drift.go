@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// maxReportedDrifts is the max number of drifted objects reported in the status of ManagedCluster
+const maxReportedDrifts = 50
+
+// DriftScanner periodically compares the objects deployed by the Descriptions of current cluster with their
+// live state. The drifts are only reported, and never get reverted.
+type DriftScanner struct {
+	frequency time.Duration
+
+	dynamicClient dynamic.Interface
+	restMapper    *restmapper.DeferredDiscoveryRESTMapper
+
+	lock    sync.RWMutex
+	summary *clusterapi.DriftSummary
+}
+
+// NewDriftScanner returns a new DriftScanner.
+func NewDriftScanner(childKubeConfig *rest.Config, childKubeClientSet kubernetes.Interface, frequency time.Duration) (*DriftScanner, error) {
+	dynamicClient, err := dynamic.NewForConfig(childKubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &DriftScanner{
+		frequency:     frequency,
+		dynamicClient: dynamicClient,
+		restMapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(childKubeClientSet.Discovery())),
+	}, nil
+}
+
+// Run scans the Descriptions in the dedicated namespace periodically. onChange is called once the drifts change.
+func (s *DriftScanner) Run(ctx context.Context, client clusternetClientSet.Interface, namespace string, onChange func()) {
+	klog.Infof("scanning drifts of Descriptions in namespace %s every %s", namespace, s.frequency)
+
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		summary, err := s.scan(ctx, client, namespace)
+		if err != nil {
+			klog.Warningf("failed to scan drifts of Descriptions in namespace %s: %v", namespace, err)
+			return
+		}
+
+		s.lock.Lock()
+		changed := s.summary == nil || s.summary.DriftedObjects != summary.DriftedObjects ||
+			!reflect.DeepEqual(s.summary.Objects, summary.Objects)
+		s.summary = summary
+		s.lock.Unlock()
+
+		klog.V(5).Infof("found %d drifted objects out of %d", summary.DriftedObjects, summary.ScannedObjects)
+		if changed && onChange != nil {
+			onChange()
+		}
+	}, s.frequency)
+}
+
+// GetSummary returns the latest drift summary, which is nil before the first scan finishes.
+func (s *DriftScanner) GetSummary() *clusterapi.DriftSummary {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.summary.DeepCopy()
+}
+
+func (s *DriftScanner) scan(ctx context.Context, client clusternetClientSet.Interface, namespace string) (*clusterapi.DriftSummary, error) {
+	descs, err := client.AppsV1alpha1().Descriptions(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &clusterapi.DriftSummary{
+		LastScanTime: metav1.Now(),
+	}
+	for _, desc := range descs.Items {
+		// only the objects deployed successfully are scanned, and Helm releases are left to Helm
+		if desc.DeletionTimestamp != nil || desc.Spec.Deployer != appsapi.DescriptionGenericDeployer ||
+			desc.Status.Phase != appsapi.DescriptionPhaseSuccess || utils.IsJobsFinished(&desc) {
+			continue
+		}
+
+		for _, raw := range desc.Spec.Raw {
+			desired := &unstructured.Unstructured{}
+			if err := desired.UnmarshalJSON(raw); err != nil {
+				klog.Warningf("failed to unmarshal object in Description %s: %v", klog.KObj(&desc), err)
+				continue
+			}
+			summary.ScannedObjects++
+
+			drift, err := s.compare(ctx, desired)
+			if err != nil {
+				klog.Warningf("failed to compare %s %s in Description %s: %v", desired.GetKind(), klog.KObj(desired),
+					klog.KObj(&desc), err)
+				continue
+			}
+			if drift == nil {
+				continue
+			}
+			drift.Description = desc.Name
+			summary.DriftedObjects++
+			if len(summary.Objects) < maxReportedDrifts {
+				summary.Objects = append(summary.Objects, *drift)
+			}
+		}
+	}
+	return summary, nil
+}
+
+// compare returns the drift of the object, or nil if the live state matches the desired state.
+func (s *DriftScanner) compare(ctx context.Context, desired *unstructured.Unstructured) (*clusterapi.ObjectDrift, error) {
+	gvk := desired.GroupVersionKind()
+	restMapping, err := s.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		// the resource may be newly installed
+		s.restMapper.Reset()
+		return nil, err
+	}
+
+	drift := &clusterapi.ObjectDrift{
+		APIVersion: desired.GetAPIVersion(),
+		Kind:       desired.GetKind(),
+		Namespace:  desired.GetNamespace(),
+		Name:       desired.GetName(),
+	}
+	live, err := s.dynamicClient.Resource(restMapping.Resource).Namespace(desired.GetNamespace()).
+		Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		drift.Missing = true
+		return drift, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	drift.Fields = utils.FindDriftedFields(desired.Object, live.Object)
+	if len(drift.Fields) == 0 {
+		return nil, nil
+	}
+	return drift, nil
+}
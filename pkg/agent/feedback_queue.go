@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// the key in the ConfigMap holding the queued feedback
+	feedbackQueueDataKey = "queue"
+)
+
+// Feedback is a status update to an object in parent cluster.
+type Feedback struct {
+	// Kind is the kind of the object, such as ManagedCluster
+	Kind string `json:"kind"`
+	// Namespace is the namespace of the object
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Status is the serialized status of the object
+	Status json.RawMessage `json:"status"`
+	// Timestamp is the time when the feedback is generated
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// FeedbackQueue is a bounded FIFO queue buffering the feedback that fails to be sent to parent cluster,
+// such as during hub outages. Only the latest feedback of each object is kept, since a status update supersedes
+// the earlier ones, so that a single write per object is replayed once parent cluster gets reachable again.
+// The queue is persisted to a ConfigMap in child cluster, so that no feedback gets lost across agent restarts.
+// When the queue is full, the oldest feedback will be dropped.
+type FeedbackQueue struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+	maxSize    int
+
+	lock  sync.Mutex
+	items []Feedback
+}
+
+// NewFeedbackQueue creates a new FeedbackQueue, with feedback loaded from the ConfigMap if exists.
+func NewFeedbackQueue(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string, maxSize int) (*FeedbackQueue, error) {
+	q := &FeedbackQueue{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		maxSize:    maxSize,
+	}
+
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return q, nil
+		}
+		return nil, err
+	}
+	if data, ok := cm.Data[feedbackQueueDataKey]; ok && len(data) > 0 {
+		if err = json.Unmarshal([]byte(data), &q.items); err != nil {
+			klog.Warningf("failed to load feedback queue from ConfigMap %s/%s, will drop it: %v", namespace, name, err)
+			q.items = nil
+		}
+	}
+	klog.V(4).Infof("loaded %d feedback from ConfigMap %s/%s", len(q.items), namespace, name)
+	return q, nil
+}
+
+// Len returns the number of feedback in the queue.
+func (q *FeedbackQueue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items)
+}
+
+// Push appends the feedback to the queue and persists the queue.
+// Earlier feedback of the same object is replaced.
+func (q *FeedbackQueue) Push(ctx context.Context, feedback Feedback) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	items := q.items[:0]
+	for _, item := range q.items {
+		if item.Kind != feedback.Kind || item.Namespace != feedback.Namespace || item.Name != feedback.Name {
+			items = append(items, item)
+		}
+	}
+	q.items = append(items, feedback)
+	if len(q.items) > q.maxSize {
+		klog.Warningf("feedback queue is full, dropping %d oldest feedback", len(q.items)-q.maxSize)
+		q.items = q.items[len(q.items)-q.maxSize:]
+	}
+	return q.persist(ctx)
+}
+
+// Replay sends the queued feedback in order with sendFunc, until all of them get sent or the first failure.
+// Sent feedback will be removed from the queue.
+func (q *FeedbackQueue) Replay(ctx context.Context, sendFunc func(Feedback) error) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.items) == 0 {
+		return nil
+	}
+
+	var sent int
+	var sendErr error
+	for _, feedback := range q.items {
+		if sendErr = sendFunc(feedback); sendErr != nil {
+			break
+		}
+		sent++
+	}
+	if sent == 0 {
+		return sendErr
+	}
+
+	klog.V(4).Infof("replayed %d feedback to parent cluster", sent)
+	q.items = q.items[sent:]
+	if err := q.persist(ctx); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// persist saves the queue to the ConfigMap. It should be called with lock held.
+func (q *FeedbackQueue) persist(ctx context.Context) error {
+	data, err := json.Marshal(q.items)
+	if err != nil {
+		return err
+	}
+
+	cm, err := q.kubeClient.CoreV1().ConfigMaps(q.namespace).Get(ctx, q.name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		_, err = q.kubeClient.CoreV1().ConfigMaps(q.namespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      q.name,
+				Namespace: q.namespace,
+			},
+			Data: map[string]string{
+				feedbackQueueDataKey: string(data),
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[feedbackQueueDataKey] = string(data)
+	_, err = q.kubeClient.CoreV1().ConfigMaps(q.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
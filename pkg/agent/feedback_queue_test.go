@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFeedbackQueue(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+
+	queue, err := NewFeedbackQueue(ctx, client, ClusternetSystemNamespace, FeedbackQueueConfigMapName, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if err = queue.Push(ctx, Feedback{Kind: "ManagedCluster", Name: name, Status: []byte("{}")}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// reload from the ConfigMap, where the oldest one should have been dropped
+	queue, err = NewFeedbackQueue(ctx, client, ClusternetSystemNamespace, FeedbackQueueConfigMapName, 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queue.Len() != 3 {
+		t.Fatalf("expected 3 feedback, got %d", queue.Len())
+	}
+
+	// replay stops at the first failure
+	var replayed []string
+	err = queue.Replay(ctx, func(feedback Feedback) error {
+		if feedback.Name == "c" {
+			return errors.New("parent cluster is unreachable")
+		}
+		replayed = append(replayed, feedback.Name)
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected error on replaying")
+	}
+	if !reflect.DeepEqual(replayed, []string{"b"}) {
+		t.Errorf("expected replayed feedback [b], got %v", replayed)
+	}
+
+	replayed = nil
+	err = queue.Replay(ctx, func(feedback Feedback) error {
+		replayed = append(replayed, feedback.Name)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, []string{"c", "d"}) {
+		t.Errorf("expected replayed feedback [c d], got %v", replayed)
+	}
+	if queue.Len() != 0 {
+		t.Errorf("expected empty queue, got %d", queue.Len())
+	}
+}
+
+func TestFeedbackQueueKeepsLatestPerObject(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset()
+
+	queue, err := NewFeedbackQueue(ctx, client, ClusternetSystemNamespace, FeedbackQueueConfigMapName, 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// every failed report buffers a status snapshot of the same ManagedCluster
+	for _, status := range []string{`{"n":1}`, `{"n":2}`, `{"n":3}`} {
+		err = queue.Push(ctx, Feedback{Kind: "ManagedCluster", Namespace: "ns", Name: "mcls", Status: []byte(status)})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var replayed []string
+	err = queue.Replay(ctx, func(feedback Feedback) error {
+		replayed = append(replayed, string(feedback.Status))
+		return nil
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(replayed, []string{`{"n":3}`}) {
+		t.Errorf("expected a single write with the latest status, got %v", replayed)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// renewLease renews the Lease named with the cluster id in parent cluster every quarter of the lease duration.
+// Compared with posting the whole cluster status, renewing a Lease is much cheaper, which serves as heartbeats.
+func (mgr *Manager) renewLease(ctx context.Context, client kubernetes.Interface, namespace, clusterID string) {
+	klog.Infof("renewing Lease %s/%s as heartbeats every %s", namespace, clusterID, mgr.leaseDuration.Duration/4)
+
+	healthy := true
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := mgr.tryRenewLease(ctx, client, namespace, clusterID); err != nil {
+			klog.Warningf("failed to renew Lease %s/%s: %v", namespace, clusterID, err)
+			healthy = false
+			return
+		}
+		if !healthy {
+			// the status may be marked as Unknown by parent cluster during disconnection
+			klog.V(4).Infof("Lease %s/%s gets renewed again, refreshing cluster status", namespace, clusterID)
+			mgr.clusterStatusController.Refresh()
+			healthy = true
+		}
+	}, mgr.leaseDuration.Duration/4)
+}
+
+func (mgr *Manager) tryRenewLease(ctx context.Context, client kubernetes.Interface, namespace, clusterID string) error {
+	lease, err := client.CoordinationV1().Leases(namespace).Get(ctx, clusterID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.CoordinationV1().Leases(namespace).Create(ctx, mgr.newLease(namespace, clusterID), metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease = lease.DeepCopy()
+	lease.Spec = mgr.newLease(namespace, clusterID).Spec
+	_, err = client.CoordinationV1().Leases(namespace).Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+func (mgr *Manager) newLease(namespace, clusterID string) *coordinationv1.Lease {
+	holderIdentity := clusterID
+	leaseDurationSeconds := int32(mgr.leaseDuration.Seconds())
+	renewTime := metav1.NewMicroTime(time.Now())
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterID,
+			Namespace: namespace,
+			Labels: map[string]string{
+				known.ClusterIDLabel: clusterID,
+			},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holderIdentity,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			RenewTime:            &renewTime,
+		},
+	}
+}
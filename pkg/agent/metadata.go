@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// ClusterMetadata is the labels and taints propagated from child cluster to its ManagedCluster.
+type ClusterMetadata struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Taints []corev1.Taint    `json:"taints,omitempty"`
+}
+
+// syncPropagatedMetadata propagates the labels and taints of current cluster to the ManagedCluster.
+// Labels and taints propagated before are recorded in annotations, so that they could be removed once they are
+// no longer present, while the ones set by the operators are left untouched.
+func (mgr *Manager) syncPropagatedMetadata(ctx context.Context, client clusternetClientSet.Interface) error {
+	if len(mgr.metadataFile) == 0 && len(mgr.labelAllowlist) == 0 {
+		return nil
+	}
+
+	metadata, err := mgr.getPropagatedMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	mc := mgr.managedCluster
+	labelsPatch := map[string]interface{}{}
+	for key, value := range metadata.Labels {
+		if mc.Labels[key] != value {
+			labelsPatch[key] = value
+		}
+	}
+	for _, key := range splitKeys(mc.Annotations[known.PropagatedLabelsAnnotation]) {
+		if _, ok := metadata.Labels[key]; !ok {
+			if _, ok := mc.Labels[key]; ok {
+				// a null value removes the label with merge patch
+				labelsPatch[key] = nil
+			}
+		}
+	}
+
+	taints := mergeTaints(mc.Spec.Taints, splitKeys(mc.Annotations[known.PropagatedTaintsAnnotation]), metadata.Taints)
+	labelKeys := joinKeys(sets.StringKeySet(metadata.Labels).List())
+	taintKeys := make([]string, 0, len(metadata.Taints))
+	for _, taint := range metadata.Taints {
+		taintKeys = append(taintKeys, taint.Key)
+	}
+
+	if len(labelsPatch) == 0 && reflect.DeepEqual(taints, mc.Spec.Taints) &&
+		mc.Annotations[known.PropagatedLabelsAnnotation] == labelKeys &&
+		mc.Annotations[known.PropagatedTaintsAnnotation] == joinKeys(taintKeys) {
+		return nil
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": labelsPatch,
+			"annotations": map[string]interface{}{
+				known.PropagatedLabelsAnnotation: nilIfEmpty(labelKeys),
+				known.PropagatedTaintsAnnotation: nilIfEmpty(joinKeys(taintKeys)),
+			},
+		},
+	}
+	if !reflect.DeepEqual(taints, mc.Spec.Taints) {
+		patch["spec"] = map[string]interface{}{
+			"taints": taints,
+		}
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	mc, err = client.ClustersV1beta1().ManagedClusters(mc.Namespace).Patch(ctx, mc.Name,
+		types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	mgr.managedCluster = mc
+	return nil
+}
+
+// getPropagatedMetadata collects the labels shared by all the nodes, the labels of namespace clusternet-system,
+// as well as the labels and taints in the metadata file. Labels from nodes and the namespace must match the allowlist,
+// and labels in the metadata file take precedence.
+func (mgr *Manager) getPropagatedMetadata(ctx context.Context) (*ClusterMetadata, error) {
+	metadata := &ClusterMetadata{Labels: map[string]string{}}
+
+	if len(mgr.labelAllowlist) > 0 {
+		nodes, err := mgr.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %v", err)
+		}
+		for key, value := range filterLabels(getCommonNodeLabels(nodes.Items), mgr.labelAllowlist) {
+			metadata.Labels[key] = value
+		}
+
+		ns, err := mgr.kubeClient.CoreV1().Namespaces().Get(ctx, ClusternetSystemNamespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace %s: %v", ClusternetSystemNamespace, err)
+		}
+		for key, value := range filterLabels(ns.Labels, mgr.labelAllowlist) {
+			metadata.Labels[key] = value
+		}
+	}
+
+	if len(mgr.metadataFile) > 0 {
+		// the file is read every time, so that changes of a mounted ConfigMap get propagated
+		fromFile, err := loadClusterMetadata(mgr.metadataFile)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fromFile.Labels {
+			metadata.Labels[key] = value
+		}
+		metadata.Taints = fromFile.Taints
+	}
+	return metadata, nil
+}
+
+// loadClusterMetadata loads labels and taints from a YAML or JSON file, skipping the invalid ones.
+func loadClusterMetadata(path string) (*ClusterMetadata, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster metadata file %s: %v", path, err)
+	}
+	metadata := &ClusterMetadata{}
+	if err = yaml.Unmarshal(data, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster metadata file %s: %v", path, err)
+	}
+
+	for key, value := range metadata.Labels {
+		if errs := append(validation.IsQualifiedName(key), validation.IsValidLabelValue(value)...); len(errs) > 0 {
+			klog.Warningf("skip invalid label %s=%s in %s: %s", key, value, path, strings.Join(errs, "; "))
+			delete(metadata.Labels, key)
+		}
+	}
+
+	var taints []corev1.Taint
+	seen := sets.NewString()
+	for _, taint := range metadata.Taints {
+		switch {
+		case len(validation.IsQualifiedName(taint.Key)) > 0:
+			klog.Warningf("skip taint with invalid key %q in %s", taint.Key, path)
+		case taint.Effect != corev1.TaintEffectNoSchedule && taint.Effect != corev1.TaintEffectPreferNoSchedule &&
+			taint.Effect != corev1.TaintEffectNoExecute:
+			klog.Warningf("skip taint %s with invalid effect %q in %s", taint.Key, taint.Effect, path)
+		case seen.Has(taint.Key):
+			klog.Warningf("skip duplicated taint %s in %s", taint.Key, path)
+		default:
+			seen.Insert(taint.Key)
+			taints = append(taints, taint)
+		}
+	}
+	metadata.Taints = taints
+	return metadata, nil
+}
+
+// getCommonNodeLabels returns the labels with the same value on all the nodes, which describe the whole cluster.
+func getCommonNodeLabels(nodes []corev1.Node) map[string]string {
+	if len(nodes) == 0 {
+		return nil
+	}
+	common := map[string]string{}
+	for key, value := range nodes[0].Labels {
+		common[key] = value
+	}
+	for _, node := range nodes[1:] {
+		for key, value := range common {
+			if node.Labels[key] != value {
+				delete(common, key)
+			}
+		}
+	}
+	return common
+}
+
+// filterLabels returns the labels whose keys match the allowlist. An entry ending with "*" matches keys by prefix.
+func filterLabels(labels map[string]string, allowlist []string) map[string]string {
+	filtered := map[string]string{}
+	for key, value := range labels {
+		for _, pattern := range allowlist {
+			if key == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*"))) {
+				filtered[key] = value
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// mergeTaints replaces the taints propagated before with the desired ones, and keeps the others set by the operators.
+func mergeTaints(current []corev1.Taint, propagatedKeys []string, desired []corev1.Taint) []corev1.Taint {
+	replaced := sets.NewString(propagatedKeys...)
+	for _, taint := range desired {
+		replaced.Insert(taint.Key)
+	}
+
+	var taints []corev1.Taint
+	for _, taint := range current {
+		if !replaced.Has(taint.Key) {
+			taints = append(taints, taint)
+		}
+	}
+	taints = append(taints, desired...)
+	return taints
+}
+
+func splitKeys(value string) []string {
+	if len(value) == 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func joinKeys(keys []string) string {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// nilIfEmpty returns nil for empty values, which removes the annotations with merge patch.
+func nilIfEmpty(value string) interface{} {
+	if len(value) == 0 {
+		return nil
+	}
+	return value
+}
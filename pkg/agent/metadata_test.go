@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetCommonNodeLabels(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"env": "prod", "team": "payments", "kubernetes.io/hostname": "node-1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"env": "prod", "team": "search", "kubernetes.io/hostname": "node-2"}}},
+	}
+	want := map[string]string{"env": "prod"}
+	if got := getCommonNodeLabels(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("getCommonNodeLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterLabels(t *testing.T) {
+	labels := map[string]string{"env": "prod", "example.com/team": "payments", "kubernetes.io/os": "linux"}
+	want := map[string]string{"env": "prod", "example.com/team": "payments"}
+	if got := filterLabels(labels, []string{"env", "example.com/*"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterLabels() = %v, want %v", got, want)
+	}
+}
+
+func TestMergeTaints(t *testing.T) {
+	manual := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}
+	stale := corev1.Taint{Key: "gpu", Effect: corev1.TaintEffectNoSchedule}
+	desired := corev1.Taint{Key: "dedicated", Value: "payments", Effect: corev1.TaintEffectNoSchedule}
+
+	got := mergeTaints([]corev1.Taint{manual, stale}, []string{"gpu"}, []corev1.Taint{desired})
+	want := []corev1.Taint{manual, desired}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeTaints() = %v, want %v", got, want)
+	}
+}
@@ -18,13 +18,20 @@ package agent
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"regexp"
 	"strings"
 
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/clusters/clusterstatus"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
 	"github.com/spf13/pflag"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+	componentbaseoptions "k8s.io/component-base/config/options"
 )
 
 var validateClusterNameRegex = regexp.MustCompile(nameFmt)
@@ -44,12 +51,53 @@ type ClusterRegistrationOptions struct {
 	ClusterStatusReportFrequency metav1.Duration
 	// ClusterStatusCollectFrequency is the frequency at which the agent updates current cluster's status
 	ClusterStatusCollectFrequency metav1.Duration
+	// ClusterStatusCollectors is the list of collectors to enable for collecting cluster status
+	ClusterStatusCollectors []string
+
+	// FeedbackQueueSize is the max number of status updates buffered when parent cluster is unreachable
+	FeedbackQueueSize int
+
+	// PodSecurityLevel is the Pod Security admission level enforced in current cluster
+	PodSecurityLevel string
+
+	// ClusterLeaseDuration is the duration of the Lease renewed in parent cluster as heartbeats
+	ClusterLeaseDuration metav1.Duration
+
+	// ClusterMetadataFile is the file of labels and taints propagated to ManagedCluster
+	ClusterMetadataFile string
+	// ClusterLabelAllowlist selects the labels of nodes and namespace clusternet-system propagated to ManagedCluster
+	ClusterLabelAllowlist []string
+
+	// DriftScanFrequency is how often the objects deployed by Descriptions are compared with their live state
+	DriftScanFrequency metav1.Duration
+
+	// ResourceFeedbackFrequency is how often the feedback of objects deployed by Descriptions is refreshed
+	ResourceFeedbackFrequency metav1.Duration
+
+	// ResourceInterpreterWebhooks is the YAML file declaring the webhooks that interpret custom kinds
+	ResourceInterpreterWebhooks string
 
 	ParentURL      string
 	BootstrapToken string
 
+	// CredentialRenewalPeriod is how long the dedicated token is used before getting renewed.
+	// Tokens carrying an expiry are always renewed before they expire.
+	CredentialRenewalPeriod metav1.Duration
+
 	// No tunnel logging by default
 	TunnelLogging bool
+	// TunnelConnections is the number of tunnels set up to parent cluster, over which streams are spread
+	TunnelConnections int
+	// TunnelTransport is the transport of tunnels set up to parent cluster, websocket or gRPC
+	TunnelTransport string
+	// TunnelGRPCAddress is the address of gRPC tunnels served by parent cluster
+	TunnelGRPCAddress string
+	// TunnelGRPCCAFile is the CA verifying the serving certificate of gRPC tunnels
+	TunnelGRPCCAFile string
+
+	// LeaderElection defines the configuration of leader election. The uid of the Lease used as the lock
+	// is taken as the cluster id.
+	LeaderElection componentbaseconfig.LeaderElectionConfiguration
 
 	// TODO: check ca hash
 }
@@ -62,6 +110,23 @@ func NewClusterRegistrationOptions() *ClusterRegistrationOptions {
 		ClusterSyncMode:               string(clusterapi.Pull),
 		ClusterStatusReportFrequency:  metav1.Duration{Duration: DefaultClusterStatusReportFrequency},
 		ClusterStatusCollectFrequency: metav1.Duration{Duration: DefaultClusterStatusCollectFrequency},
+		ClusterStatusCollectors:       []string{"*"},
+		FeedbackQueueSize:             DefaultFeedbackQueueSize,
+		ClusterLeaseDuration:          metav1.Duration{Duration: DefaultClusterLeaseDuration},
+		DriftScanFrequency:            metav1.Duration{Duration: DefaultDriftScanFrequency},
+		ResourceFeedbackFrequency:     metav1.Duration{Duration: DefaultResourceFeedbackFrequency},
+		TunnelConnections:             DefaultTunnelConnections,
+		TunnelTransport:               known.TunnelTransportWebSocket,
+		CredentialRenewalPeriod:       metav1.Duration{Duration: DefaultCredentialRenewalPeriod},
+		LeaderElection: componentbaseconfig.LeaderElectionConfiguration{
+			LeaderElect:       true,
+			LeaseDuration:     metav1.Duration{Duration: DefaultLeaseDuration},
+			RenewDeadline:     metav1.Duration{Duration: DefaultRenewDeadline},
+			RetryPeriod:       metav1.Duration{Duration: DefaultRetryPeriod},
+			ResourceLock:      resourcelock.LeasesResourceLock,
+			ResourceName:      SelfClusterLeaseName,
+			ResourceNamespace: ClusternetSystemNamespace,
+		},
 	}
 }
 
@@ -74,6 +139,10 @@ func (opts *ClusterRegistrationOptions) AddFlags(fs *pflag.FlagSet) {
 		"The boostrap token is used to temporarily authenticate with parent cluster while registering "+
 			"a unregistered child cluster. On success, parent cluster credentials will be stored to a secret "+
 			"in child cluster. On every restart, this credentials will be firstly used if found")
+	fs.DurationVar(&opts.CredentialRenewalPeriod.Duration, CredentialRenewalPeriod, opts.CredentialRenewalPeriod.Duration,
+		"How long the dedicated token issued by parent cluster is used before it gets renewed, without re-registering "+
+			"current cluster. Tokens carrying an expiry are always renewed at 80% of their lifetime. "+
+			"Set to 0 to only renew the tokens carrying an expiry")
 	fs.StringVar(&opts.ClusterName, ClusterRegistrationName, opts.ClusterName,
 		"Specify the cluster registration name")
 	fs.StringVar(&opts.ClusterNamePrefix, ClusterRegistrationNamePrefix, opts.ClusterNamePrefix,
@@ -87,7 +156,60 @@ func (opts *ClusterRegistrationOptions) AddFlags(fs *pflag.FlagSet) {
 		"Specifies how often the agent posts current child cluster status to parent cluster")
 	fs.DurationVar(&opts.ClusterStatusCollectFrequency.Duration, ClusterStatusCollectFrequency, opts.ClusterStatusCollectFrequency.Duration,
 		"Specifies how often the agent collects current child cluster status")
+	fs.StringSliceVar(&opts.ClusterStatusCollectors, ClusterStatusCollectors, opts.ClusterStatusCollectors,
+		fmt.Sprintf("A list of collectors to enable for collecting cluster status. '*' enables all on-by-default collectors, "+
+			"'foo' enables the collector named 'foo', '-foo' disables the collector named 'foo'. All collectors: %s",
+			strings.Join(clusterstatus.KnownCollectors(), ", ")))
+	fs.IntVar(&opts.FeedbackQueueSize, FeedbackQueueSize, opts.FeedbackQueueSize,
+		"The max number of status updates that are persisted in child cluster when parent cluster is unreachable, "+
+			"which will be replayed in order on reconnection. Only the latest update of each object is kept. "+
+			"Set to 0 to disable buffering")
+	fs.StringVar(&opts.PodSecurityLevel, PodSecurityLevel, opts.PodSecurityLevel,
+		"Specify the Pod Security admission level 'privileged', 'baseline' or 'restricted' enforced in child cluster, "+
+			"which is reported to parent cluster, so that workloads violating it won't be deployed")
+	fs.DurationVar(&opts.ClusterLeaseDuration.Duration, ClusterLeaseDuration, opts.ClusterLeaseDuration.Duration,
+		"The duration of the Lease renewed in parent cluster as lightweight heartbeats, which is renewed every quarter "+
+			"of the duration. With Leases, cluster status could be posted less frequently. Set to 0 to disable Leases")
+	fs.StringVar(&opts.ClusterMetadataFile, ClusterMetadataFile, opts.ClusterMetadataFile,
+		"The path of a YAML file with 'labels' and 'taints', which are propagated to the ManagedCluster in parent cluster. "+
+			"The file is re-read on every status report, so it could be mounted from a ConfigMap")
+	fs.StringSliceVar(&opts.ClusterLabelAllowlist, ClusterLabelAllowlist, opts.ClusterLabelAllowlist,
+		fmt.Sprintf("A list of label keys, such as 'env,example.com/*', selecting the labels shared by all the nodes and "+
+			"the labels of namespace %s, which are propagated to the ManagedCluster in parent cluster. "+
+			"An entry ending with '*' matches keys by prefix", ClusternetSystemNamespace))
+	fs.DurationVar(&opts.DriftScanFrequency.Duration, DriftScanFrequency, opts.DriftScanFrequency.Duration,
+		"Specifies how often the objects deployed by Descriptions are compared with their live state in child cluster, "+
+			"whose drifts are reported in the status of ManagedCluster. Only takes effect when feature gate DriftReport is enabled")
+	fs.DurationVar(&opts.ResourceFeedbackFrequency.Duration, ResourceFeedbackFrequency, opts.ResourceFeedbackFrequency.Duration,
+		"Specifies how often the live state of the objects deployed by Descriptions is refreshed in the status of "+
+			"Descriptions, besides after each apply. Only takes effect when feature gate ResourceFeedback is enabled")
+	fs.StringVar(&opts.ResourceInterpreterWebhooks, ResourceInterpreterWebhooks, opts.ResourceInterpreterWebhooks,
+		"The path of a YAML file declaring the webhooks that interpret custom kinds, such as judging their health. "+
+			"Kinds without webhooks are interpreted by the built-in interpreters")
 	fs.BoolVar(&opts.TunnelLogging, "enable-tunnel-logging", opts.TunnelLogging, "Enable tunnel logging")
+	fs.IntVar(&opts.TunnelConnections, TunnelConnections, opts.TunnelConnections,
+		fmt.Sprintf("The number of websocket connections set up to parent cluster when feature gate SocketConnection is enabled, "+
+			"over which proxied streams are spread. Broken connections are reconnected with jittered exponential backoff. "+
+			"At most %d connections are allowed", known.MaxTunnelsPerCluster))
+	fs.StringVar(&opts.TunnelTransport, TunnelTransport, opts.TunnelTransport,
+		fmt.Sprintf("The transport of tunnels set up to parent cluster, '%s' or '%s'. gRPC tunnels are multiplexed over "+
+			"HTTP/2 with mutual TLS, for environments where websocket connections are blocked or unstable, which "+
+			"require feature gate CertificateSigning", known.TunnelTransportWebSocket, known.TunnelTransportGRPC))
+	fs.StringVar(&opts.TunnelGRPCAddress, TunnelGRPCAddress, opts.TunnelGRPCAddress,
+		fmt.Sprintf("The address of gRPC tunnels served by parent cluster, such as 'hub.example.com:8123', "+
+			"which is required when --%s is '%s'", TunnelTransport, known.TunnelTransportGRPC))
+	fs.StringVar(&opts.TunnelGRPCCAFile, TunnelGRPCCAFile, opts.TunnelGRPCCAFile,
+		"The PEM-encoded CA verifying the serving certificate of gRPC tunnels. If not set, "+
+			"the serving certificate is not verified")
+
+	// leader election is always enabled, since the cluster id is the uid of the Lease
+	leaderElectionFlags := pflag.NewFlagSet("leader-election", pflag.ContinueOnError)
+	componentbaseoptions.BindLeaderElectionFlags(&opts.LeaderElection, leaderElectionFlags)
+	leaderElectionFlags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name != "leader-elect" {
+			fs.AddFlag(flag)
+		}
+	})
 }
 
 // Complete completes all the required options.
@@ -133,13 +255,72 @@ func (opts *ClusterRegistrationOptions) Validate() []error {
 			opts.ClusterName, ClusterNameMaxLength-DefaultRandomUIDLength))
 	}
 
+	if err := clusterstatus.ValidateCollectors(opts.ClusterStatusCollectors); err != nil {
+		allErrs = append(allErrs, fmt.Errorf("invalid value for --%s: %v", ClusterStatusCollectors, err))
+	}
+
+	if opts.FeedbackQueueSize < 0 {
+		allErrs = append(allErrs, fmt.Errorf("--%s must not be negative", FeedbackQueueSize))
+	}
+
+	if opts.CredentialRenewalPeriod.Duration < 0 {
+		allErrs = append(allErrs, fmt.Errorf("--%s must not be negative", CredentialRenewalPeriod))
+	}
+
+	if opts.ClusterLeaseDuration.Duration < 0 {
+		allErrs = append(allErrs, fmt.Errorf("--%s must not be negative", ClusterLeaseDuration))
+	}
+
+	if opts.DriftScanFrequency.Duration <= 0 {
+		allErrs = append(allErrs, fmt.Errorf("--%s must be positive", DriftScanFrequency))
+	}
+
+	if opts.ResourceFeedbackFrequency.Duration <= 0 {
+		allErrs = append(allErrs, fmt.Errorf("--%s must be positive", ResourceFeedbackFrequency))
+	}
+
+	if opts.TunnelConnections < 1 || opts.TunnelConnections > known.MaxTunnelsPerCluster {
+		allErrs = append(allErrs, fmt.Errorf("--%s must be in [1, %d]", TunnelConnections, known.MaxTunnelsPerCluster))
+	}
+
+	switch opts.TunnelTransport {
+	case known.TunnelTransportWebSocket:
+	case known.TunnelTransportGRPC:
+		if _, _, err := net.SplitHostPort(opts.TunnelGRPCAddress); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("invalid value for --%s: %v", TunnelGRPCAddress, err))
+		}
+	default:
+		allErrs = append(allErrs, fmt.Errorf("invalid value for --%s: %q, only '%s' and '%s' are supported",
+			TunnelTransport, opts.TunnelTransport, known.TunnelTransportWebSocket, known.TunnelTransportGRPC))
+	}
+
+	allErrs = append(allErrs, utils.ValidateLeaderElectionConfiguration(opts.LeaderElection)...)
+	switch opts.LeaderElection.ResourceLock {
+	case resourcelock.LeasesResourceLock, resourcelock.EndpointsLeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock:
+	default:
+		allErrs = append(allErrs, fmt.Errorf("--leader-elect-resource-lock must be one of %q, %q and %q, "+
+			"since the cluster id is the uid of the Lease", resourcelock.LeasesResourceLock,
+			resourcelock.EndpointsLeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock))
+	}
+
+	switch clusterapi.PodSecurityLevel(opts.PodSecurityLevel) {
+	case "", clusterapi.PodSecurityPrivileged, clusterapi.PodSecurityBaseline, clusterapi.PodSecurityRestricted:
+	default:
+		allErrs = append(allErrs, fmt.Errorf("invalid value for --%s: %q, only 'privileged', 'baseline' and 'restricted' are supported",
+			PodSecurityLevel, opts.PodSecurityLevel))
+	}
+
 	switch opts.ClusterSyncMode {
 	case string(clusterapi.Pull), string(clusterapi.Push), string(clusterapi.Dual):
 	default:
 		allErrs = append(allErrs, fmt.Errorf("invalid sync mode %q, only 'Pull', 'Push' and 'Dual' are supported", opts.ClusterSyncMode))
 	}
 
-	// TODO: check bootstrap token
+	if len(opts.BootstrapToken) > 0 {
+		if _, _, err := utils.ParseBootstrapToken(opts.BootstrapToken); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("invalid value for --%s: %v", ClusterRegistrationToken, err))
+		}
+	}
 
 	return allErrs
 }
@@ -0,0 +1,321 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetInformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	appListers "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// ResourceReporter reports the live state of the objects deployed by the Descriptions of current cluster in
+// status.resources of the Descriptions, once they get applied and periodically afterwards, so that parent cluster
+// could tell whether the objects are healthy besides being applied.
+type ResourceReporter struct {
+	frequency time.Duration
+
+	dynamicClient dynamic.Interface
+	restMapper    *restmapper.DeferredDiscoveryRESTMapper
+
+	descLister appListers.DescriptionLister
+	workqueue  workqueue.RateLimitingInterface
+}
+
+// NewResourceReporter returns a new ResourceReporter.
+func NewResourceReporter(childKubeConfig *rest.Config, childKubeClientSet kubernetes.Interface, frequency time.Duration) (*ResourceReporter, error) {
+	dynamicClient, err := dynamic.NewForConfig(childKubeConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &ResourceReporter{
+		frequency:     frequency,
+		dynamicClient: dynamicClient,
+		restMapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(childKubeClientSet.Discovery())),
+		workqueue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "resourceFeedback"),
+	}, nil
+}
+
+// Run watches the Descriptions in the dedicated namespace, and reports the feedback of their objects.
+// Descriptions are resynced with the frequency, so that later changes of health get reported as well.
+func (r *ResourceReporter) Run(ctx context.Context, client clusternetClientSet.Interface, namespace string) {
+	klog.Infof("reporting feedback of Descriptions in namespace %s every %s", namespace, r.frequency)
+	defer r.workqueue.ShutDown()
+
+	factory := clusternetInformers.NewSharedInformerFactoryWithOptions(client, r.frequency,
+		clusternetInformers.WithNamespace(namespace))
+	descInformer := factory.Apps().V1alpha1().Descriptions()
+	descInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: r.enqueue,
+		UpdateFunc: func(old, cur interface{}) {
+			r.enqueue(cur)
+		},
+	})
+	r.descLister = descInformer.Lister()
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), descInformer.Informer().HasSynced) {
+		return
+	}
+
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for r.processNextItem(ctx, client) {
+		}
+	}, time.Second)
+	<-ctx.Done()
+}
+
+func (r *ResourceReporter) enqueue(obj interface{}) {
+	desc, ok := obj.(*appsapi.Description)
+	if !ok || !shouldReportFeedback(desc) {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(desc)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	r.workqueue.Add(key)
+}
+
+func (r *ResourceReporter) processNextItem(ctx context.Context, client clusternetClientSet.Interface) bool {
+	obj, shutdown := r.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.workqueue.Done(obj)
+
+	key := obj.(string)
+	if err := r.report(ctx, client, key); err != nil {
+		klog.Warningf("failed to report feedback of Description %s: %v", key, err)
+		r.workqueue.AddRateLimited(key)
+		return true
+	}
+	r.workqueue.Forget(key)
+	return true
+}
+
+func (r *ResourceReporter) report(ctx context.Context, client clusternetClientSet.Interface, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	desc, err := r.descLister.Descriptions(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !shouldReportFeedback(desc) {
+		return nil
+	}
+
+	var resources []appsapi.ResourceFeedback
+	for _, raw := range desc.Spec.Raw {
+		desired := &unstructured.Unstructured{}
+		if err = desired.UnmarshalJSON(raw); err != nil {
+			klog.Warningf("failed to unmarshal object in Description %s: %v", klog.KObj(desc), err)
+			continue
+		}
+		fields := utils.FindStatusFields(desc.Spec.StatusAggregations, appsapi.Feed{
+			APIVersion: desired.GetAPIVersion(),
+			Kind:       desired.GetKind(),
+			Namespace:  desired.GetNamespace(),
+			Name:       desired.GetName(),
+		})
+		resources = append(resources, r.getFeedback(ctx, desired, fields))
+	}
+	if reflect.DeepEqual(desc.Status.Resources, resources) {
+		return nil
+	}
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"resources": resources,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.AppsV1alpha1().Descriptions(namespace).Patch(ctx, name, types.MergePatchType, patchBytes,
+		metav1.PatchOptions{}, "status")
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// getFeedback compares the object with its live state in child cluster, evaluates its health
+// and collects the status fields.
+func (r *ResourceReporter) getFeedback(ctx context.Context, desired *unstructured.Unstructured, fields []appsapi.StatusField) appsapi.ResourceFeedback {
+	feedback := appsapi.ResourceFeedback{
+		APIVersion: desired.GetAPIVersion(),
+		Kind:       desired.GetKind(),
+		Namespace:  desired.GetNamespace(),
+		Name:       desired.GetName(),
+	}
+
+	gvk := desired.GroupVersionKind()
+	restMapping, err := r.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		// the resource may be newly installed
+		r.restMapper.Reset()
+		feedback.Message = err.Error()
+		return feedback
+	}
+	live, err := r.dynamicClient.Resource(restMapping.Resource).Namespace(desired.GetNamespace()).
+		Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			feedback.Message = "object is not found in the cluster"
+		} else {
+			feedback.Message = err.Error()
+		}
+		return feedback
+	}
+
+	return evaluateFeedback(feedback, desired, live, fields)
+}
+
+// evaluateFeedback fills in the feedback with the live state of the object.
+func evaluateFeedback(feedback appsapi.ResourceFeedback, desired, live *unstructured.Unstructured, statusFields []appsapi.StatusField) appsapi.ResourceFeedback {
+	feedback.ObservedGeneration, _, _ = unstructured.NestedInt64(live.Object, "status", "observedGeneration")
+	// the drifted fields owned by other field managers are left to them, and reported as conflicts
+	var fields []string
+	feedback.Conflicts = nil
+	for _, field := range utils.FindDriftedFields(desired.Object, live.Object) {
+		var managers []string
+		for _, manager := range utils.FindFieldManagers(live, field) {
+			if !utils.IsClusternetFieldManager(manager) {
+				managers = append(managers, manager)
+			}
+		}
+		if len(managers) == 0 {
+			fields = append(fields, field)
+			continue
+		}
+		feedback.Conflicts = append(feedback.Conflicts, appsapi.FieldConflict{Field: field, Managers: managers})
+	}
+	feedback.Applied = len(fields) == 0
+	healthy, message, err := interpreter.InterpretHealth(live)
+	feedback.Healthy = healthy
+	if len(statusFields) > 0 {
+		status, cerr := collectStatusFields(live, statusFields)
+		if cerr != nil {
+			klog.Warningf("failed to collect status fields of %s %s: %v", live.GetKind(), klog.KObj(live), cerr)
+		}
+		feedback.Status = status
+	}
+	switch {
+	case !feedback.Applied:
+		feedback.Message = fmt.Sprintf("live state drifts from the desired one on %s", strings.Join(fields, ", "))
+	case err != nil:
+		feedback.Message = fmt.Sprintf("failed to interpret health: %v", err)
+	case !feedback.Healthy:
+		feedback.Message = message
+	case len(feedback.Conflicts) > 0:
+		var conflicts []string
+		for _, conflict := range feedback.Conflicts {
+			conflicts = append(conflicts, fmt.Sprintf("%s (managed by %s)", conflict.Field, strings.Join(conflict.Managers, ", ")))
+		}
+		feedback.Message = fmt.Sprintf("fields owned by other managers are left as is: %s", strings.Join(conflicts, "; "))
+	}
+	return feedback
+}
+
+// collectStatusFields evaluates the JSONPath of the fields on the object. Missing fields are skipped,
+// and the fields matching multiple values are collected as lists.
+func collectStatusFields(obj *unstructured.Unstructured, fields []appsapi.StatusField) (*runtime.RawExtension, error) {
+	status := map[string]interface{}{}
+	var errs []string
+	for _, field := range fields {
+		path := field.JSONPath
+		if !strings.HasPrefix(path, "{") {
+			path = fmt.Sprintf("{%s}", path)
+		}
+		jp := jsonpath.New(field.Name).AllowMissingKeys(true)
+		if err := jp.Parse(path); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid jsonPath of field %s: %v", field.Name, err))
+			continue
+		}
+		results, err := jp.FindResults(obj.Object)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("failed to find field %s: %v", field.Name, err))
+			continue
+		}
+
+		var values []interface{}
+		for _, result := range results {
+			for _, value := range result {
+				values = append(values, value.Interface())
+			}
+		}
+		switch len(values) {
+		case 0:
+		case 1:
+			status[field.Name] = values[0]
+		default:
+			status[field.Name] = values
+		}
+	}
+
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	if len(status) == 0 {
+		return nil, err
+	}
+	raw, merr := json.Marshal(status)
+	if merr != nil {
+		return nil, merr
+	}
+	return &runtime.RawExtension{Raw: raw}, err
+}
+
+// shouldReportFeedback tells whether the objects of the Description have been applied by the generic deployer,
+// since Helm releases are left to Helm.
+func shouldReportFeedback(desc *appsapi.Description) bool {
+	return desc.DeletionTimestamp == nil && desc.Spec.Deployer == appsapi.DescriptionGenericDeployer &&
+		len(desc.Status.Phase) > 0 && !utils.IsJobsFinished(desc)
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestEvaluateFeedback(t *testing.T) {
+	desired := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+	}
+	tests := []struct {
+		name string
+		live map[string]interface{}
+		want appsapi.ResourceFeedback
+	}{
+		{
+			name: "applied and healthy",
+			live: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "foo", "generation": int64(3)},
+				"spec":       map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"observedGeneration": int64(3), "updatedReplicas": int64(2),
+					"availableReplicas": int64(2)},
+			},
+			want: appsapi.ResourceFeedback{Applied: true, Healthy: true, ObservedGeneration: 3},
+		},
+		{
+			name: "applied but unavailable",
+			live: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "foo", "generation": int64(3)},
+				"spec":       map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"observedGeneration": int64(3), "updatedReplicas": int64(2),
+					"availableReplicas": int64(1)},
+			},
+			want: appsapi.ResourceFeedback{Applied: true, ObservedGeneration: 3, Message: "1 of 2 updated replicas are available"},
+		},
+		{
+			name: "drifted",
+			live: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+				"spec":       map[string]interface{}{"replicas": int64(1)},
+				"status":     map[string]interface{}{"updatedReplicas": int64(1), "availableReplicas": int64(1)},
+			},
+			want: appsapi.ResourceFeedback{Healthy: true,
+				Message: "live state drifts from the desired one on spec.replicas"},
+		}, {
+			name: "scaled by other managers",
+			live: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{"name": "web", "namespace": "foo",
+					"managedFields": []interface{}{
+						map[string]interface{}{
+							"manager":    "kube-controller-manager",
+							"operation":  "Update",
+							"fieldsType": "FieldsV1",
+							"fieldsV1":   map[string]interface{}{"f:spec": map[string]interface{}{"f:replicas": map[string]interface{}{}}},
+						},
+					},
+				},
+				"spec":   map[string]interface{}{"replicas": int64(5)},
+				"status": map[string]interface{}{"updatedReplicas": int64(5), "availableReplicas": int64(5)},
+			},
+			want: appsapi.ResourceFeedback{Applied: true, Healthy: true,
+				Conflicts: []appsapi.FieldConflict{{Field: "spec.replicas", Managers: []string{"kube-controller-manager"}}},
+				Message:   "fields owned by other managers are left as is: spec.replicas (managed by kube-controller-manager)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluateFeedback(appsapi.ResourceFeedback{}, &unstructured.Unstructured{Object: desired},
+				&unstructured.Unstructured{Object: tt.live}, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("evaluateFeedback() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectStatusFields(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+		"status": map[string]interface{}{
+			"readyReplicas": int64(2),
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "True"},
+				map[string]interface{}{"type": "Progressing", "status": "True"},
+			},
+		},
+	}}
+	tests := []struct {
+		name    string
+		fields  []appsapi.StatusField
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "single values",
+			fields: []appsapi.StatusField{
+				{Name: "readyReplicas", JSONPath: "{.status.readyReplicas}"},
+				{Name: "available", JSONPath: `{.status.conditions[?(@.type=="Available")].status}`},
+			},
+			want: `{"available":"True","readyReplicas":2}`,
+		},
+		{
+			name: "multiple values and relaxed path",
+			fields: []appsapi.StatusField{
+				{Name: "conditionTypes", JSONPath: ".status.conditions[*].type"},
+			},
+			want: `{"conditionTypes":["Available","Progressing"]}`,
+		},
+		{
+			name: "missing field",
+			fields: []appsapi.StatusField{
+				{Name: "unavailableReplicas", JSONPath: "{.status.unavailableReplicas}"},
+			},
+		},
+		{
+			name: "invalid path",
+			fields: []appsapi.StatusField{
+				{Name: "readyReplicas", JSONPath: "{.status.readyReplicas}"},
+				{Name: "broken", JSONPath: "{.status[}"},
+			},
+			want:    `{"readyReplicas":2}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectStatusFields(obj, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("collectStatusFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var status string
+			if got != nil {
+				status = string(got.Raw)
+			}
+			if status != tt.want {
+				t.Errorf("collectStatusFields() = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldReportFeedback(t *testing.T) {
+	tests := []struct {
+		name string
+		desc *appsapi.Description
+		want bool
+	}{
+		{
+			name: "applied generic Description",
+			desc: &appsapi.Description{
+				Spec:   appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer},
+				Status: appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseSuccess},
+			},
+			want: true,
+		},
+		{
+			name: "generic Description not applied yet",
+			desc: &appsapi.Description{
+				Spec: appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer},
+			},
+			want: false,
+		},
+		{
+			name: "helm Description",
+			desc: &appsapi.Description{
+				Spec:   appsapi.DescriptionSpec{Deployer: appsapi.DescriptionHelmDeployer},
+				Status: appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseSuccess},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldReportFeedback(tt.desc); got != tt.want {
+				t.Errorf("shouldReportFeedback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
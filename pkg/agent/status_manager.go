@@ -18,21 +18,28 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
 	"github.com/clusternet/clusternet/pkg/controllers/clusters/clusterstatus"
 	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetInformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
 	"github.com/clusternet/clusternet/pkg/known"
 )
 
@@ -43,13 +50,49 @@ type Manager struct {
 	clusterStatusController *clusterstatus.Controller
 
 	managedCluster *clusterapi.ManagedCluster
+
+	kubeClient kubernetes.Interface
+
+	// feedbackQueueSize is the max number of status updates buffered when parent cluster is unreachable
+	feedbackQueueSize int
+	feedbackQueue     *FeedbackQueue
+
+	// metadataFile is the file of labels and taints propagated to the ManagedCluster
+	metadataFile string
+	// labelAllowlist selects the labels of nodes and namespace clusternet-system propagated to the ManagedCluster
+	labelAllowlist []string
+
+	// leaseDuration is the duration of the heartbeat Lease in parent cluster. 0 disables the Lease.
+	leaseDuration metav1.Duration
+
+	// driftScanner reports the drifts of objects deployed by Descriptions.
+	// It is nil when feature gate DriftReport is disabled.
+	driftScanner *DriftScanner
+
+	// resourceReporter reports the live state of objects deployed by Descriptions.
+	// It is nil when feature gate ResourceFeedback is disabled.
+	resourceReporter *ResourceReporter
+
+	// refreshRequest is the value of the pending refresh request annotated on the ManagedCluster
+	refreshRequest string
+	refreshLock    sync.Mutex
 }
 
-func NewStatusManager(ctx context.Context, apiserverURL, parentAPIServerURL string, kubeClient kubernetes.Interface, statusCollectFrequency metav1.Duration, statusReportFrequency metav1.Duration) *Manager {
+func NewStatusManager(ctx context.Context, apiserverURL, parentAPIServerURL string, kubeClient kubernetes.Interface, statusCollectFrequency metav1.Duration, statusReportFrequency metav1.Duration, statusCollectors []string, feedbackQueueSize int, podSecurityLevel clusterapi.PodSecurityLevel, leaseDuration metav1.Duration, metadataFile string, labelAllowlist []string) (*Manager, error) {
+	clusterStatusController, err := clusterstatus.NewController(ctx, apiserverURL, parentAPIServerURL, kubeClient, statusCollectFrequency, statusCollectors, podSecurityLevel)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Manager{
 		statusReportFrequency:   statusReportFrequency,
-		clusterStatusController: clusterstatus.NewController(ctx, apiserverURL, parentAPIServerURL, kubeClient, statusCollectFrequency),
-	}
+		clusterStatusController: clusterStatusController,
+		kubeClient:              kubeClient,
+		feedbackQueueSize:       feedbackQueueSize,
+		leaseDuration:           leaseDuration,
+		metadataFile:            metadataFile,
+		labelAllowlist:          labelAllowlist,
+	}, nil
 }
 
 func (mgr *Manager) Run(ctx context.Context, parentDedicatedKubeConfig *rest.Config, secret *corev1.Secret) {
@@ -57,10 +100,19 @@ func (mgr *Manager) Run(ctx context.Context, parentDedicatedKubeConfig *rest.Con
 
 	go mgr.clusterStatusController.Run(ctx)
 
+	if mgr.feedbackQueueSize > 0 {
+		queue, err := NewFeedbackQueue(ctx, mgr.kubeClient, ClusternetSystemNamespace, FeedbackQueueConfigMapName, mgr.feedbackQueueSize)
+		if err != nil {
+			klog.Errorf("failed to initialize feedback queue, status updates will not be buffered: %v", err)
+		} else {
+			mgr.feedbackQueue = queue
+		}
+	}
+
 	// in case the dedicated kubeconfig get changed when leader election gets lost,
 	// initialize the client when Run() is called
 	client := clusternetClientSet.NewForConfigOrDie(parentDedicatedKubeConfig)
-	wait.Until(func() {
+	report := func() {
 		if secret == nil {
 			klog.Error("unexpected nil secret")
 			// in case a race condition here
@@ -90,7 +142,104 @@ func (mgr *Manager) Run(ctx context.Context, parentDedicatedKubeConfig *rest.Con
 				Factor:   5.0,
 				Jitter:   0.1,
 			})
-	}, mgr.statusReportFrequency.Duration, ctx.Done())
+	}
+
+	if secret != nil {
+		namespace, clusterID := string(secret.Data[corev1.ServiceAccountNamespaceKey]), secret.Labels[known.ClusterIDLabel]
+		if len(namespace) > 0 && len(clusterID) > 0 {
+			go mgr.watchRefreshRequests(ctx, client, namespace, clusterID)
+			if mgr.leaseDuration.Duration > 0 {
+				go mgr.renewLease(ctx, kubernetes.NewForConfigOrDie(parentDedicatedKubeConfig), namespace, clusterID)
+			}
+			if mgr.driftScanner != nil {
+				go mgr.driftScanner.Run(ctx, client, namespace, mgr.clusterStatusController.Refresh)
+			}
+			if mgr.resourceReporter != nil {
+				go mgr.resourceReporter.Run(ctx, client, namespace)
+			}
+		}
+	}
+
+	// besides reporting periodically, push the status immediately once it gets refreshed on node changes or on demand
+	ticker := time.NewTicker(mgr.statusReportFrequency.Duration)
+	defer ticker.Stop()
+	report()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report()
+		case <-mgr.clusterStatusController.StatusUpdated():
+			klog.V(5).Info("reporting cluster status on node changes or on demand")
+			report()
+			mgr.acknowledgeRefreshRequest(ctx, client)
+		}
+	}
+}
+
+// watchRefreshRequests watches the ManagedCluster of current cluster, and refreshes the cluster status immediately
+// once a new refresh request is annotated by the operators.
+func (mgr *Manager) watchRefreshRequests(ctx context.Context, client clusternetClientSet.Interface, namespace, clusterID string) {
+	factory := clusternetInformers.NewSharedInformerFactoryWithOptions(client, 0,
+		clusternetInformers.WithNamespace(namespace),
+		clusternetInformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: clusterID}).String()
+		}),
+	)
+	factory.Clusters().V1beta1().ManagedClusters().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: mgr.handleRefreshRequest,
+		UpdateFunc: func(old, cur interface{}) {
+			mgr.handleRefreshRequest(cur)
+		},
+	})
+	factory.Start(ctx.Done())
+}
+
+func (mgr *Manager) handleRefreshRequest(obj interface{}) {
+	mc, ok := obj.(*clusterapi.ManagedCluster)
+	if !ok {
+		return
+	}
+
+	request := mc.Annotations[known.RefreshRequestedAnnotation]
+	mgr.refreshLock.Lock()
+	defer mgr.refreshLock.Unlock()
+	if request == mgr.refreshRequest {
+		return
+	}
+	mgr.refreshRequest = request
+	if len(request) > 0 {
+		klog.V(4).Infof("refreshing cluster status on demand of request %q", request)
+		mgr.clusterStatusController.Refresh()
+	}
+}
+
+// acknowledgeRefreshRequest removes the refresh request annotation after the refreshed status gets reported.
+// The annotation is kept if its value changes meanwhile, since a new request is pending.
+func (mgr *Manager) acknowledgeRefreshRequest(ctx context.Context, client clusternetClientSet.Interface) {
+	mgr.refreshLock.Lock()
+	defer mgr.refreshLock.Unlock()
+	if len(mgr.refreshRequest) == 0 || mgr.managedCluster == nil {
+		return
+	}
+
+	path := fmt.Sprintf("/metadata/annotations/%s", strings.ReplaceAll(known.RefreshRequestedAnnotation, "/", "~1"))
+	patchBytes, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": path, "value": mgr.refreshRequest},
+		{"op": "remove", "path": path},
+	})
+	if err != nil {
+		klog.Errorf("failed to marshal patch for refresh request: %v", err)
+		return
+	}
+	_, err = client.ClustersV1beta1().ManagedClusters(mgr.managedCluster.Namespace).Patch(ctx, mgr.managedCluster.Name,
+		types.JSONPatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil && !apierrors.IsInvalid(err) {
+		klog.Warningf("failed to acknowledge refresh request on ManagedCluster %s: %v", klog.KObj(mgr.managedCluster), err)
+		return
+	}
+	mgr.refreshRequest = ""
 }
 
 func (mgr *Manager) updateClusterStatus(ctx context.Context, namespace, clusterID string, client clusternetClientSet.Interface, backoff wait.Backoff) {
@@ -117,6 +266,18 @@ func (mgr *Manager) updateClusterStatus(ctx context.Context, namespace, clusterI
 		}
 	}
 
+	// replay the buffered status updates in order before sending current one
+	if mgr.feedbackQueue != nil && mgr.feedbackQueue.Len() > 0 {
+		err := mgr.feedbackQueue.Replay(ctx, func(feedback Feedback) error {
+			return replayFeedback(ctx, client, feedback)
+		})
+		if err != nil {
+			klog.Warningf("failed to replay buffered status updates: %v", err)
+			mgr.bufferClusterStatus(ctx)
+			return
+		}
+	}
+
 	// in case the network is not stable, retry with backoff
 	err := wait.ExponentialBackoffWithContext(ctx, backoff, func() (done bool, err error) {
 		status := mgr.clusterStatusController.GetClusterStatus()
@@ -126,6 +287,10 @@ func (mgr *Manager) updateClusterStatus(ctx context.Context, namespace, clusterI
 		}
 
 		mgr.managedCluster.Status = *status
+		mgr.managedCluster.Status.ObservedGeneration = mgr.managedCluster.Generation
+		if mgr.driftScanner != nil {
+			mgr.managedCluster.Status.Drift = mgr.driftScanner.GetSummary()
+		}
 		mc, err := client.ClustersV1beta1().ManagedClusters(namespace).UpdateStatus(ctx, mgr.managedCluster, metav1.UpdateOptions{})
 		if err != nil {
 			if apierrors.IsConflict(err) {
@@ -146,5 +311,109 @@ func (mgr *Manager) updateClusterStatus(ctx context.Context, namespace, clusterI
 	})
 	if err != nil {
 		klog.Errorf("failed to update status of ManagedCluster after retrying many times: %v", err)
+		mgr.bufferClusterStatus(ctx)
+		return
+	}
+
+	if err = mgr.syncTopologyLabels(ctx, client); err != nil {
+		klog.Warningf("failed to sync topology labels of ManagedCluster %s: %v", klog.KObj(mgr.managedCluster), err)
+	}
+	if err = mgr.syncPropagatedMetadata(ctx, client); err != nil {
+		klog.Warningf("failed to propagate labels and taints to ManagedCluster %s: %v", klog.KObj(mgr.managedCluster), err)
+	}
+}
+
+// syncTopologyLabels labels the ManagedCluster with detected provider, region and zone,
+// so that clusters could be selected by their locations.
+func (mgr *Manager) syncTopologyLabels(ctx context.Context, client clusternetClientSet.Interface) error {
+	desired := getTopologyLabels(&mgr.managedCluster.Status)
+	changed := map[string]string{}
+	for key, value := range desired {
+		if mgr.managedCluster.Labels[key] != value {
+			changed[key] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": changed,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	mc, err := client.ClustersV1beta1().ManagedClusters(mgr.managedCluster.Namespace).Patch(ctx, mgr.managedCluster.Name,
+		types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	mgr.managedCluster = mc
+	return nil
+}
+
+// getTopologyLabels returns the labels of detected topology. Zone is only labeled for clusters in a single zone,
+// and undetected topology is not labeled, so that labels set manually are kept.
+func getTopologyLabels(status *clusterapi.ManagedClusterStatus) map[string]string {
+	topologyLabels := map[string]string{}
+	if len(status.Provider) > 0 {
+		topologyLabels[known.ClusterProviderLabel] = status.Provider
+	}
+	if len(status.Region) > 0 {
+		topologyLabels[corev1.LabelTopologyRegion] = status.Region
+	}
+	if len(status.Zones) == 1 {
+		topologyLabels[corev1.LabelTopologyZone] = status.Zones[0]
+	}
+	return topologyLabels
+}
+
+// bufferClusterStatus pushes current cluster status to the feedback queue, which will be replayed later.
+func (mgr *Manager) bufferClusterStatus(ctx context.Context) {
+	if mgr.feedbackQueue == nil {
+		return
+	}
+
+	status := mgr.clusterStatusController.GetClusterStatus()
+	if status == nil {
+		return
+	}
+	statusBytes, err := json.Marshal(status)
+	if err != nil {
+		klog.Errorf("failed to marshal cluster status: %v", err)
+		return
+	}
+	err = mgr.feedbackQueue.Push(ctx, Feedback{
+		Kind:      "ManagedCluster",
+		Namespace: mgr.managedCluster.Namespace,
+		Name:      mgr.managedCluster.Name,
+		Status:    statusBytes,
+		Timestamp: metav1.Now(),
+	})
+	if err != nil {
+		klog.Errorf("failed to buffer cluster status: %v", err)
+	}
+}
+
+// replayFeedback sends the buffered status update to parent cluster.
+func replayFeedback(ctx context.Context, client clusternetClientSet.Interface, feedback Feedback) error {
+	switch feedback.Kind {
+	case "ManagedCluster":
+		patchBytes, err := json.Marshal(map[string]json.RawMessage{"status": feedback.Status})
+		if err != nil {
+			return err
+		}
+		_, err = client.ClustersV1beta1().ManagedClusters(feedback.Namespace).Patch(ctx, feedback.Name,
+			types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		if apierrors.IsNotFound(err) {
+			klog.Warningf("drop status update to ManagedCluster %s/%s which no longer exists", feedback.Namespace, feedback.Name)
+			return nil
+		}
+		return err
+	default:
+		klog.Warningf("drop status update to unsupported kind %s", feedback.Kind)
+		return nil
 	}
 }
@@ -42,6 +42,49 @@ type BaseSpec struct {
 	// +required
 	// +kubebuilder:validation:Required
 	Feeds []Feed `json:"feeds"`
+
+	// Replicas is the number of replicas divided to this cluster for the workloads in Feeds,
+	// which is only set when the Subscription uses the Dividing scheduling strategy.
+	//
+	// +optional
+	Replicas []FeedReplicas `json:"replicas,omitempty"`
+}
+
+// FeedReplicas is the number of replicas of a workload feed in a cluster.
+type FeedReplicas struct {
+	// Kind is a string value representing the REST resource this object represents.
+	// In CamelCase.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	Kind string `json:"kind"`
+
+	// APIVersion defines the versioned schema of this representation of an object.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	APIVersion string `json:"apiVersion"`
+
+	// Namespace of the target resource.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the target resource.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	Name string `json:"name"`
+
+	// Replicas is the number of replicas of the workload in this cluster.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
 }
 
 // +kubebuilder:object:root=true
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // Important: Run "make generated" to regenerate code after modifying this file
@@ -58,6 +59,20 @@ type DescriptionSpec struct {
 	//
 	// +optional
 	Raw [][]byte `json:"raw,omitempty"`
+
+	// StatusAggregations declare the status fields collected by clusternet-agent from the objects in Raw,
+	// which are reported in the feedback of the objects.
+	//
+	// +optional
+	StatusAggregations []StatusAggregationSpec `json:"statusAggregations,omitempty"`
+
+	// DeletionPolicy decides how the objects are removed from the child cluster when the Description is deleted.
+	// "Background" and "Foreground" delete the objects with the same propagation policy, while "Orphan" leaves
+	// the objects in the child cluster, or keeps the helm releases installed. Defaults to "Background".
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Background;Foreground;Orphan
+	DeletionPolicy *metav1.DeletionPropagation `json:"deletionPolicy,omitempty"`
 }
 
 // DescriptionStatus defines the observed state of Description
@@ -70,6 +85,78 @@ type DescriptionStatus struct {
 	// Reason indicates the reason of DescriptionPhase
 	// +optional
 	Reason string `json:"reason,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this Description.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// FailedAttempts is the number of consecutive failed attempts to deploy the observed generation.
+	//
+	// +optional
+	FailedAttempts int32 `json:"failedAttempts,omitempty"`
+
+	// Resources are the feedback of the objects in Raw, which is reported by clusternet-agent with their live state
+	// in the child cluster after each apply.
+	//
+	// +optional
+	Resources []ResourceFeedback `json:"resources,omitempty"`
+
+	// Conditions represent the latest available observations of the Description's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ResourceFeedback is the live state of an object deployed by a Description.
+type ResourceFeedback struct {
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace of the object.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object.
+	Name string `json:"name"`
+
+	// Applied means the object exists in the child cluster, and its live state matches the desired one.
+	Applied bool `json:"applied"`
+
+	// Healthy means the object is ready, such as all the replicas of a Deployment being available.
+	Healthy bool `json:"healthy"`
+
+	// Message tells why the object is not applied or not healthy.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the object observed by its controller in the child cluster.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Status holds the status fields collected from the object, which are declared by StatusAggregations.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Status *runtime.RawExtension `json:"status,omitempty"`
+
+	// Conflicts are the fields managed by other controllers in the child cluster, such as replicas scaled by
+	// HorizontalPodAutoscalers, whose live values differ from the desired ones and are not overwritten.
+	// +optional
+	Conflicts []FieldConflict `json:"conflicts,omitempty"`
+}
+
+// FieldConflict is a field of an object owned by other field managers in the child cluster.
+type FieldConflict struct {
+	// Field is the path of the field, such as spec.replicas.
+	Field string `json:"field"`
+
+	// Managers are the field managers owning the field.
+	Managers []string `json:"managers"`
 }
 
 type DescriptionDeployer string
@@ -82,10 +169,19 @@ const (
 type DescriptionPhase string
 
 const (
+	DescriptionPhasePending DescriptionPhase = "Pending"
 	DescriptionPhaseSuccess DescriptionPhase = "Success"
 	DescriptionPhaseFailure DescriptionPhase = "Failure"
 )
 
+const (
+	// DescriptionReady means the Description has been deployed successfully.
+	DescriptionReady = "Ready"
+	// DescriptionApproved means the changes of the latest generation of the Description have been approved,
+	// which is only required when the Subscription specifies an ApprovalStrategy.
+	DescriptionApproved = "Approved"
+)
+
 // +kubebuilder:object:root=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
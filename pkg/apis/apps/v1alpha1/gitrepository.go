@@ -0,0 +1,141 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make generated" to regenerate code after modifying this file
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope="Namespaced",shortName=gitrepo,categories=clusternet
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`,description="The url of the git repository"
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the manifests are synced"
+// +kubebuilder:printcolumn:name="REVISION",type=string,JSONPath=`.status.revision`,description="The revision of the synced manifests"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// GitRepository pulls the manifests in a directory of a git repository into Manifests,
+// which can then be used as a feed in Subscriptions.
+type GitRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GitRepositorySpec   `json:"spec"`
+	Status GitRepositoryStatus `json:"status,omitempty"`
+}
+
+// GitRepositorySpec defines the desired state of GitRepository
+type GitRepositorySpec struct {
+	// URL is the url of the git repository, with scheme http, https or ssh.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^(http|https|ssh)://"
+	URL string `json:"url"`
+
+	// Reference specifies the revision to check out. Defaults to the branch "master".
+	//
+	// +optional
+	Reference *GitRepositoryRef `json:"ref,omitempty"`
+
+	// SecretRef refers to a Secret in the same namespace with the credentials of the git repository,
+	// which are "username" and "password" for http(s), or "identity" and "known_hosts" for ssh.
+	//
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Path is the directory of the manifests in the git repository. A directory with a kustomization.yaml
+	// is built with kustomize, otherwise all the YAML and JSON files under it are used.
+	//
+	// +optional
+	// +kubebuilder:default="."
+	Path string `json:"path,omitempty"`
+
+	// Interval is how often the git repository is polled for new revisions.
+	//
+	// +optional
+	// +kubebuilder:default="5m"
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// GitRepositoryRef specifies the revision to check out, in the order of precedence of
+// Commit, SemVer, Tag and Branch.
+type GitRepositoryRef struct {
+	// Branch is the name of the branch to check out.
+	//
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Tag is the name of the tag to check out.
+	//
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// SemVer is a semver range, and the tag of the highest version in the range is checked out.
+	//
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// Commit is the SHA of the commit to check out.
+	//
+	// +optional
+	Commit string `json:"commit,omitempty"`
+}
+
+// GitRepositoryStatus defines the observed state of GitRepository
+type GitRepositoryStatus struct {
+	// ObservedGeneration is the most recent generation observed for this GitRepository.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Revision is the reference and SHA of the last synced commit, such as "master/<SHA>".
+	//
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Resources is the number of the last synced resources.
+	//
+	// +optional
+	Resources int32 `json:"resources,omitempty"`
+
+	// Conditions represent the latest available observations of the GitRepository's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// GitRepositoryReady means the manifests of the latest revision have been synced into Manifests.
+	GitRepositoryReady = "Ready"
+)
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GitRepositoryList contains a list of GitRepository
+type GitRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GitRepository `json:"items"`
+}
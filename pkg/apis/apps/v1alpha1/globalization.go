@@ -57,7 +57,9 @@ type GlobalizationSpec struct {
 	Overrides []OverrideConfig `json:"overrides,omitempty"`
 
 	// Priority is an integer defining the relative importance of this Globalization compared to others. Lower
-	// numbers are considered lower priority.
+	// numbers are considered lower priority. Globalizations are applied in ascending order of priority before all
+	// the Localizations, so the ones with higher priority take precedence. Globalizations with the same priority
+	// are applied in the order of creation, then names.
 	//
 	// +optional
 	// +kubebuilder:validation:Maximum=1000
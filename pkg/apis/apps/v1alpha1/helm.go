@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -52,6 +53,12 @@ type HelmChartSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Type=string
 	TargetNamespace string `json:"targetNamespace"`
+
+	// Verify verifies the cosign signatures of the chart before it is deployed,
+	// which is only supported for charts in OCI registries.
+	//
+	// +optional
+	Verify *SignatureVerification `json:"verify,omitempty"`
 }
 
 // HelmChartStatus defines the observed state of HelmChart
@@ -59,13 +66,25 @@ type HelmChartStatus struct {
 	// Phase denotes the phase of HelmChart
 	//
 	// +optional
-	// +kubebuilder:validation:Enum=Found;NotFound
+	// +kubebuilder:validation:Enum=Found;NotFound;Unverified
 	Phase HelmChartPhase `json:"phase,omitempty"`
 
 	// Reason indicates the reason of HelmChartPhase
 	//
 	// +optional
 	Reason string `json:"reason,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this HelmChart.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the HelmChart's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type HelmChartPhase string
@@ -73,16 +92,32 @@ type HelmChartPhase string
 const (
 	HelmChartFound    HelmChartPhase = "Found"
 	HelmChartNotFound HelmChartPhase = "NotFound"
+	// HelmChartUnverified means the signatures of the chart fail to be verified
+	HelmChartUnverified HelmChartPhase = "Unverified"
+)
+
+const (
+	// HelmChartReady means the chart has been found in the repository.
+	HelmChartReady = "Ready"
+
+	// HelmReleaseReady means the release has been deployed successfully.
+	HelmReleaseReady = "Ready"
+
+	// HelmReleaseTested means the tests of the chart have passed against the deployed release.
+	HelmReleaseTested = "Tested"
+
+	// HelmReleaseDrifted means the objects of the release in the cluster have drifted from the chart.
+	HelmReleaseDrifted = "Drifted"
 )
 
 type HelmOptions struct {
 	// a Helm Repository to be used.
-	// such as, https://charts.bitnami.com/bitnami
+	// such as, https://charts.bitnami.com/bitnami, or an OCI registry like oci://registry.example.com/charts
 	//
 	// +required
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Type=string
-	// +kubebuilder:validation:Pattern=`^(?:http(s)?:\/\/)?[\w.-]+(?:\.[\w\.-]+)+[\w\-\._~:/?#[\]@!\$&\(\)\*\+,;=.]+$`
+	// +kubebuilder:validation:Pattern=`^(?:(http(s)?|oci):\/\/)?[\w.-]+(?:\.[\w\.-]+)+[\w\-\._~:/?#[\]@!\$&\(\)\*\+,;=.]+$`
 	Repository string `json:"repo"`
 
 	// Chart is the name of a Helm Chart in the Repository.
@@ -97,8 +132,55 @@ type HelmOptions struct {
 	//
 	// +optional
 	ChartVersion string `json:"version,omitempty"`
+
+	// ChartPullSecret references a Secret to access the Repository, with keys "username" and "password" for basic
+	// authentication, "ca.crt" for the CA bundle to verify the Repository, and "tls.crt" and "tls.key" for
+	// client certificates. The namespace of the HelmChart is used if Namespace is empty.
+	//
+	// +optional
+	ChartPullSecret corev1.SecretReference `json:"chartPullSecret,omitempty"`
+
+	// InsecureSkipTLSVerify skips verifying the certificates of the Repository.
+	//
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// DisableHooks prevents the hooks of the chart from running on install, upgrade and uninstall.
+	//
+	// +optional
+	DisableHooks bool `json:"disableHooks,omitempty"`
+
+	// RunTests runs the tests of the chart, like "helm test", after it is installed or upgraded.
+	//
+	// +optional
+	RunTests bool `json:"runTests,omitempty"`
+
+	// Timeout is the time to wait for hooks and tests to complete, which defaults to 5m.
+	//
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// DriftRemediation specifies how to handle drifts of the objects of the release from the chart, such as being
+	// changed or deleted manually. Warn only reports drifts in condition "Drifted", while Upgrade also upgrades the
+	// release to revert them.
+	//
+	// +optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Enum=Warn;Upgrade
+	// +kubebuilder:default=Warn
+	DriftRemediation HelmDriftRemediation `json:"driftRemediation,omitempty"`
 }
 
+type HelmDriftRemediation string
+
+const (
+	// HelmDriftWarn reports drifts of releases only.
+	HelmDriftWarn HelmDriftRemediation = "Warn"
+
+	// HelmDriftUpgrade upgrades releases with drifts to revert them.
+	HelmDriftUpgrade HelmDriftRemediation = "Upgrade"
+)
+
 // +kubebuilder:object:root=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -138,6 +220,11 @@ type HelmReleaseSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Type=string
 	TargetNamespace string `json:"targetNamespace"`
+
+	// Overrides holds the Helm values merged from Globalizations and Localizations, in JSON format.
+	//
+	// +optional
+	Overrides []byte `json:"overrides,omitempty"`
 }
 
 // HelmReleaseStatus defines the observed state of HelmRelease
@@ -169,6 +256,54 @@ type HelmReleaseStatus struct {
 	//
 	// +optional
 	Version int `json:"version,omitempty"`
+
+	// Hooks shows the last runs of the hooks of the release, including tests.
+	//
+	// +optional
+	Hooks []HelmHookStatus `json:"hooks,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this HelmRelease.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the HelmRelease's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// HelmHookStatus shows the last run of a hook.
+type HelmHookStatus struct {
+	// Name is the name of the hook object.
+	Name string `json:"name"`
+
+	// Kind is the kind of the hook object.
+	//
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Events are the lifecycle events that the hook runs on, such as pre-install and test.
+	//
+	// +optional
+	Events []string `json:"events,omitempty"`
+
+	// Phase is the phase of the last run, which is one of Unknown, Running, Succeeded and Failed.
+	//
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// StartedAt is when the last run started.
+	//
+	// +optional
+	StartedAt string `json:"startedAt,omitempty"`
+
+	// CompletedAt is when the last run completed.
+	//
+	// +optional
+	CompletedAt string `json:"completedAt,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make generated" to regenerate code after modifying this file
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope="Namespaced",shortName=ks,categories=clusternet
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the kustomization is rendered"
+// +kubebuilder:printcolumn:name="REVISION",type=string,JSONPath=`.status.revision`,description="The revision of the rendered resources"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Kustomization renders a kustomize overlay into Manifests, which can then be used as a feed in Subscriptions.
+type Kustomization struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KustomizationSpec   `json:"spec"`
+	Status KustomizationStatus `json:"status,omitempty"`
+}
+
+// KustomizationSpec defines the desired state of Kustomization
+type KustomizationSpec struct {
+	// Sources holds the files that make up the kustomize overlay and its bases.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Sources []KustomizationSource `json:"sources"`
+
+	// Path is the directory of the kustomization.yaml to build, relative to the root of all the Sources.
+	//
+	// +optional
+	// +kubebuilder:default="."
+	Path string `json:"path,omitempty"`
+}
+
+// KustomizationSource populates a directory with the data of a ConfigMap.
+type KustomizationSource struct {
+	// ConfigMap is the name of a ConfigMap in the same namespace,
+	// each key of which is written as a file with the value as its content.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	ConfigMap string `json:"configMap"`
+
+	// Path is the directory the files of the ConfigMap are written to, relative to the root of all the Sources.
+	//
+	// +optional
+	// +kubebuilder:default="."
+	Path string `json:"path,omitempty"`
+}
+
+// KustomizationStatus defines the observed state of Kustomization
+type KustomizationStatus struct {
+	// ObservedGeneration is the most recent generation observed for this Kustomization.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Revision is the hash of the last successfully rendered resources.
+	//
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Resources is the number of the last successfully rendered resources.
+	//
+	// +optional
+	Resources int32 `json:"resources,omitempty"`
+
+	// Conditions represent the latest available observations of the Kustomization's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// KustomizationReady means the kustomization has been rendered into Manifests.
+	KustomizationReady = "Ready"
+)
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KustomizationList contains a list of Kustomization
+type KustomizationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Kustomization `json:"items"`
+}
@@ -50,7 +50,9 @@ type LocalizationSpec struct {
 	Overrides []OverrideConfig `json:"overrides,omitempty"`
 
 	// Priority is an integer defining the relative importance of this Localization compared to others. Lower
-	// numbers are considered lower priority.
+	// numbers are considered lower priority. Localizations are applied in ascending order of priority after all
+	// the Globalizations, so the ones with higher priority take precedence. Localizations with the same priority
+	// are applied in the order of creation, then names.
 	//
 	// +optional
 	// +kubebuilder:validation:Maximum=1000
@@ -90,8 +92,11 @@ const (
 	// Note: MergePatchType does not work with HelmChart(s).
 	MergePatchType OverrideType = "MergePatch"
 
-	// StrategicMergePatchType won't be supported, since `patchStrategy`
-	// and `patchMergeKey` can not be retrieved.
+	// StrategicMergePatchType applies a strategic merge patch for all matched objects, such as merging
+	// containers by their names. The patch strategies are only known for built-in kinds, so objects of
+	// other kinds are patched as a json merge patch instead.
+	// Note: StrategicMergePatchType does not work with HelmChart(s).
+	StrategicMergePatchType OverrideType = "StrategicMergePatch"
 )
 
 // OverrideConfig holds information that describes a override config.
@@ -113,7 +118,7 @@ type OverrideConfig struct {
 	// +required
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Type=string
-	// +kubebuilder:validation:Enum=Helm;JSONPatch;MergePatch
+	// +kubebuilder:validation:Enum=Helm;JSONPatch;MergePatch;StrategicMergePatch
 	Type OverrideType `json:"type"`
 }
 
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make generated" to regenerate code after modifying this file
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope="Namespaced",shortName=ocirepo,categories=clusternet
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`,description="The url of the OCI repository"
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`,description="Whether the manifests are synced"
+// +kubebuilder:printcolumn:name="REVISION",type=string,JSONPath=`.status.revision`,description="The revision of the synced manifests"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// OCIRepository pulls the manifests in an OCI artifact, such as one pushed with ORAS, into Manifests,
+// which can then be used as a feed in Subscriptions.
+type OCIRepository struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OCIRepositorySpec   `json:"spec"`
+	Status OCIRepositoryStatus `json:"status,omitempty"`
+}
+
+// OCIRepositorySpec defines the desired state of OCIRepository
+type OCIRepositorySpec struct {
+	// URL is the url of the OCI repository without a tag or digest, such as "oci://ghcr.io/org/manifests".
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^oci://"
+	URL string `json:"url"`
+
+	// Reference specifies the artifact to pull. Defaults to the tag "latest".
+	//
+	// +optional
+	Reference *OCIRepositoryRef `json:"ref,omitempty"`
+
+	// SecretRef refers to a Secret in the same namespace with the "username" and "password" of the registry.
+	//
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Insecure allows accessing the registry with plain http.
+	//
+	// +optional
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Verify verifies the cosign signatures of the artifact before its manifests are synced.
+	//
+	// +optional
+	Verify *SignatureVerification `json:"verify,omitempty"`
+
+	// Path is the directory of the manifests in the artifact. A directory with a kustomization.yaml
+	// is built with kustomize, otherwise all the YAML and JSON files under it are used.
+	//
+	// +optional
+	// +kubebuilder:default="."
+	Path string `json:"path,omitempty"`
+
+	// Interval is how often the OCI repository is polled for new artifacts.
+	//
+	// +optional
+	// +kubebuilder:default="5m"
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// OCIRepositoryRef specifies the artifact to pull, in the order of precedence of Digest, SemVer and Tag.
+type OCIRepositoryRef struct {
+	// Tag is the tag of the artifact to pull.
+	//
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// SemVer is a semver range, and the tag of the highest version in the range is pulled.
+	//
+	// +optional
+	SemVer string `json:"semver,omitempty"`
+
+	// Digest pins the manifest digest of the artifact to pull, such as "sha256:<hex>".
+	// The pulled content is verified against it.
+	//
+	// +optional
+	// +kubebuilder:validation:Pattern="^sha256:[a-f0-9]{64}$"
+	Digest string `json:"digest,omitempty"`
+}
+
+// OCIRepositoryStatus defines the observed state of OCIRepository
+type OCIRepositoryStatus struct {
+	// ObservedGeneration is the most recent generation observed for this OCIRepository.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Revision is the reference and manifest digest of the last synced artifact, such as "latest/sha256:<hex>".
+	//
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Resources is the number of the last synced resources.
+	//
+	// +optional
+	Resources int32 `json:"resources,omitempty"`
+
+	// Conditions represent the latest available observations of the OCIRepository's state, such as "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+const (
+	// OCIRepositoryReady means the manifests of the latest artifact have been synced into Manifests.
+	OCIRepositoryReady = "Ready"
+)
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OCIRepositoryList contains a list of OCIRepository
+type OCIRepositoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OCIRepository `json:"items"`
+}
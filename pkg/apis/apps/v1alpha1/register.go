@@ -67,6 +67,18 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&GlobalizationList{},
 		&Manifest{},
 		&ManifestList{},
+		&ResidencyPolicy{},
+		&ResidencyPolicyList{},
+		&Tenant{},
+		&TenantList{},
+		&Kustomization{},
+		&KustomizationList{},
+		&GitRepository{},
+		&GitRepositoryList{},
+		&OCIRepository{},
+		&OCIRepositoryList{},
+		&StatusAggregation{},
+		&StatusAggregationList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
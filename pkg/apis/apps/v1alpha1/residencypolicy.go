@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make generated" to regenerate code after modifying this file
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope="Cluster",shortName=residency,categories=clusternet
+// +kubebuilder:printcolumn:name="RESIDENCY",type=string,JSONPath=".spec.residency"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ResidencyPolicy represents the cluster-scoped data residency requirement for a group of resources.
+// Feeds referred by a ResidencyPolicy will only be distributed to clusters located in the allowed regions.
+type ResidencyPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ResidencyPolicySpec `json:"spec"`
+}
+
+// ResidencyPolicySpec defines the desired state of ResidencyPolicy
+type ResidencyPolicySpec struct {
+	// Residency is the name of the data residency requirement, such as "eu-only".
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	Residency string `json:"residency"`
+
+	// Regions are the regions that the feeds are allowed to reside in.
+	// The region of a cluster is read from its label "topology.kubernetes.io/region".
+	// Clusters without this label never satisfy any ResidencyPolicy.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Regions []string `json:"regions"`
+
+	// Feeds holds references to the objects the ResidencyPolicy applies to.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Feeds []Feed `json:"feeds"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ResidencyPolicyList contains a list of ResidencyPolicy
+type ResidencyPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ResidencyPolicy `json:"items"`
+}
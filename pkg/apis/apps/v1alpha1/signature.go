@@ -0,0 +1,61 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SignatureVerification verifies the cosign signatures of an OCI artifact before it is deployed.
+// Signatures signed with a key pair are verified against the public key, otherwise keyless signatures
+// are verified against the certificates of Fulcio, the transparency log of Rekor and the Identities.
+type SignatureVerification struct {
+	// SecretRef refers to a Secret in the same namespace with the trusted materials of the signatures.
+	// Key "cosign.pub" holds the PEM-encoded public key of the key pair. For keyless signatures,
+	// key "fulcio.crt" holds the PEM-encoded root certificates of Fulcio, and key "rekor.pub" holds
+	// the PEM-encoded public key of Rekor.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+
+	// Identities are the accepted signers of keyless signatures, and a signature is accepted if its
+	// certificate matches any of them. It is required for keyless signatures.
+	//
+	// +optional
+	Identities []SignatureIdentity `json:"identities,omitempty"`
+}
+
+// SignatureIdentity matches the certificate of a keyless signature
+type SignatureIdentity struct {
+	// Issuer is the OIDC issuer of the certificate, such as "https://token.actions.githubusercontent.com".
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Issuer string `json:"issuer"`
+
+	// Subject is a regular expression matching the whole email or URI in the certificate, such as
+	// "https://github.com/org/repo/.github/workflows/release.yaml@refs/tags/.*".
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Subject string `json:"subject"`
+}
+
+// SignatureVerificationFailed is the reason of condition Ready of HelmCharts and OCIRepositories,
+// when their signatures fail to be verified.
+const SignatureVerificationFailed = "VerificationFailed"
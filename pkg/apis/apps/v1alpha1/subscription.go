@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,6 +27,8 @@ import (
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope="Namespaced",shortName=sub;subs,categories=clusternet
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=".status.conditions[?(@.type=='Ready')].status"
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // Subscription represents the policy that install a group of resources to one or more clusters.
@@ -57,8 +60,340 @@ type SubscriptionSpec struct {
 	// +required
 	// +kubebuilder:validation:Required
 	Feeds []Feed `json:"feeds"`
+
+	// StartTime is the time when the Subscription gets activated.
+	// If not specified, the Subscription will be activated immediately.
+	//
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// TTL is the duration that the Subscription keeps active since activated.
+	// Once expired, all the resources distributed by this Subscription will be removed from child clusters.
+	// If not specified, the Subscription never expires.
+	//
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// JobsCleanup specifies how to clean up Jobs after they finish in child clusters,
+	// which only takes effect on Descriptions consisting solely of Jobs.
+	// If not specified, finished Jobs are kept in child clusters.
+	//
+	// +optional
+	JobsCleanup *JobsCleanupPolicy `json:"jobsCleanup,omitempty"`
+
+	// Tolerations of the Subscription, which tolerate the taints of ManagedClusters.
+	// Clusters with untolerated NoSchedule taints won't be newly selected, while the ones with
+	// untolerated NoExecute taints get the distributed resources removed.
+	//
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// SchedulingStrategy decides how the feeds are scheduled to the matching clusters.
+	// "Replication" deploys a full copy of the feeds to every cluster, while "Dividing" splits the replicas
+	// of the workloads in the feeds across clusters.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Replication;Dividing
+	// +kubebuilder:default=Replication
+	SchedulingStrategy SchedulingStrategyType `json:"schedulingStrategy,omitempty"`
+
+	// DividingScheduling describes how to divide the replicas, which only takes effect with
+	// the Dividing scheduling strategy.
+	//
+	// +optional
+	DividingScheduling *DividingScheduling `json:"dividingScheduling,omitempty"`
+
+	// TopologySpreadConstraints describe how the selected clusters are spread across topology domains,
+	// such as regions and zones, which are read from the labels of ManagedClusters.
+	// If not specified, clusters in less crowded regions are preferred.
+	//
+	// +optional
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Priority of the Subscription. When a cluster has no resources left, the Subscription could preempt
+	// the ones with lower priorities in the cluster, whose resources get removed from the cluster.
+	//
+	// +optional
+	// +kubebuilder:default=0
+	Priority int32 `json:"priority,omitempty"`
+
+	// PreemptionPolicy decides whether the Subscription could preempt the ones with lower priorities.
+	// "PreemptLowerPriority" allows preemption, while "Never" doesn't.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=PreemptLowerPriority;Never
+	// +kubebuilder:default=PreemptLowerPriority
+	PreemptionPolicy PreemptionPolicy `json:"preemptionPolicy,omitempty"`
+
+	// RolloutStrategy updates the clusters in batches when the feeds change, and a batch only starts after
+	// all the clusters updated in previous batches are ready. Newly selected clusters always get the latest feeds.
+	// If not specified, all the clusters are updated at the same time.
+	//
+	// +optional
+	RolloutStrategy *RolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// RevisionHistoryLimit is the number of old revisions of the feeds kept to allow rolling back,
+	// with annotation "apps.clusternet.io/rollback-to" set to a revision. Defaults to 10.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Approval holds the changes of the Descriptions until they get approved. Descriptions are created or updated
+	// in phase "Pending" with a summary of their changes, which are applied to the clusters only after condition
+	// "Approved" of the Descriptions is set to True for their latest generations, either manually or by a webhook.
+	// If not specified, changes are applied immediately.
+	//
+	// +optional
+	Approval *ApprovalStrategy `json:"approval,omitempty"`
+
+	// Prune decides what happens to the objects removed from the feeds, which are deleted from child clusters
+	// by default.
+	//
+	// +optional
+	Prune *PruneStrategy `json:"prune,omitempty"`
+
+	// DeletionPolicy decides how the distributed resources are removed from child clusters when the Descriptions
+	// get deleted, such as deleting the Subscription or the clusters being unselected. "Background" and "Foreground"
+	// delete the objects with the same propagation policy, while "Orphan" leaves them in child clusters.
+	// Defaults to "Background".
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Background;Foreground;Orphan
+	DeletionPolicy *metav1.DeletionPropagation `json:"deletionPolicy,omitempty"`
+}
+
+type SchedulingStrategyType string
+
+const (
+	// ReplicationSchedulingStrategyType deploys a full copy of the feeds to every matching cluster.
+	ReplicationSchedulingStrategyType SchedulingStrategyType = "Replication"
+	// DividingSchedulingStrategyType splits the replicas of the workloads across the matching clusters.
+	DividingSchedulingStrategyType SchedulingStrategyType = "Dividing"
+)
+
+// DividingScheduling describes how to divide the replicas of the workloads across clusters.
+type DividingScheduling struct {
+	// Type of dividing scheduling. "Static" divides the replicas by the weights of subscribers, while "Dynamic"
+	// divides the replicas by the available resources of clusters, which are re-divided periodically.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Static;Dynamic
+	// +kubebuilder:default=Static
+	Type DividingSchedulingType `json:"type,omitempty"`
+}
+
+type DividingSchedulingType string
+
+const (
+	// StaticDividingSchedulingType divides the replicas by the static weights of subscribers.
+	StaticDividingSchedulingType DividingSchedulingType = "Static"
+	// DynamicDividingSchedulingType divides the replicas by the available resources of clusters,
+	// i.e. how many replicas each cluster could hold.
+	DynamicDividingSchedulingType DividingSchedulingType = "Dynamic"
+)
+
+// ApprovalStrategy describes how the changes of Descriptions get approved.
+type ApprovalStrategy struct {
+	// Webhook is the url where the changes of pending Descriptions are posted to, which approves the changes
+	// by responding with {"approved": true}. Otherwise, the changes are left to be approved manually.
+	//
+	// +optional
+	Webhook string `json:"webhook,omitempty"`
+}
+
+// PruneStrategy describes how to handle the objects removed from the feeds.
+type PruneStrategy struct {
+	// Policy of pruning. "Delete" deletes the objects removed from the feeds from child clusters,
+	// while "Orphan" leaves them in child clusters unmanaged.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +kubebuilder:default=Delete
+	Policy PrunePolicy `json:"policy,omitempty"`
+
+	// PropagationPolicy is used when deleting the pruned objects from child clusters,
+	// which decides how their dependents are garbage collected. Defaults to "Background".
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Background;Foreground;Orphan
+	PropagationPolicy *metav1.DeletionPropagation `json:"propagationPolicy,omitempty"`
+}
+
+type PrunePolicy string
+
+const (
+	// PruneDelete deletes the objects removed from the feeds from child clusters.
+	PruneDelete PrunePolicy = "Delete"
+	// PruneOrphan leaves the objects removed from the feeds in child clusters.
+	PruneOrphan PrunePolicy = "Orphan"
+)
+
+// RolloutStrategy describes how to roll out changes of the feeds to clusters.
+type RolloutStrategy struct {
+	// MaxConcurrentClusters is the maximum number of clusters updated in a batch.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	MaxConcurrentClusters int32 `json:"maxConcurrentClusters,omitempty"`
+
+	// OrderByLabel is a key of ManagedCluster labels, by whose values the clusters are updated in ascending order.
+	// Clusters without this label are updated last. Clusters are ordered by their names otherwise.
+	//
+	// +optional
+	OrderByLabel string `json:"orderByLabel,omitempty"`
+
+	// PauseBetweenBatches is how long to wait before starting the next batch
+	// after all the clusters in previous batches are ready.
+	//
+	// +optional
+	PauseBetweenBatches *metav1.Duration `json:"pauseBetweenBatches,omitempty"`
+
+	// AutoPromote starts the next batch automatically once the previous ones are ready, which defaults to true.
+	// If false, the rollout pauses after each batch, until it is promoted by setting annotation
+	// "apps.clusternet.io/rollout-promote" of the Subscription to the number of batches allowed.
+	//
+	// +optional
+	// +kubebuilder:default=true
+	AutoPromote *bool `json:"autoPromote,omitempty"`
+
+	// Analysis checks the metrics of the updated clusters before starting the next batch
+	// and completing the rollout.
+	//
+	// +optional
+	Analysis *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// RolloutAnalysis describes the checks on the updated clusters during a rollout.
+// The updated clusters are always required to be ready with the feedback status of their Descriptions.
+type RolloutAnalysis struct {
+	// Metrics are queried from the Prometheus specified by flag "--rollout-prometheus-address" of clusternet-hub.
+	//
+	// +optional
+	Metrics []RolloutMetric `json:"metrics,omitempty"`
+
+	// FailurePolicy decides what to do when a metric is out of its thresholds.
+	// "Pause" keeps the rollout paused until the metrics recover or the rollout is promoted,
+	// while "Rollback" reverts the updated clusters to the last completed revision.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Pause;Rollback
+	// +kubebuilder:default=Pause
+	FailurePolicy RolloutFailurePolicy `json:"failurePolicy,omitempty"`
 }
 
+// RolloutMetric is a Prometheus query and the thresholds of its results.
+type RolloutMetric struct {
+	// Name of the metric.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Query is a PromQL expression, where "{{clusters}}" is replaced with a regular expression
+	// matching the names of the updated clusters, such as
+	// sum(rate(http_requests_total{code=~"5..",cluster=~"{{clusters}}"}[5m])).
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	Query string `json:"query"`
+
+	// Min is the minimum value allowed for every result of the query, such as "0.99".
+	//
+	// +optional
+	Min string `json:"min,omitempty"`
+
+	// Max is the maximum value allowed for every result of the query, such as "0.05".
+	//
+	// +optional
+	Max string `json:"max,omitempty"`
+}
+
+type RolloutFailurePolicy string
+
+const (
+	// RolloutFailurePause pauses the rollout when the analysis fails.
+	RolloutFailurePause RolloutFailurePolicy = "Pause"
+	// RolloutFailureRollback rolls back the updated clusters when the analysis fails.
+	RolloutFailureRollback RolloutFailurePolicy = "Rollback"
+)
+
+type PreemptionPolicy string
+
+const (
+	// PreemptLowerPriority allows the Subscription to preempt the ones with lower priorities.
+	PreemptLowerPriority PreemptionPolicy = "PreemptLowerPriority"
+	// PreemptNever never preempts other Subscriptions.
+	PreemptNever PreemptionPolicy = "Never"
+)
+
+// TopologySpreadConstraint specifies how to spread the selected clusters among the given topology.
+type TopologySpreadConstraint struct {
+	// MaxSkew is the maximum permitted difference between the numbers of selected clusters
+	// in any two topology domains.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MaxSkew int32 `json:"maxSkew"`
+
+	// TopologyKey is the key of ManagedCluster labels. Clusters with the same value of this label
+	// are in the same topology domain.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	TopologyKey string `json:"topologyKey"`
+
+	// WhenUnsatisfiable decides what to do with the clusters violating the constraint.
+	// "DoNotSchedule" skips the clusters exceeding maxSkew in crowded domains, as well as the clusters without
+	// the topology key, while "ScheduleAnyway" only prefers the clusters in less crowded domains.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:default=DoNotSchedule
+	WhenUnsatisfiable UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+type UnsatisfiableConstraintAction string
+
+const (
+	// DoNotSchedule skips the clusters violating the constraint.
+	DoNotSchedule UnsatisfiableConstraintAction = "DoNotSchedule"
+	// ScheduleAnyway selects the clusters violating the constraint, but prefers the ones in less crowded domains.
+	ScheduleAnyway UnsatisfiableConstraintAction = "ScheduleAnyway"
+)
+
+// JobsCleanupPolicy defines the cleanup of finished Jobs, just like ttlSecondsAfterFinished of Jobs.
+type JobsCleanupPolicy struct {
+	// TTLSecondsAfterFinished is the number of seconds to wait after all the Jobs in a cluster
+	// complete or fail, before these Jobs get removed from the cluster.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished int32 `json:"ttlSecondsAfterFinished"`
+
+	// DescriptionPolicy specifies what to do with the Description after the Jobs get removed.
+	// "Retain" keeps the Description, while "Delete" deletes it as well.
+	// Either way, the Jobs won't be deployed again until the Subscription changes.
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default=Retain
+	DescriptionPolicy DescriptionCleanupPolicy `json:"descriptionPolicy,omitempty"`
+}
+
+type DescriptionCleanupPolicy string
+
+const (
+	// DescriptionCleanupRetain keeps the Description after the Jobs get removed.
+	DescriptionCleanupRetain DescriptionCleanupPolicy = "Retain"
+	// DescriptionCleanupDelete deletes the Description after the Jobs get removed.
+	DescriptionCleanupDelete DescriptionCleanupPolicy = "Delete"
+)
+
 // SubscriptionStatus defines the observed state of Subscription
 type SubscriptionStatus struct {
 	// Total number of Helm releases desired by this Subscription.
@@ -70,8 +405,157 @@ type SubscriptionStatus struct {
 	//
 	// +optional
 	CompletedReleases int32 `json:"completedReleases,omitempty"`
+
+	// Phase denotes the phase of Subscription
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Pending;Active;Expired
+	Phase SubscriptionPhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this Subscription.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the Subscription's state,
+	// such as "Scheduled", "ResidencySatisfied" and "Ready".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ClusterReadiness is the number of matching clusters in each readiness state,
+	// which are "Ready", "Pending", "Failed" and "OverridesFailed".
+	//
+	// +optional
+	ClusterReadiness map[string]int32 `json:"clusterReadiness,omitempty"`
+
+	// FeedsRevision is the revision of current content of the feeds in the revision history.
+	//
+	// +optional
+	FeedsRevision int64 `json:"feedsRevision,omitempty"`
+
+	// Rollout is the progress of rolling out the latest feeds, which is only set with a RolloutStrategy.
+	//
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
 }
 
+// RolloutStatus is the progress of rolling out a revision of the feeds.
+type RolloutStatus struct {
+	// Revision is the hash of the feeds being rolled out.
+	//
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// StableRevision is the last revision that is rolled out to all the clusters.
+	//
+	// +optional
+	StableRevision string `json:"stableRevision,omitempty"`
+
+	// Phase of the rollout, which is "Progressing", "Paused", "Completed" or "RolledBack".
+	//
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// Batches is the number of batches started.
+	//
+	// +optional
+	Batches int32 `json:"batches,omitempty"`
+
+	// UpdatedClusters is the number of clusters that are ready with the revision.
+	//
+	// +optional
+	UpdatedClusters int32 `json:"updatedClusters,omitempty"`
+
+	// TotalClusters is the number of matching clusters.
+	//
+	// +optional
+	TotalClusters int32 `json:"totalClusters,omitempty"`
+
+	// LastBatchTime is the time when the latest batch started.
+	//
+	// +optional
+	LastBatchTime *metav1.Time `json:"lastBatchTime,omitempty"`
+
+	// Message is a human-readable message about the rollout.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+type RolloutPhase string
+
+const (
+	// RolloutProgressing means clusters are being updated in batches.
+	RolloutProgressing RolloutPhase = "Progressing"
+	// RolloutPaused means the rollout is waiting for a promotion or the pause between batches.
+	RolloutPaused RolloutPhase = "Paused"
+	// RolloutCompleted means all the clusters are ready with the revision.
+	RolloutCompleted RolloutPhase = "Completed"
+	// RolloutRolledBack means the updated clusters are reverted to the stable revision for failed analysis.
+	RolloutRolledBack RolloutPhase = "RolledBack"
+)
+
+type SubscriptionPhase string
+
+const (
+	// SubscriptionPending means the Subscription is waiting for its start time.
+	SubscriptionPending SubscriptionPhase = "Pending"
+	// SubscriptionActive means the resources are distributed to matching clusters.
+	SubscriptionActive SubscriptionPhase = "Active"
+	// SubscriptionExpired means the Subscription exceeds its TTL,
+	// and all the distributed resources have been removed.
+	SubscriptionExpired SubscriptionPhase = "Expired"
+)
+
+const (
+	// SubscriptionScheduled means the feeds have been scheduled to all the matching clusters.
+	SubscriptionScheduled = "Scheduled"
+
+	// SubscriptionResidencySatisfied means all the matching clusters satisfy the ResidencyPolicies
+	// of the feeds. Clusters that violate any ResidencyPolicy are skipped.
+	SubscriptionResidencySatisfied = "ResidencySatisfied"
+
+	// SubscriptionTenantQuotaSatisfied means the Subscription fits in the quota of its Tenant.
+	// Otherwise, the Subscription is not scheduled, and the current placement is kept.
+	SubscriptionTenantQuotaSatisfied = "TenantQuotaSatisfied"
+
+	// SubscriptionFeedsVerified means the signatures of all the feeds requiring verification are verified.
+	// The Subscription is not scheduled if any of them fails.
+	SubscriptionFeedsVerified = "FeedsVerified"
+
+	// SubscriptionReady rolls up the readiness of all the matching clusters. It is True only when the Subscription
+	// is scheduled and the Descriptions in all the matching clusters are deployed successfully.
+	SubscriptionReady = "Ready"
+)
+
+// readiness states of matching clusters, as well as reasons of condition Ready
+const (
+	// ClusterReadinessReady means all the Descriptions in the cluster are deployed successfully.
+	ClusterReadinessReady = "Ready"
+	// ClusterReadinessPending means the Descriptions in the cluster are not created or deployed yet.
+	ClusterReadinessPending = "Pending"
+	// ClusterReadinessFailed means some Descriptions in the cluster fail to be deployed.
+	ClusterReadinessFailed = "Failed"
+	// ClusterReadinessOverridesFailed means the overrides fail to be applied to the Descriptions of the cluster.
+	ClusterReadinessOverridesFailed = "OverridesFailed"
+
+	// SubscriptionAllClustersReady is the reason of condition Ready when all the matching clusters are ready.
+	SubscriptionAllClustersReady = "AllClustersReady"
+	// SubscriptionClustersPending is the reason of condition Ready when some clusters are pending.
+	SubscriptionClustersPending = "ClustersPending"
+	// SubscriptionClustersFailed is the reason of condition Ready when some clusters fail to be deployed.
+	SubscriptionClustersFailed = "ClustersFailed"
+	// SubscriptionOverridesFailed is the reason of condition Ready when overrides fail to be applied for some clusters.
+	SubscriptionOverridesFailed = "OverridesFailed"
+	// SubscriptionNoMatchingClusters is the reason of condition Ready when no clusters get matched.
+	SubscriptionNoMatchingClusters = "NoMatchingClusters"
+	// SubscriptionNotScheduled is the reason of condition Ready when the Subscription is not scheduled.
+	SubscriptionNotScheduled = "NotScheduled"
+)
+
 // Subscriber defines
 type Subscriber struct {
 	// ClusterAffinity is a label query over managed clusters by labels.
@@ -79,6 +563,14 @@ type Subscriber struct {
 	// +required
 	// +kubebuilder:validation:Required
 	ClusterAffinity *metav1.LabelSelector `json:"clusterAffinity"`
+
+	// Weight of the clusters matched by this subscriber, which decides the share of replicas of each cluster
+	// with the Static dividing scheduling. Clusters with weight 0 get no replicas, unless no subscribers
+	// have weights, in which case the replicas are divided evenly.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // Feed defines the resource to be selected.
@@ -109,6 +601,60 @@ type Feed struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Type=string
 	Name string `json:"name"`
+
+	// DependsOn declares the objects that should be ready before deploying this feed, such as deploying
+	// the app Deployment only after the DB StatefulSet is ready, either in the same cluster or in other clusters.
+	// This only takes effect in Subscriptions.
+	//
+	// +optional
+	DependsOn []FeedDependency `json:"dependsOn,omitempty"`
+}
+
+// FeedDependency refers to an object that should be ready first, which is a feed in the same Subscription
+// by default, or an object deployed to other clusters with ClusterSelector set.
+type FeedDependency struct {
+	// Kind is a string value representing the REST resource this object represents.
+	// In CamelCase.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	Kind string `json:"kind"`
+
+	// APIVersion defines the versioned schema of this representation of an object.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	APIVersion string `json:"apiVersion"`
+
+	// Namespace of the target resource.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the target resource.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Type=string
+	Name string `json:"name"`
+
+	// ConditionType is the type of the status condition that should be True on the depended object.
+	// If not set, the readiness is evaluated by kind, such as all the replicas being ready for
+	// Deployments and StatefulSets, or a Ready condition for other kinds.
+	// It is ignored for dependencies in other clusters.
+	//
+	// +optional
+	ConditionType string `json:"conditionType,omitempty"`
+
+	// ClusterSelector selects the clusters where the depended object should be ready, such as deploying
+	// the app only after the database in another cluster is ready. Its readiness is taken from the feedback
+	// reported by clusternet-agent with feature gate ResourceFeedback, and it is not ready until being reported
+	// healthy in all the matching clusters. If not set, the object should be ready in the same cluster.
+	//
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
 }
 
 // +kubebuilder:object:root=true
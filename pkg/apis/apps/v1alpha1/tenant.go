@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Important: Run "make generated" to regenerate code after modifying this file
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope="Cluster",categories=clusternet
+// +kubebuilder:printcolumn:name="NAMESPACES",type=string,JSONPath=".spec.namespaces"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Tenant isolates the clusters used by the Subscriptions in a group of namespaces.
+// Subscriptions in the namespaces of a Tenant are only scheduled to the clusters owned by the Tenant,
+// and each of them is limited by the quota of the Tenant.
+type Tenant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TenantSpec `json:"spec"`
+}
+
+// TenantSpec defines the desired state of Tenant
+type TenantSpec struct {
+	// Namespaces owned by the Tenant. A namespace belonging to several Tenants could use the clusters of all of
+	// them, and is limited by the most restrictive quota. Subscriptions in namespaces not belonging to any Tenant
+	// are not restricted.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	Namespaces []string `json:"namespaces"`
+
+	// ClusterSelector selects the ManagedClusters owned by the Tenant with their labels.
+	// An empty selector selects all the clusters.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+
+	// Quota limits what a single Subscription of the Tenant could consume.
+	// If not specified, Subscriptions are not limited.
+	//
+	// +optional
+	Quota *TenantQuota `json:"quota,omitempty"`
+}
+
+// TenantQuota limits what a single Subscription could consume.
+type TenantQuota struct {
+	// MaxClustersPerSubscription is the max number of clusters that a Subscription could be scheduled to.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxClustersPerSubscription *int32 `json:"maxClustersPerSubscription,omitempty"`
+
+	// MaxReplicasPerSubscription is the max number of replicas of the workloads in a Subscription, summed up
+	// across all the clusters it is scheduled to.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxReplicasPerSubscription *int32 `json:"maxReplicasPerSubscription,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// TenantList contains a list of Tenant
+type TenantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Tenant `json:"items"`
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AgentUpgradePlanSpec defines the desired state of AgentUpgradePlan
+type AgentUpgradePlanSpec struct {
+	// Image is the target image of clusternet-agent, such as "ghcr.io/clusternet/clusternet-agent:v0.5.0".
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// ClusterSelector selects the ManagedClusters to upgrade. All the clusters are selected if it is empty.
+	//
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// BatchSize is the number of clusters upgraded at the same time.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// HealthCheckTimeout is how long to wait for an upgraded agent to be available and report heartbeats,
+	// after which the whole batch will be rolled back.
+	//
+	// +optional
+	// +kubebuilder:default="10m"
+	HealthCheckTimeout metav1.Duration `json:"healthCheckTimeout,omitempty"`
+
+	// AgentNamespace is the namespace of clusternet-agent in child clusters.
+	//
+	// +optional
+	// +kubebuilder:default=clusternet-system
+	AgentNamespace string `json:"agentNamespace,omitempty"`
+
+	// AgentDeployment is the name of the Deployment of clusternet-agent in child clusters.
+	// The image of its container with the same name will be upgraded.
+	//
+	// +optional
+	// +kubebuilder:default=clusternet-agent
+	AgentDeployment string `json:"agentDeployment,omitempty"`
+}
+
+// AgentUpgradePlanStatus defines the observed state of AgentUpgradePlan
+type AgentUpgradePlanStatus struct {
+	// Phase denotes the phase of AgentUpgradePlan
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Progressing;Completed;Failed
+	Phase AgentUpgradePlanPhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this AgentUpgradePlan.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Clusters tracks the upgrade state of every selected cluster, in the order of being upgraded.
+	//
+	// +optional
+	Clusters []ClusterUpgradeStatus `json:"clusters,omitempty"`
+
+	// Conditions represent the latest available observations of the AgentUpgradePlan's state.
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type AgentUpgradePlanPhase string
+
+const (
+	// AgentUpgradeProgressing means clusters are being upgraded batch by batch.
+	AgentUpgradeProgressing AgentUpgradePlanPhase = "Progressing"
+	// AgentUpgradeCompleted means all the selected clusters have been upgraded.
+	AgentUpgradeCompleted AgentUpgradePlanPhase = "Completed"
+	// AgentUpgradeFailed means a batch failed and got rolled back, and the remaining clusters are left untouched.
+	AgentUpgradeFailed AgentUpgradePlanPhase = "Failed"
+)
+
+const (
+	// AgentUpgradePlanCompleted means all the selected clusters have been upgraded successfully.
+	AgentUpgradePlanCompleted = "Completed"
+)
+
+// ClusterUpgradeStatus is the upgrade state of clusternet-agent in a cluster.
+type ClusterUpgradeStatus struct {
+	// ClusterID is the unique id of the cluster
+	//
+	// +required
+	ClusterID types.UID `json:"clusterID"`
+
+	// Namespace is the dedicated namespace of the cluster
+	//
+	// +required
+	Namespace string `json:"namespace"`
+
+	// Batch is the index of the batch that the cluster belongs to, starting from 0.
+	//
+	// +optional
+	Batch int32 `json:"batch,omitempty"`
+
+	// Phase denotes the upgrade phase of the cluster
+	//
+	// +optional
+	// +kubebuilder:validation:Enum=Pending;Upgrading;Succeeded;Failed;RolledBack
+	Phase ClusterUpgradePhase `json:"phase,omitempty"`
+
+	// PreviousImage is the image of clusternet-agent before upgrading, which is used for rolling back.
+	//
+	// +optional
+	PreviousImage string `json:"previousImage,omitempty"`
+
+	// Message is a human readable message indicating details about the phase.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the last time the phase transitioned.
+	//
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+type ClusterUpgradePhase string
+
+const (
+	ClusterUpgradePending    ClusterUpgradePhase = "Pending"
+	ClusterUpgradeUpgrading  ClusterUpgradePhase = "Upgrading"
+	ClusterUpgradeSucceeded  ClusterUpgradePhase = "Succeeded"
+	ClusterUpgradeFailed     ClusterUpgradePhase = "Failed"
+	ClusterUpgradeRolledBack ClusterUpgradePhase = "RolledBack"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope="Cluster",shortName=aup,categories=clusternet
+// +kubebuilder:printcolumn:name="IMAGE",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="PHASE",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="AGE",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AgentUpgradePlan upgrades clusternet-agent across the fleet in batches,
+// and rolls back a batch if any upgraded agent fails the health check.
+type AgentUpgradePlan struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AgentUpgradePlanSpec   `json:"spec"`
+	Status AgentUpgradePlanStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AgentUpgradePlanList contains a list of AgentUpgradePlan
+type AgentUpgradePlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AgentUpgradePlan `json:"items"`
+}
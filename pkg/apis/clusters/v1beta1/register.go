@@ -51,8 +51,12 @@ var (
 // Adds the list of known types to Scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&AgentUpgradePlan{},
+		&AgentUpgradePlanList{},
 		&ClusterRegistrationRequest{},
 		&ClusterRegistrationRequestList{},
+		&Grant{},
+		&GrantList{},
 		&ManagedCluster{},
 		&ManagedClusterList{},
 	)
@@ -49,6 +49,20 @@ const (
 	Dual ClusterSyncMode = "Dual"
 )
 
+type PodSecurityLevel string
+
+// These are the valid values for PodSecurityLevel, following the Kubernetes Pod Security Standards
+const (
+	// PodSecurityPrivileged is entirely unrestricted.
+	PodSecurityPrivileged PodSecurityLevel = "privileged"
+
+	// PodSecurityBaseline prevents known privilege escalations, such as privileged containers and host namespaces.
+	PodSecurityBaseline PodSecurityLevel = "baseline"
+
+	// PodSecurityRestricted follows the current pod hardening best practices, such as running as non-root.
+	PodSecurityRestricted PodSecurityLevel = "restricted"
+)
+
 // ClusterRegistrationRequestSpec defines the desired state of ClusterRegistrationRequest
 type ClusterRegistrationRequestSpec struct {
 	// ClusterID, a Random (Version 4) UUID, is a unique value in time and space value representing for child cluster.
@@ -120,6 +134,18 @@ type ClusterRegistrationRequestStatus struct {
 	//
 	// +optional
 	ManagedClusterName string `json:"managedClusterName,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed for this ClusterRegistrationRequest.
+	//
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations of the ClusterRegistrationRequest's state, such as "Approved".
+	//
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type ApprovedResult string
@@ -131,6 +157,31 @@ const (
 	RequestFailed   ApprovedResult = "Failed"
 )
 
+// These are valid conditions of a cluster.
+const (
+	// ClusterReady means the cluster is healthy and ready to run workloads,
+	// which is False if any of the other conditions is False.
+	ClusterReady = "Ready"
+
+	// ClusterNetworkReady means the network of all the ready nodes is correctly configured.
+	ClusterNetworkReady = "NetworkReady"
+
+	// ClusterAPIServerHealthy means the kube-apiserver is live and ready.
+	ClusterAPIServerHealthy = "APIServerHealthy"
+
+	// ClusterSchedulerHealthy means the kube-scheduler is healthy.
+	ClusterSchedulerHealthy = "SchedulerHealthy"
+
+	// ClusterControllerManagerHealthy means the kube-controller-manager is healthy.
+	ClusterControllerManagerHealthy = "ControllerManagerHealthy"
+)
+
+const (
+	// ClusterRegistrationRequestApproved means the request has been approved,
+	// and all the objects for registration are ready.
+	ClusterRegistrationRequestApproved = "Approved"
+)
+
 // +genclient
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -185,6 +236,23 @@ type ManagedClusterSpec struct {
 	// +kubebuilder:validation:Type=string
 	// +kubebuilder:validation:Enum=Push;Pull;Dual
 	SyncMode ClusterSyncMode `json:"syncMode"`
+
+	// Taints are attached to the cluster, which could be set by the operators or propagated by clusternet-agent.
+	//
+	// +optional
+	Taints []corev1.Taint `json:"taints,omitempty"`
+
+	// Unschedulable cordons the cluster, so that it won't be selected by Subscriptions any more, while the workloads
+	// already distributed are kept. It is the same as cordoning a node.
+	//
+	// +optional
+	Unschedulable bool `json:"unschedulable,omitempty"`
+
+	// Drain removes the workloads distributed to the cluster, unless the Subscriptions tolerate the draining taint,
+	// so that they get rescheduled to other clusters. A draining cluster is unschedulable as well.
+	//
+	// +optional
+	Drain bool `json:"drain,omitempty"`
 }
 
 // ManagedClusterStatus defines the observed state of ManagedCluster
@@ -206,19 +274,18 @@ type ManagedClusterStatus struct {
 	// +optional
 	APIServerURL string `json:"apiserverURL,omitempty"`
 
-	// Healthz indicates the healthz status of the cluster
-	// which is deprecated since Kubernetes v1.16. Please use Livez and Readyz instead.
-	// Leave it here only for compatibility.
-	// +optional
-	Healthz bool `json:"healthz,omitempty"`
-
-	// Livez indicates the livez status of the cluster
+	// ObservedGeneration is the most recent generation observed for this ManagedCluster.
+	//
 	// +optional
-	Livez bool `json:"livez,omitempty"`
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-	// Readyz indicates the readyz status of the cluster
+	// Conditions represent the latest available observations of the cluster's state,
+	// such as "Ready", "NetworkReady", "APIServerHealthy", "SchedulerHealthy" and "ControllerManagerHealthy".
+	//
 	// +optional
-	Readyz bool `json:"readyz,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 
 	// AppPusher indicates whether to allow parent cluster deploying applications in Push or Dual Mode.
 	// Mainly for security concerns.
@@ -243,6 +310,10 @@ type ManagedClusterStatus struct {
 	// +optional
 	Capacity corev1.ResourceList `json:"capacity,omitempty"`
 
+	// Available is the allocatable resources minus the sum of resource requests of pods in the cluster
+	// +optional
+	Available corev1.ResourceList `json:"available,omitempty"`
+
 	// ClusterCIDR is the CIDR range of the cluster
 	// +optional
 	ClusterCIDR string `json:"clusterCIDR,omitempty"`
@@ -254,6 +325,101 @@ type ManagedClusterStatus struct {
 	// NodeStatistics is the info summary of nodes in the cluster
 	// +optional
 	NodeStatistics NodeStatistics `json:"nodeStatistics,omitempty"`
+
+	// PodStatistics is the info summary of pods in the cluster
+	// +optional
+	PodStatistics PodStatistics `json:"podStatistics,omitempty"`
+
+	// NodePlatforms is the number of nodes per platform, such as "linux/amd64" and "linux/arm64"
+	// +optional
+	NodePlatforms map[string]int32 `json:"nodePlatforms,omitempty"`
+
+	// NodePools reports how fragmented the available resources are in each node pool,
+	// which helps telling whether a large pod can actually fit in the cluster.
+	// +optional
+	NodePools []NodePoolFragmentation `json:"nodePools,omitempty"`
+
+	// NodeUsage is the actual resource usage of nodes reported by metrics-server
+	// +optional
+	NodeUsage *NodeUsage `json:"nodeUsage,omitempty"`
+
+	// APIGroupVersions are the sorted group versions served by the cluster, such as "v1" and "apps/v1"
+	// +optional
+	APIGroupVersions []string `json:"apiGroupVersions,omitempty"`
+
+	// CRDs are the sorted names of CustomResourceDefinitions installed in the cluster, such as "foos.example.com"
+	// +optional
+	CRDs []string `json:"crds,omitempty"`
+
+	// Provider is the cloud provider of the cluster, which is derived from the providerID of nodes, such as "aws"
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Region is the region where most nodes of the cluster are located,
+	// which is derived from node label "topology.kubernetes.io/region"
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Zones are the sorted zones where nodes of the cluster are located,
+	// which are derived from node label "topology.kubernetes.io/zone"
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+
+	// PodSecurityLevel is the Pod Security admission level enforced in the cluster.
+	// Workloads violating this level will be rejected by the hub before being deployed.
+	// +optional
+	// +kubebuilder:validation:Enum=privileged;baseline;restricted
+	PodSecurityLevel PodSecurityLevel `json:"podSecurityLevel,omitempty"`
+
+	// Drift summarizes the objects deployed by Descriptions whose live state drifts from the desired state,
+	// which is reported when feature gate DriftReport is enabled in clusternet-agent.
+	// +optional
+	Drift *DriftSummary `json:"drift,omitempty"`
+}
+
+// DriftSummary summarizes the configuration drift of the objects deployed by Descriptions in a cluster.
+type DriftSummary struct {
+	// LastScanTime is the last time the objects were scanned.
+	LastScanTime metav1.Time `json:"lastScanTime"`
+
+	// ScannedObjects is the number of objects scanned.
+	ScannedObjects int32 `json:"scannedObjects"`
+
+	// DriftedObjects is the number of objects whose live state drifts from the desired state,
+	// including the missing ones.
+	DriftedObjects int32 `json:"driftedObjects"`
+
+	// Objects are the drifted objects, which are truncated to at most 50 items.
+	// +optional
+	Objects []ObjectDrift `json:"objects,omitempty"`
+}
+
+// ObjectDrift describes an object whose live state drifts from the desired state in a Description.
+type ObjectDrift struct {
+	// Description is the name of the Description deploying the object.
+	Description string `json:"description"`
+
+	// APIVersion of the object.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the object.
+	Kind string `json:"kind"`
+
+	// Namespace of the object.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object.
+	Name string `json:"name"`
+
+	// Missing means the object does not exist in the cluster.
+	// +optional
+	Missing bool `json:"missing,omitempty"`
+
+	// Fields are the paths of the drifted fields, such as "spec.replicas" and
+	// "spec.template.spec.containers[0].image", which are truncated to at most 10 items.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
 }
 
 // +genclient
@@ -264,7 +430,11 @@ type ManagedClusterStatus struct {
 // +kubebuilder:printcolumn:name="CLUSTER TYPE",type=string,JSONPath=`.spec.clusterType`,description="The type of the cluster",priority=100
 // +kubebuilder:printcolumn:name="SYNC MODE",type=string,JSONPath=`.spec.syncMode`,description="The cluster sync mode"
 // +kubebuilder:printcolumn:name="KUBERNETES",type=string,JSONPath=".status.k8sVersion"
-// +kubebuilder:printcolumn:name="READYZ",type=string,JSONPath=".status.readyz"
+// +kubebuilder:printcolumn:name="READY",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="RUNNING PODS",type=integer,JSONPath=`.status.podStatistics.runningPods`,description="The number of running pods",priority=100
+// +kubebuilder:printcolumn:name="PENDING PODS",type=integer,JSONPath=`.status.podStatistics.pendingPods`,description="The number of pending pods",priority=100
+// +kubebuilder:printcolumn:name="FAILED PODS",type=integer,JSONPath=`.status.podStatistics.failedPods`,description="The number of failed pods",priority=100
+// +kubebuilder:printcolumn:name="DRIFTED",type=integer,JSONPath=`.status.drift.driftedObjects`,description="The number of drifted objects",priority=100
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ManagedCluster is the Schema for the managedclusters API
@@ -286,6 +456,42 @@ type ManagedClusterList struct {
 	Items           []ManagedCluster `json:"items"`
 }
 
+// NodePoolFragmentation describes the fragmentation of available resources in a node pool
+type NodePoolFragmentation struct {
+	// Name is the name of the node pool
+	Name string `json:"name"`
+
+	// Nodes is the number of ready and schedulable nodes in the node pool
+	// +optional
+	Nodes int32 `json:"nodes,omitempty"`
+
+	// LargestSchedulablePod is the largest cpu and memory requests that a single pod can get on one node,
+	// which are the maximum available cpu and memory among all the nodes in the node pool
+	// +optional
+	LargestSchedulablePod corev1.ResourceList `json:"largestSchedulablePod,omitempty"`
+
+	// FragmentationScore ranges from 0 to 100. It is the larger one of cpu and memory on
+	// 100 * (1 - largest available on a single node / total available in the node pool).
+	// A higher score means the available resources are more scattered across nodes.
+	// +optional
+	FragmentationScore int32 `json:"fragmentationScore,omitempty"`
+}
+
+// NodeUsage describes the actual resource usage of nodes in the cluster
+type NodeUsage struct {
+	// Usage is the sum of actual cpu and memory usage of nodes
+	// +optional
+	Usage corev1.ResourceList `json:"usage,omitempty"`
+
+	// CPUPercentage is the percentage of actual cpu usage against allocatable cpu of nodes
+	// +optional
+	CPUPercentage int32 `json:"cpuPercentage,omitempty"`
+
+	// MemoryPercentage is the percentage of actual memory usage against allocatable memory of nodes
+	// +optional
+	MemoryPercentage int32 `json:"memoryPercentage,omitempty"`
+}
+
 type NodeStatistics struct {
 	// ReadyNodes is the number of ready nodes in the cluster
 	// +optional
@@ -303,3 +509,25 @@ type NodeStatistics struct {
 	// +optional
 	LostNodes int32 `json:"lostNodes,omitempty"`
 }
+
+type PodStatistics struct {
+	// TotalPods is the number of pods in the cluster
+	// +optional
+	TotalPods int32 `json:"totalPods,omitempty"`
+
+	// RunningPods is the number of running pods in the cluster
+	// +optional
+	RunningPods int32 `json:"runningPods,omitempty"`
+
+	// PendingPods is the number of pending pods in the cluster
+	// +optional
+	PendingPods int32 `json:"pendingPods,omitempty"`
+
+	// FailedPods is the number of failed pods in the cluster
+	// +optional
+	FailedPods int32 `json:"failedPods,omitempty"`
+
+	// SucceededPods is the number of succeeded pods in the cluster
+	// +optional
+	SucceededPods int32 `json:"succeededPods,omitempty"`
+}
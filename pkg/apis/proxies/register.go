@@ -47,6 +47,8 @@ var (
 // Adds the list of known types to the given scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&DescriptionDiff{},
+		&Render{},
 		&Socket{},
 	)
 	return nil
@@ -36,3 +36,64 @@ type Socket struct {
 	// Path is the URL path to use for the current proxy request
 	Path string
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Render is the query options to render the manifests of a Subscription for a child cluster
+type Render struct {
+	metav1.TypeMeta
+
+	// Cluster is the child cluster to render the manifests for, which could be
+	// the cluster name, the cluster id, or the dedicated namespace of the cluster.
+	Cluster string
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DescriptionDiff is the difference between the manifests of a Description and the live objects in the child cluster
+type DescriptionDiff struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	// Items are the differences of the objects in the Description
+	Items []ObjectDiff
+}
+
+// DiffAction is the change made to a live object when the Description gets applied
+type DiffAction string
+
+const (
+	DiffActionCreate  DiffAction = "Create"
+	DiffActionUpdate  DiffAction = "Update"
+	DiffActionNone    DiffAction = "None"
+	DiffActionUnknown DiffAction = "Unknown"
+)
+
+// ObjectDiff is the difference between a desired object and the live one in the child cluster
+type ObjectDiff struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+
+	// Action is the change made to the live object when the Description gets applied
+	Action DiffAction
+
+	// Fields are the fields whose live values differ from the desired ones
+	Fields []FieldDiff
+
+	// Error is the reason why the live object fails to be retrieved
+	Error string
+}
+
+// FieldDiff is a field whose live value differs from the desired one
+type FieldDiff struct {
+	// Path is the path of the field, such as spec.template.spec.containers[0].image
+	Path string
+
+	// Live is the JSON encoded live value, which is empty if the field is not set
+	Live string
+
+	// Desired is the JSON encoded desired value
+	Desired string
+}
@@ -48,6 +48,8 @@ func init() {
 // Adds the list of known types to the given scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&DescriptionDiff{},
+		&Render{},
 		&Socket{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -37,3 +37,87 @@ type Socket struct {
 	// Path is the URL path to use for the current proxy request
 	Path string `json:"path,omitempty"`
 }
+
+// +k8s:conversion-gen:explicit-from=net/url.Values
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Render is the query options to render the manifests of a Subscription for a child cluster.
+// For example, the whole request URL is
+// http://localhost:8001/apis/proxies.clusternet.io/v1alpha1/namespaces/default/renders/app-demo?cluster=clusternet-cluster-dzqkw,
+// which renders Subscription default/app-demo for cluster clusternet-cluster-dzqkw.
+type Render struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Cluster is the child cluster to render the manifests for, which could be
+	// the cluster name, the cluster id, or the dedicated namespace of the cluster.
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DescriptionDiff is the difference between the manifests of a Description and the live objects in the child cluster.
+// For example, the whole request URL is
+// http://localhost:8001/apis/proxies.clusternet.io/v1alpha1/namespaces/clusternet-5l82l/descriptiondiffs/app-demo-generic,
+// which compares Description clusternet-5l82l/app-demo-generic with the objects in the child cluster.
+type DescriptionDiff struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Items are the differences of the objects in the Description
+	//
+	// +optional
+	Items []ObjectDiff `json:"items,omitempty"`
+}
+
+// DiffAction is the change made to a live object when the Description gets applied
+type DiffAction string
+
+const (
+	// DiffActionCreate means the object does not exist in the child cluster
+	DiffActionCreate DiffAction = "Create"
+	// DiffActionUpdate means some fields of the live object differ from the desired ones
+	DiffActionUpdate DiffAction = "Update"
+	// DiffActionNone means the live object is the same as the desired one
+	DiffActionNone DiffAction = "None"
+	// DiffActionUnknown means the live object fails to be retrieved
+	DiffActionUnknown DiffAction = "Unknown"
+)
+
+// ObjectDiff is the difference between a desired object and the live one in the child cluster
+type ObjectDiff struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Action is the change made to the live object when the Description gets applied
+	Action DiffAction `json:"action"`
+
+	// Fields are the fields whose live values differ from the desired ones.
+	// Only the fields set in the desired object are compared.
+	//
+	// +optional
+	Fields []FieldDiff `json:"fields,omitempty"`
+
+	// Error is the reason why the live object fails to be retrieved
+	//
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// FieldDiff is a field whose live value differs from the desired one
+type FieldDiff struct {
+	// Path is the path of the field, such as spec.template.spec.containers[0].image
+	Path string `json:"path"`
+
+	// Live is the JSON encoded live value, which is empty if the field is not set
+	//
+	// +optional
+	Live string `json:"live,omitempty"`
+
+	// Desired is the JSON encoded desired value
+	//
+	// +optional
+	Desired string `json:"desired,omitempty"`
+}
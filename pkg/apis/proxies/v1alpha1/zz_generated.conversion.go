@@ -21,6 +21,7 @@ package v1alpha1
 
 import (
 	url "net/url"
+	unsafe "unsafe"
 
 	proxies "github.com/clusternet/clusternet/pkg/apis/proxies"
 	conversion "k8s.io/apimachinery/pkg/conversion"
@@ -34,6 +35,46 @@ func init() {
 // RegisterConversions adds conversion functions to the given scheme.
 // Public to allow building arbitrary schemes.
 func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*DescriptionDiff)(nil), (*proxies.DescriptionDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_DescriptionDiff_To_proxies_DescriptionDiff(a.(*DescriptionDiff), b.(*proxies.DescriptionDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*proxies.DescriptionDiff)(nil), (*DescriptionDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_proxies_DescriptionDiff_To_v1alpha1_DescriptionDiff(a.(*proxies.DescriptionDiff), b.(*DescriptionDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*FieldDiff)(nil), (*proxies.FieldDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_FieldDiff_To_proxies_FieldDiff(a.(*FieldDiff), b.(*proxies.FieldDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*proxies.FieldDiff)(nil), (*FieldDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_proxies_FieldDiff_To_v1alpha1_FieldDiff(a.(*proxies.FieldDiff), b.(*FieldDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*ObjectDiff)(nil), (*proxies.ObjectDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ObjectDiff_To_proxies_ObjectDiff(a.(*ObjectDiff), b.(*proxies.ObjectDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*proxies.ObjectDiff)(nil), (*ObjectDiff)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_proxies_ObjectDiff_To_v1alpha1_ObjectDiff(a.(*proxies.ObjectDiff), b.(*ObjectDiff), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*Render)(nil), (*proxies.Render)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_Render_To_proxies_Render(a.(*Render), b.(*proxies.Render), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*proxies.Render)(nil), (*Render)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_proxies_Render_To_v1alpha1_Render(a.(*proxies.Render), b.(*Render), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Socket)(nil), (*proxies.Socket)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1alpha1_Socket_To_proxies_Socket(a.(*Socket), b.(*proxies.Socket), scope)
 	}); err != nil {
@@ -44,6 +85,11 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*url.Values)(nil), (*Render)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_url_Values_To_v1alpha1_Render(a.(*url.Values), b.(*Render), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*url.Values)(nil), (*Socket)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_url_Values_To_v1alpha1_Socket(a.(*url.Values), b.(*Socket), scope)
 	}); err != nil {
@@ -52,6 +98,122 @@ func RegisterConversions(s *runtime.Scheme) error {
 	return nil
 }
 
+func autoConvert_v1alpha1_DescriptionDiff_To_proxies_DescriptionDiff(in *DescriptionDiff, out *proxies.DescriptionDiff, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Items = *(*[]proxies.ObjectDiff)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_v1alpha1_DescriptionDiff_To_proxies_DescriptionDiff is an autogenerated conversion function.
+func Convert_v1alpha1_DescriptionDiff_To_proxies_DescriptionDiff(in *DescriptionDiff, out *proxies.DescriptionDiff, s conversion.Scope) error {
+	return autoConvert_v1alpha1_DescriptionDiff_To_proxies_DescriptionDiff(in, out, s)
+}
+
+func autoConvert_proxies_DescriptionDiff_To_v1alpha1_DescriptionDiff(in *proxies.DescriptionDiff, out *DescriptionDiff, s conversion.Scope) error {
+	out.ObjectMeta = in.ObjectMeta
+	out.Items = *(*[]ObjectDiff)(unsafe.Pointer(&in.Items))
+	return nil
+}
+
+// Convert_proxies_DescriptionDiff_To_v1alpha1_DescriptionDiff is an autogenerated conversion function.
+func Convert_proxies_DescriptionDiff_To_v1alpha1_DescriptionDiff(in *proxies.DescriptionDiff, out *DescriptionDiff, s conversion.Scope) error {
+	return autoConvert_proxies_DescriptionDiff_To_v1alpha1_DescriptionDiff(in, out, s)
+}
+
+func autoConvert_v1alpha1_FieldDiff_To_proxies_FieldDiff(in *FieldDiff, out *proxies.FieldDiff, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Live = in.Live
+	out.Desired = in.Desired
+	return nil
+}
+
+// Convert_v1alpha1_FieldDiff_To_proxies_FieldDiff is an autogenerated conversion function.
+func Convert_v1alpha1_FieldDiff_To_proxies_FieldDiff(in *FieldDiff, out *proxies.FieldDiff, s conversion.Scope) error {
+	return autoConvert_v1alpha1_FieldDiff_To_proxies_FieldDiff(in, out, s)
+}
+
+func autoConvert_proxies_FieldDiff_To_v1alpha1_FieldDiff(in *proxies.FieldDiff, out *FieldDiff, s conversion.Scope) error {
+	out.Path = in.Path
+	out.Live = in.Live
+	out.Desired = in.Desired
+	return nil
+}
+
+// Convert_proxies_FieldDiff_To_v1alpha1_FieldDiff is an autogenerated conversion function.
+func Convert_proxies_FieldDiff_To_v1alpha1_FieldDiff(in *proxies.FieldDiff, out *FieldDiff, s conversion.Scope) error {
+	return autoConvert_proxies_FieldDiff_To_v1alpha1_FieldDiff(in, out, s)
+}
+
+func autoConvert_v1alpha1_ObjectDiff_To_proxies_ObjectDiff(in *ObjectDiff, out *proxies.ObjectDiff, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.Action = proxies.DiffAction(in.Action)
+	out.Fields = *(*[]proxies.FieldDiff)(unsafe.Pointer(&in.Fields))
+	out.Error = in.Error
+	return nil
+}
+
+// Convert_v1alpha1_ObjectDiff_To_proxies_ObjectDiff is an autogenerated conversion function.
+func Convert_v1alpha1_ObjectDiff_To_proxies_ObjectDiff(in *ObjectDiff, out *proxies.ObjectDiff, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ObjectDiff_To_proxies_ObjectDiff(in, out, s)
+}
+
+func autoConvert_proxies_ObjectDiff_To_v1alpha1_ObjectDiff(in *proxies.ObjectDiff, out *ObjectDiff, s conversion.Scope) error {
+	out.APIVersion = in.APIVersion
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.Action = DiffAction(in.Action)
+	out.Fields = *(*[]FieldDiff)(unsafe.Pointer(&in.Fields))
+	out.Error = in.Error
+	return nil
+}
+
+// Convert_proxies_ObjectDiff_To_v1alpha1_ObjectDiff is an autogenerated conversion function.
+func Convert_proxies_ObjectDiff_To_v1alpha1_ObjectDiff(in *proxies.ObjectDiff, out *ObjectDiff, s conversion.Scope) error {
+	return autoConvert_proxies_ObjectDiff_To_v1alpha1_ObjectDiff(in, out, s)
+}
+
+func autoConvert_v1alpha1_Render_To_proxies_Render(in *Render, out *proxies.Render, s conversion.Scope) error {
+	out.Cluster = in.Cluster
+	return nil
+}
+
+// Convert_v1alpha1_Render_To_proxies_Render is an autogenerated conversion function.
+func Convert_v1alpha1_Render_To_proxies_Render(in *Render, out *proxies.Render, s conversion.Scope) error {
+	return autoConvert_v1alpha1_Render_To_proxies_Render(in, out, s)
+}
+
+func autoConvert_proxies_Render_To_v1alpha1_Render(in *proxies.Render, out *Render, s conversion.Scope) error {
+	out.Cluster = in.Cluster
+	return nil
+}
+
+// Convert_proxies_Render_To_v1alpha1_Render is an autogenerated conversion function.
+func Convert_proxies_Render_To_v1alpha1_Render(in *proxies.Render, out *Render, s conversion.Scope) error {
+	return autoConvert_proxies_Render_To_v1alpha1_Render(in, out, s)
+}
+
+func autoConvert_url_Values_To_v1alpha1_Render(in *url.Values, out *Render, s conversion.Scope) error {
+	// WARNING: Field TypeMeta does not have json tag, skipping.
+
+	if values, ok := map[string][]string(*in)["cluster"]; ok && len(values) > 0 {
+		if err := runtime.Convert_Slice_string_To_string(&values, &out.Cluster, s); err != nil {
+			return err
+		}
+	} else {
+		out.Cluster = ""
+	}
+	return nil
+}
+
+// Convert_url_Values_To_v1alpha1_Render is an autogenerated conversion function.
+func Convert_url_Values_To_v1alpha1_Render(in *url.Values, out *Render, s conversion.Scope) error {
+	return autoConvert_url_Values_To_v1alpha1_Render(in, out, s)
+}
+
 func autoConvert_v1alpha1_Socket_To_proxies_Socket(in *Socket, out *proxies.Socket, s conversion.Scope) error {
 	out.Path = in.Path
 	return nil
@@ -36,6 +36,7 @@ import (
 	utilpointer "k8s.io/utils/pointer"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
@@ -119,11 +120,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process Base resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addBase(obj interface{}) {
@@ -262,7 +269,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Base resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("base", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -359,6 +369,11 @@ func (c *Controller) enqueue(base *appsapi.Base) {
 	c.workqueue.Add(key)
 }
 
+// Enqueue puts the Base onto the work queue to be synced again.
+func (c *Controller) Enqueue(base *appsapi.Base) {
+	c.enqueue(base)
+}
+
 func (c *Controller) patchBaseLabels(base *appsapi.Base, labels map[string]*string) (*appsapi.Base, error) {
 	if base.DeletionTimestamp != nil || len(labels) == 0 {
 		return base, nil
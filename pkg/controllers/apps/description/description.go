@@ -25,6 +25,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -34,10 +35,12 @@ import (
 	"k8s.io/klog/v2"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appInformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	appListers "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
 	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
@@ -65,12 +68,19 @@ type Controller struct {
 
 	recorder record.EventRecorder
 
+	clusterLimiter      *keyedRateLimiter
+	subscriptionLimiter *keyedRateLimiter
+
+	// sharder decides whether the Descriptions of a child cluster are handled by current replica
+	sharder *sharding.Sharder
+
 	syncHandlerFunc SyncHandlerFunc
 }
 
 func NewController(ctx context.Context, clusternetClient clusternetClientSet.Interface,
 	descInformer appInformers.DescriptionInformer, hrInformer appInformers.HelmReleaseInformer,
-	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	recorder record.EventRecorder, rateLimitOptions RateLimitOptions, sharder *sharding.Sharder,
+	syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
 	if syncHandlerFunc == nil {
 		return nil, fmt.Errorf("syncHandlerFunc must be set")
 	}
@@ -78,12 +88,16 @@ func NewController(ctx context.Context, clusternetClient clusternetClientSet.Int
 	c := &Controller{
 		ctx:              ctx,
 		clusternetClient: clusternetClient,
-		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "description"),
+		workqueue:        workqueue.NewNamedRateLimitingQueue(rateLimitOptions.newRateLimiter(), "description"),
 		descLister:       descInformer.Lister(),
 		descSynced:       descInformer.Informer().HasSynced,
 		hrSynced:         hrInformer.Informer().HasSynced,
 		recorder:         recorder,
-		syncHandlerFunc:  syncHandlerFunc,
+		clusterLimiter:   newKeyedRateLimiter(rateLimitOptions.ClusterQPS, rateLimitOptions.ClusterBurst),
+		subscriptionLimiter: newKeyedRateLimiter(rateLimitOptions.SubscriptionQPS,
+			rateLimitOptions.SubscriptionBurst),
+		sharder:         sharder,
+		syncHandlerFunc: syncHandlerFunc,
 	}
 
 	// Manage the addition/update of Description
@@ -97,6 +111,9 @@ func NewController(ctx context.Context, clusternetClient clusternetClientSet.Int
 		DeleteFunc: c.deleteHelmRelease,
 	})
 
+	// handle the Descriptions of the child clusters moved to current replica
+	sharder.AddMembershipHandler(c.enqueueAll)
+
 	return c, nil
 }
 
@@ -119,11 +136,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process Description resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addDescription(obj interface{}) {
@@ -141,6 +164,13 @@ func (c *Controller) updateDescription(old, cur interface{}) {
 		return
 	}
 
+	// changes that were held for approval get approved, or approval is no longer required
+	if utils.IsDescriptionPendingApproval(oldDesc) && !utils.IsDescriptionPendingApproval(newDesc) {
+		klog.V(4).Infof("changes of Description %q are no longer pending approval", klog.KObj(newDesc))
+		c.enqueue(newDesc)
+		return
+	}
+
 	// Decide whether discovery has reported a spec change.
 	if reflect.DeepEqual(oldDesc.Spec, newDesc.Spec) {
 		klog.V(4).Infof("no updates on the spec of Description %s, skipping syncing", klog.KObj(oldDesc))
@@ -259,9 +289,18 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
+		// Defer the item without counting it as a failure when exceeding the rate limits.
+		if delay := c.throttle(key); delay > 0 {
+			klog.V(5).Infof("throttling Description %q for %v", key, delay)
+			c.workqueue.AddAfter(key, delay)
+			return nil
+		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Description resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("description", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -307,6 +346,10 @@ func (c *Controller) syncHandler(key string) error {
 	if err != nil {
 		return err
 	}
+	if !c.sharder.Owns(desc.Namespace) {
+		klog.V(5).Infof("skip Description %q handled by other replicas", key)
+		return nil
+	}
 
 	// add finalizer
 	if !utils.ContainsString(desc.Finalizers, known.AppFinalizer) && desc.DeletionTimestamp == nil {
@@ -343,7 +386,7 @@ func (c *Controller) UpdateDescriptionStatus(desc *appsapi.Description, status *
 	klog.V(5).Infof("try to update Description %q status", desc.Name)
 
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		desc.Status = *status
+		utils.SetDescriptionStatus(desc, *status)
 		_, err := c.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(c.ctx, desc, metav1.UpdateOptions{})
 		if err == nil {
 			//TODO
@@ -371,3 +414,25 @@ func (c *Controller) enqueue(desc *appsapi.Description) {
 	}
 	c.workqueue.Add(key)
 }
+
+// enqueueAll puts all the Descriptions onto the work queue.
+func (c *Controller) enqueueAll() {
+	descs, err := c.descLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, desc := range descs {
+		c.enqueue(desc)
+	}
+}
+
+// EnqueueAfter puts the Description onto the work queue after the indicated duration has passed.
+func (c *Controller) EnqueueAfter(desc *appsapi.Description, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(desc)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.AddAfter(key, duration)
+}
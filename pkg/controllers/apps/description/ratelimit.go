@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package description
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// RateLimitOptions configures how fast Descriptions are handled, so that a change to a popular Base
+// does not overload the deployer and child clusters.
+type RateLimitOptions struct {
+	// ClusterQPS and ClusterBurst limit how often the Descriptions of a single child cluster are handled.
+	// 0 QPS means no limit.
+	ClusterQPS   float32
+	ClusterBurst int
+
+	// SubscriptionQPS and SubscriptionBurst limit how often the Descriptions of a single Subscription are handled.
+	// 0 QPS means no limit.
+	SubscriptionQPS   float32
+	SubscriptionBurst int
+
+	// RetryBaseDelay and RetryMaxDelay are the exponential backoff of retrying failed Descriptions.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+func (opts RateLimitOptions) newRateLimiter() workqueue.RateLimiter {
+	if opts.RetryBaseDelay <= 0 || opts.RetryMaxDelay <= 0 {
+		return workqueue.DefaultControllerRateLimiter()
+	}
+	return workqueue.NewItemExponentialFailureRateLimiter(opts.RetryBaseDelay, opts.RetryMaxDelay)
+}
+
+// keyedRateLimiter keeps a token bucket for each key, such as a child cluster.
+type keyedRateLimiter struct {
+	qps   float32
+	burst int
+
+	lock     sync.Mutex
+	limiters map[string]flowcontrol.RateLimiter
+}
+
+// newKeyedRateLimiter returns nil if qps is not positive, which accepts everything.
+func newKeyedRateLimiter(qps float32, burst int) *keyedRateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &keyedRateLimiter{
+		qps:      qps,
+		burst:    burst,
+		limiters: map[string]flowcontrol.RateLimiter{},
+	}
+}
+
+// tryAccept returns zero if a token is taken for the key, or how long to wait before trying again.
+func (l *keyedRateLimiter) tryAccept(key string) time.Duration {
+	if l == nil || len(key) == 0 {
+		return 0
+	}
+
+	l.lock.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(l.qps, l.burst)
+		l.limiters[key] = limiter
+	}
+	l.lock.Unlock()
+
+	if limiter.TryAccept() {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / float64(l.qps))
+}
+
+// throttle returns how long to defer handling the Description, when its child cluster or Subscription
+// exceeds the rate limits.
+func (c *Controller) throttle(key string) time.Duration {
+	if c.clusterLimiter == nil && c.subscriptionLimiter == nil {
+		return 0
+	}
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return 0
+	}
+	desc, err := c.descLister.Descriptions(ns).Get(name)
+	if err != nil || !c.sharder.Owns(desc.Namespace) {
+		// handled by syncHandler
+		return 0
+	}
+
+	subKey := ""
+	if subName := desc.Labels[known.ConfigSubscriptionNameLabel]; len(subName) > 0 {
+		subKey = desc.Labels[known.ConfigSubscriptionNamespaceLabel] + "/" + subName
+	}
+	if delay := c.subscriptionLimiter.tryAccept(subKey); delay > 0 {
+		return delay
+	}
+	// Descriptions are placed in the dedicated namespaces of child clusters
+	return c.clusterLimiter.tryAccept(desc.Namespace)
+}
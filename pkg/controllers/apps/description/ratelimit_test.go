@@ -0,0 +1,39 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package description
+
+import (
+	"testing"
+)
+
+func TestKeyedRateLimiter(t *testing.T) {
+	var unlimited *keyedRateLimiter
+	if delay := unlimited.tryAccept("cluster-a"); delay != 0 {
+		t.Errorf("tryAccept() without limits = %v, want 0", delay)
+	}
+
+	limiter := newKeyedRateLimiter(1, 1)
+	if delay := limiter.tryAccept("cluster-a"); delay != 0 {
+		t.Errorf("tryAccept() within burst = %v, want 0", delay)
+	}
+	if delay := limiter.tryAccept("cluster-a"); delay <= 0 {
+		t.Errorf("tryAccept() beyond burst = %v, want positive", delay)
+	}
+	if delay := limiter.tryAccept("cluster-b"); delay != 0 {
+		t.Errorf("tryAccept() of another key = %v, want 0", delay)
+	}
+}
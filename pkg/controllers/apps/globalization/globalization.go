@@ -35,6 +35,7 @@ import (
 	"k8s.io/klog/v2"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
@@ -128,11 +129,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process Globalization resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addGlobalization(obj interface{}) {
@@ -221,7 +228,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Globalization resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("globalization", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -34,6 +34,7 @@ import (
 	"k8s.io/klog/v2"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
@@ -115,11 +116,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process HelmChart resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addHelmChart(obj interface{}) {
@@ -208,7 +215,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// HelmChart resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("helmChart", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -310,7 +320,7 @@ func (c *Controller) UpdateChartStatus(chart *appsapi.HelmChart, status *appsapi
 	klog.V(5).Infof("try to update HelmChart %q status", chart.Name)
 
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		chart.Status = *status
+		utils.SetHelmChartStatus(chart, *status)
 		_, err := c.clusternetClient.AppsV1alpha1().HelmCharts(chart.Namespace).UpdateStatus(c.ctx, chart, metav1.UpdateOptions{})
 		if err == nil {
 			//TODO
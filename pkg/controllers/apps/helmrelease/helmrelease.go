@@ -25,7 +25,9 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -35,10 +37,12 @@ import (
 	"k8s.io/klog/v2"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
 	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
@@ -67,11 +71,14 @@ type Controller struct {
 
 	recorder        record.EventRecorder
 	syncHandlerFunc SyncHandlerFunc
+
+	// sharder decides whether the HelmReleases of a child cluster are handled by current replica
+	sharder *sharding.Sharder
 }
 
 func NewController(ctx context.Context, clusternetClient clusternetclientset.Interface,
 	descInformer appinformers.DescriptionInformer, hrInformer appinformers.HelmReleaseInformer,
-	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	recorder record.EventRecorder, sharder *sharding.Sharder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
 	if syncHandlerFunc == nil {
 		return nil, fmt.Errorf("syncHandlerFunc must be set")
 	}
@@ -86,6 +93,7 @@ func NewController(ctx context.Context, clusternetClient clusternetclientset.Int
 		hrSynced:         hrInformer.Informer().HasSynced,
 		recorder:         recorder,
 		syncHandlerFunc:  syncHandlerFunc,
+		sharder:          sharder,
 	}
 
 	// Manage the addition/update of HelmRelease
@@ -95,6 +103,9 @@ func NewController(ctx context.Context, clusternetClient clusternetclientset.Int
 		DeleteFunc: c.deleteHelmRelease,
 	})
 
+	// handle the HelmReleases of the child clusters moved to current replica
+	sharder.AddMembershipHandler(c.enqueueAll)
+
 	return c, nil
 }
 
@@ -117,11 +128,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process HelmRelease resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addHelmRelease(obj interface{}) {
@@ -210,7 +227,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// HelmRelease resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("helmRelease", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -256,6 +276,10 @@ func (c *Controller) syncHandler(key string) error {
 	if err != nil {
 		return err
 	}
+	if !c.sharder.Owns(hr.Namespace) {
+		klog.V(5).Infof("skip HelmRelease %q handled by other replicas", key)
+		return nil
+	}
 
 	// add finalizer
 	if !utils.ContainsString(hr.Finalizers, known.AppFinalizer) && hr.DeletionTimestamp == nil {
@@ -294,7 +318,7 @@ func (c *Controller) UpdateHelmReleaseStatus(hr *appsapi.HelmRelease, status *ap
 	klog.V(5).Infof("try to update HelmRelease %q status", hr.Name)
 
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		hr.Status = *status
+		utils.SetHelmReleaseStatus(hr, *status)
 		_, err := c.clusternetClient.AppsV1alpha1().HelmReleases(hr.Namespace).UpdateStatus(c.ctx, hr, metav1.UpdateOptions{})
 		if err == nil {
 			return nil
@@ -324,13 +348,20 @@ func (c *Controller) UpdateHelmReleaseStatus(hr *appsapi.HelmRelease, status *ap
 		if desc == nil {
 			return nil
 		}
-		if status.Phase == release.StatusDeployed {
-			desc.Status.Phase = appsapi.DescriptionPhaseSuccess
-			desc.Status.Reason = ""
+		descStatus := *desc.Status.DeepCopy()
+		tested := apimeta.FindStatusCondition(status.Conditions, appsapi.HelmReleaseTested)
+		if tested != nil && tested.Status == metav1.ConditionFalse {
+			// failed tests block the Description from being ready
+			descStatus.Phase = appsapi.DescriptionPhaseFailure
+			descStatus.Reason = tested.Message
+		} else if status.Phase == release.StatusDeployed {
+			descStatus.Phase = appsapi.DescriptionPhaseSuccess
+			descStatus.Reason = ""
 		} else {
-			desc.Status.Phase = appsapi.DescriptionPhaseFailure
-			desc.Status.Reason = status.Notes
+			descStatus.Phase = appsapi.DescriptionPhaseFailure
+			descStatus.Reason = status.Notes
 		}
+		utils.SetDescriptionStatus(desc, descStatus)
 		_, err := c.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(c.ctx, desc, metav1.UpdateOptions{})
 		if err == nil {
 			return nil
@@ -373,3 +404,15 @@ func (c *Controller) enqueue(hr *appsapi.HelmRelease) {
 	}
 	c.workqueue.Add(key)
 }
+
+// enqueueAll puts all the HelmReleases onto the work queue.
+func (c *Controller) enqueueAll() {
+	hrs, err := c.hrLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, hr := range hrs {
+		c.enqueue(hr)
+	}
+}
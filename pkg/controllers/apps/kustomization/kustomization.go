@@ -0,0 +1,385 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomization
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// controllerKind contains the schema.GroupVersionKind for this controller type.
+var controllerKind = appsapi.SchemeGroupVersion.WithKind("Kustomization")
+
+type SyncHandlerFunc func(ks *appsapi.Kustomization) error
+
+// Controller is a controller that handle Kustomization
+type Controller struct {
+	ctx context.Context
+
+	clusternetClient clusternetclientset.Interface
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+
+	ksLister applisters.KustomizationLister
+	ksSynced cache.InformerSynced
+
+	configMapSynced cache.InformerSynced
+
+	recorder        record.EventRecorder
+	syncHandlerFunc SyncHandlerFunc
+}
+
+func NewController(ctx context.Context, clusternetClient clusternetclientset.Interface,
+	ksInformer appinformers.KustomizationInformer, configMapInformer coreinformers.ConfigMapInformer,
+	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	if syncHandlerFunc == nil {
+		return nil, fmt.Errorf("syncHandlerFunc must be set")
+	}
+
+	c := &Controller{
+		ctx:              ctx,
+		clusternetClient: clusternetClient,
+		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "kustomization"),
+		ksLister:         ksInformer.Lister(),
+		ksSynced:         ksInformer.Informer().HasSynced,
+		configMapSynced:  configMapInformer.Informer().HasSynced,
+		recorder:         recorder,
+		syncHandlerFunc:  syncHandlerFunc,
+	}
+
+	// Manage the addition/update of Kustomization
+	ksInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addKustomization,
+		UpdateFunc: c.updateKustomization,
+		DeleteFunc: c.deleteKustomization,
+	})
+
+	// re-render the Kustomizations whenever their source ConfigMaps change
+	configMapInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.handleConfigMap,
+		UpdateFunc: func(old, cur interface{}) {
+			oldConfigMap := old.(*corev1.ConfigMap)
+			newConfigMap := cur.(*corev1.ConfigMap)
+			if reflect.DeepEqual(oldConfigMap.Data, newConfigMap.Data) &&
+				reflect.DeepEqual(oldConfigMap.BinaryData, newConfigMap.BinaryData) {
+				return
+			}
+			c.handleConfigMap(cur)
+		},
+		DeleteFunc: c.handleConfigMap,
+	})
+
+	return c, nil
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	klog.Info("starting kustomization controller...")
+	defer klog.Info("shutting down kustomization controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(stopCh, c.ksSynced, c.configMapSynced) {
+		return
+	}
+
+	klog.V(5).Infof("starting %d worker threads", workers)
+	// Launch workers to process Kustomization resources
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
+	}
+
+	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (c *Controller) addKustomization(obj interface{}) {
+	ks := obj.(*appsapi.Kustomization)
+	klog.V(4).Infof("adding Kustomization %q", klog.KObj(ks))
+	c.enqueue(ks)
+}
+
+func (c *Controller) updateKustomization(old, cur interface{}) {
+	oldKs := old.(*appsapi.Kustomization)
+	newKs := cur.(*appsapi.Kustomization)
+
+	if newKs.DeletionTimestamp != nil {
+		c.enqueue(newKs)
+		return
+	}
+
+	// Decide whether discovery has reported a spec change.
+	if reflect.DeepEqual(oldKs.Spec, newKs.Spec) {
+		klog.V(4).Infof("no updates on the spec of Kustomization %s, skipping syncing", klog.KObj(oldKs))
+		return
+	}
+
+	klog.V(4).Infof("updating Kustomization %q", klog.KObj(oldKs))
+	c.enqueue(newKs)
+}
+
+func (c *Controller) deleteKustomization(obj interface{}) {
+	ks, ok := obj.(*appsapi.Kustomization)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		ks, ok = tombstone.Obj.(*appsapi.Kustomization)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("tombstone contained object that is not a Kustomization %#v", obj))
+			return
+		}
+	}
+	klog.V(4).Infof("deleting Kustomization %q", klog.KObj(ks))
+	c.enqueue(ks)
+}
+
+func (c *Controller) handleConfigMap(obj interface{}) {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		configMap, ok = tombstone.Obj.(*corev1.ConfigMap)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("tombstone contained object that is not a ConfigMap %#v", obj))
+			return
+		}
+	}
+
+	kss, err := c.ksLister.Kustomizations(configMap.Namespace).List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, ks := range kss {
+		for _, source := range ks.Spec.Sources {
+			if source.ConfigMap == configMap.Name {
+				klog.V(4).Infof("ConfigMap %s of Kustomization %s changes", klog.KObj(configMap), klog.KObj(ks))
+				c.enqueue(ks)
+				break
+			}
+		}
+	}
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// Kustomization resource to be synced.
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("kustomization", start, err)
+		if err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("successfully synced Kustomization %q", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the Kustomization resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(key string) error {
+	// If an error occurs during handling, we'll requeue the item so we can
+	// attempt processing again later. This could have been caused by a
+	// temporary network failure, or any other transient reason.
+
+	// Convert the namespace/name string into a distinct namespace and name
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	klog.V(4).Infof("start processing Kustomization %q", key)
+	// Get the Kustomization resource with this name
+	ks, err := c.ksLister.Kustomizations(ns).Get(name)
+	// The Kustomization resource may no longer exist, in which case we stop processing.
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("Kustomization %q has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if ks.DeletionTimestamp == nil {
+		updatedKs := ks.DeepCopy()
+
+		// add finalizer
+		if !utils.ContainsString(updatedKs.Finalizers, known.AppFinalizer) {
+			updatedKs.Finalizers = append(updatedKs.Finalizers, known.AppFinalizer)
+		}
+
+		// only update on changed
+		if !reflect.DeepEqual(ks, updatedKs) {
+			if ks, err = c.clusternetClient.AppsV1alpha1().Kustomizations(ks.Namespace).Update(context.TODO(),
+				updatedKs, metav1.UpdateOptions{}); err != nil {
+				msg := fmt.Sprintf("failed to inject finalizer %s to Kustomization %s: %v",
+					known.AppFinalizer, klog.KObj(updatedKs), err)
+				klog.WarningDepth(4, msg)
+				c.recorder.Event(updatedKs, corev1.EventTypeWarning, "FailedInjectingFinalizer", msg)
+				return err
+			}
+			msg := fmt.Sprintf("successfully inject finalizer %s to Kustomization %s", known.AppFinalizer, klog.KObj(ks))
+			klog.V(4).Info(msg)
+			c.recorder.Event(ks, corev1.EventTypeNormal, "FinalizerInjected", msg)
+		}
+	}
+
+	ks.Kind = controllerKind.Kind
+	ks.APIVersion = controllerKind.Version
+	err = c.syncHandlerFunc(ks)
+	if err != nil {
+		c.recorder.Event(ks, corev1.EventTypeWarning, "FailedSynced", err.Error())
+	} else {
+		c.recorder.Event(ks, corev1.EventTypeNormal, "Synced", "Kustomization synced successfully")
+	}
+	return err
+}
+
+func (c *Controller) UpdateKustomizationStatus(ks *appsapi.Kustomization, status *appsapi.KustomizationStatus) error {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+
+	klog.V(5).Infof("try to update Kustomization %q status", ks.Name)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ks.Status = *status
+		_, err := c.clusternetClient.AppsV1alpha1().Kustomizations(ks.Namespace).UpdateStatus(c.ctx, ks, metav1.UpdateOptions{})
+		if err == nil {
+			return nil
+		}
+
+		if updated, err := c.ksLister.Kustomizations(ks.Namespace).Get(ks.Name); err == nil {
+			// make a copy so we don't mutate the shared cache
+			ks = updated.DeepCopy()
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated Kustomization %q from lister: %v", ks.Name, err))
+		}
+		return err
+	})
+}
+
+// enqueue takes a Kustomization resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than Kustomization.
+func (c *Controller) enqueue(ks *appsapi.Kustomization) {
+	key, err := cache.MetaNamespaceKeyFunc(ks)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocirepository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// controllerKind contains the schema.GroupVersionKind for this controller type.
+var controllerKind = appsapi.SchemeGroupVersion.WithKind("OCIRepository")
+
+type SyncHandlerFunc func(repo *appsapi.OCIRepository) error
+
+// Controller is a controller that handle OCIRepository
+type Controller struct {
+	ctx context.Context
+
+	clusternetClient clusternetclientset.Interface
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+
+	repoLister applisters.OCIRepositoryLister
+	repoSynced cache.InformerSynced
+
+	recorder        record.EventRecorder
+	syncHandlerFunc SyncHandlerFunc
+}
+
+func NewController(ctx context.Context, clusternetClient clusternetclientset.Interface,
+	repoInformer appinformers.OCIRepositoryInformer,
+	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	if syncHandlerFunc == nil {
+		return nil, fmt.Errorf("syncHandlerFunc must be set")
+	}
+
+	c := &Controller{
+		ctx:              ctx,
+		clusternetClient: clusternetClient,
+		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ocirepository"),
+		repoLister:       repoInformer.Lister(),
+		repoSynced:       repoInformer.Informer().HasSynced,
+		recorder:         recorder,
+		syncHandlerFunc:  syncHandlerFunc,
+	}
+
+	// Manage the addition/update of OCIRepository
+	repoInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addOCIRepository,
+		UpdateFunc: c.updateOCIRepository,
+		DeleteFunc: c.deleteOCIRepository,
+	})
+
+	return c, nil
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	klog.Info("starting ocirepository controller...")
+	defer klog.Info("shutting down ocirepository controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(stopCh, c.repoSynced) {
+		return
+	}
+
+	klog.V(5).Infof("starting %d worker threads", workers)
+	// Launch workers to process OCIRepository resources
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
+	}
+
+	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (c *Controller) addOCIRepository(obj interface{}) {
+	repo := obj.(*appsapi.OCIRepository)
+	klog.V(4).Infof("adding OCIRepository %q", klog.KObj(repo))
+	c.enqueue(repo)
+}
+
+func (c *Controller) updateOCIRepository(old, cur interface{}) {
+	oldRepo := old.(*appsapi.OCIRepository)
+	newRepo := cur.(*appsapi.OCIRepository)
+
+	if newRepo.DeletionTimestamp != nil {
+		c.enqueue(newRepo)
+		return
+	}
+
+	// Decide whether discovery has reported a spec change.
+	if reflect.DeepEqual(oldRepo.Spec, newRepo.Spec) {
+		klog.V(4).Infof("no updates on the spec of OCIRepository %s, skipping syncing", klog.KObj(oldRepo))
+		return
+	}
+
+	klog.V(4).Infof("updating OCIRepository %q", klog.KObj(oldRepo))
+	c.enqueue(newRepo)
+}
+
+func (c *Controller) deleteOCIRepository(obj interface{}) {
+	repo, ok := obj.(*appsapi.OCIRepository)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		repo, ok = tombstone.Obj.(*appsapi.OCIRepository)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("tombstone contained object that is not a OCIRepository %#v", obj))
+			return
+		}
+	}
+	klog.V(4).Infof("deleting OCIRepository %q", klog.KObj(repo))
+	c.enqueue(repo)
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form namespace/name. We do this as the delayed nature of the
+		// workqueue means the items in the informer cache may actually be
+		// more up to date that when the item was initially put onto the
+		// workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the namespace/name string of the
+		// OCIRepository resource to be synced.
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("ocirepository", start, err)
+		if err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("successfully synced OCIRepository %q", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the OCIRepository resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(key string) error {
+	// If an error occurs during handling, we'll requeue the item so we can
+	// attempt processing again later. This could have been caused by a
+	// temporary network failure, or any other transient reason.
+
+	// Convert the namespace/name string into a distinct namespace and name
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	klog.V(4).Infof("start processing OCIRepository %q", key)
+	// Get the OCIRepository resource with this name
+	repo, err := c.repoLister.OCIRepositories(ns).Get(name)
+	// The OCIRepository resource may no longer exist, in which case we stop processing.
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("OCIRepository %q has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if repo.DeletionTimestamp == nil {
+		updatedRepo := repo.DeepCopy()
+
+		// add finalizer
+		if !utils.ContainsString(updatedRepo.Finalizers, known.AppFinalizer) {
+			updatedRepo.Finalizers = append(updatedRepo.Finalizers, known.AppFinalizer)
+		}
+
+		// only update on changed
+		if !reflect.DeepEqual(repo, updatedRepo) {
+			if repo, err = c.clusternetClient.AppsV1alpha1().OCIRepositories(repo.Namespace).Update(context.TODO(),
+				updatedRepo, metav1.UpdateOptions{}); err != nil {
+				msg := fmt.Sprintf("failed to inject finalizer %s to OCIRepository %s: %v",
+					known.AppFinalizer, klog.KObj(updatedRepo), err)
+				klog.WarningDepth(4, msg)
+				c.recorder.Event(updatedRepo, corev1.EventTypeWarning, "FailedInjectingFinalizer", msg)
+				return err
+			}
+			msg := fmt.Sprintf("successfully inject finalizer %s to OCIRepository %s", known.AppFinalizer, klog.KObj(repo))
+			klog.V(4).Info(msg)
+			c.recorder.Event(repo, corev1.EventTypeNormal, "FinalizerInjected", msg)
+		}
+	}
+
+	repo.Kind = controllerKind.Kind
+	repo.APIVersion = controllerKind.Version
+	err = c.syncHandlerFunc(repo)
+	if err != nil {
+		c.recorder.Event(repo, corev1.EventTypeWarning, "FailedSynced", err.Error())
+	} else {
+		c.recorder.Event(repo, corev1.EventTypeNormal, "Synced", "OCIRepository synced successfully")
+	}
+	return err
+}
+
+func (c *Controller) UpdateOCIRepositoryStatus(repo *appsapi.OCIRepository, status *appsapi.OCIRepositoryStatus) error {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+
+	klog.V(5).Infof("try to update OCIRepository %q status", repo.Name)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		repo.Status = *status
+		_, err := c.clusternetClient.AppsV1alpha1().OCIRepositories(repo.Namespace).UpdateStatus(c.ctx, repo, metav1.UpdateOptions{})
+		if err == nil {
+			return nil
+		}
+
+		if updated, err := c.repoLister.OCIRepositories(repo.Namespace).Get(repo.Name); err == nil {
+			// make a copy so we don't mutate the shared cache
+			repo = updated.DeepCopy()
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated OCIRepository %q from lister: %v", repo.Name, err))
+		}
+		return err
+	})
+}
+
+// EnqueueAfter puts the OCIRepository onto the work queue after the indicated duration has passed.
+func (c *Controller) EnqueueAfter(repo *appsapi.OCIRepository, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(repo)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.AddAfter(key, duration)
+}
+
+// enqueue takes a OCIRepository resource and converts it into a namespace/name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than OCIRepository.
+func (c *Controller) enqueue(repo *appsapi.OCIRepository) {
+	key, err := cache.MetaNamespaceKeyFunc(repo)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
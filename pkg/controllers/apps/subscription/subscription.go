@@ -38,6 +38,7 @@ import (
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	appinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/apps/v1alpha1"
 	clusterinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/clusters/v1beta1"
@@ -71,6 +72,7 @@ type Controller struct {
 	subsLister    applisters.SubscriptionLister
 	subsSynced    cache.InformerSynced
 	baseSynced    cache.InformerSynced
+	descSynced    cache.InformerSynced
 	clusterLister clusterlisters.ManagedClusterLister
 	clusterSynced cache.InformerSynced
 
@@ -81,7 +83,7 @@ type Controller struct {
 
 func NewController(ctx context.Context, clusternetClient clusternetclientset.Interface,
 	subsInformer appinformers.SubscriptionInformer, baseInformer appinformers.BaseInformer,
-	clusterInformer clusterinformers.ManagedClusterInformer, recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	descInformer appinformers.DescriptionInformer, clusterInformer clusterinformers.ManagedClusterInformer, recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
 	if syncHandlerFunc == nil {
 		return nil, fmt.Errorf("syncHandlerFunc must be set")
 	}
@@ -94,6 +96,7 @@ func NewController(ctx context.Context, clusternetClient clusternetclientset.Int
 		subsLister:       subsInformer.Lister(),
 		subsSynced:       subsInformer.Informer().HasSynced,
 		baseSynced:       baseInformer.Informer().HasSynced,
+		descSynced:       descInformer.Informer().HasSynced,
 		clusterLister:    clusterInformer.Lister(),
 		clusterSynced:    clusterInformer.Informer().HasSynced,
 		recorder:         recorder,
@@ -114,9 +117,17 @@ func NewController(ctx context.Context, clusternetClient clusternetclientset.Int
 	})
 
 	baseInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: c.updateBase,
 		DeleteFunc: c.deleteBase,
 	})
 
+	// roll up the readiness of Descriptions into the Subscription
+	descInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addDescription,
+		UpdateFunc: c.updateDescription,
+		DeleteFunc: c.deleteDescription,
+	})
+
 	return c, nil
 }
 
@@ -136,6 +147,7 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 	if !cache.WaitForCacheSync(stopCh,
 		c.subsSynced,
 		c.baseSynced,
+		c.descSynced,
 		c.clusterSynced,
 	) {
 		return
@@ -143,11 +155,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(5).Infof("starting %d worker threads", workers)
 	// Launch workers to process Subscription resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addSubscription(obj interface{}) {
@@ -172,6 +190,18 @@ func (c *Controller) updateSubscription(old, cur interface{}) {
 
 	// Decide whether discovery has reported a spec change.
 	if reflect.DeepEqual(oldSub.Spec, newSub.Spec) {
+		// Decide whether the rollout is promoted.
+		if oldSub.Annotations[known.RolloutPromoteAnnotation] != newSub.Annotations[known.RolloutPromoteAnnotation] {
+			klog.V(4).Infof("promoting the rollout of Subscription %q", klog.KObj(oldSub))
+			c.enqueue(newSub)
+			return
+		}
+		// Decide whether a rollback is requested.
+		if _, ok := newSub.Annotations[known.RollbackToAnnotation]; ok {
+			klog.V(4).Infof("rolling back Subscription %q", klog.KObj(oldSub))
+			c.enqueue(newSub)
+			return
+		}
 		klog.V(4).Infof("no updates on the spec of Subscription %s, skipping syncing", klog.KObj(oldSub))
 		return
 	}
@@ -207,6 +237,22 @@ func (c *Controller) deleteSubscription(obj interface{}) {
 	c.enqueue(sub)
 }
 
+func (c *Controller) updateBase(old, cur interface{}) {
+	oldBase := old.(*appsapi.Base)
+	newBase := cur.(*appsapi.Base)
+
+	if oldBase.Annotations[known.OverridesFailureAnnotation] == newBase.Annotations[known.OverridesFailureAnnotation] {
+		return
+	}
+
+	sub := c.resolveControllerRef(newBase.Labels[known.ConfigNameLabel], newBase.Labels[known.ConfigNamespaceLabel], types.UID(newBase.Labels[known.ConfigUIDLabel]))
+	if sub == nil {
+		return
+	}
+	klog.V(5).Infof("overrides failure of Base %q changes", klog.KObj(newBase))
+	c.enqueue(sub)
+}
+
 func (c *Controller) deleteBase(obj interface{}) {
 	base, ok := obj.(*appsapi.Base)
 	if !ok {
@@ -230,6 +276,54 @@ func (c *Controller) deleteBase(obj interface{}) {
 	c.enqueue(sub)
 }
 
+func (c *Controller) addDescription(obj interface{}) {
+	desc := obj.(*appsapi.Description)
+	c.enqueueSubscriptionForDescription(desc)
+}
+
+func (c *Controller) updateDescription(old, cur interface{}) {
+	oldDesc := old.(*appsapi.Description)
+	newDesc := cur.(*appsapi.Description)
+
+	if oldDesc.Generation == newDesc.Generation &&
+		oldDesc.Status.ObservedGeneration == newDesc.Status.ObservedGeneration &&
+		oldDesc.Status.Phase == newDesc.Status.Phase &&
+		oldDesc.Status.Reason == newDesc.Status.Reason &&
+		oldDesc.Status.FailedAttempts == newDesc.Status.FailedAttempts {
+		return
+	}
+	c.enqueueSubscriptionForDescription(newDesc)
+}
+
+func (c *Controller) deleteDescription(obj interface{}) {
+	desc, ok := obj.(*appsapi.Description)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("couldn't get object from tombstone %#v", obj))
+			return
+		}
+		desc, ok = tombstone.Obj.(*appsapi.Description)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("tombstone contained object that is not a Description %#v", obj))
+			return
+		}
+	}
+	c.enqueueSubscriptionForDescription(desc)
+}
+
+// enqueueSubscriptionForDescription enqueues the Subscription that the Description is populated from,
+// so that the readiness of the Description gets rolled up.
+func (c *Controller) enqueueSubscriptionForDescription(desc *appsapi.Description) {
+	sub := c.resolveControllerRef(desc.Labels[known.ConfigSubscriptionNameLabel], desc.Labels[known.ConfigSubscriptionNamespaceLabel],
+		types.UID(desc.Labels[known.ConfigSubscriptionUIDLabel]))
+	if sub == nil {
+		return
+	}
+	klog.V(5).Infof("readiness of Description %q changes", klog.KObj(desc))
+	c.enqueue(sub)
+}
+
 // resolveControllerRef returns the controller referenced by a ControllerRef,
 // or nil if the ControllerRef could not be resolved to a matching controller
 // of the correct Kind.
@@ -261,9 +355,9 @@ func (c *Controller) updateCluster(old, cur interface{}) {
 		return
 	}
 
-	// Decide whether discovery has reported a label change.
-	if reflect.DeepEqual(oldMcls.Labels, newMcls.Labels) {
-		klog.V(4).Infof("no updates on the labels of ManagedCluster %s, skipping syncing", klog.KObj(oldMcls))
+	// Decide whether discovery has reported a label or taint change.
+	if reflect.DeepEqual(oldMcls.Labels, newMcls.Labels) && reflect.DeepEqual(oldMcls.Spec.Taints, newMcls.Spec.Taints) {
+		klog.V(4).Infof("no updates on the labels and taints of ManagedCluster %s, skipping syncing", klog.KObj(oldMcls))
 		return
 	}
 
@@ -342,7 +436,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// Subscription resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("subscription", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -424,6 +521,7 @@ func (c *Controller) UpdateSubscriptionStatus(sub *appsapi.Subscription, status
 
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		sub.Status = *status
+		sub.Status.ObservedGeneration = sub.Generation
 		_, err := c.clusternetClient.AppsV1alpha1().Subscriptions(sub.Namespace).UpdateStatus(c.ctx, sub, metav1.UpdateOptions{})
 		if err == nil {
 			//TODO
@@ -451,3 +549,13 @@ func (c *Controller) enqueue(sub *appsapi.Subscription) {
 	}
 	c.workqueue.Add(key)
 }
+
+// EnqueueAfter puts the Subscription onto the work queue after the indicated duration has passed.
+func (c *Controller) EnqueueAfter(sub *appsapi.Subscription, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(sub)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.AddAfter(key, duration)
+}
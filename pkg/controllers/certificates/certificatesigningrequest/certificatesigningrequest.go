@@ -0,0 +1,264 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certificatesigningrequest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certificatesinformers "k8s.io/client-go/informers/certificates/v1"
+	certificateslisters "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+)
+
+type SyncHandlerFunc func(csr *certificatesv1.CertificateSigningRequest) error
+
+// Controller is a controller that handle CertificateSigningRequests with a given signer name
+type Controller struct {
+	ctx context.Context
+
+	signerName string
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+
+	csrLister certificateslisters.CertificateSigningRequestLister
+	csrSynced cache.InformerSynced
+
+	recorder        record.EventRecorder
+	syncHandlerFunc SyncHandlerFunc
+}
+
+func NewController(ctx context.Context, signerName string,
+	csrInformer certificatesinformers.CertificateSigningRequestInformer,
+	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	if syncHandlerFunc == nil {
+		return nil, fmt.Errorf("syncHandlerFunc must be set")
+	}
+
+	c := &Controller{
+		ctx:             ctx,
+		signerName:      signerName,
+		workqueue:       workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "certificateSigningRequest"),
+		csrLister:       csrInformer.Lister(),
+		csrSynced:       csrInformer.Informer().HasSynced,
+		recorder:        recorder,
+		syncHandlerFunc: syncHandlerFunc,
+	}
+
+	// Manage the addition/update of CertificateSigningRequest
+	csrInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch t := obj.(type) {
+			case *certificatesv1.CertificateSigningRequest:
+				return t.Spec.SignerName == signerName
+			case cache.DeletedFinalStateUnknown:
+				if csr, ok := t.Obj.(*certificatesv1.CertificateSigningRequest); ok {
+					return csr.Spec.SignerName == signerName
+				}
+				return false
+			default:
+				return false
+			}
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.addCSR,
+			UpdateFunc: c.updateCSR,
+		},
+	})
+
+	return c, nil
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	klog.Infof("starting certificatesigningrequest controller for signer %s...", c.signerName)
+	defer klog.Info("shutting down certificatesigningrequest controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(stopCh, c.csrSynced) {
+		return
+	}
+
+	klog.V(5).Infof("starting %d worker threads", workers)
+	// Launch workers to process CertificateSigningRequest resources
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
+	}
+
+	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (c *Controller) addCSR(obj interface{}) {
+	csr := obj.(*certificatesv1.CertificateSigningRequest)
+	klog.V(4).Infof("adding CertificateSigningRequest %q", klog.KObj(csr))
+	c.enqueue(csr)
+}
+
+func (c *Controller) updateCSR(old, cur interface{}) {
+	oldCSR := old.(*certificatesv1.CertificateSigningRequest)
+	newCSR := cur.(*certificatesv1.CertificateSigningRequest)
+	klog.V(4).Infof("updating CertificateSigningRequest %q", klog.KObj(oldCSR))
+	c.enqueue(newCSR)
+}
+
+// enqueue takes a CertificateSigningRequest resource and converts it into a name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than CertificateSigningRequest.
+func (c *Controller) enqueue(csr *certificatesv1.CertificateSigningRequest) {
+	key, err := cache.MetaNamespaceKeyFunc(csr)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form name.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the name string of the
+		// CertificateSigningRequest resource to be synced.
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("certificateSigningRequest", start, err)
+		if err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.V(4).Infof("successfully synced CertificateSigningRequest %q", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler passes the CertificateSigningRequests that are neither issued nor finished to syncHandlerFunc.
+func (c *Controller) syncHandler(key string) error {
+	klog.V(4).Infof("start processing CertificateSigningRequest %q", key)
+	// Get the CertificateSigningRequest resource with this name
+	csr, err := c.csrLister.Get(key)
+	// The CertificateSigningRequest resource may no longer exist, in which case we stop processing.
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("CertificateSigningRequest %q has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if csr.DeletionTimestamp != nil || len(csr.Status.Certificate) > 0 || IsFinished(csr) {
+		return nil
+	}
+
+	err = c.syncHandlerFunc(csr.DeepCopy())
+	if err != nil {
+		c.recorder.Event(csr, corev1.EventTypeWarning, "FailedSynced", err.Error())
+	}
+	return err
+}
+
+// IsFinished returns true if the CertificateSigningRequest has been denied or failed.
+func IsFinished(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certificatesv1.CertificateDenied || c.Type == certificatesv1.CertificateFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApproved returns true if the CertificateSigningRequest has been approved.
+func IsApproved(csr *certificatesv1.CertificateSigningRequest) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == certificatesv1.CertificateApproved {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentupgradeplan
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusterinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/clusters/v1beta1"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+)
+
+// controllerKind contains the schema.GroupVersionKind for this controller type.
+var controllerKind = clusterapi.SchemeGroupVersion.WithKind("AgentUpgradePlan")
+
+type SyncHandlerFunc func(plan *clusterapi.AgentUpgradePlan) error
+
+// Controller is a controller that handle AgentUpgradePlan
+type Controller struct {
+	ctx context.Context
+
+	clusternetClient clusternetclientset.Interface
+
+	// workqueue is a rate limited work queue. This is used to queue work to be
+	// processed instead of performing it as soon as a change happens. This
+	// means we can ensure we only process a fixed amount of resources at a
+	// time, and makes it easy to ensure we are never processing the same item
+	// simultaneously in two different workers.
+	workqueue workqueue.RateLimitingInterface
+
+	planLister clusterlisters.AgentUpgradePlanLister
+	planSynced cache.InformerSynced
+
+	recorder        record.EventRecorder
+	syncHandlerFunc SyncHandlerFunc
+}
+
+func NewController(ctx context.Context, clusternetClient clusternetclientset.Interface,
+	planInformer clusterinformers.AgentUpgradePlanInformer,
+	recorder record.EventRecorder, syncHandlerFunc SyncHandlerFunc) (*Controller, error) {
+	if syncHandlerFunc == nil {
+		return nil, fmt.Errorf("syncHandlerFunc must be set")
+	}
+
+	c := &Controller{
+		ctx:              ctx,
+		clusternetClient: clusternetClient,
+		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "agentUpgradePlan"),
+		planLister:       planInformer.Lister(),
+		planSynced:       planInformer.Informer().HasSynced,
+		recorder:         recorder,
+		syncHandlerFunc:  syncHandlerFunc,
+	}
+
+	// Manage the addition/update of AgentUpgradePlan
+	planInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.addPlan,
+		UpdateFunc: c.updatePlan,
+	})
+
+	return c, nil
+}
+
+// Run will set up the event handlers for types we are interested in, as well
+// as syncing informer caches and starting workers. It will block until stopCh
+// is closed, at which point it will shutdown the workqueue and wait for
+// workers to finish processing their current work items.
+func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
+	defer utilruntime.HandleCrash()
+	defer c.workqueue.ShutDown()
+
+	klog.Info("starting agentupgradeplan controller...")
+	defer klog.Info("shutting down agentupgradeplan controller")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(stopCh, c.planSynced) {
+		return
+	}
+
+	klog.V(5).Infof("starting %d worker threads", workers)
+	// Launch workers to process AgentUpgradePlan resources
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
+	}
+
+	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (c *Controller) addPlan(obj interface{}) {
+	plan := obj.(*clusterapi.AgentUpgradePlan)
+	klog.V(4).Infof("adding AgentUpgradePlan %q", klog.KObj(plan))
+	c.enqueue(plan)
+}
+
+func (c *Controller) updatePlan(old, cur interface{}) {
+	oldPlan := old.(*clusterapi.AgentUpgradePlan)
+	newPlan := cur.(*clusterapi.AgentUpgradePlan)
+
+	// the status is updated by ourselves, only resync on spec changes
+	if reflect.DeepEqual(oldPlan.Spec, newPlan.Spec) {
+		klog.V(4).Infof("no updates on the spec of AgentUpgradePlan %s, skipping syncing", klog.KObj(oldPlan))
+		return
+	}
+
+	klog.V(4).Infof("updating AgentUpgradePlan %q", klog.KObj(oldPlan))
+	c.enqueue(newPlan)
+}
+
+// runWorker is a long-running function that will continually call the
+// processNextWorkItem function in order to read and process a message on the
+// workqueue.
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem will read a single work item off the workqueue and
+// attempt to process it, by calling the syncHandler.
+func (c *Controller) processNextWorkItem() bool {
+	obj, shutdown := c.workqueue.Get()
+
+	if shutdown {
+		return false
+	}
+
+	// We wrap this block in a func so we can defer c.workqueue.Done.
+	err := func(obj interface{}) error {
+		// We call Done here so the workqueue knows we have finished
+		// processing this item. We also must remember to call Forget if we
+		// do not want this work item being re-queued. For example, we do
+		// not call Forget if a transient error occurs, instead the item is
+		// put back on the workqueue and attempted again after a back-off
+		// period.
+		defer c.workqueue.Done(obj)
+		var key string
+		var ok bool
+		// We expect strings to come off the workqueue. These are of the
+		// form name. We do this as the delayed nature of the workqueue means
+		// the items in the informer cache may actually be more up to date
+		// that when the item was initially put onto the workqueue.
+		if key, ok = obj.(string); !ok {
+			// As the item in the workqueue is actually invalid, we call
+			// Forget here else we'd go into a loop of attempting to
+			// process a work item that is invalid.
+			c.workqueue.Forget(obj)
+			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+			return nil
+		}
+		// Run the syncHandler, passing it the name string of the
+		// AgentUpgradePlan resource to be synced.
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("agentUpgradePlan", start, err)
+		if err != nil {
+			// Put the item back on the workqueue to handle any transient errors.
+			c.workqueue.AddRateLimited(key)
+			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
+		}
+		// Finally, if no error occurs we Forget this item so it does not
+		// get queued again until another change happens.
+		c.workqueue.Forget(obj)
+		klog.Infof("successfully synced AgentUpgradePlan %q", key)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		return true
+	}
+
+	return true
+}
+
+// syncHandler compares the actual state with the desired, and attempts to
+// converge the two. It then updates the Status block of the AgentUpgradePlan resource
+// with the current status of the resource.
+func (c *Controller) syncHandler(key string) error {
+	// If an error occurs during handling, we'll requeue the item so we can
+	// attempt processing again later. This could have been caused by a
+	// temporary network failure, or any other transient reason.
+
+	klog.V(4).Infof("start processing AgentUpgradePlan %q", key)
+	// Get the AgentUpgradePlan resource with this name
+	plan, err := c.planLister.Get(key)
+	// The AgentUpgradePlan resource may no longer exist, in which case we stop processing.
+	if errors.IsNotFound(err) {
+		klog.V(2).Infof("AgentUpgradePlan %q has been deleted", key)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if plan.DeletionTimestamp != nil {
+		return nil
+	}
+
+	plan = plan.DeepCopy()
+	plan.Kind = controllerKind.Kind
+	plan.APIVersion = controllerKind.Version
+	err = c.syncHandlerFunc(plan)
+	if err != nil {
+		c.recorder.Event(plan, corev1.EventTypeWarning, "FailedSynced", err.Error())
+	}
+	return err
+}
+
+func (c *Controller) UpdatePlanStatus(plan *clusterapi.AgentUpgradePlan, status *clusterapi.AgentUpgradePlanStatus) error {
+	// NEVER modify objects from the store. It's a read-only, local cache.
+	// You can use DeepCopy() to make a deep copy of original object and modify this copy
+	// Or create a copy manually for better performance
+
+	klog.V(5).Infof("try to update AgentUpgradePlan %q status", plan.Name)
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		plan.Status = *status
+		plan.Status.ObservedGeneration = plan.Generation
+		_, err := c.clusternetClient.ClustersV1beta1().AgentUpgradePlans().UpdateStatus(c.ctx, plan, metav1.UpdateOptions{})
+		if err == nil {
+			return nil
+		}
+
+		if updated, err := c.planLister.Get(plan.Name); err == nil {
+			// make a copy so we don't mutate the shared cache
+			plan = updated.DeepCopy()
+		} else {
+			utilruntime.HandleError(fmt.Errorf("error getting updated AgentUpgradePlan %q from lister: %v", plan.Name, err))
+		}
+		return err
+	})
+}
+
+// EnqueueAfter adds the AgentUpgradePlan to the work queue after given duration,
+// which is used to check the health of upgrading clusters periodically.
+func (c *Controller) EnqueueAfter(plan *clusterapi.AgentUpgradePlan, duration time.Duration) {
+	c.workqueue.AddAfter(plan.Name, duration)
+}
+
+// enqueue takes a AgentUpgradePlan resource and converts it into a name
+// string which is then put onto the work queue. This method should *not* be
+// passed resources of any type other than AgentUpgradePlan.
+func (c *Controller) enqueue(plan *clusterapi.AgentUpgradePlan) {
+	key, err := cache.MetaNamespaceKeyFunc(plan)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.Add(key)
+}
@@ -33,9 +33,12 @@ import (
 	"k8s.io/klog/v2"
 
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
 	clusternetClientSet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	crrsInformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/clusters/v1beta1"
 	crrsListers "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
 )
 
 const (
@@ -115,11 +118,17 @@ func (c *Controller) Run(workers int, stopCh <-chan struct{}) {
 
 	klog.V(2).Infof("starting %d worker threads", workers)
 	// Launch workers to process ClusterRegistrationRequest resources
+	var wg wait.Group
 	for i := 0; i < workers; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		wg.Start(func() {
+			wait.Until(c.runWorker, time.Second, stopCh)
+		})
 	}
 
 	<-stopCh
+	// drain the items left in the workqueue before returning
+	c.workqueue.ShutDown()
+	wg.Wait()
 }
 
 func (c *Controller) addCRR(obj interface{}) {
@@ -132,8 +141,11 @@ func (c *Controller) updateCRR(old, cur interface{}) {
 	oldCrr := old.(*clusterapi.ClusterRegistrationRequest)
 	newCrr := cur.(*clusterapi.ClusterRegistrationRequest)
 
-	// Decide whether discovery has reported a spec change.
-	if reflect.DeepEqual(oldCrr.Spec, newCrr.Spec) {
+	// Decide whether discovery has reported a spec change, a renewal gets requested,
+	// or the result gets changed manually.
+	if reflect.DeepEqual(oldCrr.Spec, newCrr.Spec) &&
+		oldCrr.Annotations[known.RenewRegistrationAnnotation] == newCrr.Annotations[known.RenewRegistrationAnnotation] &&
+		reflect.DeepEqual(oldCrr.Status.Result, newCrr.Status.Result) {
 		klog.V(4).Infof("no updates on the spec of ClusterRegistrationRequest %q, skipping syncing", oldCrr.Name)
 		return
 	}
@@ -203,7 +215,10 @@ func (c *Controller) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// ClusterRegistrationRequest resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		metrics.ObserveReconcile("cluster-registration-requests", start, err)
+		if err != nil {
 			// Put the item back on the workqueue to handle any transient errors.
 			c.workqueue.AddRateLimited(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
@@ -279,7 +294,7 @@ func (c *Controller) UpdateCRRStatus(crr *clusterapi.ClusterRegistrationRequest,
 	}
 
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		crr.Status = *status
+		utils.SetClusterRegistrationRequestStatus(crr, *status)
 		_, err := c.clusternetClient.ClustersV1beta1().ClusterRegistrationRequests().UpdateStatus(c.ctx, crr, metav1.UpdateOptions{})
 		if err == nil {
 			klog.V(4).Infof("successfully update status of ClusterRegistrationRequest %q to %q", crr.Name, status.Result)
@@ -296,6 +311,17 @@ func (c *Controller) UpdateCRRStatus(crr *clusterapi.ClusterRegistrationRequest,
 	})
 }
 
+// EnqueueAfter adds the ClusterRegistrationRequest to the work queue after given duration,
+// which is used to review the held requests again.
+func (c *Controller) EnqueueAfter(crr *clusterapi.ClusterRegistrationRequest, duration time.Duration) {
+	key, err := cache.MetaNamespaceKeyFunc(crr)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueue.AddAfter(key, duration)
+}
+
 // enqueue takes a ClusterRegistrationRequest resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than ClusterRegistrationRequest.
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// APIResourcesCollectorName is the name of the collector reading served API groups and installed CRDs
+const APIResourcesCollectorName = "api-resources"
+
+// crdPaths are where CustomResourceDefinitions are served, with v1beta1 for clusters older than Kubernetes v1.16
+var crdPaths = []string{
+	"/apis/apiextensions.k8s.io/v1/customresourcedefinitions",
+	"/apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions",
+}
+
+// partialObjectMetadataListAccept asks for metadata only, since the schemas of CRDs could be huge
+const partialObjectMetadataListAccept = "application/json;as=PartialObjectMetadataList;v=v1;g=meta.k8s.io,application/json"
+
+func init() {
+	RegisterCollector(APIResourcesCollectorName, newAPIResourcesCollector, true)
+}
+
+// apiResourcesCollector collects the served group versions and the names of installed CRDs,
+// so that the scheduler could skip clusters lacking APIs required by the feeds.
+type apiResourcesCollector struct {
+	kubeClient kubernetes.Interface
+}
+
+func newAPIResourcesCollector(cc *CollectorContext) (Collector, error) {
+	return &apiResourcesCollector{kubeClient: cc.KubeClient}, nil
+}
+
+func (a *apiResourcesCollector) Name() string {
+	return APIResourcesCollectorName
+}
+
+func (a *apiResourcesCollector) Collect(ctx context.Context, status *clusterapi.ManagedClusterStatus) error {
+	var allErrs []error
+
+	groups, err := a.kubeClient.Discovery().ServerGroups()
+	if err != nil {
+		allErrs = append(allErrs, fmt.Errorf("failed to discover api groups: %v", err))
+	} else {
+		status.APIGroupVersions = getGroupVersions(groups)
+	}
+
+	crds, err := a.listCRDNames(ctx)
+	if err != nil {
+		allErrs = append(allErrs, fmt.Errorf("failed to list CustomResourceDefinitions: %v", err))
+	} else {
+		status.CRDs = crds
+	}
+
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// listCRDNames lists the sorted names of CRDs, by fetching their metadata only.
+func (a *apiResourcesCollector) listCRDNames(ctx context.Context) ([]string, error) {
+	var data []byte
+	var err error
+	for _, path := range crdPaths {
+		data, err = a.kubeClient.Discovery().RESTClient().Get().AbsPath(path).
+			SetHeader("Accept", partialObjectMetadataListAccept).DoRaw(ctx)
+		if !apierrors.IsNotFound(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := &metav1.PartialObjectMetadataList{}
+	if err = json.Unmarshal(data, list); err != nil {
+		return nil, err
+	}
+	names := sets.NewString()
+	for _, item := range list.Items {
+		names.Insert(item.Name)
+	}
+	return names.List(), nil
+}
+
+// getGroupVersions returns the sorted group versions, such as "v1" for the legacy core group and "apps/v1".
+func getGroupVersions(groups *metav1.APIGroupList) []string {
+	groupVersions := sets.NewString()
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			groupVersions.Insert(version.GroupVersion)
+		}
+	}
+	return groupVersions.List()
+}
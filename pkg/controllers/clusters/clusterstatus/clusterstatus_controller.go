@@ -18,21 +18,19 @@ package clusterstatus
 
 import (
 	"context"
-	"net/http"
+	"fmt"
 	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/apimachinery/pkg/version"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	corev1Lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
 	"k8s.io/klog/v2"
 
 	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
@@ -42,11 +40,15 @@ import (
 const (
 	// default resync time
 	defaultResync = time.Hour * 12
+
+	// refreshing on node changes is limited to once per 5 seconds,
+	// which avoids collecting status too frequently when many nodes flip at the same time
+	defaultRefreshQPS   = 0.2
+	defaultRefreshBurst = 1
 )
 
 // Controller is a controller that collects cluster status
 type Controller struct {
-	kubeClient       kubernetes.Interface
 	lock             *sync.Mutex
 	clusterStatus    *clusterapi.ManagedClusterStatus
 	collectingPeriod metav1.Duration
@@ -54,84 +56,151 @@ type Controller struct {
 	appPusherEnabled bool
 	useSocket        bool
 	parentAPIServer  string
-	nodeLister       corev1Lister.NodeLister
-	podLister        corev1Lister.PodLister
-	nodeListerSynced cache.InformerSynced
-	podListerSynced  cache.InformerSynced
+	podSecurityLevel clusterapi.PodSecurityLevel
+	informerFactory  informers.SharedInformerFactory
+	collectors       []Collector
+
+	// refreshCh is used to trigger an immediate collecting on node changes or on demand
+	refreshCh      chan struct{}
+	refreshLimiter flowcontrol.RateLimiter
+	// statusUpdatedCh notifies that the status gets refreshed on node changes or on demand
+	statusUpdatedCh chan struct{}
 }
 
-func NewController(ctx context.Context, apiserverURL, parentAPIServerURL string, kubeClient kubernetes.Interface, collectingPeriod metav1.Duration) *Controller {
+// NewController creates a Controller with the enabled collectors.
+// '*' enables all the collectors that are enabled by default, and '-foo' disables collector 'foo'.
+func NewController(ctx context.Context, apiserverURL, parentAPIServerURL string, kubeClient kubernetes.Interface,
+	collectingPeriod metav1.Duration, enabledCollectors []string, podSecurityLevel clusterapi.PodSecurityLevel) (*Controller, error) {
 	k8sFactory := informers.NewSharedInformerFactory(kubeClient, defaultResync)
-	k8sFactory.Core().V1().Nodes().Informer()
-	k8sFactory.Core().V1().Pods().Informer()
-	k8sFactory.Start(ctx.Done())
+	collectors, err := newCollectors(&CollectorContext{
+		KubeClient:      kubeClient,
+		InformerFactory: k8sFactory,
+	}, enabledCollectors)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Controller{
-		kubeClient:       kubeClient,
+	c := &Controller{
 		lock:             &sync.Mutex{},
 		collectingPeriod: collectingPeriod,
 		apiserverURL:     apiserverURL,
 		appPusherEnabled: utilfeature.DefaultFeatureGate.Enabled(features.AppPusher),
 		useSocket:        utilfeature.DefaultFeatureGate.Enabled(features.SocketConnection),
 		parentAPIServer:  parentAPIServerURL,
-		nodeLister:       k8sFactory.Core().V1().Nodes().Lister(),
-		nodeListerSynced: k8sFactory.Core().V1().Nodes().Informer().HasSynced,
-		podLister:        k8sFactory.Core().V1().Pods().Lister(),
-		podListerSynced:  k8sFactory.Core().V1().Pods().Informer().HasSynced,
+		podSecurityLevel: podSecurityLevel,
+		informerFactory:  k8sFactory,
+		collectors:       collectors,
+		refreshCh:        make(chan struct{}, 1),
+		refreshLimiter:   flowcontrol.NewTokenBucketRateLimiter(defaultRefreshQPS, defaultRefreshBurst),
+		statusUpdatedCh:  make(chan struct{}, 1),
 	}
+	k8sFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.triggerRefresh()
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			oldNode, ok := old.(*corev1.Node)
+			if !ok {
+				return
+			}
+			curNode, ok := cur.(*corev1.Node)
+			if !ok {
+				return
+			}
+			if nodeStatusChanged(oldNode, curNode) {
+				c.triggerRefresh()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.triggerRefresh()
+		},
+	})
+	k8sFactory.Start(ctx.Done())
 
+	return c, nil
 }
 
 func (c *Controller) Run(ctx context.Context) {
-	if !cache.WaitForNamedCacheSync("cluster-status-controller", ctx.Done(),
-		c.podListerSynced, c.nodeListerSynced) {
-		return
-	}
-
-	wait.UntilWithContext(ctx, c.collectingClusterStatus, c.collectingPeriod.Duration)
-}
-
-func (c *Controller) collectingClusterStatus(ctx context.Context) {
-	klog.V(7).Info("collecting cluster status...")
-	clusterVersion, err := c.getKubernetesVersion(ctx)
-	if err != nil {
-		klog.Warningf("failed to collect kubernetes version: %v", err)
+	klog.V(5).Info("waiting for informer caches of cluster-status-controller to sync")
+	for informerType, synced := range c.informerFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			klog.Errorf("failed to sync informer cache for %v", informerType)
+			return
+		}
 	}
 
-	nodes, err := c.nodeLister.List(labels.Everything())
-	if err != nil {
-		klog.Warningf("failed to list nodes: %v", err)
+	ticker := time.NewTicker(c.collectingPeriod.Duration)
+	defer ticker.Stop()
+	c.collectingClusterStatus(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.collectingClusterStatus(ctx)
+		case <-c.refreshCh:
+			if err := c.refreshLimiter.Wait(ctx); err != nil {
+				return
+			}
+			klog.V(5).Info("refreshing cluster status on node changes or on demand")
+			c.collectingClusterStatus(ctx)
+			select {
+			case c.statusUpdatedCh <- struct{}{}:
+			default:
+			}
+		}
 	}
+}
 
-	nodeStatistics := getNodeStatistics(nodes)
+// StatusUpdated returns a channel, which receives a notification once the cluster status gets refreshed
+// on node changes, such as node Ready condition flips and capacity changes, or on demand by calling Refresh.
+func (c *Controller) StatusUpdated() <-chan struct{} {
+	return c.statusUpdatedCh
+}
 
-	capacity, allocatable := getNodeResource(nodes)
+// Refresh requests an immediate collecting outside the collecting period.
+func (c *Controller) Refresh() {
+	c.triggerRefresh()
+}
 
-	clusterCIDR, err := c.discoverClusterCIDR()
-	if err != nil {
-		klog.Warningf("failed to discover cluster CIDR: %v", err)
+// triggerRefresh triggers an immediate collecting, which is merged with the pending one if any.
+func (c *Controller) triggerRefresh() {
+	select {
+	case c.refreshCh <- struct{}{}:
+	default:
 	}
+}
 
-	serviceCIDR, err := c.discoverServiceCIDR()
-	if err != nil {
-		klog.Warningf("failed to discover service CIDR: %v", err)
+// nodeStatusChanged checks whether the changes of the node affect the cluster status,
+// including the Ready condition, capacity and allocatable resources.
+func nodeStatusChanged(old, cur *corev1.Node) bool {
+	_, oldReady := getNodeCondition(&old.Status, corev1.NodeReady)
+	_, curReady := getNodeCondition(&cur.Status, corev1.NodeReady)
+	if (oldReady == nil) != (curReady == nil) {
+		return true
+	}
+	if oldReady != nil && oldReady.Status != curReady.Status {
+		return true
 	}
+	return !equality.Semantic.DeepEqual(old.Status.Capacity, cur.Status.Capacity) ||
+		!equality.Semantic.DeepEqual(old.Status.Allocatable, cur.Status.Allocatable)
+}
+
+func (c *Controller) collectingClusterStatus(ctx context.Context) {
+	klog.V(7).Info("collecting cluster status...")
 
 	var status clusterapi.ManagedClusterStatus
-	status.KubernetesVersion = clusterVersion.GitVersion
-	status.Platform = clusterVersion.Platform
 	status.APIServerURL = c.apiserverURL
-	status.Healthz = c.getHealthStatus(ctx, "/healthz")
-	status.Livez = c.getHealthStatus(ctx, "/livez")
-	status.Readyz = c.getHealthStatus(ctx, "/readyz")
 	status.AppPusher = c.appPusherEnabled
 	status.UseSocket = c.useSocket
 	status.ParentAPIServerURL = c.parentAPIServer
-	status.ClusterCIDR = clusterCIDR
-	status.ServiceCIDR = serviceCIDR
-	status.NodeStatistics = nodeStatistics
-	status.Allocatable = allocatable
-	status.Capacity = capacity
+	status.PodSecurityLevel = c.podSecurityLevel
+	for _, collector := range c.collectors {
+		if err := collector.Collect(ctx, &status); err != nil {
+			klog.Warningf("failed to collect cluster status with collector %s: %v", collector.Name(), err)
+		}
+	}
+	setReadyCondition(&status)
 	c.setClusterStatus(status)
 }
 
@@ -143,6 +212,7 @@ func (c *Controller) setClusterStatus(status clusterapi.ManagedClusterStatus) {
 		c.clusterStatus = new(clusterapi.ManagedClusterStatus)
 	}
 
+	inheritTransitionTimes(status.Conditions, c.clusterStatus.Conditions)
 	c.clusterStatus = &status
 	c.clusterStatus.LastObservedTime = metav1.Now()
 	klog.V(7).Infof("current cluster status is %#v", status)
@@ -159,16 +229,6 @@ func (c *Controller) GetClusterStatus() *clusterapi.ManagedClusterStatus {
 	return c.clusterStatus.DeepCopy()
 }
 
-func (c *Controller) getKubernetesVersion(_ context.Context) (*version.Info, error) {
-	return c.kubeClient.Discovery().ServerVersion()
-}
-
-func (c *Controller) getHealthStatus(ctx context.Context, path string) bool {
-	var statusCode int
-	c.kubeClient.Discovery().RESTClient().Get().AbsPath(path).Do(ctx).StatusCode(&statusCode)
-	return statusCode == http.StatusOK
-}
-
 // getNodeStatistics returns the NodeStatistics in the cluster
 // get nodes num in different conditions
 func getNodeStatistics(nodes []*corev1.Node) (nodeStatistics clusterapi.NodeStatistics) {
@@ -191,36 +251,122 @@ func getNodeStatistics(nodes []*corev1.Node) (nodeStatistics clusterapi.NodeStat
 	return
 }
 
-// discoverServiceCIDR returns the service CIDR for the cluster.
-func (c *Controller) discoverServiceCIDR() (string, error) {
-	return findPodIPRange(c.nodeLister, c.podLister)
+// getPodStatistics returns the PodStatistics in the cluster, counting pods by phase
+func getPodStatistics(pods []*corev1.Pod) (podStatistics clusterapi.PodStatistics) {
+	for _, pod := range pods {
+		podStatistics.TotalPods += 1
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			podStatistics.RunningPods += 1
+		case corev1.PodPending:
+			podStatistics.PendingPods += 1
+		case corev1.PodFailed:
+			podStatistics.FailedPods += 1
+		case corev1.PodSucceeded:
+			podStatistics.SucceededPods += 1
+		}
+	}
+	return
 }
 
-// discoverClusterCIDR returns the cluster CIDR for the cluster.
-func (c *Controller) discoverClusterCIDR() (string, error) {
-	return findClusterIPRange(c.podLister)
+// getNodePlatforms returns the number of nodes per platform in the cluster
+func getNodePlatforms(nodes []*corev1.Node) map[string]int32 {
+	platforms := make(map[string]int32)
+	for _, node := range nodes {
+		platform := fmt.Sprintf("%s/%s", node.Status.NodeInfo.OperatingSystem, node.Status.NodeInfo.Architecture)
+		platforms[platform]++
+	}
+	return platforms
 }
 
 // get node capacity and allocatable resource
+// getNodeResource sums up the capacity and allocatable resources of all nodes, including cpu, memory
+// and extended resources, such as ephemeral-storage, hugepages and those advertised by device plugins.
 func getNodeResource(nodes []*corev1.Node) (Capacity, Allocatable corev1.ResourceList) {
-	var capacityCpu, capacityMem, allocatableCpu, allocatableMem resource.Quantity
 	Capacity, Allocatable = make(map[corev1.ResourceName]resource.Quantity), make(map[corev1.ResourceName]resource.Quantity)
+	// cpu and memory are always reported, even if there are no nodes
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		Capacity[name] = resource.Quantity{}
+		Allocatable[name] = resource.Quantity{}
+	}
 
 	for _, node := range nodes {
-		capacityCpu.Add(*node.Status.Capacity.Cpu())
-		capacityMem.Add(*node.Status.Capacity.Memory())
-		allocatableCpu.Add(*node.Status.Allocatable.Cpu())
-		allocatableMem.Add(*node.Status.Allocatable.Memory())
+		addResourceList(Capacity, node.Status.Capacity)
+		addResourceList(Allocatable, node.Status.Allocatable)
 	}
 
-	Capacity[corev1.ResourceCPU] = capacityCpu
-	Capacity[corev1.ResourceMemory] = capacityMem
-	Allocatable[corev1.ResourceCPU] = allocatableCpu
-	Allocatable[corev1.ResourceMemory] = allocatableMem
-
 	return
 }
 
+// getAvailableResource returns the allocatable resources minus the sum of resource requests of
+// all scheduled and non-terminated pods
+func getAvailableResource(allocatable corev1.ResourceList, pods []*corev1.Pod) corev1.ResourceList {
+	requested := make(map[corev1.ResourceName]resource.Quantity)
+	var podCount int64
+	for _, pod := range pods {
+		if !isScheduledActivePod(pod) {
+			continue
+		}
+		addResourceList(requested, getPodRequests(pod))
+		podCount++
+	}
+	requested[corev1.ResourcePods] = *resource.NewQuantity(podCount, resource.DecimalSI)
+
+	available := make(map[corev1.ResourceName]resource.Quantity)
+	for name, quantity := range allocatable {
+		value := quantity.DeepCopy()
+		if request, ok := requested[name]; ok {
+			value.Sub(request)
+		}
+		if value.Sign() < 0 {
+			value.Set(0)
+		}
+		available[name] = value
+	}
+	return available
+}
+
+// isScheduledActivePod returns whether the pod has been bound to a node and not terminated yet
+func isScheduledActivePod(pod *corev1.Pod) bool {
+	if len(pod.Spec.NodeName) == 0 {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// getPodRequests returns the effective resource requests of a pod, which is the larger one of
+// the sum of all containers and the maximum of init containers, plus pod overhead
+// Refer to k8s.io/kubernetes/pkg/api/v1/resource/helpers.go
+func getPodRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := make(map[corev1.ResourceName]resource.Quantity)
+	for _, container := range pod.Spec.Containers {
+		addResourceList(requests, container.Resources.Requests)
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if value, ok := requests[name]; !ok || quantity.Cmp(value) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	addResourceList(requests, pod.Spec.Overhead)
+	return requests
+}
+
+// addResourceList adds the resources in new to list
+func addResourceList(list, new corev1.ResourceList) {
+	for name, quantity := range new {
+		if value, ok := list[name]; !ok {
+			list[name] = quantity.DeepCopy()
+		} else {
+			value.Add(quantity)
+			list[name] = value
+		}
+	}
+}
+
 // getNodeCondition returns the specified condition from node's status
 // Copied from k8s.io/kubernetes/pkg/controller/util/node/controller_utils.go and make some modifications
 func getNodeCondition(status *corev1.NodeStatus, conditionType corev1.NodeConditionType) (int, *corev1.NodeCondition) {
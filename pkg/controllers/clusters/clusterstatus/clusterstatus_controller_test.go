@@ -0,0 +1,230 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestGetNodeResource(t *testing.T) {
+	gpu := corev1.ResourceName("nvidia.com/gpu")
+	nodes := []*corev1.Node{
+		{
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceCPU:              resource.MustParse("4"),
+					corev1.ResourceMemory:           resource.MustParse("8Gi"),
+					corev1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+					gpu:                             resource.MustParse("2"),
+				},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:              resource.MustParse("3800m"),
+					corev1.ResourceMemory:           resource.MustParse("7Gi"),
+					corev1.ResourceEphemeralStorage: resource.MustParse("90Gi"),
+					gpu:                             resource.MustParse("2"),
+				},
+			},
+		},
+		{
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+					"hugepages-2Mi":       resource.MustParse("1Gi"),
+				},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1900m"),
+					corev1.ResourceMemory: resource.MustParse("3Gi"),
+					"hugepages-2Mi":       resource.MustParse("1Gi"),
+				},
+			},
+		},
+	}
+
+	capacity, allocatable := getNodeResource(nodes)
+
+	tests := []struct {
+		list     corev1.ResourceList
+		name     corev1.ResourceName
+		expected string
+	}{
+		{capacity, corev1.ResourceCPU, "6"},
+		{capacity, corev1.ResourceMemory, "12Gi"},
+		{capacity, corev1.ResourceEphemeralStorage, "100Gi"},
+		{capacity, gpu, "2"},
+		{capacity, "hugepages-2Mi", "1Gi"},
+		{allocatable, corev1.ResourceCPU, "5700m"},
+		{allocatable, corev1.ResourceMemory, "10Gi"},
+		{allocatable, gpu, "2"},
+	}
+	for _, tt := range tests {
+		got := tt.list[tt.name]
+		if got.Cmp(resource.MustParse(tt.expected)) != 0 {
+			t.Errorf("expected %s to be %s, got %s", tt.name, tt.expected, got.String())
+		}
+	}
+
+	// cpu and memory are reported even without nodes
+	capacity, _ = getNodeResource(nil)
+	if _, ok := capacity[corev1.ResourceCPU]; !ok {
+		t.Errorf("expected cpu in capacity")
+	}
+}
+
+func TestGetAvailableResource(t *testing.T) {
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("8Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+	}
+	newContainer := func(cpu, memory string) corev1.Container {
+		return corev1.Container{
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+	pods := []*corev1.Pod{
+		{
+			Spec: corev1.PodSpec{
+				NodeName:       "node-1",
+				Containers:     []corev1.Container{newContainer("500m", "1Gi"), newContainer("500m", "1Gi")},
+				InitContainers: []corev1.Container{newContainer("1500m", "512Mi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		{
+			// pending pods that are not scheduled yet
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{newContainer("1", "1Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		{
+			// terminated pods
+			Spec: corev1.PodSpec{
+				NodeName:   "node-1",
+				Containers: []corev1.Container{newContainer("1", "1Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+		{
+			Spec: corev1.PodSpec{
+				NodeName:   "node-2",
+				Containers: []corev1.Container{newContainer("4", "1Gi")},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	}
+
+	available := getAvailableResource(allocatable, pods)
+
+	tests := []struct {
+		name     corev1.ResourceName
+		expected string
+	}{
+		{corev1.ResourceCPU, "0"},
+		{corev1.ResourceMemory, "5Gi"},
+		{corev1.ResourcePods, "108"},
+	}
+	for _, tt := range tests {
+		got := available[tt.name]
+		if got.Cmp(resource.MustParse(tt.expected)) != 0 {
+			t.Errorf("expected available %s to be %s, got %s", tt.name, tt.expected, got.String())
+		}
+	}
+}
+
+func TestGetPodStatistics(t *testing.T) {
+	newPod := func(phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{Status: corev1.PodStatus{Phase: phase}}
+	}
+	pods := []*corev1.Pod{
+		newPod(corev1.PodRunning),
+		newPod(corev1.PodRunning),
+		newPod(corev1.PodPending),
+		newPod(corev1.PodFailed),
+		newPod(corev1.PodSucceeded),
+		newPod(corev1.PodUnknown),
+	}
+
+	got := getPodStatistics(pods)
+	want := clusterapi.PodStatistics{TotalPods: 6, RunningPods: 2, PendingPods: 1, FailedPods: 1, SucceededPods: 1}
+	if got != want {
+		t.Errorf("getPodStatistics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNodeStatusChanged(t *testing.T) {
+	newNode := func(ready corev1.ConditionStatus, cpu string) *corev1.Node {
+		return &corev1.Node{
+			Status: corev1.NodeStatus{
+				Capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name string
+		old  *corev1.Node
+		cur  *corev1.Node
+		want bool
+	}{
+		{
+			name: "unchanged",
+			old:  newNode(corev1.ConditionTrue, "4"),
+			cur:  newNode(corev1.ConditionTrue, "4000m"),
+			want: false,
+		},
+		{
+			name: "ready condition flips",
+			old:  newNode(corev1.ConditionTrue, "4"),
+			cur:  newNode(corev1.ConditionUnknown, "4"),
+			want: true,
+		},
+		{
+			name: "capacity changes",
+			old:  newNode(corev1.ConditionTrue, "4"),
+			cur:  newNode(corev1.ConditionTrue, "8"),
+			want: true,
+		},
+		{
+			name: "ready condition removed",
+			old:  newNode(corev1.ConditionTrue, "4"),
+			cur:  &corev1.Node{Status: corev1.NodeStatus{Capacity: newNode("", "4").Status.Capacity}},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nodeStatusChanged(tt.old, tt.cur); got != tt.want {
+				t.Errorf("nodeStatusChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// Collector collects a part of the cluster status, such as node statistics or CIDR ranges.
+// Downstream users could add their own collectors with RegisterCollector.
+type Collector interface {
+	// Name returns the name of the collector, which is used to enable/disable it.
+	Name() string
+
+	// Collect fills the collected info into the status.
+	// Collectors should fill as much as they can even if an error is returned.
+	Collect(ctx context.Context, status *clusterapi.ManagedClusterStatus) error
+}
+
+// CollectorContext holds the clients and informers shared by all the collectors.
+type CollectorContext struct {
+	// KubeClient is the clientset of the child cluster
+	KubeClient kubernetes.Interface
+	// InformerFactory is started after all the enabled collectors are created,
+	// so collectors should get their informers when being created.
+	InformerFactory informers.SharedInformerFactory
+}
+
+// CollectorFactory creates a Collector.
+// A nil Collector could be returned if it is not applicable, such as its feature gate being disabled.
+type CollectorFactory func(cc *CollectorContext) (Collector, error)
+
+type collectorRegistration struct {
+	factory          CollectorFactory
+	enabledByDefault bool
+}
+
+var (
+	collectorsLock sync.RWMutex
+	collectors     = map[string]collectorRegistration{}
+)
+
+// RegisterCollector registers a collector with the given name.
+// It panics if a collector with the same name has been registered.
+func RegisterCollector(name string, factory CollectorFactory, enabledByDefault bool) {
+	collectorsLock.Lock()
+	defer collectorsLock.Unlock()
+
+	if _, ok := collectors[name]; ok {
+		panic(fmt.Sprintf("cluster status collector %q has been registered", name))
+	}
+	collectors[name] = collectorRegistration{
+		factory:          factory,
+		enabledByDefault: enabledByDefault,
+	}
+}
+
+// KnownCollectors returns the names of all the registered collectors.
+func KnownCollectors() []string {
+	collectorsLock.RLock()
+	defer collectorsLock.RUnlock()
+
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateCollectors validates the names of collectors to enable/disable.
+func ValidateCollectors(enabled []string) error {
+	knownCollectors := sets.NewString(KnownCollectors()...)
+	for _, name := range enabled {
+		name = strings.TrimPrefix(name, "-")
+		if name == "*" {
+			continue
+		}
+		if !knownCollectors.Has(name) {
+			return fmt.Errorf("unknown cluster status collector %q, known collectors are %v", name, knownCollectors.List())
+		}
+	}
+	return nil
+}
+
+// isCollectorEnabled checks whether the collector is enabled.
+// '*' means "all enabled by default collectors", 'foo' means "enable 'foo'", '-foo' means "disable 'foo'".
+// The first item for a particular name wins.
+func isCollectorEnabled(name string, enabledByDefault bool, enabled []string) bool {
+	hasStar := false
+	for _, item := range enabled {
+		if item == name {
+			return true
+		}
+		if item == "-"+name {
+			return false
+		}
+		if item == "*" {
+			hasStar = true
+		}
+	}
+	return hasStar && enabledByDefault
+}
+
+// newCollectors creates all the enabled collectors in order of their names.
+func newCollectors(cc *CollectorContext, enabled []string) ([]Collector, error) {
+	var result []Collector
+	for _, name := range KnownCollectors() {
+		collectorsLock.RLock()
+		registration := collectors[name]
+		collectorsLock.RUnlock()
+
+		if !isCollectorEnabled(name, registration.enabledByDefault, enabled) {
+			continue
+		}
+		collector, err := registration.factory(cc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cluster status collector %q: %v", name, err)
+		}
+		if collector == nil {
+			continue
+		}
+		result = append(result, collector)
+	}
+	return result, nil
+}
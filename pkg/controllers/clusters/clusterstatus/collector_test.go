@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"testing"
+)
+
+func TestIsCollectorEnabled(t *testing.T) {
+	tests := []struct {
+		name             string
+		enabledByDefault bool
+		enabled          []string
+		want             bool
+	}{
+		{
+			name:             "star enables on-by-default collectors",
+			enabledByDefault: true,
+			enabled:          []string{"*"},
+			want:             true,
+		},
+		{
+			name:    "star does not enable off-by-default collectors",
+			enabled: []string{"*"},
+			want:    false,
+		},
+		{
+			name:    "explicitly enabled",
+			enabled: []string{"*", "foo"},
+			want:    true,
+		},
+		{
+			name:             "explicitly disabled",
+			enabledByDefault: true,
+			enabled:          []string{"-foo", "*"},
+			want:             false,
+		},
+		{
+			name:             "not listed",
+			enabledByDefault: true,
+			enabled:          []string{"bar"},
+			want:             false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isCollectorEnabled("foo", tt.enabledByDefault, tt.enabled); got != tt.want {
+				t.Errorf("isCollectorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCollectors(t *testing.T) {
+	if err := ValidateCollectors([]string{"*", "-" + CIDRCollectorName, NodesCollectorName}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateCollectors([]string{"unknown"}); err == nil {
+		t.Errorf("expected error for unknown collector")
+	}
+}
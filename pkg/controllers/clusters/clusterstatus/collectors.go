@@ -0,0 +1,270 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	corev1Lister "k8s.io/client-go/listers/core/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// names of built-in collectors
+const (
+	KubernetesCollectorName = "kubernetes"
+	NodesCollectorName      = "nodes"
+	PodsCollectorName       = "pods"
+	CIDRCollectorName       = "cidr"
+)
+
+// maxNodesInMessage is the maximum number of node names listed in a condition message
+const maxNodesInMessage = 10
+
+func init() {
+	RegisterCollector(KubernetesCollectorName, newKubernetesCollector, true)
+	RegisterCollector(NodesCollectorName, newNodesCollector, true)
+	RegisterCollector(PodsCollectorName, newPodsCollector, true)
+	RegisterCollector(CIDRCollectorName, newCIDRCollector, true)
+}
+
+// kubernetesCollector collects the version and health of the kube-apiserver
+type kubernetesCollector struct {
+	kubeClient kubernetes.Interface
+}
+
+func newKubernetesCollector(cc *CollectorContext) (Collector, error) {
+	return &kubernetesCollector{kubeClient: cc.KubeClient}, nil
+}
+
+func (k *kubernetesCollector) Name() string {
+	return KubernetesCollectorName
+}
+
+func (k *kubernetesCollector) Collect(ctx context.Context, status *clusterapi.ManagedClusterStatus) error {
+	k.setAPIServerCondition(ctx, status)
+	k.setComponentCondition(ctx, status, clusterapi.ClusterSchedulerHealthy, "scheduler")
+	k.setComponentCondition(ctx, status, clusterapi.ClusterControllerManagerHealthy, "controller-manager")
+
+	clusterVersion, err := k.kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Errorf("failed to collect kubernetes version: %v", err)
+	}
+	status.KubernetesVersion = clusterVersion.GitVersion
+	status.Platform = clusterVersion.Platform
+	return nil
+}
+
+func (k *kubernetesCollector) getHealthStatus(ctx context.Context, path string) int {
+	var statusCode int
+	k.kubeClient.Discovery().RESTClient().Get().AbsPath(path).Do(ctx).StatusCode(&statusCode)
+	return statusCode
+}
+
+// setAPIServerCondition checks "/livez" and "/readyz" of the kube-apiserver,
+// and falls back to "/healthz" for clusters older than Kubernetes v1.16.
+func (k *kubernetesCollector) setAPIServerCondition(ctx context.Context, status *clusterapi.ManagedClusterStatus) {
+	var unhealthy []string
+	for _, path := range []string{"/livez", "/readyz"} {
+		statusCode := k.getHealthStatus(ctx, path)
+		if statusCode == http.StatusNotFound {
+			statusCode = k.getHealthStatus(ctx, "/healthz")
+		}
+		if statusCode != http.StatusOK {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s returns %d", path, statusCode))
+		}
+	}
+
+	if len(unhealthy) > 0 {
+		setCondition(status, clusterapi.ClusterAPIServerHealthy, metav1.ConditionFalse, "APIServerUnhealthy",
+			fmt.Sprintf("kube-apiserver is unhealthy: %s", strings.Join(unhealthy, ", ")))
+		return
+	}
+	setCondition(status, clusterapi.ClusterAPIServerHealthy, metav1.ConditionTrue, "APIServerHealthy",
+		"kube-apiserver is live and ready")
+}
+
+// setComponentCondition checks the health of a control plane component with ComponentStatus.
+// The condition is Unknown if ComponentStatus is not available, such as on managed Kubernetes services.
+func (k *kubernetesCollector) setComponentCondition(ctx context.Context, status *clusterapi.ManagedClusterStatus,
+	conditionType, component string) {
+	cs, err := k.kubeClient.CoreV1().ComponentStatuses().Get(ctx, component, metav1.GetOptions{})
+	if err != nil {
+		setCondition(status, conditionType, metav1.ConditionUnknown, "ComponentStatusUnavailable",
+			fmt.Sprintf("failed to get component status of %s: %v", component, err))
+		return
+	}
+
+	for _, condition := range cs.Conditions {
+		if condition.Type != corev1.ComponentHealthy {
+			continue
+		}
+		switch condition.Status {
+		case corev1.ConditionTrue:
+			setCondition(status, conditionType, metav1.ConditionTrue, "ComponentHealthy",
+				fmt.Sprintf("%s is healthy", component))
+		case corev1.ConditionFalse:
+			setCondition(status, conditionType, metav1.ConditionFalse, "ComponentUnhealthy",
+				fmt.Sprintf("%s is unhealthy: %s", component, condition.Error))
+		default:
+			setCondition(status, conditionType, metav1.ConditionUnknown, "ComponentStatusUnknown",
+				fmt.Sprintf("health of %s is unknown: %s", component, condition.Error))
+		}
+		return
+	}
+	setCondition(status, conditionType, metav1.ConditionUnknown, "ComponentStatusUnknown",
+		fmt.Sprintf("no health condition is reported for %s", component))
+}
+
+// nodesCollector collects node statistics, platforms and resources
+type nodesCollector struct {
+	nodeLister corev1Lister.NodeLister
+}
+
+func newNodesCollector(cc *CollectorContext) (Collector, error) {
+	return &nodesCollector{nodeLister: cc.InformerFactory.Core().V1().Nodes().Lister()}, nil
+}
+
+func (n *nodesCollector) Name() string {
+	return NodesCollectorName
+}
+
+func (n *nodesCollector) Collect(_ context.Context, status *clusterapi.ManagedClusterStatus) error {
+	nodes, err := n.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	status.NodeStatistics = getNodeStatistics(nodes)
+	status.NodePlatforms = getNodePlatforms(nodes)
+	status.Capacity, status.Allocatable = getNodeResource(nodes)
+	setNetworkCondition(status, nodes)
+	return nil
+}
+
+// setNetworkCondition checks whether the network of all the ready nodes is correctly configured.
+func setNetworkCondition(status *clusterapi.ManagedClusterStatus, nodes []*corev1.Node) {
+	var readyNodes int
+	var unavailable []string
+	for _, node := range nodes {
+		if _, condition := getNodeCondition(&node.Status, corev1.NodeReady); condition == nil ||
+			condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		readyNodes++
+		if _, condition := getNodeCondition(&node.Status, corev1.NodeNetworkUnavailable); condition != nil &&
+			condition.Status == corev1.ConditionTrue {
+			unavailable = append(unavailable, node.Name)
+		}
+	}
+
+	switch {
+	case readyNodes == 0:
+		setCondition(status, clusterapi.ClusterNetworkReady, metav1.ConditionFalse, "NoReadyNodes",
+			"there are no ready nodes in the cluster")
+	case len(unavailable) > 0:
+		count := len(unavailable)
+		sort.Strings(unavailable)
+		if count > maxNodesInMessage {
+			unavailable = append(unavailable[:maxNodesInMessage], "...")
+		}
+		setCondition(status, clusterapi.ClusterNetworkReady, metav1.ConditionFalse, "NetworkUnavailable",
+			fmt.Sprintf("network is unavailable on %d of %d ready nodes: %s", count, readyNodes,
+				strings.Join(unavailable, ", ")))
+	default:
+		setCondition(status, clusterapi.ClusterNetworkReady, metav1.ConditionTrue, "NetworkReady",
+			fmt.Sprintf("network is available on all %d ready nodes", readyNodes))
+	}
+}
+
+// podsCollector collects pod statistics, the resources available for new pods, and how fragmented they are
+type podsCollector struct {
+	nodeLister corev1Lister.NodeLister
+	podLister  corev1Lister.PodLister
+}
+
+func newPodsCollector(cc *CollectorContext) (Collector, error) {
+	return &podsCollector{
+		nodeLister: cc.InformerFactory.Core().V1().Nodes().Lister(),
+		podLister:  cc.InformerFactory.Core().V1().Pods().Lister(),
+	}, nil
+}
+
+func (p *podsCollector) Name() string {
+	return PodsCollectorName
+}
+
+func (p *podsCollector) Collect(_ context.Context, status *clusterapi.ManagedClusterStatus) error {
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := p.podLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	status.PodStatistics = getPodStatistics(pods)
+	_, allocatable := getNodeResource(nodes)
+	status.Available = getAvailableResource(allocatable, pods)
+	status.NodePools = getNodePoolFragmentation(nodes, pods)
+	return nil
+}
+
+// cidrCollector discovers the CIDR ranges of pods and services
+type cidrCollector struct {
+	nodeLister corev1Lister.NodeLister
+	podLister  corev1Lister.PodLister
+}
+
+func newCIDRCollector(cc *CollectorContext) (Collector, error) {
+	return &cidrCollector{
+		nodeLister: cc.InformerFactory.Core().V1().Nodes().Lister(),
+		podLister:  cc.InformerFactory.Core().V1().Pods().Lister(),
+	}, nil
+}
+
+func (c *cidrCollector) Name() string {
+	return CIDRCollectorName
+}
+
+func (c *cidrCollector) Collect(_ context.Context, status *clusterapi.ManagedClusterStatus) error {
+	var allErrs []error
+
+	clusterCIDR, err := findClusterIPRange(c.podLister)
+	if err != nil {
+		allErrs = append(allErrs, fmt.Errorf("failed to discover cluster CIDR: %v", err))
+	}
+	status.ClusterCIDR = clusterCIDR
+
+	serviceCIDR, err := findPodIPRange(c.nodeLister, c.podLister)
+	if err != nil {
+		allErrs = append(allErrs, fmt.Errorf("failed to discover service CIDR: %v", err))
+	}
+	status.ServiceCIDR = serviceCIDR
+
+	return utilerrors.NewAggregate(allErrs)
+}
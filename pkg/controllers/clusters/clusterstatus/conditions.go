@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"fmt"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// setCondition sets the condition with the given type in the status.
+func setCondition(status *clusterapi.ManagedClusterStatus, conditionType string, conditionStatus metav1.ConditionStatus,
+	reason, message string) {
+	apimeta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:    conditionType,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// setReadyCondition rolls up all the other conditions into the Ready condition.
+// The cluster is not ready if any condition is False, and the readiness is unknown if no conditions are collected.
+func setReadyCondition(status *clusterapi.ManagedClusterStatus) {
+	var failed, unknown []string
+	collected := 0
+	for _, condition := range status.Conditions {
+		if condition.Type == clusterapi.ClusterReady {
+			continue
+		}
+		collected++
+		switch condition.Status {
+		case metav1.ConditionFalse:
+			failed = append(failed, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+		case metav1.ConditionUnknown:
+			unknown = append(unknown, condition.Type)
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		setCondition(status, clusterapi.ClusterReady, metav1.ConditionFalse, "ClusterNotReady", strings.Join(failed, "; "))
+	case collected == 0:
+		setCondition(status, clusterapi.ClusterReady, metav1.ConditionUnknown, "NoConditionsCollected",
+			"no conditions have been collected for the cluster")
+	case len(unknown) > 0:
+		setCondition(status, clusterapi.ClusterReady, metav1.ConditionTrue, "ClusterReady",
+			fmt.Sprintf("cluster is ready, but failed to determine %s", strings.Join(unknown, ", ")))
+	default:
+		setCondition(status, clusterapi.ClusterReady, metav1.ConditionTrue, "ClusterReady", "cluster is ready")
+	}
+}
+
+// inheritTransitionTimes keeps the last transition times of the conditions whose status doesn't change.
+func inheritTransitionTimes(conditions, previous []metav1.Condition) {
+	for i := range conditions {
+		old := apimeta.FindStatusCondition(previous, conditions[i].Type)
+		if old != nil && old.Status == conditions[i].Status {
+			conditions[i].LastTransitionTime = old.LastTransitionTime
+		}
+	}
+}
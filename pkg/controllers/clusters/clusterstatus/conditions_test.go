@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestSetReadyCondition(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions map[string]metav1.ConditionStatus
+		want       metav1.ConditionStatus
+	}{
+		{
+			name: "no conditions",
+			want: metav1.ConditionUnknown,
+		},
+		{
+			name: "all healthy",
+			conditions: map[string]metav1.ConditionStatus{
+				clusterapi.ClusterAPIServerHealthy: metav1.ConditionTrue,
+				clusterapi.ClusterNetworkReady:     metav1.ConditionTrue,
+			},
+			want: metav1.ConditionTrue,
+		},
+		{
+			name: "unknown scheduler",
+			conditions: map[string]metav1.ConditionStatus{
+				clusterapi.ClusterAPIServerHealthy: metav1.ConditionTrue,
+				clusterapi.ClusterSchedulerHealthy: metav1.ConditionUnknown,
+			},
+			want: metav1.ConditionTrue,
+		},
+		{
+			name: "network not ready",
+			conditions: map[string]metav1.ConditionStatus{
+				clusterapi.ClusterAPIServerHealthy: metav1.ConditionTrue,
+				clusterapi.ClusterNetworkReady:     metav1.ConditionFalse,
+			},
+			want: metav1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &clusterapi.ManagedClusterStatus{}
+			for conditionType, conditionStatus := range tt.conditions {
+				setCondition(status, conditionType, conditionStatus, "Test", "test")
+			}
+			setReadyCondition(status)
+			if got := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady); got == nil || got.Status != tt.want {
+				t.Errorf("setReadyCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInheritTransitionTimes(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	previous := []metav1.Condition{
+		{Type: clusterapi.ClusterReady, Status: metav1.ConditionTrue, LastTransitionTime: past},
+		{Type: clusterapi.ClusterNetworkReady, Status: metav1.ConditionTrue, LastTransitionTime: past},
+	}
+
+	status := &clusterapi.ManagedClusterStatus{}
+	setCondition(status, clusterapi.ClusterReady, metav1.ConditionTrue, "ClusterReady", "cluster is ready")
+	setCondition(status, clusterapi.ClusterNetworkReady, metav1.ConditionFalse, "NetworkUnavailable", "down")
+	inheritTransitionTimes(status.Conditions, previous)
+
+	if got := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady); !got.LastTransitionTime.Equal(&past) {
+		t.Errorf("expected unchanged condition to keep its last transition time")
+	}
+	if got := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterNetworkReady); got.LastTransitionTime.Equal(&past) {
+		t.Errorf("expected changed condition to get a new last transition time")
+	}
+}
+
+func TestSetNetworkCondition(t *testing.T) {
+	newNode := func(name string, ready, networkUnavailable corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{
+					{Type: corev1.NodeReady, Status: ready},
+					{Type: corev1.NodeNetworkUnavailable, Status: networkUnavailable},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		nodes []*corev1.Node
+		want  metav1.ConditionStatus
+	}{
+		{
+			name: "no ready nodes",
+			nodes: []*corev1.Node{
+				newNode("node-1", corev1.ConditionFalse, corev1.ConditionFalse),
+			},
+			want: metav1.ConditionFalse,
+		},
+		{
+			name: "network available",
+			nodes: []*corev1.Node{
+				newNode("node-1", corev1.ConditionTrue, corev1.ConditionFalse),
+				newNode("node-2", corev1.ConditionFalse, corev1.ConditionTrue),
+			},
+			want: metav1.ConditionTrue,
+		},
+		{
+			name: "network unavailable",
+			nodes: []*corev1.Node{
+				newNode("node-1", corev1.ConditionTrue, corev1.ConditionFalse),
+				newNode("node-2", corev1.ConditionTrue, corev1.ConditionTrue),
+			},
+			want: metav1.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := &clusterapi.ManagedClusterStatus{}
+			setNetworkCondition(status, tt.nodes)
+			if got := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterNetworkReady); got == nil || got.Status != tt.want {
+				t.Errorf("setNetworkCondition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+const (
+	// defaultNodePool is the node pool of nodes that have none of the nodePoolLabels
+	defaultNodePool = "default"
+)
+
+// nodePoolLabels are the labels used to group nodes into node pools, in order of precedence
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	corev1.LabelInstanceTypeStable,
+}
+
+// fragmentationResources are the resources evaluated for fragmentation
+var fragmentationResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// getNodePoolName returns the node pool that the node belongs to
+func getNodePoolName(node *corev1.Node) string {
+	for _, label := range nodePoolLabels {
+		if pool, ok := node.Labels[label]; ok && len(pool) > 0 {
+			return pool
+		}
+	}
+	return defaultNodePool
+}
+
+// getNodePoolFragmentation returns the fragmentation of available cpu and memory per node pool.
+// Only ready and schedulable nodes are taken into account.
+func getNodePoolFragmentation(nodes []*corev1.Node, pods []*corev1.Pod) []clusterapi.NodePoolFragmentation {
+	requestsPerNode := make(map[string]corev1.ResourceList)
+	for _, pod := range pods {
+		if !isScheduledActivePod(pod) {
+			continue
+		}
+		if _, ok := requestsPerNode[pod.Spec.NodeName]; !ok {
+			requestsPerNode[pod.Spec.NodeName] = make(map[corev1.ResourceName]resource.Quantity)
+		}
+		addResourceList(requestsPerNode[pod.Spec.NodeName], getPodRequests(pod))
+	}
+
+	pools := make(map[string]*clusterapi.NodePoolFragmentation)
+	totals := make(map[string]corev1.ResourceList)
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		if _, condition := getNodeCondition(&node.Status, corev1.NodeReady); condition == nil || condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		name := getNodePoolName(node)
+		pool, ok := pools[name]
+		if !ok {
+			pool = &clusterapi.NodePoolFragmentation{
+				Name:                  name,
+				LargestSchedulablePod: make(map[corev1.ResourceName]resource.Quantity),
+			}
+			pools[name] = pool
+			totals[name] = make(map[corev1.ResourceName]resource.Quantity)
+		}
+		pool.Nodes++
+
+		available := make(map[corev1.ResourceName]resource.Quantity)
+		for _, resourceName := range fragmentationResources {
+			value := node.Status.Allocatable[resourceName].DeepCopy()
+			if requested, ok := requestsPerNode[node.Name][resourceName]; ok {
+				value.Sub(requested)
+			}
+			if value.Sign() < 0 {
+				value.Set(0)
+			}
+			available[resourceName] = value
+
+			if largest, ok := pool.LargestSchedulablePod[resourceName]; !ok || value.Cmp(largest) > 0 {
+				pool.LargestSchedulablePod[resourceName] = value.DeepCopy()
+			}
+		}
+		addResourceList(totals[name], available)
+	}
+
+	result := make([]clusterapi.NodePoolFragmentation, 0, len(pools))
+	for name, pool := range pools {
+		pool.FragmentationScore = getFragmentationScore(pool.LargestSchedulablePod, totals[name])
+		result = append(result, *pool)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// getFragmentationScore returns the larger score of cpu and memory on 100 * (1 - largest / total)
+func getFragmentationScore(largest, total corev1.ResourceList) int32 {
+	var score int32
+	for _, resourceName := range fragmentationResources {
+		totalQuantity := total[resourceName]
+		if totalQuantity.IsZero() {
+			continue
+		}
+		largestQuantity := largest[resourceName]
+		ratio := float64(largestQuantity.MilliValue()) / float64(totalQuantity.MilliValue())
+		if s := int32(math.Round(100 * (1 - ratio))); s > score {
+			score = s
+		}
+	}
+	return score
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodePoolFragmentation(t *testing.T) {
+	newNode := func(name, pool string, ready bool) *corev1.Node {
+		status := corev1.ConditionTrue
+		if !ready {
+			status = corev1.ConditionFalse
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+		if len(pool) > 0 {
+			node.Labels = map[string]string{"cloud.google.com/gke-nodepool": pool}
+		}
+		return node
+	}
+	newPod := func(node, cpu, memory string) *corev1.Pod {
+		return &corev1.Pod{
+			Spec: corev1.PodSpec{
+				NodeName: node,
+				Containers: []corev1.Container{{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+
+	nodes := []*corev1.Node{
+		newNode("node-1", "pool-a", true),
+		newNode("node-2", "pool-a", true),
+		newNode("node-3", "pool-a", false),
+		newNode("node-4", "", true),
+	}
+	pods := []*corev1.Pod{
+		newPod("node-1", "3", "2Gi"),
+		newPod("node-2", "1", "6Gi"),
+		newPod("node-3", "1", "1Gi"),
+	}
+
+	pools := getNodePoolFragmentation(nodes, pods)
+	if len(pools) != 2 {
+		t.Fatalf("expected 2 node pools, got %d", len(pools))
+	}
+
+	// node pools are sorted by name
+	if pools[0].Name != defaultNodePool || pools[0].Nodes != 1 || pools[0].FragmentationScore != 0 {
+		t.Errorf("unexpected node pool %#v", pools[0])
+	}
+
+	poolA := pools[1]
+	if poolA.Name != "pool-a" || poolA.Nodes != 2 {
+		t.Errorf("unexpected node pool %#v", poolA)
+	}
+	largestCPU := poolA.LargestSchedulablePod[corev1.ResourceCPU]
+	if largestCPU.Cmp(resource.MustParse("3")) != 0 {
+		t.Errorf("expected largest schedulable cpu to be 3, got %s", largestCPU.String())
+	}
+	largestMemory := poolA.LargestSchedulablePod[corev1.ResourceMemory]
+	if largestMemory.Cmp(resource.MustParse("6Gi")) != 0 {
+		t.Errorf("expected largest schedulable memory to be 6Gi, got %s", largestMemory.String())
+	}
+	// cpu: 1 - 3/4, memory: 1 - 6Gi/8Gi
+	if poolA.FragmentationScore != 25 {
+		t.Errorf("expected fragmentation score to be 25, got %d", poolA.FragmentationScore)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/kubernetes"
+	corev1Lister "k8s.io/client-go/listers/core/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/features"
+)
+
+// MetricsServerCollectorName is the name of the collector reading node usage from metrics-server
+const MetricsServerCollectorName = "metrics-server"
+
+// nodeMetricsPath is where metrics-server serves NodeMetrics
+const nodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+
+func init() {
+	RegisterCollector(MetricsServerCollectorName, newMetricsServerCollector, true)
+}
+
+// nodeMetrics is a trimmed copy of NodeMetrics in k8s.io/metrics/pkg/apis/metrics/v1beta1
+type nodeMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Usage             corev1.ResourceList `json:"usage"`
+}
+
+// nodeMetricsList is a trimmed copy of NodeMetricsList in k8s.io/metrics/pkg/apis/metrics/v1beta1
+type nodeMetricsList struct {
+	Items []nodeMetrics `json:"items"`
+}
+
+// metricsServerCollector collects the actual cpu and memory usage of nodes from metrics-server
+type metricsServerCollector struct {
+	kubeClient kubernetes.Interface
+	nodeLister corev1Lister.NodeLister
+}
+
+func newMetricsServerCollector(cc *CollectorContext) (Collector, error) {
+	if !utilfeature.DefaultFeatureGate.Enabled(features.NodeUsageMetrics) {
+		return nil, nil
+	}
+	return &metricsServerCollector{
+		kubeClient: cc.KubeClient,
+		nodeLister: cc.InformerFactory.Core().V1().Nodes().Lister(),
+	}, nil
+}
+
+func (m *metricsServerCollector) Name() string {
+	return MetricsServerCollectorName
+}
+
+func (m *metricsServerCollector) Collect(ctx context.Context, status *clusterapi.ManagedClusterStatus) error {
+	// metrics-server may be absent or not ready, so stale usage should not be kept
+	status.NodeUsage = nil
+
+	data, err := m.kubeClient.Discovery().RESTClient().Get().AbsPath(nodeMetricsPath).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get node metrics from metrics-server: %v", err)
+	}
+	metricsList := &nodeMetricsList{}
+	if err = json.Unmarshal(data, metricsList); err != nil {
+		return fmt.Errorf("failed to decode node metrics: %v", err)
+	}
+
+	nodes, err := m.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	status.NodeUsage = getNodeUsage(nodes, metricsList.Items)
+	return nil
+}
+
+// getNodeUsage sums up the usage of nodes and calculates the percentages against their allocatable.
+// Nodes without metrics are skipped, so that the percentages are not diluted.
+func getNodeUsage(nodes []*corev1.Node, metrics []nodeMetrics) *clusterapi.NodeUsage {
+	allocatable := map[string]corev1.ResourceList{}
+	for _, node := range nodes {
+		allocatable[node.Name] = node.Status.Allocatable
+	}
+
+	usedCPU, usedMemory := resource.NewQuantity(0, resource.DecimalSI), resource.NewQuantity(0, resource.BinarySI)
+	totalCPU, totalMemory := resource.NewQuantity(0, resource.DecimalSI), resource.NewQuantity(0, resource.BinarySI)
+	for _, m := range metrics {
+		nodeAllocatable, ok := allocatable[m.Name]
+		if !ok {
+			continue
+		}
+		usedCPU.Add(m.Usage[corev1.ResourceCPU])
+		usedMemory.Add(m.Usage[corev1.ResourceMemory])
+		totalCPU.Add(nodeAllocatable[corev1.ResourceCPU])
+		totalMemory.Add(nodeAllocatable[corev1.ResourceMemory])
+	}
+
+	return &clusterapi.NodeUsage{
+		Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    *usedCPU,
+			corev1.ResourceMemory: *usedMemory,
+		},
+		CPUPercentage:    getPercentage(usedCPU.MilliValue(), totalCPU.MilliValue()),
+		MemoryPercentage: getPercentage(usedMemory.Value(), totalMemory.Value()),
+	}
+}
+
+func getPercentage(used, total int64) int32 {
+	if total <= 0 {
+		return 0
+	}
+	return int32(math.Round(float64(used) * 100 / float64(total)))
+}
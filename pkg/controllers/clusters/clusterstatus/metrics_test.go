@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetNodeUsage(t *testing.T) {
+	newNode := func(name string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		}
+	}
+	newMetrics := func(name, cpu, memory string) nodeMetrics {
+		return nodeMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}
+	}
+
+	nodes := []*corev1.Node{newNode("node-1"), newNode("node-2"), newNode("node-3")}
+	metrics := []nodeMetrics{
+		newMetrics("node-1", "1", "2Gi"),
+		newMetrics("node-2", "2", "6Gi"),
+		// metrics of a deleted node
+		newMetrics("node-4", "4", "8Gi"),
+	}
+
+	usage := getNodeUsage(nodes, metrics)
+	if cpu := usage.Usage[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("3")) != 0 {
+		t.Errorf("expected cpu usage 3, got %s", cpu.String())
+	}
+	if memory := usage.Usage[corev1.ResourceMemory]; memory.Cmp(resource.MustParse("8Gi")) != 0 {
+		t.Errorf("expected memory usage 8Gi, got %s", memory.String())
+	}
+	if usage.CPUPercentage != 38 {
+		t.Errorf("expected cpu percentage 38, got %d", usage.CPUPercentage)
+	}
+	if usage.MemoryPercentage != 50 {
+		t.Errorf("expected memory percentage 50, got %d", usage.MemoryPercentage)
+	}
+
+	usage = getNodeUsage(nodes, nil)
+	if usage.CPUPercentage != 0 || usage.MemoryPercentage != 0 {
+		t.Errorf("expected zero percentages without metrics, got %d and %d", usage.CPUPercentage, usage.MemoryPercentage)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1Lister "k8s.io/client-go/listers/core/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// TopologyCollectorName is the name of the collector detecting the cloud provider, region and zones
+const TopologyCollectorName = "topology"
+
+func init() {
+	RegisterCollector(TopologyCollectorName, newTopologyCollector, true)
+}
+
+// topologyCollector detects the cloud provider, region and zones of the cluster from its nodes
+type topologyCollector struct {
+	nodeLister corev1Lister.NodeLister
+}
+
+func newTopologyCollector(cc *CollectorContext) (Collector, error) {
+	return &topologyCollector{nodeLister: cc.InformerFactory.Core().V1().Nodes().Lister()}, nil
+}
+
+func (t *topologyCollector) Name() string {
+	return TopologyCollectorName
+}
+
+func (t *topologyCollector) Collect(ctx context.Context, status *clusterapi.ManagedClusterStatus) error {
+	nodes, err := t.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	status.Provider, status.Region, status.Zones = getTopology(nodes)
+	return nil
+}
+
+// getTopology returns the most common provider and region of nodes, and all the zones of nodes.
+// Deprecated labels "failure-domain.beta.kubernetes.io/*" are honored for clusters older than Kubernetes v1.17.
+func getTopology(nodes []*corev1.Node) (string, string, []string) {
+	providers := map[string]int{}
+	regions := map[string]int{}
+	zones := sets.NewString()
+	for _, node := range nodes {
+		if provider := getProvider(node.Spec.ProviderID); len(provider) > 0 {
+			providers[provider]++
+		}
+		if region := getNodeLabel(node, corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion); len(region) > 0 {
+			regions[region]++
+		}
+		if zone := getNodeLabel(node, corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone); len(zone) > 0 {
+			zones.Insert(zone)
+		}
+	}
+
+	var zoneList []string
+	if zones.Len() > 0 {
+		zoneList = zones.List()
+	}
+	return mostCommon(providers), mostCommon(regions), zoneList
+}
+
+// getProvider returns the provider name of a providerID, such as "aws" for "aws:///us-east-1a/i-0123456789".
+func getProvider(providerID string) string {
+	parts := strings.SplitN(providerID, "://", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+func getNodeLabel(node *corev1.Node, key, deprecatedKey string) string {
+	if value, ok := node.Labels[key]; ok {
+		return value
+	}
+	return node.Labels[deprecatedKey]
+}
+
+// mostCommon returns the key with the largest count, and the smallest key wins a tie.
+func mostCommon(counts map[string]int) string {
+	var result string
+	for key, count := range counts {
+		if count > counts[result] || (count == counts[result] && key < result) {
+			result = key
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterstatus
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetTopology(t *testing.T) {
+	newNode := func(providerID string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			Spec:       corev1.NodeSpec{ProviderID: providerID},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		nodes        []*corev1.Node
+		wantProvider string
+		wantRegion   string
+		wantZones    []string
+	}{
+		{
+			name:  "no nodes",
+			nodes: nil,
+		},
+		{
+			name: "multiple zones",
+			nodes: []*corev1.Node{
+				newNode("aws:///us-east-1a/i-1", map[string]string{
+					corev1.LabelTopologyRegion: "us-east-1",
+					corev1.LabelTopologyZone:   "us-east-1a",
+				}),
+				newNode("aws:///us-east-1b/i-2", map[string]string{
+					corev1.LabelTopologyRegion: "us-east-1",
+					corev1.LabelTopologyZone:   "us-east-1b",
+				}),
+				newNode("", map[string]string{
+					corev1.LabelTopologyRegion: "us-west-2",
+				}),
+			},
+			wantProvider: "aws",
+			wantRegion:   "us-east-1",
+			wantZones:    []string{"us-east-1a", "us-east-1b"},
+		},
+		{
+			name: "deprecated labels",
+			nodes: []*corev1.Node{
+				newNode("gce://project/europe-west1-b/node-1", map[string]string{
+					corev1.LabelFailureDomainBetaRegion: "europe-west1",
+					corev1.LabelFailureDomainBetaZone:   "europe-west1-b",
+				}),
+			},
+			wantProvider: "gce",
+			wantRegion:   "europe-west1",
+			wantZones:    []string{"europe-west1-b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, region, zones := getTopology(tt.nodes)
+			if provider != tt.wantProvider {
+				t.Errorf("expected provider %q, got %q", tt.wantProvider, provider)
+			}
+			if region != tt.wantRegion {
+				t.Errorf("expected region %q, got %q", tt.wantRegion, region)
+			}
+			if !reflect.DeepEqual(zones, tt.wantZones) {
+				t.Errorf("expected zones %v, got %v", tt.wantZones, zones)
+			}
+		})
+	}
+}
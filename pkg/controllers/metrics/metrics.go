@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics defines the metrics of the controllers, which are exposed on /metrics of clusternet-hub.
+// Besides the metrics of reconciles, importing this package registers the standard workqueue metrics,
+// such as workqueue_depth, workqueue_adds_total, workqueue_queue_duration_seconds and workqueue_retries_total,
+// for all the named work queues.
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	// register the workqueue metrics provider
+	_ "k8s.io/component-base/metrics/prometheus/workqueue"
+)
+
+const (
+	metricsSubsystem = "clusternet_controller"
+)
+
+var (
+	reconcileDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "reconcile_duration_seconds",
+			Help:           "Latency of reconciling an item from the work queue in seconds, partitioned by controller and result.",
+			Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller", "result"},
+	)
+
+	reconcileErrorsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "reconcile_errors_total",
+			Help:           "Number of failed reconciles, which are requeued with backoff, partitioned by controller.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"controller"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of the controllers.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(reconcileDuration)
+		legacyregistry.MustRegister(reconcileErrorsTotal)
+	})
+}
+
+// ObserveReconcile records a reconcile of controller started at start, which failed if err is not nil.
+// controller should be the same as the name of its work queue.
+func ObserveReconcile(controller string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+		reconcileErrorsTotal.WithLabelValues(controller).Inc()
+	}
+	reconcileDuration.WithLabelValues(controller, result).Observe(time.Since(start).Seconds())
+}
@@ -18,15 +18,23 @@ package sockets
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rancher/remotedialer"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/rest"
@@ -34,6 +42,18 @@ import (
 	"k8s.io/klog/v2"
 
 	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/grpctunnel"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// initialBackoff is the initial delay before reconnecting a broken tunnel
+	initialBackoff = time.Second
+	// maxBackoff caps the delay before reconnecting a broken tunnel
+	maxBackoff = 2 * time.Minute
+	// stableDuration is how long a tunnel should keep connected before the backoff gets reset
+	stableDuration = time.Minute
 )
 
 // Controller is a controller that helps setup/maintain websocket connection
@@ -42,9 +62,24 @@ type Controller struct {
 	headers    http.Header
 	dialer     *websocket.Dialer
 	kubeConfig *rest.Config
+
+	// bearerToken returns the token authenticating websocket connections, which is read on every dial
+	// since the token file may get renewed
+	bearerToken func() (string, error)
+
+	// connections is the number of tunnels set up to parent cluster, over which streams are spread
+	connections int
+
+	// transport is the transport of tunnels, websocket or gRPC
+	transport string
+	// grpcAddress is the address of gRPC tunnels served by parent cluster
+	grpcAddress string
+	// grpcTLSConfig is used for mutual TLS with parent cluster over gRPC
+	grpcTLSConfig *tls.Config
 }
 
-func NewController(kubeConfig *rest.Config, tunnelLogging bool) (*Controller, error) {
+func NewController(kubeConfig *rest.Config, tunnelLogging bool, connections int,
+	tunnelTransport, grpcAddress, grpcCAFile string) (*Controller, error) {
 	if tunnelLogging {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
@@ -55,22 +90,36 @@ func NewController(kubeConfig *rest.Config, tunnelLogging bool) (*Controller, er
 	}
 	// TODO: check CA
 	tlsConfig.InsecureSkipVerify = true
+	if len(kubeConfig.CertFile) > 0 && len(kubeConfig.KeyFile) > 0 {
+		// client certificates may get rotated, so we load them on every dial
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(kubeConfig.CertFile, kubeConfig.KeyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
 	dialer := &websocket.Dialer{
 		TLSClientConfig: tlsConfig,
 	}
 
 	headers := http.Header{}
-	bearerToken := kubeConfig.BearerToken
+	bearerToken := func() (string, error) {
+		return kubeConfig.BearerToken, nil
+	}
 	if len(kubeConfig.BearerTokenFile) > 0 {
 		source := transport.NewCachedFileTokenSource(kubeConfig.BearerTokenFile)
-		token, err := source.Token()
-		if err != nil {
-			return nil, err
+		bearerToken = func() (string, error) {
+			token, err := source.Token()
+			if err != nil {
+				return "", err
+			}
+			return token.AccessToken, nil
 		}
-		bearerToken = token.AccessToken
 	}
-	if len(bearerToken) > 0 {
-		headers.Set("Authorization", "Bearer "+bearerToken)
+	if _, err = bearerToken(); err != nil {
+		return nil, err
 	}
 
 	u, err := url.Parse(kubeConfig.Host)
@@ -85,22 +134,179 @@ func NewController(kubeConfig *rest.Config, tunnelLogging bool) (*Controller, er
 	}
 	u.Path = path.Join(u.Path, "apis", proxiesapi.SchemeGroupVersion.String(), "sockets")
 
+	if connections < 1 {
+		connections = 1
+	}
+
+	var grpcTLSConfig *tls.Config
+	if tunnelTransport == known.TunnelTransportGRPC {
+		if tlsConfig.GetClientCertificate == nil {
+			return nil, fmt.Errorf("gRPC tunnels require client certificates issued by parent cluster, " +
+				"please enable feature gate CertificateSigning")
+		}
+		grpcTLSConfig = tlsConfig.Clone()
+		if len(grpcCAFile) > 0 {
+			caPEM, err := ioutil.ReadFile(grpcCAFile)
+			if err != nil {
+				return nil, err
+			}
+			rootCAs := x509.NewCertPool()
+			if !rootCAs.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no valid certificates found in %s", grpcCAFile)
+			}
+			host, _, err := net.SplitHostPort(grpcAddress)
+			if err != nil {
+				return nil, err
+			}
+			grpcTLSConfig.RootCAs = rootCAs
+			grpcTLSConfig.ServerName = host
+			grpcTLSConfig.InsecureSkipVerify = false
+		}
+	}
+
 	return &Controller{
-		kubeConfig: kubeConfig,
-		dialer:     dialer,
-		headers:    headers,
-		baseURL:    u.String(),
+		kubeConfig:    kubeConfig,
+		dialer:        dialer,
+		headers:       headers,
+		bearerToken:   bearerToken,
+		baseURL:       u.String(),
+		connections:   connections,
+		transport:     tunnelTransport,
+		grpcAddress:   grpcAddress,
+		grpcTLSConfig: grpcTLSConfig,
 	}, nil
 }
 
 func (c *Controller) Run(ctx context.Context, clusterID *types.UID) {
+	var wg wait.Group
+	if c.transport == known.TunnelTransportGRPC {
+		klog.V(4).Infof("setting up %d gRPC tunnel(s) to %s", c.connections, c.grpcAddress)
+		for i := 0; i < c.connections; i++ {
+			index := i
+			wg.Start(func() {
+				c.connect(ctx, "gRPC tunnel", index, func(ctx context.Context) error {
+					return c.connectGRPC(ctx, index)
+				})
+			})
+		}
+		wg.Wait()
+		return
+	}
+
 	wsURL := fmt.Sprintf("%s/%s", c.baseURL, string(*clusterID))
-	klog.V(4).Infof("setting up websocket connection to %s", wsURL)
+	klog.V(4).Infof("setting up %d websocket connection(s) to %s", c.connections, wsURL)
+	for i := 0; i < c.connections; i++ {
+		index := i
+		wg.Start(func() {
+			c.connect(ctx, "websocket connection", index, func(ctx context.Context) error {
+				headers, err := c.headersFor(index)
+				if err != nil {
+					return err
+				}
+				return connectToProxy(ctx, wsURL, headers, c.dialer)
+			})
+		})
+	}
+	wg.Wait()
+}
 
-	wait.UntilWithContext(ctx, func(ctx context.Context) {
-		err := remotedialer.ClientConnect(ctx, wsURL, c.headers, c.dialer, func(string, string) bool { return true }, nil)
+// headersFor returns the headers of the websocket connection with the given index.
+func (c *Controller) headersFor(index int) (http.Header, error) {
+	headers := c.headers.Clone()
+	headers.Set(known.TunnelIndexHeader, strconv.Itoa(index))
+	token, err := c.bearerToken()
+	if err != nil {
+		return nil, err
+	}
+	if len(token) > 0 {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+	return headers, nil
+}
+
+// connectGRPC sets up a gRPC tunnel on its own HTTP/2 connection, and serves it until it is broken.
+func (c *Controller) connectGRPC(ctx context.Context, index int) error {
+	conn, err := grpc.DialContext(ctx, c.grpcAddress,
+		grpc.WithTransportCredentials(credentials.NewTLS(c.grpcTLSConfig)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                30 * time.Second,
+			Timeout:             10 * time.Second,
+			PermitWithoutStream: true,
+		}))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return grpctunnel.ConnectToServer(ctx, conn, index, dialTarget)
+}
+
+// connectToProxy works like remotedialer.ConnectToProxy, except that connections are dialed with dialTarget.
+func connectToProxy(ctx context.Context, wsURL string, headers http.Header, dialer *websocket.Dialer) error {
+	ws, resp, err := dialer.DialContext(ctx, wsURL, headers)
+	if err != nil {
+		if resp != nil {
+			body, _ := ioutil.ReadAll(resp.Body)
+			return fmt.Errorf("%v, response status %s: %s", err, resp.Status, body)
+		}
+		return err
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	session := remotedialer.NewClientSessionWithDialer(func(string, string) bool { return true }, ws, dialTarget)
+	defer session.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := session.Serve(ctx)
+		result <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err = <-result:
+		return err
+	}
+}
+
+// dialTarget dials the targets requested by parent cluster, whose traffic may be compressed.
+func dialTarget(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	return utils.DialWithCompression(ctx, dialer.DialContext, network, address)
+}
+
+// connect keeps a tunnel connected, which is reconnected with jittered exponential backoff on failures,
+// so that agents won't reconnect in lockstep after parent cluster recovers.
+func (c *Controller) connect(ctx context.Context, kind string, index int, connectFn func(ctx context.Context) error) {
+	backoff := initialBackoff
+	for {
+		start := time.Now()
+		err := connectFn(ctx)
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
-			klog.Errorf("websocket connection error: %v", err)
+			klog.Errorf("%s %d error: %v", kind, index, err)
 		}
-	}, time.Duration(0))
+
+		if time.Since(start) >= stableDuration {
+			backoff = initialBackoff
+		}
+		delay := wait.Jitter(backoff, 0.5)
+		klog.V(4).Infof("reconnecting %s %d in %v", kind, index, delay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
 }
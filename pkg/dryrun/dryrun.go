@@ -0,0 +1,337 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	cacheddiscovery "k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/localizer"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// informers are only used for listing, so no resync is needed
+	noResync = time.Duration(0)
+)
+
+var (
+	helmChartKind = appsapi.SchemeGroupVersion.WithKind("HelmChart")
+)
+
+// ClusterResult holds the dry-run result of a Subscription against a child cluster.
+type ClusterResult struct {
+	// ClusterID is the id of the child cluster
+	ClusterID string
+	// ClusterName is the name of the child cluster
+	ClusterName string
+	// Namespace is the dedicated namespace of the child cluster in parent cluster
+	Namespace string
+	// Errors are the admission/validation failures returned by the child cluster
+	Errors []error
+}
+
+// DryRunner renders the Descriptions of a Subscription as clusternet-hub does, and performs
+// server-side dry-run applies of them against each target cluster, without changing anything.
+type DryRunner struct {
+	opts *DryRunOptions
+	out  io.Writer
+
+	clusternetClient *clusternetclientset.Clientset
+
+	kubeInformerFactory       kubeinformers.SharedInformerFactory
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory
+
+	clusterLister clusterlisters.ManagedClusterLister
+	secretLister  corev1lister.SecretLister
+	mfstLister    applisters.ManifestLister
+	localizer     *localizer.Localizer
+}
+
+// NewDryRunner returns a new DryRunner.
+func NewDryRunner(ctx context.Context, opts *DryRunOptions, out io.Writer) (*DryRunner, error) {
+	parentConfig, err := utils.LoadsKubeConfig(opts.ParentKubeConfig, 1)
+	if err != nil {
+		return nil, err
+	}
+	kubeclient := kubernetes.NewForConfigOrDie(parentConfig)
+	clusternetclient := clusternetclientset.NewForConfigOrDie(parentConfig)
+
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclient, noResync)
+	clusternetInformerFactory := clusternetinformers.NewSharedInformerFactory(clusternetclient, noResync)
+
+	// events are dropped, since nothing will be changed
+	l, err := localizer.NewLocalizer(ctx, clusternetclient, clusternetInformerFactory, &record.FakeRecorder{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DryRunner{
+		opts:                      opts,
+		out:                       out,
+		clusternetClient:          clusternetclient,
+		kubeInformerFactory:       kubeInformerFactory,
+		clusternetInformerFactory: clusternetInformerFactory,
+		clusterLister:             clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		secretLister:              kubeInformerFactory.Core().V1().Secrets().Lister(),
+		mfstLister:                clusternetInformerFactory.Apps().V1alpha1().Manifests().Lister(),
+		localizer:                 l,
+	}, nil
+}
+
+// Run dry-runs the Subscription against all the target clusters, and reports the failures per cluster.
+func (d *DryRunner) Run(ctx context.Context) error {
+	d.kubeInformerFactory.Start(ctx.Done())
+	d.clusternetInformerFactory.Start(ctx.Done())
+	for informerType, synced := range d.kubeInformerFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to wait for %v to sync", informerType)
+		}
+	}
+	for informerType, synced := range d.clusternetInformerFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to wait for %v to sync", informerType)
+		}
+	}
+
+	sub, err := d.clusternetClient.AppsV1alpha1().Subscriptions(d.opts.Namespace).Get(ctx, d.opts.SubscriptionName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	mcls, err := d.getTargetClusters(sub)
+	if err != nil {
+		return err
+	}
+	if len(mcls) == 0 {
+		fmt.Fprintf(d.out, "no clusters get matched by Subscription %s\n", klog.KObj(sub))
+		return nil
+	}
+
+	if d.opts.RenderOnly {
+		return d.renderInClusters(sub, mcls)
+	}
+
+	var failed int
+	for _, cluster := range mcls {
+		result := d.dryRunInCluster(ctx, sub, cluster)
+		d.print(result)
+		if len(result.Errors) > 0 {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("dry-run of Subscription %s failed in %d of %d clusters", klog.KObj(sub), failed, len(mcls))
+	}
+	return nil
+}
+
+// getTargetClusters returns the clusters matched by the subscribers, the same as clusternet-hub does.
+func (d *DryRunner) getTargetClusters(sub *appsapi.Subscription) ([]*clusterapi.ManagedCluster, error) {
+	var mcls []*clusterapi.ManagedCluster
+	for _, subscriber := range sub.Spec.Subscribers {
+		selector, err := metav1.LabelSelectorAsSelector(subscriber.ClusterAffinity)
+		if err != nil {
+			return nil, err
+		}
+		clusters, err := d.clusterLister.ManagedClusters("").List(selector)
+		if err != nil {
+			return nil, err
+		}
+		mcls = append(mcls, clusters...)
+	}
+	return mcls, nil
+}
+
+// render generates the generic Description for the cluster with overrides applied.
+func (d *DryRunner) render(sub *appsapi.Subscription, cluster *clusterapi.ManagedCluster) (*appsapi.Description, error) {
+	var rawObjects [][]byte
+	for _, feed := range sub.Spec.Feeds {
+		if feed.Kind == helmChartKind.Kind {
+			klog.Warningf("skip dry-running %s, which is not supported yet", utils.FormatFeed(feed))
+			continue
+		}
+
+		manifests, err := utils.ListManifestsBySelector(d.mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		if manifests == nil {
+			return nil, fmt.Errorf("Subscription %s is using a nonexistent %s", klog.KObj(sub), utils.FormatFeed(feed))
+		}
+		for _, manifest := range manifests {
+			rawObjects = append(rawObjects, manifest.Template.Raw)
+		}
+	}
+
+	desc := &appsapi.Description{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-generic", sub.Name),
+			Namespace: cluster.Namespace,
+			Labels: map[string]string{
+				known.ClusterIDLabel:   cluster.Labels[known.ClusterIDLabel],
+				known.ClusterNameLabel: cluster.Labels[known.ClusterNameLabel],
+			},
+		},
+		Spec: appsapi.DescriptionSpec{
+			Deployer: appsapi.DescriptionGenericDeployer,
+			Raw:      rawObjects,
+		},
+	}
+	if err := d.localizer.ApplyOverridesToDescription(desc); err != nil {
+		return nil, err
+	}
+	return desc, nil
+}
+
+// renderInClusters prints the manifests rendered for each cluster as YAML documents.
+func (d *DryRunner) renderInClusters(sub *appsapi.Subscription, mcls []*clusterapi.ManagedCluster) error {
+	var allErrs []error
+	for _, cluster := range mcls {
+		desc, err := d.render(sub, cluster)
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to render Description for cluster %s: %v", cluster.Namespace, err))
+			continue
+		}
+		for _, object := range desc.Spec.Raw {
+			manifest, err := yaml.JSONToYAML(object)
+			if err != nil {
+				allErrs = append(allErrs, err)
+				continue
+			}
+			fmt.Fprintf(d.out, "---\n# cluster %s (%s) in namespace %s\n%s", cluster.Labels[known.ClusterNameLabel],
+				cluster.Spec.ClusterID, cluster.Namespace, manifest)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+func (d *DryRunner) dryRunInCluster(ctx context.Context, sub *appsapi.Subscription, cluster *clusterapi.ManagedCluster) *ClusterResult {
+	result := &ClusterResult{
+		ClusterID:   string(cluster.Spec.ClusterID),
+		ClusterName: cluster.Labels[known.ClusterNameLabel],
+		Namespace:   cluster.Namespace,
+	}
+
+	desc, err := d.render(sub, cluster)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("failed to render Description: %v", err))
+		return result
+	}
+
+	dynamicClient, restMapper, err := d.getDynamicClient(cluster)
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Errorf("failed to connect to cluster: %v", err))
+		return result
+	}
+
+	for _, object := range desc.Spec.Raw {
+		resource := &unstructured.Unstructured{}
+		if err := resource.UnmarshalJSON(object); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("failed to unmarshal resource: %v", err))
+			continue
+		}
+		if err := dryRunResource(ctx, dynamicClient, restMapper, resource); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+		}
+	}
+	return result
+}
+
+// dryRunResource creates the resource with server-side dry-run, or updates it if already exists.
+func dryRunResource(ctx context.Context, dynamicClient dynamic.Interface, restMapper meta.RESTMapper, resource *unstructured.Unstructured) error {
+	restMapping, err := restMapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
+	if err != nil {
+		return err
+	}
+	resource.SetUID("")
+
+	resourceClient := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace())
+	_, err = resourceClient.Create(ctx, resource, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	curObj, err := resourceClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	resource.SetResourceVersion(curObj.GetResourceVersion())
+	_, err = resourceClient.Update(ctx, resource, metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}})
+	return err
+}
+
+func (d *DryRunner) getDynamicClient(cluster *clusterapi.ManagedCluster) (dynamic.Interface, meta.RESTMapper, error) {
+	config, err := utils.GetChildClusterConfig(d.secretLister, d.clusterLister, cluster.Namespace, string(cluster.Spec.ClusterID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kubeclient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cacheddiscovery.NewMemCacheClient(kubeclient.Discovery()))
+
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dynamicClient, restMapper, nil
+}
+
+func (d *DryRunner) print(result *ClusterResult) {
+	if len(result.Errors) == 0 {
+		fmt.Fprintf(d.out, "cluster %s (%s): passed\n", result.ClusterName, result.ClusterID)
+		return
+	}
+	fmt.Fprintf(d.out, "cluster %s (%s): %d failures\n", result.ClusterName, result.ClusterID, len(result.Errors))
+	for _, err := range utilerrors.Flatten(utilerrors.NewAggregate(result.Errors)).Errors() {
+		fmt.Fprintf(d.out, "  - %v\n", err)
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dryrun
+
+import (
+	"errors"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DryRunOptions holds the command-line options for dry-running a Subscription.
+type DryRunOptions struct {
+	// ParentKubeConfig is the kubeconfig of the parent cluster where clusternet-hub is running
+	ParentKubeConfig string
+
+	// Namespace is the namespace of the Subscription
+	Namespace string
+	// SubscriptionName is the name of the Subscription
+	SubscriptionName string
+
+	// RenderOnly prints the rendered manifests for each target cluster, without dry-running them
+	// against the child clusters
+	RenderOnly bool
+}
+
+// NewDryRunOptions creates a new *DryRunOptions with sane defaults
+func NewDryRunOptions() *DryRunOptions {
+	return &DryRunOptions{
+		Namespace: metav1.NamespaceDefault,
+	}
+}
+
+// AddFlags adds the flags to the flagset.
+func (opts *DryRunOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opts.ParentKubeConfig, "parent-kubeconfig", opts.ParentKubeConfig,
+		"Path to a kubeconfig file for the parent cluster where clusternet-hub is running")
+	fs.StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace,
+		"The namespace of the Subscription")
+	fs.StringVar(&opts.SubscriptionName, "subscription-name", opts.SubscriptionName,
+		"The name of the Subscription to dry-run")
+	fs.BoolVar(&opts.RenderOnly, "render-only", opts.RenderOnly,
+		"Only print the final manifests rendered for each target cluster with overrides applied, "+
+			"without dry-running them against the child clusters")
+}
+
+// Validate validates all the required options.
+func (opts *DryRunOptions) Validate() []error {
+	var allErrs []error
+
+	if len(opts.ParentKubeConfig) == 0 {
+		allErrs = append(allErrs, errors.New("--parent-kubeconfig must be specified"))
+	}
+	if len(opts.Namespace) == 0 {
+		allErrs = append(allErrs, errors.New("--namespace must be specified"))
+	}
+	if len(opts.SubscriptionName) == 0 {
+		allErrs = append(allErrs, errors.New("--subscription-name must be specified"))
+	}
+
+	return allErrs
+}
@@ -42,6 +42,7 @@ import (
 	proxies "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
 	clusterInformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions/clusters/v1beta1"
 	clusterListers "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/grpctunnel"
 	"github.com/clusternet/clusternet/pkg/known"
 )
 
@@ -54,6 +55,15 @@ type Exchanger struct {
 	// dialerServer is used for serving websocket connection
 	dialerServer *remotedialer.Server
 
+	// grpcServer is used for serving gRPC tunnels
+	grpcServer *grpctunnel.Server
+
+	// pool holds the tunnels of every cluster, over which streams are multiplexed
+	pool *tunnelPool
+
+	// shaper limits the bandwidth of the tunnels of every cluster
+	shaper *trafficShaper
+
 	mcLister clusterListers.ManagedClusterLister
 	mcSynced cache.InformerSynced
 }
@@ -70,7 +80,14 @@ const (
 
 func authorizer(req *http.Request) (string, bool, error) {
 	clusterID := strings.TrimPrefix(strings.TrimRight(req.URL.Path, "/"), urlPrefix)
-	return clusterID, clusterID != "", nil
+	if clusterID == "" {
+		return "", false, nil
+	}
+	key, err := tunnelKey(clusterID, req.Header.Get(known.TunnelIndexHeader))
+	if err != nil {
+		return "", false, err
+	}
+	return key, true, nil
 }
 
 func NewExchanger(tunnelLogging bool, mclsInformer clusterInformers.ManagedClusterInformer) *Exchanger {
@@ -79,15 +96,39 @@ func NewExchanger(tunnelLogging bool, mclsInformer clusterInformers.ManagedClust
 		remotedialer.PrintTunnelData = true
 	}
 
+	RegisterMetrics()
+
 	e := &Exchanger{
 		cachedTransports: map[string]*http.Transport{},
 		dialerServer:     remotedialer.New(authorizer, remotedialer.DefaultErrorWriter),
+		shaper:           newTrafficShaper(),
 		mcLister:         mclsInformer.Lister(),
 		mcSynced:         mclsInformer.Informer().HasSynced,
 	}
+	e.pool = newTunnelPool(e.hasSession, e.dialer)
+	e.grpcServer = grpctunnel.NewServer(grpcAuthorizer, func(clusterID, key string, connected bool) {
+		if connected {
+			e.pool.add(clusterID, key)
+			return
+		}
+		e.pool.remove(clusterID, key)
+	})
 	return e
 }
 
+// hasSession tells whether the tunnel with the given key is connected, with either websocket or gRPC.
+func (e *Exchanger) hasSession(key string) bool {
+	return e.grpcServer.HasSession(key) || e.dialerServer.HasSession(key)
+}
+
+// dialer returns the dialer of the tunnel with the given key.
+func (e *Exchanger) dialer(key string) remotedialer.Dialer {
+	if e.grpcServer.HasSession(key) {
+		return e.grpcServer.Dialer(key)
+	}
+	return e.dialerServer.Dialer(key)
+}
+
 func (e *Exchanger) getClonedTransport(clusterID string) *http.Transport {
 	// return cloned transport to avoid being changed outside
 	e.lock.Lock()
@@ -98,7 +139,7 @@ func (e *Exchanger) getClonedTransport(clusterID string) *http.Transport {
 		return transport.Clone()
 	}
 
-	dialer := e.dialerServer.Dialer(clusterID)
+	dialer := e.clusterDialer(clusterID)
 	transport = &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
@@ -116,7 +157,15 @@ func (e *Exchanger) getClonedTransport(clusterID string) *http.Transport {
 }
 
 func (e *Exchanger) Connect(ctx context.Context, id string, opts *proxies.Socket, responder rest.Responder) (http.Handler, error) {
-	return e.dialerServer, nil
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		key, authed, err := authorizer(request)
+		if err == nil && authed {
+			// register the tunnel to the pool of the cluster while it is being served
+			e.pool.add(id, key)
+			defer e.pool.remove(id, key)
+		}
+		e.dialerServer.ServeHTTP(writer, request)
+	}), nil
 }
 
 func (e *Exchanger) ProxyConnect(ctx context.Context, id string, opts *proxies.Socket, responder rest.Responder, extraHeaderPrefixes []string) (http.Handler, error) {
@@ -164,7 +213,7 @@ func (e *Exchanger) ProxyConnect(ctx context.Context, id string, opts *proxies.S
 				return
 			}
 
-			dialer := e.dialerServer.Dialer(id)
+			dialer := e.clusterDialer(id)
 			transport = &http.Transport{
 				TLSClientConfig: &tls.Config{
 					InsecureSkipVerify: true,
@@ -188,6 +237,11 @@ func (e *Exchanger) ProxyConnect(ctx context.Context, id string, opts *proxies.S
 		}
 
 		handler := newThrottledUpgradeAwareProxyHandler(location, transport, false, false, true, true, responder)
+		if info, err := ResolveRequestInfo(request, opts.Path); err == nil && info.Verb == "watch" {
+			// watch events and bookmarks are passed through as they are, including the resourceVersions,
+			// which are opaque to clients and only meaningful within the same child cluster
+			handler.FlushInterval = immediateFlushInterval
+		}
 		handler.ServeHTTP(writer, request)
 	})
 
@@ -251,7 +305,7 @@ func (e *Exchanger) ClusterLocation(id string, opts *proxies.Socket) (*url.URL,
 	}
 
 	if mcls[0].Status.UseSocket {
-		if !e.dialerServer.HasSession(id) {
+		if !e.pool.HasSession(id) {
 			return nil, nil, apierrors.NewBadRequest(fmt.Sprintf("cannot proxy through cluster %s, whose agent is disconnected", id))
 		}
 		transport = e.getClonedTransport(id)
@@ -270,6 +324,16 @@ func newThrottledUpgradeAwareProxyHandler(location *url.URL, transport http.Roun
 	return handler
 }
 
+// HasChildCredentials tells whether the request carries credentials of the child cluster in the extra headers,
+// which are used as they are when proxying the request.
+func HasChildCredentials(h http.Header, extraHeaderPrefixes []string) bool {
+	extra := getExtraFromHeaders(h, extraHeaderPrefixes)
+	if token := extra[strings.ToLower(TokenHeaderKey)]; len(token) > 0 && len(token[0]) > 0 {
+		return true
+	}
+	return len(extra[strings.ToLower(CertificateHeaderKey)]) > 0 && len(extra[strings.ToLower(PrivateKeyHeaderKey)]) > 0
+}
+
 // copied from k8s.io/apiserver/pkg/authentication/request/headerrequest/requestheader.go
 func unescapeExtraKey(encodedKey string) string {
 	key, err := url.PathUnescape(encodedKey) // Decode %-encoded bytes.
@@ -21,6 +21,8 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+
+	"github.com/clusternet/clusternet/pkg/known"
 )
 
 func TestAuthorizer(t *testing.T) {
@@ -72,6 +74,38 @@ func TestAuthorizer(t *testing.T) {
 			clusterID: "9bb775e3-b177-4e77-8685-1aadcb03b0a8",
 			authed:    true,
 		},
+		{
+			name: "first tunnel in the pool",
+			req: &http.Request{
+				URL: &url.URL{
+					Path: "/apis/proxies.clusternet.io/v1alpha1/sockets/9bb775e3-b177-4e77-8685-1aadcb03b0a8",
+				},
+				Header: http.Header{known.TunnelIndexHeader: []string{"0"}},
+			},
+			clusterID: "9bb775e3-b177-4e77-8685-1aadcb03b0a8",
+			authed:    true,
+		},
+		{
+			name: "other tunnel in the pool",
+			req: &http.Request{
+				URL: &url.URL{
+					Path: "/apis/proxies.clusternet.io/v1alpha1/sockets/9bb775e3-b177-4e77-8685-1aadcb03b0a8",
+				},
+				Header: http.Header{known.TunnelIndexHeader: []string{"3"}},
+			},
+			clusterID: "9bb775e3-b177-4e77-8685-1aadcb03b0a8#3",
+			authed:    true,
+		},
+		{
+			name: "tunnel index out of range",
+			req: &http.Request{
+				URL: &url.URL{
+					Path: "/apis/proxies.clusternet.io/v1alpha1/sockets/9bb775e3-b177-4e77-8685-1aadcb03b0a8",
+				},
+				Header: http.Header{known.TunnelIndexHeader: []string{"16"}},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
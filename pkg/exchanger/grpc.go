@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/grpctunnel"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// ServeGRPC serves gRPC tunnels on the given address with mutual TLS. The serving certificate of clusternet-hub
+// is used, which is reloaded on every handshake, while clusternet-agents are authenticated with their client
+// certificates signed by the CA in clientCAFile.
+func (e *Exchanger) ServeGRPC(address string, servingCert dynamiccertificates.CertKeyContentProvider, clientCAFile string,
+	stopCh <-chan struct{}) error {
+	caPEM, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no valid certificates found in %s", clientCAFile)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certPEM, keyPEM := servingCert.CurrentCertKeyContent()
+			cert, err := tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	gs := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	)
+	e.grpcServer.Register(gs)
+
+	go func() {
+		<-stopCh
+		gs.Stop()
+	}()
+	go func() {
+		klog.Infof("serving gRPC tunnels on %s", address)
+		if err := gs.Serve(listener); err != nil {
+			klog.Errorf("failed to serve gRPC tunnels: %v", err)
+		}
+	}()
+	return nil
+}
+
+// grpcAuthorizer authenticates clusternet-agents with the client certificates issued by clusternet-hub,
+// whose common names carry the cluster ids.
+func grpcAuthorizer(ctx context.Context) (string, string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", "", errors.New("no peer found")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", "", errors.New("no verified client certificate found")
+	}
+
+	subject := tlsInfo.State.VerifiedChains[0][0].Subject
+	clusterID := strings.TrimPrefix(subject.CommonName, known.ClusterAgentUserPrefix)
+	if clusterID == subject.CommonName || len(clusterID) == 0 ||
+		len(subject.Organization) != 1 || subject.Organization[0] != known.ClusterAgentGroup {
+		return "", "", fmt.Errorf("client certificate %q is not issued to clusternet-agent", subject.CommonName)
+	}
+
+	key, err := tunnelKey(clusterID, grpctunnel.IndexFromContext(ctx))
+	if err != nil {
+		return "", "", err
+	}
+	return clusterID, key, nil
+}
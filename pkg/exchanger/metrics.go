@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsSubsystem = "clusternet_tunnel"
+)
+
+var (
+	tunnelRTT = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "rtt_seconds",
+			Help:           "Round-trip time of health probes through the tunnels to child clusters in seconds, partitioned by cluster.",
+			Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	probeFailuresTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "probe_failures_total",
+			Help:           "Number of failed health probes through the tunnels to child clusters, partitioned by cluster.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	droppedStreamsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "dropped_streams_total",
+			Help:           "Number of streams through the tunnels failing to be dialed or broken halfway, partitioned by cluster and reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster", "reason"},
+	)
+
+	connectedTunnels = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "connected",
+			Help:           "Number of tunnels connected by child clusters, partitioned by cluster.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cluster"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of tunnels, which are exposed on /metrics.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(tunnelRTT)
+		legacyregistry.MustRegister(probeFailuresTotal)
+		legacyregistry.MustRegister(droppedStreamsTotal)
+		legacyregistry.MustRegister(connectedTunnels)
+	})
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/remotedialer"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// tunnelKey returns the client key of a tunnel in remotedialer, which is the cluster id for the first tunnel
+// of a cluster, so that agents setting up a single tunnel keep working as they are.
+func tunnelKey(clusterID, index string) (string, error) {
+	if len(index) == 0 || index == "0" {
+		return clusterID, nil
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= known.MaxTunnelsPerCluster {
+		return "", fmt.Errorf("invalid tunnel index %q, which should be an integer in [0, %d)", index, known.MaxTunnelsPerCluster)
+	}
+	return fmt.Sprintf("%s#%d", clusterID, i), nil
+}
+
+// tunnel is a websocket connection set up by clusternet-agent, over which streams are multiplexed.
+type tunnel struct {
+	// refs is the number of connections registered with the same key,
+	// which may be greater than one for a short while when an agent reconnects.
+	refs int
+	// streams is the number of streams being dialed or served over this tunnel
+	streams int
+	// unhealthy is set when the last health probe through this tunnel fails
+	unhealthy bool
+}
+
+// tunnelPool holds the tunnels of every cluster and spreads streams over them.
+type tunnelPool struct {
+	lock    sync.Mutex
+	tunnels map[string]map[string]*tunnel
+
+	// hasSession tells whether the tunnel with the given key is served by remotedialer
+	hasSession func(key string) bool
+	// dial dials through the tunnel with the given key
+	dial func(key string) remotedialer.Dialer
+}
+
+func newTunnelPool(hasSession func(key string) bool, dial func(key string) remotedialer.Dialer) *tunnelPool {
+	return &tunnelPool{
+		tunnels:    map[string]map[string]*tunnel{},
+		hasSession: hasSession,
+		dial:       dial,
+	}
+}
+
+func (p *tunnelPool) add(clusterID, key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.tunnels[clusterID] == nil {
+		p.tunnels[clusterID] = map[string]*tunnel{}
+	}
+	t := p.tunnels[clusterID][key]
+	if t == nil {
+		t = &tunnel{}
+		p.tunnels[clusterID][key] = t
+	}
+	t.refs++
+	connectedTunnels.WithLabelValues(clusterID).Set(float64(len(p.tunnels[clusterID])))
+}
+
+func (p *tunnelPool) remove(clusterID, key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	t := p.tunnels[clusterID][key]
+	if t == nil {
+		return
+	}
+	t.refs--
+	if t.refs > 0 {
+		return
+	}
+	delete(p.tunnels[clusterID], key)
+	connectedTunnels.WithLabelValues(clusterID).Set(float64(len(p.tunnels[clusterID])))
+	if len(p.tunnels[clusterID]) == 0 {
+		delete(p.tunnels, clusterID)
+	}
+}
+
+// HasSession tells whether any tunnel of the cluster is connected.
+func (p *tunnelPool) HasSession(clusterID string) bool {
+	for _, key := range p.keys(clusterID) {
+		if p.hasSession(key) {
+			return true
+		}
+	}
+	// fall back to the first tunnel in case it is served before being registered
+	return p.hasSession(clusterID)
+}
+
+// keys returns the sorted keys of the tunnels of the cluster.
+func (p *tunnelPool) keys(clusterID string) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var keys []string
+	for key := range p.tunnels[clusterID] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// clusters returns the ids of all the clusters with tunnels connected.
+func (p *tunnelPool) clusters() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var clusterIDs []string
+	for clusterID := range p.tunnels {
+		clusterIDs = append(clusterIDs, clusterID)
+	}
+	sort.Strings(clusterIDs)
+	return clusterIDs
+}
+
+// pick returns the key of the tunnel carrying the fewest streams, and reserves a stream on it.
+// Healthy tunnels are always preferred to unhealthy ones.
+func (p *tunnelPool) pick(clusterID string) string {
+	keys := p.keys(clusterID)
+	var connected []string
+	for _, key := range keys {
+		// check sessions without holding the lock
+		if p.hasSession(key) {
+			connected = append(connected, key)
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var picked *tunnel
+	pickedKey := clusterID
+	for _, key := range connected {
+		t := p.tunnels[clusterID][key]
+		if t == nil {
+			continue
+		}
+		if picked == nil ||
+			(picked.unhealthy && !t.unhealthy) ||
+			(picked.unhealthy == t.unhealthy && t.streams < picked.streams) {
+			picked, pickedKey = t, key
+		}
+	}
+	if picked != nil {
+		picked.streams++
+	}
+	return pickedKey
+}
+
+func (p *tunnelPool) release(clusterID, key string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if t := p.tunnels[clusterID][key]; t != nil && t.streams > 0 {
+		t.streams--
+	}
+}
+
+func (p *tunnelPool) setHealthy(clusterID, key string, healthy bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if t := p.tunnels[clusterID][key]; t != nil {
+		t.unhealthy = !healthy
+	}
+}
+
+// Dialer returns a dialer that multiplexes streams over the tunnels of the cluster.
+func (p *tunnelPool) Dialer(clusterID string) remotedialer.Dialer {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		key := p.pick(clusterID)
+		conn, err := p.dial(key)(ctx, network, address)
+		if err != nil {
+			p.release(clusterID, key)
+			droppedStreamsTotal.WithLabelValues(clusterID, "dial").Inc()
+			return nil, err
+		}
+		klog.V(6).Infof("dialed %s through tunnel %s", address, key)
+		return &streamConn{
+			Conn: conn,
+			release: func(broken bool) {
+				p.release(clusterID, key)
+				if broken {
+					droppedStreamsTotal.WithLabelValues(clusterID, "broken").Inc()
+				}
+			},
+		}, nil
+	}
+}
+
+// streamConn is a stream over a tunnel, which gets released from the tunnel on closing.
+type streamConn struct {
+	net.Conn
+
+	lock    sync.Mutex
+	closed  bool
+	broken  bool
+	release func(broken bool)
+}
+
+func (c *streamConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.observe(err)
+	return n, err
+}
+
+func (c *streamConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.observe(err)
+	return n, err
+}
+
+// observe marks the stream as broken on errors other than the ones of reaching the end,
+// which happen before the stream gets closed.
+func (c *streamConn) observe(err error) {
+	if err == nil || err == io.EOF {
+		return
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		// deadlines are set by the callers on purpose
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.closed {
+		c.broken = true
+	}
+}
+
+func (c *streamConn) Close() error {
+	c.lock.Lock()
+	if !c.closed {
+		c.closed = true
+		defer c.release(c.broken)
+	}
+	c.lock.Unlock()
+
+	return c.Conn.Close()
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/rancher/remotedialer"
+)
+
+func newFakeTunnelPool(connected ...string) (*tunnelPool, map[string]int) {
+	sessions := map[string]bool{}
+	for _, key := range connected {
+		sessions[key] = true
+	}
+	dialed := map[string]int{}
+	return &tunnelPool{
+		tunnels: map[string]map[string]*tunnel{},
+		hasSession: func(key string) bool {
+			return sessions[key]
+		},
+		dial: func(key string) remotedialer.Dialer {
+			return func(ctx context.Context, network, address string) (net.Conn, error) {
+				if !sessions[key] {
+					return nil, errors.New("failed to find Session for client " + key)
+				}
+				dialed[key]++
+				client, server := net.Pipe()
+				server.Close()
+				return client, nil
+			}
+		},
+	}, dialed
+}
+
+func TestTunnelPoolPick(t *testing.T) {
+	pool, _ := newFakeTunnelPool("c1", "c1#1", "c1#2")
+	pool.add("c1", "c1")
+	pool.add("c1", "c1#1")
+	pool.add("c1", "c1#2")
+	// registered but not served yet
+	pool.add("c1", "c1#3")
+
+	counts := map[string]int{}
+	for i := 0; i < 6; i++ {
+		counts[pool.pick("c1")]++
+	}
+	for _, key := range []string{"c1", "c1#1", "c1#2"} {
+		if counts[key] != 2 {
+			t.Errorf("expected 2 streams over tunnel %s, but got %d", key, counts[key])
+		}
+	}
+	if counts["c1#3"] != 0 {
+		t.Errorf("expected no streams over tunnel c1#3 without session, but got %d", counts["c1#3"])
+	}
+
+	pool.setHealthy("c1", "c1", false)
+	pool.setHealthy("c1", "c1#1", false)
+	for i := 0; i < 4; i++ {
+		if key := pool.pick("c1"); key != "c1#2" {
+			t.Errorf("expected healthy tunnel c1#2 to be picked, but got %s", key)
+		}
+	}
+
+	pool.release("c1", "c1#2")
+	pool.remove("c1", "c1#2")
+	if key := pool.pick("c1"); key != "c1" && key != "c1#1" {
+		t.Errorf("expected unhealthy tunnels to be picked when no healthy ones left, but got %s", key)
+	}
+}
+
+func TestTunnelPoolAddAndRemove(t *testing.T) {
+	pool, _ := newFakeTunnelPool("c1")
+	if !pool.HasSession("c1") {
+		t.Errorf("expected the first tunnel to be found before being registered")
+	}
+
+	pool.add("c1", "c1")
+	// the agent reconnects before the old connection gets removed
+	pool.add("c1", "c1")
+	pool.remove("c1", "c1")
+	if keys := pool.keys("c1"); len(keys) != 1 {
+		t.Errorf("expected tunnel c1 to be kept, but got %v", keys)
+	}
+	pool.remove("c1", "c1")
+	if clusters := pool.clusters(); len(clusters) != 0 {
+		t.Errorf("expected no clusters left, but got %v", clusters)
+	}
+
+	if pool.HasSession("c2") {
+		t.Errorf("expected no tunnels of cluster c2")
+	}
+	if key := pool.pick("c2"); key != "c2" {
+		t.Errorf("expected to fall back to the cluster id, but got %s", key)
+	}
+}
+
+func TestTunnelPoolDialer(t *testing.T) {
+	pool, dialed := newFakeTunnelPool("c1", "c1#1")
+	pool.add("c1", "c1")
+	pool.add("c1", "c1#1")
+
+	dial := pool.Dialer("c1")
+	conn1, err := dial(context.TODO(), "tcp", "127.0.0.1:6443")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn2, err := dial(context.TODO(), "tcp", "127.0.0.1:6443")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dialed["c1"] != 1 || dialed["c1#1"] != 1 {
+		t.Errorf("expected streams to be spread over tunnels, but got %v", dialed)
+	}
+
+	// closing twice releases the stream only once
+	conn1.Close()
+	conn1.Close()
+	conn2.Close()
+	for _, key := range []string{"c1", "c1#1"} {
+		if streams := pool.tunnels["c1"][key].streams; streams != 0 {
+			t.Errorf("expected no streams over tunnel %s, but got %d", key, streams)
+		}
+	}
+
+	if _, err = pool.Dialer("c2")(context.TODO(), "tcp", "127.0.0.1:6443"); err == nil {
+		t.Errorf("expected error dialing through cluster c2 without tunnels")
+	}
+}
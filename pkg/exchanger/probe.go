@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const (
+	// probeInterval is the interval of probing every tunnel
+	probeInterval = 30 * time.Second
+	// probeTimeout is the timeout of a single probe
+	probeTimeout = 10 * time.Second
+)
+
+// RunProbes probes the health of every tunnel periodically, by sending requests to /healthz of the apiserver
+// of the child cluster through the tunnel. Any response, no matter which status code it carries, means the
+// tunnel works well. Streams are spread over healthy tunnels first.
+func (e *Exchanger) RunProbes(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		for _, clusterID := range e.pool.clusters() {
+			e.probeCluster(clusterID)
+		}
+	}, probeInterval, stopCh)
+}
+
+func (e *Exchanger) probeCluster(clusterID string) {
+	mcls, err := e.mcLister.List(labels.SelectorFromSet(labels.Set{
+		known.ClusterIDLabel: clusterID,
+	}))
+	if err != nil || len(mcls) == 0 || len(mcls[0].Status.APIServerURL) == 0 {
+		klog.V(5).Infof("skip probing tunnels of cluster %s with no apiserver url found", clusterID)
+		return
+	}
+	loc, err := url.Parse(mcls[0].Status.APIServerURL)
+	if err != nil {
+		klog.V(5).Infof("skip probing tunnels of cluster %s with invalid apiserver url: %v", clusterID, err)
+		return
+	}
+	loc.Path = "/healthz"
+
+	for _, key := range e.pool.keys(clusterID) {
+		rtt, err := e.probe(key, loc)
+		if err != nil {
+			klog.Warningf("failed to probe tunnel %s of cluster %s: %v", key, clusterID, err)
+			probeFailuresTotal.WithLabelValues(clusterID).Inc()
+			e.pool.setHealthy(clusterID, key, false)
+			continue
+		}
+		tunnelRTT.WithLabelValues(clusterID).Observe(rtt.Seconds())
+		e.pool.setHealthy(clusterID, key, true)
+	}
+}
+
+func (e *Exchanger) probe(key string, loc *url.URL) (time.Duration, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+			DialContext:       e.pool.dial(key),
+			DisableKeepAlives: true,
+		},
+		Timeout: probeTimeout,
+	}
+
+	start := time.Now()
+	resp, err := client.Get(loc.String())
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return time.Since(start), nil
+}
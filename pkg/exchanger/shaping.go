@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// minBandwidthBurst is the min burst of bandwidth limiters, which should hold a chunk of most reads and writes
+const minBandwidthBurst = 32 * 1024
+
+// tunnelSettings is the settings of the tunnels of a cluster, which are annotated on the ManagedCluster.
+type tunnelSettings struct {
+	// bandwidth is the bytes per second transferred in each direction, and 0 means no limit
+	bandwidth int64
+	// compression is the algorithm compressing the traffic, and empty means no compression
+	compression string
+}
+
+// parseTunnelSettings parses the settings of tunnels from the annotations of a ManagedCluster.
+// Invalid values are ignored, so that the tunnels keep working.
+func parseTunnelSettings(clusterID string, annotations map[string]string) tunnelSettings {
+	settings := tunnelSettings{}
+	if value, ok := annotations[known.TunnelBandwidthAnnotation]; ok {
+		bandwidth, err := resource.ParseQuantity(value)
+		if err != nil || bandwidth.Sign() < 0 {
+			klog.Warningf("ignore invalid annotation %s=%q of cluster %s", known.TunnelBandwidthAnnotation, value, clusterID)
+		} else {
+			settings.bandwidth = bandwidth.Value()
+		}
+	}
+	if value, ok := annotations[known.TunnelCompressionAnnotation]; ok {
+		if err := utils.ValidateTunnelCompression(value); err != nil {
+			klog.Warningf("ignore invalid annotation %s of cluster %s: %v", known.TunnelCompressionAnnotation, clusterID, err)
+		} else {
+			settings.compression = value
+		}
+	}
+	return settings
+}
+
+// bandwidthLimiter limits the bytes transferred through the tunnels of a cluster, which is shared by all the streams.
+type bandwidthLimiter struct {
+	read  *rate.Limiter
+	write *rate.Limiter
+}
+
+func newBandwidthLimiter(bandwidth int64) *bandwidthLimiter {
+	return &bandwidthLimiter{
+		read:  rate.NewLimiter(rate.Limit(bandwidth), bandwidthBurst(bandwidth)),
+		write: rate.NewLimiter(rate.Limit(bandwidth), bandwidthBurst(bandwidth)),
+	}
+}
+
+// bandwidthBurst allows bursts of one second of traffic.
+func bandwidthBurst(bandwidth int64) int {
+	if bandwidth < minBandwidthBurst {
+		return minBandwidthBurst
+	}
+	return int(bandwidth)
+}
+
+func (l *bandwidthLimiter) update(bandwidth int64) {
+	for _, limiter := range []*rate.Limiter{l.read, l.write} {
+		if limiter.Limit() != rate.Limit(bandwidth) {
+			limiter.SetLimit(rate.Limit(bandwidth))
+			limiter.SetBurst(bandwidthBurst(bandwidth))
+		}
+	}
+}
+
+// trafficShaper shapes the traffic through the tunnels of every cluster.
+type trafficShaper struct {
+	lock     sync.Mutex
+	limiters map[string]*bandwidthLimiter
+}
+
+func newTrafficShaper() *trafficShaper {
+	return &trafficShaper{
+		limiters: map[string]*bandwidthLimiter{},
+	}
+}
+
+// limiter returns the limiter of the cluster with the given bandwidth, which is nil for no limit.
+func (s *trafficShaper) limiter(clusterID string, bandwidth int64) *bandwidthLimiter {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if bandwidth <= 0 {
+		delete(s.limiters, clusterID)
+		return nil
+	}
+	limiter := s.limiters[clusterID]
+	if limiter == nil {
+		limiter = newBandwidthLimiter(bandwidth)
+		s.limiters[clusterID] = limiter
+		return limiter
+	}
+	limiter.update(bandwidth)
+	return limiter
+}
+
+// throttledConn is a connection whose reads and writes are throttled by a bandwidthLimiter.
+type throttledConn struct {
+	net.Conn
+	limiter *bandwidthLimiter
+}
+
+func (c *throttledConn) Read(b []byte) (int, error) {
+	if burst := c.limiter.read.Burst(); len(b) > burst {
+		b = b[:burst]
+	}
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		// the bytes have been received, so just hold off the next read
+		c.limiter.read.WaitN(context.Background(), n)
+	}
+	return n, err
+}
+
+func (c *throttledConn) Write(b []byte) (int, error) {
+	var written int
+	for len(b) > 0 {
+		size := len(b)
+		if burst := c.limiter.write.Burst(); size > burst {
+			size = burst
+		}
+		if err := c.limiter.write.WaitN(context.Background(), size); err != nil {
+			return written, err
+		}
+		n, err := c.Conn.Write(b[:size])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[size:]
+	}
+	return written, nil
+}
+
+// tunnelSettings returns the settings of the tunnels of the cluster.
+func (e *Exchanger) tunnelSettings(clusterID string) tunnelSettings {
+	mcls, err := e.mcLister.List(labels.SelectorFromSet(labels.Set{
+		known.ClusterIDLabel: clusterID,
+	}))
+	if err != nil || len(mcls) == 0 {
+		return tunnelSettings{}
+	}
+	return parseTunnelSettings(clusterID, mcls[0].Annotations)
+}
+
+// clusterDialer returns a dialer through the tunnels of the cluster, whose traffic is shaped
+// with the settings annotated on the ManagedCluster on every dial.
+func (e *Exchanger) clusterDialer(clusterID string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	dial := e.pool.Dialer(clusterID)
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		settings := e.tunnelSettings(clusterID)
+		conn, err := dial(ctx, utils.TunnelNetwork(network, settings.compression), address)
+		if err != nil {
+			return nil, err
+		}
+		if limiter := e.shaper.limiter(clusterID, settings.bandwidth); limiter != nil {
+			conn = &throttledConn{Conn: conn, limiter: limiter}
+		}
+		if len(settings.compression) > 0 {
+			conn = utils.NewCompressedConn(conn)
+		}
+		return conn, nil
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestParseTunnelSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        tunnelSettings
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				known.TunnelBandwidthAnnotation:   "10Mi",
+				known.TunnelCompressionAnnotation: "gzip",
+			},
+			want: tunnelSettings{bandwidth: 10 * 1024 * 1024, compression: "gzip"},
+		},
+		{
+			name: "invalid annotations",
+			annotations: map[string]string{
+				known.TunnelBandwidthAnnotation:   "fast",
+				known.TunnelCompressionAnnotation: "zstd",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTunnelSettings("c1", tt.annotations); got != tt.want {
+				t.Errorf("parseTunnelSettings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrafficShaper(t *testing.T) {
+	shaper := newTrafficShaper()
+	if limiter := shaper.limiter("c1", 0); limiter != nil {
+		t.Errorf("expected no limiter without bandwidth")
+	}
+
+	limiter := shaper.limiter("c1", 1024)
+	if limiter == nil || limiter != shaper.limiter("c1", 2048) {
+		t.Fatalf("expected the limiter to be shared by the streams of a cluster")
+	}
+	if limiter.write.Limit() != 2048 {
+		t.Errorf("expected the limit to be updated to 2048, but got %v", limiter.write.Limit())
+	}
+
+	shaper.limiter("c1", 0)
+	if len(shaper.limiters) != 0 {
+		t.Errorf("expected the limiter to be removed")
+	}
+}
+
+func TestThrottledConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			if _, err := server.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	// bursts of minBandwidthBurst bytes are allowed, then 1 byte per millisecond
+	conn := &throttledConn{Conn: client, limiter: newBandwidthLimiter(1000)}
+	start := time.Now()
+	if _, err := conn.Write(make([]byte, minBandwidthBurst+100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected writes to be throttled, but took %v", elapsed)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// immediateFlushInterval makes the proxy flush every watch event to the client immediately,
+// instead of buffering them, which delays the events and bookmarks
+const immediateFlushInterval = -1
+
+// longRunningSubresources are subresources in child clusters that may hold the connections for a long time
+var longRunningSubresources = sets.NewString("exec", "attach", "portforward", "proxy", "log")
+
+// streamingSubresources are subresources of pods in child clusters that stream data in interactive sessions
+var streamingSubresources = sets.NewString("exec", "attach", "portforward", "log")
+
+var requestInfoFactory = &request.RequestInfoFactory{
+	APIPrefixes:          sets.NewString("api", "apis"),
+	GrouplessAPIPrefixes: sets.NewString("api"),
+}
+
+// ChildRequestPath returns the request path in child cluster from the proxy path,
+// which is either "direct/<path>" or "<scheme>/<host>/<path>".
+func ChildRequestPath(proxyPath string) string {
+	parts := strings.Split(strings.TrimLeft(proxyPath, "/"), "/")
+	if parts[0] == "direct" {
+		return "/" + strings.Join(parts[1:], "/")
+	}
+	if len(parts) > 2 {
+		return "/" + strings.Join(parts[2:], "/")
+	}
+	return "/"
+}
+
+// ResolveRequestInfo resolves the request info, such as the verb, of the request proxied to child cluster.
+func ResolveRequestInfo(req *http.Request, proxyPath string) (*request.RequestInfo, error) {
+	proxiedReq := req.Clone(req.Context())
+	proxiedReq.URL.Path = ChildRequestPath(proxyPath)
+	return requestInfoFactory.NewRequestInfo(proxiedReq)
+}
+
+// IsLongRunning tells whether the request to child cluster is long-running, such as watches and exec.
+func IsLongRunning(info *request.RequestInfo) bool {
+	return info.Verb == "watch" || longRunningSubresources.Has(info.Subresource)
+}
+
+// IsStreaming tells whether the request to child cluster is a streaming session with a pod,
+// such as exec, attach, port-forward and logs.
+func IsStreaming(info *request.RequestInfo) bool {
+	return info.IsResourceRequest && info.Resource == "pods" && streamingSubresources.Has(info.Subresource)
+}
+
+// IsLongRunningProxyRequest tells whether the request to the proxy subresource of sockets is long-running,
+// so that it won't be terminated by the request timeout of clusternet-hub.
+func IsLongRunningProxyRequest(req *http.Request, requestInfo *request.RequestInfo) bool {
+	if requestInfo == nil || !requestInfo.IsResourceRequest ||
+		requestInfo.Resource != "sockets" || requestInfo.Subresource != "proxy" {
+		return false
+	}
+	// parts are in the form of "sockets/<cluster-id>/proxy/<proxy-path>"
+	if len(requestInfo.Parts) < 4 {
+		return false
+	}
+	info, err := ResolveRequestInfo(req, strings.Join(requestInfo.Parts[3:], "/"))
+	if err != nil {
+		return false
+	}
+	return IsLongRunning(info)
+}
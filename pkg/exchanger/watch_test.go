@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exchanger
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestChildRequestPath(t *testing.T) {
+	tests := []struct {
+		proxyPath string
+		want      string
+	}{
+		{proxyPath: "direct/api/v1/pods", want: "/api/v1/pods"},
+		{proxyPath: "/direct/apis/apps/v1/deployments", want: "/apis/apps/v1/deployments"},
+		{proxyPath: "https/demo1.cluster.net/api/v1/pods", want: "/api/v1/pods"},
+		{proxyPath: "https/demo1.cluster.net", want: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proxyPath, func(t *testing.T) {
+			if got := ChildRequestPath(tt.proxyPath); got != tt.want {
+				t.Errorf("ChildRequestPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsLongRunningProxyRequest(t *testing.T) {
+	hubRequestInfoFactory := &request.RequestInfoFactory{
+		APIPrefixes:          sets.NewString("api", "apis"),
+		GrouplessAPIPrefixes: sets.NewString("api"),
+	}
+	prefix := "/apis/proxies.clusternet.io/v1alpha1/sockets/dc91021d-2361-4f6d-a404-7c33b9e01118/proxy"
+
+	tests := []struct {
+		name  string
+		path  string
+		query string
+		want  bool
+	}{
+		{name: "list", path: prefix + "/direct/api/v1/pods", want: false},
+		{name: "watch with query", path: prefix + "/direct/api/v1/pods", query: "watch=true", want: true},
+		{name: "watch with query 1", path: prefix + "/direct/api/v1/pods", query: "watch=1", want: true},
+		{name: "watch with path", path: prefix + "/https/demo1.cluster.net/api/v1/watch/namespaces/default/pods", want: true},
+		{name: "exec", path: prefix + "/direct/api/v1/namespaces/default/pods/nginx/exec", want: true},
+		{name: "not a proxy request", path: "/apis/proxies.clusternet.io/v1alpha1/sockets", query: "watch=true", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &http.Request{Method: http.MethodGet, URL: &url.URL{Path: tt.path, RawQuery: tt.query}}
+			info, err := hubRequestInfoFactory.NewRequestInfo(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := IsLongRunningProxyRequest(req, info); got != tt.want {
+				t.Errorf("IsLongRunningProxyRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
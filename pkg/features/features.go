@@ -54,6 +54,76 @@ const (
 	//
 	// Postpone deletion of an object that is being referred as a feed in several Subscriptions.
 	FeedInUseProtection featuregate.Feature = "FeedInUseProtection"
+
+	// alpha: v0.5.0
+	//
+	// Inspect the images referred by feeds from registries, and skip the clusters whose node platforms
+	// are not provided by these images.
+	ImagePlatformCheck featuregate.Feature = "ImagePlatformCheck"
+
+	// alpha: v0.5.0
+	//
+	// Inject the identity of the target cluster, such as cluster id, name and region, as labels into pod templates,
+	// so that logs and metrics emitted in child clusters can be attributed to placement decisions.
+	ClusterIdentityInjection featuregate.Feature = "ClusterIdentityInjection"
+
+	// alpha: v0.5.0
+	//
+	// Enforce ResidencyPolicies, which only allow the referred feeds to be distributed to clusters
+	// located in given regions.
+	DataResidency featuregate.Feature = "DataResidency"
+
+	// alpha: v0.5.0
+	//
+	// Collect actual cpu and memory usage of nodes from metrics-server in child clusters.
+	NodeUsageMetrics featuregate.Feature = "NodeUsageMetrics"
+
+	// alpha: v0.5.0
+	//
+	// Upgrade clusternet-agent across child clusters in batches with AgentUpgradePlans.
+	AgentUpgrade featuregate.Feature = "AgentUpgrade"
+
+	// alpha: v0.5.0
+	//
+	// Authenticate clusternet-agent with short-lived client certificates, which are signed by clusternet-hub
+	// through CertificateSigningRequests and get rotated automatically.
+	CertificateSigning featuregate.Feature = "CertificateSigning"
+
+	// alpha: v0.5.0
+	//
+	// Periodically compare the objects deployed by Descriptions with their live state in child clusters,
+	// and report the drifts in the status of ManagedClusters.
+	DriftReport featuregate.Feature = "DriftReport"
+
+	// alpha: v0.5.0
+	//
+	// Report the live state of the objects deployed by Descriptions, including whether they are applied and healthy,
+	// in the status of Descriptions.
+	ResourceFeedback featuregate.Feature = "ResourceFeedback"
+
+	// alpha: v0.5.0
+	//
+	// Collect the status fields declared by StatusAggregations from child clusters, and aggregate them
+	// into the status of shadow objects in parent cluster. This requires ResourceFeedback on clusternet-agent.
+	StatusAggregation featuregate.Feature = "StatusAggregation"
+
+	// alpha: v0.5.0
+	//
+	// Split child clusters across the replicas of clusternet-hub with consistent hashing, where each replica
+	// deploys Descriptions and HelmReleases only for its own clusters.
+	DeployerSharding featuregate.Feature = "DeployerSharding"
+
+	// alpha: v0.5.0
+	//
+	// Enforce Tenants, which restrict the Subscriptions in their namespaces to the clusters they own,
+	// and limit how many clusters and replicas each Subscription could consume.
+	MultiTenancy featuregate.Feature = "MultiTenancy"
+
+	// alpha: v0.5.0
+	//
+	// Propagate the marked ClusterRoles and ClusterRoleBindings in the parent cluster to child clusters,
+	// so that users visiting child clusters through the proxy get the same permissions everywhere.
+	RBACPropagation featuregate.Feature = "RBACPropagation"
 )
 
 func init() {
@@ -64,9 +134,21 @@ func init() {
 // To add a new feature, define a key for it above and add it here. The features will be
 // available throughout Clusternet binaries.
 var defaultClusternetFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
-	SocketConnection:    {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
-	AppPusher:           {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
-	Deployer:            {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
-	ShadowAPI:           {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
-	FeedInUseProtection: {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	SocketConnection:         {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	AppPusher:                {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	Deployer:                 {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	ShadowAPI:                {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	FeedInUseProtection:      {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	ImagePlatformCheck:       {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	ClusterIdentityInjection: {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	DataResidency:            {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	NodeUsageMetrics:         {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	AgentUpgrade:             {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	CertificateSigning:       {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	DriftReport:              {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	ResourceFeedback:         {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	StatusAggregation:        {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	DeployerSharding:         {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	MultiTenancy:             {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
+	RBACPropagation:          {Default: false, PreRelease: featuregate.Alpha, LockToDefault: false},
 }
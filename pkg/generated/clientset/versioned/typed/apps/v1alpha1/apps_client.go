@@ -27,12 +27,18 @@ type AppsV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	BasesGetter
 	DescriptionsGetter
+	GitRepositoriesGetter
 	GlobalizationsGetter
 	HelmChartsGetter
 	HelmReleasesGetter
+	KustomizationsGetter
 	LocalizationsGetter
 	ManifestsGetter
+	OCIRepositoriesGetter
+	ResidencyPoliciesGetter
+	StatusAggregationsGetter
 	SubscriptionsGetter
+	TenantsGetter
 }
 
 // AppsV1alpha1Client is used to interact with features provided by the apps.clusternet.io group.
@@ -48,6 +54,10 @@ func (c *AppsV1alpha1Client) Descriptions(namespace string) DescriptionInterface
 	return newDescriptions(c, namespace)
 }
 
+func (c *AppsV1alpha1Client) GitRepositories(namespace string) GitRepositoryInterface {
+	return newGitRepositories(c, namespace)
+}
+
 func (c *AppsV1alpha1Client) Globalizations() GlobalizationInterface {
 	return newGlobalizations(c)
 }
@@ -60,6 +70,10 @@ func (c *AppsV1alpha1Client) HelmReleases(namespace string) HelmReleaseInterface
 	return newHelmReleases(c, namespace)
 }
 
+func (c *AppsV1alpha1Client) Kustomizations(namespace string) KustomizationInterface {
+	return newKustomizations(c, namespace)
+}
+
 func (c *AppsV1alpha1Client) Localizations(namespace string) LocalizationInterface {
 	return newLocalizations(c, namespace)
 }
@@ -68,10 +82,26 @@ func (c *AppsV1alpha1Client) Manifests(namespace string) ManifestInterface {
 	return newManifests(c, namespace)
 }
 
+func (c *AppsV1alpha1Client) OCIRepositories(namespace string) OCIRepositoryInterface {
+	return newOCIRepositories(c, namespace)
+}
+
+func (c *AppsV1alpha1Client) ResidencyPolicies() ResidencyPolicyInterface {
+	return newResidencyPolicies(c)
+}
+
+func (c *AppsV1alpha1Client) StatusAggregations(namespace string) StatusAggregationInterface {
+	return newStatusAggregations(c, namespace)
+}
+
 func (c *AppsV1alpha1Client) Subscriptions(namespace string) SubscriptionInterface {
 	return newSubscriptions(c, namespace)
 }
 
+func (c *AppsV1alpha1Client) Tenants() TenantInterface {
+	return newTenants(c)
+}
+
 // NewForConfig creates a new AppsV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*AppsV1alpha1Client, error) {
 	config := *c
@@ -35,6 +35,10 @@ func (c *FakeAppsV1alpha1) Descriptions(namespace string) v1alpha1.DescriptionIn
 	return &FakeDescriptions{c, namespace}
 }
 
+func (c *FakeAppsV1alpha1) GitRepositories(namespace string) v1alpha1.GitRepositoryInterface {
+	return &FakeGitRepositories{c, namespace}
+}
+
 func (c *FakeAppsV1alpha1) Globalizations() v1alpha1.GlobalizationInterface {
 	return &FakeGlobalizations{c}
 }
@@ -47,6 +51,10 @@ func (c *FakeAppsV1alpha1) HelmReleases(namespace string) v1alpha1.HelmReleaseIn
 	return &FakeHelmReleases{c, namespace}
 }
 
+func (c *FakeAppsV1alpha1) Kustomizations(namespace string) v1alpha1.KustomizationInterface {
+	return &FakeKustomizations{c, namespace}
+}
+
 func (c *FakeAppsV1alpha1) Localizations(namespace string) v1alpha1.LocalizationInterface {
 	return &FakeLocalizations{c, namespace}
 }
@@ -55,10 +63,26 @@ func (c *FakeAppsV1alpha1) Manifests(namespace string) v1alpha1.ManifestInterfac
 	return &FakeManifests{c, namespace}
 }
 
+func (c *FakeAppsV1alpha1) OCIRepositories(namespace string) v1alpha1.OCIRepositoryInterface {
+	return &FakeOCIRepositories{c, namespace}
+}
+
+func (c *FakeAppsV1alpha1) ResidencyPolicies() v1alpha1.ResidencyPolicyInterface {
+	return &FakeResidencyPolicies{c}
+}
+
+func (c *FakeAppsV1alpha1) StatusAggregations(namespace string) v1alpha1.StatusAggregationInterface {
+	return &FakeStatusAggregations{c, namespace}
+}
+
 func (c *FakeAppsV1alpha1) Subscriptions(namespace string) v1alpha1.SubscriptionInterface {
 	return &FakeSubscriptions{c, namespace}
 }
 
+func (c *FakeAppsV1alpha1) Tenants() v1alpha1.TenantInterface {
+	return &FakeTenants{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeAppsV1alpha1) RESTClient() rest.Interface {
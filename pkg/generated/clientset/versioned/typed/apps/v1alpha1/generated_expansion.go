@@ -21,14 +21,26 @@ type BaseExpansion interface{}
 
 type DescriptionExpansion interface{}
 
+type GitRepositoryExpansion interface{}
+
 type GlobalizationExpansion interface{}
 
 type HelmChartExpansion interface{}
 
 type HelmReleaseExpansion interface{}
 
+type KustomizationExpansion interface{}
+
 type LocalizationExpansion interface{}
 
 type ManifestExpansion interface{}
 
+type OCIRepositoryExpansion interface{}
+
+type ResidencyPolicyExpansion interface{}
+
+type StatusAggregationExpansion interface{}
+
 type SubscriptionExpansion interface{}
+
+type TenantExpansion interface{}
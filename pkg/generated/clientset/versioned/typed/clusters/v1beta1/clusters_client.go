@@ -25,7 +25,9 @@ import (
 
 type ClustersV1beta1Interface interface {
 	RESTClient() rest.Interface
+	AgentUpgradePlansGetter
 	ClusterRegistrationRequestsGetter
+	GrantsGetter
 	ManagedClustersGetter
 }
 
@@ -34,10 +36,18 @@ type ClustersV1beta1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ClustersV1beta1Client) AgentUpgradePlans() AgentUpgradePlanInterface {
+	return newAgentUpgradePlans(c)
+}
+
 func (c *ClustersV1beta1Client) ClusterRegistrationRequests() ClusterRegistrationRequestInterface {
 	return newClusterRegistrationRequests(c)
 }
 
+func (c *ClustersV1beta1Client) Grants() GrantInterface {
+	return newGrants(c)
+}
+
 func (c *ClustersV1beta1Client) ManagedClusters(namespace string) ManagedClusterInterface {
 	return newManagedClusters(c, namespace)
 }
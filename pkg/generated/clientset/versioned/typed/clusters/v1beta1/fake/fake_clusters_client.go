@@ -27,10 +27,18 @@ type FakeClustersV1beta1 struct {
 	*testing.Fake
 }
 
+func (c *FakeClustersV1beta1) AgentUpgradePlans() v1beta1.AgentUpgradePlanInterface {
+	return &FakeAgentUpgradePlans{c}
+}
+
 func (c *FakeClustersV1beta1) ClusterRegistrationRequests() v1beta1.ClusterRegistrationRequestInterface {
 	return &FakeClusterRegistrationRequests{c}
 }
 
+func (c *FakeClustersV1beta1) Grants() v1beta1.GrantInterface {
+	return &FakeGrants{c}
+}
+
 func (c *FakeClustersV1beta1) ManagedClusters(namespace string) v1beta1.ManagedClusterInterface {
 	return &FakeManagedClusters{c, namespace}
 }
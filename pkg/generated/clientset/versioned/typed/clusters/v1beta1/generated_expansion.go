@@ -17,6 +17,10 @@ limitations under the License.
 
 package v1beta1
 
+type AgentUpgradePlanExpansion interface{}
+
 type ClusterRegistrationRequestExpansion interface{}
 
+type GrantExpansion interface{}
+
 type ManagedClusterExpansion interface{}
@@ -27,18 +27,30 @@ type Interface interface {
 	Bases() BaseInformer
 	// Descriptions returns a DescriptionInformer.
 	Descriptions() DescriptionInformer
+	// GitRepositories returns a GitRepositoryInformer.
+	GitRepositories() GitRepositoryInformer
 	// Globalizations returns a GlobalizationInformer.
 	Globalizations() GlobalizationInformer
 	// HelmCharts returns a HelmChartInformer.
 	HelmCharts() HelmChartInformer
 	// HelmReleases returns a HelmReleaseInformer.
 	HelmReleases() HelmReleaseInformer
+	// Kustomizations returns a KustomizationInformer.
+	Kustomizations() KustomizationInformer
 	// Localizations returns a LocalizationInformer.
 	Localizations() LocalizationInformer
 	// Manifests returns a ManifestInformer.
 	Manifests() ManifestInformer
+	// OCIRepositories returns a OCIRepositoryInformer.
+	OCIRepositories() OCIRepositoryInformer
+	// ResidencyPolicies returns a ResidencyPolicyInformer.
+	ResidencyPolicies() ResidencyPolicyInformer
+	// StatusAggregations returns a StatusAggregationInformer.
+	StatusAggregations() StatusAggregationInformer
 	// Subscriptions returns a SubscriptionInformer.
 	Subscriptions() SubscriptionInformer
+	// Tenants returns a TenantInformer.
+	Tenants() TenantInformer
 }
 
 type version struct {
@@ -62,6 +74,11 @@ func (v *version) Descriptions() DescriptionInformer {
 	return &descriptionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// GitRepositories returns a GitRepositoryInformer.
+func (v *version) GitRepositories() GitRepositoryInformer {
+	return &gitRepositoryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Globalizations returns a GlobalizationInformer.
 func (v *version) Globalizations() GlobalizationInformer {
 	return &globalizationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -77,6 +94,11 @@ func (v *version) HelmReleases() HelmReleaseInformer {
 	return &helmReleaseInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// Kustomizations returns a KustomizationInformer.
+func (v *version) Kustomizations() KustomizationInformer {
+	return &kustomizationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Localizations returns a LocalizationInformer.
 func (v *version) Localizations() LocalizationInformer {
 	return &localizationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -87,7 +109,27 @@ func (v *version) Manifests() ManifestInformer {
 	return &manifestInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// OCIRepositories returns a OCIRepositoryInformer.
+func (v *version) OCIRepositories() OCIRepositoryInformer {
+	return &oCIRepositoryInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ResidencyPolicies returns a ResidencyPolicyInformer.
+func (v *version) ResidencyPolicies() ResidencyPolicyInformer {
+	return &residencyPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// StatusAggregations returns a StatusAggregationInformer.
+func (v *version) StatusAggregations() StatusAggregationInformer {
+	return &statusAggregationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Subscriptions returns a SubscriptionInformer.
 func (v *version) Subscriptions() SubscriptionInformer {
 	return &subscriptionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// Tenants returns a TenantInformer.
+func (v *version) Tenants() TenantInformer {
+	return &tenantInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
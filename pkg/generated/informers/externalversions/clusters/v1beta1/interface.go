@@ -23,8 +23,12 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AgentUpgradePlans returns a AgentUpgradePlanInformer.
+	AgentUpgradePlans() AgentUpgradePlanInformer
 	// ClusterRegistrationRequests returns a ClusterRegistrationRequestInformer.
 	ClusterRegistrationRequests() ClusterRegistrationRequestInformer
+	// Grants returns a GrantInformer.
+	Grants() GrantInformer
 	// ManagedClusters returns a ManagedClusterInformer.
 	ManagedClusters() ManagedClusterInformer
 }
@@ -40,11 +44,21 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AgentUpgradePlans returns a AgentUpgradePlanInformer.
+func (v *version) AgentUpgradePlans() AgentUpgradePlanInformer {
+	return &agentUpgradePlanInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ClusterRegistrationRequests returns a ClusterRegistrationRequestInformer.
 func (v *version) ClusterRegistrationRequests() ClusterRegistrationRequestInformer {
 	return &clusterRegistrationRequestInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// Grants returns a GrantInformer.
+func (v *version) Grants() GrantInformer {
+	return &grantInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ManagedClusters returns a ManagedClusterInformer.
 func (v *version) ManagedClusters() ManagedClusterInformer {
 	return &managedClusterInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
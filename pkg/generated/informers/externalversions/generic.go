@@ -57,22 +57,38 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Bases().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("descriptions"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Descriptions().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("gitrepositories"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().GitRepositories().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("globalizations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Globalizations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("helmcharts"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().HelmCharts().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("helmreleases"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().HelmReleases().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("kustomizations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Kustomizations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("localizations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Localizations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("manifests"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Manifests().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("ocirepositories"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().OCIRepositories().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("residencypolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().ResidencyPolicies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("statusaggregations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().StatusAggregations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("subscriptions"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Subscriptions().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("tenants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().Tenants().Informer()}, nil
 
 		// Group=clusters.clusternet.io, Version=v1beta1
+	case v1beta1.SchemeGroupVersion.WithResource("agentupgradeplans"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Clusters().V1beta1().AgentUpgradePlans().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("clusterregistrationrequests"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Clusters().V1beta1().ClusterRegistrationRequests().Informer()}, nil
+	case v1beta1.SchemeGroupVersion.WithResource("grants"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Clusters().V1beta1().Grants().Informer()}, nil
 	case v1beta1.SchemeGroupVersion.WithResource("managedclusters"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Clusters().V1beta1().ManagedClusters().Informer()}, nil
 
@@ -33,6 +33,14 @@ type DescriptionListerExpansion interface{}
 // DescriptionNamespaceLister.
 type DescriptionNamespaceListerExpansion interface{}
 
+// GitRepositoryListerExpansion allows custom methods to be added to
+// GitRepositoryLister.
+type GitRepositoryListerExpansion interface{}
+
+// GitRepositoryNamespaceListerExpansion allows custom methods to be added to
+// GitRepositoryNamespaceLister.
+type GitRepositoryNamespaceListerExpansion interface{}
+
 // GlobalizationListerExpansion allows custom methods to be added to
 // GlobalizationLister.
 type GlobalizationListerExpansion interface{}
@@ -53,6 +61,14 @@ type HelmReleaseListerExpansion interface{}
 // HelmReleaseNamespaceLister.
 type HelmReleaseNamespaceListerExpansion interface{}
 
+// KustomizationListerExpansion allows custom methods to be added to
+// KustomizationLister.
+type KustomizationListerExpansion interface{}
+
+// KustomizationNamespaceListerExpansion allows custom methods to be added to
+// KustomizationNamespaceLister.
+type KustomizationNamespaceListerExpansion interface{}
+
 // LocalizationListerExpansion allows custom methods to be added to
 // LocalizationLister.
 type LocalizationListerExpansion interface{}
@@ -69,6 +85,26 @@ type ManifestListerExpansion interface{}
 // ManifestNamespaceLister.
 type ManifestNamespaceListerExpansion interface{}
 
+// OCIRepositoryListerExpansion allows custom methods to be added to
+// OCIRepositoryLister.
+type OCIRepositoryListerExpansion interface{}
+
+// OCIRepositoryNamespaceListerExpansion allows custom methods to be added to
+// OCIRepositoryNamespaceLister.
+type OCIRepositoryNamespaceListerExpansion interface{}
+
+// ResidencyPolicyListerExpansion allows custom methods to be added to
+// ResidencyPolicyLister.
+type ResidencyPolicyListerExpansion interface{}
+
+// StatusAggregationListerExpansion allows custom methods to be added to
+// StatusAggregationLister.
+type StatusAggregationListerExpansion interface{}
+
+// StatusAggregationNamespaceListerExpansion allows custom methods to be added to
+// StatusAggregationNamespaceLister.
+type StatusAggregationNamespaceListerExpansion interface{}
+
 // SubscriptionListerExpansion allows custom methods to be added to
 // SubscriptionLister.
 type SubscriptionListerExpansion interface{}
@@ -76,3 +112,7 @@ type SubscriptionListerExpansion interface{}
 // SubscriptionNamespaceListerExpansion allows custom methods to be added to
 // SubscriptionNamespaceLister.
 type SubscriptionNamespaceListerExpansion interface{}
+
+// TenantListerExpansion allows custom methods to be added to
+// TenantLister.
+type TenantListerExpansion interface{}
@@ -17,10 +17,18 @@ limitations under the License.
 
 package v1beta1
 
+// AgentUpgradePlanListerExpansion allows custom methods to be added to
+// AgentUpgradePlanLister.
+type AgentUpgradePlanListerExpansion interface{}
+
 // ClusterRegistrationRequestListerExpansion allows custom methods to be added to
 // ClusterRegistrationRequestLister.
 type ClusterRegistrationRequestListerExpansion interface{}
 
+// GrantListerExpansion allows custom methods to be added to
+// GrantLister.
+type GrantListerExpansion interface{}
+
 // ManagedClusterListerExpansion allows custom methods to be added to
 // ManagedClusterLister.
 type ManagedClusterListerExpansion interface{}
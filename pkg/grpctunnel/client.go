@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpctunnel
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/klog/v2"
+)
+
+// DialFunc dials an address in the child cluster.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// ConnectToServer keeps a Control stream open to clusternet-hub over conn, and serves the dial requests received
+// until the stream is broken or ctx is done.
+func ConnectToServer(ctx context.Context, conn *grpc.ClientConn, index int, dial DialFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	control, err := conn.NewStream(metadata.AppendToOutgoingContext(ctx, IndexMetadataKey, strconv.Itoa(index)),
+		&serviceDesc.Streams[0], controlMethod)
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg := &wrapperspb.BytesValue{}
+		if err = control.RecvMsg(msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		req := dialRequest{}
+		if err = json.Unmarshal(msg.Value, &req); err != nil {
+			klog.Warningf("got an invalid dial request through gRPC tunnel: %v", err)
+			continue
+		}
+		go serveDial(ctx, conn, req, dial)
+	}
+}
+
+// serveDial dials the requested address, and pipes the connection through a Data stream.
+func serveDial(ctx context.Context, conn *grpc.ClientConn, req dialRequest, dial DialFunc) {
+	dialCtx, cancel := context.WithTimeout(ctx, defaultDialTimeout)
+	target, dialErr := dial(dialCtx, req.Network, req.Address)
+	cancel()
+
+	pairs := []string{dialIDMetadataKey, req.ID}
+	if dialErr != nil {
+		klog.V(4).Infof("failed to dial %s through gRPC tunnel: %v", req.Address, dialErr)
+		pairs = append(pairs, dialErrorMetadataKey, dialErr.Error())
+	}
+
+	ctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	stream, err := conn.NewStream(metadata.AppendToOutgoingContext(ctx, pairs...), &serviceDesc.Streams[1], dataMethod)
+	if err != nil {
+		klog.Errorf("failed to open data stream through gRPC tunnel: %v", err)
+		if target != nil {
+			target.Close()
+		}
+		return
+	}
+	if dialErr != nil {
+		// wait for clusternet-hub to end the stream
+		stream.CloseSend()
+		stream.RecvMsg(&wrapperspb.BytesValue{})
+		return
+	}
+	defer target.Close()
+
+	data := newStreamConn(stream, func() {})
+	go func() {
+		io.Copy(data, target)
+		// half-close, so that clusternet-hub gets EOF
+		stream.CloseSend()
+	}()
+	// clusternet-hub ends the stream after closing the connection
+	io.Copy(target, data)
+}
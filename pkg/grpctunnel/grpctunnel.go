@@ -0,0 +1,208 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package grpctunnel implements tunnels between clusternet-hub and clusternet-agent over gRPC bidirectional streams,
+// which could be used in environments where websocket connections are blocked or unstable.
+//
+// clusternet-agent keeps a Control stream open to clusternet-hub. For every connection to be dialed in the child
+// cluster, clusternet-hub sends a dial request over the Control stream, and clusternet-agent answers it by opening a
+// Data stream carrying the bytes of that connection. All the streams are multiplexed over a single HTTP/2 connection.
+package grpctunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	serviceName   = "clusternet.tunnel.v1alpha1.Tunnel"
+	controlMethod = "/" + serviceName + "/Control"
+	dataMethod    = "/" + serviceName + "/Data"
+
+	// IndexMetadataKey is the metadata key carrying the index of a tunnel in the connection pool of a cluster
+	IndexMetadataKey = "clusternet-tunnel-index"
+	// dialIDMetadataKey is the metadata key carrying the id of the dial request answered by a Data stream
+	dialIDMetadataKey = "clusternet-tunnel-dial-id"
+	// dialErrorMetadataKey is the metadata key carrying the error of dialing in the child cluster
+	dialErrorMetadataKey = "clusternet-tunnel-dial-error-bin"
+
+	// maxChunkSize is the max number of bytes sent in a single message
+	maxChunkSize = 32 * 1024
+)
+
+// tunnelHandler is implemented by Server.
+type tunnelHandler interface {
+	serveControl(stream grpc.ServerStream) error
+	serveData(stream grpc.ServerStream) error
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*tunnelHandler)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName: "Control",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(tunnelHandler).serveControl(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName: "Data",
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				return srv.(tunnelHandler).serveData(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+}
+
+// dialRequest asks clusternet-agent to dial an address in the child cluster.
+type dialRequest struct {
+	ID      string `json:"id"`
+	Network string `json:"network"`
+	Address string `json:"address"`
+}
+
+// IndexFromContext returns the index of the tunnel carried in the incoming metadata.
+func IndexFromContext(ctx context.Context) string {
+	return getMetadata(ctx, IndexMetadataKey)
+}
+
+func getMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// messageStream is implemented by both grpc.ServerStream and grpc.ClientStream.
+type messageStream interface {
+	Context() context.Context
+	SendMsg(m interface{}) error
+	RecvMsg(m interface{}) error
+}
+
+// streamConn turns a Data stream into a net.Conn.
+type streamConn struct {
+	stream messageStream
+
+	readBuf []byte
+
+	writeLock sync.Mutex
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	// onClose is called once the conn gets closed
+	onClose func()
+}
+
+var _ net.Conn = &streamConn{}
+
+func newStreamConn(stream messageStream, onClose func()) *streamConn {
+	return &streamConn{
+		stream:  stream,
+		closed:  make(chan struct{}),
+		onClose: onClose,
+	}
+}
+
+func (c *streamConn) Read(b []byte) (int, error) {
+	for len(c.readBuf) == 0 {
+		msg := &wrapperspb.BytesValue{}
+		if err := c.stream.RecvMsg(msg); err != nil {
+			select {
+			case <-c.closed:
+				return 0, io.ErrClosedPipe
+			default:
+			}
+			return 0, err
+		}
+		c.readBuf = msg.Value
+	}
+	n := copy(b, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+func (c *streamConn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	var written int
+	for len(b) > 0 {
+		select {
+		case <-c.closed:
+			return written, io.ErrClosedPipe
+		default:
+		}
+
+		size := len(b)
+		if size > maxChunkSize {
+			size = maxChunkSize
+		}
+		if err := c.stream.SendMsg(&wrapperspb.BytesValue{Value: b[:size]}); err != nil {
+			return written, err
+		}
+		written += size
+		b = b[size:]
+	}
+	return written, nil
+}
+
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.onClose()
+	})
+	return nil
+}
+
+func (c *streamConn) LocalAddr() net.Addr {
+	return tunnelAddr("local")
+}
+
+func (c *streamConn) RemoteAddr() net.Addr {
+	if p, ok := peer.FromContext(c.stream.Context()); ok && p.Addr != nil {
+		return p.Addr
+	}
+	return tunnelAddr("remote")
+}
+
+// deadlines are not supported, connections are closed on cancellation instead
+func (c *streamConn) SetDeadline(time.Time) error      { return nil }
+func (c *streamConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *streamConn) SetWriteDeadline(time.Time) error { return nil }
+
+type tunnelAddr string
+
+func (a tunnelAddr) Network() string { return "grpc" }
+func (a tunnelAddr) String() string  { return string(a) }
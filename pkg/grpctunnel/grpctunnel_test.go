@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpctunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeSessions struct {
+	lock      sync.Mutex
+	connected map[string]bool
+}
+
+func (f *fakeSessions) handle(clusterID, key string, connected bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.connected[key] = connected
+}
+
+func (f *fakeSessions) isConnected(key string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.connected[key]
+}
+
+// setupTunnel starts a Server and connects an agent to it, whose dials are served by dial.
+func setupTunnel(t *testing.T, dial DialFunc) (*Server, *fakeSessions, func()) {
+	sessions := &fakeSessions{connected: map[string]bool{}}
+	server := NewServer(func(ctx context.Context) (string, string, error) {
+		return "c1", "c1#" + IndexFromContext(ctx), nil
+	}, sessions.handle)
+
+	lis := bufconn.Listen(1024 * 1024)
+	gs := grpc.NewServer()
+	server.Register(gs)
+	go gs.Serve(lis)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	go ConnectToServer(ctx, conn, 1, dial)
+
+	if err = waitFor(func() bool { return server.HasSession("c1#1") && sessions.isConnected("c1#1") }); err != nil {
+		t.Fatalf("tunnel is not connected: %v", err)
+	}
+	return server, sessions, func() {
+		cancel()
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func waitFor(condition func() bool) error {
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return errors.New("timed out")
+}
+
+func TestDial(t *testing.T) {
+	echo := func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			io.Copy(server, server)
+			server.Close()
+		}()
+		return client, nil
+	}
+	server, _, stop := setupTunnel(t, echo)
+	defer stop()
+
+	conn, err := server.Dialer("c1#1")(context.TODO(), "tcp", "10.0.0.1:6443")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	payload := make([]byte, maxChunkSize*2+10)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	go conn.Write(payload)
+	got := make([]byte, len(payload))
+	if _, err = io.ReadFull(conn, got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range payload {
+		if got[i] != payload[i] {
+			t.Fatalf("expected byte %d to be %d, but got %d", i, payload[i], got[i])
+		}
+	}
+
+	if _, err = server.Dialer("c1#2")(context.TODO(), "tcp", "10.0.0.1:6443"); err == nil {
+		t.Errorf("expected error dialing through a disconnected tunnel")
+	}
+}
+
+func TestDialError(t *testing.T) {
+	refuse := func(ctx context.Context, network, address string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	server, _, stop := setupTunnel(t, refuse)
+	defer stop()
+
+	_, err := server.Dialer("c1#1")(context.TODO(), "tcp", "10.0.0.1:6443")
+	if err == nil || err.Error() != "connection refused" {
+		t.Errorf("expected error from the agent, but got %v", err)
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if len(server.pending) != 0 {
+		t.Errorf("expected no pending dial requests left, but got %d", len(server.pending))
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	server, sessions, stop := setupTunnel(t, nil)
+	stop()
+
+	if err := waitFor(func() bool { return !server.HasSession("c1#1") && !sessions.isConnected("c1#1") }); err != nil {
+		t.Errorf("expected tunnel c1#1 to be disconnected: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpctunnel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"k8s.io/klog/v2"
+)
+
+// defaultDialTimeout is the timeout of dialing through a tunnel without a deadline given
+const defaultDialTimeout = 30 * time.Second
+
+// Authorizer authenticates clusternet-agent opening a stream, and returns the cluster id and the key of the tunnel.
+type Authorizer func(ctx context.Context) (clusterID string, key string, err error)
+
+// SessionHandler is called when a tunnel gets connected or disconnected.
+type SessionHandler func(clusterID, key string, connected bool)
+
+// Server serves the tunnels set up by clusternet-agents in clusternet-hub.
+type Server struct {
+	authorizer     Authorizer
+	sessionHandler SessionHandler
+
+	lock sync.Mutex
+	// sessions holds the Control streams of each tunnel, where the latest one is used for dialing
+	sessions map[string][]*session
+	// pending holds the dial requests waiting for Data streams
+	pending map[string]*pendingDial
+	nextID  int64
+}
+
+type session struct {
+	clusterID string
+	stream    grpc.ServerStream
+	sendLock  sync.Mutex
+}
+
+func (s *session) send(data []byte) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	return s.stream.SendMsg(&wrapperspb.BytesValue{Value: data})
+}
+
+type pendingDial struct {
+	clusterID string
+	result    chan dialResult
+}
+
+type dialResult struct {
+	conn net.Conn
+	err  error
+}
+
+// NewServer returns a new Server.
+func NewServer(authorizer Authorizer, sessionHandler SessionHandler) *Server {
+	return &Server{
+		authorizer:     authorizer,
+		sessionHandler: sessionHandler,
+		sessions:       map[string][]*session{},
+		pending:        map[string]*pendingDial{},
+	}
+}
+
+// Register registers the tunnel service to the gRPC server.
+func (s *Server) Register(gs *grpc.Server) {
+	gs.RegisterService(&serviceDesc, s)
+}
+
+// HasSession tells whether the tunnel with the given key is connected.
+func (s *Server) HasSession(key string) bool {
+	return s.getSession(key) != nil
+}
+
+func (s *Server) getSession(key string) *session {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sessions := s.sessions[key]
+	if len(sessions) == 0 {
+		return nil
+	}
+	return sessions[len(sessions)-1]
+}
+
+func (s *Server) addSession(key string, sess *session) {
+	s.lock.Lock()
+	s.sessions[key] = append(s.sessions[key], sess)
+	s.lock.Unlock()
+
+	if s.sessionHandler != nil {
+		s.sessionHandler(sess.clusterID, key, true)
+	}
+}
+
+func (s *Server) removeSession(key string, sess *session) {
+	s.lock.Lock()
+	var sessions []*session
+	for _, item := range s.sessions[key] {
+		if item != sess {
+			sessions = append(sessions, item)
+		}
+	}
+	if len(sessions) == 0 {
+		delete(s.sessions, key)
+	} else {
+		s.sessions[key] = sessions
+	}
+	s.lock.Unlock()
+
+	if s.sessionHandler != nil {
+		s.sessionHandler(sess.clusterID, key, false)
+	}
+}
+
+func (s *Server) serveControl(stream grpc.ServerStream) error {
+	clusterID, key, err := s.authorizer(stream.Context())
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	sess := &session{clusterID: clusterID, stream: stream}
+	s.addSession(key, sess)
+	defer s.removeSession(key, sess)
+	klog.Infof("gRPC tunnel %s of cluster %s is connected", key, clusterID)
+
+	// no messages are expected from clusternet-agent, so just wait until the stream is broken
+	for {
+		if err := stream.RecvMsg(&wrapperspb.BytesValue{}); err != nil {
+			klog.Infof("gRPC tunnel %s of cluster %s is disconnected: %v", key, clusterID, err)
+			return nil
+		}
+	}
+}
+
+func (s *Server) serveData(stream grpc.ServerStream) error {
+	clusterID, _, err := s.authorizer(stream.Context())
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	id := getMetadata(stream.Context(), dialIDMetadataKey)
+	s.lock.Lock()
+	p := s.pending[id]
+	// never let a cluster answer the dial requests sent to others
+	if p != nil && p.clusterID == clusterID {
+		delete(s.pending, id)
+	} else {
+		p = nil
+	}
+	s.lock.Unlock()
+	if p == nil {
+		return status.Errorf(codes.NotFound, "no pending dial request %q for cluster %s", id, clusterID)
+	}
+
+	if dialErr := getMetadata(stream.Context(), dialErrorMetadataKey); len(dialErr) > 0 {
+		p.result <- dialResult{err: errors.New(dialErr)}
+		return nil
+	}
+
+	done := make(chan struct{})
+	p.result <- dialResult{conn: newStreamConn(stream, func() { close(done) })}
+	// the Data stream ends once the handler returns
+	select {
+	case <-done:
+	case <-stream.Context().Done():
+	}
+	return nil
+}
+
+// Dialer returns a dialer that dials through the tunnel with the given key.
+func (s *Server) Dialer(key string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		sess := s.getSession(key)
+		if sess == nil {
+			return nil, fmt.Errorf("failed to find gRPC tunnel %s", key)
+		}
+
+		id := strconv.FormatInt(atomic.AddInt64(&s.nextID, 1), 10)
+		p := &pendingDial{
+			clusterID: sess.clusterID,
+			result:    make(chan dialResult, 1),
+		}
+		s.lock.Lock()
+		s.pending[id] = p
+		s.lock.Unlock()
+
+		req, err := json.Marshal(dialRequest{ID: id, Network: network, Address: address})
+		if err != nil {
+			s.cancelDial(id, p)
+			return nil, err
+		}
+		if err = sess.send(req); err != nil {
+			s.cancelDial(id, p)
+			return nil, err
+		}
+
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, defaultDialTimeout)
+			defer cancel()
+		}
+		select {
+		case r := <-p.result:
+			return r.conn, r.err
+		case <-ctx.Done():
+			s.cancelDial(id, p)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// cancelDial withdraws a pending dial request, and closes the connection if it has been answered meanwhile.
+func (s *Server) cancelDial(id string, p *pendingDial) {
+	s.lock.Lock()
+	_, ok := s.pending[id]
+	delete(s.pending, id)
+	s.lock.Unlock()
+
+	if !ok {
+		if r := <-p.result; r.conn != nil {
+			r.conn.Close()
+		}
+	}
+}
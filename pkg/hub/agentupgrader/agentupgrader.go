@@ -0,0 +1,521 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentupgrader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/clusters/agentupgradeplan"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	defaultBatchSize          = 1
+	defaultHealthCheckTimeout = 10 * time.Minute
+	defaultAgentNamespace     = "clusternet-system"
+	defaultAgentDeployment    = "clusternet-agent"
+
+	// healthCheckInterval is how often the upgrading clusters are checked
+	healthCheckInterval = 10 * time.Second
+)
+
+// AgentUpgrader upgrades clusternet-agent in child clusters following AgentUpgradePlans.
+//
+// Clusters are upgraded batch by batch. The next batch won't start until every agent in the
+// current batch has been rolled out and reported heartbeats again. If any agent fails to do so
+// within the health check timeout, the whole batch is rolled back to the previous images and
+// the plan is marked as Failed, leaving the remaining clusters untouched.
+type AgentUpgrader struct {
+	ctx context.Context
+
+	clusterLister clusterlisters.ManagedClusterLister
+	clusterSynced cache.InformerSynced
+	secretLister  corev1lister.SecretLister
+	secretSynced  cache.InformerSynced
+
+	controller *agentupgradeplan.Controller
+
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+// NewAgentUpgrader returns a new AgentUpgrader.
+// It should be called before the informer factories start.
+func NewAgentUpgrader(ctx context.Context, kubeclient *kubernetes.Clientset, clusternetclient *clusternetclientset.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory) (*AgentUpgrader, error) {
+	upgrader := &AgentUpgrader{
+		ctx:           ctx,
+		clusterLister: clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		clusterSynced: clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
+		secretLister:  kubeInformerFactory.Core().V1().Secrets().Lister(),
+		secretSynced:  kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
+		broadcaster:   record.NewBroadcaster(),
+	}
+
+	upgrader.broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
+		Interface: kubeclient.CoreV1().Events(""),
+	})
+	utilruntime.Must(clusterapi.AddToScheme(scheme.Scheme))
+	upgrader.recorder = upgrader.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "clusternet-hub"})
+
+	controller, err := agentupgradeplan.NewController(ctx, clusternetclient,
+		clusternetInformerFactory.Clusters().V1beta1().AgentUpgradePlans(),
+		upgrader.recorder, upgrader.handlePlan)
+	if err != nil {
+		return nil, err
+	}
+	upgrader.controller = controller
+
+	return upgrader, nil
+}
+
+func (upgrader *AgentUpgrader) Run(workers int) {
+	klog.Infof("starting Clusternet agent upgrader ...")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(upgrader.ctx.Done(), upgrader.clusterSynced, upgrader.secretSynced) {
+		return
+	}
+
+	upgrader.controller.Run(workers, upgrader.ctx.Done())
+}
+
+func (upgrader *AgentUpgrader) handlePlan(plan *clusterapi.AgentUpgradePlan) error {
+	klog.V(5).Infof("handle AgentUpgradePlan %s", klog.KObj(plan))
+
+	status := plan.Status.DeepCopy()
+	if len(status.Phase) == 0 || plan.Generation != status.ObservedGeneration {
+		// (re)start the plan on creation and spec changes
+		clusters, err := upgrader.selectClusters(plan)
+		if err != nil {
+			return err
+		}
+		status = &clusterapi.AgentUpgradePlanStatus{
+			Phase:    clusterapi.AgentUpgradeProgressing,
+			Clusters: newClusterUpgradeStatuses(clusters, getBatchSize(plan)),
+		}
+		upgrader.recorder.Eventf(plan, corev1.EventTypeNormal, "UpgradeStarted",
+			"upgrading clusternet-agent to %s in %d clusters", plan.Spec.Image, len(clusters))
+	}
+
+	if status.Phase == clusterapi.AgentUpgradeProgressing {
+		upgrader.progress(plan, status)
+	}
+
+	if err := upgrader.controller.UpdatePlanStatus(plan, status); err != nil {
+		return err
+	}
+
+	if status.Phase == clusterapi.AgentUpgradeProgressing {
+		upgrader.controller.EnqueueAfter(plan, healthCheckInterval)
+	}
+	return nil
+}
+
+// progress checks the health of the upgrading batch, and moves on to the next batch once it succeeds.
+func (upgrader *AgentUpgrader) progress(plan *clusterapi.AgentUpgradePlan, status *clusterapi.AgentUpgradePlanStatus) {
+	batch := currentBatch(status.Clusters)
+	if len(batch) > 0 {
+		if upgrader.checkBatch(plan, status, batch) {
+			upgrader.rollbackBatch(plan, status, batch)
+		}
+		return
+	}
+
+	batch = nextBatch(status.Clusters)
+	if len(batch) == 0 {
+		status.Phase = clusterapi.AgentUpgradeCompleted
+		status.Conditions = utils.MergeConditions(status.Conditions, plan.Generation, metav1.Condition{
+			Type:    clusterapi.AgentUpgradePlanCompleted,
+			Status:  metav1.ConditionTrue,
+			Reason:  "UpgradeSucceeded",
+			Message: fmt.Sprintf("clusternet-agent has been upgraded to %s in all the selected clusters", plan.Spec.Image),
+		})
+		upgrader.recorder.Event(plan, corev1.EventTypeNormal, "UpgradeCompleted", "all the selected clusters have been upgraded")
+		return
+	}
+
+	failed := false
+	for _, idx := range batch {
+		cs := &status.Clusters[idx]
+		previousImage, err := upgrader.upgradeCluster(plan, cs)
+		if err != nil {
+			setClusterPhase(cs, clusterapi.ClusterUpgradeFailed, fmt.Sprintf("failed to upgrade: %v", err))
+			failed = true
+			break
+		}
+		cs.PreviousImage = previousImage
+		setClusterPhase(cs, clusterapi.ClusterUpgradeUpgrading, "")
+	}
+	if failed {
+		upgrader.rollbackBatch(plan, status, batch)
+		return
+	}
+	upgrader.recorder.Eventf(plan, corev1.EventTypeNormal, "BatchStarted", "upgrading batch %d", status.Clusters[batch[0]].Batch)
+}
+
+// checkBatch updates the phases of upgrading clusters, and returns true if the batch should be rolled back.
+func (upgrader *AgentUpgrader) checkBatch(plan *clusterapi.AgentUpgradePlan, status *clusterapi.AgentUpgradePlanStatus, batch []int) bool {
+	timeout := getHealthCheckTimeout(plan)
+	for _, idx := range batch {
+		cs := &status.Clusters[idx]
+		if cs.Phase != clusterapi.ClusterUpgradeUpgrading {
+			continue
+		}
+
+		healthy, msg := upgrader.checkCluster(plan, cs)
+		if healthy {
+			setClusterPhase(cs, clusterapi.ClusterUpgradeSucceeded, "")
+			continue
+		}
+		cs.Message = msg
+		if cs.LastTransitionTime != nil && time.Since(cs.LastTransitionTime.Time) > timeout {
+			setClusterPhase(cs, clusterapi.ClusterUpgradeFailed, fmt.Sprintf("health check timed out after %s: %s", timeout, msg))
+			return true
+		}
+	}
+	return false
+}
+
+// checkCluster tells whether the upgraded agent has been rolled out and reports heartbeats again.
+func (upgrader *AgentUpgrader) checkCluster(plan *clusterapi.AgentUpgradePlan, cs *clusterapi.ClusterUpgradeStatus) (bool, string) {
+	cluster, err := upgrader.getCluster(cs)
+	if err != nil {
+		return false, err.Error()
+	}
+	client, err := upgrader.getChildClient(cluster)
+	if err != nil {
+		return false, err.Error()
+	}
+	deploy, err := client.AppsV1().Deployments(getAgentNamespace(plan)).Get(upgrader.ctx, getAgentDeployment(plan), metav1.GetOptions{})
+	if err != nil {
+		return false, err.Error()
+	}
+
+	idx := agentContainerIndex(deploy, getAgentDeployment(plan))
+	if idx < 0 || deploy.Spec.Template.Spec.Containers[idx].Image != plan.Spec.Image {
+		return false, "the image of clusternet-agent has been changed by others"
+	}
+	if !isAgentRolledOut(deploy) {
+		return false, "waiting for clusternet-agent to be rolled out"
+	}
+	if cs.LastTransitionTime == nil || !isClusterHeartbeating(cluster, cs.LastTransitionTime.Time) {
+		return false, "waiting for heartbeats from the upgraded clusternet-agent"
+	}
+	return true, ""
+}
+
+// rollbackBatch restores the previous images of clusternet-agent in the batch, and fails the plan.
+func (upgrader *AgentUpgrader) rollbackBatch(plan *clusterapi.AgentUpgradePlan, status *clusterapi.AgentUpgradePlanStatus, batch []int) {
+	for _, idx := range batch {
+		cs := &status.Clusters[idx]
+		if len(cs.PreviousImage) == 0 {
+			// not touched yet
+			continue
+		}
+		if cs.Phase != clusterapi.ClusterUpgradeUpgrading && cs.Phase != clusterapi.ClusterUpgradeSucceeded &&
+			cs.Phase != clusterapi.ClusterUpgradeFailed {
+			continue
+		}
+
+		cluster, err := upgrader.getCluster(cs)
+		if err == nil {
+			err = upgrader.setAgentImage(cluster, plan, cs.PreviousImage)
+		}
+		if err != nil {
+			klog.Errorf("failed to roll back clusternet-agent in cluster %s: %v", cs.ClusterID, err)
+			setClusterPhase(cs, clusterapi.ClusterUpgradeFailed, fmt.Sprintf("failed to roll back: %v", err))
+			continue
+		}
+		if cs.Phase == clusterapi.ClusterUpgradeFailed {
+			cs.Message = fmt.Sprintf("rolled back to %s: %s", cs.PreviousImage, cs.Message)
+			continue
+		}
+		setClusterPhase(cs, clusterapi.ClusterUpgradeRolledBack, fmt.Sprintf("rolled back to %s", cs.PreviousImage))
+	}
+
+	batchIndex := status.Clusters[batch[0]].Batch
+	status.Phase = clusterapi.AgentUpgradeFailed
+	status.Conditions = utils.MergeConditions(status.Conditions, plan.Generation, metav1.Condition{
+		Type:    clusterapi.AgentUpgradePlanCompleted,
+		Status:  metav1.ConditionFalse,
+		Reason:  "UpgradeFailed",
+		Message: fmt.Sprintf("batch %d failed and has been rolled back", batchIndex),
+	})
+	upgrader.recorder.Eventf(plan, corev1.EventTypeWarning, "UpgradeFailed", "batch %d failed and has been rolled back", batchIndex)
+}
+
+// upgradeCluster sets the image of clusternet-agent to the target one, and returns the previous image.
+func (upgrader *AgentUpgrader) upgradeCluster(plan *clusterapi.AgentUpgradePlan, cs *clusterapi.ClusterUpgradeStatus) (string, error) {
+	cluster, err := upgrader.getCluster(cs)
+	if err != nil {
+		return "", err
+	}
+	client, err := upgrader.getChildClient(cluster)
+	if err != nil {
+		return "", err
+	}
+	deploy, err := client.AppsV1().Deployments(getAgentNamespace(plan)).Get(upgrader.ctx, getAgentDeployment(plan), metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	idx := agentContainerIndex(deploy, getAgentDeployment(plan))
+	if idx < 0 {
+		return "", fmt.Errorf("no containers found in Deployment %s", klog.KObj(deploy))
+	}
+	previousImage := deploy.Spec.Template.Spec.Containers[idx].Image
+
+	if err = upgrader.setAgentImage(cluster, plan, plan.Spec.Image); err != nil {
+		return "", err
+	}
+	return previousImage, nil
+}
+
+func (upgrader *AgentUpgrader) setAgentImage(cluster *clusterapi.ManagedCluster, plan *clusterapi.AgentUpgradePlan, image string) error {
+	client, err := upgrader.getChildClient(cluster)
+	if err != nil {
+		return err
+	}
+	deploy, err := client.AppsV1().Deployments(getAgentNamespace(plan)).Get(upgrader.ctx, getAgentDeployment(plan), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	idx := agentContainerIndex(deploy, getAgentDeployment(plan))
+	if idx < 0 {
+		return fmt.Errorf("no containers found in Deployment %s", klog.KObj(deploy))
+	}
+
+	// containers are merged by name with strategic merge patch
+	patchData, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []map[string]interface{}{
+						{
+							"name":  deploy.Spec.Template.Spec.Containers[idx].Name,
+							"image": image,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.AppsV1().Deployments(deploy.Namespace).Patch(upgrader.ctx, deploy.Name,
+		types.StrategicMergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
+
+// selectClusters returns the ManagedClusters matching the cluster selector of the plan.
+func (upgrader *AgentUpgrader) selectClusters(plan *clusterapi.AgentUpgradePlan) ([]*clusterapi.ManagedCluster, error) {
+	selector := labels.Everything()
+	if plan.Spec.ClusterSelector != nil {
+		var err error
+		selector, err = metav1.LabelSelectorAsSelector(plan.Spec.ClusterSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return upgrader.clusterLister.List(selector)
+}
+
+func (upgrader *AgentUpgrader) getCluster(cs *clusterapi.ClusterUpgradeStatus) (*clusterapi.ManagedCluster, error) {
+	clusters, err := upgrader.clusterLister.ManagedClusters(cs.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		if cluster.Spec.ClusterID == cs.ClusterID {
+			return cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("ManagedCluster of cluster %s is not found in namespace %s", cs.ClusterID, cs.Namespace)
+}
+
+func (upgrader *AgentUpgrader) getChildClient(cluster *clusterapi.ManagedCluster) (kubernetes.Interface, error) {
+	config, err := utils.GetChildClusterConfig(upgrader.secretLister, upgrader.clusterLister, cluster.Namespace, string(cluster.Spec.ClusterID))
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// newClusterUpgradeStatuses returns the pending upgrade states of clusters, sorted by namespaces
+// and divided into batches.
+func newClusterUpgradeStatuses(clusters []*clusterapi.ManagedCluster, batchSize int32) []clusterapi.ClusterUpgradeStatus {
+	sorted := make([]*clusterapi.ManagedCluster, len(clusters))
+	copy(sorted, clusters)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	now := metav1.Now()
+	statuses := make([]clusterapi.ClusterUpgradeStatus, 0, len(sorted))
+	for i, cluster := range sorted {
+		statuses = append(statuses, clusterapi.ClusterUpgradeStatus{
+			ClusterID:          cluster.Spec.ClusterID,
+			Namespace:          cluster.Namespace,
+			Batch:              int32(i) / batchSize,
+			Phase:              clusterapi.ClusterUpgradePending,
+			LastTransitionTime: &now,
+		})
+	}
+	return statuses
+}
+
+// currentBatch returns the indexes of clusters in the batch being upgraded, if any.
+func currentBatch(statuses []clusterapi.ClusterUpgradeStatus) []int {
+	for _, cs := range statuses {
+		if cs.Phase == clusterapi.ClusterUpgradeUpgrading {
+			return batchIndexes(statuses, cs.Batch)
+		}
+	}
+	return nil
+}
+
+// nextBatch returns the indexes of clusters in the first batch that has pending clusters.
+func nextBatch(statuses []clusterapi.ClusterUpgradeStatus) []int {
+	for _, cs := range statuses {
+		if cs.Phase == clusterapi.ClusterUpgradePending {
+			var indexes []int
+			for idx := range statuses {
+				if statuses[idx].Batch == cs.Batch && statuses[idx].Phase == clusterapi.ClusterUpgradePending {
+					indexes = append(indexes, idx)
+				}
+			}
+			return indexes
+		}
+	}
+	return nil
+}
+
+func batchIndexes(statuses []clusterapi.ClusterUpgradeStatus, batch int32) []int {
+	var indexes []int
+	for idx := range statuses {
+		if statuses[idx].Batch == batch {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes
+}
+
+func setClusterPhase(cs *clusterapi.ClusterUpgradeStatus, phase clusterapi.ClusterUpgradePhase, message string) {
+	now := metav1.Now()
+	cs.Phase = phase
+	cs.Message = message
+	cs.LastTransitionTime = &now
+}
+
+// agentContainerIndex returns the index of the container with the given name, or the first container.
+func agentContainerIndex(deploy *appsv1.Deployment, name string) int {
+	containers := deploy.Spec.Template.Spec.Containers
+	for idx := range containers {
+		if containers[idx].Name == name {
+			return idx
+		}
+	}
+	if len(containers) > 0 {
+		return 0
+	}
+	return -1
+}
+
+// isAgentRolledOut tells whether all the replicas of the Deployment have been updated and are available.
+func isAgentRolledOut(deploy *appsv1.Deployment) bool {
+	if deploy.Status.ObservedGeneration < deploy.Generation {
+		return false
+	}
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	return deploy.Status.UpdatedReplicas == replicas &&
+		deploy.Status.Replicas == replicas &&
+		deploy.Status.AvailableReplicas == replicas
+}
+
+// isClusterHeartbeating tells whether the cluster is ready and has reported its status since the given time.
+func isClusterHeartbeating(cluster *clusterapi.ManagedCluster, since time.Time) bool {
+	if !cluster.Status.LastObservedTime.After(since) {
+		return false
+	}
+	cond := apimeta.FindStatusCondition(cluster.Status.Conditions, clusterapi.ClusterReady)
+	return cond != nil && cond.Status == metav1.ConditionTrue
+}
+
+func getBatchSize(plan *clusterapi.AgentUpgradePlan) int32 {
+	if plan.Spec.BatchSize > 0 {
+		return plan.Spec.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func getHealthCheckTimeout(plan *clusterapi.AgentUpgradePlan) time.Duration {
+	if plan.Spec.HealthCheckTimeout.Duration > 0 {
+		return plan.Spec.HealthCheckTimeout.Duration
+	}
+	return defaultHealthCheckTimeout
+}
+
+func getAgentNamespace(plan *clusterapi.AgentUpgradePlan) string {
+	if len(plan.Spec.AgentNamespace) > 0 {
+		return plan.Spec.AgentNamespace
+	}
+	return defaultAgentNamespace
+}
+
+func getAgentDeployment(plan *clusterapi.AgentUpgradePlan) string {
+	if len(plan.Spec.AgentDeployment) > 0 {
+		return plan.Spec.AgentDeployment
+	}
+	return defaultAgentDeployment
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentupgrader
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilpointer "k8s.io/utils/pointer"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func newCluster(namespace, id string) *clusterapi.ManagedCluster {
+	return &clusterapi.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "cluster"},
+		Spec:       clusterapi.ManagedClusterSpec{ClusterID: types.UID(id)},
+	}
+}
+
+func TestNewClusterUpgradeStatuses(t *testing.T) {
+	clusters := []*clusterapi.ManagedCluster{
+		newCluster("clusternet-c", "c"),
+		newCluster("clusternet-a", "a"),
+		newCluster("clusternet-b", "b"),
+	}
+
+	statuses := newClusterUpgradeStatuses(clusters, 2)
+	var ids []types.UID
+	var batches []int32
+	for _, cs := range statuses {
+		ids = append(ids, cs.ClusterID)
+		batches = append(batches, cs.Batch)
+		if cs.Phase != clusterapi.ClusterUpgradePending {
+			t.Errorf("expected phase Pending, got %s", cs.Phase)
+		}
+	}
+	if !reflect.DeepEqual(ids, []types.UID{"a", "b", "c"}) {
+		t.Errorf("unexpected order %v", ids)
+	}
+	if !reflect.DeepEqual(batches, []int32{0, 0, 1}) {
+		t.Errorf("unexpected batches %v", batches)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	statuses := []clusterapi.ClusterUpgradeStatus{
+		{ClusterID: "a", Batch: 0, Phase: clusterapi.ClusterUpgradeSucceeded},
+		{ClusterID: "b", Batch: 0, Phase: clusterapi.ClusterUpgradeSucceeded},
+		{ClusterID: "c", Batch: 1, Phase: clusterapi.ClusterUpgradePending},
+		{ClusterID: "d", Batch: 1, Phase: clusterapi.ClusterUpgradePending},
+		{ClusterID: "e", Batch: 2, Phase: clusterapi.ClusterUpgradePending},
+	}
+
+	if got := currentBatch(statuses); got != nil {
+		t.Errorf("expected no batch being upgraded, got %v", got)
+	}
+	if got := nextBatch(statuses); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("unexpected next batch %v", got)
+	}
+
+	statuses[2].Phase = clusterapi.ClusterUpgradeSucceeded
+	statuses[3].Phase = clusterapi.ClusterUpgradeUpgrading
+	if got := currentBatch(statuses); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("unexpected current batch %v", got)
+	}
+
+	statuses[3].Phase = clusterapi.ClusterUpgradeSucceeded
+	statuses[4].Phase = clusterapi.ClusterUpgradeSucceeded
+	if got := nextBatch(statuses); got != nil {
+		t.Errorf("expected no more batches, got %v", got)
+	}
+}
+
+func TestIsAgentRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		deploy *appsv1.Deployment
+		want   bool
+	}{
+		{
+			name: "rolled out",
+			deploy: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(2)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			},
+			want: true,
+		},
+		{
+			name: "spec not observed",
+			deploy: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(2)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			},
+			want: false,
+		},
+		{
+			name: "old replicas remaining",
+			deploy: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(2)},
+				Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAgentRolledOut(tt.deploy); got != tt.want {
+				t.Errorf("isAgentRolledOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsClusterHeartbeating(t *testing.T) {
+	since := time.Now()
+	cluster := newCluster("clusternet-a", "a")
+	cluster.Status.Conditions = []metav1.Condition{{Type: clusterapi.ClusterReady, Status: metav1.ConditionTrue}}
+
+	cluster.Status.LastObservedTime = metav1.NewTime(since.Add(-time.Minute))
+	if isClusterHeartbeating(cluster, since) {
+		t.Errorf("expected stale heartbeats to be ignored")
+	}
+
+	cluster.Status.LastObservedTime = metav1.NewTime(since.Add(time.Minute))
+	if !isClusterHeartbeating(cluster, since) {
+		t.Errorf("expected cluster to be heartbeating")
+	}
+
+	cluster.Status.Conditions[0].Status = metav1.ConditionFalse
+	if isClusterHeartbeating(cluster, since) {
+		t.Errorf("expected not ready cluster to be unhealthy")
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package apiserver
 
 import (
+	crdinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -25,6 +26,7 @@ import (
 	"k8s.io/apiserver/pkg/registry/rest"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
@@ -34,9 +36,13 @@ import (
 	"github.com/clusternet/clusternet/pkg/features"
 	clusternet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	informers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
 	shadowapiserver "github.com/clusternet/clusternet/pkg/hub/apiserver/shadow"
+	diffstorage "github.com/clusternet/clusternet/pkg/registry/proxies/diff"
+	renderstorage "github.com/clusternet/clusternet/pkg/registry/proxies/render"
 	socketstorage "github.com/clusternet/clusternet/pkg/registry/proxies/socket"
 	"github.com/clusternet/clusternet/pkg/registry/proxies/socket/subresources"
+	"github.com/clusternet/clusternet/pkg/registry/shadow/template"
 )
 
 var (
@@ -109,9 +115,16 @@ func (cfg *Config) Complete() CompletedConfig {
 }
 
 // New returns a new instance of HubAPIServer from the given config.
-func (c completedConfig) New(tunnelLogging, socketConnection bool, extraHeaderPrefixes []string,
+func (c completedConfig) New(tunnelLogging, socketConnection, requireProxyGrants bool,
+	tunnelGRPCBindAddress, clusterSigningCertFile string,
+	maxProxiedRequestsPerCluster, maxProxiedRequestsPerUser int,
+	proxyImpersonation bool, proxyImpersonationGroups []string, proxySessionLimits subresources.SessionLimits,
+	shadowAdmissionCluster, shadowAuditWebhook string, shadowExcludeResources, extraHeaderPrefixes []string,
+	renderer renderstorage.Renderer, differ diffstorage.Differ,
 	kubeclient *kubernetes.Clientset, clusternetclient *clusternet.Clientset,
-	clusternetInformerFactory informers.SharedInformerFactory) (*HubAPIServer, error) {
+	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	clusternetInformerFactory informers.SharedInformerFactory,
+	crdInformerFactory crdinformers.SharedInformerFactory) (*HubAPIServer, error) {
 	genericServer, err := c.GenericConfig.New("clusternet-hub", genericapiserver.NewEmptyDelegate())
 	if err != nil {
 		return nil, err
@@ -128,15 +141,44 @@ func (c completedConfig) New(tunnelLogging, socketConnection bool, extraHeaderPr
 		ec = exchanger.NewExchanger(tunnelLogging, clusternetInformerFactory.Clusters().V1beta1().ManagedClusters())
 	}
 
+	var grantLister clusterlisters.GrantLister
+	if requireProxyGrants {
+		grantLister = clusternetInformerFactory.Clusters().V1beta1().Grants().Lister()
+	}
+
 	proxiesv1alpha1storage := map[string]rest.Storage{}
 	proxiesv1alpha1storage["sockets"] = socketstorage.NewREST(socketConnection, ec)
-	proxiesv1alpha1storage["sockets/proxy"] = subresources.NewProxyREST(socketConnection, ec, extraHeaderPrefixes)
+	var limiter *subresources.InFlightLimiter
+	if maxProxiedRequestsPerCluster > 0 || maxProxiedRequestsPerUser > 0 {
+		limiter = subresources.NewInFlightLimiter(maxProxiedRequestsPerCluster, maxProxiedRequestsPerUser)
+	}
+	var impersonator *subresources.Impersonator
+	if proxyImpersonation {
+		impersonator = subresources.NewImpersonator(kubeInformerFactory.Core().V1().Secrets().Lister(),
+			clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(), proxyImpersonationGroups)
+	}
+	proxiesv1alpha1storage["sockets/proxy"] = subresources.NewProxyREST(socketConnection, ec, extraHeaderPrefixes,
+		grantLister, limiter, impersonator, proxySessionLimits)
+	proxiesv1alpha1storage["renders"] = renderstorage.NewREST(renderer)
+	proxiesv1alpha1storage["descriptiondiffs"] = diffstorage.NewREST(differ)
 	proxiesAPIGroupInfo.VersionedResourcesStorageMap["v1alpha1"] = proxiesv1alpha1storage
 
 	if err := s.GenericAPIServer.InstallAPIGroup(&proxiesAPIGroupInfo); err != nil {
 		return nil, err
 	}
 
+	if ec != nil {
+		s.GenericAPIServer.AddPostStartHookOrDie("start-clusternet-hub-tunnel-probes", func(context genericapiserver.PostStartHookContext) error {
+			go ec.RunProbes(context.StopCh)
+			return nil
+		})
+		if len(tunnelGRPCBindAddress) > 0 {
+			s.GenericAPIServer.AddPostStartHookOrDie("start-clusternet-hub-grpc-tunnels", func(context genericapiserver.PostStartHookContext) error {
+				return ec.ServeGRPC(tunnelGRPCBindAddress, c.GenericConfig.SecureServing.Cert, clusterSigningCertFile, context.StopCh)
+			})
+		}
+	}
+
 	s.GenericAPIServer.AddPostStartHookOrDie("start-clusternet-hub-shadowapis", func(context genericapiserver.PostStartHookContext) error {
 		if s.GenericAPIServer.OpenAPIVersionedService != nil && s.GenericAPIServer.StaticOpenAPISpec != nil {
 			//openapiController := openapi.NewController(hub.crdInformerFactory.Apiextensions().V1().CustomResourceDefinitions())
@@ -144,13 +186,30 @@ func (c completedConfig) New(tunnelLogging, socketConnection bool, extraHeaderPr
 
 			if utilfeature.DefaultFeatureGate.Enabled(features.ShadowAPI) {
 				klog.Infof("install shadow apis...")
+				var admissionProxy *template.AdmissionProxy
+				if len(shadowAdmissionCluster) > 0 {
+					klog.Infof("objects from shadow apis will be admitted in cluster %s", shadowAdmissionCluster)
+					admissionProxy = template.NewAdmissionProxy(shadowAdmissionCluster,
+						kubeInformerFactory.Core().V1().Secrets().Lister(),
+						clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister())
+				}
+				var auditor *template.Auditor
+				if len(shadowAuditWebhook) > 0 {
+					auditor = template.NewAuditor(shadowAuditWebhook)
+				}
 				ss := shadowapiserver.NewShadowAPIServer(s.GenericAPIServer,
 					c.GenericConfig.MaxRequestBodyBytes,
 					c.GenericConfig.MinRequestTimeout,
 					c.GenericConfig.AdmissionControl,
 					kubeclient,
 					clusternetclient,
-					clusternetInformerFactory)
+					clusternetInformerFactory,
+					crdInformerFactory,
+					admissionProxy,
+					auditor)
+				if err := ss.ExcludeResources(shadowExcludeResources...); err != nil {
+					return err
+				}
 				return ss.InstallShadowAPIGroups(kubeclient.DiscoveryClient)
 			}
 		}
@@ -18,15 +18,19 @@ package apiserver
 
 import (
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
+	crdinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	crdlisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
 	genericapi "k8s.io/apiserver/pkg/endpoints"
 	genericdiscovery "k8s.io/apiserver/pkg/endpoints/discovery"
@@ -37,6 +41,7 @@ import (
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/restmapper"
 	"k8s.io/klog/v2"
 
@@ -81,6 +86,12 @@ func init() {
 	)
 }
 
+// isClusternetGroup tells whether the group belongs to clusternet, which is not shadowed.
+func isClusternetGroup(group string) bool {
+	// no need to duplicate xxx.clusternet.io, and skip shadow group to avoid getting nested
+	return strings.HasSuffix(group, clusternetGroupSuffix) || group == shadowapi.GroupName
+}
+
 // ShadowAPIServer will make a shadow copy for all the APIs
 type ShadowAPIServer struct {
 	GenericAPIServer    *genericapiserver.GenericAPIServer
@@ -95,13 +106,26 @@ type ShadowAPIServer struct {
 	clusternetclient *clusternet.Clientset
 
 	clusternetInformerFactory informers.SharedInformerFactory
+
+	crdLister crdlisters.CustomResourceDefinitionLister
+
+	// admissionProxy invokes admission webhooks in a designated child cluster, which is optional
+	admissionProxy *template.AdmissionProxy
+
+	// auditor records the writes to shadow resources, which is optional
+	auditor *template.Auditor
+
+	// excludedResources are the resources that will not be shadowed
+	excludedResources []resourcePattern
 }
 
 func NewShadowAPIServer(apiserver *genericapiserver.GenericAPIServer,
 	maxRequestBodyBytes int64, minRequestTimeout int,
 	admissionControl admission.Interface,
 	kubeclient *kubernetes.Clientset, clusternetclient *clusternet.Clientset,
-	clusternetInformerFactory informers.SharedInformerFactory) *ShadowAPIServer {
+	clusternetInformerFactory informers.SharedInformerFactory,
+	crdInformerFactory crdinformers.SharedInformerFactory,
+	admissionProxy *template.AdmissionProxy, auditor *template.Auditor) *ShadowAPIServer {
 	return &ShadowAPIServer{
 		GenericAPIServer:          apiserver,
 		maxRequestBodyBytes:       maxRequestBodyBytes,
@@ -110,6 +134,9 @@ func NewShadowAPIServer(apiserver *genericapiserver.GenericAPIServer,
 		kubeclient:                kubeclient,
 		clusternetclient:          clusternetclient,
 		clusternetInformerFactory: clusternetInformerFactory,
+		crdLister:                 crdInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Lister(),
+		admissionProxy:            admissionProxy,
+		auditor:                   auditor,
 	}
 }
 
@@ -121,24 +148,27 @@ func (ss *ShadowAPIServer) InstallShadowAPIGroups(cl discovery.DiscoveryInterfac
 		return err
 	}
 
+	template.RegisterMetrics()
 	shadowv1alpha1storage := map[string]rest.Storage{}
+	customResources := sets.NewString()
 	for _, apiGroupResource := range apiGroupResources {
-		// no need to duplicate xxx.clusternet.io
-		if strings.HasSuffix(apiGroupResource.Group.Name, clusternetGroupSuffix) {
-			continue
-		}
-
-		// skip shadow group to avoid getting nested
-		if apiGroupResource.Group.Name == shadowapi.GroupName {
+		if isClusternetGroup(apiGroupResource.Group.Name) {
 			continue
 		}
 
 		preferredVersion := apiGroupResource.Group.PreferredVersion.Version
 		for _, apiresource := range apiGroupResource.VersionedResources[preferredVersion] {
+			if ss.isExcluded(apiGroupResource.Group.Name, apiresource.Name) {
+				klog.V(4).Infof("skip shadowing excluded resource %s in group %q", apiresource.Name, apiGroupResource.Group.Name)
+				continue
+			}
+
 			// register scheme for original GVK
-			Scheme.AddKnownTypeWithName(schema.GroupVersion{Group: apiGroupResource.Group.Name, Version: preferredVersion}.WithKind(apiresource.Kind),
-				&unstructured.Unstructured{},
-			)
+			gvk := schema.GroupVersion{Group: apiGroupResource.Group.Name, Version: preferredVersion}.WithKind(apiresource.Kind)
+			Scheme.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+			if !kubescheme.Scheme.Recognizes(gvk) {
+				customResources.Insert(apiresource.Name)
+			}
 			resourceRest := template.NewREST(ss.kubeclient, ss.clusternetclient, ParameterCodec, ss.clusternetInformerFactory)
 			resourceRest.SetNamespaceScoped(apiresource.Namespaced)
 			resourceRest.SetName(apiresource.Name)
@@ -146,11 +176,21 @@ func (ss *ShadowAPIServer) InstallShadowAPIGroups(cl discovery.DiscoveryInterfac
 			resourceRest.SetKind(apiresource.Kind)
 			resourceRest.SetGroup(apiGroupResource.Group.Name)
 			resourceRest.SetVersion(preferredVersion)
-			shadowv1alpha1storage[apiresource.Name] = resourceRest
+			resourceRest.SetAdmissionProxy(ss.admissionProxy)
+			resourceRest.SetAuditor(ss.auditor)
+			tableConvertor, err := ss.getTableConvertor(apiGroupResource.Group.Name, preferredVersion, apiresource)
+			if err != nil {
+				klog.Warningf("failed to build table convertor for %s: %v, will fall back to default one",
+					apiresource.Name, err)
+			} else {
+				resourceRest.SetTableConvertor(tableConvertor)
+			}
+			shadowv1alpha1storage[apiresource.Name] = template.NewInstrumentedREST(resourceRest)
 		}
 	}
 
 	shadowAPIGroupInfo := genericapiserver.NewDefaultAPIGroupInfo(shadowapi.GroupName, Scheme, ParameterCodec, Codecs)
+	shadowAPIGroupInfo.NegotiatedSerializer = newShadowNegotiatedSerializer(Codecs)
 	shadowAPIGroupInfo.PrioritizedVersions = []schema.GroupVersion{
 		{
 			Group:   shadowapi.GroupName,
@@ -158,7 +198,17 @@ func (ss *ShadowAPIServer) InstallShadowAPIGroups(cl discovery.DiscoveryInterfac
 		},
 	}
 	shadowAPIGroupInfo.VersionedResourcesStorageMap["v1alpha1"] = shadowv1alpha1storage
-	return ss.installAPIGroups(&shadowAPIGroupInfo)
+	if err = ss.installAPIGroups(&shadowAPIGroupInfo); err != nil {
+		return err
+	}
+
+	rootPath := path.Join(genericapiserver.APIGroupPrefix, shadowapi.SchemeGroupVersion.String())
+	for _, ws := range ss.GenericAPIServer.Handler.GoRestfulContainer.RegisteredWebServices() {
+		if ws.RootPath() == rootPath {
+			ws.Filter(jsonForCustomResources(customResources))
+		}
+	}
+	return nil
 }
 
 // Exposes given api groups in the API.
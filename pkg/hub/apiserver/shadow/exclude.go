@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+const wildcard = "*"
+
+// resourcePattern matches resources with group and resource name, where "*" matches everything.
+type resourcePattern struct {
+	group    string
+	resource string
+}
+
+// parseResourcePattern parses pattern in the format of "<group>/<resource>".
+// A pattern without group, such as "secrets", matches resources in core group.
+func parseResourcePattern(pattern string) (resourcePattern, error) {
+	parts := strings.Split(pattern, "/")
+	switch {
+	case len(parts) == 1 && len(parts[0]) > 0:
+		return resourcePattern{resource: parts[0]}, nil
+	case len(parts) == 2 && len(parts[1]) > 0:
+		return resourcePattern{group: parts[0], resource: parts[1]}, nil
+	default:
+		return resourcePattern{}, fmt.Errorf("invalid resource pattern %q, should be in the format of <group>/<resource>", pattern)
+	}
+}
+
+func (p resourcePattern) matches(group, resource string) bool {
+	// subresources are excluded together with their parent resources
+	resource = strings.Split(resource, "/")[0]
+	return (p.group == wildcard || p.group == group) && (p.resource == wildcard || p.resource == resource)
+}
+
+// ValidateResourcePatterns validates the patterns of resources to be excluded from shadow APIs.
+func ValidateResourcePatterns(patterns []string) []error {
+	var allErrs []error
+	for _, pattern := range patterns {
+		if _, err := parseResourcePattern(pattern); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return allErrs
+}
+
+// ExcludeResources prevents resources matching any of the patterns from being shadowed.
+// Patterns are in the format of "<group>/<resource>", such as "secrets", "coordination.k8s.io/leases"
+// and "events.k8s.io/*".
+func (ss *ShadowAPIServer) ExcludeResources(patterns ...string) error {
+	for _, pattern := range patterns {
+		p, err := parseResourcePattern(pattern)
+		if err != nil {
+			return err
+		}
+		ss.excludedResources = append(ss.excludedResources, p)
+	}
+	return nil
+}
+
+func (ss *ShadowAPIServer) isExcluded(group, resource string) bool {
+	for _, p := range ss.excludedResources {
+		if p.matches(group, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsShadowed tells whether the resource is served by shadow APIs, i.e. it is neither in the groups of clusternet
+// nor excluded by any of the patterns. Invalid patterns are ignored, which are rejected when validating options.
+func IsShadowed(group, resource string, excludePatterns []string) bool {
+	if isClusternetGroup(group) {
+		return false
+	}
+	for _, pattern := range excludePatterns {
+		p, err := parseResourcePattern(pattern)
+		if err == nil && p.matches(group, resource) {
+			return false
+		}
+	}
+	return true
+}
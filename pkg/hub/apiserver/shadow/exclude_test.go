@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"testing"
+)
+
+func TestIsExcluded(t *testing.T) {
+	ss := &ShadowAPIServer{}
+	if err := ss.ExcludeResources("secrets", "coordination.k8s.io/leases", "events.k8s.io/*", "*/events"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		group    string
+		resource string
+		want     bool
+	}{
+		{"", "secrets", true},
+		{"", "configmaps", false},
+		{"apps", "secrets", false},
+		{"coordination.k8s.io", "leases", true},
+		{"coordination.k8s.io", "leases/status", true},
+		{"events.k8s.io", "events", true},
+		{"", "events", true},
+		{"apps", "deployments", false},
+	}
+
+	for _, tt := range tests {
+		if got := ss.isExcluded(tt.group, tt.resource); got != tt.want {
+			t.Errorf("isExcluded(%q, %q) = %v, want %v", tt.group, tt.resource, got, tt.want)
+		}
+	}
+}
+
+func TestValidateResourcePatterns(t *testing.T) {
+	if errs := ValidateResourcePatterns([]string{"secrets", "apps/*", "*/*"}); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if errs := ValidateResourcePatterns([]string{"", "apps/", "a/b/c"}); len(errs) != 3 {
+		t.Errorf("expected 3 errors, got %v", errs)
+	}
+}
+
+func TestIsShadowed(t *testing.T) {
+	patterns := []string{"secrets", "events.k8s.io/*"}
+	tests := []struct {
+		group    string
+		resource string
+		want     bool
+	}{
+		{"", "configmaps", true},
+		{"", "secrets", false},
+		{"events.k8s.io", "events", false},
+		{"apps", "deployments", true},
+		{"apps.clusternet.io", "subscriptions", false},
+		{"shadow", "deployments", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsShadowed(tt.group, tt.resource, patterns); got != tt.want {
+			t.Errorf("IsShadowed(%q, %q) = %v, want %v", tt.group, tt.resource, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	crdlisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/registry/customresource/tableconvertor"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+)
+
+// builtInPrinterColumns mimics the columns printed by kube-apiserver for some commonly used built-in resources.
+// Since the built-in printers live in k8s.io/kubernetes, here we describe them with JSONPath.
+var builtInPrinterColumns = map[schema.GroupResource][]apiextensionsv1.CustomResourceColumnDefinition{
+	{Group: "apps", Resource: "deployments"}: {
+		{Name: "Ready", Type: "integer", JSONPath: ".status.readyReplicas"},
+		{Name: "Desired", Type: "integer", JSONPath: ".spec.replicas"},
+		{Name: "Up-to-date", Type: "integer", JSONPath: ".status.updatedReplicas"},
+		{Name: "Available", Type: "integer", JSONPath: ".status.availableReplicas"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "apps", Resource: "statefulsets"}: {
+		{Name: "Ready", Type: "integer", JSONPath: ".status.readyReplicas"},
+		{Name: "Desired", Type: "integer", JSONPath: ".spec.replicas"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "apps", Resource: "replicasets"}: {
+		{Name: "Desired", Type: "integer", JSONPath: ".spec.replicas"},
+		{Name: "Current", Type: "integer", JSONPath: ".status.replicas"},
+		{Name: "Ready", Type: "integer", JSONPath: ".status.readyReplicas"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "apps", Resource: "daemonsets"}: {
+		{Name: "Desired", Type: "integer", JSONPath: ".status.desiredNumberScheduled"},
+		{Name: "Current", Type: "integer", JSONPath: ".status.currentNumberScheduled"},
+		{Name: "Ready", Type: "integer", JSONPath: ".status.numberReady"},
+		{Name: "Up-to-date", Type: "integer", JSONPath: ".status.updatedNumberScheduled"},
+		{Name: "Available", Type: "integer", JSONPath: ".status.numberAvailable"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "batch", Resource: "jobs"}: {
+		{Name: "Completions", Type: "integer", JSONPath: ".spec.completions"},
+		{Name: "Succeeded", Type: "integer", JSONPath: ".status.succeeded"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "batch", Resource: "cronjobs"}: {
+		{Name: "Schedule", Type: "string", JSONPath: ".spec.schedule"},
+		{Name: "Suspend", Type: "boolean", JSONPath: ".spec.suspend"},
+		{Name: "Last Schedule", Type: "date", JSONPath: ".status.lastScheduleTime"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "", Resource: "services"}: {
+		{Name: "Type", Type: "string", JSONPath: ".spec.type"},
+		{Name: "Cluster-IP", Type: "string", JSONPath: ".spec.clusterIP"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "", Resource: "pods"}: {
+		{Name: "Status", Type: "string", JSONPath: ".status.phase"},
+		{Name: "Node", Type: "string", JSONPath: ".spec.nodeName", Priority: 1},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "", Resource: "configmaps"}: {
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "", Resource: "secrets"}: {
+		{Name: "Type", Type: "string", JSONPath: ".type"},
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+	{Group: "", Resource: "namespaces"}: {
+		{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"},
+	},
+}
+
+// getTableConvertor returns a TableConvertor for the shadow resource, whose columns are
+// extracted from the additionalPrinterColumns of the original CRD or the built-in printers.
+// A nil TableConvertor means the default one should be used.
+func (ss *ShadowAPIServer) getTableConvertor(group, version string, apiresource metav1.APIResource) (rest.TableConvertor, error) {
+	// subresources, such as "deployments/scale", use the default table convertor
+	if strings.Contains(apiresource.Name, "/") {
+		return nil, nil
+	}
+
+	if columns, ok := builtInPrinterColumns[schema.GroupResource{Group: group, Resource: apiresource.Name}]; ok {
+		return tableconvertor.New(columns)
+	}
+	return newCRDTableConvertor(ss.crdLister, group, version, apiresource.Name), nil
+}
+
+// crdTableConvertor converts objects with the additionalPrinterColumns of the original CRD, which is looked up
+// on every conversion, since the CRD informer may not have synced when shadow APIs get installed, and the columns
+// may change afterwards. Resources not defined by CRDs use the default columns.
+type crdTableConvertor struct {
+	crdLister crdlisters.CustomResourceDefinitionLister
+	crdName   string
+	version   string
+
+	defaultConvertor rest.TableConvertor
+
+	lock sync.Mutex
+	// convertor is built from the CRD with resourceVersion
+	convertor       rest.TableConvertor
+	resourceVersion string
+}
+
+func newCRDTableConvertor(crdLister crdlisters.CustomResourceDefinitionLister, group, version, resource string) *crdTableConvertor {
+	return &crdTableConvertor{
+		crdLister:        crdLister,
+		crdName:          fmt.Sprintf("%s.%s", resource, group),
+		version:          version,
+		defaultConvertor: rest.NewDefaultTableConvertor(schema.GroupResource{Group: group, Resource: resource}),
+	}
+}
+
+func (c *crdTableConvertor) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	convertor, err := c.getConvertor()
+	if err != nil {
+		klog.Warningf("failed to build table convertor from CRD %s: %v, will fall back to default one", c.crdName, err)
+		convertor = c.defaultConvertor
+	}
+	return convertor.ConvertToTable(ctx, object, tableOptions)
+}
+
+func (c *crdTableConvertor) getConvertor() (rest.TableConvertor, error) {
+	crd, err := c.crdLister.Get(c.crdName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return c.defaultConvertor, nil
+		}
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.convertor != nil && c.resourceVersion == crd.ResourceVersion {
+		return c.convertor, nil
+	}
+
+	var columns []apiextensionsv1.CustomResourceColumnDefinition
+	for _, crdVersion := range crd.Spec.Versions {
+		if crdVersion.Name == c.version {
+			columns = crdVersion.AdditionalPrinterColumns
+			break
+		}
+	}
+	convertor := c.defaultConvertor
+	if len(columns) > 0 {
+		if convertor, err = tableconvertor.New(columns); err != nil {
+			return nil, err
+		}
+	}
+	c.convertor = convertor
+	c.resourceVersion = crd.ResourceVersion
+	return convertor, nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	crdlisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCRDTableConvertor(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	convertor := newCRDTableConvertor(crdlisters.NewCustomResourceDefinitionLister(indexer), "example.io", "v1", "foos")
+
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.io/v1")
+	obj.SetKind("Foo")
+	obj.SetName("foo")
+	obj.SetNamespace("default")
+	obj.Object["spec"] = map[string]interface{}{"size": int64(3)}
+
+	columnNames := func() []string {
+		table, err := convertor.ConvertToTable(context.TODO(), obj, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var names []string
+		for _, column := range table.ColumnDefinitions {
+			names = append(names, column.Name)
+		}
+		return names
+	}
+
+	// the CRD has not been synced yet
+	if got, want := columnNames(), []string{"Name", "Created At"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns before CRD synced = %v, want %v", got, want)
+	}
+
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "foos.example.io", ResourceVersion: "1"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.io",
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name: "v1",
+					AdditionalPrinterColumns: []apiextensionsv1.CustomResourceColumnDefinition{
+						{Name: "Size", Type: "integer", JSONPath: ".spec.size"},
+					},
+				},
+			},
+		},
+	}
+	if err := indexer.Add(crd); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := columnNames(), []string{"Name", "Size"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns after CRD synced = %v, want %v", got, want)
+	}
+
+	// columns follow updates of the CRD
+	crd = crd.DeepCopy()
+	crd.ResourceVersion = "2"
+	crd.Spec.Versions[0].AdditionalPrinterColumns = append(crd.Spec.Versions[0].AdditionalPrinterColumns,
+		apiextensionsv1.CustomResourceColumnDefinition{Name: "Age", Type: "date", JSONPath: ".metadata.creationTimestamp"})
+	if err := indexer.Update(crd); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := columnNames(), []string{"Name", "Size", "Age"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns after CRD updated = %v, want %v", got, want)
+	}
+}
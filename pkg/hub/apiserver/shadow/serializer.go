@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer/protobuf"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	kubescheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+// shadowNegotiatedSerializer serves shadow APIs with protobuf for built-in kinds,
+// besides JSON and YAML provided by the wrapped NegotiatedSerializer.
+//
+// Shadow objects are kept as unstructured, which has no protobuf representation. So objects of
+// built-in kinds known to client-go are converted from/to their typed counterparts on the fly.
+// Watch is not served with protobuf, since the embedded objects are encoded separately.
+type shadowNegotiatedSerializer struct {
+	runtime.NegotiatedSerializer
+
+	protobufSerializer runtime.Serializer
+}
+
+func newShadowNegotiatedSerializer(ns runtime.NegotiatedSerializer) runtime.NegotiatedSerializer {
+	return shadowNegotiatedSerializer{
+		NegotiatedSerializer: ns,
+		protobufSerializer: &unstructuredProtobufSerializer{
+			scheme:     kubescheme.Scheme,
+			serializer: protobuf.NewSerializer(kubescheme.Scheme, kubescheme.Scheme),
+		},
+	}
+}
+
+func (s shadowNegotiatedSerializer) SupportedMediaTypes() []runtime.SerializerInfo {
+	infos := s.NegotiatedSerializer.SupportedMediaTypes()
+	result := make([]runtime.SerializerInfo, 0, len(infos)+1)
+	for _, info := range infos {
+		// the protobuf serializer is removed from the codec factory of clusternet, and is replaced below
+		if info.MediaType == runtime.ContentTypeProtobuf {
+			continue
+		}
+		result = append(result, info)
+	}
+	return append(result, runtime.SerializerInfo{
+		MediaType:        runtime.ContentTypeProtobuf,
+		MediaTypeType:    "application",
+		MediaTypeSubType: "vnd.kubernetes.protobuf",
+		Serializer:       s.protobufSerializer,
+	})
+}
+
+// jsonForCustomResources serves custom resources with JSON to the clients preferring protobuf, the way
+// kube-apiserver serves CRDs, since custom resources have no protobuf representation.
+func jsonForCustomResources(customResources sets.String) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if info, ok := request.RequestInfoFrom(req.Request.Context()); ok && customResources.Has(info.Resource) {
+			req.Request.Header.Set("Accept", withoutProtobuf(req.Request.Header.Get("Accept")))
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// withoutProtobuf removes protobuf from the media types of an Accept header,
+// and falls back to JSON if nothing else is accepted.
+func withoutProtobuf(accept string) string {
+	var mediaTypes []string
+	for _, mediaType := range strings.Split(accept, ",") {
+		if strings.HasPrefix(strings.TrimSpace(mediaType), runtime.ContentTypeProtobuf) {
+			continue
+		}
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	if len(mediaTypes) == 0 {
+		return runtime.ContentTypeJSON
+	}
+	return strings.Join(mediaTypes, ",")
+}
+
+// unstructuredProtobufSerializer encodes/decodes unstructured objects of built-in kinds with protobuf.
+type unstructuredProtobufSerializer struct {
+	scheme     *runtime.Scheme
+	serializer *protobuf.Serializer
+}
+
+func (s *unstructuredProtobufSerializer) Decode(data []byte, defaults *schema.GroupVersionKind, into runtime.Object) (runtime.Object, *schema.GroupVersionKind, error) {
+	obj, gvk, err := s.serializer.Decode(data, defaults, nil)
+	if err != nil {
+		return nil, gvk, err
+	}
+
+	switch t := into.(type) {
+	case *unstructured.Unstructured:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, gvk, err
+		}
+		t.SetUnstructuredContent(content)
+		t.SetGroupVersionKind(*gvk)
+		return t, gvk, nil
+	case *unstructured.UnstructuredList:
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, gvk, err
+		}
+		t.SetUnstructuredContent(content)
+		t.SetGroupVersionKind(*gvk)
+		return t, gvk, nil
+	}
+	return obj, gvk, nil
+}
+
+func (s *unstructuredProtobufSerializer) Encode(obj runtime.Object, w io.Writer) error {
+	switch t := obj.(type) {
+	case *unstructured.Unstructured:
+		typed, err := s.toTyped(t.GroupVersionKind(), t.UnstructuredContent())
+		if err != nil {
+			return err
+		}
+		return s.serializer.Encode(typed, w)
+	case *unstructured.UnstructuredList:
+		typed, err := s.toTyped(t.GroupVersionKind(), t.UnstructuredContent())
+		if err != nil {
+			return err
+		}
+		return s.serializer.Encode(typed, w)
+	}
+	return s.serializer.Encode(obj, w)
+}
+
+func (s *unstructuredProtobufSerializer) Identifier() runtime.Identifier {
+	return s.serializer.Identifier()
+}
+
+// toTyped converts unstructured content to the typed object of gvk, which should be a built-in kind.
+func (s *unstructuredProtobufSerializer) toTyped(gvk schema.GroupVersionKind, content map[string]interface{}) (runtime.Object, error) {
+	if !s.scheme.Recognizes(gvk) {
+		return nil, fmt.Errorf("protobuf is not supported for %s, please use application/json instead", gvk.String())
+	}
+	typed, err := s.scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(content, typed); err != nil {
+		return nil, err
+	}
+	typed.GetObjectKind().SetGroupVersionKind(gvk)
+	return typed, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/endpoints/handlers/negotiation"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestUnstructuredProtobufSerializer(t *testing.T) {
+	var serializer runtime.Serializer
+	for _, info := range newShadowNegotiatedSerializer(Codecs).SupportedMediaTypes() {
+		if info.MediaType == runtime.ContentTypeProtobuf {
+			serializer = info.Serializer
+		}
+	}
+	if serializer == nil {
+		t.Fatalf("protobuf is not supported")
+	}
+
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "bar",
+		},
+		"data": map[string]interface{}{
+			"key": "value",
+		},
+	}}
+	buf := &bytes.Buffer{}
+	if err := serializer.Encode(cm, buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded := &unstructured.Unstructured{}
+	if _, _, err := serializer.Decode(buf.Bytes(), nil, decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.GetName() != "foo" || decoded.GetNamespace() != "bar" || decoded.GetKind() != "ConfigMap" {
+		t.Errorf("unexpected decoded object: %v", decoded.Object)
+	}
+	if value, _, _ := unstructured.NestedString(decoded.Object, "data", "key"); value != "value" {
+		t.Errorf("expected data.key to be value, got %q", value)
+	}
+
+	// custom resources are not supported
+	foo := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Foo",
+		"metadata": map[string]interface{}{
+			"name": "foo",
+		},
+	}}
+	if err := serializer.Encode(foo, &bytes.Buffer{}); err == nil {
+		t.Errorf("expected error on encoding custom resources")
+	}
+}
+
+func TestNegotiateProtobufForCustomResources(t *testing.T) {
+	ns := newShadowNegotiatedSerializer(Codecs)
+	foo := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Foo",
+		"metadata": map[string]interface{}{
+			"name": "foo",
+		},
+	}}
+
+	tests := []struct {
+		name          string
+		accept        string
+		wantMediaType string
+	}{
+		{
+			name:          "prefer protobuf",
+			accept:        "application/vnd.kubernetes.protobuf, application/json",
+			wantMediaType: runtime.ContentTypeJSON,
+		},
+		{
+			name:          "protobuf only",
+			accept:        "application/vnd.kubernetes.protobuf",
+			wantMediaType: runtime.ContentTypeJSON,
+		},
+		{
+			name:          "yaml",
+			accept:        "application/vnd.kubernetes.protobuf, application/yaml",
+			wantMediaType: runtime.ContentTypeYAML,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/apis/shadow/v1alpha1/foos/foo", nil)
+			req.Header.Set("Accept", tt.accept)
+			req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{Resource: "foos"}))
+
+			var info runtime.SerializerInfo
+			chain := &restful.FilterChain{Target: func(req *restful.Request, resp *restful.Response) {
+				var err error
+				_, info, err = negotiation.NegotiateOutputMediaType(req.Request, ns, negotiation.DefaultEndpointRestrictions)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}}
+			jsonForCustomResources(sets.NewString("foos"))(restful.NewRequest(req), nil, chain)
+			if info.MediaType != tt.wantMediaType {
+				t.Fatalf("expected media type %s, got %s", tt.wantMediaType, info.MediaType)
+			}
+
+			buf := &bytes.Buffer{}
+			if err := info.Serializer.Encode(foo, buf); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantMediaType == runtime.ContentTypeJSON && !json.Valid(buf.Bytes()) {
+				t.Errorf("expected JSON, got %q", buf.String())
+			}
+		})
+	}
+
+	// built-in kinds are still served with protobuf
+	req := httptest.NewRequest(http.MethodGet, "/apis/shadow/v1alpha1/configmaps/foo", nil)
+	req.Header.Set("Accept", "application/vnd.kubernetes.protobuf, application/json")
+	req = req.WithContext(request.WithRequestInfo(req.Context(), &request.RequestInfo{Resource: "configmaps"}))
+	jsonForCustomResources(sets.NewString("foos"))(restful.NewRequest(req), nil, &restful.FilterChain{
+		Target: func(req *restful.Request, resp *restful.Response) {
+			_, info, err := negotiation.NegotiateOutputMediaType(req.Request, ns, negotiation.DefaultEndpointRestrictions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if info.MediaType != runtime.ContentTypeProtobuf {
+				t.Errorf("expected media type %s, got %s", runtime.ContentTypeProtobuf, info.MediaType)
+			}
+		},
+	})
+}
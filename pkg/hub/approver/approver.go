@@ -17,13 +17,20 @@ limitations under the License.
 package approver
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
 	"strings"
 	"sync"
+	"time"
 
+	certificatesv1 "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -62,22 +69,36 @@ type CRRApprover struct {
 	kubeclient       *kubernetes.Clientset
 	clusternetclient *clusternetClientSet.Clientset
 
-	socketConnection bool
+	socketConnection   bool
+	certificateSigning bool
+
+	// renewalMinInterval is the min interval between two approved renewals of the same cluster
+	renewalMinInterval time.Duration
+
+	// policy decides which requests get approved automatically, and all are approved if nil
+	policy *ApprovalPolicy
+	// webhook reviews the requests approved by the policy if not nil
+	webhook *RegistrationWebhook
 }
 
 // NewCRRApprover returns a new CRRApprover for ClusterRegistrationRequest.
 func NewCRRApprover(ctx context.Context, kubeclient *kubernetes.Clientset, clusternetclient *clusternetClientSet.Clientset,
 	clusternetInformerFactory clusternetInformers.SharedInformerFactory, kubeInformerFactory kubeInformers.SharedInformerFactory,
-	socketConnection bool) (*CRRApprover, error) {
+	socketConnection, certificateSigning bool, renewalMinInterval time.Duration, policy *ApprovalPolicy,
+	webhook *RegistrationWebhook) (*CRRApprover, error) {
 	crrApprover := &CRRApprover{
-		ctx:              ctx,
-		kubeclient:       kubeclient,
-		clusternetclient: clusternetclient,
-		crrLister:        clusternetInformerFactory.Clusters().V1beta1().ClusterRegistrationRequests().Lister(),
-		mclsLister:       clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
-		nsLister:         kubeInformerFactory.Core().V1().Namespaces().Lister(),
-		saLister:         kubeInformerFactory.Core().V1().ServiceAccounts().Lister(),
-		socketConnection: socketConnection,
+		ctx:                ctx,
+		kubeclient:         kubeclient,
+		clusternetclient:   clusternetclient,
+		crrLister:          clusternetInformerFactory.Clusters().V1beta1().ClusterRegistrationRequests().Lister(),
+		mclsLister:         clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		nsLister:           kubeInformerFactory.Core().V1().Namespaces().Lister(),
+		saLister:           kubeInformerFactory.Core().V1().ServiceAccounts().Lister(),
+		socketConnection:   socketConnection,
+		certificateSigning: certificateSigning,
+		renewalMinInterval: renewalMinInterval,
+		policy:             policy,
+		webhook:            webhook,
 	}
 
 	newCRRController, err := clusterregistrationrequest.NewController(ctx,
@@ -183,6 +204,14 @@ func (crrApprover *CRRApprover) defaultClusterRoles(clusterID types.UID) []rbacv
 					"get",    // and get the created object, we don't allow to "list" operation due to security concerns
 				},
 			},
+			{
+				APIGroups:     []string{clusters.GroupName},
+				Resources:     []string{"clusterregistrationrequests"},
+				ResourceNames: []string{known.NamePrefixForClusternetObjects + string(clusterID)},
+				Verbs: []string{
+					"patch", // request renewals of the dedicated token with annotations
+				},
+			},
 		},
 	}
 
@@ -195,6 +224,17 @@ func (crrApprover *CRRApprover) defaultClusterRoles(clusterID types.UID) []rbacv
 		})
 	}
 
+	if crrApprover.certificateSigning {
+		clusterRoles.Rules = append(clusterRoles.Rules, rbacv1.PolicyRule{
+			APIGroups: []string{certificatesv1.GroupName},
+			Resources: []string{"certificatesigningrequests"},
+			Verbs: []string{
+				"create", // request client certificates
+				"get",    // and get the issued certificates
+			},
+		})
+	}
+
 	return []rbacv1.ClusterRole{
 		clusterRoles,
 	}
@@ -222,9 +262,36 @@ func (crrApprover *CRRApprover) handleClusterRegistrationRequests(crr *clusterap
 		return nil
 	}
 
-	if crr.Status.Result != nil {
-		klog.V(4).Infof("ClusterRegistrationRequest %q has already been processed with Result %q. Skip it.", *crr.Status.Result)
-		return nil
+	// approved requests get provisioned with dedicated namespaces and credentials,
+	// while the ones approved manually through the status are not provisioned yet
+	provisioned := len(crr.Status.DedicatedNamespace) > 0
+	renewal := provisioned && isRenewalRequested(crr)
+	switch {
+	case crr.Status.Result == nil:
+		decision, reason, err := crrApprover.reviewRequest(crr)
+		if err != nil {
+			return err
+		}
+		switch decision {
+		case RegistrationDeny:
+			return crrApprover.denyRequest(crr, reason)
+		case RegistrationHold:
+			return crrApprover.holdRequest(crr, reason)
+		}
+	case *crr.Status.Result != clusterapi.RequestApproved:
+		klog.V(4).Infof("ClusterRegistrationRequest %q has already been processed with Result %q. Skip it.", crr.Name, *crr.Status.Result)
+		return crrApprover.syncDeniedRequest(crr)
+	case !provisioned:
+		klog.V(4).Infof("ClusterRegistrationRequest %q has been approved manually", crr.Name)
+	case !renewal:
+		klog.V(4).Infof("ClusterRegistrationRequest %q has already been processed with Result %q. Skip it.", crr.Name, *crr.Status.Result)
+		// keep the clusterroles of registered clusters up to date, such as the permission of requesting renewals
+		return crrApprover.ensureClusterRoles(crr.Spec.ClusterID)
+	default:
+		if err := crrApprover.checkRenewalPolicy(crr); err != nil {
+			return err
+		}
+		klog.V(4).Infof("renewing the registration of cluster %q (%q)", crr.Spec.ClusterID, crr.Spec.ClusterName)
 	}
 
 	// 1. create dedicated namespace
@@ -254,14 +321,19 @@ func (crrApprover *CRRApprover) handleClusterRegistrationRequests(crr *clusterap
 	if err != nil {
 		return err
 	}
-	err = crrApprover.bindingRoleIfNeeded(sa.Name, sa.Namespace)
+	err = crrApprover.bindingRoleIfNeeded(sa.Name, sa.Namespace, crr.Spec.ClusterID)
 	if err != nil {
 		return err
 	}
 
 	// 5. get credentials
 	klog.V(5).Infof("get generated credentials for cluster %q (%q)", crr.Spec.ClusterID, crr.Spec.ClusterName)
-	secret, err := getCredentialsForChildCluster(crrApprover.ctx, crrApprover.kubeclient, retry.DefaultBackoff, sa.Name, sa.Namespace)
+	var secret *corev1.Secret
+	if renewal {
+		secret, err = crrApprover.issueNewToken(sa, crr.Spec.ClusterID, crr.Status.DedicatedToken)
+	} else {
+		secret, err = getCredentialsForChildCluster(crrApprover.ctx, crrApprover.kubeclient, retry.DefaultBackoff, sa.Name, sa.Namespace)
+	}
 	if err != nil {
 		return err
 	}
@@ -280,9 +352,227 @@ func (crrApprover *CRRApprover) handleClusterRegistrationRequests(crr *clusterap
 		return err
 	}
 
+	if renewal {
+		// 7. acknowledge the renewal, after which clusternet-agent picks up the new token
+		return crrApprover.acknowledgeRenewal(crr)
+	}
 	return nil
 }
 
+// reviewRequest decides whether the request is approved, denied or held for manual approval, with the reason.
+// A request is approved if it matches the approval policy and gets allowed by the webhook, either of which
+// approves all the requests if not configured.
+func (crrApprover *CRRApprover) reviewRequest(crr *clusterapi.ClusterRegistrationRequest) (RegistrationDecision, string, error) {
+	if crrApprover.policy == nil && crrApprover.webhook == nil {
+		return RegistrationAllow, "", nil
+	}
+
+	attrs, err := crrApprover.getRegistrationAttributes(crr)
+	if err != nil {
+		return "", "", err
+	}
+	if crrApprover.policy != nil {
+		rule := crrApprover.policy.match(attrs)
+		if len(rule) == 0 {
+			return RegistrationHold, "no approval rule matches, waiting for manual approval", nil
+		}
+		klog.V(4).Infof("ClusterRegistrationRequest %q is approved by rule %q", crr.Name, rule)
+	}
+	if crrApprover.webhook == nil {
+		return RegistrationAllow, "", nil
+	}
+
+	response, err := crrApprover.webhook.review(crrApprover.ctx, crr, attrs.tokenGroups)
+	if err != nil {
+		klog.Warningf("failed to review ClusterRegistrationRequest %q with webhook: %v", crr.Name, err)
+		crrApprover.crrController.EnqueueAfter(crr, registrationWebhookRecheckPeriod)
+		return RegistrationHold, fmt.Sprintf("failed to review the request with webhook: %v", err), nil
+	}
+	klog.V(4).Infof("ClusterRegistrationRequest %q is reviewed by webhook with decision %q: %s",
+		crr.Name, response.Decision, response.Reason)
+	switch response.Decision {
+	case RegistrationAllow:
+		return RegistrationAllow, "", nil
+	case RegistrationDeny:
+		if len(response.Reason) == 0 {
+			response.Reason = "request is denied by webhook"
+		}
+		return RegistrationDeny, response.Reason, nil
+	case RegistrationHold:
+		if len(response.Reason) == 0 {
+			response.Reason = "request is held by webhook, waiting for manual approval"
+		}
+	default:
+		response.Reason = fmt.Sprintf("unknown decision %q from webhook, waiting for manual approval", response.Decision)
+	}
+	// the webhook may allow the request later, such as the cluster gets recorded in the CMDB
+	crrApprover.crrController.EnqueueAfter(crr, registrationWebhookRecheckPeriod)
+	return RegistrationHold, response.Reason, nil
+}
+
+// getRegistrationAttributes collects the attributes of the request matched by the approval policy.
+func (crrApprover *CRRApprover) getRegistrationAttributes(crr *clusterapi.ClusterRegistrationRequest) (registrationAttributes, error) {
+	attrs := registrationAttributes{labels: crr.Labels}
+	for _, address := range strings.Split(crr.Annotations[known.RegistrationAddressesAnnotation], ",") {
+		if ip := net.ParseIP(strings.TrimSpace(address)); ip != nil {
+			attrs.addresses = append(attrs.addresses, ip)
+		}
+	}
+	if signature := crr.Annotations[known.BootstrapTokenSignatureAnnotation]; len(signature) > 0 {
+		secret, err := crrApprover.kubeclient.CoreV1().Secrets(metav1.NamespaceSystem).Get(crrApprover.ctx,
+			utils.BootstrapTokenSecretName(utils.BootstrapTokenIDFromSignature(signature)), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return attrs, err
+		}
+		if err == nil {
+			attrs.tokenGroups, err = utils.VerifyBootstrapTokenSignature(secret, signature, string(crr.Spec.ClusterID))
+			if err != nil {
+				klog.Warningf("ClusterRegistrationRequest %q: %v", crr.Name, err)
+			}
+		}
+	}
+
+	return attrs, nil
+}
+
+// holdRequest keeps the request pending with the reason, until it gets approved or denied manually.
+func (crrApprover *CRRApprover) holdRequest(crr *clusterapi.ClusterRegistrationRequest, reason string) error {
+	if crr.Status.ErrorMessage == reason {
+		return nil
+	}
+	klog.V(4).Infof("holding ClusterRegistrationRequest %q: %s", crr.Name, reason)
+	return crrApprover.crrController.UpdateCRRStatus(crr.DeepCopy(), &clusterapi.ClusterRegistrationRequestStatus{
+		ErrorMessage: reason,
+	})
+}
+
+// denyRequest denies the request with the reason.
+func (crrApprover *CRRApprover) denyRequest(crr *clusterapi.ClusterRegistrationRequest, reason string) error {
+	klog.V(4).Infof("denying ClusterRegistrationRequest %q: %s", crr.Name, reason)
+	result := clusterapi.RequestDenied
+	return crrApprover.crrController.UpdateCRRStatus(crr.DeepCopy(), &clusterapi.ClusterRegistrationRequestStatus{
+		Result:       &result,
+		ErrorMessage: reason,
+	})
+}
+
+// syncDeniedRequest sets condition Approved for the requests denied manually through the status,
+// and removes the credentials populated before if any.
+func (crrApprover *CRRApprover) syncDeniedRequest(crr *clusterapi.ClusterRegistrationRequest) error {
+	condition := apimeta.FindStatusCondition(crr.Status.Conditions, clusterapi.ClusterRegistrationRequestApproved)
+	if condition != nil && condition.Reason == string(*crr.Status.Result) && len(crr.Status.DedicatedToken) == 0 {
+		return nil
+	}
+
+	status := crr.Status.DeepCopy()
+	if len(status.ErrorMessage) == 0 {
+		status.ErrorMessage = "request is denied manually"
+	}
+	return crrApprover.crrController.UpdateCRRStatus(crr.DeepCopy(), status)
+}
+
+// isRenewalRequested tells whether clusternet-agent has requested a renewal that is not handled yet.
+func isRenewalRequested(crr *clusterapi.ClusterRegistrationRequest) bool {
+	requested := crr.Annotations[known.RenewRegistrationAnnotation]
+	return len(requested) > 0 && requested != crr.Annotations[known.RegistrationRenewedAnnotation]
+}
+
+// checkRenewalPolicy returns an error if a renewal should not be approved for now, which gets retried later.
+// Renewals are only approved for clusters still registered, and no more often than renewalMinInterval.
+func (crrApprover *CRRApprover) checkRenewalPolicy(crr *clusterapi.ClusterRegistrationRequest) error {
+	mcs, err := crrApprover.mclsLister.List(labels.SelectorFromSet(labels.Set{
+		known.ObjectCreatedByLabel: known.ClusternetAgentName,
+		known.ClusterIDLabel:       string(crr.Spec.ClusterID),
+	}))
+	if err != nil {
+		return err
+	}
+	if len(mcs) == 0 {
+		return fmt.Errorf("no ManagedCluster found for cluster %q, will not renew its registration", crr.Spec.ClusterID)
+	}
+
+	requested, err := time.Parse(time.RFC3339, crr.Annotations[known.RenewRegistrationAnnotation])
+	if err != nil {
+		return fmt.Errorf("invalid annotation %s on ClusterRegistrationRequest %q: %v",
+			known.RenewRegistrationAnnotation, crr.Name, err)
+	}
+	renewed, err := time.Parse(time.RFC3339, crr.Annotations[known.RegistrationRenewedAnnotation])
+	if err == nil && requested.Sub(renewed) < crrApprover.renewalMinInterval {
+		return fmt.Errorf("the registration of cluster %q was renewed at %s, the next renewal is not allowed until %s",
+			crr.Spec.ClusterID, renewed, renewed.Add(crrApprover.renewalMinInterval))
+	}
+	return nil
+}
+
+// issueNewToken creates a new token for the service account of child cluster, and revokes the old tokens except the
+// one in use, which keeps valid until next renewal so that clusternet-agent won't be interrupted while switching.
+func (crrApprover *CRRApprover) issueNewToken(sa *corev1.ServiceAccount, clusterID types.UID, tokenInUse []byte) (*corev1.Secret, error) {
+	secret, err := crrApprover.kubeclient.CoreV1().Secrets(sa.Namespace).Create(crrApprover.ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: sa.Name + "-token-",
+			Annotations:  map[string]string{corev1.ServiceAccountNameKey: sa.Name},
+			Labels: map[string]string{
+				known.ObjectCreatedByLabel: known.ClusternetHubName,
+				known.ClusterIDLabel:       string(clusterID),
+			},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// wait for the token getting populated by the token controller
+	err = wait.ExponentialBackoffWithContext(crrApprover.ctx, retry.DefaultBackoff, func() (bool, error) {
+		secret, err = crrApprover.kubeclient.CoreV1().Secrets(sa.Namespace).Get(crrApprover.ctx, secret.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		return len(secret.Data[corev1.ServiceAccountTokenKey]) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for the token in Secret %s/%s: %v", sa.Namespace, secret.Name, err)
+	}
+
+	secrets, err := crrApprover.kubeclient.CoreV1().Secrets(sa.Namespace).List(crrApprover.ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var allErrs []error
+	for _, old := range secrets.Items {
+		if old.Type != corev1.SecretTypeServiceAccountToken || old.Annotations[corev1.ServiceAccountNameKey] != sa.Name ||
+			old.Name == secret.Name || bytes.Equal(old.Data[corev1.ServiceAccountTokenKey], tokenInUse) {
+			continue
+		}
+		klog.V(4).Infof("revoking token in Secret %s/%s for cluster %q", old.Namespace, old.Name, clusterID)
+		err = crrApprover.kubeclient.CoreV1().Secrets(old.Namespace).Delete(crrApprover.ctx, old.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return secret, utilerrors.NewAggregate(allErrs)
+}
+
+// acknowledgeRenewal marks the renewal requested by clusternet-agent as done.
+func (crrApprover *CRRApprover) acknowledgeRenewal(crr *clusterapi.ClusterRegistrationRequest) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				known.RegistrationRenewedAnnotation: crr.Annotations[known.RenewRegistrationAnnotation],
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = crrApprover.clusternetclient.ClustersV1beta1().ClusterRegistrationRequests().Patch(crrApprover.ctx,
+		crr.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err == nil {
+		klog.V(4).Infof("successfully renew the registration of cluster %q", crr.Spec.ClusterID)
+	}
+	return err
+}
+
 func (crrApprover *CRRApprover) createNamespaceForChildClusterIfNeeded(clusterID types.UID, clusterName string) (*corev1.Namespace, error) {
 	// checks for an existed dedicated namespace for child cluster
 	// the clusterName here may vary, we use clusterID as the identifier
@@ -402,6 +692,16 @@ func (crrApprover *CRRApprover) createServiceAccountIfNeeded(namespace, clusterN
 	return newSA, nil
 }
 
+func (crrApprover *CRRApprover) ensureClusterRoles(clusterID types.UID) error {
+	var allErrs []error
+	for _, cr := range crrApprover.defaultClusterRoles(clusterID) {
+		if err := utils.EnsureClusterRole(crrApprover.ctx, cr, crrApprover.kubeclient, retry.DefaultRetry); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to ensure ClusterRole %q: %v", cr.Name, err))
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
 func (crrApprover *CRRApprover) bindingClusterRolesIfNeeded(serviceAccountName, serivceAccountNamespace string, clusterID types.UID) error {
 	var allErrs []error
 	wg := sync.WaitGroup{}
@@ -438,10 +738,8 @@ func (crrApprover *CRRApprover) bindingClusterRolesIfNeeded(serviceAccountName,
 						known.ClusterIDLabel:            string(clusterID),
 					},
 				},
-				Subjects: []rbacv1.Subject{
-					{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: serivceAccountNamespace},
-				},
-				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: cr.Name},
+				Subjects: crrApprover.subjects(serviceAccountName, serivceAccountNamespace, clusterID),
+				RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: cr.Name},
 			}, crrApprover.kubeclient, retry.DefaultRetry)
 			if err != nil {
 				allErrs = append(allErrs, fmt.Errorf("failed to ensure binding for ClusterRole %q: %v", cr.Name, err))
@@ -453,7 +751,7 @@ func (crrApprover *CRRApprover) bindingClusterRolesIfNeeded(serviceAccountName,
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func (crrApprover *CRRApprover) bindingRoleIfNeeded(serviceAccountName, namespace string) error {
+func (crrApprover *CRRApprover) bindingRoleIfNeeded(serviceAccountName, namespace string, clusterID types.UID) error {
 	var allErrs []error
 	wg := sync.WaitGroup{}
 
@@ -490,10 +788,8 @@ func (crrApprover *CRRApprover) bindingRoleIfNeeded(serviceAccountName, namespac
 						known.ObjectCreatedByLabel:      known.ClusternetHubName,
 					},
 				},
-				Subjects: []rbacv1.Subject{
-					{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: namespace},
-				},
-				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: r.Name},
+				Subjects: crrApprover.subjects(serviceAccountName, namespace, clusterID),
+				RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: r.Name},
 			}, crrApprover.kubeclient, retry.DefaultRetry)
 			if err != nil {
 				allErrs = append(allErrs, fmt.Errorf("failed to ensure binding for Role %q: %v", r.Name, err))
@@ -505,6 +801,22 @@ func (crrApprover *CRRApprover) bindingRoleIfNeeded(serviceAccountName, namespac
 	return utilerrors.NewAggregate(allErrs)
 }
 
+// subjects returns the identities of child cluster, which are the dedicated service account,
+// as well as the user in client certificates if certificate signing is enabled.
+func (crrApprover *CRRApprover) subjects(serviceAccountName, namespace string, clusterID types.UID) []rbacv1.Subject {
+	subjects := []rbacv1.Subject{
+		{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: namespace},
+	}
+	if crrApprover.certificateSigning {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     known.ClusterAgentUserPrefix + string(clusterID),
+		})
+	}
+	return subjects
+}
+
 func getCredentialsForChildCluster(ctx context.Context, client *kubernetes.Clientset, backoff wait.Backoff, saName, saNamespace string) (*corev1.Secret, error) {
 	var secret *corev1.Secret
 
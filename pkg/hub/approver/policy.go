@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// ApprovalPolicy decides which ClusterRegistrationRequests get approved automatically.
+// The requests matching none of the rules are held for manual approval.
+type ApprovalPolicy struct {
+	// Rules are the rules approving requests automatically, which are matched in order.
+	Rules []ApprovalRule `json:"rules"`
+}
+
+// ApprovalRule matches the ClusterRegistrationRequests meeting all the criteria set.
+// A rule without any criteria matches all the requests.
+type ApprovalRule struct {
+	// Name is the name of the rule, which is logged when approving requests.
+	Name string `json:"name"`
+
+	// CIDRs match the requests whose node addresses reported by clusternet-agent all fall in any of them.
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// LabelSelector matches the labels of the requests, which are the labels of ManagedClusters
+	// reported by clusternet-agent.
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// TokenGroups match the requests signed with bootstrap tokens authenticating as any of these groups,
+	// such as "system:bootstrappers:clusternet:team-a", which tell the audiences of the tokens.
+	// +optional
+	TokenGroups []string `json:"tokenGroups,omitempty"`
+
+	cidrs    []*net.IPNet
+	selector labels.Selector
+}
+
+// registrationAttributes are the attributes of a ClusterRegistrationRequest matched by the approval rules.
+type registrationAttributes struct {
+	labels      map[string]string
+	addresses   []net.IP
+	tokenGroups []string
+}
+
+// LoadApprovalPolicy loads and validates the ApprovalPolicy from the file.
+func LoadApprovalPolicy(file string) (*ApprovalPolicy, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy := &ApprovalPolicy{}
+	if err = yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse approval policy in %s: %v", file, err)
+	}
+
+	names := sets.NewString()
+	for idx := range policy.Rules {
+		rule := &policy.Rules[idx]
+		if len(rule.Name) == 0 {
+			return nil, fmt.Errorf("invalid approval policy in %s: rules must have names", file)
+		}
+		if names.Has(rule.Name) {
+			return nil, fmt.Errorf("invalid approval policy in %s: duplicated rule %q", file, rule.Name)
+		}
+		names.Insert(rule.Name)
+
+		for _, cidr := range rule.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid approval policy in %s: rule %q has invalid cidr %q: %v",
+					file, rule.Name, cidr, err)
+			}
+			rule.cidrs = append(rule.cidrs, ipNet)
+		}
+
+		rule.selector = labels.Everything()
+		if rule.LabelSelector != nil {
+			rule.selector, err = metav1.LabelSelectorAsSelector(rule.LabelSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid approval policy in %s: rule %q has invalid label selector: %v",
+					file, rule.Name, err)
+			}
+		}
+	}
+	return policy, nil
+}
+
+// match returns the name of the first rule matching the attributes, or an empty string if none matches.
+func (policy *ApprovalPolicy) match(attrs registrationAttributes) string {
+	for _, rule := range policy.Rules {
+		if rule.matches(attrs) {
+			return rule.Name
+		}
+	}
+	return ""
+}
+
+func (rule *ApprovalRule) matches(attrs registrationAttributes) bool {
+	if !rule.selector.Matches(labels.Set(attrs.labels)) {
+		return false
+	}
+
+	if len(rule.cidrs) > 0 {
+		if len(attrs.addresses) == 0 {
+			return false
+		}
+		for _, address := range attrs.addresses {
+			if !containsIP(rule.cidrs, address) {
+				return false
+			}
+		}
+	}
+
+	if len(rule.TokenGroups) > 0 && !sets.NewString(rule.TokenGroups...).HasAny(attrs.tokenGroups...) {
+		return false
+	}
+	return true
+}
+
+func containsIP(cidrs []*net.IPNet, ip net.IP) bool {
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const testPolicy = `rules:
+- name: edge-network
+  cidrs:
+  - 10.0.0.0/8
+  - 192.168.1.0/24
+  labelSelector:
+    matchLabels:
+      env: production
+- name: trusted-tokens
+  tokenGroups:
+  - system:bootstrappers:clusternet:team-a
+`
+
+func loadTestPolicy(t *testing.T, dir, name, config string) (*ApprovalPolicy, error) {
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return LoadApprovalPolicy(file)
+}
+
+func TestLoadApprovalPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: testPolicy,
+		},
+		{
+			name:   "no rules",
+			config: `rules: []`,
+		},
+		{
+			name: "missing name",
+			config: `rules:
+- cidrs: ["10.0.0.0/8"]
+`,
+			wantErr: "rules must have names",
+		},
+		{
+			name: "duplicated names",
+			config: `rules:
+- name: foo
+- name: foo
+`,
+			wantErr: `duplicated rule "foo"`,
+		},
+		{
+			name: "invalid cidr",
+			config: `rules:
+- name: foo
+  cidrs: ["10.0.0.0"]
+`,
+			wantErr: `invalid cidr "10.0.0.0"`,
+		},
+		{
+			name: "invalid label selector",
+			config: `rules:
+- name: foo
+  labelSelector:
+    matchExpressions:
+    - key: env
+      operator: Exists
+      values: ["production"]
+`,
+			wantErr: "invalid label selector",
+		},
+		{
+			name:    "malformed",
+			config:  `rules: {`,
+			wantErr: "failed to parse approval policy",
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestPolicy(t, dir, fmt.Sprintf("policy-%d.yaml", i), tt.config)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("LoadApprovalPolicy() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadApprovalPolicy() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err = LoadApprovalPolicy(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("LoadApprovalPolicy() expects an error on missing files")
+	}
+}
+
+func TestApprovalPolicyMatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	policy, err := loadTestPolicy(t, dir, "policy.yaml", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	production := map[string]string{"env": "production"}
+	tests := []struct {
+		name      string
+		labels    map[string]string
+		addresses []string
+		groups    []string
+		want      string
+	}{
+		{
+			name:      "cidr hit",
+			labels:    production,
+			addresses: []string{"10.0.0.1", "192.168.1.10"},
+			want:      "edge-network",
+		},
+		{
+			name:      "cidr miss",
+			labels:    production,
+			addresses: []string{"172.16.0.1"},
+		},
+		{
+			name:      "cidr partially missed",
+			labels:    production,
+			addresses: []string{"10.0.0.1", "192.168.2.10"},
+		},
+		{
+			name:   "no addresses reported",
+			labels: production,
+		},
+		{
+			name:      "label selector miss",
+			labels:    map[string]string{"env": "test"},
+			addresses: []string{"10.0.0.1"},
+		},
+		{
+			name:   "token groups hit",
+			groups: []string{"system:bootstrappers", "system:bootstrappers:clusternet:team-a"},
+			want:   "trusted-tokens",
+		},
+		{
+			name:   "token groups miss",
+			groups: []string{"system:bootstrappers", "system:bootstrappers:clusternet:team-b"},
+		},
+		{
+			name:      "first matching rule wins",
+			labels:    production,
+			addresses: []string{"10.0.0.1"},
+			groups:    []string{"system:bootstrappers:clusternet:team-a"},
+			want:      "edge-network",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := registrationAttributes{labels: tt.labels, tokenGroups: tt.groups}
+			for _, address := range tt.addresses {
+				attrs.addresses = append(attrs.addresses, net.ParseIP(address))
+			}
+			if got := policy.match(attrs); got != tt.want {
+				t.Errorf("match() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// a rule without any criteria matches all the requests
+	everything, err := loadTestPolicy(t, dir, "everything.yaml", "rules:\n- name: everything\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := everything.match(registrationAttributes{}); got != "everything" {
+		t.Errorf("match() = %q, want %q", got, "everything")
+	}
+}
+
+func TestReviewRequestWithPolicy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	policy, err := loadTestPolicy(t, dir, "policy.yaml", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		policy       *ApprovalPolicy
+		labels       map[string]string
+		addresses    string
+		wantDecision RegistrationDecision
+	}{
+		{
+			name:         "no policy",
+			wantDecision: RegistrationAllow,
+		},
+		{
+			name:         "matching rule",
+			policy:       policy,
+			labels:       map[string]string{"env": "production"},
+			addresses:    "10.0.0.1,192.168.1.10",
+			wantDecision: RegistrationAllow,
+		},
+		{
+			name:         "no matching rule",
+			policy:       policy,
+			labels:       map[string]string{"env": "production"},
+			addresses:    "10.0.0.1,172.16.0.1",
+			wantDecision: RegistrationHold,
+		},
+		{
+			name:         "invalid addresses",
+			policy:       policy,
+			labels:       map[string]string{"env": "production"},
+			addresses:    "foo,bar",
+			wantDecision: RegistrationHold,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crrApprover := &CRRApprover{policy: tt.policy}
+			crr := &clusterapi.ClusterRegistrationRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "clusternet-abc",
+					Labels:      tt.labels,
+					Annotations: map[string]string{known.RegistrationAddressesAnnotation: tt.addresses},
+				},
+			}
+			decision, reason, err := crrApprover.reviewRequest(crr)
+			if err != nil {
+				t.Fatalf("reviewRequest() error = %v", err)
+			}
+			if decision != tt.wantDecision {
+				t.Errorf("reviewRequest() = %q, want %q", decision, tt.wantDecision)
+			}
+			if decision == RegistrationHold && len(reason) == 0 {
+				t.Errorf("reviewRequest() holds the request without a reason")
+			}
+		})
+	}
+}
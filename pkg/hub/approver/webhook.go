@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+const (
+	// defaultRegistrationWebhookTimeout is the timeout of reviewing a registration request with the webhook
+	defaultRegistrationWebhookTimeout = 10 * time.Second
+
+	// registrationWebhookRecheckPeriod is how often the requests held by the webhook are reviewed again
+	registrationWebhookRecheckPeriod = 5 * time.Minute
+)
+
+// RegistrationDecision is the answer of the registration webhook on a ClusterRegistrationRequest.
+type RegistrationDecision string
+
+const (
+	// RegistrationAllow approves the request, and the cluster gets registered.
+	RegistrationAllow RegistrationDecision = "allow"
+	// RegistrationDeny denies the request with the reason.
+	RegistrationDeny RegistrationDecision = "deny"
+	// RegistrationHold keeps the request pending, which is reviewed again later or approved manually.
+	RegistrationHold RegistrationDecision = "hold"
+)
+
+// RegistrationReview is the payload posted to the registration webhook.
+type RegistrationReview struct {
+	// Request is the ClusterRegistrationRequest to be reviewed, without its status
+	Request *clusterapi.ClusterRegistrationRequest `json:"request"`
+	// TokenGroups are the groups of the bootstrap token, which are verified with the signature of the token
+	TokenGroups []string `json:"tokenGroups,omitempty"`
+}
+
+// RegistrationReviewResponse is the response of the registration webhook.
+type RegistrationReviewResponse struct {
+	Decision RegistrationDecision `json:"decision"`
+	Reason   string               `json:"reason,omitempty"`
+}
+
+// RegistrationWebhook reviews ClusterRegistrationRequests with an external service, such as a CMDB.
+type RegistrationWebhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewRegistrationWebhook returns a RegistrationWebhook posting to the url, whose serving certificate
+// is verified with the CA file if specified, or the system roots otherwise.
+func NewRegistrationWebhook(url, caFile string) (*RegistrationWebhook, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(caFile) > 0 {
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	return &RegistrationWebhook{
+		url:    url,
+		client: &http.Client{Transport: transport},
+	}, nil
+}
+
+func (webhook *RegistrationWebhook) review(ctx context.Context, crr *clusterapi.ClusterRegistrationRequest,
+	tokenGroups []string) (*RegistrationReviewResponse, error) {
+	request := crr.DeepCopy()
+	request.APIVersion = clusterapi.SchemeGroupVersion.String()
+	request.Kind = "ClusterRegistrationRequest"
+	request.ManagedFields = nil
+	request.Status = clusterapi.ClusterRegistrationRequestStatus{}
+	payload, err := json.Marshal(&RegistrationReview{
+		Request:     request,
+		TokenGroups: tokenGroups,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultRegistrationWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := webhook.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("webhook returns status code %d", resp.StatusCode)
+	}
+
+	response := &RegistrationReviewResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of webhook: %v", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package approver
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/clusters/clusterregistrationrequest"
+	"github.com/clusternet/clusternet/pkg/generated/clientset/versioned/fake"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+)
+
+func newTestRequest() *clusterapi.ClusterRegistrationRequest {
+	return &clusterapi.ClusterRegistrationRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "clusternet-abc",
+			Labels: map[string]string{"env": "production"},
+		},
+		Spec: clusterapi.ClusterRegistrationRequestSpec{
+			ClusterID:   "abc",
+			ClusterName: "foo",
+		},
+		Status: clusterapi.ClusterRegistrationRequestStatus{
+			DedicatedToken: []byte("secret"),
+		},
+	}
+}
+
+// newWebhookServer returns a server answering registration reviews with the handler,
+// after checking the payload posted.
+func newWebhookServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := &RegistrationReview{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+			t.Errorf("failed to decode the review: %v", err)
+		}
+		if review.Request == nil || review.Request.Kind != "ClusterRegistrationRequest" ||
+			review.Request.Spec.ClusterID != "abc" {
+			t.Errorf("unexpected request %v", review.Request)
+		}
+		if review.Request != nil && len(review.Request.Status.DedicatedToken) > 0 {
+			t.Errorf("the status of the request should not be posted")
+		}
+		handler(w, r)
+	}))
+}
+
+func TestRegistrationWebhookReview(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	tests := []struct {
+		name         string
+		handler      func(w http.ResponseWriter, r *http.Request)
+		wantDecision RegistrationDecision
+		wantErr      bool
+	}{
+		{
+			name: "allow",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"decision": "allow"}`)
+			},
+			wantDecision: RegistrationAllow,
+		},
+		{
+			name: "deny",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"decision": "deny", "reason": "unknown cluster"}`)
+			},
+			wantDecision: RegistrationDeny,
+		},
+		{
+			name: "hold",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"decision": "hold"}`)
+			},
+			wantDecision: RegistrationHold,
+		},
+		{
+			name: "non-2xx response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"decision": "allow"}`)
+			},
+			wantErr: true,
+		},
+		{
+			name: "undecodable body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `allow`)
+			},
+			wantErr: true,
+		},
+		{
+			name: "timeout",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-done:
+				}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, tt.handler)
+			defer server.Close()
+
+			webhook, err := NewRegistrationWebhook(server.URL, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			response, err := webhook.review(ctx, newTestRequest(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("review() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && response.Decision != tt.wantDecision {
+				t.Errorf("review() = %q, want %q", response.Decision, tt.wantDecision)
+			}
+		})
+	}
+}
+
+func TestNewRegistrationWebhookWithCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"decision": "allow"}`)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "approver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err = ioutil.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	webhook, err := NewRegistrationWebhook(server.URL, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := webhook.review(context.TODO(), newTestRequest(), nil)
+	if err != nil {
+		t.Fatalf("review() error = %v", err)
+	}
+	if response.Decision != RegistrationAllow {
+		t.Errorf("review() = %q, want %q", response.Decision, RegistrationAllow)
+	}
+
+	// the serving certificate is not trusted without the CA
+	webhook, err = NewRegistrationWebhook(server.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = webhook.review(context.TODO(), newTestRequest(), nil); err == nil {
+		t.Errorf("review() expects an error on untrusted certificates")
+	}
+
+	invalidFile := filepath.Join(dir, "invalid.crt")
+	if err = ioutil.WriteFile(invalidFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewRegistrationWebhook(server.URL, invalidFile); err == nil {
+		t.Errorf("NewRegistrationWebhook() expects an error on invalid CA files")
+	}
+}
+
+func TestReviewRequestWithWebhook(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusternetClient := fake.NewSimpleClientset()
+	informerFactory := clusternetinformers.NewSharedInformerFactory(clusternetClient, 0)
+	crrController, err := clusterregistrationrequest.NewController(ctx, kubefake.NewSimpleClientset(), clusternetClient,
+		informerFactory.Clusters().V1beta1().ClusterRegistrationRequests(),
+		func(*clusterapi.ClusterRegistrationRequest) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		response     string
+		closed       bool
+		wantDecision RegistrationDecision
+		wantReason   string
+	}{
+		{
+			name:         "allow",
+			response:     `{"decision": "allow"}`,
+			wantDecision: RegistrationAllow,
+		},
+		{
+			name:         "deny",
+			response:     `{"decision": "deny", "reason": "unknown cluster"}`,
+			wantDecision: RegistrationDeny,
+			wantReason:   "unknown cluster",
+		},
+		{
+			name:         "deny without reason",
+			response:     `{"decision": "deny"}`,
+			wantDecision: RegistrationDeny,
+			wantReason:   "request is denied by webhook",
+		},
+		{
+			name:         "hold",
+			response:     `{"decision": "hold", "reason": "cluster is not found in CMDB"}`,
+			wantDecision: RegistrationHold,
+			wantReason:   "cluster is not found in CMDB",
+		},
+		{
+			name:         "unknown decision",
+			response:     `{"decision": "approve"}`,
+			wantDecision: RegistrationHold,
+			wantReason:   `unknown decision "approve" from webhook, waiting for manual approval`,
+		},
+		{
+			name:         "transport error",
+			closed:       true,
+			wantDecision: RegistrationHold,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhookServer(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.response)
+			})
+			if tt.closed {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+
+			webhook, err := NewRegistrationWebhook(server.URL, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			crrApprover := &CRRApprover{ctx: ctx, crrController: crrController, webhook: webhook}
+			decision, reason, err := crrApprover.reviewRequest(newTestRequest())
+			if err != nil {
+				t.Fatalf("reviewRequest() error = %v", err)
+			}
+			if decision != tt.wantDecision {
+				t.Errorf("reviewRequest() = %q, want %q", decision, tt.wantDecision)
+			}
+			if len(tt.wantReason) > 0 && reason != tt.wantReason {
+				t.Errorf("reviewRequest() reason = %q, want %q", reason, tt.wantReason)
+			}
+			if decision == RegistrationHold && len(reason) == 0 {
+				t.Errorf("reviewRequest() holds the request without a reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterlifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	coordinationlisters "k8s.io/client-go/listers/coordination/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// ClusterStatusUnknownReason is the reason of the conditions marked as Unknown by clusternet-hub,
+// once clusternet-agent stops posting cluster status
+const ClusterStatusUnknownReason = "ClusterStatusUnknown"
+
+// ClusterLifecycleController monitors the heartbeats of ManagedClusters, which are reported by clusternet-agent
+// as status.lastObservedTime, as well as the renew time of the Lease named with the cluster id in the cluster namespace,
+// similar to the node lifecycle controller in kube-controller-manager.
+//
+// A cluster without heartbeats for longer than the grace period will have all its conditions marked as Unknown.
+// If eviction is enabled, a cluster staying Unknown for longer than the eviction timeout will be labeled with
+// "clusters.clusternet.io/evicted", so that its workloads are removed by the deployer, until the heartbeats recover.
+//
+// It also cordons and drains clusters with taints, following spec.unschedulable and spec.drain of ManagedClusters,
+// similar to how nodes are cordoned and drained.
+//
+// If failover is enabled, a cluster staying not ready for longer than the failover tolerance will be tainted with
+// "clusters.clusternet.io/unhealthy", so that the deployer migrates the divided replicas to the other healthy clusters.
+// The taint is removed once the cluster gets ready again, and the replicas are divided back.
+type ClusterLifecycleController struct {
+	ctx context.Context
+
+	clusternetClient *clusternetclientset.Clientset
+
+	clusterLister clusterlisters.ManagedClusterLister
+	clusterSynced cache.InformerSynced
+	leaseLister   coordinationlisters.LeaseLister
+	leaseSynced   cache.InformerSynced
+
+	// monitorPeriod is how often the heartbeats of clusters are checked
+	monitorPeriod time.Duration
+	// gracePeriod is how long a cluster can go without heartbeats before being marked as Unknown
+	gracePeriod time.Duration
+	// evictionTimeout is how long a cluster can stay Unknown before its workloads get evicted. 0 disables eviction.
+	evictionTimeout time.Duration
+	// failoverTolerance is how long a cluster can stay not ready before its replicas fail over. 0 disables failover.
+	failoverTolerance time.Duration
+
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+// NewClusterLifecycleController returns a new ClusterLifecycleController.
+// It should be called before clusternetInformerFactory starts.
+func NewClusterLifecycleController(ctx context.Context, kubeclient *kubernetes.Clientset, clusternetclient *clusternetclientset.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory,
+	monitorPeriod, gracePeriod, evictionTimeout, failoverTolerance time.Duration) *ClusterLifecycleController {
+	clusterInformer := clusternetInformerFactory.Clusters().V1beta1().ManagedClusters()
+	leaseInformer := kubeInformerFactory.Coordination().V1().Leases()
+
+	c := &ClusterLifecycleController{
+		ctx:               ctx,
+		clusternetClient:  clusternetclient,
+		clusterLister:     clusterInformer.Lister(),
+		clusterSynced:     clusterInformer.Informer().HasSynced,
+		leaseLister:       leaseInformer.Lister(),
+		leaseSynced:       leaseInformer.Informer().HasSynced,
+		monitorPeriod:     monitorPeriod,
+		gracePeriod:       gracePeriod,
+		evictionTimeout:   evictionTimeout,
+		failoverTolerance: failoverTolerance,
+		broadcaster:       record.NewBroadcaster(),
+	}
+
+	c.broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
+		Interface: kubeclient.CoreV1().Events(""),
+	})
+	utilruntime.Must(clusterapi.AddToScheme(scheme.Scheme))
+	c.recorder = c.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "clusternet-hub"})
+
+	clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: c.recordReadinessChange,
+	})
+	clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.syncSchedulingTaints(c.ctx, obj.(*clusterapi.ManagedCluster))
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			c.syncSchedulingTaints(c.ctx, cur.(*clusterapi.ManagedCluster))
+		},
+	})
+	return c
+}
+
+func (c *ClusterLifecycleController) Run() {
+	klog.Infof("starting Clusternet cluster lifecycle controller ...")
+	defer klog.Infof("shutting down Clusternet cluster lifecycle controller")
+
+	// Wait for the caches to be synced before starting monitoring
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(c.ctx.Done(), c.clusterSynced, c.leaseSynced) {
+		return
+	}
+
+	wait.UntilWithContext(c.ctx, c.monitorClusterHealth, c.monitorPeriod)
+}
+
+func (c *ClusterLifecycleController) monitorClusterHealth(ctx context.Context) {
+	clusters, err := c.clusterLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list ManagedClusters: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, cluster := range clusters {
+		if cluster.DeletionTimestamp != nil {
+			continue
+		}
+		if err := c.checkCluster(ctx, cluster, now); err != nil {
+			klog.Errorf("failed to check the health of ManagedCluster %s: %v", klog.KObj(cluster), err)
+		}
+		// retry the failures of syncing taints on cluster changes
+		c.syncSchedulingTaints(ctx, cluster)
+		if c.failoverTolerance > 0 {
+			c.syncUnhealthyTaint(ctx, cluster, now)
+		}
+	}
+}
+
+func (c *ClusterLifecycleController) checkCluster(ctx context.Context, cluster *clusterapi.ManagedCluster, now time.Time) error {
+	_, evicted := cluster.Labels[known.ClusterEvictedLabel]
+
+	lease, err := c.leaseLister.Leases(cluster.Namespace).Get(string(cluster.Spec.ClusterID))
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	lastHeartbeat := getLastHeartbeatTime(cluster, lease)
+	if !isHeartbeatLost(lastHeartbeat, c.gracePeriod, now) {
+		if evicted && isClusterReady(cluster) {
+			c.recorder.Event(cluster, corev1.EventTypeNormal, "ClusterRecovered",
+				"heartbeats have recovered, workloads will be scheduled to the cluster again")
+			return c.setEvictedLabel(ctx, cluster, false)
+		}
+		return nil
+	}
+
+	status := cluster.Status.DeepCopy()
+	if markConditionsUnknown(status, fmt.Sprintf("clusternet-agent stopped posting cluster status for more than %s", c.gracePeriod)) {
+		klog.V(2).Infof("ManagedCluster %s has lost heartbeats since %s", klog.KObj(cluster), lastHeartbeat)
+		mcls := cluster.DeepCopy()
+		mcls.Status = *status
+		if _, err := c.clusternetClient.ClustersV1beta1().ManagedClusters(mcls.Namespace).UpdateStatus(ctx, mcls, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	if c.evictionTimeout > 0 && !evicted && shouldEvict(status, c.evictionTimeout, now) {
+		c.recorder.Event(cluster, corev1.EventTypeWarning, "EvictingWorkloads",
+			fmt.Sprintf("cluster status has been unknown for more than %s, evicting workloads", c.evictionTimeout))
+		return c.setEvictedLabel(ctx, cluster, true)
+	}
+	return nil
+}
+
+func (c *ClusterLifecycleController) setEvictedLabel(ctx context.Context, cluster *clusterapi.ManagedCluster, evicted bool) error {
+	var value interface{}
+	if evicted {
+		value = "true"
+	}
+	// a null value removes the label with merge patch
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{
+				known.ClusterEvictedLabel: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.clusternetClient.ClustersV1beta1().ManagedClusters(cluster.Namespace).Patch(ctx, cluster.Name,
+		types.MergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
+
+// syncSchedulingTaints adds or removes the taints for cordoning and draining the cluster following
+// spec.unschedulable and spec.drain, which are respected by the deployer when selecting clusters.
+func (c *ClusterLifecycleController) syncSchedulingTaints(ctx context.Context, cluster *clusterapi.ManagedCluster) {
+	if cluster.DeletionTimestamp != nil {
+		return
+	}
+	taints, changed := getSchedulingTaints(cluster.Spec, time.Now())
+	if !changed {
+		return
+	}
+
+	mcls := cluster.DeepCopy()
+	mcls.Spec.Taints = taints
+	if _, err := c.clusternetClient.ClustersV1beta1().ManagedClusters(mcls.Namespace).Update(ctx, mcls, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("failed to update scheduling taints of ManagedCluster %s: %v", klog.KObj(cluster), err)
+		return
+	}
+
+	switch {
+	case cluster.Spec.Drain:
+		c.recorder.Event(cluster, corev1.EventTypeNormal, "ClusterDraining",
+			"cluster is drained, workloads will be removed unless the draining taint is tolerated")
+	case cluster.Spec.Unschedulable:
+		c.recorder.Event(cluster, corev1.EventTypeNormal, "ClusterCordoned",
+			"cluster is cordoned, no more workloads will be scheduled to it")
+	default:
+		c.recorder.Event(cluster, corev1.EventTypeNormal, "ClusterUncordoned",
+			"cluster is schedulable again")
+	}
+}
+
+// syncUnhealthyTaint taints the cluster staying not ready for longer than the failover tolerance, and removes the taint
+// once the cluster gets ready again. The deployer migrates the divided replicas away from clusters with this taint.
+func (c *ClusterLifecycleController) syncUnhealthyTaint(ctx context.Context, cluster *clusterapi.ManagedCluster, now time.Time) {
+	// get the latest one, which may have been updated when checking heartbeats or syncing scheduling taints
+	cluster, err := c.clusterLister.ManagedClusters(cluster.Namespace).Get(cluster.Name)
+	if err != nil {
+		return
+	}
+	taints, changed := getUnhealthyTaints(cluster, c.failoverTolerance, now)
+	if !changed {
+		return
+	}
+
+	mcls := cluster.DeepCopy()
+	mcls.Spec.Taints = taints
+	if _, err := c.clusternetClient.ClustersV1beta1().ManagedClusters(mcls.Namespace).Update(ctx, mcls, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("failed to update unhealthy taint of ManagedCluster %s: %v", klog.KObj(cluster), err)
+		return
+	}
+
+	if isClusterReady(cluster) {
+		c.recorder.Event(cluster, corev1.EventTypeNormal, "ClusterFailback",
+			"cluster is ready again, replicas will be divided back to the cluster")
+		return
+	}
+	c.recorder.Event(cluster, corev1.EventTypeWarning, "ClusterFailover",
+		fmt.Sprintf("cluster has not been ready for more than %s, replicas will fail over to other healthy clusters", c.failoverTolerance))
+}
+
+// recordReadinessChange records events when the Ready condition of a cluster changes.
+func (c *ClusterLifecycleController) recordReadinessChange(old, cur interface{}) {
+	oldCluster := old.(*clusterapi.ManagedCluster)
+	newCluster := cur.(*clusterapi.ManagedCluster)
+
+	oldReady := apimeta.FindStatusCondition(oldCluster.Status.Conditions, clusterapi.ClusterReady)
+	newReady := apimeta.FindStatusCondition(newCluster.Status.Conditions, clusterapi.ClusterReady)
+	if newReady == nil || (oldReady != nil && oldReady.Status == newReady.Status) {
+		return
+	}
+
+	switch newReady.Status {
+	case metav1.ConditionTrue:
+		c.recorder.Event(newCluster, corev1.EventTypeNormal, "ClusterReady", newReady.Message)
+	case metav1.ConditionFalse:
+		c.recorder.Event(newCluster, corev1.EventTypeWarning, "ClusterNotReady", newReady.Message)
+	default:
+		c.recorder.Event(newCluster, corev1.EventTypeWarning, "ClusterStatusUnknown", newReady.Message)
+	}
+}
+
+// getLastHeartbeatTime returns the later one of the last time the cluster posted its status and renewed its Lease.
+func getLastHeartbeatTime(cluster *clusterapi.ManagedCluster, lease *coordinationv1.Lease) metav1.Time {
+	lastHeartbeat := cluster.Status.LastObservedTime
+	if lease != nil && lease.Spec.RenewTime != nil && lease.Spec.RenewTime.After(lastHeartbeat.Time) {
+		lastHeartbeat = metav1.NewTime(lease.Spec.RenewTime.Time)
+	}
+	return lastHeartbeat
+}
+
+// getSchedulingTaints returns the taints with the unschedulable and draining taints updated following the spec,
+// and whether they are changed. The other taints are kept untouched.
+func getSchedulingTaints(spec clusterapi.ManagedClusterSpec, now time.Time) ([]corev1.Taint, bool) {
+	desired := map[string]corev1.TaintEffect{}
+	if spec.Unschedulable || spec.Drain {
+		desired[known.TaintClusterUnschedulable] = corev1.TaintEffectNoSchedule
+	}
+	if spec.Drain {
+		desired[known.TaintClusterDraining] = corev1.TaintEffectNoExecute
+	}
+
+	var taints []corev1.Taint
+	changed := false
+	for _, taint := range spec.Taints {
+		if taint.Key != known.TaintClusterUnschedulable && taint.Key != known.TaintClusterDraining {
+			taints = append(taints, taint)
+			continue
+		}
+		if effect, ok := desired[taint.Key]; ok && taint.Effect == effect {
+			taints = append(taints, taint)
+			delete(desired, taint.Key)
+			continue
+		}
+		changed = true
+	}
+
+	for _, key := range []string{known.TaintClusterUnschedulable, known.TaintClusterDraining} {
+		effect, ok := desired[key]
+		if !ok {
+			continue
+		}
+		taint := corev1.Taint{Key: key, Effect: effect}
+		if effect == corev1.TaintEffectNoExecute {
+			taint.TimeAdded = &metav1.Time{Time: now}
+		}
+		taints = append(taints, taint)
+		changed = true
+	}
+	return taints, changed
+}
+
+// getUnhealthyTaints returns the taints with the unhealthy taint added if the cluster has not been ready for longer than
+// the tolerance, or removed if the cluster is ready, and whether they are changed. The other taints are kept untouched.
+func getUnhealthyTaints(cluster *clusterapi.ManagedCluster, tolerance time.Duration, now time.Time) ([]corev1.Taint, bool) {
+	tainted := false
+	var taints []corev1.Taint
+	for _, taint := range cluster.Spec.Taints {
+		if taint.Key == known.TaintClusterUnhealthy {
+			tainted = true
+			continue
+		}
+		taints = append(taints, taint)
+	}
+
+	switch {
+	case isClusterReady(cluster):
+		return taints, tainted
+	case tainted:
+		return cluster.Spec.Taints, false
+	case shouldFailover(&cluster.Status, tolerance, now):
+		taints = append(taints, corev1.Taint{
+			Key:       known.TaintClusterUnhealthy,
+			Effect:    corev1.TaintEffectNoSchedule,
+			TimeAdded: &metav1.Time{Time: now},
+		})
+		return taints, true
+	default:
+		return cluster.Spec.Taints, false
+	}
+}
+
+// isHeartbeatLost tells whether the cluster has not sent heartbeats within the grace period.
+// Clusters that have never sent heartbeats are skipped, since they may be still registering.
+func isHeartbeatLost(lastHeartbeat metav1.Time, gracePeriod time.Duration, now time.Time) bool {
+	if lastHeartbeat.IsZero() {
+		return false
+	}
+	return now.After(lastHeartbeat.Add(gracePeriod))
+}
+
+func isClusterReady(cluster *clusterapi.ManagedCluster) bool {
+	return apimeta.IsStatusConditionTrue(cluster.Status.Conditions, clusterapi.ClusterReady)
+}
+
+// markConditionsUnknown sets all the conditions to Unknown, and returns whether the status is changed.
+func markConditionsUnknown(status *clusterapi.ManagedClusterStatus, message string) bool {
+	if apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady) == nil {
+		status.Conditions = append(status.Conditions, metav1.Condition{Type: clusterapi.ClusterReady})
+	}
+
+	changed := false
+	for i := range status.Conditions {
+		condition := status.Conditions[i]
+		if condition.Status == metav1.ConditionUnknown && condition.Reason == ClusterStatusUnknownReason {
+			continue
+		}
+		changed = true
+		apimeta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    condition.Type,
+			Status:  metav1.ConditionUnknown,
+			Reason:  ClusterStatusUnknownReason,
+			Message: message,
+		})
+	}
+	return changed
+}
+
+// shouldEvict tells whether the cluster has been marked as Unknown by clusternet-hub for longer than the timeout.
+func shouldEvict(status *clusterapi.ManagedClusterStatus, timeout time.Duration, now time.Time) bool {
+	ready := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady)
+	if ready == nil || ready.Status != metav1.ConditionUnknown || ready.Reason != ClusterStatusUnknownReason {
+		return false
+	}
+	return now.After(ready.LastTransitionTime.Add(timeout))
+}
+
+// shouldFailover tells whether the cluster has not been ready for longer than the tolerance.
+// Clusters that have never reported readiness are skipped, since they may be still registering.
+func shouldFailover(status *clusterapi.ManagedClusterStatus, tolerance time.Duration, now time.Time) bool {
+	ready := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady)
+	if ready == nil || ready.Status == metav1.ConditionTrue {
+		return false
+	}
+	return now.After(ready.LastTransitionTime.Add(tolerance))
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterlifecycle
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestIsHeartbeatLost(t *testing.T) {
+	now := time.Now()
+	if isHeartbeatLost(metav1.Time{}, time.Minute, now) {
+		t.Errorf("expected clusters never sending heartbeats to be skipped")
+	}
+
+	if isHeartbeatLost(metav1.NewTime(now.Add(-30*time.Second)), time.Minute, now) {
+		t.Errorf("expected heartbeats within the grace period to be alive")
+	}
+
+	if !isHeartbeatLost(metav1.NewTime(now.Add(-2*time.Minute)), time.Minute, now) {
+		t.Errorf("expected heartbeats beyond the grace period to be lost")
+	}
+}
+
+func TestGetLastHeartbeatTime(t *testing.T) {
+	now := time.Now()
+	cluster := &clusterapi.ManagedCluster{}
+	cluster.Status.LastObservedTime = metav1.NewTime(now.Add(-5 * time.Minute))
+
+	if got := getLastHeartbeatTime(cluster, nil); !got.Equal(&cluster.Status.LastObservedTime) {
+		t.Errorf("expected status time without Lease, got %s", got)
+	}
+
+	renewTime := metav1.NewMicroTime(now.Add(-10 * time.Second))
+	lease := &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{RenewTime: &renewTime}}
+	if got := getLastHeartbeatTime(cluster, lease); !got.Time.Equal(renewTime.Time) {
+		t.Errorf("expected renew time of the Lease, got %s", got)
+	}
+
+	staleRenewTime := metav1.NewMicroTime(now.Add(-10 * time.Minute))
+	lease.Spec.RenewTime = &staleRenewTime
+	if got := getLastHeartbeatTime(cluster, lease); !got.Equal(&cluster.Status.LastObservedTime) {
+		t.Errorf("expected status time newer than the Lease, got %s", got)
+	}
+}
+
+func TestMarkConditionsUnknown(t *testing.T) {
+	status := &clusterapi.ManagedClusterStatus{
+		Conditions: []metav1.Condition{
+			{Type: clusterapi.ClusterAPIServerHealthy, Status: metav1.ConditionTrue, Reason: "APIServerHealthy"},
+		},
+	}
+
+	if !markConditionsUnknown(status, "lost") {
+		t.Fatalf("expected status to be changed")
+	}
+	if len(status.Conditions) != 2 {
+		t.Fatalf("expected Ready condition to be added, got %v", status.Conditions)
+	}
+	for _, condition := range status.Conditions {
+		if condition.Status != metav1.ConditionUnknown || condition.Reason != ClusterStatusUnknownReason {
+			t.Errorf("expected condition %s to be Unknown, got %s/%s", condition.Type, condition.Status, condition.Reason)
+		}
+	}
+
+	if markConditionsUnknown(status, "lost") {
+		t.Errorf("expected no changes on conditions already marked as Unknown")
+	}
+}
+
+func TestShouldEvict(t *testing.T) {
+	now := time.Now()
+	status := &clusterapi.ManagedClusterStatus{}
+	markConditionsUnknown(status, "lost")
+	ready := apimeta.FindStatusCondition(status.Conditions, clusterapi.ClusterReady)
+
+	ready.LastTransitionTime = metav1.NewTime(now.Add(-time.Minute))
+	if shouldEvict(status, 5*time.Minute, now) {
+		t.Errorf("expected no eviction within the timeout")
+	}
+
+	ready.LastTransitionTime = metav1.NewTime(now.Add(-10 * time.Minute))
+	if !shouldEvict(status, 5*time.Minute, now) {
+		t.Errorf("expected eviction beyond the timeout")
+	}
+
+	ready.Reason = "NoConditionsCollected"
+	if shouldEvict(status, 5*time.Minute, now) {
+		t.Errorf("expected no eviction for clusters reporting Unknown by themselves")
+	}
+}
+
+func TestShouldFailover(t *testing.T) {
+	now := time.Now()
+	status := &clusterapi.ManagedClusterStatus{}
+	if shouldFailover(status, 5*time.Minute, now) {
+		t.Errorf("expected no failover for clusters never reporting readiness")
+	}
+
+	status.Conditions = []metav1.Condition{{
+		Type:               clusterapi.ClusterReady,
+		Status:             metav1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+	}}
+	if shouldFailover(status, 5*time.Minute, now) {
+		t.Errorf("expected no failover within the tolerance")
+	}
+
+	status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-10 * time.Minute))
+	if !shouldFailover(status, 5*time.Minute, now) {
+		t.Errorf("expected failover beyond the tolerance")
+	}
+
+	status.Conditions[0].Status = metav1.ConditionTrue
+	if shouldFailover(status, 5*time.Minute, now) {
+		t.Errorf("expected no failover for ready clusters")
+	}
+}
+
+func TestGetUnhealthyTaints(t *testing.T) {
+	now := time.Now()
+	maintenance := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}
+	unhealthy := corev1.Taint{Key: known.TaintClusterUnhealthy, Effect: corev1.TaintEffectNoSchedule}
+	notReadySince := func(d time.Duration) []metav1.Condition {
+		return []metav1.Condition{{
+			Type:               clusterapi.ClusterReady,
+			Status:             metav1.ConditionUnknown,
+			LastTransitionTime: metav1.NewTime(now.Add(-d)),
+		}}
+	}
+
+	tests := []struct {
+		name        string
+		taints      []corev1.Taint
+		conditions  []metav1.Condition
+		wantKeys    []string
+		wantChanged bool
+	}{
+		{
+			name:       "not ready within the tolerance",
+			taints:     []corev1.Taint{maintenance},
+			conditions: notReadySince(time.Minute),
+			wantKeys:   []string{"maintenance"},
+		},
+		{
+			name:        "not ready beyond the tolerance",
+			taints:      []corev1.Taint{maintenance},
+			conditions:  notReadySince(10 * time.Minute),
+			wantKeys:    []string{"maintenance", known.TaintClusterUnhealthy},
+			wantChanged: true,
+		},
+		{
+			name:       "already tainted",
+			taints:     []corev1.Taint{unhealthy},
+			conditions: notReadySince(10 * time.Minute),
+			wantKeys:   []string{known.TaintClusterUnhealthy},
+		},
+		{
+			name:   "recovered",
+			taints: []corev1.Taint{maintenance, unhealthy},
+			conditions: []metav1.Condition{{
+				Type:   clusterapi.ClusterReady,
+				Status: metav1.ConditionTrue,
+			}},
+			wantKeys:    []string{"maintenance"},
+			wantChanged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterapi.ManagedCluster{
+				Spec:   clusterapi.ManagedClusterSpec{Taints: tt.taints},
+				Status: clusterapi.ManagedClusterStatus{Conditions: tt.conditions},
+			}
+			taints, changed := getUnhealthyTaints(cluster, 5*time.Minute, now)
+			if changed != tt.wantChanged {
+				t.Errorf("getUnhealthyTaints() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			var keys []string
+			for _, taint := range taints {
+				keys = append(keys, taint.Key)
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("getUnhealthyTaints() = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestGetSchedulingTaints(t *testing.T) {
+	now := time.Now()
+	maintenance := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}
+	unschedulable := corev1.Taint{Key: known.TaintClusterUnschedulable, Effect: corev1.TaintEffectNoSchedule}
+	draining := corev1.Taint{Key: known.TaintClusterDraining, Effect: corev1.TaintEffectNoExecute}
+
+	tests := []struct {
+		name        string
+		spec        clusterapi.ManagedClusterSpec
+		wantKeys    []string
+		wantChanged bool
+	}{
+		{
+			name:     "schedulable",
+			spec:     clusterapi.ManagedClusterSpec{Taints: []corev1.Taint{maintenance}},
+			wantKeys: []string{"maintenance"},
+		},
+		{
+			name:        "cordon",
+			spec:        clusterapi.ManagedClusterSpec{Unschedulable: true, Taints: []corev1.Taint{maintenance}},
+			wantKeys:    []string{"maintenance", known.TaintClusterUnschedulable},
+			wantChanged: true,
+		},
+		{
+			name:     "already cordoned",
+			spec:     clusterapi.ManagedClusterSpec{Unschedulable: true, Taints: []corev1.Taint{unschedulable}},
+			wantKeys: []string{known.TaintClusterUnschedulable},
+		},
+		{
+			name:        "drain",
+			spec:        clusterapi.ManagedClusterSpec{Drain: true, Taints: []corev1.Taint{unschedulable}},
+			wantKeys:    []string{known.TaintClusterUnschedulable, known.TaintClusterDraining},
+			wantChanged: true,
+		},
+		{
+			name:        "uncordon",
+			spec:        clusterapi.ManagedClusterSpec{Taints: []corev1.Taint{unschedulable, maintenance, draining}},
+			wantKeys:    []string{"maintenance"},
+			wantChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taints, changed := getSchedulingTaints(tt.spec, now)
+			if changed != tt.wantChanged {
+				t.Errorf("getSchedulingTaints() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			var keys []string
+			for _, taint := range taints {
+				keys = append(keys, taint.Key)
+				if taint.Key == known.TaintClusterDraining && taint.TimeAdded == nil {
+					t.Errorf("expected timeAdded to be set on the draining taint")
+				}
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.wantKeys, ",") {
+				t.Errorf("getSchedulingTaints() = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csrsigner
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/controllers/certificates/certificatesigningrequest"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const (
+	// clockSkew is backdated on the signed certificates to tolerate clock skew between clusters
+	clockSkew = 5 * time.Minute
+)
+
+var allowedUsages = sets.NewString(
+	string(certificatesv1.UsageClientAuth),
+	string(certificatesv1.UsageDigitalSignature),
+	string(certificatesv1.UsageKeyEncipherment),
+)
+
+// CSRSigner approves and signs the CertificateSigningRequests submitted by clusternet-agent
+// with signer name "clusternet.io/cluster-agent".
+//
+// A request is only approved when it comes from a registered child cluster, either with the dedicated
+// service account in the cluster namespace, or with a client certificate issued before for rotation.
+// The signed client certificates identify the child clusters as user "clusternet:cluster:<cluster id>".
+type CSRSigner struct {
+	ctx context.Context
+
+	kubeclient *kubernetes.Clientset
+
+	clusterLister clusterlisters.ManagedClusterLister
+	clusterSynced cache.InformerSynced
+
+	controller *certificatesigningrequest.Controller
+
+	caCert   *x509.Certificate
+	caKey    crypto.Signer
+	duration time.Duration
+
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+// NewCSRSigner returns a new CSRSigner, which signs certificates with the CA loaded from certFile and keyFile.
+// It should be called before the informer factories start.
+func NewCSRSigner(ctx context.Context, kubeclient *kubernetes.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory,
+	certFile, keyFile string, duration time.Duration) (*CSRSigner, error) {
+	caCert, caKey, err := loadCA(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &CSRSigner{
+		ctx:           ctx,
+		kubeclient:    kubeclient,
+		clusterLister: clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		clusterSynced: clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
+		caCert:        caCert,
+		caKey:         caKey,
+		duration:      duration,
+		broadcaster:   record.NewBroadcaster(),
+	}
+
+	signer.broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
+		Interface: kubeclient.CoreV1().Events(""),
+	})
+	signer.recorder = signer.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "clusternet-hub"})
+
+	controller, err := certificatesigningrequest.NewController(ctx, known.ClusterAgentSignerName,
+		kubeInformerFactory.Certificates().V1().CertificateSigningRequests(),
+		signer.recorder, signer.handleCSR)
+	if err != nil {
+		return nil, err
+	}
+	signer.controller = controller
+
+	return signer, nil
+}
+
+func (signer *CSRSigner) Run(workers int) {
+	klog.Infof("starting Clusternet CSR signer ...")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(signer.ctx.Done(), signer.clusterSynced) {
+		return
+	}
+
+	signer.controller.Run(workers, signer.ctx.Done())
+}
+
+func (signer *CSRSigner) handleCSR(request *certificatesv1.CertificateSigningRequest) error {
+	klog.V(5).Infof("handle CertificateSigningRequest %s", klog.KObj(request))
+
+	x509cr, err := parseCSR(request.Spec.Request)
+	if err != nil {
+		return signer.deny(request, fmt.Sprintf("unable to parse the request: %v", err))
+	}
+	clusterID, err := validateRequest(request, x509cr)
+	if err != nil {
+		return signer.deny(request, err.Error())
+	}
+
+	mcls, err := signer.clusterLister.List(labels.SelectorFromSet(labels.Set{
+		known.ClusterIDLabel: clusterID,
+	}))
+	if err != nil {
+		return err
+	}
+	if len(mcls) == 0 {
+		return signer.deny(request, fmt.Sprintf("cluster %s is not registered", clusterID))
+	}
+	if !isRequestedByCluster(request.Spec.Username, mcls[0].Namespace, clusterID) {
+		return signer.deny(request, fmt.Sprintf("user %s is not allowed to request certificates for cluster %s",
+			request.Spec.Username, clusterID))
+	}
+
+	if !certificatesigningrequest.IsApproved(request) {
+		request.Status.Conditions = append(request.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:           certificatesv1.CertificateApproved,
+			Status:         corev1.ConditionTrue,
+			Reason:         "AutoApproved",
+			Message:        fmt.Sprintf("Auto approved by clusternet-hub for cluster %s", clusterID),
+			LastUpdateTime: metav1.Now(),
+		})
+		request, err = signer.kubeclient.CertificatesV1().CertificateSigningRequests().UpdateApproval(signer.ctx,
+			request.Name, request, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	certPEM, err := signCertificate(signer.caCert, signer.caKey, x509cr, signer.duration, time.Now())
+	if err != nil {
+		return err
+	}
+	request.Status.Certificate = certPEM
+	_, err = signer.kubeclient.CertificatesV1().CertificateSigningRequests().UpdateStatus(signer.ctx, request, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	klog.V(4).Infof("successfully signed client certificate for cluster %s with CertificateSigningRequest %s",
+		clusterID, klog.KObj(request))
+	return nil
+}
+
+func (signer *CSRSigner) deny(request *certificatesv1.CertificateSigningRequest, message string) error {
+	klog.Warningf("deny CertificateSigningRequest %s: %s", klog.KObj(request), message)
+	signer.recorder.Event(request, corev1.EventTypeWarning, "RequestDenied", message)
+
+	request.Status.Conditions = append(request.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateDenied,
+		Status:         corev1.ConditionTrue,
+		Reason:         "RequestInvalid",
+		Message:        message,
+		LastUpdateTime: metav1.Now(),
+	})
+	_, err := signer.kubeclient.CertificatesV1().CertificateSigningRequests().UpdateApproval(signer.ctx,
+		request.Name, request, metav1.UpdateOptions{})
+	return err
+}
+
+// parseCSR decodes the PEM-encoded certificate request.
+func parseCSR(data []byte) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("PEM block type must be CERTIFICATE REQUEST")
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// validateRequest checks whether the request is a valid client certificate request of a child cluster,
+// and returns the cluster id.
+func validateRequest(request *certificatesv1.CertificateSigningRequest, x509cr *x509.CertificateRequest) (string, error) {
+	if err := x509cr.CheckSignature(); err != nil {
+		return "", fmt.Errorf("invalid signature of the request: %v", err)
+	}
+
+	clusterID := strings.TrimPrefix(x509cr.Subject.CommonName, known.ClusterAgentUserPrefix)
+	if clusterID == x509cr.Subject.CommonName || len(clusterID) == 0 {
+		return "", fmt.Errorf("common name must be in the format of %s<cluster id>", known.ClusterAgentUserPrefix)
+	}
+	if len(x509cr.Subject.Organization) != 1 || x509cr.Subject.Organization[0] != known.ClusterAgentGroup {
+		return "", fmt.Errorf("organization must be %s", known.ClusterAgentGroup)
+	}
+	if len(x509cr.DNSNames) > 0 || len(x509cr.EmailAddresses) > 0 || len(x509cr.IPAddresses) > 0 || len(x509cr.URIs) > 0 {
+		return "", fmt.Errorf("subject alternative names are not allowed")
+	}
+
+	usages := sets.NewString()
+	for _, usage := range request.Spec.Usages {
+		usages.Insert(string(usage))
+	}
+	if !usages.Has(string(certificatesv1.UsageClientAuth)) || !allowedUsages.IsSuperset(usages) {
+		return "", fmt.Errorf("usages must include %q and be within %v", certificatesv1.UsageClientAuth, allowedUsages.List())
+	}
+	return clusterID, nil
+}
+
+// isRequestedByCluster returns true if the user is a service account in the cluster namespace,
+// or the identity of the cluster itself, which is the case of rotation.
+func isRequestedByCluster(username, namespace, clusterID string) bool {
+	return username == known.ClusterAgentUserPrefix+clusterID ||
+		strings.HasPrefix(username, serviceaccount.ServiceAccountUsernamePrefix+namespace+serviceaccount.ServiceAccountUsernameSeparator)
+}
+
+// signCertificate signs a client certificate valid for duration, which won't outlive the CA.
+func signCertificate(caCert *x509.Certificate, caKey crypto.Signer, x509cr *x509.CertificateRequest,
+	duration time.Duration, now time.Time) ([]byte, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter := now.Add(duration)
+	if caCert.NotAfter.Before(notAfter) {
+		notAfter = caCert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               x509cr.Subject,
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, x509cr.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: der}), nil
+}
+
+func loadCA(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	certs, err := certutil.CertsFromFile(certFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load signing certificate: %v", err)
+	}
+	key, err := keyutil.PrivateKeyFromFile(keyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load signing key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("signing key in %s is not a valid private key", keyFile)
+	}
+	return certs[0], signer, nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csrsigner
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func newRequest(t *testing.T, subject *pkix.Name, dnsNames []string, usages ...certificatesv1.KeyUsage) (*certificatesv1.CertificateSigningRequest, *x509.CertificateRequest) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := certutil.MakeCSR(key, subject, dnsNames, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x509cr, err := parseCSR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &certificatesv1.CertificateSigningRequest{
+		Spec: certificatesv1.CertificateSigningRequestSpec{Request: data, Usages: usages},
+	}, x509cr
+}
+
+func TestParseCSR(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := &pkix.Name{CommonName: "clusternet:cluster:abc", Organization: []string{"clusternet:clusters"}}
+	data, err := certutil.MakeCSR(key, subject, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "clusternet-ca"}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: caCert.Raw})
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{
+			name: "certificate request",
+			data: data,
+		},
+		{
+			name:    "not PEM-encoded",
+			data:    []byte("clusternet"),
+			wantErr: true,
+		},
+		{
+			name:    "certificate",
+			data:    certPEM,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x509cr, err := parseCSR(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCSR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if x509cr.Subject.CommonName != subject.CommonName {
+				t.Errorf("unexpected common name %s", x509cr.Subject.CommonName)
+			}
+			if err = x509cr.CheckSignature(); err != nil {
+				t.Errorf("failed to check the signature: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	validSubject := &pkix.Name{CommonName: "clusternet:cluster:abc", Organization: []string{"clusternet:clusters"}}
+	clientAuth := []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature, certificatesv1.UsageKeyEncipherment, certificatesv1.UsageClientAuth}
+
+	tests := []struct {
+		name     string
+		subject  *pkix.Name
+		dnsNames []string
+		usages   []certificatesv1.KeyUsage
+		wantID   string
+		wantErr  bool
+	}{
+		{
+			name:    "valid request",
+			subject: validSubject,
+			usages:  clientAuth,
+			wantID:  "abc",
+		},
+		{
+			name:    "invalid common name",
+			subject: &pkix.Name{CommonName: "system:node:abc", Organization: []string{"clusternet:clusters"}},
+			usages:  clientAuth,
+			wantErr: true,
+		},
+		{
+			name:    "empty cluster id",
+			subject: &pkix.Name{CommonName: "clusternet:cluster:", Organization: []string{"clusternet:clusters"}},
+			usages:  clientAuth,
+			wantErr: true,
+		},
+		{
+			name:    "extra organization",
+			subject: &pkix.Name{CommonName: "clusternet:cluster:abc", Organization: []string{"clusternet:clusters", "system:masters"}},
+			usages:  clientAuth,
+			wantErr: true,
+		},
+		{
+			name:     "subject alternative names",
+			subject:  validSubject,
+			dnsNames: []string{"example.com"},
+			usages:   clientAuth,
+			wantErr:  true,
+		},
+		{
+			name:    "server auth",
+			subject: validSubject,
+			usages:  append(clientAuth, certificatesv1.UsageServerAuth),
+			wantErr: true,
+		},
+		{
+			name:    "missing client auth",
+			subject: validSubject,
+			usages:  []certificatesv1.KeyUsage{certificatesv1.UsageDigitalSignature},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, x509cr := newRequest(t, tt.subject, tt.dnsNames, tt.usages...)
+			got, err := validateRequest(request, x509cr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantID {
+				t.Errorf("validateRequest() = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestIsRequestedByCluster(t *testing.T) {
+	tests := []struct {
+		username string
+		want     bool
+	}{
+		{username: "system:serviceaccount:clusternet-abcde:cluster-bootstrap-xyz", want: true},
+		{username: "clusternet:cluster:abc", want: true},
+		{username: "clusternet:cluster:def", want: false},
+		{username: "system:serviceaccount:clusternet-abcdef:cluster-bootstrap-xyz", want: false},
+		{username: "admin", want: false},
+	}
+	for _, tt := range tests {
+		if got := isRequestedByCluster(tt.username, "clusternet-abcde", "abc"); got != tt.want {
+			t.Errorf("isRequestedByCluster(%q) = %v, want %v", tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestSignCertificate(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := certutil.NewSelfSignedCACert(certutil.Config{CommonName: "clusternet-ca"}, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, x509cr := newRequest(t, &pkix.Name{CommonName: "clusternet:cluster:abc", Organization: []string{"clusternet:clusters"}}, nil)
+
+	now := time.Now()
+	for _, duration := range []time.Duration{time.Hour, 100 * 365 * 24 * time.Hour} {
+		certPEM, err := signCertificate(caCert, caKey, x509cr, duration, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs, err := certutil.ParseCertsPEM(certPEM)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert := certs[0]
+
+		roots := x509.NewCertPool()
+		roots.AddCert(caCert)
+		if _, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}); err != nil {
+			t.Errorf("failed to verify the signed certificate: %v", err)
+		}
+		if cert.Subject.CommonName != "clusternet:cluster:abc" {
+			t.Errorf("unexpected common name %s", cert.Subject.CommonName)
+		}
+		if cert.NotAfter.After(caCert.NotAfter) {
+			t.Errorf("the signed certificate outlives the CA")
+		}
+		if duration == time.Hour && !cert.NotAfter.Equal(now.Add(time.Hour).Truncate(time.Second)) {
+			t.Errorf("unexpected expiration %v", cert.NotAfter)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// defaultPrometheusQueryTimeout is the timeout of querying a metric from Prometheus
+	defaultPrometheusQueryTimeout = 10 * time.Second
+
+	// rolloutAnalysisInterval is how often the metrics are checked again when the rollout is paused by the analysis
+	rolloutAnalysisInterval = time.Minute
+
+	// clustersPlaceholder in the queries is replaced with a regular expression matching the updated clusters
+	clustersPlaceholder = "{{clusters}}"
+)
+
+// analyzeRollout checks the metrics of the updated clusters,
+// and returns a message about the first metric out of its thresholds.
+func (deployer *Deployer) analyzeRollout(analysis *appsapi.RolloutAnalysis, clusterNames []string) (string, error) {
+	if len(analysis.Metrics) == 0 {
+		return "", nil
+	}
+	if len(deployer.rolloutPrometheusAddress) == 0 {
+		return "", errors.New("flag --rollout-prometheus-address of clusternet-hub is not set")
+	}
+
+	var names []string
+	for _, name := range clusterNames {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	matcher := strings.Join(names, "|")
+	for _, metric := range analysis.Metrics {
+		query := strings.ReplaceAll(metric.Query, clustersPlaceholder, matcher)
+		ctx, cancel := context.WithTimeout(deployer.ctx, defaultPrometheusQueryTimeout)
+		values, err := queryPrometheus(ctx, deployer.rolloutPrometheusAddress, query)
+		cancel()
+		if err != nil {
+			return "", fmt.Errorf("failed to query metric %s: %v", metric.Name, err)
+		}
+
+		msg, err := utils.CheckMetricThresholds(values, metric.Min, metric.Max)
+		if err != nil {
+			return "", fmt.Errorf("metric %s: %v", metric.Name, err)
+		}
+		if len(msg) > 0 {
+			return fmt.Sprintf("metric %s: %s", metric.Name, msg), nil
+		}
+	}
+	return "", nil
+}
+
+// queryPrometheus runs an instant query with the Prometheus HTTP API
+func queryPrometheus(ctx context.Context, address, query string) ([]float64, error) {
+	u := fmt.Sprintf("%s/api/v1/query?%s", strings.TrimSuffix(address, "/"), url.Values{"query": []string{query}}.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	values, err := utils.ParsePrometheusValues(body)
+	if err != nil && resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("prometheus returns status code %d: %v", resp.StatusCode, err)
+	}
+	return values, err
+}
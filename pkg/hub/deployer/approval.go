@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// defaultApprovalWebhookTimeout is the timeout of requesting an approval from the webhook
+const defaultApprovalWebhookTimeout = 10 * time.Second
+
+// ApprovalRequest is the payload posted to the approval webhook of a Subscription,
+// which describes the pending changes of a Description.
+type ApprovalRequest struct {
+	SubscriptionNamespace string `json:"subscriptionNamespace"`
+	SubscriptionName      string `json:"subscriptionName"`
+
+	DescriptionNamespace string `json:"descriptionNamespace"`
+	DescriptionName      string `json:"descriptionName"`
+	// Generation is the generation of the Description to be approved
+	Generation int64 `json:"generation"`
+
+	ClusterID   string `json:"clusterId,omitempty"`
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// Changes are the summary of the changes, such as "update Deployment foo/nginx"
+	Changes []string `json:"changes"`
+}
+
+// ApprovalResponse is the response of the approval webhook.
+type ApprovalResponse struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// getApprovalStrategy returns the ApprovalStrategy of the Subscription that the Base is populated from.
+func (deployer *Deployer) getApprovalStrategy(base *appsapi.Base) (*appsapi.ApprovalStrategy, error) {
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sub.Spec.Approval, nil
+}
+
+// requestApproval marks the newly created or updated Description as pending with a summary of its changes,
+// and asks the webhook for an approval if configured. Descriptions without changes are approved directly.
+func (deployer *Deployer) requestApproval(base *appsapi.Base, approval *appsapi.ApprovalStrategy,
+	oldSpec *appsapi.DescriptionSpec, desc *appsapi.Description) error {
+	changes := utils.SummarizeDescriptionChanges(oldSpec, &desc.Spec)
+	condition := metav1.Condition{
+		Type:    appsapi.DescriptionApproved,
+		Status:  metav1.ConditionFalse,
+		Reason:  "PendingApproval",
+		Message: strings.Join(changes, "; "),
+	}
+	switch {
+	case len(changes) == 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "NoChanges"
+		condition.Message = "no changes to be approved"
+	case len(approval.Webhook) > 0:
+		response, err := deployer.postApprovalRequest(base, desc, approval.Webhook, changes)
+		if err != nil {
+			msg := fmt.Sprintf("failed to request approval for Description %s from webhook: %v", klog.KObj(desc), err)
+			klog.Warning(msg)
+			deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedRequestingApproval", msg)
+		} else if response.Approved {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "ApprovedByWebhook"
+			if len(response.Reason) > 0 {
+				condition.Message = response.Reason
+			}
+		}
+	}
+
+	if condition.Status != metav1.ConditionTrue {
+		deployer.recorder.Event(base, corev1.EventTypeNormal, "PendingApproval",
+			fmt.Sprintf("changes of Description %s are waiting for approval: %s", klog.KObj(desc), condition.Message))
+	}
+	return deployer.setApprovalCondition(desc, condition)
+}
+
+// setApprovalCondition sets condition Approved for the current generation of the Description,
+// which is pending until the changes get approved and deployed.
+func (deployer *Deployer) setApprovalCondition(desc *appsapi.Description, condition metav1.Condition) error {
+	status := *desc.Status.DeepCopy()
+	status.Phase = appsapi.DescriptionPhasePending
+	status.Reason = "waiting for the changes to be approved"
+	if condition.Status == metav1.ConditionTrue {
+		status.Reason = "changes are approved"
+	}
+	status.Conditions = []metav1.Condition{condition}
+	utils.SetDescriptionStatus(desc, status)
+	_, err := deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(context.TODO(),
+		desc, metav1.UpdateOptions{})
+	return err
+}
+
+func (deployer *Deployer) postApprovalRequest(base *appsapi.Base, desc *appsapi.Description, url string,
+	changes []string) (*ApprovalResponse, error) {
+	payload, err := json.Marshal(&ApprovalRequest{
+		SubscriptionNamespace: base.Labels[known.ConfigSubscriptionNamespaceLabel],
+		SubscriptionName:      base.Labels[known.ConfigSubscriptionNameLabel],
+		DescriptionNamespace:  desc.Namespace,
+		DescriptionName:       desc.Name,
+		Generation:            desc.Generation,
+		ClusterID:             desc.Labels[known.ClusterIDLabel],
+		ClusterName:           desc.Labels[known.ClusterNameLabel],
+		Changes:               changes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(deployer.ctx, defaultApprovalWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("webhook returns status code %d", resp.StatusCode)
+	}
+
+	response := &ApprovalResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of webhook: %v", err)
+	}
+	return response, nil
+}
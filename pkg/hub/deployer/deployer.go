@@ -23,6 +23,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -33,19 +34,21 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	appslisters "k8s.io/client-go/listers/apps/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	utilpointer "k8s.io/utils/pointer"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
-	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
 	"github.com/clusternet/clusternet/pkg/controllers/apps/base"
+	"github.com/clusternet/clusternet/pkg/controllers/apps/description"
 	"github.com/clusternet/clusternet/pkg/controllers/apps/manifest"
 	"github.com/clusternet/clusternet/pkg/controllers/apps/subscription"
 	"github.com/clusternet/clusternet/pkg/features"
@@ -55,8 +58,14 @@ import (
 	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
 	"github.com/clusternet/clusternet/pkg/hub/deployer/generic"
 	"github.com/clusternet/clusternet/pkg/hub/deployer/helm"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/hub/kustomizer"
 	"github.com/clusternet/clusternet/pkg/hub/localizer"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/hub/sourcer"
 	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
@@ -64,6 +73,8 @@ var (
 	helmChartKind    = appsapi.SchemeGroupVersion.WithKind("HelmChart")
 	subscriptionKind = appsapi.SchemeGroupVersion.WithKind("Subscription")
 	baseKind         = appsapi.SchemeGroupVersion.WithKind("Base")
+	descriptionKind  = appsapi.SchemeGroupVersion.WithKind("Description")
+	ociRepoKind      = appsapi.SchemeGroupVersion.WithKind("OCIRepository")
 )
 
 const (
@@ -82,6 +93,7 @@ type Deployer struct {
 	baseSynced    cache.InformerSynced
 	mfstLister    applisters.ManifestLister
 	mfstSynced    cache.InformerSynced
+	ociRepoLister applisters.OCIRepositoryLister
 	subLister     applisters.SubscriptionLister
 	subSynced     cache.InformerSynced
 	clusterLister clusterlisters.ManagedClusterLister
@@ -99,12 +111,63 @@ type Deployer struct {
 
 	localizer *localizer.Localizer
 
+	kustomizer *kustomizer.Kustomizer
+
+	sourcer *sourcer.Sourcer
+
+	// framework runs the scheduling plugins to select the clusters for Subscriptions
+	framework *framework.Framework
+
+	// residencyLister is used to reject the Bases that violate ResidencyPolicies.
+	// It is nil when feature gate DataResidency is disabled.
+	residencyLister applisters.ResidencyPolicyLister
+	residencySynced cache.InformerSynced
+
+	// tenantLister is used to reject the Bases targeting clusters not owned by the Tenants.
+	// It is nil when feature gate MultiTenancy is disabled.
+	tenantLister applisters.TenantLister
+	tenantSynced cache.InformerSynced
+
+	// aggregationLister is used to declare the status fields to collect in Descriptions.
+	// It is nil when feature gate StatusAggregation is disabled.
+	aggregationLister applisters.StatusAggregationLister
+	aggregationSynced cache.InformerSynced
+
+	// placementWebhook is the url where placement changes of Subscriptions are sent to.
+	// Empty means only events will be recorded.
+	placementWebhook string
+
+	// descriptionRollbackAttempts is the number of failed attempts to deploy a Description,
+	// after which the Description is rolled back to its last successfully deployed spec. 0 disables rolling back.
+	descriptionRollbackAttempts int
+
+	// crLister is used to get the revision history of feeds and the last successfully deployed specs of Descriptions
+	crLister appslisters.ControllerRevisionLister
+	crSynced cache.InformerSynced
+
+	// rolloutPrometheusAddress is the address of Prometheus, where the metrics are queried
+	// from during the rollouts of Subscriptions.
+	rolloutPrometheusAddress string
+
+	// maxManifestsPerDescription and maxDescriptionBytes limit the number of manifests and total bytes
+	// carried by a single Description. 0 means no limit.
+	maxManifestsPerDescription int
+	maxDescriptionBytes        int
+
+	// dynamicSchedulingInterval is how often the replicas are re-divided with Dynamic dividing scheduling
+	dynamicSchedulingInterval time.Duration
+
 	broadcaster record.EventBroadcaster
 	recorder    record.EventRecorder
+	// lifecycleRecorder records the lifecycle events of Subscriptions, and posts them to the webhook if configured
+	lifecycleRecorder *events.Recorder
 }
 
 func NewDeployer(ctx context.Context, kubeclient *kubernetes.Clientset, clusternetclient *clusternetclientset.Clientset,
-	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory) (*Deployer, error) {
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory,
+	placementWebhook, lifecycleEventWebhook, rolloutPrometheusAddress string, maxManifestsPerDescription, maxDescriptionBytes int,
+	dynamicSchedulingInterval time.Duration, descriptionRollbackAttempts int, helmDriftCheckInterval time.Duration,
+	descriptionRateLimitOptions description.RateLimitOptions, sharder *sharding.Sharder) (*Deployer, error) {
 	feedInUseProtection := utilfeature.DefaultFeatureGate.Enabled(features.FeedInUseProtection)
 
 	deployer := &Deployer{
@@ -118,12 +181,23 @@ func NewDeployer(ctx context.Context, kubeclient *kubernetes.Clientset, clustern
 		baseLister:       clusternetInformerFactory.Apps().V1alpha1().Bases().Lister(),
 		baseSynced:       clusternetInformerFactory.Apps().V1alpha1().Bases().Informer().HasSynced,
 		mfstLister:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Lister(),
+		ociRepoLister:    clusternetInformerFactory.Apps().V1alpha1().OCIRepositories().Lister(),
 		mfstSynced:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Informer().HasSynced,
 		subLister:        clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Lister(),
 		subSynced:        clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Informer().HasSynced,
+		crLister:         kubeInformerFactory.Apps().V1().ControllerRevisions().Lister(),
+		crSynced:         kubeInformerFactory.Apps().V1().ControllerRevisions().Informer().HasSynced,
 		kubeClient:       kubeclient,
 		clusternetClient: clusternetclient,
+		placementWebhook: placementWebhook,
 		broadcaster:      record.NewBroadcaster(),
+
+		maxManifestsPerDescription: maxManifestsPerDescription,
+		maxDescriptionBytes:        maxDescriptionBytes,
+		dynamicSchedulingInterval:  dynamicSchedulingInterval,
+		rolloutPrometheusAddress:   rolloutPrometheusAddress,
+
+		descriptionRollbackAttempts: descriptionRollbackAttempts,
 	}
 
 	//deployer.broadcaster.StartStructuredLogging(5)
@@ -135,18 +209,69 @@ func NewDeployer(ctx context.Context, kubeclient *kubernetes.Clientset, clustern
 	} else {
 		klog.Warningf("no api server defined - no events will be sent to API server.")
 	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.DataResidency) {
+		residencyInformer := clusternetInformerFactory.Apps().V1alpha1().ResidencyPolicies()
+		deployer.residencyLister = residencyInformer.Lister()
+		deployer.residencySynced = residencyInformer.Informer().HasSynced
+		residencyInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: deployer.enqueueSubscriptionsForResidencyPolicy,
+			UpdateFunc: func(old, cur interface{}) {
+				deployer.enqueueSubscriptionsForResidencyPolicy(cur)
+			},
+			DeleteFunc: deployer.enqueueSubscriptionsForResidencyPolicy,
+		})
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.MultiTenancy) {
+		tenantInformer := clusternetInformerFactory.Apps().V1alpha1().Tenants()
+		deployer.tenantLister = tenantInformer.Lister()
+		deployer.tenantSynced = tenantInformer.Informer().HasSynced
+		tenantInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: deployer.enqueueSubscriptionsForTenant,
+			UpdateFunc: func(old, cur interface{}) {
+				deployer.enqueueSubscriptionsForTenant(old)
+				deployer.enqueueSubscriptionsForTenant(cur)
+			},
+			DeleteFunc: deployer.enqueueSubscriptionsForTenant,
+		})
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.StatusAggregation) {
+		aggregationInformer := clusternetInformerFactory.Apps().V1alpha1().StatusAggregations()
+		deployer.aggregationLister = aggregationInformer.Lister()
+		deployer.aggregationSynced = aggregationInformer.Informer().HasSynced
+		aggregationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: deployer.enqueueBasesForStatusAggregation,
+			UpdateFunc: func(old, cur interface{}) {
+				deployer.enqueueBasesForStatusAggregation(old)
+				deployer.enqueueBasesForStatusAggregation(cur)
+			},
+			DeleteFunc: deployer.enqueueBasesForStatusAggregation,
+		})
+	}
+
+	clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: deployer.updateClusterImageRegistryMirrors,
+	})
+
 	utilruntime.Must(appsapi.AddToScheme(scheme.Scheme))
 	deployer.recorder = deployer.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "clusternet-hub"})
+	deployer.lifecycleRecorder = events.NewRecorder(ctx, deployer.recorder, lifecycleEventWebhook)
+
+	f, err := scheduler.NewFramework(clusternetclient, clusternetInformerFactory, deployer.recorder)
+	if err != nil {
+		return nil, err
+	}
+	deployer.framework = f
 
 	helmDeployer, err := helm.NewDeployer(ctx, clusternetclient, kubeclient, clusternetInformerFactory,
-		kubeInformerFactory, feedInUseProtection, deployer.recorder)
+		kubeInformerFactory, feedInUseProtection, helmDriftCheckInterval, deployer.recorder, deployer.lifecycleRecorder,
+		descriptionRateLimitOptions, sharder)
 	if err != nil {
 		return nil, err
 	}
 	deployer.helmDeployer = helmDeployer
 
 	genericDeployer, err := generic.NewDeployer(ctx, clusternetclient, clusternetInformerFactory,
-		kubeInformerFactory, deployer.recorder)
+		kubeInformerFactory, deployer.recorder, deployer.lifecycleRecorder, descriptionRateLimitOptions, sharder)
 	if err != nil {
 		return nil, err
 	}
@@ -156,6 +281,7 @@ func NewDeployer(ctx context.Context, kubeclient *kubernetes.Clientset, clustern
 		clusternetclient,
 		clusternetInformerFactory.Apps().V1alpha1().Subscriptions(),
 		clusternetInformerFactory.Apps().V1alpha1().Bases(),
+		clusternetInformerFactory.Apps().V1alpha1().Descriptions(),
 		clusternetInformerFactory.Clusters().V1beta1().ManagedClusters(),
 		deployer.recorder,
 		deployer.handleSubscription)
@@ -192,6 +318,18 @@ func NewDeployer(ctx context.Context, kubeclient *kubernetes.Clientset, clustern
 	}
 	deployer.localizer = l
 
+	k, err := kustomizer.NewKustomizer(ctx, clusternetclient, clusternetInformerFactory, kubeInformerFactory, deployer.recorder)
+	if err != nil {
+		return nil, err
+	}
+	deployer.kustomizer = k
+
+	src, err := sourcer.NewSourcer(ctx, clusternetclient, clusternetInformerFactory, kubeInformerFactory, deployer.recorder)
+	if err != nil {
+		return nil, err
+	}
+	deployer.sourcer = src
+
 	return deployer, nil
 }
 
@@ -207,56 +345,63 @@ func (deployer *Deployer) Run(workers int) {
 		deployer.mfstSynced,
 		deployer.clusterSynced,
 		deployer.subSynced,
+		deployer.crSynced,
 	) {
 		return
 	}
+	if deployer.residencySynced != nil && !cache.WaitForCacheSync(deployer.ctx.Done(), deployer.residencySynced) {
+		return
+	}
+	if deployer.tenantSynced != nil && !cache.WaitForCacheSync(deployer.ctx.Done(), deployer.tenantSynced) {
+		return
+	}
+	if deployer.aggregationSynced != nil && !cache.WaitForCacheSync(deployer.ctx.Done(), deployer.aggregationSynced) {
+		return
+	}
 
-	go deployer.helmDeployer.Run(workers)
-	go deployer.genericDeployer.Run(workers)
-	go deployer.subsController.Run(workers, deployer.ctx.Done())
-	go deployer.mfstController.Run(workers, deployer.ctx.Done())
-	go deployer.baseController.Run(workers, deployer.ctx.Done())
-	go deployer.localizer.Run(workers)
+	var wg wait.Group
+	wg.Start(func() {
+		deployer.helmDeployer.Run(workers)
+	})
+	wg.Start(func() {
+		deployer.genericDeployer.Run(workers)
+	})
+	wg.Start(func() {
+		deployer.subsController.Run(workers, deployer.ctx.Done())
+	})
+	wg.Start(func() {
+		deployer.mfstController.Run(workers, deployer.ctx.Done())
+	})
+	wg.Start(func() {
+		deployer.baseController.Run(workers, deployer.ctx.Done())
+	})
+	wg.Start(func() {
+		deployer.localizer.Run(workers)
+	})
+	wg.Start(func() {
+		deployer.kustomizer.Run(workers)
+	})
+	wg.Start(func() {
+		deployer.sourcer.Run(workers)
+	})
 
-	<-deployer.ctx.Done()
+	wg.Wait()
 }
 
 func (deployer *Deployer) handleSubscription(sub *appsapi.Subscription) error {
 	klog.V(5).Infof("handle Subscription %s", klog.KObj(sub))
 	if sub.DeletionTimestamp != nil {
-		bases, err := deployer.baseLister.List(labels.SelectorFromSet(labels.Set{
-			known.ConfigKindLabel:      subscriptionKind.Kind,
-			known.ConfigNameLabel:      sub.Name,
-			known.ConfigNamespaceLabel: sub.Namespace,
-			known.ConfigUIDLabel:       string(sub.UID),
-		}))
-		if err != nil {
+		if err := deployer.deleteBases(sub); err != nil {
 			return err
 		}
 
-		// delete all matching Base
-		var allErrs []error
-		for _, base := range bases {
-			if base.DeletionTimestamp != nil {
-				continue
-			}
-			if err := deployer.deleteBase(context.TODO(), klog.KObj(base).String()); err != nil {
-				klog.ErrorDepth(5, err)
-				allErrs = append(allErrs, err)
-				continue
-			}
-		}
-		if bases != nil || len(allErrs) > 0 {
-			return fmt.Errorf("waiting for Bases belongs to Subscription %s getting deleted", klog.KObj(sub))
-		}
-
 		// remove label (subUID="Subscription") from referred Manifest/HelmChart
 		if err := deployer.removeLabelsFromReferredFeeds(sub.UID, subscriptionKind.Kind); err != nil {
 			return err
 		}
 
 		sub.Finalizers = utils.RemoveString(sub.Finalizers, known.AppFinalizer)
-		_, err = deployer.clusternetClient.AppsV1alpha1().Subscriptions(sub.Namespace).Update(context.TODO(), sub, metav1.UpdateOptions{})
+		_, err := deployer.clusternetClient.AppsV1alpha1().Subscriptions(sub.Namespace).Update(context.TODO(), sub, metav1.UpdateOptions{})
 		if err != nil {
 			klog.WarningDepth(4,
 				fmt.Sprintf("failed to remove finalizer %s from Subscription %s: %v", known.AppFinalizer, klog.KObj(sub), err))
@@ -271,34 +416,132 @@ func (deployer *Deployer) handleSubscription(sub *appsapi.Subscription) error {
 		return nil
 	}
 
-	err := deployer.populateBases(sub)
-	if err != nil {
+	phase, requeueAfter := utils.GetSubscriptionPhase(sub, time.Now())
+	status := sub.Status.DeepCopy()
+	status.Phase = phase
+	status.ObservedGeneration = sub.Generation
+	switch phase {
+	case appsapi.SubscriptionPending:
+		klog.V(4).Infof("Subscription %s will be activated after %s", klog.KObj(sub), requeueAfter)
+		setScheduledCondition(sub, status, metav1.ConditionFalse, "Pending",
+			fmt.Sprintf("Subscription will be activated after %s", requeueAfter))
+	case appsapi.SubscriptionExpired:
+		if err := deployer.deleteBases(sub); err != nil {
+			return err
+		}
+		setScheduledCondition(sub, status, metav1.ConditionFalse, "Expired", "Subscription has expired")
+	default:
+		if err := deployer.verifyFeeds(sub, status); err != nil {
+			return deployer.failScheduling(sub, status, appsapi.SignatureVerificationFailed, err)
+		}
+		if err := deployer.rollbackFeeds(sub); err != nil {
+			return err
+		}
+		if err := deployer.populateBases(sub, status); err != nil {
+			return deployer.failScheduling(sub, status, "SchedulingFailed", err)
+		}
+		setScheduledCondition(sub, status, metav1.ConditionTrue, "Scheduled",
+			"Subscription is scheduled to all the matching clusters")
+		if err := deployer.syncFeedsHistory(sub, status); err != nil {
+			return err
+		}
+		rolloutRequeueAfter, err := deployer.progressRollout(sub, status)
+		if err != nil {
+			return err
+		}
+		if rolloutRequeueAfter > 0 && (requeueAfter == 0 || rolloutRequeueAfter < requeueAfter) {
+			requeueAfter = rolloutRequeueAfter
+		}
+		if deployer.descriptionRollbackAttempts > 0 {
+			if err := deployer.rollbackFailedDescriptions(sub); err != nil {
+				return err
+			}
+		}
+		// available resources of clusters keep changing
+		if isDynamicDividing(sub) && (requeueAfter == 0 || deployer.dynamicSchedulingInterval < requeueAfter) {
+			requeueAfter = deployer.dynamicSchedulingInterval
+		}
+	}
+
+	if err := deployer.setReadyCondition(sub, status); err != nil {
 		return err
 	}
+	if !reflect.DeepEqual(sub.Status, *status) {
+		if err := deployer.subsController.UpdateSubscriptionStatus(sub.DeepCopy(), status); err != nil {
+			return err
+		}
+	}
+	if sub.Status.Phase != phase {
+		deployer.recorder.Event(sub, corev1.EventTypeNormal, fmt.Sprintf("Subscription%s", phase),
+			fmt.Sprintf("Subscription %s is %s", klog.KObj(sub), strings.ToLower(string(phase))))
+	}
 
+	if requeueAfter > 0 {
+		deployer.subsController.EnqueueAfter(sub, requeueAfter)
+	}
 	return nil
 }
 
-func (deployer *Deployer) populateBases(sub *appsapi.Subscription) error {
-	var mcls []*clusterapi.ManagedCluster
-	for _, subscriber := range sub.Spec.Subscribers {
-		selector, err := metav1.LabelSelectorAsSelector(subscriber.ClusterAffinity)
-		if err != nil {
-			return err
-		}
-		clusters, err := deployer.clusterLister.ManagedClusters("").List(selector)
-		if err != nil {
-			return err
-		}
+// setScheduledCondition sets the Scheduled condition of the Subscription
+func setScheduledCondition(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus,
+	conditionStatus metav1.ConditionStatus, reason, message string) {
+	status.Conditions = utils.MergeConditions(status.Conditions, sub.Generation, metav1.Condition{
+		Type:    appsapi.SubscriptionScheduled,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	})
+}
 
-		if clusters == nil {
-			deployer.recorder.Event(sub, corev1.EventTypeWarning, "NoClusters", "No clusters get matched")
-			return nil
+// failScheduling sets the Scheduled condition of the Subscription to False with err, and returns err
+func (deployer *Deployer) failScheduling(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus, reason string, err error) error {
+	setScheduledCondition(sub, status, metav1.ConditionFalse, reason, err.Error())
+	if rerr := deployer.setReadyCondition(sub, status); rerr != nil {
+		klog.Warningf("failed to roll up readiness of Subscription %s: %v", klog.KObj(sub), rerr)
+	}
+	if !reflect.DeepEqual(sub.Status, *status) {
+		if uerr := deployer.subsController.UpdateSubscriptionStatus(sub.DeepCopy(), status); uerr != nil {
+			klog.Warningf("failed to update status of Subscription %s: %v", klog.KObj(sub), uerr)
 		}
+	}
+	return err
+}
 
-		mcls = append(mcls, clusters...)
+// deleteBases deletes all the Bases populated from the Subscription
+func (deployer *Deployer) deleteBases(sub *appsapi.Subscription) error {
+	bases, err := deployer.baseLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigKindLabel:      subscriptionKind.Kind,
+		known.ConfigNameLabel:      sub.Name,
+		known.ConfigNamespaceLabel: sub.Namespace,
+		known.ConfigUIDLabel:       string(sub.UID),
+	}))
+	if err != nil {
+		return err
 	}
 
+	// delete all matching Base
+	var allErrs []error
+	for _, base := range bases {
+		if base.DeletionTimestamp != nil {
+			continue
+		}
+		if err := deployer.deleteBase(context.TODO(), klog.KObj(base).String()); err != nil {
+			klog.ErrorDepth(5, err)
+			allErrs = append(allErrs, err)
+			continue
+		}
+	}
+	if bases != nil || len(allErrs) > 0 {
+		return fmt.Errorf("waiting for Bases belongs to Subscription %s getting deleted", klog.KObj(sub))
+	}
+	return nil
+}
+
+func (deployer *Deployer) populateBases(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus) error {
+	clusters, err := deployer.clusterLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
 	allExistingBases, err := deployer.baseLister.List(labels.SelectorFromSet(labels.Set{
 		known.ConfigKindLabel:      subscriptionKind.Kind,
 		known.ConfigNameLabel:      sub.Name,
@@ -308,6 +551,45 @@ func (deployer *Deployer) populateBases(sub *appsapi.Subscription) error {
 	if err != nil {
 		return err
 	}
+
+	state := &framework.CycleState{
+		Subscription:  sub,
+		ExistingBases: allExistingBases,
+		Status:        status,
+	}
+	scores, result := deployer.framework.Schedule(deployer.ctx, state, clusters)
+	switch result.Code() {
+	case framework.Success:
+	case framework.Unschedulable:
+		// keep the current placement, and the plugin should have recorded the reason
+		klog.V(4).Infof("Subscription %s is unschedulable by plugin %s: %s", klog.KObj(sub), result.Plugin(), result.Message())
+		return nil
+	default:
+		return fmt.Errorf("failed to run scheduling plugin %s: %s", result.Plugin(), result.Message())
+	}
+
+	result = deployer.framework.RunBindPlugins(deployer.ctx, state, scores)
+	if !result.IsSkip() {
+		if err = result.AsError(); err != nil {
+			return fmt.Errorf("failed to run binding plugin %s: %v", result.Plugin(), err)
+		}
+		return nil
+	}
+	mcls := framework.Clusters(scores)
+
+	change, err := deployer.getPlacementChange(sub, allExistingBases, mcls)
+	if err != nil {
+		return err
+	}
+
+	var dividedReplicas map[string][]appsapi.FeedReplicas
+	if sub.Spec.SchedulingStrategy == appsapi.DividingSchedulingStrategyType {
+		dividedReplicas, err = deployer.getDividedReplicas(sub, mcls, allExistingBases)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Bases to be deleted
 	basesToBeDeleted := sets.String{}
 	for _, base := range allExistingBases {
@@ -316,6 +598,11 @@ func (deployer *Deployer) populateBases(sub *appsapi.Subscription) error {
 
 	var allErrs []error
 	for _, cluster := range mcls {
+		if err := deployer.preemptBases(sub, state.Victims[cluster.Namespace]); err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+
 		base := &appsapi.Base{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      sub.Name,
@@ -339,7 +626,8 @@ func (deployer *Deployer) populateBases(sub *appsapi.Subscription) error {
 				// Base and Subscription are in different namespaces
 			},
 			Spec: appsapi.BaseSpec{
-				Feeds: sub.Spec.Feeds,
+				Feeds:    sub.Spec.Feeds,
+				Replicas: dividedReplicas[cluster.Namespace],
 			},
 		}
 
@@ -360,6 +648,9 @@ func (deployer *Deployer) populateBases(sub *appsapi.Subscription) error {
 		}
 	}
 
+	if change != nil {
+		deployer.notifyPlacementChange(sub, change)
+	}
 	return utilerrors.NewAggregate(allErrs)
 }
 
@@ -474,6 +765,19 @@ func (deployer *Deployer) handleBase(base *appsapi.Base) error {
 		return err
 	}
 
+	if deployer.residencyLister != nil {
+		if err := deployer.admitBase(base); err != nil {
+			deployer.recorder.Event(base, corev1.EventTypeWarning, "ResidencyViolation", err.Error())
+			return err
+		}
+	}
+	if deployer.tenantLister != nil {
+		if err := deployer.admitTenancy(base); err != nil {
+			deployer.recorder.Event(base, corev1.EventTypeWarning, "TenantIsolationViolation", err.Error())
+			return err
+		}
+	}
+
 	// add label (baseUID="Base") to referred Manifest/HelmChart
 	if err := deployer.addLabelsToReferredFeeds(base); err != nil {
 		return err
@@ -508,6 +812,11 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 				deployer.recorder.Event(base, corev1.EventTypeWarning, "VerifyingHelmChart", msg)
 				return fmt.Errorf(msg)
 			}
+			if chart.Status.Phase == appsapi.HelmChartUnverified {
+				deployer.recorder.Event(base, corev1.EventTypeWarning, "HelmChartUnverified",
+					fmt.Sprintf("signatures of helm chart %s fail to be verified", klog.KObj(chart)))
+				return nil
+			}
 			if chart.Status.Phase != appsapi.HelmChartFound {
 				deployer.recorder.Event(base, corev1.EventTypeWarning, "HelmChartNotFound",
 					fmt.Sprintf("helm chart %s is not found", klog.KObj(chart)))
@@ -523,6 +832,13 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 			if err != nil {
 				break
 			}
+			manifests = excludeSupersededManifests(manifests)
+			if replicas, ok := getFeedReplicas(base.Spec.Replicas, feed); ok {
+				manifests, err = withDividedReplicas(manifests, replicas)
+				if err != nil {
+					break
+				}
+			}
 			allManifests = append(allManifests, manifests...)
 			if manifests == nil {
 				err = apierrors.NewNotFound(schema.GroupResource{}, "")
@@ -557,6 +873,21 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 	if err != nil {
 		return err
 	}
+	revision, blocked, err := deployer.checkRollout(base, allExistingDescriptions)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		// keep existing Descriptions until the cluster gets its turn in the rollout
+		klog.V(5).Infof("Base %s is waiting for the rollout of revision %s", klog.KObj(base), revision)
+		return nil
+	}
+	if len(revision) > 0 {
+		if err := deployer.saveDescriptionRevisions(base, allExistingDescriptions, revision); err != nil {
+			return err
+		}
+	}
+
 	// Descriptions to be deleted
 	descsToBeDeleted := sets.String{}
 	for _, desc := range allExistingDescriptions {
@@ -594,8 +925,16 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 			},
 		},
 	}
+	if len(revision) > 0 {
+		descTemplate.Labels[known.RolloutRevisionLabel] = revision
+	}
+	descTemplate.Spec.DeletionPolicy, err = deployer.getDeletionPolicy(base)
+	if err != nil {
+		return err
+	}
 
 	var allErrs []error
+	var overridesFailures []string
 	if len(allChartRefs) > 0 {
 		desc := descTemplate.DeepCopy()
 		desc.Name = fmt.Sprintf("%s-helm", base.Name)
@@ -605,6 +944,9 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 		err := deployer.syncDescriptions(base, desc)
 		if err != nil {
 			allErrs = append(allErrs, err)
+			if oerr, ok := err.(*overridesError); ok {
+				overridesFailures = append(overridesFailures, oerr.message)
+			}
 			msg := fmt.Sprintf("Failed to sync Description %s: %v", klog.KObj(desc), err)
 			klog.ErrorDepth(5, msg)
 			deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedSyncingDescription", msg)
@@ -612,23 +954,50 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 		descsToBeDeleted.Delete(klog.KObj(desc).String())
 	}
 
-	if len(allManifests) > 0 {
+	if len(allManifests) > 0 && !utils.IsJobsFinished(base) {
 		var rawObjects [][]byte
 		for _, manifest := range allManifests {
 			rawObjects = append(rawObjects, manifest.Template.Raw)
 		}
-		desc := descTemplate.DeepCopy()
-		desc.Name = fmt.Sprintf("%s-generic", base.Name)
-		desc.Spec.Deployer = appsapi.DescriptionGenericDeployer
-		desc.Spec.Raw = rawObjects
-		err := deployer.syncDescriptions(base, desc)
+
+		// split oversized bundles into multiple Descriptions, named with suffixes "-generic", "-generic-1", ...
+		statusAggregations, err := deployer.findStatusAggregations(base)
 		if err != nil {
-			allErrs = append(allErrs, err)
-			msg := fmt.Sprintf("Failed to sync Description %s: %v", klog.KObj(desc), err)
+			return err
+		}
+
+		chunks, err := utils.SplitRawObjects(rawObjects, deployer.maxManifestsPerDescription, deployer.maxDescriptionBytes)
+		if err != nil {
+			msg := fmt.Sprintf("Base %s is too large to be populated: %v", klog.KObj(base), err)
 			klog.ErrorDepth(5, msg)
-			deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedSyncingDescription", msg)
+			deployer.recorder.Event(base, corev1.EventTypeWarning, "DescriptionTooLarge", msg)
+			// keep existing Descriptions as they are
+			return errors.New(msg)
+		}
+		if len(chunks) > 1 {
+			klog.V(4).Infof("splitting %d manifests of Base %s into %d Descriptions", len(rawObjects), klog.KObj(base), len(chunks))
+		}
+		for idx, chunk := range chunks {
+			desc := descTemplate.DeepCopy()
+			desc.Name = fmt.Sprintf("%s-generic", base.Name)
+			if idx > 0 {
+				desc.Name = fmt.Sprintf("%s-generic-%d", base.Name, idx)
+			}
+			desc.Spec.Deployer = appsapi.DescriptionGenericDeployer
+			desc.Spec.Raw = chunk
+			desc.Spec.StatusAggregations = statusAggregations
+			err := deployer.syncDescriptions(base, desc)
+			if err != nil {
+				allErrs = append(allErrs, err)
+				if oerr, ok := err.(*overridesError); ok {
+					overridesFailures = append(overridesFailures, oerr.message)
+				}
+				msg := fmt.Sprintf("Failed to sync Description %s: %v", klog.KObj(desc), err)
+				klog.ErrorDepth(5, msg)
+				deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedSyncingDescription", msg)
+			}
+			descsToBeDeleted.Delete(klog.KObj(desc).String())
 		}
-		descsToBeDeleted.Delete(klog.KObj(desc).String())
 	}
 
 	for key := range descsToBeDeleted {
@@ -638,6 +1007,10 @@ func (deployer *Deployer) populateDescriptions(base *appsapi.Base) error {
 		}
 	}
 
+	if err := deployer.setOverridesFailure(base, strings.Join(overridesFailures, "; ")); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
 	return utilerrors.NewAggregate(allErrs)
 }
 
@@ -647,49 +1020,124 @@ func (deployer *Deployer) syncDescriptions(base *appsapi.Base, description *apps
 		msg := fmt.Sprintf("Failed to apply overrides for Description %s: %v", klog.KObj(description), err)
 		klog.ErrorDepth(5, msg)
 		deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedApplyingOverrides", msg)
+		return &overridesError{message: msg}
+	}
+
+	if utilfeature.DefaultFeatureGate.Enabled(features.ClusterIdentityInjection) {
+		if err := deployer.injectClusterIdentity(description); err != nil {
+			msg := fmt.Sprintf("Failed to inject cluster identity into Description %s: %v", klog.KObj(description), err)
+			klog.ErrorDepth(5, msg)
+			deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedInjectingClusterIdentity", msg)
+			return err
+		}
+	}
+
+	if err := deployer.rewriteImageRegistries(description); err != nil {
+		msg := fmt.Sprintf("Failed to rewrite image registries of Description %s: %v", klog.KObj(description), err)
+		klog.ErrorDepth(5, msg)
+		deployer.recorder.Event(base, corev1.EventTypeWarning, "FailedRewritingImageRegistries", msg)
 		return err
 	}
 
+	// overrides may enlarge the objects
+	if size := utils.GetRawObjectsSize(description.Spec.Raw); deployer.maxDescriptionBytes > 0 && size > deployer.maxDescriptionBytes {
+		msg := fmt.Sprintf("Description %s is %d bytes after applying overrides, which exceeds the limit of %d bytes, "+
+			"please reduce the size of the overrides or the manifests", klog.KObj(description), size, deployer.maxDescriptionBytes)
+		klog.ErrorDepth(5, msg)
+		deployer.recorder.Event(base, corev1.EventTypeWarning, "DescriptionTooLarge", msg)
+		return errors.New(msg)
+	}
+
+	approval, err := deployer.getApprovalStrategy(base)
+	if err != nil {
+		return err
+	}
+	if approval != nil {
+		if description.Annotations == nil {
+			description.Annotations = make(map[string]string)
+		}
+		description.Annotations[known.ApprovalRequiredAnnotation] = "true"
+	}
+
 	desc, err := deployer.descLister.Descriptions(description.Namespace).Get(description.Name)
 	if err == nil {
 		if desc.DeletionTimestamp != nil {
 			return fmt.Errorf("Description %s is deleting, will resync later", klog.KObj(desc))
 		}
 
+		// keep the Description rolled back until the spec changes
+		if failedSpec, ok := desc.Annotations[known.FailedSpecAnnotation]; ok {
+			if hash, err := utils.HashDescriptionSpec(description.Spec); err == nil && hash == failedSpec {
+				return nil
+			}
+		}
+
 		// update it
-		if !reflect.DeepEqual(desc.Spec, description.Spec) {
+		if !reflect.DeepEqual(desc.Spec, description.Spec) ||
+			desc.Labels[known.RolloutRevisionLabel] != description.Labels[known.RolloutRevisionLabel] ||
+			desc.Annotations[known.ApprovalRequiredAnnotation] != description.Annotations[known.ApprovalRequiredAnnotation] {
+			desc = desc.DeepCopy()
+			oldSpec := desc.Spec.DeepCopy()
 			if desc.Labels == nil {
 				desc.Labels = make(map[string]string)
 			}
 			for key, value := range description.Labels {
 				desc.Labels[key] = value
 			}
+			if _, ok := description.Labels[known.RolloutRevisionLabel]; !ok {
+				delete(desc.Labels, known.RolloutRevisionLabel)
+			}
+			delete(desc.Annotations, known.FailedSpecAnnotation)
+			if approval != nil {
+				if desc.Annotations == nil {
+					desc.Annotations = make(map[string]string)
+				}
+				desc.Annotations[known.ApprovalRequiredAnnotation] = "true"
+			} else {
+				delete(desc.Annotations, known.ApprovalRequiredAnnotation)
+			}
+
+			prune, err := deployer.getPruneStrategy(base)
+			if err != nil {
+				return err
+			}
+			if err = setPrunedObjects(desc, description.Spec.Raw, prune); err != nil {
+				return err
+			}
 
 			desc.Spec = description.Spec
 			if !utils.ContainsString(desc.Finalizers, known.AppFinalizer) {
 				desc.Finalizers = append(desc.Finalizers, known.AppFinalizer)
 			}
 
-			_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(),
+			desc, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(),
 				desc, metav1.UpdateOptions{})
-			if err == nil {
-				msg := fmt.Sprintf("Description %s is updated successfully", klog.KObj(description))
-				klog.V(4).Info(msg)
-				deployer.recorder.Event(base, corev1.EventTypeNormal, "DescriptionUpdated", msg)
+			if err != nil {
+				return err
 			}
-			return err
+			msg := fmt.Sprintf("Description %s is updated successfully", klog.KObj(description))
+			klog.V(4).Info(msg)
+			deployer.recorder.Event(base, corev1.EventTypeNormal, "DescriptionUpdated", msg)
+			if approval != nil {
+				return deployer.requestApproval(base, approval, oldSpec, desc)
+			}
+			return nil
 		}
 		return nil
 	}
 
-	_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(description.Namespace).Create(context.TODO(),
+	desc, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(description.Namespace).Create(context.TODO(),
 		description, metav1.CreateOptions{})
-	if err == nil {
-		msg := fmt.Sprintf("Description %s is created successfully", klog.KObj(description))
-		klog.V(4).Info(msg)
-		deployer.recorder.Event(base, corev1.EventTypeNormal, "DescriptionCreated", msg)
+	if err != nil {
+		return err
 	}
-	return err
+	msg := fmt.Sprintf("Description %s is created successfully", klog.KObj(description))
+	klog.V(4).Info(msg)
+	deployer.recorder.Event(base, corev1.EventTypeNormal, "DescriptionCreated", msg)
+	if approval != nil {
+		return deployer.requestApproval(base, approval, nil, desc)
+	}
+	return nil
 }
 
 func (deployer *Deployer) deleteDescription(ctx context.Context, namespacedKey string) error {
@@ -712,7 +1160,13 @@ func (deployer *Deployer) deleteDescription(ctx context.Context, namespacedKey s
 func (deployer *Deployer) handleManifest(manifest *appsapi.Manifest) error {
 	klog.V(5).Infof("handle Manifest %s", klog.KObj(manifest))
 	if manifest.DeletionTimestamp != nil {
-		if err := deployer.protectManifestFeed(manifest); err != nil {
+		if deployer.isManifestSuperseded(manifest) {
+			// the feed is still backed by other Manifests, e.g. when a stale Manifest is pruned from
+			// a re-rendered Kustomization, so we only drop this Manifest from the Descriptions
+			if err := deployer.populateDescriptionsForManifest(manifest); err != nil {
+				return err
+			}
+		} else if err := deployer.protectManifestFeed(manifest); err != nil {
 			return err
 		}
 
@@ -730,6 +1184,11 @@ func (deployer *Deployer) handleManifest(manifest *appsapi.Manifest) error {
 		return err
 	}
 
+	return deployer.populateDescriptionsForManifest(manifest)
+}
+
+// populateDescriptionsForManifest populates the Descriptions of all the Bases referring the Manifest
+func (deployer *Deployer) populateDescriptionsForManifest(manifest *appsapi.Manifest) error {
 	// find all referred Base UIDs
 	var baseUIDs []string
 	for key, val := range manifest.Labels {
@@ -756,6 +1215,8 @@ func (deployer *Deployer) handleManifest(manifest *appsapi.Manifest) error {
 				return
 			}
 			// here the length should always be 1
+			deployer.enqueueDividingSubscription(bases[0])
+			deployer.enqueueSubscriptionForFeeds(bases[0])
 			if err := deployer.populateDescriptions(bases[0]); err != nil {
 				errCh <- err
 			}
@@ -773,6 +1234,40 @@ func (deployer *Deployer) handleManifest(manifest *appsapi.Manifest) error {
 	return utilerrors.NewAggregate(allErrs)
 }
 
+// isManifestSuperseded tells whether the feed of a Manifest being deleted is still backed by other Manifests
+func (deployer *Deployer) isManifestSuperseded(manifest *appsapi.Manifest) bool {
+	manifests, err := deployer.mfstLister.Manifests(manifest.Namespace).List(labels.SelectorFromSet(labels.Set{
+		known.ConfigGroupLabel:     manifest.Labels[known.ConfigGroupLabel],
+		known.ConfigVersionLabel:   manifest.Labels[known.ConfigVersionLabel],
+		known.ConfigKindLabel:      manifest.Labels[known.ConfigKindLabel],
+		known.ConfigNameLabel:      manifest.Labels[known.ConfigNameLabel],
+		known.ConfigNamespaceLabel: manifest.Labels[known.ConfigNamespaceLabel],
+	}))
+	if err != nil {
+		return false
+	}
+	for _, m := range manifests {
+		if m.Name != manifest.Name && m.DeletionTimestamp == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeSupersededManifests drops the Manifests being deleted when the feed is still backed by other Manifests
+func excludeSupersededManifests(manifests []*appsapi.Manifest) []*appsapi.Manifest {
+	var live []*appsapi.Manifest
+	for _, manifest := range manifests {
+		if manifest.DeletionTimestamp == nil {
+			live = append(live, manifest)
+		}
+	}
+	if len(live) == 0 {
+		return manifests
+	}
+	return live
+}
+
 func (deployer *Deployer) addLabelsToReferredFeeds(b *appsapi.Base) error {
 	var allHelmCharts []*appsapi.HelmChart
 	var allManifests []*appsapi.Manifest
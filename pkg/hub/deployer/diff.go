@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+// Diff returns the differences between the manifests of Description namespace/name and the live objects in
+// the child cluster, which are retrieved through the agent when the cluster is connected with sockets.
+func (deployer *Deployer) Diff(ctx context.Context, namespace, name string) (*proxiesapi.DescriptionDiff, error) {
+	desc, err := deployer.descLister.Descriptions(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if desc.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("Description %s uses deployer %s, only %s Descriptions could be compared",
+			klog.KObj(desc), desc.Spec.Deployer, appsapi.DescriptionGenericDeployer))
+	}
+
+	items, err := deployer.genericDeployer.Diff(ctx, desc)
+	if err != nil {
+		return nil, apierrors.NewServiceUnavailable(fmt.Sprintf("failed to compare Description %s with the child cluster: %v",
+			klog.KObj(desc), err))
+	}
+	return &proxiesapi.DescriptionDiff{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      desc.Name,
+			Namespace: desc.Namespace,
+			Labels:    desc.Labels,
+		},
+		Items: items,
+	}, nil
+}
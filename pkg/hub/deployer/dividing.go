@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// getDividedReplicas divides the replicas of the workloads in the feeds across the clusters, keyed by the namespaces
+// of the clusters. The replicas are divided by the weights of subscribers, or by the available resources of clusters
+// with Dynamic dividing scheduling. Feeds without replicas, such as ConfigMaps and HelmCharts, are still deployed to
+// every cluster as a full copy.
+//
+// Clusters with the unhealthy taint not tolerated by the Subscription get no replicas, which fail over to the other
+// healthy clusters, unless none of the clusters are healthy.
+func (deployer *Deployer) getDividedReplicas(sub *appsapi.Subscription, mcls []*clusterapi.ManagedCluster,
+	existingBases []*appsapi.Base) (map[string][]appsapi.FeedReplicas, error) {
+	healthy, unhealthy := splitUnhealthyClusters(sub, mcls)
+	weights := getClusterWeights(sub, healthy)
+	divided := map[string][]appsapi.FeedReplicas{}
+	for _, feed := range sub.Spec.Feeds {
+		if feed.Kind == helmChartKind.Kind {
+			continue
+		}
+		manifests, err := utils.ListManifestsBySelector(deployer.mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		// nonexistent feeds are reported when populating Descriptions
+		if len(manifests) == 0 {
+			continue
+		}
+		total, found, err := interpreter.GetReplicas(manifests[0].Template.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replicas of %s: %v", utils.FormatFeed(feed), err)
+		}
+		if !found {
+			continue
+		}
+		if isDynamicDividing(sub) {
+			weights, err = getAvailableWeights(feed, manifests[0], healthy, existingBases)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get resource requests of %s: %v", utils.FormatFeed(feed), err)
+			}
+		}
+
+		dividedByCluster := utils.DivideReplicas(total, weights)
+		for _, cluster := range unhealthy {
+			dividedByCluster[cluster.Namespace] = 0
+		}
+		for namespace, replicas := range dividedByCluster {
+			divided[namespace] = append(divided[namespace], appsapi.FeedReplicas{
+				Kind:       feed.Kind,
+				APIVersion: feed.APIVersion,
+				Namespace:  feed.Namespace,
+				Name:       feed.Name,
+				Replicas:   replicas,
+			})
+		}
+		klog.V(5).Infof("divide %d replicas of %s in Subscription %s by weights %v",
+			total, utils.FormatFeed(feed), klog.KObj(sub), weights)
+	}
+	deployer.recordFailover(sub, unhealthy, existingBases)
+	return divided, nil
+}
+
+// splitUnhealthyClusters splits out the clusters with the unhealthy taint not tolerated by the Subscription.
+// All the clusters are taken as healthy if none of them are, so that the replicas are not dropped.
+func splitUnhealthyClusters(sub *appsapi.Subscription, mcls []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster,
+	[]*clusterapi.ManagedCluster) {
+	var healthy, unhealthy []*clusterapi.ManagedCluster
+	for _, cluster := range mcls {
+		if isUnhealthy(cluster, sub.Spec.Tolerations) {
+			unhealthy = append(unhealthy, cluster)
+			continue
+		}
+		healthy = append(healthy, cluster)
+	}
+	if len(healthy) == 0 {
+		return mcls, nil
+	}
+	return healthy, unhealthy
+}
+
+func isUnhealthy(cluster *clusterapi.ManagedCluster, tolerations []corev1.Toleration) bool {
+	for i := range cluster.Spec.Taints {
+		taint := &cluster.Spec.Taints[i]
+		if taint.Key == known.TaintClusterUnhealthy && !utils.TolerationsTolerateTaint(tolerations, taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordFailover records events for the unhealthy clusters still holding replicas, which are migrated away.
+func (deployer *Deployer) recordFailover(sub *appsapi.Subscription, unhealthy []*clusterapi.ManagedCluster,
+	existingBases []*appsapi.Base) {
+	failedOver := sets.NewString()
+	for _, cluster := range unhealthy {
+		failedOver.Insert(cluster.Namespace)
+	}
+	for _, base := range existingBases {
+		if !failedOver.Has(base.Namespace) {
+			continue
+		}
+		for _, replicas := range base.Spec.Replicas {
+			if replicas.Replicas > 0 {
+				deployer.recorder.Event(sub, corev1.EventTypeWarning, "ReplicasFailover",
+					fmt.Sprintf("Migrate replicas of Base %s away from its unhealthy cluster", klog.KObj(base)))
+				break
+			}
+		}
+	}
+}
+
+// getClusterWeights returns the weights of the clusters keyed by their namespaces.
+// A cluster matched by multiple subscribers takes the weight of the first one.
+func getClusterWeights(sub *appsapi.Subscription, mcls []*clusterapi.ManagedCluster) map[string]int64 {
+	weights := map[string]int64{}
+	for _, cluster := range mcls {
+		if _, ok := weights[cluster.Namespace]; ok {
+			continue
+		}
+		for _, subscriber := range sub.Spec.Subscribers {
+			selector, err := metav1.LabelSelectorAsSelector(subscriber.ClusterAffinity)
+			if err != nil {
+				continue
+			}
+			if selector.Matches(labels.Set(cluster.Labels)) {
+				weights[cluster.Namespace] = int64(subscriber.Weight)
+				break
+			}
+		}
+	}
+	return weights
+}
+
+// getAvailableWeights returns how many replicas of the workload each cluster could hold with its available resources,
+// keyed by the namespaces of the clusters. Since the requests of the replicas already divided to a cluster have been
+// deducted from its available resources, these replicas are counted in, otherwise replicas would swing between
+// clusters on every re-dividing.
+func getAvailableWeights(feed appsapi.Feed, manifest *appsapi.Manifest, mcls []*clusterapi.ManagedCluster,
+	existingBases []*appsapi.Base) (map[string]int64, error) {
+	requests, err := utils.GetReplicaRequests(manifest.Template.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[string]int64{}
+	for _, base := range existingBases {
+		if replicas, ok := getFeedReplicas(base.Spec.Replicas, feed); ok {
+			current[base.Namespace] = int64(replicas)
+		}
+	}
+
+	weights := map[string]int64{}
+	for _, cluster := range mcls {
+		available := corev1.ResourceList{}
+		for name, quantity := range cluster.Status.Available {
+			available[name] = quantity.DeepCopy()
+		}
+		for name, request := range requests {
+			quantity := available[name]
+			quantity.Add(*resource.NewMilliQuantity(request.MilliValue()*current[cluster.Namespace], request.Format))
+			available[name] = quantity
+		}
+		weights[cluster.Namespace] = utils.EstimateReplicas(available, requests)
+	}
+	return weights, nil
+}
+
+func isDynamicDividing(sub *appsapi.Subscription) bool {
+	return sub.Spec.SchedulingStrategy == appsapi.DividingSchedulingStrategyType && sub.Spec.DividingScheduling != nil &&
+		sub.Spec.DividingScheduling.Type == appsapi.DynamicDividingSchedulingType
+}
+
+// getFeedReplicas returns the replicas divided to the cluster for the feed.
+func getFeedReplicas(replicas []appsapi.FeedReplicas, feed appsapi.Feed) (int32, bool) {
+	for _, r := range replicas {
+		if r.Kind == feed.Kind && r.APIVersion == feed.APIVersion && r.Namespace == feed.Namespace && r.Name == feed.Name {
+			return r.Replicas, true
+		}
+	}
+	return 0, false
+}
+
+// withDividedReplicas returns copies of the Manifests with the divided replicas.
+func withDividedReplicas(manifests []*appsapi.Manifest, replicas int32) ([]*appsapi.Manifest, error) {
+	var result []*appsapi.Manifest
+	for _, manifest := range manifests {
+		raw, err := utils.SetReplicas(manifest.Template.Raw, replicas)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set replicas of Manifest %s: %v", klog.KObj(manifest), err)
+		}
+		manifest = manifest.DeepCopy()
+		manifest.Template.Raw = raw
+		result = append(result, manifest)
+	}
+	return result, nil
+}
+
+// enqueueDividingSubscription re-divides the replicas of the Subscription that populates the Base,
+// since the total replicas in the feeds may change.
+func (deployer *Deployer) enqueueDividingSubscription(base *appsapi.Base) {
+	if len(base.Spec.Replicas) == 0 {
+		return
+	}
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		klog.V(5).Infof("failed to get Subscription of Base %s: %v", klog.KObj(base), err)
+		return
+	}
+	deployer.subsController.EnqueueAfter(sub, 0)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+var baseKind = appsapi.SchemeGroupVersion.WithKind("Base")
+
+// getFeedDependencies returns the dependencies declared in the Base of the Description, keyed by feedKey.
+func (deployer *Deployer) getFeedDependencies(desc *appsapi.Description) (map[string][]appsapi.FeedDependency, error) {
+	if desc.Labels[known.ConfigKindLabel] != baseKind.Kind {
+		return nil, nil
+	}
+	base, err := deployer.baseLister.Bases(desc.Namespace).Get(desc.Labels[known.ConfigNameLabel])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	dependencies := map[string][]appsapi.FeedDependency{}
+	for _, feed := range base.Spec.Feeds {
+		if len(feed.DependsOn) == 0 {
+			continue
+		}
+		key := feedKey(feed.APIVersion, feed.Kind, feed.Namespace, feed.Name)
+		dependencies[key] = append(dependencies[key], feed.DependsOn...)
+	}
+	return dependencies, nil
+}
+
+// checkDependencies returns the dependencies that are not ready yet in the child cluster.
+func (deployer *Deployer) checkDependencies(dynamicClient dynamic.Interface, restMapper meta.RESTMapper,
+	dependencies []appsapi.FeedDependency) ([]string, error) {
+	var unready []string
+	for _, dependency := range dependencies {
+		if dependency.ClusterSelector != nil {
+			ready, err := deployer.isReadyInClusters(dependency)
+			if err != nil {
+				return nil, err
+			}
+			if !ready {
+				unready = append(unready, fmt.Sprintf("%s in clusters %q", formatDependency(dependency),
+					metav1.FormatLabelSelector(dependency.ClusterSelector)))
+			}
+			continue
+		}
+
+		gv, err := schema.ParseGroupVersion(dependency.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		restMapping, err := restMapper.RESTMapping(gv.WithKind(dependency.Kind).GroupKind(), gv.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		obj, err := dynamicClient.Resource(restMapping.Resource).Namespace(dependency.Namespace).
+			Get(context.TODO(), dependency.Name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err != nil {
+			unready = append(unready, formatDependency(dependency))
+			continue
+		}
+		ready, err := isObjectReady(obj, dependency.ConditionType)
+		if err != nil {
+			return nil, err
+		}
+		if !ready {
+			unready = append(unready, formatDependency(dependency))
+		}
+	}
+	return unready, nil
+}
+
+// isReadyInClusters tells whether the depended object is reported healthy in all the clusters matching
+// its ClusterSelector, by the feedback in the status of the Descriptions deployed to those clusters.
+func (deployer *Deployer) isReadyInClusters(dependency appsapi.FeedDependency) (bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(dependency.ClusterSelector)
+	if err != nil {
+		return false, err
+	}
+	clusters, err := deployer.clusterLister.List(selector)
+	if err != nil {
+		return false, err
+	}
+	if len(clusters) == 0 {
+		return false, nil
+	}
+	for _, cluster := range clusters {
+		descs, err := deployer.descLister.Descriptions(cluster.Namespace).List(labels.Everything())
+		if err != nil {
+			return false, err
+		}
+		if !isReportedHealthy(descs, dependency) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// isReportedHealthy tells whether the depended object is reported healthy in the feedback of any Description.
+func isReportedHealthy(descs []*appsapi.Description, dependency appsapi.FeedDependency) bool {
+	for _, desc := range descs {
+		if desc.DeletionTimestamp != nil {
+			continue
+		}
+		for _, feedback := range desc.Status.Resources {
+			if feedback.APIVersion == dependency.APIVersion && feedback.Kind == dependency.Kind &&
+				feedback.Namespace == dependency.Namespace && feedback.Name == dependency.Name && feedback.Healthy {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isObjectReady evaluates the readiness of the object with the given condition type,
+// or by the interpreter of its kind if conditionType is empty.
+func isObjectReady(obj *unstructured.Unstructured, conditionType string) (bool, error) {
+	if len(conditionType) > 0 {
+		return interpreter.IsConditionTrue(obj, conditionType), nil
+	}
+	healthy, _, err := interpreter.InterpretHealth(obj)
+	return healthy, err
+}
+
+func feedKey(apiVersion, kind, namespace, name string) string {
+	return strings.Join([]string{apiVersion, kind, namespace, name}, "/")
+}
+
+func formatDependency(dependency appsapi.FeedDependency) string {
+	namespacedName := dependency.Name
+	if len(dependency.Namespace) > 0 {
+		namespacedName = fmt.Sprintf("%s/%s", dependency.Namespace, dependency.Name)
+	}
+	return fmt.Sprintf("%s %s", dependency.Kind, namespacedName)
+}
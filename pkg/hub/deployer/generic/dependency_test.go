@@ -0,0 +1,179 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestIsObjectReady(t *testing.T) {
+	tests := []struct {
+		name          string
+		obj           map[string]interface{}
+		conditionType string
+		want          bool
+	}{
+		{
+			name: "ready statefulset",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"metadata":   map[string]interface{}{"generation": int64(2)},
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+				"status":     map[string]interface{}{"observedGeneration": int64(2), "readyReplicas": int64(3)},
+			},
+			want: true,
+		},
+		{
+			name: "statefulset with stale status",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"metadata":   map[string]interface{}{"generation": int64(3)},
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+				"status":     map[string]interface{}{"observedGeneration": int64(2), "readyReplicas": int64(3)},
+			},
+			want: false,
+		},
+		{
+			name: "deployment not available",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"spec":       map[string]interface{}{"replicas": int64(2)},
+				"status":     map[string]interface{}{"updatedReplicas": int64(2), "availableReplicas": int64(1)},
+			},
+			want: false,
+		},
+		{
+			name: "completed job",
+			obj: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Complete", "status": "True"},
+				}},
+			},
+			want: true,
+		},
+		{
+			name: "configmap without conditions",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+			want: true,
+		},
+		{
+			name: "custom condition type",
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True"},
+					map[string]interface{}{"type": "Migrated", "status": "False"},
+				}},
+			},
+			conditionType: "Migrated",
+			want:          false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isObjectReady(&unstructured.Unstructured{Object: tt.obj}, tt.conditionType)
+			if err != nil {
+				t.Fatalf("isObjectReady() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isObjectReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsReportedHealthy(t *testing.T) {
+	dependency := appsapi.FeedDependency{
+		APIVersion: "apps/v1",
+		Kind:       "StatefulSet",
+		Namespace:  "db",
+		Name:       "mysql",
+	}
+	feedback := func(name string, healthy bool) appsapi.ResourceFeedback {
+		return appsapi.ResourceFeedback{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Namespace:  "db",
+			Name:       name,
+			Applied:    true,
+			Healthy:    healthy,
+		}
+	}
+	now := metav1.Now()
+
+	tests := []struct {
+		name  string
+		descs []*appsapi.Description
+		want  bool
+	}{
+		{
+			name: "no feedback",
+			descs: []*appsapi.Description{
+				{},
+			},
+			want: false,
+		},
+		{
+			name: "reported healthy",
+			descs: []*appsapi.Description{
+				{Status: appsapi.DescriptionStatus{Resources: []appsapi.ResourceFeedback{feedback("redis", true)}}},
+				{Status: appsapi.DescriptionStatus{Resources: []appsapi.ResourceFeedback{feedback("mysql", true)}}},
+			},
+			want: true,
+		},
+		{
+			name: "reported unhealthy",
+			descs: []*appsapi.Description{
+				{Status: appsapi.DescriptionStatus{Resources: []appsapi.ResourceFeedback{feedback("mysql", false)}}},
+			},
+			want: false,
+		},
+		{
+			name: "Description being deleted",
+			descs: []*appsapi.Description{
+				{
+					ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+					Status:     appsapi.DescriptionStatus{Resources: []appsapi.ResourceFeedback{feedback("mysql", true)}},
+				},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReportedHealthy(tt.descs, dependency); got != tt.want {
+				t.Errorf("isReportedHealthy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
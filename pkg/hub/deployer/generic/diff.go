@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Diff compares the manifests of a generic Description with the live objects in the child cluster,
+// without changing anything. Failures of single objects are reported in their ObjectDiffs.
+func (deployer *Deployer) Diff(ctx context.Context, desc *appsapi.Description) ([]proxiesapi.ObjectDiff, error) {
+	dynamicClient, restMapper, err := deployer.getDynamicClient(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []proxiesapi.ObjectDiff
+	for _, object := range desc.Spec.Raw {
+		resource := &unstructured.Unstructured{}
+		if err := resource.UnmarshalJSON(object); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal resource: %v", err)
+		}
+		diff := proxiesapi.ObjectDiff{
+			APIVersion: resource.GetAPIVersion(),
+			Kind:       resource.GetKind(),
+			Namespace:  resource.GetNamespace(),
+			Name:       resource.GetName(),
+		}
+
+		restMapping, err := restMapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
+		if err != nil {
+			diff.Action = proxiesapi.DiffActionUnknown
+			diff.Error = err.Error()
+			diffs = append(diffs, diff)
+			continue
+		}
+		live, err := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
+			Get(ctx, resource.GetName(), metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			diff.Action = proxiesapi.DiffActionCreate
+		case err != nil:
+			diff.Action = proxiesapi.DiffActionUnknown
+			diff.Error = err.Error()
+		default:
+			diff.Fields, err = diffFields(resource, live)
+			if err != nil {
+				return nil, err
+			}
+			diff.Action = proxiesapi.DiffActionNone
+			if len(diff.Fields) > 0 {
+				diff.Action = proxiesapi.DiffActionUpdate
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+// diffFields returns the fields of the live object that differ from the desired ones, with JSON encoded values.
+func diffFields(desired, live *unstructured.Unstructured) ([]proxiesapi.FieldDiff, error) {
+	var fields []proxiesapi.FieldDiff
+	for _, drifted := range utils.DiffObjects(desired.Object, live.Object, 0) {
+		field := proxiesapi.FieldDiff{Path: drifted.Path}
+		if drifted.Live != nil {
+			liveValue, err := json.Marshal(drifted.Live)
+			if err != nil {
+				return nil, err
+			}
+			field.Live = string(liveValue)
+		}
+		if drifted.Desired != nil {
+			desiredValue, err := json.Marshal(drifted.Desired)
+			if err != nil {
+				return nil, err
+			}
+			field.Desired = string(desiredValue)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
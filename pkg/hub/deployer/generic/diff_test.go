@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+func TestDiffFields(t *testing.T) {
+	desired := &unstructured.Unstructured{}
+	if err := desired.UnmarshalJSON([]byte(`{
+  "apiVersion": "v1",
+  "kind": "Service",
+  "metadata": {"name": "nginx", "namespace": "foo", "labels": {"app": "nginx", "tier": "web"}},
+  "spec": {"type": "NodePort", "ports": [{"port": 80}]}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+	live := &unstructured.Unstructured{}
+	if err := live.UnmarshalJSON([]byte(`{
+  "apiVersion": "v1",
+  "kind": "Service",
+  "metadata": {"name": "nginx", "namespace": "foo", "labels": {"app": "nginx"}},
+  "spec": {"type": "ClusterIP", "clusterIP": "10.0.0.1", "ports": [{"port": 80, "protocol": "TCP"}]}
+}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []proxiesapi.FieldDiff{
+		{Path: "metadata.labels.tier", Desired: `"web"`},
+		{Path: "spec.type", Live: `"ClusterIP"`, Desired: `"NodePort"`},
+	}
+	got, err := diffFields(desired, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffFields() = %v, want %v", got, want)
+	}
+}
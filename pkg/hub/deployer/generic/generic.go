@@ -29,6 +29,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	cacheddiscovery "k8s.io/client-go/discovery/cached/memory"
@@ -48,13 +50,19 @@ import (
 	"github.com/clusternet/clusternet/pkg/controllers/apps/description"
 	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
 	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
 	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
 const (
 	defaultRetries = 3
+
+	// maxApplyAttempts bounds the attempts of resolving conflicts and immutable fields in one apply
+	maxApplyAttempts = 3
 )
 
 type Deployer struct {
@@ -62,6 +70,12 @@ type Deployer struct {
 
 	clusterLister clusterlisters.ManagedClusterLister
 	clusterSynced cache.InformerSynced
+	baseLister    applisters.BaseLister
+	baseSynced    cache.InformerSynced
+	subLister     applisters.SubscriptionLister
+	subSynced     cache.InformerSynced
+	descLister    applisters.DescriptionLister
+	descSynced    cache.InformerSynced
 	secretLister  corev1lister.SecretLister
 	secretSynced  cache.InformerSynced
 
@@ -69,21 +83,30 @@ type Deployer struct {
 
 	descController *description.Controller
 
-	recorder record.EventRecorder
+	recorder          record.EventRecorder
+	lifecycleRecorder *events.Recorder
 }
 
 func NewDeployer(ctx context.Context, clusternetClient *clusternetclientset.Clientset,
 	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory,
-	recorder record.EventRecorder) (*Deployer, error) {
+	recorder record.EventRecorder, lifecycleRecorder *events.Recorder, rateLimitOptions description.RateLimitOptions,
+	sharder *sharding.Sharder) (*Deployer, error) {
 
 	deployer := &Deployer{
-		ctx:              ctx,
-		clusterLister:    clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
-		clusterSynced:    clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
-		secretLister:     kubeInformerFactory.Core().V1().Secrets().Lister(),
-		secretSynced:     kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
-		clusternetClient: clusternetClient,
-		recorder:         recorder,
+		ctx:               ctx,
+		clusterLister:     clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		clusterSynced:     clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
+		baseLister:        clusternetInformerFactory.Apps().V1alpha1().Bases().Lister(),
+		baseSynced:        clusternetInformerFactory.Apps().V1alpha1().Bases().Informer().HasSynced,
+		subLister:         clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Lister(),
+		subSynced:         clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Informer().HasSynced,
+		descLister:        clusternetInformerFactory.Apps().V1alpha1().Descriptions().Lister(),
+		descSynced:        clusternetInformerFactory.Apps().V1alpha1().Descriptions().Informer().HasSynced,
+		secretLister:      kubeInformerFactory.Core().V1().Secrets().Lister(),
+		secretSynced:      kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
+		clusternetClient:  clusternetClient,
+		recorder:          recorder,
+		lifecycleRecorder: lifecycleRecorder,
 	}
 
 	descController, err := description.NewController(ctx,
@@ -91,6 +114,8 @@ func NewDeployer(ctx context.Context, clusternetClient *clusternetclientset.Clie
 		clusternetInformerFactory.Apps().V1alpha1().Descriptions(),
 		clusternetInformerFactory.Apps().V1alpha1().HelmReleases(),
 		deployer.recorder,
+		rateLimitOptions,
+		sharder,
 		deployer.handleDescription)
 	if err != nil {
 		return nil, err
@@ -108,13 +133,14 @@ func (deployer *Deployer) Run(workers int) {
 	klog.V(5).Info("waiting for informer caches to sync")
 	if !cache.WaitForCacheSync(deployer.ctx.Done(),
 		deployer.clusterSynced,
+		deployer.baseSynced,
+		deployer.subSynced,
+		deployer.descSynced,
 		deployer.secretSynced) {
 		return
 	}
 
-	go deployer.descController.Run(workers, deployer.ctx.Done())
-
-	<-deployer.ctx.Done()
+	deployer.descController.Run(workers, deployer.ctx.Done())
 }
 
 func (deployer *Deployer) handleDescription(desc *appsapi.Description) error {
@@ -152,20 +178,50 @@ func (deployer *Deployer) handleDescription(desc *appsapi.Description) error {
 		return deployer.deleteDescription(desc)
 	}
 
-	return deployer.createOrUpdateDescription(desc)
+	if utils.IsJobsFinished(desc) {
+		klog.V(5).Infof("Jobs in Description %s have finished and been cleaned up, skip deploying", klog.KObj(desc))
+		return nil
+	}
+
+	if utils.IsDescriptionPendingApproval(desc) {
+		klog.V(5).Infof("Description %s is waiting for approval, skip deploying", klog.KObj(desc))
+		return nil
+	}
+
+	return deployer.createOrUpdateDescription(desc, mcls[0])
 }
 
-func (deployer *Deployer) createOrUpdateDescription(desc *appsapi.Description) error {
+func (deployer *Deployer) createOrUpdateDescription(desc *appsapi.Description, cluster *clusterapi.ManagedCluster) error {
+	// fail fast instead of letting the child cluster reject the workloads
+	if violations := lintPodSecurity(desc, cluster.Status.PodSecurityLevel); len(violations) > 0 {
+		msg := fmt.Sprintf("violating Pod Security level %q of the cluster: %s",
+			cluster.Status.PodSecurityLevel, strings.Join(violations, "; "))
+		klog.V(4).Infof("Description %s is %s", klog.KObj(desc), msg)
+		deployer.recorder.Event(desc, corev1.EventTypeWarning, "PodSecurityViolation", msg)
+
+		deployer.recordLifecycleEvent(desc, appsapi.DescriptionPhaseFailure, msg)
+		status := *desc.Status.DeepCopy()
+		status.Phase = appsapi.DescriptionPhaseFailure
+		status.Reason = msg
+		utils.SetDescriptionStatus(desc, status)
+		_, err := deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(context.TODO(), desc, metav1.UpdateOptions{})
+		return err
+	}
+
 	dynamicClient, discoveryRESTMapper, err := deployer.getDynamicClient(desc)
 	if err != nil {
 		return err
 	}
 
+	dependencies, err := deployer.getFeedDependencies(desc)
+	if err != nil {
+		return err
+	}
+
 	var allErrs []error
-	wg := sync.WaitGroup{}
-	objectsToBeDeployed := desc.Spec.Raw
-	errCh := make(chan error, len(objectsToBeDeployed))
-	for _, object := range objectsToBeDeployed {
+	var waiting []string
+	var resources []*unstructured.Unstructured
+	for _, object := range desc.Spec.Raw {
 		resource := &unstructured.Unstructured{}
 		err := resource.UnmarshalJSON(object)
 		if err != nil {
@@ -173,25 +229,52 @@ func (deployer *Deployer) createOrUpdateDescription(desc *appsapi.Description) e
 			msg := fmt.Sprintf("failed to unmarshal resource: %v", err)
 			klog.ErrorDepth(5, msg)
 			deployer.recorder.Event(desc, corev1.EventTypeWarning, "FailedMarshalingResource", msg)
-		} else {
-			wg.Add(1)
-			go func(resource *unstructured.Unstructured) {
-				defer wg.Done()
-
-				err := deployer.applyResourceWithRetry(dynamicClient, discoveryRESTMapper, resource, defaultRetries)
-				if err != nil {
-					errCh <- err
-				}
-			}(resource)
+			continue
 		}
+		resources = append(resources, resource)
 	}
-	wg.Wait()
 
-	// collect errors
-	close(errCh)
-	for err := range errCh {
+	waves, err := groupResources(resources)
+	if err != nil {
 		allErrs = append(allErrs, err)
 	}
+	for idx, wave := range waves {
+		// objects of the kinds in a wave are deployed in order
+		for _, group := range wave {
+			waiting = append(waiting, deployer.applyResources(dynamicClient, discoveryRESTMapper, group, dependencies, &allErrs)...)
+			if len(allErrs) > 0 {
+				break
+			}
+		}
+		if len(allErrs) > 0 || len(waiting) > 0 || idx == len(waves)-1 {
+			break
+		}
+
+		// deploy next wave after all the objects in this wave get ready
+		var objects []appsapi.FeedDependency
+		for _, group := range wave {
+			for _, resource := range group {
+				objects = append(objects, toDependency(resource))
+			}
+		}
+		unready, err := deployer.checkDependencies(dynamicClient, discoveryRESTMapper, objects)
+		if err != nil {
+			allErrs = append(allErrs, err)
+			break
+		}
+		if len(unready) > 0 {
+			waiting = append(waiting, fmt.Sprintf("wave %d is waiting for %s", idx+1, strings.Join(unready, ", ")))
+			break
+		}
+	}
+
+	if len(waiting) > 0 && len(allErrs) == 0 {
+		// requeue to check the dependencies later
+		msg := strings.Join(waiting, "; ")
+		klog.V(4).Infof("Description %s is partially deployed: %s", klog.KObj(desc), msg)
+		deployer.recorder.Event(desc, corev1.EventTypeNormal, "WaitingForDependencies", msg)
+		return fmt.Errorf("Description %s is waiting for dependencies: %s", klog.KObj(desc), msg)
+	}
 
 	var statusPhase appsapi.DescriptionPhase
 	var reason string
@@ -213,14 +296,97 @@ func (deployer *Deployer) createOrUpdateDescription(desc *appsapi.Description) e
 	}
 
 	// update status
-	desc.Status.Phase = statusPhase
-	desc.Status.Reason = reason
-	_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(context.TODO(), desc, metav1.UpdateOptions{})
+	deployer.recordLifecycleEvent(desc, statusPhase, reason)
+	status := *desc.Status.DeepCopy()
+	status.Phase = statusPhase
+	status.Reason = reason
+	utils.SetDescriptionStatus(desc, status)
+	desc, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).UpdateStatus(context.TODO(), desc, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	if statusPhase != appsapi.DescriptionPhaseSuccess {
+		// retry with backoff, and each failed attempt is counted in the status
+		return fmt.Errorf("failed to deploy Description %s: %s", klog.KObj(desc), reason)
+	}
 
-	return err
+	if err = deployer.pruneObjects(desc, dynamicClient, discoveryRESTMapper); err != nil {
+		return err
+	}
+
+	return deployer.cleanupFinishedJobs(desc, resources, dynamicClient, discoveryRESTMapper)
+}
+
+// recordLifecycleEvent records an event on the Subscription of desc when desc gets applied, or fails with
+// a new reason, rather than on every retry.
+func (deployer *Deployer) recordLifecycleEvent(desc *appsapi.Description, phase appsapi.DescriptionPhase, reason string) {
+	if desc.Status.Phase == phase && desc.Status.Reason == reason {
+		return
+	}
+	switch phase {
+	case appsapi.DescriptionPhaseSuccess:
+		deployer.lifecycleRecorder.ClusterEvent(desc, corev1.EventTypeNormal, events.ReasonDescriptionApplied,
+			fmt.Sprintf("Description %s is applied to cluster %s", klog.KObj(desc), desc.Labels[known.ClusterNameLabel]))
+	case appsapi.DescriptionPhaseFailure:
+		deployer.lifecycleRecorder.ClusterEvent(desc, corev1.EventTypeWarning, events.ReasonDescriptionFailed,
+			fmt.Sprintf("Description %s failed to be applied to cluster %s: %s", klog.KObj(desc),
+				desc.Labels[known.ClusterNameLabel], reason))
+	}
+}
+
+// applyResources deploys the objects concurrently, except the ones waiting for their dependencies,
+// and returns messages about the waiting objects.
+func (deployer *Deployer) applyResources(dynamicClient dynamic.Interface, restMapper meta.RESTMapper,
+	resources []*unstructured.Unstructured, dependencies map[string][]appsapi.FeedDependency, allErrs *[]error) []string {
+	var waiting []string
+	wg := sync.WaitGroup{}
+	errCh := make(chan error, len(resources))
+	for _, resource := range resources {
+		// defer deploying the resource until all of its dependencies get ready
+		key := feedKey(resource.GetAPIVersion(), resource.GetKind(), resource.GetNamespace(), resource.GetName())
+		if len(dependencies[key]) > 0 {
+			unready, err := deployer.checkDependencies(dynamicClient, restMapper, dependencies[key])
+			if err != nil {
+				*allErrs = append(*allErrs, err)
+				continue
+			}
+			if len(unready) > 0 {
+				waiting = append(waiting, fmt.Sprintf("%s %s is waiting for %s", resource.GetKind(),
+					klog.KObj(resource), strings.Join(unready, ", ")))
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(resource *unstructured.Unstructured) {
+			defer wg.Done()
+
+			err := deployer.applyResourceWithRetry(dynamicClient, restMapper, resource, defaultRetries)
+			if err != nil {
+				errCh <- err
+			}
+		}(resource)
+	}
+	wg.Wait()
+
+	// collect errors
+	close(errCh)
+	for err := range errCh {
+		*allErrs = append(*allErrs, err)
+	}
+	return waiting
 }
 
 func (deployer *Deployer) deleteDescription(desc *appsapi.Description) error {
+	propagationPolicy := metav1.DeletePropagationBackground
+	if desc.Spec.DeletionPolicy != nil {
+		propagationPolicy = *desc.Spec.DeletionPolicy
+	}
+	if propagationPolicy == metav1.DeletePropagationOrphan {
+		klog.V(5).Infof("orphaning objects defined in Description %s", klog.KObj(desc))
+		return deployer.removeFinalizer(desc)
+	}
+
 	dynamicClient, discoveryRESTMapper, err := deployer.getDynamicClient(desc)
 	if err != nil {
 		return err
@@ -244,7 +410,8 @@ func (deployer *Deployer) deleteDescription(desc *appsapi.Description) error {
 				defer wg.Done()
 				klog.V(5).Infof("deleting %s %s defined in Description %s", resource.GetKind(),
 					klog.KObj(resource), klog.KObj(desc))
-				err := deployer.deleteResourceWithRetry(dynamicClient, discoveryRESTMapper, resource, defaultRetries)
+				err := deployer.deleteResourceWithRetry(dynamicClient, discoveryRESTMapper, resource,
+					propagationPolicy, defaultRetries)
 				if err != nil {
 					errCh <- err
 				}
@@ -264,16 +431,38 @@ func (deployer *Deployer) deleteDescription(desc *appsapi.Description) error {
 		msg := fmt.Sprintf("failed to deleting Description %s: %v", klog.KObj(desc), err)
 		klog.ErrorDepth(5, msg)
 		deployer.recorder.Event(desc, corev1.EventTypeWarning, "FailedDeletingDescription", msg)
-	} else {
-		klog.V(5).Infof("Description %s is deleted successfully", klog.KObj(desc))
+		return err
+	}
+	klog.V(5).Infof("Description %s is deleted successfully", klog.KObj(desc))
+	return deployer.removeFinalizer(desc)
+}
+
+// removeFinalizer removes finalizer AppFinalizer from the Description after its objects are handled.
+func (deployer *Deployer) removeFinalizer(desc *appsapi.Description) error {
+	if !utils.ContainsString(desc.Finalizers, known.AppFinalizer) {
+		return nil
+	}
+	desc = desc.DeepCopy()
+	desc.Finalizers = utils.RemoveString(desc.Finalizers, known.AppFinalizer)
+	_, err := deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(), desc, metav1.UpdateOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		klog.WarningDepth(4,
+			fmt.Sprintf("failed to remove finalizer %s from Description %s: %v", known.AppFinalizer, klog.KObj(desc), err))
 	}
 	return err
 }
 
+// applyResourceWithRetry applies the object with server-side apply. The fields owned by other field managers
+// in the child cluster, such as replicas scaled by HorizontalPodAutoscalers, are left to them.
 func (deployer *Deployer) applyResourceWithRetry(dynamicClient dynamic.Interface, restMapper meta.RESTMapper,
 	resource *unstructured.Unstructured, retries int) error {
-	// set UID as empty
+	// clear the fields that are not allowed in server-side apply
 	resource.SetUID("")
+	resource.SetResourceVersion("")
+	resource.SetManagedFields(nil)
 
 	backoff := retry.DefaultBackoff
 	backoff.Steps = retries
@@ -284,59 +473,102 @@ func (deployer *Deployer) applyResourceWithRetry(dynamicClient dynamic.Interface
 			return false, nil
 		}
 
-		_, err = dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
-			Create(context.TODO(), resource, metav1.CreateOptions{})
-		if err == nil {
-			return true, nil
-		}
-		if !apierrors.IsAlreadyExists(err) {
-			klog.ErrorDepth(5, fmt.Sprintf("failed to create %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
-			return false, nil
+		resourceCopy := resource.DeepCopy()
+		if ignored := utils.GetIgnoredFields(resource.Object); ignored.Len() > 0 {
+			curObj, err := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
+				Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				klog.ErrorDepth(5, fmt.Sprintf("failed to get %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+				return false, nil
+			}
+			// ignored fields are set only when creating the object
+			if err == nil {
+				for _, path := range ignored.List() {
+					utils.RetainField(resourceCopy.Object, curObj.Object, path)
+				}
+			}
 		}
 
-		// try  to update resource
-		_, err = dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
-			Update(context.TODO(), resource, metav1.UpdateOptions{})
-		if err == nil {
-			return true, nil
-		}
-		statusCauses, ok := getStatusCause(err)
-		if !ok {
-			klog.ErrorDepth(5, fmt.Sprintf("failed to get StatusCause for %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
-			return false, nil
-		}
+		force := false
+		for i := 0; i < maxApplyAttempts; i++ {
+			err = applyResource(dynamicClient, restMapping.Resource, resourceCopy, force)
+			if err == nil {
+				return true, nil
+			}
+			statusCauses, ok := getStatusCause(err)
+			if !ok {
+				klog.ErrorDepth(5, fmt.Sprintf("failed to apply %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+				return false, nil
+			}
 
-		curObj, err := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
-			Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
-		if err != nil {
-			klog.ErrorDepth(5, fmt.Sprintf("failed to get %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
-			return false, nil
-		}
-		resourceCopy := resource.DeepCopy()
-		for _, cause := range statusCauses {
-			if cause.Type != metav1.CauseTypeFieldValueInvalid {
-				continue
+			switch {
+			case apierrors.IsConflict(err):
+				forceApply, resolveErr := resolveApplyConflicts(resourceCopy, statusCauses)
+				if resolveErr != nil {
+					klog.ErrorDepth(5, fmt.Sprintf("failed to apply %s %s: %v", resource.GetKind(), klog.KObj(resource), resolveErr))
+					return false, nil
+				}
+				force = forceApply
+			case apierrors.IsInvalid(err):
+				curObj, err := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
+					Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+				if err != nil {
+					klog.ErrorDepth(5, fmt.Sprintf("failed to get %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+					return false, nil
+				}
+				for _, cause := range statusCauses {
+					if cause.Type != metav1.CauseTypeFieldValueInvalid {
+						continue
+					}
+					// apply immutable value
+					fields := strings.Split(cause.Field, ".")
+					setNestedField(resourceCopy, getNestedString(curObj.Object, fields...), fields...)
+				}
+			default:
+				klog.ErrorDepth(5, fmt.Sprintf("failed to apply %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+				return false, nil
 			}
-			// apply immutable value
-			fields := strings.Split(cause.Field, ".")
-			setNestedField(resourceCopy, getNestedString(curObj.Object, fields...), fields...)
-		}
-		// update with immutable values applied
-		_, err = dynamicClient.Resource(restMapping.Resource).Namespace(resourceCopy.GetNamespace()).
-			Update(context.TODO(), resourceCopy, metav1.UpdateOptions{})
-		if err == nil {
-			return true, nil
 		}
-		klog.ErrorDepth(5, fmt.Sprintf("failed to update %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+		klog.ErrorDepth(5, fmt.Sprintf("failed to apply %s %s: %v", resource.GetKind(), klog.KObj(resource), err))
 		return false, nil
 	})
 }
 
+func applyResource(dynamicClient dynamic.Interface, resource schema.GroupVersionResource,
+	obj *unstructured.Unstructured, force bool) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	_, err = dynamicClient.Resource(resource).Namespace(obj.GetNamespace()).Patch(context.TODO(), obj.GetName(),
+		types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: known.ClusternetHubName, Force: &force})
+	return err
+}
+
+// resolveApplyConflicts drops the conflicting fields owned by other field managers from the object, so that they
+// are left to those managers. It returns whether to force the apply, which is needed when all the conflicting
+// fields are owned by Clusternet itself, e.g. the ones updated by earlier versions without server-side apply.
+func resolveApplyConflicts(obj *unstructured.Unstructured, causes []metav1.StatusCause) (bool, error) {
+	force := true
+	for _, cause := range causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		if utils.IsClusternetFieldManager(getConflictManager(cause.Message)) {
+			continue
+		}
+		force = false
+		if !utils.RemoveField(obj.Object, cause.Field) {
+			return false, fmt.Errorf("%s: %s", cause.Field, cause.Message)
+		}
+	}
+	return force, nil
+}
+
 func (deployer *Deployer) deleteResourceWithRetry(dynamicClient dynamic.Interface, restMapper meta.RESTMapper,
-	resource *unstructured.Unstructured, retries int) error {
+	resource *unstructured.Unstructured, propagationPolicy metav1.DeletionPropagation, retries int) error {
 	backoff := retry.DefaultBackoff
 	backoff.Steps = retries
-	deletePropagationBackground := metav1.DeletePropagationBackground
 	return wait.ExponentialBackoffWithContext(deployer.ctx, backoff, func() (bool, error) {
 		restMapping, err := restMapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
 		if err != nil {
@@ -346,7 +578,7 @@ func (deployer *Deployer) deleteResourceWithRetry(dynamicClient dynamic.Interfac
 		}
 
 		err = dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
-			Delete(context.TODO(), resource.GetName(), metav1.DeleteOptions{PropagationPolicy: &deletePropagationBackground})
+			Delete(context.TODO(), resource.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagationPolicy})
 		if err == nil || (err != nil && apierrors.IsNotFound(err)) {
 			return true, nil
 		}
@@ -355,6 +587,28 @@ func (deployer *Deployer) deleteResourceWithRetry(dynamicClient dynamic.Interfac
 	})
 }
 
+// lintPodSecurity checks the workloads in the Description against the Pod Security admission level of the cluster,
+// and returns the violations prefixed with the objects.
+func lintPodSecurity(desc *appsapi.Description, level clusterapi.PodSecurityLevel) []string {
+	var violations []string
+	for _, object := range desc.Spec.Raw {
+		resource := &unstructured.Unstructured{}
+		if err := resource.UnmarshalJSON(object); err != nil {
+			// will be reported when deploying
+			continue
+		}
+		msgs, err := utils.CheckPodSecurity(level, resource)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("%s %s: %v", resource.GetKind(), klog.KObj(resource), err))
+			continue
+		}
+		for _, msg := range msgs {
+			violations = append(violations, fmt.Sprintf("%s %s: %s", resource.GetKind(), klog.KObj(resource), msg))
+		}
+	}
+	return violations
+}
+
 func (deployer *Deployer) getDynamicClient(desc *appsapi.Description) (dynamic.Interface, meta.RESTMapper, error) {
 	config, err := utils.GetChildClusterConfig(deployer.secretLister, deployer.clusterLister, desc.Namespace, desc.Labels[known.ClusterIDLabel])
 	if err != nil {
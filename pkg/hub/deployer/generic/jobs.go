@@ -0,0 +1,195 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const (
+	// defaultJobsCheckInterval is how often to check whether the Jobs finish in child clusters
+	defaultJobsCheckInterval = 30 * time.Second
+)
+
+var jobGroupKind = schema.GroupKind{Group: "batch", Kind: "Job"}
+
+// getJobsCleanupPolicy returns the JobsCleanupPolicy of the Subscription that the Description is populated from.
+func (deployer *Deployer) getJobsCleanupPolicy(desc *appsapi.Description) (*appsapi.JobsCleanupPolicy, error) {
+	name := desc.Labels[known.ConfigSubscriptionNameLabel]
+	if len(name) == 0 {
+		return nil, nil
+	}
+	sub, err := deployer.subLister.Subscriptions(desc.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sub.Spec.JobsCleanup, nil
+}
+
+// cleanupFinishedJobs removes the Jobs from the child cluster once all of them finish and the ttl expires.
+// Descriptions that contain any objects other than Jobs are left untouched.
+func (deployer *Deployer) cleanupFinishedJobs(desc *appsapi.Description, resources []*unstructured.Unstructured,
+	dynamicClient dynamic.Interface, restMapper meta.RESTMapper) error {
+	if len(resources) == 0 {
+		return nil
+	}
+	for _, resource := range resources {
+		if resource.GroupVersionKind().GroupKind() != jobGroupKind {
+			return nil
+		}
+	}
+	policy, err := deployer.getJobsCleanupPolicy(desc)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	var finishedAt time.Time
+	for _, resource := range resources {
+		restMapping, err := restMapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
+		if err != nil {
+			return err
+		}
+		job, err := dynamicClient.Resource(restMapping.Resource).Namespace(resource.GetNamespace()).
+			Get(context.TODO(), resource.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		finishedTime, finished := getJobFinishedTime(job)
+		if !finished {
+			klog.V(5).Infof("Job %s in Description %s is not finished yet", klog.KObj(job), klog.KObj(desc))
+			deployer.descController.EnqueueAfter(desc, defaultJobsCheckInterval)
+			return nil
+		}
+		if finishedTime.After(finishedAt) {
+			finishedAt = finishedTime
+		}
+	}
+
+	ttl := time.Duration(policy.TTLSecondsAfterFinished) * time.Second
+	if remaining := time.Until(finishedAt.Add(ttl)); remaining > 0 {
+		klog.V(5).Infof("Jobs in Description %s will be cleaned up after %s", klog.KObj(desc), remaining)
+		deployer.descController.EnqueueAfter(desc, remaining)
+		return nil
+	}
+
+	var allErrs []error
+	for _, resource := range resources {
+		if err := deployer.deleteResourceWithRetry(dynamicClient, restMapper, resource,
+			metav1.DeletePropagationBackground, defaultRetries); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if err := utilerrors.NewAggregate(allErrs); err != nil {
+		return err
+	}
+	deployer.recorder.Event(desc, corev1.EventTypeNormal, "JobsCleanedUp",
+		fmt.Sprintf("all the Jobs finished and have been removed after %d seconds", policy.TTLSecondsAfterFinished))
+
+	if policy.DescriptionPolicy != appsapi.DescriptionCleanupDelete {
+		return deployer.markJobsFinished(desc.Namespace, desc.Name, desc.Generation, func(patch []byte) error {
+			_, err := deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Patch(context.TODO(),
+				desc.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+			return err
+		})
+	}
+
+	// mark the Base, so that the Description won't be populated again
+	base, err := deployer.baseLister.Bases(desc.Namespace).Get(desc.Labels[known.ConfigNameLabel])
+	if err != nil {
+		return err
+	}
+	err = deployer.markJobsFinished(base.Namespace, base.Name, base.Generation, func(patch []byte) error {
+		_, err := deployer.clusternetClient.AppsV1alpha1().Bases(base.Namespace).Patch(context.TODO(),
+			base.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Delete(context.TODO(),
+		desc.Name, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// markJobsFinished records the generation with finished Jobs in annotations.
+func (deployer *Deployer) markJobsFinished(namespace, name string, generation int64, patchFunc func([]byte) error) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				known.JobsFinishedGenerationAnnotation: strconv.FormatInt(generation, 10),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err = patchFunc(patch); err != nil {
+		return fmt.Errorf("failed to mark Jobs as finished on %s/%s: %v", namespace, name, err)
+	}
+	return nil
+}
+
+// getJobFinishedTime returns the time when the Job completes or fails, and whether it has finished.
+func getJobFinishedTime(job *unstructured.Unstructured) (time.Time, bool) {
+	conditions, _, _ := unstructured.NestedSlice(job.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] != "Complete" && condition["type"] != "Failed" {
+			continue
+		}
+		if condition["status"] != string(corev1.ConditionTrue) {
+			continue
+		}
+
+		lastTransitionTime, _ := condition["lastTransitionTime"].(string)
+		finishedTime, err := time.Parse(time.RFC3339, lastTransitionTime)
+		if err != nil {
+			// regard it as finished just now
+			return time.Now(), true
+		}
+		return finishedTime, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetJobFinishedTime(t *testing.T) {
+	newJob := func(conditions ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"status":     map[string]interface{}{"conditions": conditions},
+		}}
+	}
+
+	tests := []struct {
+		name         string
+		job          *unstructured.Unstructured
+		wantFinished bool
+		wantTime     time.Time
+	}{
+		{
+			name:         "running job",
+			job:          newJob(),
+			wantFinished: false,
+		},
+		{
+			name: "completed job",
+			job: newJob(map[string]interface{}{
+				"type":               "Complete",
+				"status":             "True",
+				"lastTransitionTime": "2021-11-01T08:00:00Z",
+			}),
+			wantFinished: true,
+			wantTime:     time.Date(2021, 11, 1, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "failed job",
+			job: newJob(map[string]interface{}{
+				"type":               "Failed",
+				"status":             "True",
+				"lastTransitionTime": "2021-11-01T09:30:00Z",
+			}),
+			wantFinished: true,
+			wantTime:     time.Date(2021, 11, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "suspended job",
+			job: newJob(map[string]interface{}{
+				"type":   "Suspended",
+				"status": "True",
+			}),
+			wantFinished: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTime, gotFinished := getJobFinishedTime(tt.job)
+			if gotFinished != tt.wantFinished {
+				t.Errorf("getJobFinishedTime() finished = %v, want %v", gotFinished, tt.wantFinished)
+			}
+			if tt.wantFinished && !gotTime.Equal(tt.wantTime) {
+				t.Errorf("getJobFinishedTime() time = %v, want %v", gotTime, tt.wantTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// getPruneStrategy returns the PruneStrategy of the Subscription that the Description is populated from.
+func (deployer *Deployer) getPruneStrategy(desc *appsapi.Description) (*appsapi.PruneStrategy, error) {
+	name := desc.Labels[known.ConfigSubscriptionNameLabel]
+	if len(name) == 0 {
+		return nil, nil
+	}
+	sub, err := deployer.subLister.Subscriptions(desc.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sub.Spec.Prune, nil
+}
+
+// pruneObjects deletes the objects removed from the Description from the child cluster, and then clears them
+// from annotation "apps.clusternet.io/pruned-objects". Objects still declared by other Descriptions in the same
+// cluster are left untouched.
+func (deployer *Deployer) pruneObjects(desc *appsapi.Description, dynamicClient dynamic.Interface, restMapper meta.RESTMapper) error {
+	objects, err := utils.GetPrunedObjects(desc)
+	if err != nil {
+		klog.Warningf("failed to parse the pruned objects of Description %s: %v", klog.KObj(desc), err)
+		return deployer.clearPrunedObjects(desc)
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+
+	strategy, err := deployer.getPruneStrategy(desc)
+	if err != nil {
+		return err
+	}
+	if strategy != nil && strategy.Policy == appsapi.PruneOrphan {
+		return deployer.clearPrunedObjects(desc)
+	}
+	propagationPolicy := metav1.DeletePropagationBackground
+	if strategy != nil && strategy.PropagationPolicy != nil {
+		propagationPolicy = *strategy.PropagationPolicy
+	}
+
+	descs, err := deployer.descLister.Descriptions(desc.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range descs {
+		if other.UID == desc.UID || other.DeletionTimestamp != nil || other.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+			continue
+		}
+		// keep the objects that are still missing from the other Description
+		objects, err = utils.FindPrunedObjects(nil, other.Spec.Raw, objects)
+		if err != nil {
+			return err
+		}
+	}
+
+	var allErrs []error
+	var pruned []string
+	for _, object := range objects {
+		resource := &unstructured.Unstructured{}
+		resource.SetAPIVersion(object.APIVersion)
+		resource.SetKind(object.Kind)
+		resource.SetNamespace(object.Namespace)
+		resource.SetName(object.Name)
+		klog.V(5).Infof("pruning %s %s removed from Description %s", object.Kind, klog.KObj(resource), klog.KObj(desc))
+		if err := deployer.deleteResourceWithRetry(dynamicClient, restMapper, resource, propagationPolicy, defaultRetries); err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		pruned = append(pruned, fmt.Sprintf("%s %s", object.Kind, klog.KObj(resource)))
+	}
+	if err = utilerrors.NewAggregate(allErrs); err != nil {
+		msg := fmt.Sprintf("failed to prune objects removed from Description %s: %v", klog.KObj(desc), err)
+		klog.ErrorDepth(5, msg)
+		deployer.recorder.Event(desc, corev1.EventTypeWarning, "FailedPruning", msg)
+		return err
+	}
+	if len(pruned) > 0 {
+		deployer.recorder.Event(desc, corev1.EventTypeNormal, "Pruned",
+			fmt.Sprintf("objects removed from the Description are deleted: %s", strings.Join(pruned, ", ")))
+	}
+	return deployer.clearPrunedObjects(desc)
+}
+
+// clearPrunedObjects removes annotation "apps.clusternet.io/pruned-objects" from the Description,
+// which fails with a conflict if the Description has been changed in the meantime.
+func (deployer *Deployer) clearPrunedObjects(desc *appsapi.Description) error {
+	// a null value removes the annotation with merge patch
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": desc.ResourceVersion,
+			"annotations": map[string]interface{}{
+				known.PrunedObjectsAnnotation: nil,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Patch(context.TODO(), desc.Name,
+		types.MergePatchType, patchData, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package generic
 
 import (
+	"strings"
+
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -53,3 +55,13 @@ func getStatusCause(err error) ([]metav1.StatusCause, bool) {
 	}
 	return apierr.Status().Details.Causes, true
 }
+
+// getConflictManager returns the field manager from the message of a conflict cause, such as
+// `conflict with "kube-controller-manager" using apps/v1`.
+func getConflictManager(message string) string {
+	parts := strings.SplitN(message, `"`, 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
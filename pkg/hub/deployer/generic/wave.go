@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// kindOrder is the order of kinds that objects in a wave are deployed in,
+// so that objects are deployed after the ones they rely on, such as CRDs and Namespaces.
+var kindOrder = []string{
+	"CustomResourceDefinition",
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"Ingress",
+	"APIService",
+}
+
+var kindPriorities = func() map[string]int {
+	priorities := make(map[string]int, len(kindOrder))
+	for idx, kind := range kindOrder {
+		priorities[kind] = idx
+	}
+	return priorities
+}()
+
+// getKindPriority returns the priority of the kind, and unknown kinds, such as custom resources, come last
+func getKindPriority(kind string) int {
+	if priority, ok := kindPriorities[kind]; ok {
+		return priority
+	}
+	return len(kindOrder)
+}
+
+// getApplyWave returns the wave of the object declared with annotation "apps.clusternet.io/apply-wave"
+func getApplyWave(resource *unstructured.Unstructured) (int, error) {
+	value, ok := resource.GetAnnotations()[known.ApplyWaveAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	wave, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid annotation %s=%q on %s", known.ApplyWaveAnnotation, value,
+			formatDependency(toDependency(resource)))
+	}
+	return wave, nil
+}
+
+// groupResources groups the objects into waves in ascending order, and the objects in each wave
+// are further grouped by kinds in the order of kindOrder.
+func groupResources(resources []*unstructured.Unstructured) ([][][]*unstructured.Unstructured, error) {
+	type key struct {
+		wave     int
+		priority int
+	}
+	groups := map[key][]*unstructured.Unstructured{}
+	var keys []key
+	for _, resource := range resources {
+		wave, err := getApplyWave(resource)
+		if err != nil {
+			return nil, err
+		}
+		k := key{wave: wave, priority: getKindPriority(resource.GetKind())}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], resource)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].wave != keys[j].wave {
+			return keys[i].wave < keys[j].wave
+		}
+		return keys[i].priority < keys[j].priority
+	})
+
+	var waves [][][]*unstructured.Unstructured
+	for idx, k := range keys {
+		if idx == 0 || keys[idx-1].wave != k.wave {
+			waves = append(waves, nil)
+		}
+		waves[len(waves)-1] = append(waves[len(waves)-1], groups[k])
+	}
+	return waves, nil
+}
+
+func toDependency(resource *unstructured.Unstructured) appsapi.FeedDependency {
+	return appsapi.FeedDependency{
+		APIVersion: resource.GetAPIVersion(),
+		Kind:       resource.GetKind(),
+		Namespace:  resource.GetNamespace(),
+		Name:       resource.GetName(),
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generic
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newObject(kind, name, wave string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetName(name)
+	if wave != "" {
+		obj.SetAnnotations(map[string]string{"apps.clusternet.io/apply-wave": wave})
+	}
+	return obj
+}
+
+func TestGroupResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []*unstructured.Unstructured
+		want    [][]string
+		wantErr bool
+	}{
+		{
+			name: "kind order",
+			objects: []*unstructured.Unstructured{
+				newObject("Deployment", "web", ""),
+				newObject("Foo", "foo", ""),
+				newObject("Service", "web", ""),
+				newObject("Namespace", "demo", ""),
+				newObject("CustomResourceDefinition", "foos", ""),
+				newObject("ConfigMap", "web", ""),
+			},
+			want: [][]string{{"CustomResourceDefinition", "Namespace", "ConfigMap", "Service", "Deployment", "Foo"}},
+		},
+		{
+			name: "waves",
+			objects: []*unstructured.Unstructured{
+				newObject("Deployment", "web", "1"),
+				newObject("Job", "migrate", ""),
+				newObject("Secret", "db", "-1"),
+				newObject("ConfigMap", "web", "1"),
+			},
+			want: [][]string{{"Secret"}, {"Job"}, {"ConfigMap", "Deployment"}},
+		},
+		{
+			name: "invalid wave",
+			objects: []*unstructured.Unstructured{
+				newObject("Deployment", "web", "first"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waves, err := groupResources(tt.objects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("groupResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var got [][]string
+			for _, wave := range waves {
+				var kinds []string
+				for _, group := range wave {
+					for _, obj := range group {
+						kinds = append(kinds, obj.GetKind())
+					}
+				}
+				got = append(got, kinds)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
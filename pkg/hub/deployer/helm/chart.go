@@ -17,35 +17,89 @@ limitations under the License.
 package helm
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"helm.sh/helm/v3/pkg/action"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/helmpath"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/oci"
 )
 
+// defaultTimeout is the default time to wait for hooks and tests, which is the same as helm command line
+const defaultTimeout = 5 * time.Minute
+
 var (
 	settings = cli.New()
+
+	// registryConfigLock guards the credentials file of OCI registries shared by all the charts
+	registryConfigLock sync.Mutex
 )
 
+// RepoOptions holds the credentials and TLS options to access a chart repository.
+type RepoOptions struct {
+	Username string
+	Password string
+
+	// files of PEM-encoded certificates and keys
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	InsecureSkipTLSVerify bool
+}
+
 // LocateHelmChart will looks for a chart from repository and load it.
-func LocateHelmChart(chartRepo, chartName, chartVersion string) (*chart.Chart, error) {
-	client := action.NewInstall(nil)
-	client.ChartPathOptions.RepoURL = chartRepo
-	client.ChartPathOptions.Version = chartVersion
+func LocateHelmChart(chartRepo, chartName, chartVersion string, opts RepoOptions) (*chart.Chart, error) {
+	var cp string
+	if IsOCIRepository(chartRepo) {
+		dir, err := ioutil.TempDir("", "clusternet-chart-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
 
-	cp, err := client.ChartPathOptions.LocateChart(chartName, settings)
-	if err != nil {
-		return nil, err
+		cp, err = pullOCIChart(chartRepo, chartName, chartVersion, opts, dir)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		client := action.NewInstall(nil)
+		client.ChartPathOptions.RepoURL = chartRepo
+		client.ChartPathOptions.Version = chartVersion
+		client.ChartPathOptions.Username = opts.Username
+		client.ChartPathOptions.Password = opts.Password
+		client.ChartPathOptions.CaFile = opts.CAFile
+		client.ChartPathOptions.CertFile = opts.CertFile
+		client.ChartPathOptions.KeyFile = opts.KeyFile
+		client.ChartPathOptions.InsecureSkipTLSverify = opts.InsecureSkipTLSVerify
+
+		var err error
+		cp, err = client.ChartPathOptions.LocateChart(chartName, settings)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	klog.V(5).Infof("chart %s/%s:%s locates at: %s", chartRepo, chartName, chartVersion, cp)
@@ -55,6 +109,15 @@ func LocateHelmChart(chartRepo, chartName, chartVersion string) (*chart.Chart, e
 	if err != nil {
 		return nil, err
 	}
+	if req := chartRequested.Metadata.Dependencies; req != nil {
+		if err := action.CheckDependencies(chartRequested, req); err != nil {
+			klog.V(5).Infof("resolving dependencies of chart %s/%s:%s: %v", chartRepo, chartName, chartVersion, err)
+			chartRequested, err = buildDependencies(cp)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve dependencies of chart %s: %v", chartName, err)
+			}
+		}
+	}
 
 	if err := CheckIfInstallable(chartRequested); err != nil {
 		return nil, err
@@ -63,6 +126,182 @@ func LocateHelmChart(chartRepo, chartName, chartVersion string) (*chart.Chart, e
 	return chartRequested, nil
 }
 
+// FindHelmChart checks whether the chart exists in the repository.
+func FindHelmChart(chartRepo, chartName, chartVersion string, opts RepoOptions) error {
+	if IsOCIRepository(chartRepo) {
+		dir, err := ioutil.TempDir("", "clusternet-chart-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		_, err = pullOCIChart(chartRepo, chartName, chartVersion, opts, dir)
+		return err
+	}
+
+	_, err := repo.FindChartInAuthAndTLSRepoURL(chartRepo, opts.Username, opts.Password, chartName, chartVersion,
+		opts.CertFile, opts.KeyFile, opts.CAFile, opts.InsecureSkipTLSVerify,
+		getter.All(settings))
+	return err
+}
+
+// VerifyHelmChart verifies the cosign signatures of the chart in an OCI registry.
+// The chart is pulled by its version afterwards, which should not be moved to another digest.
+func VerifyHelmChart(chartRepo, chartName, chartVersion string, opts RepoOptions, verifier *oci.Verifier) error {
+	if !IsOCIRepository(chartRepo) {
+		return fmt.Errorf("signatures of chart %s can only be verified in OCI registries", chartName)
+	}
+	if len(chartVersion) == 0 {
+		return fmt.Errorf("version of chart %s is required for OCI registry %s", chartName, chartRepo)
+	}
+
+	client, err := oci.NewClient(fmt.Sprintf("%s/%s", strings.TrimSuffix(chartRepo, "/"), chartName),
+		false, opts.Username, opts.Password)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), defaultTimeout)
+	defer cancel()
+
+	_, digest, err := client.FetchManifest(ctx, chartVersion)
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(ctx, client, digest)
+}
+
+// IsOCIRepository tells whether the repository is an OCI registry.
+func IsOCIRepository(chartRepo string) bool {
+	return strings.HasPrefix(chartRepo, "oci://")
+}
+
+// pullOCIChart pulls the chart from an OCI registry and saves the archive into dir.
+// The registry client of helm v3.6 does not accept TLS options, so only the credentials are used.
+func pullOCIChart(chartRepo, chartName, chartVersion string, opts RepoOptions, dir string) (string, error) {
+	if len(chartVersion) == 0 {
+		return "", fmt.Errorf("version of chart %s is required for OCI registry %s", chartName, chartRepo)
+	}
+
+	// the registry client reads credentials from a file shared by all the charts
+	registryConfigLock.Lock()
+	defer registryConfigLock.Unlock()
+
+	ref := fmt.Sprintf("%s/%s", strings.TrimSuffix(chartRepo, "/"), chartName)
+	if len(opts.Username) > 0 || len(opts.Password) > 0 {
+		if err := saveRegistryCredentials(ref, opts.Username, opts.Password); err != nil {
+			return "", err
+		}
+	}
+
+	g, err := getter.All(settings).ByScheme("oci")
+	if err != nil {
+		return "", err
+	}
+	data, err := g.Get(ref, getter.WithTagName(chartVersion))
+	if err != nil {
+		return "", err
+	}
+
+	cp := filepath.Join(dir, fmt.Sprintf("%s-%s.tgz", chartName, chartVersion))
+	if err := ioutil.WriteFile(cp, data.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return cp, nil
+}
+
+// saveRegistryCredentials saves the credentials of the OCI registry in docker config format,
+// which is where the registry client of helm looks for credentials.
+func saveRegistryCredentials(ref, username, password string) error {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+
+	configFile := helmpath.CachePath("registry", "config.json")
+	config := make(map[string]interface{})
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return err
+		}
+	}
+
+	auths, ok := config["auths"].(map[string]interface{})
+	if !ok {
+		auths = make(map[string]interface{})
+	}
+	auths[u.Host] = map[string]string{
+		"auth": base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+	config["auths"] = auths
+
+	data, err = json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configFile, data, 0600)
+}
+
+// buildDependencies downloads the dependencies of the chart archive into its charts/ directory, with the
+// versions pinned in Chart.lock if any, and loads the chart.
+func buildDependencies(chartPath string) (*chart.Chart, error) {
+	dir, err := ioutil.TempDir("", "clusternet-chart-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := chartutil.ExpandFile(dir, chartPath); err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 || !files[0].IsDir() {
+		return nil, fmt.Errorf("unexpected layout of chart archive %s", chartPath)
+	}
+	chartDir := filepath.Join(dir, files[0].Name())
+
+	ch, err := loader.LoadDir(chartDir)
+	if err != nil {
+		return nil, err
+	}
+	if ch.Lock != nil {
+		// pin the versions in Chart.lock, since the repositories may not be known by helm
+		lockedVersions := make(map[string]string)
+		for _, dep := range ch.Lock.Dependencies {
+			lockedVersions[dep.Name] = dep.Version
+		}
+		for _, dep := range ch.Metadata.Dependencies {
+			if version, ok := lockedVersions[dep.Name]; ok {
+				dep.Version = version
+			}
+		}
+		if err := chartutil.SaveChartfile(filepath.Join(chartDir, chartutil.ChartfileName), ch.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	man := &downloader.Manager{
+		Out:              ioutil.Discard,
+		ChartPath:        chartDir,
+		Getters:          getter.All(settings),
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	if err := man.Update(); err != nil {
+		return nil, err
+	}
+	return loader.LoadDir(chartDir)
+}
+
 // CheckIfInstallable validates if a chart can be installed
 // only application chart type is installable
 func CheckIfInstallable(chart *chart.Chart) error {
@@ -79,6 +318,8 @@ func InstallRelease(cfg *action.Configuration, hr *appsapi.HelmRelease,
 	client.ReleaseName = hr.Name
 	client.CreateNamespace = true
 	client.Namespace = hr.Spec.TargetNamespace
+	client.DisableHooks = hr.Spec.DisableHooks
+	client.Timeout = getTimeout(hr)
 
 	return client.Run(chart, vals)
 }
@@ -88,11 +329,15 @@ func UpgradeRelease(cfg *action.Configuration, hr *appsapi.HelmRelease,
 	klog.V(5).Infof("Upgrading HelmRelease %s", klog.KObj(hr))
 	client := action.NewUpgrade(cfg)
 	client.Namespace = hr.Spec.TargetNamespace
+	client.DisableHooks = hr.Spec.DisableHooks
+	client.Timeout = getTimeout(hr)
 	return client.Run(hr.Name, chart, vals)
 }
 
 func UninstallRelease(cfg *action.Configuration, hr *appsapi.HelmRelease) error {
 	client := action.NewUninstall(cfg)
+	client.DisableHooks = hr.Spec.DisableHooks
+	client.Timeout = getTimeout(hr)
 	_, err := client.Run(hr.Name)
 	if err != nil {
 		if strings.Contains(err.Error(), "Release not loaded") {
@@ -103,6 +348,100 @@ func UninstallRelease(cfg *action.Configuration, hr *appsapi.HelmRelease) error
 	return nil
 }
 
+// RunReleaseTests runs the tests of the release, unless they have run against current revision.
+func RunReleaseTests(cfg *action.Configuration, hr *appsapi.HelmRelease, rel *release.Release) (*release.Release, error) {
+	pending := false
+	for _, hook := range rel.Hooks {
+		if isTestHook(hook) && (hook.LastRun.Phase == "" || hook.LastRun.Phase == release.HookPhaseUnknown) {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return rel, nil
+	}
+
+	klog.V(5).Infof("Running tests of HelmRelease %s", klog.KObj(hr))
+	client := action.NewReleaseTesting(cfg)
+	client.Namespace = hr.Spec.TargetNamespace
+	client.Timeout = getTimeout(hr)
+	return client.Run(hr.Name)
+}
+
+// GetHookStatuses returns the last runs of the hooks of the release.
+func GetHookStatuses(rel *release.Release) []appsapi.HelmHookStatus {
+	var statuses []appsapi.HelmHookStatus
+	for _, hook := range rel.Hooks {
+		status := appsapi.HelmHookStatus{
+			Name:  hook.Name,
+			Kind:  hook.Kind,
+			Phase: string(hook.LastRun.Phase),
+		}
+		for _, event := range hook.Events {
+			status.Events = append(status.Events, string(event))
+		}
+		if !hook.LastRun.StartedAt.IsZero() {
+			status.StartedAt = hook.LastRun.StartedAt.String()
+		}
+		if !hook.LastRun.CompletedAt.IsZero() {
+			status.CompletedAt = hook.LastRun.CompletedAt.String()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// GetTestCondition returns the Tested condition with the results of the tests of the release.
+func GetTestCondition(rel *release.Release) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    appsapi.HelmReleaseTested,
+		Status:  metav1.ConditionTrue,
+		Reason:  "TestsPassed",
+		Message: "all the tests have passed",
+	}
+
+	var failed, pending []string
+	for _, hook := range rel.Hooks {
+		if !isTestHook(hook) {
+			continue
+		}
+		switch hook.LastRun.Phase {
+		case release.HookPhaseSucceeded:
+		case release.HookPhaseFailed:
+			failed = append(failed, hook.Name)
+		default:
+			pending = append(pending, hook.Name)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "TestsFailed"
+		condition.Message = fmt.Sprintf("tests failed: %s", strings.Join(failed, ", "))
+	case len(pending) > 0:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "TestsPending"
+		condition.Message = fmt.Sprintf("tests are not completed: %s", strings.Join(pending, ", "))
+	}
+	return condition
+}
+
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+func getTimeout(hr *appsapi.HelmRelease) time.Duration {
+	if hr.Spec.Timeout != nil {
+		return hr.Spec.Timeout.Duration
+	}
+	return defaultTimeout
+}
+
 func ReleaseNeedsUpgrade(rel *release.Release, hr *appsapi.HelmRelease, chart *chart.Chart, vals map[string]interface{}) bool {
 	if rel.Name != hr.Name {
 		return true
@@ -125,12 +464,17 @@ func ReleaseNeedsUpgrade(rel *release.Release, hr *appsapi.HelmRelease, chart *c
 	return false
 }
 
-func UpdateRepo(repoURL string) error {
+func UpdateRepo(repoURL string, opts RepoOptions) error {
 	klog.V(4).Infof("updating helm repo %s", repoURL)
 
 	entry := repo.Entry{
 		URL:                   repoURL,
-		InsecureSkipTLSverify: true,
+		Username:              opts.Username,
+		Password:              opts.Password,
+		CAFile:                opts.CAFile,
+		CertFile:              opts.CertFile,
+		KeyFile:               opts.KeyFile,
+		InsecureSkipTLSverify: opts.InsecureSkipTLSVerify,
 	}
 	cr, err := repo.NewChartRepository(&entry, getter.All(settings))
 	if err != nil {
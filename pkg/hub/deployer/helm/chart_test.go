@@ -0,0 +1,64 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetTestCondition(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks []*release.Hook
+		want  metav1.ConditionStatus
+	}{
+		{
+			name: "all passed",
+			hooks: []*release.Hook{
+				{Name: "pre", Events: []release.HookEvent{release.HookPreInstall}, LastRun: release.HookExecution{Phase: release.HookPhaseFailed}},
+				{Name: "test", Events: []release.HookEvent{release.HookTest}, LastRun: release.HookExecution{Phase: release.HookPhaseSucceeded}},
+			},
+			want: metav1.ConditionTrue,
+		},
+		{
+			name: "failed",
+			hooks: []*release.Hook{
+				{Name: "test-1", Events: []release.HookEvent{release.HookTest}, LastRun: release.HookExecution{Phase: release.HookPhaseSucceeded}},
+				{Name: "test-2", Events: []release.HookEvent{release.HookTest}, LastRun: release.HookExecution{Phase: release.HookPhaseFailed}},
+			},
+			want: metav1.ConditionFalse,
+		},
+		{
+			name: "not run",
+			hooks: []*release.Hook{
+				{Name: "test", Events: []release.HookEvent{release.HookTest}},
+			},
+			want: metav1.ConditionUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetTestCondition(&release.Release{Hooks: tt.hooks})
+			if got.Status != tt.want {
+				t.Errorf("GetTestCondition() = %v, want %v", got.Status, tt.want)
+			}
+		})
+	}
+}
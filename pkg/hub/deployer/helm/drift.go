@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// maxReportedDrifts is the max number of drifted objects reported in the Drifted condition
+const maxReportedDrifts = 10
+
+// checkDrifts compares the deployed HelmReleases with the objects in child clusters.
+func (deployer *Deployer) checkDrifts(ctx context.Context) {
+	hrs, err := deployer.hrLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list HelmReleases: %v", err)
+		return
+	}
+
+	for _, hr := range hrs {
+		if ctx.Err() != nil {
+			return
+		}
+		if hr.DeletionTimestamp != nil || hr.Status.Phase != release.StatusDeployed || !deployer.sharder.Owns(hr.Namespace) {
+			continue
+		}
+		if err := deployer.checkDrift(ctx, hr.DeepCopy()); err != nil {
+			klog.ErrorDepth(5, fmt.Sprintf("failed to check drifts of HelmRelease %s: %v", klog.KObj(hr), err))
+		}
+	}
+}
+
+// checkDrift reports drifts of the HelmRelease in condition Drifted, and upgrades the release to revert them if
+// its DriftRemediation is Upgrade.
+func (deployer *Deployer) checkDrift(ctx context.Context, hr *appsapi.HelmRelease) error {
+	cfg, deployCtx, err := deployer.getActionConfig(hr)
+	if err != nil {
+		return err
+	}
+	rel, err := cfg.Releases.Deployed(hr.Name)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(deployCtx.restConfig)
+	if err != nil {
+		return err
+	}
+
+	drifts, err := findReleaseDrifts(ctx, dynamicClient, deployCtx.restMapper, rel)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:    appsapi.HelmReleaseDrifted,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoDrift",
+		Message: "objects of the release are consistent with the chart",
+	}
+	if len(drifts) > 0 {
+		msg := fmt.Sprintf("objects drifted from the chart: %s", strings.Join(drifts, "; "))
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Drifted"
+		condition.Message = msg
+		deployer.recorder.Event(hr, corev1.EventTypeWarning, "Drifted", msg)
+
+		if hr.Spec.DriftRemediation == appsapi.HelmDriftUpgrade {
+			klog.V(4).Infof("upgrading HelmRelease %s to revert drifts", klog.KObj(hr))
+			upgraded, err := remediateDrifts(cfg, hr, rel)
+			if err != nil {
+				deployer.recorder.Event(hr, corev1.EventTypeWarning, "DriftRemediationFailure", err.Error())
+				return err
+			}
+			rel = upgraded
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "Remediated"
+			condition.Message = fmt.Sprintf("reverted drifts by upgrading to revision %d: %s", rel.Version, strings.Join(drifts, "; "))
+			deployer.recorder.Event(hr, corev1.EventTypeNormal, "DriftRemediated", condition.Message)
+		}
+	}
+
+	existing := apimeta.FindStatusCondition(hr.Status.Conditions, appsapi.HelmReleaseDrifted)
+	if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && hr.Status.Version == rel.Version {
+		return nil
+	}
+
+	status := hr.Status.DeepCopy()
+	status.Conditions = []metav1.Condition{condition}
+	status.Version = rel.Version
+	if rel.Info != nil {
+		status.LastDeployed = rel.Info.LastDeployed.String()
+		status.Description = rel.Info.Description
+		status.Phase = rel.Info.Status
+	}
+	return deployer.helmReleaseController.UpdateHelmReleaseStatus(hr, status)
+}
+
+// remediateDrifts upgrades the release with its deployed chart and values, where helm patches the live objects
+// back to the rendered manifest and recreates the missing ones.
+func remediateDrifts(cfg *action.Configuration, hr *appsapi.HelmRelease, rel *release.Release) (*release.Release, error) {
+	client := action.NewUpgrade(cfg)
+	client.Namespace = hr.Spec.TargetNamespace
+	client.DisableHooks = hr.Spec.DisableHooks
+	client.Timeout = getTimeout(hr)
+	client.Description = "Upgrade to revert drifts"
+	return client.Run(hr.Name, rel.Chart, rel.Config)
+}
+
+// findReleaseDrifts compares the objects in the manifest of the release with the live ones, and returns
+// the drifted objects with their drifted fields.
+func findReleaseDrifts(ctx context.Context, dynamicClient dynamic.Interface, restMapper apimeta.RESTMapper,
+	rel *release.Release) ([]string, error) {
+	manifests := releaseutil.SplitManifests(rel.Manifest)
+	keys := make([]string, 0, len(manifests))
+	for key := range manifests {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var drifts []string
+	for _, key := range keys {
+		if len(drifts) >= maxReportedDrifts {
+			break
+		}
+
+		manifest := manifests[key]
+		desired := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(manifest), &desired.Object); err != nil {
+			return nil, err
+		}
+		if len(desired.Object) == 0 {
+			continue
+		}
+
+		gvk := desired.GroupVersionKind()
+		mapping, err := restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		var resource dynamic.ResourceInterface = dynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == apimeta.RESTScopeNameNamespace {
+			namespace := desired.GetNamespace()
+			if len(namespace) == 0 {
+				namespace = rel.Namespace
+			}
+			desired.SetNamespace(namespace)
+			resource = dynamicClient.Resource(mapping.Resource).Namespace(namespace)
+		}
+
+		object := fmt.Sprintf("%s %s", desired.GetKind(), klog.KObj(desired))
+		live, err := resource.Get(ctx, desired.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				drifts = append(drifts, fmt.Sprintf("%s is missing", object))
+				continue
+			}
+			return nil, err
+		}
+		if fields := utils.FindDriftedFields(desired.Object, live.Object); len(fields) > 0 {
+			drifts = append(drifts, fmt.Sprintf("%s on %s", object, strings.Join(fields, ", ")))
+		}
+	}
+	return drifts, nil
+}
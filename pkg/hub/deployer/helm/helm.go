@@ -21,14 +21,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/release"
-	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/storage/driver"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +37,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	corev1lister "k8s.io/client-go/listers/core/v1"
@@ -53,7 +55,10 @@ import (
 	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
 	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
 	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/hub/oci"
 	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
@@ -89,32 +94,43 @@ type Deployer struct {
 	secretLister corev1lister.SecretLister
 	secretSynced cache.InformerSynced
 
-	recorder record.EventRecorder
+	// driftCheckInterval is how often HelmReleases are checked for drifts, 0 disables checking
+	driftCheckInterval time.Duration
+
+	// sharder decides whether the HelmReleases of a child cluster are handled by current replica
+	sharder *sharding.Sharder
+
+	recorder          record.EventRecorder
+	lifecycleRecorder *events.Recorder
 }
 
 func NewDeployer(ctx context.Context,
 	clusternetClient *clusternetclientset.Clientset, kubeClient *kubernetes.Clientset,
 	clusternetInformerFactory clusternetinformers.SharedInformerFactory,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
-	feedInUseProtection bool, recorder record.EventRecorder) (*Deployer, error) {
+	feedInUseProtection bool, driftCheckInterval time.Duration, recorder record.EventRecorder,
+	lifecycleRecorder *events.Recorder, rateLimitOptions description.RateLimitOptions, sharder *sharding.Sharder) (*Deployer, error) {
 
 	deployer := &Deployer{
-		ctx:              ctx,
-		clusternetClient: clusternetClient,
-		kubeClient:       kubeClient,
-		chartLister:      clusternetInformerFactory.Apps().V1alpha1().HelmCharts().Lister(),
-		chartSynced:      clusternetInformerFactory.Apps().V1alpha1().HelmCharts().Informer().HasSynced,
-		hrLister:         clusternetInformerFactory.Apps().V1alpha1().HelmReleases().Lister(),
-		hrSynced:         clusternetInformerFactory.Apps().V1alpha1().HelmReleases().Informer().HasSynced,
-		descLister:       clusternetInformerFactory.Apps().V1alpha1().Descriptions().Lister(),
-		descSynced:       clusternetInformerFactory.Apps().V1alpha1().Descriptions().Informer().HasSynced,
-		subLister:        clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Lister(),
-		subSynced:        clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Informer().HasSynced,
-		clusterLister:    clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
-		clusterSynced:    clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
-		secretLister:     kubeInformerFactory.Core().V1().Secrets().Lister(),
-		secretSynced:     kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
-		recorder:         recorder,
+		ctx:                ctx,
+		clusternetClient:   clusternetClient,
+		kubeClient:         kubeClient,
+		chartLister:        clusternetInformerFactory.Apps().V1alpha1().HelmCharts().Lister(),
+		chartSynced:        clusternetInformerFactory.Apps().V1alpha1().HelmCharts().Informer().HasSynced,
+		hrLister:           clusternetInformerFactory.Apps().V1alpha1().HelmReleases().Lister(),
+		hrSynced:           clusternetInformerFactory.Apps().V1alpha1().HelmReleases().Informer().HasSynced,
+		descLister:         clusternetInformerFactory.Apps().V1alpha1().Descriptions().Lister(),
+		descSynced:         clusternetInformerFactory.Apps().V1alpha1().Descriptions().Informer().HasSynced,
+		subLister:          clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Lister(),
+		subSynced:          clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Informer().HasSynced,
+		clusterLister:      clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Lister(),
+		clusterSynced:      clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer().HasSynced,
+		secretLister:       kubeInformerFactory.Core().V1().Secrets().Lister(),
+		secretSynced:       kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
+		driftCheckInterval: driftCheckInterval,
+		recorder:           recorder,
+		lifecycleRecorder:  lifecycleRecorder,
+		sharder:            sharder,
 	}
 
 	helmChartController, err := helmchart.NewController(ctx, clusternetClient,
@@ -131,6 +147,7 @@ func NewDeployer(ctx context.Context,
 		clusternetInformerFactory.Apps().V1alpha1().Descriptions(),
 		clusternetInformerFactory.Apps().V1alpha1().HelmReleases(),
 		deployer.recorder,
+		sharder,
 		deployer.handleHelmRelease)
 	if err != nil {
 		return nil, err
@@ -152,6 +169,8 @@ func NewDeployer(ctx context.Context,
 		clusternetInformerFactory.Apps().V1alpha1().Descriptions(),
 		clusternetInformerFactory.Apps().V1alpha1().HelmReleases(),
 		deployer.recorder,
+		rateLimitOptions,
+		sharder,
 		deployer.handleDescription)
 	if err != nil {
 		return nil, err
@@ -178,13 +197,27 @@ func (deployer *Deployer) Run(workers int) {
 		return
 	}
 
-	go deployer.helmChartController.Run(workers, deployer.ctx.Done())
-	go deployer.helmReleaseController.Run(workers, deployer.ctx.Done())
-	go deployer.descriptionController.Run(workers, deployer.ctx.Done())
+	var wg wait.Group
+	wg.Start(func() {
+		deployer.helmChartController.Run(workers, deployer.ctx.Done())
+	})
+	wg.Start(func() {
+		deployer.helmReleaseController.Run(workers, deployer.ctx.Done())
+	})
+	wg.Start(func() {
+		deployer.descriptionController.Run(workers, deployer.ctx.Done())
+	})
 	// 1 worker may get hang up, so we set minimum 2 workers here
-	go deployer.secretController.Run(2, deployer.ctx.Done())
+	wg.Start(func() {
+		deployer.secretController.Run(2, deployer.ctx.Done())
+	})
+	if deployer.driftCheckInterval > 0 {
+		wg.StartWithContext(deployer.ctx, func(ctx context.Context) {
+			wait.UntilWithContext(ctx, deployer.checkDrifts, deployer.driftCheckInterval)
+		})
+	}
 
-	<-deployer.ctx.Done()
+	wg.Wait()
 }
 
 func (deployer *Deployer) handleDescription(desc *appsapi.Description) error {
@@ -245,14 +278,34 @@ func (deployer *Deployer) handleDescription(desc *appsapi.Description) error {
 		}
 
 		desc.Finalizers = utils.RemoveString(desc.Finalizers, known.AppFinalizer)
+		desc.Finalizers = utils.RemoveString(desc.Finalizers, known.HelmReleaseProtectionFinalizer)
 		_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(), desc, metav1.UpdateOptions{})
 		if err != nil {
 			klog.WarningDepth(4,
-				fmt.Sprintf("failed to remove finalizer %s from Description %s: %v", known.AppFinalizer, klog.KObj(desc), err))
+				fmt.Sprintf("failed to remove finalizers from Description %s: %v", klog.KObj(desc), err))
 		}
 		return err
 	}
 
+	// make sure the Description won't be gone before its HelmReleases
+	if !utils.ContainsString(desc.Finalizers, known.HelmReleaseProtectionFinalizer) {
+		desc.Finalizers = append(desc.Finalizers, known.HelmReleaseProtectionFinalizer)
+		updatedDesc, err := deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(),
+			desc, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to inject finalizer %s to Description %s: %v",
+				known.HelmReleaseProtectionFinalizer, klog.KObj(desc), err)
+		}
+		updatedDesc.Kind = desc.Kind
+		updatedDesc.APIVersion = desc.APIVersion
+		desc = updatedDesc
+	}
+
+	if utils.IsDescriptionPendingApproval(desc) {
+		klog.V(5).Infof("Description %s is waiting for approval, skip deploying", klog.KObj(desc))
+		return nil
+	}
+
 	if err := deployer.PopulateHelmRelease(desc); err != nil {
 		return err
 	}
@@ -281,9 +334,12 @@ func (deployer *Deployer) handleHelmChart(chart *appsapi.HelmChart) error {
 		return err
 	}
 
-	_, err := repo.FindChartInRepoURL(chart.Spec.Repository, chart.Spec.Chart, chart.Spec.ChartVersion,
-		"", "", "",
-		getter.All(settings))
+	repoOpts, cleanup, err := deployer.getRepoOptions(chart.Spec.HelmOptions, chart.Namespace)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	err = FindHelmChart(chart.Spec.Repository, chart.Spec.Chart, chart.Spec.ChartVersion, repoOpts)
 	if err != nil {
 		// failed to find chart
 		return deployer.helmChartController.UpdateChartStatus(chart, &appsapi.HelmChartStatus{
@@ -291,11 +347,33 @@ func (deployer *Deployer) handleHelmChart(chart *appsapi.HelmChart) error {
 			Reason: err.Error(),
 		})
 	}
+	if chart.Spec.Verify != nil {
+		if err = deployer.verifyHelmChart(chart, repoOpts); err != nil {
+			deployer.recorder.Event(chart, corev1.EventTypeWarning, appsapi.SignatureVerificationFailed, err.Error())
+			return deployer.helmChartController.UpdateChartStatus(chart, &appsapi.HelmChartStatus{
+				Phase:  appsapi.HelmChartUnverified,
+				Reason: err.Error(),
+			})
+		}
+	}
 	return deployer.helmChartController.UpdateChartStatus(chart, &appsapi.HelmChartStatus{
 		Phase: appsapi.HelmChartFound,
 	})
 }
 
+// verifyHelmChart verifies the cosign signatures of the chart with the trusted materials in its namespace
+func (deployer *Deployer) verifyHelmChart(chart *appsapi.HelmChart, repoOpts RepoOptions) error {
+	secret, err := deployer.secretLister.Secrets(chart.Namespace).Get(chart.Spec.Verify.SecretRef.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get Secret %s for signature verification: %v", chart.Spec.Verify.SecretRef.Name, err)
+	}
+	verifier, err := oci.NewVerifier(secret, chart.Spec.Verify.Identities)
+	if err != nil {
+		return err
+	}
+	return VerifyHelmChart(chart.Spec.Repository, chart.Spec.Chart, chart.Spec.ChartVersion, repoOpts, verifier)
+}
+
 func (deployer *Deployer) PopulateHelmRelease(desc *appsapi.Description) error {
 	allExistingHelmReleases, err := deployer.hrLister.List(labels.SelectorFromSet(labels.Set{
 		known.ConfigKindLabel:      desc.Kind,
@@ -313,7 +391,7 @@ func (deployer *Deployer) PopulateHelmRelease(desc *appsapi.Description) error {
 	}
 
 	var allErrs []error
-	for _, chartRef := range desc.Spec.Charts {
+	for idx, chartRef := range desc.Spec.Charts {
 		chart, err := deployer.chartLister.HelmCharts(chartRef.Namespace).Get(chartRef.Name)
 		if err != nil {
 			return err
@@ -355,6 +433,13 @@ func (deployer *Deployer) PopulateHelmRelease(desc *appsapi.Description) error {
 				HelmOptions:     chart.Spec.HelmOptions,
 			},
 		}
+		// Helm values with overrides applied
+		if idx < len(desc.Spec.Raw) && len(desc.Spec.Raw[idx]) > 0 {
+			hr.Spec.Overrides = desc.Spec.Raw[idx]
+		}
+		if len(hr.Spec.ChartPullSecret.Name) > 0 && len(hr.Spec.ChartPullSecret.Namespace) == 0 {
+			hr.Spec.ChartPullSecret.Namespace = chart.Namespace
+		}
 		hrsToBeDeleted.Delete(klog.KObj(hr).String())
 
 		err = deployer.syncHelmRelease(desc, hr)
@@ -439,28 +524,32 @@ func (deployer *Deployer) deleteHelmRelease(ctx context.Context, namespacedKey s
 	return err
 }
 
-func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
-	klog.V(5).Infof("handle HelmRelease %s", klog.KObj(hr))
-	config, err := utils.GetChildClusterConfig(deployer.secretLister, deployer.clusterLister, hr.Namespace, hr.Labels[known.ClusterIDLabel])
-	if err != nil {
-		return err
+// isOrphaned tells whether the helm release should be kept installed when the HelmRelease gets deleted,
+// which follows the DeletionPolicy of the Description it belongs to.
+func (deployer *Deployer) isOrphaned(hr *appsapi.HelmRelease) bool {
+	if hr.Labels[known.ConfigKindLabel] != descriptionKind.Kind {
+		return false
 	}
-
-	deployCtx, err := newDeployContext(config)
-	if err != nil {
-		return err
+	desc, err := deployer.descLister.Descriptions(hr.Labels[known.ConfigNamespaceLabel]).Get(hr.Labels[known.ConfigNameLabel])
+	if err != nil || string(desc.UID) != hr.Labels[known.ConfigUIDLabel] {
+		return false
 	}
-	cfg := new(action.Configuration)
-	err = cfg.Init(deployCtx, hr.Spec.TargetNamespace, "secret", klog.V(5).Infof)
+	return desc.DeletionTimestamp != nil && desc.Spec.DeletionPolicy != nil &&
+		*desc.Spec.DeletionPolicy == metav1.DeletePropagationOrphan
+}
+
+func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
+	klog.V(5).Infof("handle HelmRelease %s", klog.KObj(hr))
+	cfg, _, err := deployer.getActionConfig(hr)
 	if err != nil {
 		return err
 	}
-	cfg.Releases.MaxHistory = 5
 
 	// delete helm release
 	if hr.DeletionTimestamp != nil {
-		err := UninstallRelease(cfg, hr)
-		if err != nil {
+		if deployer.isOrphaned(hr) {
+			klog.V(4).Infof("keep helm release of HelmRelease %s installed", klog.KObj(hr))
+		} else if err := UninstallRelease(cfg, hr); err != nil {
 			return err
 		}
 
@@ -470,7 +559,13 @@ func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
 	}
 
 	// install or upgrade helm release
-	chart, err := LocateHelmChart(hr.Spec.Repository, hr.Spec.Chart, hr.Spec.ChartVersion)
+	repoOpts, cleanup, err := deployer.getRepoOptions(hr.Spec.HelmOptions, hr.Namespace)
+	if err != nil {
+		deployer.recorder.Event(hr, corev1.EventTypeWarning, "ChartPullSecretFailure", err.Error())
+		return err
+	}
+	defer cleanup()
+	chart, err := LocateHelmChart(hr.Spec.Repository, hr.Spec.Chart, hr.Spec.ChartVersion, repoOpts)
 	if err != nil {
 		deployer.recorder.Event(hr, corev1.EventTypeWarning, "ChartLocateFailure", err.Error())
 		return err
@@ -500,9 +595,14 @@ func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
 	if err != nil {
 		// repo update
 		if strings.Contains(err.Error(), "helm repo update") {
-			return UpdateRepo(hr.Spec.Repository)
+			return UpdateRepo(hr.Spec.Repository, repoOpts)
 		}
 
+		if hr.Status.Phase != release.StatusFailed {
+			deployer.lifecycleRecorder.ClusterEvent(hr, corev1.EventTypeWarning, events.ReasonDescriptionFailed,
+				fmt.Sprintf("HelmRelease %s failed to be released to cluster %s: %v", klog.KObj(hr),
+					hr.Labels[known.ClusterNameLabel], err))
+		}
 		if err := deployer.helmReleaseController.UpdateHelmReleaseStatus(hr, &appsapi.HelmReleaseStatus{
 			Phase: release.StatusFailed,
 			Notes: err.Error(),
@@ -512,8 +612,26 @@ func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
 		return err
 	}
 
+	var testErr error
+	var conditions []metav1.Condition
+	if hr.Spec.RunTests {
+		var testedRel *release.Release
+		testedRel, testErr = RunReleaseTests(cfg, hr, rel)
+		if testedRel != nil {
+			rel = testedRel
+		}
+		if testErr != nil {
+			msg := fmt.Sprintf("failed to run tests of HelmRelease %s: %v", klog.KObj(hr), testErr)
+			klog.WarningDepth(5, msg)
+			deployer.recorder.Event(hr, corev1.EventTypeWarning, "TestsFailed", msg)
+		}
+		conditions = append(conditions, GetTestCondition(rel))
+	}
+
 	status := &appsapi.HelmReleaseStatus{
-		Version: rel.Version,
+		Version:    rel.Version,
+		Hooks:      GetHookStatuses(rel),
+		Conditions: conditions,
 	}
 	if rel.Info != nil {
 		status.FirstDeployed = rel.Info.FirstDeployed.String()
@@ -523,7 +641,38 @@ func (deployer *Deployer) handleHelmRelease(hr *appsapi.HelmRelease) error {
 		status.Notes = rel.Info.Notes
 	}
 
-	return deployer.helmReleaseController.UpdateHelmReleaseStatus(hr, status)
+	if status.Phase == release.StatusDeployed && hr.Status.Phase != release.StatusDeployed {
+		deployer.lifecycleRecorder.ClusterEvent(hr, corev1.EventTypeNormal, events.ReasonDescriptionApplied,
+			fmt.Sprintf("HelmRelease %s is released to cluster %s", klog.KObj(hr), hr.Labels[known.ClusterNameLabel]))
+	}
+	if err := deployer.helmReleaseController.UpdateHelmReleaseStatus(hr, status); err != nil {
+		return err
+	}
+	// retry the tests that have not completed
+	if len(conditions) > 0 && conditions[0].Status == metav1.ConditionUnknown {
+		return fmt.Errorf("tests of HelmRelease %s are not completed: %v", klog.KObj(hr), testErr)
+	}
+	return nil
+}
+
+// getActionConfig returns the helm action configuration for the child cluster of the HelmRelease.
+func (deployer *Deployer) getActionConfig(hr *appsapi.HelmRelease) (*action.Configuration, *deployContext, error) {
+	config, err := utils.GetChildClusterConfig(deployer.secretLister, deployer.clusterLister, hr.Namespace, hr.Labels[known.ClusterIDLabel])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deployCtx, err := newDeployContext(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := new(action.Configuration)
+	err = cfg.Init(deployCtx, hr.Spec.TargetNamespace, "secret", klog.V(5).Infof)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.Releases.MaxHistory = 5
+	return cfg, deployCtx, nil
 }
 
 func (deployer *Deployer) handleSecret(secret *corev1.Secret) error {
@@ -558,62 +707,70 @@ func (deployer *Deployer) handleSecret(secret *corev1.Secret) error {
 	return err
 }
 
-func (deployer *Deployer) getOverrides(hr *appsapi.HelmRelease) (map[string]interface{}, error) {
-	var overrideValues map[string]interface{}
-	// get overrides
-	controllerRef := metav1.GetControllerOf(hr)
-	if controllerRef != nil {
-		desc := deployer.resolveControllerRef(hr.Namespace, controllerRef)
-		if desc == nil {
-			return overrideValues, nil
-		}
-
-		var found bool
-		var index int
-		for idx, chart := range desc.Spec.Charts {
-			if chart.Namespace == hr.Namespace && chart.Name == hr.Name {
-				found = true
-				index = idx
-				break
-			}
+// getRepoOptions returns the options to access the chart repository, with the credentials and certificates in the
+// Secret referenced by ChartPullSecret. Certificates are written into temporary files, which are removed by cleanup.
+func (deployer *Deployer) getRepoOptions(helmOptions appsapi.HelmOptions, defaultNamespace string) (RepoOptions, func(), error) {
+	opts := RepoOptions{
+		InsecureSkipTLSVerify: helmOptions.InsecureSkipTLSVerify,
+	}
+	cleanup := func() {}
+	ref := helmOptions.ChartPullSecret
+	if len(ref.Name) == 0 {
+		return opts, cleanup, nil
+	}
+
+	namespace := ref.Namespace
+	if len(namespace) == 0 {
+		namespace = defaultNamespace
+	}
+	secret, err := deployer.secretLister.Secrets(namespace).Get(ref.Name)
+	if err != nil {
+		return opts, cleanup, fmt.Errorf("failed to get chart pull secret %s/%s: %v", namespace, ref.Name, err)
+	}
+	opts.Username = string(secret.Data["username"])
+	opts.Password = string(secret.Data["password"])
+
+	var files []string
+	cleanup = func() {
+		for _, file := range files {
+			os.Remove(file)
 		}
-		if !found {
-			msg := fmt.Sprintf("Description %s has no connection with HelmRelease %s", klog.KObj(desc), klog.KObj(hr))
-			klog.WarningDepth(5, msg)
-			deployer.recorder.Event(desc, corev1.EventTypeWarning, "DescriptionNotRelated", msg)
-			return overrideValues, nil
+	}
+	for key, file := range map[string]*string{
+		"ca.crt":                &opts.CAFile,
+		corev1.TLSCertKey:       &opts.CertFile,
+		corev1.TLSPrivateKeyKey: &opts.KeyFile,
+	} {
+		data, ok := secret.Data[key]
+		if !ok {
+			continue
 		}
-		if len(desc.Spec.Raw) < index {
-			msg := fmt.Sprintf("unequal lengths of Spec.Raw and Spec.Charts in Description %s", klog.KObj(desc))
-			klog.ErrorDepth(5, msg)
-			deployer.recorder.Event(desc, corev1.EventTypeWarning, "UnequalLengths", msg)
-			return nil, errors.New(msg)
+		f, err := ioutil.TempFile("", "clusternet-chart-"+key)
+		if err != nil {
+			cleanup()
+			return opts, func() {}, err
+		}
+		files = append(files, f.Name())
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			cleanup()
+			return opts, func() {}, err
 		}
-		err := json.Unmarshal(desc.Spec.Raw[index], &overrideValues)
-		return overrideValues, err
+		*file = f.Name()
 	}
-	return overrideValues, nil
+	return opts, cleanup, nil
 }
 
-// resolveControllerRef returns the controller referenced by a ControllerRef,
-// or nil if the ControllerRef could not be resolved to a matching controller
-// of the correct Kind.
-func (deployer *Deployer) resolveControllerRef(namespace string, controllerRef *metav1.OwnerReference) *appsapi.Description {
-	// We can't look up by UID, so look up by Name and then verify UID.
-	// Don't even try to look up by Name if it's the wrong Kind.
-	if controllerRef.Kind != descriptionKind.Kind {
-		return nil
-	}
-	desc, err := deployer.descLister.Descriptions(namespace).Get(controllerRef.Name)
-	if err != nil {
-		return nil
-	}
-	if desc.UID != controllerRef.UID {
-		// The controller we found with this Name is not the same one that the
-		// ControllerRef points to.
-		return nil
+func (deployer *Deployer) getOverrides(hr *appsapi.HelmRelease) (map[string]interface{}, error) {
+	var overrideValues map[string]interface{}
+	if len(hr.Spec.Overrides) == 0 {
+		return overrideValues, nil
 	}
-	return desc
+	err := json.Unmarshal(hr.Spec.Overrides, &overrideValues)
+	return overrideValues, err
 }
 
 func (deployer *Deployer) protectHelmChartFeed(chart *appsapi.HelmChart) error {
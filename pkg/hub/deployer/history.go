@@ -0,0 +1,157 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// saveDescriptionRevisions keeps the Descriptions of the Base in ControllerRevisions before they are updated
+// to another rollout revision, so that the Base could be rolled back later.
+func (deployer *Deployer) saveDescriptionRevisions(base *appsapi.Base, descs []*appsapi.Description, revision string) error {
+	for _, desc := range descs {
+		oldRevision := desc.Labels[known.RolloutRevisionLabel]
+		if len(oldRevision) == 0 || oldRevision == revision {
+			continue
+		}
+
+		snapshot := &appsapi.Description{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            desc.Name,
+				Namespace:       desc.Namespace,
+				Labels:          desc.Labels,
+				Finalizers:      desc.Finalizers,
+				OwnerReferences: desc.OwnerReferences,
+			},
+			Spec: desc.Spec,
+		}
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+
+		cr := &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", desc.Name, oldRevision),
+				Namespace: desc.Namespace,
+				Labels: map[string]string{
+					known.ObjectCreatedByLabel: known.ClusternetHubName,
+					known.ConfigKindLabel:      baseKind.Kind,
+					known.ConfigNameLabel:      base.Name,
+					known.ConfigNamespaceLabel: base.Namespace,
+					known.ConfigUIDLabel:       string(base.UID),
+					known.RolloutRevisionLabel: oldRevision,
+				},
+				// removed together with the Base
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(base, baseKind),
+				},
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: desc.Generation,
+		}
+		_, err = deployer.kubeClient.AppsV1().ControllerRevisions(cr.Namespace).Create(context.TODO(), cr, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackBase reverts the Descriptions of the Base to the ones kept at the revision
+func (deployer *Deployer) rollbackBase(base *appsapi.Base, revision string) error {
+	revisions, err := deployer.kubeClient.AppsV1().ControllerRevisions(base.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			known.ConfigKindLabel:      baseKind.Kind,
+			known.ConfigUIDLabel:       string(base.UID),
+			known.RolloutRevisionLabel: revision,
+		}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(revisions.Items) == 0 {
+		// the cluster is newly selected in the rollout
+		klog.V(4).Infof("no Descriptions of revision %s are kept for Base %s, skip rolling back", revision, klog.KObj(base))
+		return nil
+	}
+
+	kept := sets.String{}
+	for _, cr := range revisions.Items {
+		snapshot := &appsapi.Description{}
+		if err := json.Unmarshal(cr.Data.Raw, snapshot); err != nil {
+			return err
+		}
+		kept.Insert(snapshot.Name)
+
+		desc, err := deployer.descLister.Descriptions(snapshot.Namespace).Get(snapshot.Name)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(snapshot.Namespace).Create(context.TODO(),
+				snapshot, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		desc = desc.DeepCopy()
+		desc.Labels = snapshot.Labels
+		desc.Spec = snapshot.Spec
+		_, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(),
+			desc, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	descs, err := deployer.descLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigKindLabel:      baseKind.Kind,
+		known.ConfigNameLabel:      base.Name,
+		known.ConfigNamespaceLabel: base.Namespace,
+		known.ConfigUIDLabel:       string(base.UID),
+	}))
+	if err != nil {
+		return err
+	}
+	for _, desc := range descs {
+		if kept.Has(desc.Name) {
+			continue
+		}
+		if err := deployer.deleteDescription(context.TODO(), klog.KObj(desc).String()); err != nil {
+			return err
+		}
+	}
+
+	// keep the Base at the revision, so that its Descriptions won't be updated until next rollout
+	return deployer.admitBases([]*appsapi.Base{base}, revision)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/deployer/platform"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// podTemplateMetadataPaths are the paths of pod template metadata in commonly used workloads
+var podTemplateMetadataPaths = map[string][]string{
+	"Pod":         {"metadata"},
+	"Deployment":  {"spec", "template", "metadata"},
+	"StatefulSet": {"spec", "template", "metadata"},
+	"DaemonSet":   {"spec", "template", "metadata"},
+	"ReplicaSet":  {"spec", "template", "metadata"},
+	"Job":         {"spec", "template", "metadata"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "metadata"},
+}
+
+// injectClusterIdentity injects the identity of the target cluster as labels into the pod templates
+// of the objects in a generic Description.
+func (deployer *Deployer) injectClusterIdentity(desc *appsapi.Description) error {
+	if desc.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+		return nil
+	}
+
+	identity := map[string]string{
+		known.ClusterIDLabel:   desc.Labels[known.ClusterIDLabel],
+		known.ClusterNameLabel: desc.Labels[known.ClusterNameLabel],
+	}
+	mcls, err := deployer.getClusterOfDescription(desc)
+	if err != nil {
+		return err
+	}
+	if mcls != nil {
+		if region, ok := mcls.Labels[corev1.LabelTopologyRegion]; ok {
+			identity[corev1.LabelTopologyRegion] = region
+		}
+	}
+
+	for idx, rawObject := range desc.Spec.Raw {
+		result, err := injectLabelsIntoPodTemplate(rawObject, identity)
+		if err != nil {
+			return fmt.Errorf("failed to inject cluster identity: %v", err)
+		}
+		desc.Spec.Raw[idx] = result
+	}
+	return nil
+}
+
+// rewriteImageRegistries rewrites the container images in a generic Description with the registry mirrors
+// annotated on the target cluster.
+func (deployer *Deployer) rewriteImageRegistries(desc *appsapi.Description) error {
+	if desc.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+		return nil
+	}
+
+	mcls, err := deployer.getClusterOfDescription(desc)
+	if err != nil {
+		return err
+	}
+	if mcls == nil || len(mcls.Annotations[known.ImageRegistryMirrorsAnnotation]) == 0 {
+		return nil
+	}
+	mirrors, err := platform.ParseRegistryMirrors(mcls.Annotations[known.ImageRegistryMirrorsAnnotation])
+	if err != nil {
+		return fmt.Errorf("invalid annotation %s of ManagedCluster %s: %v", known.ImageRegistryMirrorsAnnotation,
+			klog.KObj(mcls), err)
+	}
+
+	for idx, rawObject := range desc.Spec.Raw {
+		result, err := platform.RewriteImageRegistries(rawObject, mirrors)
+		if err != nil {
+			return err
+		}
+		desc.Spec.Raw[idx] = result
+	}
+	return nil
+}
+
+// updateClusterImageRegistryMirrors re-populates the Descriptions in the namespace of the ManagedCluster
+// when its registry mirrors change.
+func (deployer *Deployer) updateClusterImageRegistryMirrors(old, cur interface{}) {
+	oldMcls := old.(*clusterapi.ManagedCluster)
+	newMcls := cur.(*clusterapi.ManagedCluster)
+	if oldMcls.Annotations[known.ImageRegistryMirrorsAnnotation] == newMcls.Annotations[known.ImageRegistryMirrorsAnnotation] {
+		return
+	}
+
+	bases, err := deployer.baseLister.Bases(newMcls.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list Bases in namespace %s: %v", newMcls.Namespace, err)
+		return
+	}
+	for _, base := range bases {
+		deployer.baseController.Enqueue(base)
+	}
+}
+
+// getClusterOfDescription returns the ManagedCluster that the Description is deployed to, or nil if not found.
+func (deployer *Deployer) getClusterOfDescription(desc *appsapi.Description) (*clusterapi.ManagedCluster, error) {
+	mcls, err := deployer.clusterLister.ManagedClusters(desc.Namespace).List(
+		labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: desc.Labels[known.ClusterIDLabel]}))
+	if err != nil {
+		return nil, err
+	}
+	if len(mcls) == 0 {
+		return nil, nil
+	}
+	return mcls[0], nil
+}
+
+// injectLabelsIntoPodTemplate adds given labels into the pod template of the object.
+// Objects that have no pod templates are returned unchanged.
+func injectLabelsIntoPodTemplate(rawObject []byte, extraLabels map[string]string) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(rawObject, obj); err != nil {
+		return nil, err
+	}
+
+	path, ok := podTemplateMetadataPaths[obj.GetKind()]
+	if !ok {
+		return rawObject, nil
+	}
+
+	podLabels, _, err := unstructured.NestedStringMap(obj.Object, append(path, "labels")...)
+	if err != nil {
+		return nil, err
+	}
+	if podLabels == nil {
+		podLabels = map[string]string{}
+	}
+	for key, value := range extraLabels {
+		if len(value) == 0 {
+			continue
+		}
+		podLabels[key] = value
+	}
+	if err = unstructured.SetNestedStringMap(obj.Object, podLabels, append(path, "labels")...); err != nil {
+		return nil, err
+	}
+	return obj.MarshalJSON()
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// reasons why a cluster is added to or removed from the placement of a Subscription
+const (
+	// PlacementReasonInitial means the Subscription is placed for the first time.
+	PlacementReasonInitial = "InitialPlacement"
+	// PlacementReasonClusterMatched means the cluster newly matches the cluster affinity and satisfies
+	// all the constraints, such as a new cluster joins or a cluster recovers.
+	PlacementReasonClusterMatched = "ClusterMatched"
+	// PlacementReasonLabelChange means the cluster stops matching the cluster affinity,
+	// due to changes of either the cluster labels or the subscribers.
+	PlacementReasonLabelChange = "LabelChange"
+	// PlacementReasonFailover means the cluster is removed while it is not ready.
+	PlacementReasonFailover = "Failover"
+	// PlacementReasonClusterDeleted means the cluster no longer exists.
+	PlacementReasonClusterDeleted = "ClusterDeleted"
+	// PlacementReasonConstraintChange means the cluster stops satisfying constraints of the feeds,
+	// such as image platforms and data residency.
+	PlacementReasonConstraintChange = "ConstraintChange"
+)
+
+// defaultPlacementWebhookTimeout is the timeout of sending a placement notification to the webhook
+const defaultPlacementWebhookTimeout = 10 * time.Second
+
+// ClusterPlacement describes a cluster added to or removed from the placement of a Subscription.
+type ClusterPlacement struct {
+	// Namespace is the dedicated namespace of the cluster
+	Namespace string `json:"namespace"`
+	// ClusterID is the unique id of the cluster
+	ClusterID string `json:"clusterId,omitempty"`
+	// ClusterName is the name of the cluster
+	ClusterName string `json:"clusterName,omitempty"`
+	// Reason is why the cluster is added or removed
+	Reason string `json:"reason"`
+}
+
+// PlacementChange is the payload of a placement notification, which describes
+// how the feeds of a Subscription move between clusters.
+type PlacementChange struct {
+	SubscriptionNamespace string    `json:"subscriptionNamespace"`
+	SubscriptionName      string    `json:"subscriptionName"`
+	SubscriptionUID       types.UID `json:"subscriptionUID"`
+
+	// Before and After are the namespaces of the clusters before and after the re-scheduling
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+
+	Added   []ClusterPlacement `json:"added,omitempty"`
+	Removed []ClusterPlacement `json:"removed,omitempty"`
+
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// getPlacementChange compares the clusters of existing Bases with the newly scheduled clusters.
+// It returns nil if the placement doesn't change.
+func (deployer *Deployer) getPlacementChange(sub *appsapi.Subscription, existingBases []*appsapi.Base,
+	mcls []*clusterapi.ManagedCluster) (*PlacementChange, error) {
+	before := map[string]*appsapi.Base{}
+	for _, base := range existingBases {
+		before[base.Namespace] = base
+	}
+	after := map[string]*clusterapi.ManagedCluster{}
+	for _, cluster := range mcls {
+		after[cluster.Namespace] = cluster
+	}
+
+	change := &PlacementChange{
+		SubscriptionNamespace: sub.Namespace,
+		SubscriptionName:      sub.Name,
+		SubscriptionUID:       sub.UID,
+		Before:                []string{},
+		After:                 []string{},
+		Timestamp:             metav1.Now(),
+	}
+	for namespace := range before {
+		change.Before = append(change.Before, namespace)
+	}
+	for namespace := range after {
+		change.After = append(change.After, namespace)
+	}
+	sort.Strings(change.Before)
+	sort.Strings(change.After)
+
+	for _, namespace := range change.After {
+		if _, ok := before[namespace]; ok {
+			continue
+		}
+		reason := PlacementReasonClusterMatched
+		if len(before) == 0 {
+			reason = PlacementReasonInitial
+		}
+		change.Added = append(change.Added, ClusterPlacement{
+			Namespace:   namespace,
+			ClusterID:   after[namespace].Labels[known.ClusterIDLabel],
+			ClusterName: after[namespace].Labels[known.ClusterNameLabel],
+			Reason:      reason,
+		})
+	}
+
+	for _, namespace := range change.Before {
+		if _, ok := after[namespace]; ok {
+			continue
+		}
+		base := before[namespace]
+		reason, err := deployer.getRemovalReason(sub, base)
+		if err != nil {
+			return nil, err
+		}
+		change.Removed = append(change.Removed, ClusterPlacement{
+			Namespace:   namespace,
+			ClusterID:   base.Labels[known.ClusterIDLabel],
+			ClusterName: base.Labels[known.ClusterNameLabel],
+			Reason:      reason,
+		})
+	}
+
+	if len(change.Added) == 0 && len(change.Removed) == 0 {
+		return nil, nil
+	}
+	return change, nil
+}
+
+// getRemovalReason tells why the cluster of the Base is removed from the placement.
+func (deployer *Deployer) getRemovalReason(sub *appsapi.Subscription, base *appsapi.Base) (string, error) {
+	clusters, err := deployer.clusterLister.ManagedClusters(base.Namespace).List(labels.SelectorFromSet(labels.Set{
+		known.ClusterIDLabel: base.Labels[known.ClusterIDLabel],
+	}))
+	if err != nil {
+		return "", err
+	}
+	if len(clusters) == 0 || clusters[0].DeletionTimestamp != nil {
+		return PlacementReasonClusterDeleted, nil
+	}
+
+	cluster := clusters[0]
+	if !matchesClusterAffinity(sub, cluster) {
+		return PlacementReasonLabelChange, nil
+	}
+	if cond := apimeta.FindStatusCondition(cluster.Status.Conditions, clusterapi.ClusterReady); cond != nil && cond.Status == metav1.ConditionFalse {
+		return PlacementReasonFailover, nil
+	}
+	return PlacementReasonConstraintChange, nil
+}
+
+func matchesClusterAffinity(sub *appsapi.Subscription, cluster *clusterapi.ManagedCluster) bool {
+	for _, subscriber := range sub.Spec.Subscribers {
+		selector, err := metav1.LabelSelectorAsSelector(subscriber.ClusterAffinity)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(cluster.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyPlacementChange records an event on the Subscription, and sends the change to the webhook if configured.
+func (deployer *Deployer) notifyPlacementChange(sub *appsapi.Subscription, change *PlacementChange) {
+	deployer.recorder.Event(sub, corev1.EventTypeNormal, "PlacementChanged", formatPlacementChange(change))
+	deployer.lifecycleRecorder.SubscriptionEvent(sub, corev1.EventTypeNormal, events.ReasonScheduled,
+		fmt.Sprintf("Subscription is scheduled to %d clusters", len(change.After)))
+
+	if len(deployer.placementWebhook) == 0 {
+		return
+	}
+	payload, err := json.Marshal(change)
+	if err != nil {
+		klog.Errorf("failed to marshal placement change of Subscription %s: %v", klog.KObj(sub), err)
+		return
+	}
+	// do not block the reconciling
+	go func() {
+		ctx, cancel := context.WithTimeout(deployer.ctx, defaultPlacementWebhookTimeout)
+		defer cancel()
+		if err := events.PostWebhook(ctx, deployer.placementWebhook, payload); err != nil {
+			klog.Warningf("failed to send placement change of Subscription %s to webhook: %v", klog.KObj(sub), err)
+		}
+	}()
+}
+
+// formatPlacementChange formats the change as "added: ns-a (LabelChange); removed: ns-b (Failover)".
+func formatPlacementChange(change *PlacementChange) string {
+	format := func(placements []ClusterPlacement) string {
+		var items []string
+		for _, p := range placements {
+			items = append(items, fmt.Sprintf("%s (%s)", p.Namespace, p.Reason))
+		}
+		return strings.Join(items, ", ")
+	}
+
+	var msgs []string
+	if len(change.Added) > 0 {
+		msgs = append(msgs, fmt.Sprintf("added: %s", format(change.Added)))
+	}
+	if len(change.Removed) > 0 {
+		msgs = append(msgs, fmt.Sprintf("removed: %s", format(change.Removed)))
+	}
+	return fmt.Sprintf("placement changed from %d to %d clusters, %s",
+		len(change.Before), len(change.After), strings.Join(msgs, "; "))
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	defaultRegistry   = "docker.io"
+	defaultRegistryV2 = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// podSpecPaths are the paths of pod specs in commonly used workloads
+var podSpecPaths = [][]string{
+	{"spec"},                     // Pod
+	{"spec", "template", "spec"}, // Deployment, StatefulSet, DaemonSet, ReplicaSet, Job, etc
+	{"spec", "jobTemplate", "spec", "template", "spec"}, // CronJob
+}
+
+// GetImagesFromManifests returns all the container images referred in the templates of given Manifests.
+func GetImagesFromManifests(manifests []*appsapi.Manifest) ([]string, error) {
+	images := sets.NewString()
+	for _, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(manifest.Template.Raw); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal template of Manifest %s/%s: %v", manifest.Namespace, manifest.Name, err)
+		}
+
+		for _, path := range podSpecPaths {
+			for _, field := range []string{"initContainers", "containers"} {
+				containers, found, err := unstructured.NestedSlice(obj.Object, append(path, field)...)
+				if !found || err != nil {
+					continue
+				}
+				for _, container := range containers {
+					c, ok := container.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					image, ok := c["image"].(string)
+					if ok && len(image) > 0 {
+						images.Insert(image)
+					}
+				}
+			}
+		}
+	}
+	return images.List(), nil
+}
+
+// IsCompatible checks whether all the platforms of the nodes are provided by the image.
+// Platform variants, such as "linux/arm/v7", are ignored during comparison.
+func IsCompatible(nodePlatforms map[string]int32, imagePlatforms []string) bool {
+	provided := sets.NewString()
+	for _, platform := range imagePlatforms {
+		provided.Insert(trimVariant(platform))
+	}
+
+	for platform, count := range nodePlatforms {
+		if count <= 0 {
+			continue
+		}
+		if !provided.Has(trimVariant(platform)) {
+			return false
+		}
+	}
+	return true
+}
+
+func trimVariant(platform string) string {
+	parts := strings.Split(platform, "/")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, "/")
+}
+
+// parseImageReference splits an image reference into registry host, repository and reference (tag or digest),
+// following the normalization rules of docker.
+func parseImageReference(image string) (registry, repository, reference string) {
+	name := image
+	reference = defaultTag
+	if idx := strings.Index(name, "@"); idx != -1 {
+		name, reference = name[:idx], name[idx+1:]
+	} else if idx := strings.LastIndex(name, ":"); idx != -1 && !strings.Contains(name[idx+1:], "/") {
+		name, reference = name[:idx], name[idx+1:]
+	}
+
+	registry = defaultRegistry
+	repository = name
+	if idx := strings.Index(name, "/"); idx != -1 {
+		domain := name[:idx]
+		if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+			registry, repository = domain, name[idx+1:]
+		}
+	}
+
+	if registry == defaultRegistry {
+		registry = defaultRegistryV2
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	return
+}
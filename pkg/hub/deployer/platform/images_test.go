@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import (
+	"testing"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image      string
+		registry   string
+		repository string
+		reference  string
+	}{
+		{"nginx", "registry-1.docker.io", "library/nginx", "latest"},
+		{"nginx:1.21", "registry-1.docker.io", "library/nginx", "1.21"},
+		{"bitnami/redis:6.2", "registry-1.docker.io", "bitnami/redis", "6.2"},
+		{"docker.io/library/busybox", "registry-1.docker.io", "library/busybox", "latest"},
+		{"ghcr.io/clusternet/clusternet-hub:v0.5.0", "ghcr.io", "clusternet/clusternet-hub", "v0.5.0"},
+		{"localhost:5000/app", "localhost:5000", "app", "latest"},
+		{"quay.io/coreos/etcd@sha256:abcd", "quay.io", "coreos/etcd", "sha256:abcd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			registry, repository, reference := parseImageReference(tt.image)
+			if registry != tt.registry || repository != tt.repository || reference != tt.reference {
+				t.Errorf("parseImageReference() = (%s, %s, %s), want (%s, %s, %s)",
+					registry, repository, reference, tt.registry, tt.repository, tt.reference)
+			}
+		})
+	}
+}
+
+func TestIsCompatible(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodePlatforms  map[string]int32
+		imagePlatforms []string
+		want           bool
+	}{
+		{
+			name:           "all platforms provided",
+			nodePlatforms:  map[string]int32{"linux/amd64": 3, "linux/arm64": 1},
+			imagePlatforms: []string{"linux/amd64", "linux/arm64", "linux/arm/v7"},
+			want:           true,
+		},
+		{
+			name:           "platform missing",
+			nodePlatforms:  map[string]int32{"linux/amd64": 3, "linux/arm64": 1},
+			imagePlatforms: []string{"linux/amd64"},
+			want:           false,
+		},
+		{
+			name:           "variant ignored",
+			nodePlatforms:  map[string]int32{"linux/arm": 2},
+			imagePlatforms: []string{"linux/arm/v7"},
+			want:           true,
+		},
+		{
+			name:           "no nodes reported",
+			nodePlatforms:  nil,
+			imagePlatforms: []string{"linux/amd64"},
+			want:           true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCompatible(tt.nodePlatforms, tt.imagePlatforms); got != tt.want {
+				t.Errorf("IsCompatible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// default duration to cache the platforms of an image
+	defaultCacheTTL = 10 * time.Minute
+
+	// default timeout when talking to registries
+	defaultTimeout = 10 * time.Second
+)
+
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// imageManifest holds the fields we care about in an image manifest or manifest list/index
+type imageManifest struct {
+	Manifests []struct {
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+			Variant      string `json:"variant,omitempty"`
+		} `json:"platform"`
+	} `json:"manifests,omitempty"`
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config,omitempty"`
+}
+
+// imageConfig holds the fields we care about in an image config blob
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+type cacheEntry struct {
+	platforms []string
+	expiry    time.Time
+}
+
+// Inspector inspects the platforms that images provide by querying registries anonymously
+// through Docker Registry HTTP API V2.
+type Inspector struct {
+	client *http.Client
+
+	lock  sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewInspector returns a new Inspector.
+func NewInspector() *Inspector {
+	return &Inspector{
+		client: &http.Client{Timeout: defaultTimeout},
+		cache:  make(map[string]cacheEntry),
+	}
+}
+
+// GetPlatforms returns the platforms, such as "linux/amd64", that the image provides.
+func (i *Inspector) GetPlatforms(ctx context.Context, image string) ([]string, error) {
+	i.lock.Lock()
+	entry, ok := i.cache[image]
+	i.lock.Unlock()
+	if ok && time.Now().Before(entry.expiry) {
+		return entry.platforms, nil
+	}
+
+	platforms, err := i.inspect(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	klog.V(5).Infof("image %s provides platforms %v", image, platforms)
+
+	i.lock.Lock()
+	i.cache[image] = cacheEntry{platforms: platforms, expiry: time.Now().Add(defaultCacheTTL)}
+	i.lock.Unlock()
+	return platforms, nil
+}
+
+func (i *Inspector) inspect(ctx context.Context, image string) ([]string, error) {
+	registry, repository, reference := parseImageReference(image)
+
+	manifest := &imageManifest{}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, reference)
+	if err := i.getJSON(ctx, manifestURL, manifestMediaTypes, manifest); err != nil {
+		return nil, fmt.Errorf("failed to get manifest of image %s: %v", image, err)
+	}
+
+	// manifest list or image index
+	if len(manifest.Manifests) > 0 {
+		var platforms []string
+		for _, m := range manifest.Manifests {
+			platforms = append(platforms, formatPlatform(m.Platform.OS, m.Platform.Architecture, m.Platform.Variant))
+		}
+		return platforms, nil
+	}
+
+	// single-platform image, where platform is recorded in the config blob
+	if len(manifest.Config.Digest) == 0 {
+		return nil, fmt.Errorf("no config found in manifest of image %s", image)
+	}
+	config := &imageConfig{}
+	configURL := fmt.Sprintf("https://%s/v2/%s/blobs/%s", registry, repository, manifest.Config.Digest)
+	if err := i.getJSON(ctx, configURL, nil, config); err != nil {
+		return nil, fmt.Errorf("failed to get config of image %s: %v", image, err)
+	}
+	return []string{formatPlatform(config.OS, config.Architecture, config.Variant)}, nil
+}
+
+// getJSON gets the url and decodes the response body into v.
+// Anonymous bearer tokens will be requested when the registry challenges for one.
+func (i *Inspector) getJSON(ctx context.Context, rawURL string, accepts []string, v interface{}) error {
+	resp, err := i.do(ctx, rawURL, accepts, "")
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		token, err := i.getToken(ctx, challenge)
+		if err != nil {
+			return err
+		}
+		resp, err = i.do(ctx, rawURL, accepts, token)
+		if err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, rawURL)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (i *Inspector) do(ctx context.Context, rawURL string, accepts []string, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accepts) > 0 {
+		req.Header.Set("Accept", strings.Join(accepts, ", "))
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return i.client.Do(req)
+}
+
+// getToken requests an anonymous token with a challenge like
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`
+func (i *Inspector) getToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, param := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[kv[0]] = strings.Trim(kv[1], `"`)
+	}
+	realm, ok := params["realm"]
+	if !ok {
+		return "", fmt.Errorf("no realm found in authentication challenge %q", challenge)
+	}
+
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if val, ok := params[key]; ok {
+			query.Set(key, val)
+		}
+	}
+	resp, err := i.do(ctx, realm+"?"+query.Encode(), nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d when requesting token from %s", resp.StatusCode, realm)
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	if len(token.Token) > 0 {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+func formatPlatform(os, arch, variant string) string {
+	if len(variant) > 0 {
+		return fmt.Sprintf("%s/%s/%s", os, arch, variant)
+	}
+	return fmt.Sprintf("%s/%s", os, arch)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ParseRegistryMirrors parses the registry mirrors in the form of "docker.io=mirror.example.com/dockerhub,quay.io=...".
+// The source could be a registry, or a registry with repository prefix, such as "docker.io/library".
+func ParseRegistryMirrors(value string) (map[string]string, error) {
+	mirrors := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid registry mirror %q, which should be in the form of <source>=<mirror>", pair)
+		}
+		source, mirror := strings.Trim(strings.TrimSpace(parts[0]), "/"), strings.Trim(strings.TrimSpace(parts[1]), "/")
+		if len(source) == 0 || len(mirror) == 0 {
+			return nil, fmt.Errorf("invalid registry mirror %q, which should be in the form of <source>=<mirror>", pair)
+		}
+		mirrors[source] = mirror
+	}
+	return mirrors, nil
+}
+
+// RewriteImageRegistries rewrites the registries of the container images in the pod templates of the object with
+// the mirrors. The longest matched source takes precedence. Objects that have no pod templates are returned unchanged.
+func RewriteImageRegistries(rawObject []byte, mirrors map[string]string) ([]byte, error) {
+	if len(mirrors) == 0 {
+		return rawObject, nil
+	}
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(rawObject); err != nil {
+		return nil, err
+	}
+
+	var changed bool
+	for _, path := range podSpecPaths {
+		for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, found, err := unstructured.NestedSlice(obj.Object, append(path, field)...)
+			if !found || err != nil {
+				continue
+			}
+			for _, container := range containers {
+				c, ok := container.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				image, ok := c["image"].(string)
+				if !ok || len(image) == 0 {
+					continue
+				}
+				if rewritten, ok := rewriteImage(image, mirrors); ok {
+					c["image"] = rewritten
+					changed = true
+				}
+			}
+			if err = unstructured.SetNestedSlice(obj.Object, containers, append(path, field)...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !changed {
+		return rawObject, nil
+	}
+	return obj.MarshalJSON()
+}
+
+// rewriteImage replaces the matched source of the image with its mirror, keeping the tag or digest.
+func rewriteImage(image string, mirrors map[string]string) (string, bool) {
+	registry, repository, _ := parseImageReference(image)
+	if registry == defaultRegistryV2 {
+		registry = defaultRegistry
+	}
+	name := registry + "/" + repository
+
+	// tag or digest explicitly set in the image
+	var suffix string
+	if idx := strings.Index(image, "@"); idx != -1 {
+		suffix = image[idx:]
+	} else if idx := strings.LastIndex(image, ":"); idx != -1 && !strings.Contains(image[idx+1:], "/") {
+		suffix = image[idx:]
+	}
+
+	sources := make([]string, 0, len(mirrors))
+	for source := range mirrors {
+		sources = append(sources, source)
+	}
+	// longest sources first
+	sort.Slice(sources, func(i, j int) bool {
+		if len(sources[i]) != len(sources[j]) {
+			return len(sources[i]) > len(sources[j])
+		}
+		return sources[i] < sources[j]
+	})
+	for _, source := range sources {
+		if name == source || strings.HasPrefix(name, source+"/") {
+			return mirrors[source] + name[len(source):] + suffix, true
+		}
+	}
+	return image, false
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestParseRegistryMirrors(t *testing.T) {
+	got, err := ParseRegistryMirrors(" docker.io=mirror.example.com/dockerhub/ , quay.io/coreos=mirror.example.com/coreos,")
+	if err != nil {
+		t.Fatalf("ParseRegistryMirrors() error = %v", err)
+	}
+	want := map[string]string{
+		"docker.io":      "mirror.example.com/dockerhub",
+		"quay.io/coreos": "mirror.example.com/coreos",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRegistryMirrors() = %v, want %v", got, want)
+	}
+
+	for _, value := range []string{"docker.io", "docker.io=", "=mirror.example.com"} {
+		if _, err := ParseRegistryMirrors(value); err == nil {
+			t.Errorf("ParseRegistryMirrors(%q) expects an error", value)
+		}
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	mirrors := map[string]string{
+		"docker.io":         "mirror.example.com/dockerhub",
+		"docker.io/bitnami": "mirror.example.com/bitnami",
+		"quay.io":           "mirror.example.com/quay",
+	}
+	tests := []struct {
+		image string
+		want  string
+		ok    bool
+	}{
+		{"nginx", "mirror.example.com/dockerhub/library/nginx", true},
+		{"nginx:1.21", "mirror.example.com/dockerhub/library/nginx:1.21", true},
+		{"docker.io/library/busybox", "mirror.example.com/dockerhub/library/busybox", true},
+		{"bitnami/redis:6.2", "mirror.example.com/bitnami/redis:6.2", true},
+		{"quay.io/coreos/etcd@sha256:abcd", "mirror.example.com/quay/coreos/etcd@sha256:abcd", true},
+		{"ghcr.io/clusternet/clusternet-hub:v0.5.0", "ghcr.io/clusternet/clusternet-hub:v0.5.0", false},
+		{"quay.io.example.com/app", "quay.io.example.com/app", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, ok := rewriteImage(tt.image, mirrors)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("rewriteImage() = (%s, %v), want (%s, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRewriteImageRegistries(t *testing.T) {
+	deploy := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"},` +
+		`"spec":{"template":{"spec":{"initContainers":[{"name":"init","image":"busybox"}],` +
+		`"containers":[{"name":"web","image":"nginx:1.21"},{"name":"sidecar","image":"ghcr.io/envoy:v1"}]}}}}`)
+	got, err := RewriteImageRegistries(deploy, map[string]string{"docker.io": "mirror.example.com"})
+	if err != nil {
+		t.Fatalf("RewriteImageRegistries() error = %v", err)
+	}
+	images, err := GetImagesFromManifests([]*appsapi.Manifest{{Template: runtime.RawExtension{Raw: got}}})
+	if err != nil {
+		t.Fatalf("failed to get images: %v", err)
+	}
+	want := []string{"ghcr.io/envoy:v1", "mirror.example.com/library/busybox", "mirror.example.com/library/nginx:1.21"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("RewriteImageRegistries() images = %v, want %v", images, want)
+	}
+
+	configMap := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"conf"}}`)
+	got, err = RewriteImageRegistries(configMap, map[string]string{"docker.io": "mirror.example.com"})
+	if err != nil {
+		t.Fatalf("RewriteImageRegistries() error = %v", err)
+	}
+	if string(got) != string(configMap) {
+		t.Errorf("RewriteImageRegistries() = %s, want unchanged", got)
+	}
+}
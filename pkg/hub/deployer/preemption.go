@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// preemptBases removes the Bases of lower-priority Subscriptions from a cluster to make room for the Subscription.
+// The preempted Subscriptions get re-scheduled, so that they could move to other clusters.
+func (deployer *Deployer) preemptBases(sub *appsapi.Subscription, victims []*appsapi.Base) error {
+	for _, victim := range victims {
+		if err := deployer.deleteBase(context.TODO(), klog.KObj(victim).String()); err != nil {
+			return err
+		}
+		msg := fmt.Sprintf("Preempt Base %s of Subscription %s/%s", klog.KObj(victim),
+			victim.Labels[known.ConfigSubscriptionNamespaceLabel], victim.Labels[known.ConfigSubscriptionNameLabel])
+		klog.V(4).Info(msg)
+		deployer.recorder.Event(sub, corev1.EventTypeNormal, "Preempting", msg)
+
+		owner, err := deployer.subLister.Subscriptions(victim.Labels[known.ConfigSubscriptionNamespaceLabel]).
+			Get(victim.Labels[known.ConfigSubscriptionNameLabel])
+		if err != nil {
+			continue
+		}
+		deployer.recorder.Event(owner, corev1.EventTypeWarning, "Preempted",
+			fmt.Sprintf("Preempted from cluster namespace %s by Subscription %s with priority %d",
+				victim.Namespace, klog.KObj(sub), sub.Spec.Priority))
+		deployer.subsController.EnqueueAfter(owner, 0)
+	}
+	return nil
+}
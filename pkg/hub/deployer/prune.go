@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// getPruneStrategy returns the PruneStrategy of the Subscription that the Base is populated from
+func (deployer *Deployer) getPruneStrategy(base *appsapi.Base) (*appsapi.PruneStrategy, error) {
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sub.Spec.Prune, nil
+}
+
+// getDeletionPolicy returns the DeletionPolicy of the Subscription that the Base is populated from
+func (deployer *Deployer) getDeletionPolicy(base *appsapi.Base) (*metav1.DeletionPropagation, error) {
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sub.Spec.DeletionPolicy, nil
+}
+
+// setPrunedObjects records the objects that are removed from the generic Description by the new raw objects
+// in its annotation, which get deleted from the child cluster after the new spec is deployed.
+func setPrunedObjects(desc *appsapi.Description, raw [][]byte, strategy *appsapi.PruneStrategy) error {
+	if desc.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+		return nil
+	}
+	if strategy != nil && strategy.Policy == appsapi.PruneOrphan {
+		delete(desc.Annotations, known.PrunedObjectsAnnotation)
+		return nil
+	}
+
+	pending, err := utils.GetPrunedObjects(desc)
+	if err != nil {
+		klog.Warningf("failed to parse the pruned objects of Description %s: %v", klog.KObj(desc), err)
+	}
+	pruned, err := utils.FindPrunedObjects(desc.Spec.Raw, raw, pending)
+	if err != nil {
+		return err
+	}
+	if len(pruned) == 0 {
+		delete(desc.Annotations, known.PrunedObjectsAnnotation)
+		return nil
+	}
+
+	data, err := json.Marshal(pruned)
+	if err != nil {
+		return err
+	}
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	desc.Annotations[known.PrunedObjectsAnnotation] = string(data)
+	return nil
+}
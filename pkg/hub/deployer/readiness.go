@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// setReadyCondition rolls up the readiness of all the matching clusters into condition Ready of the Subscription,
+// with the number of clusters in each readiness state.
+func (deployer *Deployer) setReadyCondition(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus) error {
+	scheduled := apimeta.FindStatusCondition(status.Conditions, appsapi.SubscriptionScheduled)
+	if scheduled == nil || scheduled.Status != metav1.ConditionTrue {
+		message := "Subscription is not scheduled yet"
+		if scheduled != nil {
+			message = scheduled.Message
+		}
+		status.ClusterReadiness = nil
+		status.Conditions = utils.MergeConditions(status.Conditions, sub.Generation, metav1.Condition{
+			Type:    appsapi.SubscriptionReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  appsapi.SubscriptionNotScheduled,
+			Message: message,
+		})
+		return nil
+	}
+
+	bases, err := deployer.baseLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigKindLabel:      subscriptionKind.Kind,
+		known.ConfigNameLabel:      sub.Name,
+		known.ConfigNamespaceLabel: sub.Namespace,
+		known.ConfigUIDLabel:       string(sub.UID),
+	}))
+	if err != nil {
+		return err
+	}
+	descs, err := deployer.descLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigSubscriptionNameLabel:      sub.Name,
+		known.ConfigSubscriptionNamespaceLabel: sub.Namespace,
+		known.ConfigSubscriptionUIDLabel:       string(sub.UID),
+	}))
+	if err != nil {
+		return err
+	}
+
+	readiness, condition := utils.GetSubscriptionReadiness(bases, descs)
+	status.ClusterReadiness = readiness
+	status.Conditions = utils.MergeConditions(status.Conditions, sub.Generation, condition)
+	return nil
+}
+
+// overridesError is returned when the overrides fail to be applied to a Description
+type overridesError struct {
+	message string
+}
+
+func (e *overridesError) Error() string {
+	return e.message
+}
+
+// setOverridesFailure records the error of applying overrides on the Base, which is rolled up into
+// condition Ready of the Subscription. An empty message removes the record.
+func (deployer *Deployer) setOverridesFailure(base *appsapi.Base, message string) error {
+	if base.Annotations[known.OverridesFailureAnnotation] == message {
+		return nil
+	}
+
+	var value interface{}
+	if len(message) > 0 {
+		value = message
+	}
+	// a null value removes the annotation with merge patch
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				known.OverridesFailureAnnotation: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = deployer.clusternetClient.AppsV1alpha1().Bases(base.Namespace).Patch(context.TODO(), base.Name,
+		types.MergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/features"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Render returns the final manifests of Subscription namespace/name for a child cluster, which is
+// specified with its name, id or dedicated namespace. All the Globalizations and Localizations are
+// applied in the same way as the Descriptions get populated, but nothing is created or updated.
+// HelmCharts are not rendered, since they are released by the agents.
+func (deployer *Deployer) Render(namespace, name, cluster string) ([][]byte, error) {
+	sub, err := deployer.subLister.Subscriptions(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	mcls, err := deployer.findCluster(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// prefer the Base that has been scheduled to this cluster, which may carry divided replicas
+	base, err := deployer.baseLister.Bases(mcls.Namespace).Get(sub.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+	if base == nil || base.Labels[known.ConfigUIDLabel] != string(sub.UID) {
+		base = &appsapi.Base{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      sub.Name,
+				Namespace: mcls.Namespace,
+			},
+			Spec: appsapi.BaseSpec{
+				Feeds: sub.Spec.Feeds,
+			},
+		}
+	}
+
+	var rawObjects [][]byte
+	for _, feed := range base.Spec.Feeds {
+		if feed.Kind == helmChartKind.Kind {
+			klog.V(5).Infof("skip rendering %s for Subscription %s", utils.FormatFeed(feed), klog.KObj(sub))
+			continue
+		}
+
+		manifests, err := utils.ListManifestsBySelector(deployer.mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		if manifests == nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("Subscription %s is using a nonexistent %s",
+				klog.KObj(sub), utils.FormatFeed(feed)))
+		}
+		manifests = excludeSupersededManifests(manifests)
+		if replicas, ok := getFeedReplicas(base.Spec.Replicas, feed); ok {
+			manifests, err = withDividedReplicas(manifests, replicas)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, manifest := range manifests {
+			rawObjects = append(rawObjects, manifest.Template.Raw)
+		}
+	}
+
+	desc := &appsapi.Description{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-generic", base.Name),
+			Namespace: mcls.Namespace,
+			Labels: map[string]string{
+				known.ClusterIDLabel:   mcls.Labels[known.ClusterIDLabel],
+				known.ClusterNameLabel: mcls.Labels[known.ClusterNameLabel],
+			},
+		},
+		Spec: appsapi.DescriptionSpec{
+			Deployer: appsapi.DescriptionGenericDeployer,
+			Raw:      rawObjects,
+		},
+	}
+	if err = deployer.localizer.ApplyOverridesToDescription(desc); err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("failed to apply overrides: %v", err))
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.ClusterIdentityInjection) {
+		if err = deployer.injectClusterIdentity(desc); err != nil {
+			return nil, err
+		}
+	}
+	if err = deployer.rewriteImageRegistries(desc); err != nil {
+		return nil, err
+	}
+	return desc.Spec.Raw, nil
+}
+
+// findCluster returns the ManagedCluster whose name, id or dedicated namespace is the given cluster.
+func (deployer *Deployer) findCluster(cluster string) (*clusterapi.ManagedCluster, error) {
+	mclsList, err := deployer.clusterLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*clusterapi.ManagedCluster
+	for _, mcls := range mclsList {
+		if mcls.Namespace == cluster || string(mcls.Spec.ClusterID) == cluster ||
+			mcls.Labels[known.ClusterNameLabel] == cluster {
+			matched = append(matched, mcls)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return nil, apierrors.NewNotFound(clusterapi.Resource("managedclusters"), cluster)
+	case 1:
+		return matched[0], nil
+	default:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("cluster %q matches %d ManagedClusters, please use its id instead",
+			cluster, len(matched)))
+	}
+}
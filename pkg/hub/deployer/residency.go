@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// admitBase rejects the Base if its feeds violate any ResidencyPolicy in the target cluster.
+// This also guards the Bases populated by customized schedulers.
+func (deployer *Deployer) admitBase(base *appsapi.Base) error {
+	policies, err := deployer.residencyLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	mcls, err := deployer.clusterLister.ManagedClusters(base.Namespace).List(
+		labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: base.Labels[known.ClusterIDLabel]}))
+	if err != nil {
+		return err
+	}
+	if len(mcls) == 0 {
+		return fmt.Errorf("no ManagedCluster found for Base %s", klog.KObj(base))
+	}
+
+	violations := utils.FindResidencyViolations(policies, base.Spec.Feeds, mcls[0])
+	if len(violations) == 0 {
+		return nil
+	}
+	return apierrors.NewForbidden(appsapi.Resource("bases"), base.Name, errors.New(strings.Join(violations, "; ")))
+}
+
+// enqueueSubscriptionsForResidencyPolicy re-schedules all the Subscriptions when a ResidencyPolicy changes.
+func (deployer *Deployer) enqueueSubscriptionsForResidencyPolicy(obj interface{}) {
+	subs, err := deployer.subLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list Subscriptions: %v", err)
+		return
+	}
+	for _, sub := range subs {
+		deployer.subsController.EnqueueAfter(sub, 0)
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// defaultRevisionHistoryLimit is the number of revisions of feeds kept for a Subscription by default
+	defaultRevisionHistoryLimit = 10
+)
+
+// feedsSnapshot is the content of the feeds of a Subscription at a revision
+type feedsSnapshot struct {
+	Manifests  []manifestSnapshot  `json:"manifests,omitempty"`
+	HelmCharts []helmChartSnapshot `json:"helmCharts,omitempty"`
+}
+
+type manifestSnapshot struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	Template  runtime.RawExtension `json:"template"`
+}
+
+type helmChartSnapshot struct {
+	Namespace string                `json:"namespace"`
+	Name      string                `json:"name"`
+	Spec      appsapi.HelmChartSpec `json:"spec"`
+}
+
+// getFeedsSnapshot returns the content of the feeds
+func (deployer *Deployer) getFeedsSnapshot(feeds []appsapi.Feed) (*feedsSnapshot, error) {
+	snapshot := &feedsSnapshot{}
+	for _, feed := range feeds {
+		if feed.Kind == helmChartKind.Kind {
+			chart, err := deployer.chartLister.HelmCharts(feed.Namespace).Get(feed.Name)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.HelmCharts = append(snapshot.HelmCharts, helmChartSnapshot{
+				Namespace: chart.Namespace,
+				Name:      chart.Name,
+				Spec:      chart.Spec,
+			})
+			continue
+		}
+
+		manifests, err := utils.ListManifestsBySelector(deployer.mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		for _, manifest := range manifests {
+			snapshot.Manifests = append(snapshot.Manifests, manifestSnapshot{
+				Namespace: manifest.Namespace,
+				Name:      manifest.Name,
+				Template:  manifest.Template,
+			})
+		}
+	}
+	sort.SliceStable(snapshot.Manifests, func(i, j int) bool {
+		return snapshot.Manifests[i].Name < snapshot.Manifests[j].Name
+	})
+	return snapshot, nil
+}
+
+// listFeedsRevisions returns the revision history of the feeds of the Subscription, sorted by revision
+func (deployer *Deployer) listFeedsRevisions(sub *appsapi.Subscription) ([]*appsv1.ControllerRevision, error) {
+	revisions, err := deployer.crLister.ControllerRevisions(sub.Namespace).List(labels.SelectorFromSet(labels.Set{
+		known.ConfigKindLabel: subscriptionKind.Kind,
+		known.ConfigNameLabel: sub.Name,
+		known.ConfigUIDLabel:  string(sub.UID),
+	}))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// syncFeedsHistory records the current content of the feeds as the latest revision,
+// and prunes the revisions beyond the history limit.
+func (deployer *Deployer) syncFeedsHistory(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus) error {
+	snapshot, err := deployer.getFeedsSnapshot(sub.Spec.Feeds)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// nonexistent feeds are reported when populating Descriptions
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	hasher := fnv.New32a()
+	hasher.Write(data)
+	name := fmt.Sprintf("%s-%s", sub.Name, strconv.FormatUint(uint64(hasher.Sum32()), 16))
+
+	revisions, err := deployer.listFeedsRevisions(sub)
+	if err != nil {
+		return err
+	}
+	var latest int64
+	var current *appsv1.ControllerRevision
+	for _, revision := range revisions {
+		if revision.Revision > latest {
+			latest = revision.Revision
+		}
+		if revision.Name == name {
+			current = revision
+		}
+	}
+
+	switch {
+	case current == nil:
+		current = &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: sub.Namespace,
+				Labels: map[string]string{
+					known.ObjectCreatedByLabel: known.ClusternetHubName,
+					known.ConfigKindLabel:      subscriptionKind.Kind,
+					known.ConfigNameLabel:      sub.Name,
+					known.ConfigNamespaceLabel: sub.Namespace,
+					known.ConfigUIDLabel:       string(sub.UID),
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(sub, subscriptionKind),
+				},
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: latest + 1,
+		}
+		current, err = deployer.kubeClient.AppsV1().ControllerRevisions(sub.Namespace).Create(context.TODO(),
+			current, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		revisions = append(revisions, current)
+	case current.Revision < latest:
+		// an earlier revision is restored, which becomes the latest one
+		current = current.DeepCopy()
+		current.Revision = latest + 1
+		current, err = deployer.kubeClient.AppsV1().ControllerRevisions(sub.Namespace).Update(context.TODO(),
+			current, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	status.FeedsRevision = current.Revision
+
+	limit := defaultRevisionHistoryLimit
+	if sub.Spec.RevisionHistoryLimit != nil {
+		limit = int(*sub.Spec.RevisionHistoryLimit)
+	}
+	// the current revision is always kept
+	var history []*appsv1.ControllerRevision
+	for _, revision := range revisions {
+		if revision.Name != current.Name {
+			history = append(history, revision)
+		}
+	}
+	for idx := 0; idx < len(history)-limit; idx++ {
+		err = deployer.kubeClient.AppsV1().ControllerRevisions(sub.Namespace).Delete(context.TODO(),
+			history[idx].Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackFeeds restores the content of the feeds to the revision specified by
+// annotation "apps.clusternet.io/rollback-to" of the Subscription.
+func (deployer *Deployer) rollbackFeeds(sub *appsapi.Subscription) error {
+	value, ok := sub.Annotations[known.RollbackToAnnotation]
+	if !ok {
+		return nil
+	}
+
+	if err := deployer.restoreFeeds(sub, value); err != nil {
+		msg := fmt.Sprintf("failed to roll back to revision %s: %v", value, err)
+		klog.Warningf("Subscription %s %s", klog.KObj(sub), msg)
+		deployer.recorder.Event(sub, corev1.EventTypeWarning, "FailedRollingBack", msg)
+	} else {
+		deployer.lifecycleRecorder.SubscriptionEvent(sub, corev1.EventTypeNormal, events.ReasonRollingBack,
+			fmt.Sprintf("Roll back the feeds to revision %s", value))
+	}
+
+	// a null value removes the annotation with merge patch
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				known.RollbackToAnnotation: nil,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = deployer.clusternetClient.AppsV1alpha1().Subscriptions(sub.Namespace).Patch(context.TODO(), sub.Name,
+		types.MergePatchType, patchData, metav1.PatchOptions{})
+	return err
+}
+
+func (deployer *Deployer) restoreFeeds(sub *appsapi.Subscription, value string) error {
+	to, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid revision %q", value)
+	}
+	revisions, err := deployer.listFeedsRevisions(sub)
+	if err != nil {
+		return err
+	}
+	var target *appsv1.ControllerRevision
+	for _, revision := range revisions {
+		// 0 means the previous revision
+		if revision.Revision == to || (to == 0 && len(revisions) > 1 && revision == revisions[len(revisions)-2]) {
+			target = revision
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("revision %s is not found in the history", value)
+	}
+
+	snapshot := &feedsSnapshot{}
+	if err = json.Unmarshal(target.Data.Raw, snapshot); err != nil {
+		return err
+	}
+	for _, ms := range snapshot.Manifests {
+		manifest, err := deployer.mfstLister.Manifests(ms.Namespace).Get(ms.Name)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(manifest.Template.Raw, ms.Template.Raw) {
+			continue
+		}
+		manifest = manifest.DeepCopy()
+		manifest.Template = ms.Template
+		_, err = deployer.clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Update(context.TODO(),
+			manifest, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	for _, cs := range snapshot.HelmCharts {
+		chart, err := deployer.chartLister.HelmCharts(cs.Namespace).Get(cs.Name)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(chart.Spec, cs.Spec) {
+			continue
+		}
+		chart = chart.DeepCopy()
+		chart.Spec = cs.Spec
+		_, err = deployer.clusternetClient.AppsV1alpha1().HelmCharts(chart.Namespace).Update(context.TODO(),
+			chart, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueSubscriptionForFeeds enqueues the Subscription of the Base when its feeds change,
+// which are recorded as a new revision and may need to be rolled out in batches
+func (deployer *Deployer) enqueueSubscriptionForFeeds(base *appsapi.Base) {
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		klog.V(5).Infof("failed to get Subscription of Base %s: %v", klog.KObj(base), err)
+		return
+	}
+	deployer.subsController.EnqueueAfter(sub, 0)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// rollbackFailedDescriptions reverts the Descriptions of the Subscription, which keep failing to be deployed,
+// to their last successfully deployed specs.
+func (deployer *Deployer) rollbackFailedDescriptions(sub *appsapi.Subscription) error {
+	descs, err := deployer.descLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigSubscriptionNameLabel:      sub.Name,
+		known.ConfigSubscriptionNamespaceLabel: sub.Namespace,
+		known.ConfigSubscriptionUIDLabel:       string(sub.UID),
+	}))
+	if err != nil {
+		return err
+	}
+
+	var allErrs []error
+	for _, desc := range descs {
+		if desc.DeletionTimestamp != nil || desc.Status.ObservedGeneration != desc.Generation {
+			continue
+		}
+
+		switch desc.Status.Phase {
+		case appsapi.DescriptionPhaseSuccess:
+			err = deployer.saveLastAppliedDescription(desc)
+		case appsapi.DescriptionPhaseFailure:
+			if desc.Status.FailedAttempts < int32(deployer.descriptionRollbackAttempts) {
+				continue
+			}
+			err = deployer.rollbackDescription(sub, desc)
+		default:
+			continue
+		}
+		if err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// saveLastAppliedDescription keeps the spec of the Description in a ControllerRevision
+func (deployer *Deployer) saveLastAppliedDescription(desc *appsapi.Description) error {
+	name := getLastAppliedName(desc)
+	cr, err := deployer.crLister.ControllerRevisions(desc.Namespace).Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && cr.Revision == desc.Generation && cr.Labels[known.ConfigUIDLabel] == string(desc.UID) {
+		return nil
+	}
+
+	data, err := json.Marshal(desc.Spec)
+	if err != nil {
+		return err
+	}
+	lastApplied := &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: desc.Namespace,
+			Labels: map[string]string{
+				known.ObjectCreatedByLabel: known.ClusternetHubName,
+				known.ConfigKindLabel:      descriptionKind.Kind,
+				known.ConfigNameLabel:      desc.Name,
+				known.ConfigNamespaceLabel: desc.Namespace,
+				known.ConfigUIDLabel:       string(desc.UID),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(desc, descriptionKind),
+			},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: desc.Generation,
+	}
+	if cr == nil {
+		_, err = deployer.kubeClient.AppsV1().ControllerRevisions(desc.Namespace).Create(context.TODO(),
+			lastApplied, metav1.CreateOptions{})
+		return err
+	}
+	lastApplied.ResourceVersion = cr.ResourceVersion
+	_, err = deployer.kubeClient.AppsV1().ControllerRevisions(desc.Namespace).Update(context.TODO(),
+		lastApplied, metav1.UpdateOptions{})
+	return err
+}
+
+// rollbackDescription reverts the Description to its last successfully deployed spec
+func (deployer *Deployer) rollbackDescription(sub *appsapi.Subscription, desc *appsapi.Description) error {
+	cr, err := deployer.crLister.ControllerRevisions(desc.Namespace).Get(getLastAppliedName(desc))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Description %s has never been deployed successfully, skip rolling back", klog.KObj(desc))
+			return nil
+		}
+		return err
+	}
+	if cr.Labels[known.ConfigUIDLabel] != string(desc.UID) {
+		return nil
+	}
+
+	spec := appsapi.DescriptionSpec{}
+	if err = json.Unmarshal(cr.Data.Raw, &spec); err != nil {
+		return err
+	}
+	if reflect.DeepEqual(spec, desc.Spec) {
+		return nil
+	}
+	failedSpec, err := utils.HashDescriptionSpec(desc.Spec)
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Description %s is rolled back to generation %d after %d failed attempts: %s",
+		klog.KObj(desc), cr.Revision, desc.Status.FailedAttempts, desc.Status.Reason)
+	desc = desc.DeepCopy()
+	if desc.Annotations == nil {
+		desc.Annotations = make(map[string]string)
+	}
+	desc.Annotations[known.FailedSpecAnnotation] = failedSpec
+	if err = setPrunedObjects(desc, spec.Raw, sub.Spec.Prune); err != nil {
+		return err
+	}
+	desc.Spec = spec
+	desc, err = deployer.clusternetClient.AppsV1alpha1().Descriptions(desc.Namespace).Update(context.TODO(),
+		desc, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	// the last successfully deployed spec has been approved before
+	if desc.Annotations[known.ApprovalRequiredAnnotation] == "true" {
+		if err = deployer.setApprovalCondition(desc, metav1.Condition{
+			Type:    appsapi.DescriptionApproved,
+			Status:  metav1.ConditionTrue,
+			Reason:  "RolledBack",
+			Message: "rolled back to the last successfully deployed spec",
+		}); err != nil {
+			return err
+		}
+	}
+
+	klog.V(4).Info(msg)
+	deployer.lifecycleRecorder.SubscriptionEvent(sub, corev1.EventTypeWarning, events.ReasonDescriptionRolledBack, msg)
+	deployer.recorder.Event(desc, corev1.EventTypeWarning, "RolledBack", msg)
+	return nil
+}
+
+func getLastAppliedName(desc *appsapi.Description) string {
+	return fmt.Sprintf("%s-last-applied", desc.Name)
+}
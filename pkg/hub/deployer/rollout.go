@@ -0,0 +1,357 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/events"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// getFeedsRevision returns the revision of the Descriptions populated from the feeds
+func (deployer *Deployer) getFeedsRevision(feeds []appsapi.Feed) (string, error) {
+	var allChartRefs []appsapi.ChartReference
+	var allManifests []*appsapi.Manifest
+	for _, feed := range feeds {
+		if feed.Kind == helmChartKind.Kind {
+			allChartRefs = append(allChartRefs, appsapi.ChartReference{
+				Namespace: feed.Namespace,
+				Name:      feed.Name,
+			})
+			continue
+		}
+		manifests, err := utils.ListManifestsBySelector(deployer.mfstLister, feed)
+		if err != nil {
+			return "", err
+		}
+		allManifests = append(allManifests, manifests...)
+	}
+	return utils.GetFeedsRevision(allManifests, allChartRefs), nil
+}
+
+// checkRollout tells whether the Descriptions of the Base are blocked from being updated by the rollout
+// of its Subscription, together with the revision of the feeds if a RolloutStrategy is specified.
+func (deployer *Deployer) checkRollout(base *appsapi.Base, descs []*appsapi.Description) (string, bool, error) {
+	sub, err := deployer.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).Get(
+		base.Labels[known.ConfigSubscriptionNameLabel])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if sub.Spec.RolloutStrategy == nil {
+		return "", false, nil
+	}
+
+	revision, err := deployer.getFeedsRevision(base.Spec.Feeds)
+	if err != nil {
+		return "", false, err
+	}
+	// newly selected clusters and clusters admitted to the rollout get the latest feeds
+	if len(descs) == 0 || base.Labels[known.RolloutRevisionLabel] == revision {
+		return revision, false, nil
+	}
+	for _, desc := range descs {
+		if desc.Labels[known.RolloutRevisionLabel] != revision {
+			return revision, true, nil
+		}
+	}
+	return revision, false, nil
+}
+
+// progressRollout rolls out the latest revision of the feeds to the matching clusters in batches,
+// and returns how long to wait before checking the rollout again.
+func (deployer *Deployer) progressRollout(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus) (time.Duration, error) {
+	strategy := sub.Spec.RolloutStrategy
+	if strategy == nil {
+		status.Rollout = nil
+		return 0, nil
+	}
+
+	revision, err := deployer.getFeedsRevision(sub.Spec.Feeds)
+	if err != nil {
+		return 0, err
+	}
+	allBases, err := deployer.baseLister.List(labels.SelectorFromSet(labels.Set{
+		known.ConfigKindLabel:      subscriptionKind.Kind,
+		known.ConfigNameLabel:      sub.Name,
+		known.ConfigNamespaceLabel: sub.Namespace,
+		known.ConfigUIDLabel:       string(sub.UID),
+	}))
+	if err != nil {
+		return 0, err
+	}
+	var bases []*appsapi.Base
+	for _, base := range allBases {
+		if base.DeletionTimestamp == nil {
+			bases = append(bases, base)
+		}
+	}
+
+	if status.Rollout == nil {
+		// the feeds populated before the RolloutStrategy is specified are taken as rolled out
+		status.Rollout = &appsapi.RolloutStatus{
+			Revision:       revision,
+			StableRevision: revision,
+			Phase:          appsapi.RolloutCompleted,
+			TotalClusters:  int32(len(bases)),
+		}
+		return 0, deployer.admitBases(bases, revision)
+	}
+
+	rollout := status.Rollout
+	if rollout.Revision != revision {
+		rollout = &appsapi.RolloutStatus{
+			Revision:       revision,
+			StableRevision: rollout.StableRevision,
+			Phase:          appsapi.RolloutProgressing,
+		}
+		deployer.recorder.Event(sub, corev1.EventTypeNormal, "RolloutStarted",
+			fmt.Sprintf("Start rolling out revision %s to %d clusters", revision, len(bases)))
+	}
+	status.Rollout = rollout
+	if rollout.Phase == appsapi.RolloutRolledBack {
+		// wait for a new revision of the feeds
+		return 0, nil
+	}
+
+	var pending, admitted []*appsapi.Base
+	var clusterNames []string
+	var inFlight, updated int32
+	for _, base := range bases {
+		descs, err := deployer.descLister.List(labels.SelectorFromSet(labels.Set{
+			known.ConfigKindLabel:      baseKind.Kind,
+			known.ConfigNameLabel:      base.Name,
+			known.ConfigNamespaceLabel: base.Namespace,
+			known.ConfigUIDLabel:       string(base.UID),
+		}))
+		if err != nil {
+			return 0, err
+		}
+
+		populated := len(descs) > 0
+		for _, desc := range descs {
+			if desc.Labels[known.RolloutRevisionLabel] != revision {
+				populated = false
+				break
+			}
+		}
+		if !populated && base.Labels[known.RolloutRevisionLabel] != revision {
+			pending = append(pending, base)
+			continue
+		}
+		admitted = append(admitted, base)
+		clusterNames = append(clusterNames, base.Labels[known.ClusterNameLabel])
+
+		if state, _ := utils.GetClusterReadiness(base, descs); populated && state == appsapi.ClusterReadinessReady {
+			updated++
+		} else {
+			inFlight++
+		}
+	}
+	rollout.TotalClusters = int32(len(bases))
+	rollout.UpdatedClusters = updated
+
+	if inFlight > 0 {
+		rollout.Phase = appsapi.RolloutProgressing
+		rollout.Message = fmt.Sprintf("waiting for %d clusters in batch %d to be ready", inFlight, rollout.Batches)
+		return 0, nil
+	}
+	if len(pending) == 0 && rollout.Phase == appsapi.RolloutCompleted {
+		return 0, nil
+	}
+
+	if len(pending) > 0 && strategy.PauseBetweenBatches != nil && rollout.LastBatchTime != nil {
+		wait := rollout.LastBatchTime.Add(strategy.PauseBetweenBatches.Duration).Sub(time.Now())
+		if wait > 0 {
+			deployer.pauseRollout(sub, rollout, corev1.EventTypeNormal,
+				fmt.Sprintf("batch %d will start after %s", rollout.Batches+1, wait.Round(time.Second)))
+			return wait, nil
+		}
+	}
+
+	// a promotion skips the analysis of current batch
+	if strategy.Analysis != nil && rollout.Batches > 0 && utils.GetRolloutPromotion(sub) <= rollout.Batches {
+		msg, err := deployer.analyzeRollout(strategy.Analysis, clusterNames)
+		if err != nil {
+			msg := fmt.Sprintf("failed to analyze batch %d: %v", rollout.Batches, err)
+			deployer.recorder.Event(sub, corev1.EventTypeWarning, "RolloutAnalysisError", msg)
+			deployer.pauseRollout(sub, rollout, corev1.EventTypeWarning, msg)
+			return rolloutAnalysisInterval, nil
+		}
+		if len(msg) > 0 {
+			deployer.recorder.Event(sub, corev1.EventTypeWarning, "RolloutAnalysisFailed",
+				fmt.Sprintf("Analysis of batch %d failed with %s", rollout.Batches, msg))
+			if strategy.Analysis.FailurePolicy == appsapi.RolloutFailureRollback &&
+				len(rollout.StableRevision) > 0 && rollout.StableRevision != revision {
+				return 0, deployer.rollbackRollout(sub, rollout, admitted, msg)
+			}
+			deployer.pauseRollout(sub, rollout, corev1.EventTypeWarning,
+				fmt.Sprintf("analysis of batch %d failed with %s", rollout.Batches, msg))
+			return rolloutAnalysisInterval, nil
+		}
+	}
+
+	if len(pending) == 0 {
+		deployer.recorder.Event(sub, corev1.EventTypeNormal, "RolloutCompleted",
+			fmt.Sprintf("Revision %s is rolled out to all the %d clusters", revision, updated))
+		rollout.Phase = appsapi.RolloutCompleted
+		rollout.StableRevision = revision
+		rollout.Message = ""
+		return 0, nil
+	}
+	if strategy.AutoPromote != nil && !*strategy.AutoPromote &&
+		rollout.Batches > 0 && rollout.Batches >= utils.GetRolloutPromotion(sub) {
+		deployer.pauseRollout(sub, rollout, corev1.EventTypeNormal,
+			fmt.Sprintf("waiting for promotion to batch %d with annotation %s",
+				rollout.Batches+1, known.RolloutPromoteAnnotation))
+		return 0, nil
+	}
+
+	batch, err := deployer.getRolloutBatch(pending, strategy)
+	if err != nil {
+		return 0, err
+	}
+	if err = deployer.admitBases(batch, revision); err != nil {
+		return 0, err
+	}
+	now := metav1.Now()
+	rollout.Batches++
+	rollout.LastBatchTime = &now
+	rollout.Phase = appsapi.RolloutProgressing
+	rollout.Message = fmt.Sprintf("rolling out batch %d to %d clusters", rollout.Batches, len(batch))
+	msg := fmt.Sprintf("Roll out revision %s to clusters in namespaces %s", revision, getBaseNamespaces(batch))
+	klog.V(4).Infof("Subscription %s: %s", klog.KObj(sub), msg)
+	deployer.recorder.Event(sub, corev1.EventTypeNormal, "RolloutBatch", msg)
+	return 0, nil
+}
+
+// rollbackRollout reverts the admitted clusters to the stable revision
+func (deployer *Deployer) rollbackRollout(sub *appsapi.Subscription, rollout *appsapi.RolloutStatus,
+	admitted []*appsapi.Base, reason string) error {
+	var allErrs []error
+	for _, base := range admitted {
+		if err := deployer.rollbackBase(base, rollout.StableRevision); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if len(allErrs) > 0 {
+		return utilerrors.NewAggregate(allErrs)
+	}
+
+	rollout.Phase = appsapi.RolloutRolledBack
+	rollout.UpdatedClusters = 0
+	rollout.Message = fmt.Sprintf("rolled back to revision %s for %s", rollout.StableRevision, reason)
+	deployer.lifecycleRecorder.SubscriptionEvent(sub, corev1.EventTypeWarning, events.ReasonRolledBack,
+		fmt.Sprintf("Roll back %d clusters to revision %s", len(admitted), rollout.StableRevision))
+	return nil
+}
+
+// pauseRollout pauses the rollout with message, which is recorded as an event only when the rollout gets paused,
+// rather than on every resync.
+func (deployer *Deployer) pauseRollout(sub *appsapi.Subscription, rollout *appsapi.RolloutStatus, eventType, message string) {
+	if rollout.Phase != appsapi.RolloutPaused {
+		deployer.lifecycleRecorder.SubscriptionEvent(sub, eventType, events.ReasonRolloutPaused,
+			fmt.Sprintf("Rollout of revision %s is paused: %s", rollout.Revision, message))
+	}
+	rollout.Phase = appsapi.RolloutPaused
+	rollout.Message = message
+}
+
+// getRolloutBatch returns the Bases to be updated in the next batch
+func (deployer *Deployer) getRolloutBatch(pending []*appsapi.Base, strategy *appsapi.RolloutStrategy) ([]*appsapi.Base, error) {
+	basesByNamespace := make(map[string]*appsapi.Base)
+	var clusters []*clusterapi.ManagedCluster
+	for _, base := range pending {
+		mcls, err := deployer.clusterLister.ManagedClusters(base.Namespace).List(labels.SelectorFromSet(labels.Set{
+			known.ClusterIDLabel: base.Labels[known.ClusterIDLabel],
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if len(mcls) == 0 {
+			continue
+		}
+		basesByNamespace[base.Namespace] = base
+		clusters = append(clusters, mcls[0])
+	}
+	utils.SortClustersForRollout(clusters, strategy.OrderByLabel)
+
+	maxConcurrentClusters := int(strategy.MaxConcurrentClusters)
+	if maxConcurrentClusters < 1 {
+		maxConcurrentClusters = 1
+	}
+	var batch []*appsapi.Base
+	for _, cluster := range clusters {
+		if len(batch) == maxConcurrentClusters {
+			break
+		}
+		batch = append(batch, basesByNamespace[cluster.Namespace])
+	}
+	return batch, nil
+}
+
+// admitBases labels the Bases with the revision, so that their Descriptions get updated to the revision
+func (deployer *Deployer) admitBases(bases []*appsapi.Base, revision string) error {
+	patchData, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				known.RolloutRevisionLabel: revision,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	var allErrs []error
+	for _, base := range bases {
+		if base.Labels[known.RolloutRevisionLabel] == revision {
+			continue
+		}
+		_, err = deployer.clusternetClient.AppsV1alpha1().Bases(base.Namespace).Patch(context.TODO(), base.Name,
+			types.MergePatchType, patchData, metav1.PatchOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+func getBaseNamespaces(bases []*appsapi.Base) []string {
+	var namespaces []string
+	for _, base := range bases {
+		namespaces = append(namespaces, base.Namespace)
+	}
+	return namespaces
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// findStatusAggregations returns the specs of StatusAggregations referring to the feeds of the Base,
+// which tell clusternet-agent the status fields to collect.
+func (deployer *Deployer) findStatusAggregations(base *appsapi.Base) ([]appsapi.StatusAggregationSpec, error) {
+	if deployer.aggregationLister == nil {
+		return nil, nil
+	}
+	aggregations, err := deployer.aggregationLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return utils.FindStatusAggregations(aggregations, base.Spec.Feeds), nil
+}
+
+// enqueueBasesForStatusAggregation re-populates the Descriptions of the Bases referring to the StatusAggregation.
+func (deployer *Deployer) enqueueBasesForStatusAggregation(obj interface{}) {
+	aggregation, ok := obj.(*appsapi.StatusAggregation)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		aggregation, ok = tombstone.Obj.(*appsapi.StatusAggregation)
+		if !ok {
+			return
+		}
+	}
+
+	bases, err := deployer.baseLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list Bases: %v", err)
+		return
+	}
+	for _, base := range bases {
+		if len(utils.FindStatusAggregations([]*appsapi.StatusAggregation{aggregation}, base.Spec.Feeds)) == 0 {
+			continue
+		}
+		deployer.baseController.Enqueue(base)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// admitTenancy rejects the Base if its target cluster is not owned by the Tenants of its Subscription namespace.
+// This also guards the Bases populated by customized schedulers.
+func (deployer *Deployer) admitTenancy(base *appsapi.Base) error {
+	allTenants, err := deployer.tenantLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	subNamespace := base.Labels[known.ConfigSubscriptionNamespaceLabel]
+	tenants := utils.TenantsOfNamespace(allTenants, subNamespace)
+	if len(tenants) == 0 {
+		return nil
+	}
+
+	mcls, err := deployer.clusterLister.ManagedClusters(base.Namespace).List(
+		labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: base.Labels[known.ClusterIDLabel]}))
+	if err != nil {
+		return err
+	}
+	if len(mcls) == 0 {
+		return fmt.Errorf("no ManagedCluster found for Base %s", klog.KObj(base))
+	}
+
+	owned, err := utils.IsOwnedByTenants(tenants, mcls[0])
+	if err != nil {
+		return err
+	}
+	if owned {
+		return nil
+	}
+	return apierrors.NewForbidden(appsapi.Resource("bases"), base.Name,
+		fmt.Errorf("cluster %s is not owned by any Tenant of namespace %s", klog.KObj(mcls[0]), subNamespace))
+}
+
+// enqueueSubscriptionsForTenant re-schedules the Subscriptions in the namespaces of a Tenant when it changes.
+func (deployer *Deployer) enqueueSubscriptionsForTenant(obj interface{}) {
+	tenant, ok := obj.(*appsapi.Tenant)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.Errorf("couldn't get object from tombstone %#v", obj)
+			return
+		}
+		tenant, ok = tombstone.Obj.(*appsapi.Tenant)
+		if !ok {
+			klog.Errorf("tombstone contained object that is not a Tenant %#v", obj)
+			return
+		}
+	}
+
+	for _, namespace := range tenant.Spec.Namespaces {
+		subs, err := deployer.subLister.Subscriptions(namespace).List(labels.Everything())
+		if err != nil {
+			klog.Errorf("failed to list Subscriptions in namespace %s: %v", namespace, err)
+			return
+		}
+		for _, sub := range subs {
+			deployer.subsController.EnqueueAfter(sub, 0)
+		}
+	}
+}
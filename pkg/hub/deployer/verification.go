@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deployer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// verifyFeeds sets condition FeedsVerified of the Subscription when any of its feeds requires signature verification,
+// and returns an error if the signatures of any feed fail to be verified, so that the Subscription is not scheduled.
+func (deployer *Deployer) verifyFeeds(sub *appsapi.Subscription, status *appsapi.SubscriptionStatus) error {
+	var required bool
+	var failures []string
+	for _, feed := range sub.Spec.Feeds {
+		// nonexistent feeds are reported when populating Bases and Descriptions
+		switch feed.Kind {
+		case helmChartKind.Kind:
+			chart, err := deployer.chartLister.HelmCharts(feed.Namespace).Get(feed.Name)
+			if err != nil || chart.Spec.Verify == nil {
+				continue
+			}
+			required = true
+			if chart.Status.Phase == appsapi.HelmChartUnverified {
+				failures = append(failures, fmt.Sprintf("%s: %s", utils.FormatFeed(feed), chart.Status.Reason))
+			}
+		case ociRepoKind.Kind:
+			repo, err := deployer.ociRepoLister.OCIRepositories(feed.Namespace).Get(feed.Name)
+			if err != nil || repo.Spec.Verify == nil {
+				continue
+			}
+			required = true
+			ready := apimeta.FindStatusCondition(repo.Status.Conditions, appsapi.OCIRepositoryReady)
+			if ready != nil && ready.Status == metav1.ConditionFalse && ready.Reason == appsapi.SignatureVerificationFailed {
+				failures = append(failures, fmt.Sprintf("%s: %s", utils.FormatFeed(feed), ready.Message))
+			}
+		}
+	}
+
+	if !required {
+		apimeta.RemoveStatusCondition(&status.Conditions, appsapi.SubscriptionFeedsVerified)
+		return nil
+	}
+	if len(failures) > 0 {
+		message := fmt.Sprintf("signatures of feeds fail to be verified: %s", strings.Join(failures, "; "))
+		status.Conditions = utils.MergeConditions(status.Conditions, sub.Generation, metav1.Condition{
+			Type:    appsapi.SubscriptionFeedsVerified,
+			Status:  metav1.ConditionFalse,
+			Reason:  appsapi.SignatureVerificationFailed,
+			Message: message,
+		})
+		return errors.New(message)
+	}
+	status.Conditions = utils.MergeConditions(status.Conditions, sub.Generation, metav1.Condition{
+		Type:    appsapi.SubscriptionFeedsVerified,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Verified",
+		Message: "signatures of all the feeds requiring verification are verified",
+	})
+	return nil
+}
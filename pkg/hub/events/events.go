@@ -0,0 +1,178 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events records the lifecycle events of Subscriptions as Kubernetes Events on the Subscriptions, such as being
+// scheduled, Descriptions being applied or failed to apply, rollouts being paused and being rolled back, so that they
+// are visible with "kubectl describe" instead of only in the logs of clusternet-hub. The events could also be posted
+// to a webhook in JSON.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// reasons of the lifecycle events of Subscriptions
+const (
+	// ReasonScheduled means the Subscription is scheduled to a new set of clusters.
+	ReasonScheduled = "Scheduled"
+	// ReasonDescriptionApplied means a Description of the Subscription gets applied to its cluster.
+	ReasonDescriptionApplied = "DescriptionApplied"
+	// ReasonDescriptionFailed means a Description of the Subscription fails to be applied to its cluster.
+	ReasonDescriptionFailed = "DescriptionFailed"
+	// ReasonRolloutPaused means the rollout of the Subscription gets paused.
+	ReasonRolloutPaused = "RolloutPaused"
+	// ReasonRolledBack means the clusters in the rollout of the Subscription are rolled back to the stable revision.
+	ReasonRolledBack = "RolledBack"
+	// ReasonDescriptionRolledBack means a failed Description is rolled back to its last successfully deployed spec.
+	ReasonDescriptionRolledBack = "DescriptionRolledBack"
+	// ReasonRollingBack means the feeds of the Subscription are rolled back to a revision on demand.
+	ReasonRollingBack = "RollingBack"
+)
+
+// defaultWebhookTimeout is the timeout of posting an event to the webhook
+const defaultWebhookTimeout = 10 * time.Second
+
+// LifecycleEvent is the payload posted to the webhook.
+type LifecycleEvent struct {
+	SubscriptionNamespace string    `json:"subscriptionNamespace"`
+	SubscriptionName      string    `json:"subscriptionName"`
+	SubscriptionUID       types.UID `json:"subscriptionUID"`
+
+	// ClusterNamespace and ClusterName are the cluster where the event happens,
+	// which are empty for the events of the whole Subscription.
+	ClusterNamespace string `json:"clusterNamespace,omitempty"`
+	ClusterName      string `json:"clusterName,omitempty"`
+
+	Type      string      `json:"type"`
+	Reason    string      `json:"reason"`
+	Message   string      `json:"message"`
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// Recorder records the lifecycle events of Subscriptions.
+type Recorder struct {
+	ctx      context.Context
+	recorder record.EventRecorder
+	// webhook is the url where the events are posted to. Empty means only Kubernetes Events are recorded.
+	webhook string
+}
+
+// NewRecorder returns a new Recorder.
+func NewRecorder(ctx context.Context, recorder record.EventRecorder, webhook string) *Recorder {
+	return &Recorder{
+		ctx:      ctx,
+		recorder: recorder,
+		webhook:  webhook,
+	}
+}
+
+// SubscriptionEvent records an event of the whole Subscription.
+func (r *Recorder) SubscriptionEvent(sub *appsapi.Subscription, eventType, reason, message string) {
+	r.recorder.Event(sub, eventType, reason, message)
+	r.post(&LifecycleEvent{
+		SubscriptionNamespace: sub.Namespace,
+		SubscriptionName:      sub.Name,
+		SubscriptionUID:       sub.UID,
+		Type:                  eventType,
+		Reason:                reason,
+		Message:               message,
+		Timestamp:             metav1.Now(),
+	})
+}
+
+// ClusterEvent records an event of the Subscription that obj belongs to, such as a Description or a HelmRelease,
+// which happens in the cluster of obj. The Subscription is found by the labels of obj, and objects not belonging
+// to any Subscription are ignored.
+func (r *Recorder) ClusterEvent(obj metav1.Object, eventType, reason, message string) {
+	objLabels := obj.GetLabels()
+	if len(objLabels[known.ConfigSubscriptionNameLabel]) == 0 {
+		return
+	}
+
+	// the Subscription is referred without being retrieved
+	ref := &corev1.ObjectReference{
+		APIVersion: appsapi.SchemeGroupVersion.String(),
+		Kind:       "Subscription",
+		Namespace:  objLabels[known.ConfigSubscriptionNamespaceLabel],
+		Name:       objLabels[known.ConfigSubscriptionNameLabel],
+		UID:        types.UID(objLabels[known.ConfigSubscriptionUIDLabel]),
+	}
+	r.recorder.Event(ref, eventType, reason, message)
+	r.post(&LifecycleEvent{
+		SubscriptionNamespace: ref.Namespace,
+		SubscriptionName:      ref.Name,
+		SubscriptionUID:       ref.UID,
+		ClusterNamespace:      obj.GetNamespace(),
+		ClusterName:           objLabels[known.ClusterNameLabel],
+		Type:                  eventType,
+		Reason:                reason,
+		Message:               message,
+		Timestamp:             metav1.Now(),
+	})
+}
+
+func (r *Recorder) post(event *LifecycleEvent) {
+	if len(r.webhook) == 0 {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		klog.Errorf("failed to marshal event %s of Subscription %s/%s: %v", event.Reason,
+			event.SubscriptionNamespace, event.SubscriptionName, err)
+		return
+	}
+	// do not block the reconciling
+	go func() {
+		ctx, cancel := context.WithTimeout(r.ctx, defaultWebhookTimeout)
+		defer cancel()
+		if err := PostWebhook(ctx, r.webhook, payload); err != nil {
+			klog.Warningf("failed to send event %s of Subscription %s/%s to webhook: %v", event.Reason,
+				event.SubscriptionNamespace, event.SubscriptionName, err)
+		}
+	}()
+}
+
+// PostWebhook posts the payload in JSON to url.
+func PostWebhook(ctx context.Context, url string, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook returns status code %d", resp.StatusCode)
+	}
+	return nil
+}
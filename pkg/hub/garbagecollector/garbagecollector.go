@@ -0,0 +1,447 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	shadowapiserver "github.com/clusternet/clusternet/pkg/hub/apiserver/shadow"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const (
+	// manifestUIDIndex indexes Manifests by their UIDs, which are also the UIDs of shadow objects
+	manifestUIDIndex = "manifestUID"
+	// ownerUIDIndex indexes Manifests by the owner UIDs declared in their templates
+	ownerUIDIndex = "ownerUID"
+)
+
+var subscriptionKind = appsapi.SchemeGroupVersion.WithKind("Subscription")
+
+// ownerType tells how the owner of a shadow object is looked up
+type ownerType int
+
+const (
+	// unknownOwner is neither a Subscription nor a shadow object, which is always regarded as alive,
+	// since its existence could not be told from the parent cluster
+	unknownOwner ownerType = iota
+	subscriptionOwner
+	shadowOwner
+)
+
+// GarbageCollector deletes the Manifests behind shadow objects, whose owners no longer exist.
+//
+// The owners of a shadow object are declared with ownerReferences in the template of its Manifest,
+// which could be either other shadow objects or Subscriptions. A Manifest gets deleted once all of
+// its owners are gone, the same as what kube-controller-manager does with background propagation.
+// Manifests are deleted through the API, so their finalizers are honored, and the Descriptions
+// referring them are cleaned up by the deployer as usual. Owners other than Subscriptions and objects
+// served by shadow APIs are never regarded as gone.
+type GarbageCollector struct {
+	ctx context.Context
+
+	clusternetClient clusternetclientset.Interface
+
+	// restMapper resolves the resources and scopes of owners
+	restMapper meta.RESTMapper
+	// excludedResources are the patterns of resources not served by shadow APIs
+	excludedResources []string
+
+	workqueue workqueue.RateLimitingInterface
+
+	mfstLister  applisters.ManifestLister
+	mfstIndexer cache.Indexer
+	mfstSynced  cache.InformerSynced
+	subLister   applisters.SubscriptionLister
+	subSynced   cache.InformerSynced
+}
+
+// NewGarbageCollector returns a new GarbageCollector.
+// It should be called before clusternetInformerFactory starts.
+func NewGarbageCollector(ctx context.Context, clusternetClient clusternetclientset.Interface,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, restMapper meta.RESTMapper,
+	excludedResources []string) (*GarbageCollector, error) {
+	mfstInformer := clusternetInformerFactory.Apps().V1alpha1().Manifests()
+	subInformer := clusternetInformerFactory.Apps().V1alpha1().Subscriptions()
+
+	err := mfstInformer.Informer().AddIndexers(cache.Indexers{
+		manifestUIDIndex: func(obj interface{}) ([]string, error) {
+			manifest, ok := obj.(*appsapi.Manifest)
+			if !ok {
+				return nil, nil
+			}
+			return []string{string(manifest.UID)}, nil
+		},
+		ownerUIDIndex: func(obj interface{}) ([]string, error) {
+			manifest, ok := obj.(*appsapi.Manifest)
+			if !ok {
+				return nil, nil
+			}
+			var uids []string
+			for _, ref := range getOwnerReferences(manifest) {
+				uids = append(uids, string(ref.UID))
+			}
+			return uids, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gc := &GarbageCollector{
+		ctx:               ctx,
+		clusternetClient:  clusternetClient,
+		restMapper:        restMapper,
+		excludedResources: excludedResources,
+		workqueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "shadow-gc"),
+		mfstLister:        mfstInformer.Lister(),
+		mfstIndexer:       mfstInformer.Informer().GetIndexer(),
+		mfstSynced:        mfstInformer.Informer().HasSynced,
+		subLister:         subInformer.Lister(),
+		subSynced:         subInformer.Informer().HasSynced,
+	}
+
+	mfstInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			gc.enqueue(obj.(*appsapi.Manifest))
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			manifest := cur.(*appsapi.Manifest)
+			if manifest.DeletionTimestamp != nil {
+				gc.enqueueDependents(manifest.UID)
+				return
+			}
+			gc.enqueue(manifest)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if manifest := toManifest(obj); manifest != nil {
+				gc.enqueueDependents(manifest.UID)
+			}
+		},
+	})
+	subInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			sub := cur.(*appsapi.Subscription)
+			if sub.DeletionTimestamp != nil {
+				gc.enqueueDependents(sub.UID)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if sub := toSubscription(obj); sub != nil {
+				gc.enqueueDependents(sub.UID)
+			}
+		},
+	})
+
+	return gc, nil
+}
+
+// Run starts the workers to collect garbage Manifests. It will block until the context is done.
+func (gc *GarbageCollector) Run(workers int) {
+	defer utilruntime.HandleCrash()
+	defer gc.workqueue.ShutDown()
+
+	klog.Info("starting shadow garbage collector...")
+	defer klog.Info("shutting down shadow garbage collector")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(gc.ctx.Done(), gc.mfstSynced, gc.subSynced) {
+		return
+	}
+
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(gc.runWorker, time.Second, gc.ctx.Done())
+		})
+	}
+
+	<-gc.ctx.Done()
+	// drain the items left in the workqueue before returning
+	gc.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (gc *GarbageCollector) runWorker() {
+	for gc.processNextWorkItem() {
+	}
+}
+
+func (gc *GarbageCollector) processNextWorkItem() bool {
+	obj, shutdown := gc.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer gc.workqueue.Done(obj)
+
+	key, ok := obj.(string)
+	if !ok {
+		gc.workqueue.Forget(obj)
+		utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+		return true
+	}
+	start := time.Now()
+	err := gc.syncHandler(key)
+	metrics.ObserveReconcile("shadow-gc", start, err)
+	if err != nil {
+		gc.workqueue.AddRateLimited(key)
+		utilruntime.HandleError(fmt.Errorf("error collecting garbage Manifest %q: %v, requeuing", key, err))
+		return true
+	}
+	gc.workqueue.Forget(obj)
+	return true
+}
+
+func (gc *GarbageCollector) syncHandler(key string) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	manifest, err := gc.mfstLister.Manifests(ns).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if manifest.DeletionTimestamp != nil {
+		return nil
+	}
+
+	ownerRefs := getOwnerReferences(manifest)
+	if len(ownerRefs) == 0 {
+		return nil
+	}
+	owners := make([]ownerType, len(ownerRefs))
+	namespaces := make([]string, len(ownerRefs))
+	for i, ref := range ownerRefs {
+		owners[i], namespaces[i] = gc.resolveOwner(manifest, ref)
+		if owners[i] == unknownOwner {
+			return nil
+		}
+	}
+	for i, ref := range ownerRefs {
+		exists, err := gc.ownerExists(owners[i], namespaces[i], ref)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+	// the caches may lag behind, double check with the parent apiserver before deleting
+	for i, ref := range ownerRefs {
+		exists, err := gc.ownerExistsLive(owners[i], namespaces[i], ref)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	klog.V(4).Infof("all owners of %s %s/%s are gone, deleting Manifest %s", manifest.Labels[known.ConfigKindLabel],
+		manifest.Labels[known.ConfigNamespaceLabel], manifest.Labels[known.ConfigNameLabel], klog.KObj(manifest))
+	deletePropagationBackground := metav1.DeletePropagationBackground
+	err = gc.clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Delete(gc.ctx, manifest.Name, metav1.DeleteOptions{
+		PropagationPolicy: &deletePropagationBackground,
+		Preconditions:     &metav1.Preconditions{UID: &manifest.UID},
+	})
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+// resolveOwner tells the type of the owner, and the namespace where it lives. Owners that are neither Subscriptions
+// nor served by shadow APIs, such as resources excluded from shadowing, are taken as unknown.
+func (gc *GarbageCollector) resolveOwner(manifest *appsapi.Manifest, ref metav1.OwnerReference) (ownerType, string) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		klog.Warningf("invalid ownerReference %s/%s in Manifest %s: %v", ref.Kind, ref.Name, klog.KObj(manifest), err)
+		return unknownOwner, ""
+	}
+
+	namespace := manifest.Labels[known.ConfigNamespaceLabel]
+	if gv.Group == subscriptionKind.Group && ref.Kind == subscriptionKind.Kind {
+		return subscriptionOwner, namespace
+	}
+
+	mapping, err := gc.restMapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version)
+	if err != nil {
+		klog.V(5).Infof("failed to resolve owner %s/%s of Manifest %s: %v", ref.Kind, ref.Name, klog.KObj(manifest), err)
+		return unknownOwner, ""
+	}
+	if !shadowapiserver.IsShadowed(gv.Group, mapping.Resource.Resource, gc.excludedResources) {
+		return unknownOwner, ""
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		// cluster-scoped owners are recorded with an empty namespace
+		namespace = ""
+	}
+	return shadowOwner, namespace
+}
+
+// ownerExists checks whether the owner is still alive. Owners being deleted are regarded as gone.
+func (gc *GarbageCollector) ownerExists(owner ownerType, namespace string, ref metav1.OwnerReference) (bool, error) {
+	switch owner {
+	case subscriptionOwner:
+		sub, err := gc.subLister.Subscriptions(namespace).Get(ref.Name)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return sub.UID == ref.UID && sub.DeletionTimestamp == nil, nil
+	case shadowOwner:
+		owners, err := gc.mfstIndexer.ByIndex(manifestUIDIndex, string(ref.UID))
+		if err != nil {
+			return false, err
+		}
+		for _, obj := range owners {
+			if owner, ok := obj.(*appsapi.Manifest); ok && owner.DeletionTimestamp == nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+// ownerExistsLive is the same as ownerExists, except that the owner is retrieved from the parent apiserver.
+func (gc *GarbageCollector) ownerExistsLive(owner ownerType, namespace string, ref metav1.OwnerReference) (bool, error) {
+	switch owner {
+	case subscriptionOwner:
+		sub, err := gc.clusternetClient.AppsV1alpha1().Subscriptions(namespace).Get(gc.ctx, ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return sub.UID == ref.UID && sub.DeletionTimestamp == nil, nil
+	case shadowOwner:
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return true, nil
+		}
+		owners, err := gc.clusternetClient.AppsV1alpha1().Manifests(appsapi.ReservedNamespace).List(gc.ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(labels.Set{
+				known.ConfigGroupLabel:     gv.Group,
+				known.ConfigKindLabel:      ref.Kind,
+				known.ConfigNameLabel:      ref.Name,
+				known.ConfigNamespaceLabel: namespace,
+			}).String(),
+		})
+		if err != nil {
+			return false, err
+		}
+		for _, owner := range owners.Items {
+			if owner.UID == ref.UID && owner.DeletionTimestamp == nil {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return true, nil
+	}
+}
+
+func (gc *GarbageCollector) enqueue(manifest *appsapi.Manifest) {
+	if manifest.Namespace != appsapi.ReservedNamespace {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(manifest)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	gc.workqueue.Add(key)
+}
+
+// enqueueDependents enqueues all the Manifests owned by the object with given uid.
+func (gc *GarbageCollector) enqueueDependents(uid types.UID) {
+	dependents, err := gc.mfstIndexer.ByIndex(ownerUIDIndex, string(uid))
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, obj := range dependents {
+		if manifest, ok := obj.(*appsapi.Manifest); ok {
+			gc.enqueue(manifest)
+		}
+	}
+}
+
+// getOwnerReferences returns the ownerReferences declared in the template of the Manifest.
+func getOwnerReferences(manifest *appsapi.Manifest) []metav1.OwnerReference {
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(manifest.Template.Raw, obj); err != nil {
+		return nil
+	}
+	return obj.GetOwnerReferences()
+}
+
+func toManifest(obj interface{}) *appsapi.Manifest {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	manifest, ok := obj.(*appsapi.Manifest)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("couldn't get Manifest from object %#v", obj))
+		return nil
+	}
+	return manifest
+}
+
+func toSubscription(obj interface{}) *appsapi.Subscription {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	sub, ok := obj.(*appsapi.Subscription)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("couldn't get Subscription from object %#v", obj))
+		return nil
+	}
+	return sub
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package garbagecollector
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/generated/clientset/versioned/fake"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func newManifest(name, group, kind, namespace, objName string, uid types.UID, owner *metav1.OwnerReference) *appsapi.Manifest {
+	template := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"` + objName + `"`
+	if owner != nil {
+		template += `,"ownerReferences":[{"apiVersion":"` + owner.APIVersion + `","kind":"` + owner.Kind +
+			`","name":"` + owner.Name + `","uid":"` + string(owner.UID) + `"}]`
+	}
+	template += `}}`
+	return &appsapi.Manifest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: appsapi.ReservedNamespace,
+			UID:       uid,
+			Labels: map[string]string{
+				known.ConfigGroupLabel:     group,
+				known.ConfigKindLabel:      kind,
+				known.ConfigNameLabel:      objName,
+				known.ConfigNamespaceLabel: namespace,
+			},
+		},
+		Template: runtime.RawExtension{Raw: []byte(template)},
+	}
+}
+
+func TestSyncHandler(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+
+	tests := []struct {
+		name        string
+		owner       metav1.OwnerReference
+		liveOwner   *appsapi.Manifest
+		wantDeleted bool
+	}{
+		{
+			name:        "namespaced shadow owner is gone",
+			owner:       metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "d1", UID: "owner-uid"},
+			wantDeleted: true,
+		},
+		{
+			name:  "cluster-scoped shadow owner exists",
+			owner: metav1.OwnerReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "cr1", UID: "owner-uid"},
+			// only found in the parent apiserver, not in the caches
+			liveOwner: newManifest("owner", "rbac.authorization.k8s.io", "ClusterRole", "", "cr1", "owner-uid", nil),
+		},
+		{
+			name:  "cluster-scoped shadow owner is gone",
+			owner: metav1.OwnerReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "cr1", UID: "owner-uid"},
+			// a namespaced object with the same name is not the owner
+			liveOwner:   newManifest("owner", "rbac.authorization.k8s.io", "ClusterRole", "ns1", "cr1", "other-uid", nil),
+			wantDeleted: true,
+		},
+		{
+			name:  "owner of unknown kind",
+			owner: metav1.OwnerReference{APIVersion: "example.com/v1", Kind: "Widget", Name: "w1", UID: "owner-uid"},
+		},
+		{
+			name:  "owner excluded from shadow APIs",
+			owner: metav1.OwnerReference{APIVersion: "v1", Kind: "Secret", Name: "s1", UID: "owner-uid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dependent := newManifest("dependent", "", "ConfigMap", "ns1", "cm1", "dependent-uid", &tt.owner)
+			objects := []runtime.Object{dependent}
+			if tt.liveOwner != nil {
+				objects = append(objects, tt.liveOwner)
+			}
+			client := fake.NewSimpleClientset(objects...)
+			factory := clusternetinformers.NewSharedInformerFactory(client, 0)
+			gc, err := NewGarbageCollector(context.TODO(), client, factory, mapper, []string{"secrets"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err = factory.Apps().V1alpha1().Manifests().Informer().GetIndexer().Add(dependent); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err = gc.syncHandler(appsapi.ReservedNamespace + "/dependent"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			deleted := false
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "delete" && action.GetResource().Resource == "manifests" {
+					deleted = true
+				}
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
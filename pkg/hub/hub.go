@@ -18,30 +18,48 @@ package hub
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"time"
 
 	crdclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	crdinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	cacheddiscovery "k8s.io/client-go/discovery/cached/memory"
 	kubeInformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/klog/v2"
 
+	"github.com/clusternet/clusternet/pkg/controllers/apps/description"
+	controllermetrics "github.com/clusternet/clusternet/pkg/controllers/metrics"
 	"github.com/clusternet/clusternet/pkg/features"
 	clusternet "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	informers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	"github.com/clusternet/clusternet/pkg/hub/agentupgrader"
 	"github.com/clusternet/clusternet/pkg/hub/approver"
+	"github.com/clusternet/clusternet/pkg/hub/clusterlifecycle"
+	"github.com/clusternet/clusternet/pkg/hub/csrsigner"
 	"github.com/clusternet/clusternet/pkg/hub/deployer"
+	"github.com/clusternet/clusternet/pkg/hub/garbagecollector"
+	"github.com/clusternet/clusternet/pkg/hub/notifier"
 	"github.com/clusternet/clusternet/pkg/hub/options"
+	"github.com/clusternet/clusternet/pkg/hub/rbacpropagator"
+	"github.com/clusternet/clusternet/pkg/hub/simulation"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/registry/proxies/diff"
+	"github.com/clusternet/clusternet/pkg/registry/proxies/render"
+	"github.com/clusternet/clusternet/pkg/registry/proxies/socket/subresources"
+	"github.com/clusternet/clusternet/pkg/sharding"
 	"github.com/clusternet/clusternet/pkg/utils"
 )
 
 const (
 	// default resync time
 	DefaultResync = time.Hour * 12
-	// default number of threads
-	DefaultThreadiness = 2
 )
 
 // Hub defines configuration for clusternet-hub
@@ -59,7 +77,14 @@ type Hub struct {
 	crdclient        *crdclientset.Clientset
 
 	crrApprover *approver.CRRApprover
+	lifecycle   *clusterlifecycle.ClusterLifecycleController
 	deployer    *deployer.Deployer
+	gc          *garbagecollector.GarbageCollector
+	upgrader    *agentupgrader.AgentUpgrader
+	csrSigner   *csrsigner.CSRSigner
+	notifier    *notifier.Notifier
+	propagator  *rbacpropagator.RBACPropagator
+	sharder     *sharding.Sharder
 
 	socketConnection bool
 	deployerEnabled  bool
@@ -74,6 +99,15 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 	if err != nil {
 		return nil, err
 	}
+	if opts.Simulation {
+		simulation.Wrap(config)
+	}
+	controllermetrics.RegisterMetrics()
+	if len(opts.ResourceInterpreterWebhooks) > 0 {
+		if err = interpreter.LoadWebhooks(opts.ResourceInterpreterWebhooks); err != nil {
+			return nil, err
+		}
+	}
 
 	// creating the clientset
 	kubeclient := kubernetes.NewForConfigOrDie(config)
@@ -84,8 +118,24 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 	kubeInformerFactory := kubeInformers.NewSharedInformerFactory(kubeclient, DefaultResync)
 	clusternetInformerFactory := informers.NewSharedInformerFactory(clusternetclient, DefaultResync)
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdclient, 5*time.Minute)
+	var approvalPolicy *approver.ApprovalPolicy
+	if len(opts.ClusterRegistrationPolicy) > 0 {
+		approvalPolicy, err = approver.LoadApprovalPolicy(opts.ClusterRegistrationPolicy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var registrationWebhook *approver.RegistrationWebhook
+	if len(opts.ClusterRegistrationWebhook) > 0 {
+		registrationWebhook, err = approver.NewRegistrationWebhook(opts.ClusterRegistrationWebhook,
+			opts.ClusterRegistrationWebhookCAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	approver, err := approver.NewCRRApprover(ctx, kubeclient, clusternetclient, clusternetInformerFactory,
-		kubeInformerFactory, socketConnection)
+		kubeInformerFactory, socketConnection, utilfeature.DefaultFeatureGate.Enabled(features.CertificateSigning),
+		opts.RegistrationRenewalMinInterval, approvalPolicy, registrationWebhook)
 	if err != nil {
 		return nil, err
 	}
@@ -97,8 +147,23 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 	kubeInformerFactory.Core().V1().Secrets().Informer()
 	clusternetInformerFactory.Clusters().V1beta1().ClusterRegistrationRequests().Informer()
 	clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer()
+	if opts.RequireProxyGrants {
+		clusternetInformerFactory.Clusters().V1beta1().Grants().Informer()
+	}
 	crdInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer()
 
+	lifecycle := clusterlifecycle.NewClusterLifecycleController(ctx, kubeclient, clusternetclient, clusternetInformerFactory, kubeInformerFactory,
+		opts.ClusterMonitorPeriod, opts.ClusterHeartbeatGracePeriod, opts.ClusterEvictionTimeout,
+		opts.ClusterFailoverTolerance)
+
+	var sharder *sharding.Sharder
+	if utilfeature.DefaultFeatureGate.Enabled(features.DeployerSharding) {
+		sharder, err = sharding.NewSharder(kubeclient, opts.ShardLeaseNamespace, opts.ShardLeaseDuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var d *deployer.Deployer
 	if deployerEnabled {
 		// register informers first before informerFactory starts
@@ -111,19 +176,84 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 		clusternetInformerFactory.Apps().V1alpha1().Localizations().Informer()
 		clusternetInformerFactory.Apps().V1alpha1().Globalizations().Informer()
 
-		d, err = deployer.NewDeployer(ctx, kubeclient, clusternetclient, clusternetInformerFactory, kubeInformerFactory)
+		d, err = deployer.NewDeployer(ctx, kubeclient, clusternetclient, clusternetInformerFactory, kubeInformerFactory,
+			opts.PlacementWebhook, opts.LifecycleEventWebhook, opts.RolloutPrometheusAddress, opts.MaxManifestsPerDescription, opts.MaxDescriptionBytes,
+			opts.DynamicSchedulingInterval, opts.DescriptionRollbackAttempts, opts.HelmDriftCheckInterval,
+			description.RateLimitOptions{
+				ClusterQPS:        opts.DeployerClusterQPS,
+				ClusterBurst:      opts.DeployerClusterBurst,
+				SubscriptionQPS:   opts.DeployerSubscriptionQPS,
+				SubscriptionBurst: opts.DeployerSubscriptionBurst,
+				RetryBaseDelay:    opts.DeployerRetryBaseDelay,
+				RetryMaxDelay:     opts.DeployerRetryMaxDelay,
+			}, sharder)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	var gc *garbagecollector.GarbageCollector
 	if utilfeature.DefaultFeatureGate.Enabled(features.ShadowAPI) {
 		clusternetInformerFactory.Apps().V1alpha1().Manifests().Informer()
+		// used to aggregate status for shadow resources
+		clusternetInformerFactory.Apps().V1alpha1().Descriptions().Informer()
+
+		gc, err = garbagecollector.NewGarbageCollector(ctx, clusternetclient, clusternetInformerFactory,
+			restmapper.NewDeferredDiscoveryRESTMapper(cacheddiscovery.NewMemCacheClient(kubeclient.Discovery())),
+			opts.ShadowExcludeResources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var upgrader *agentupgrader.AgentUpgrader
+	if utilfeature.DefaultFeatureGate.Enabled(features.AgentUpgrade) {
+		// register informers first before informerFactory starts
+		clusternetInformerFactory.Clusters().V1beta1().AgentUpgradePlans().Informer()
+
+		upgrader, err = agentupgrader.NewAgentUpgrader(ctx, kubeclient, clusternetclient, clusternetInformerFactory,
+			kubeInformerFactory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var csrSigner *csrsigner.CSRSigner
+	if utilfeature.DefaultFeatureGate.Enabled(features.CertificateSigning) {
+		// register informers first before informerFactory starts
+		kubeInformerFactory.Certificates().V1().CertificateSigningRequests().Informer()
+
+		csrSigner, err = csrsigner.NewCSRSigner(ctx, kubeclient, clusternetInformerFactory, kubeInformerFactory,
+			opts.ClusterSigningCertFile, opts.ClusterSigningKeyFile, opts.ClusterSigningDuration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var propagator *rbacpropagator.RBACPropagator
+	if utilfeature.DefaultFeatureGate.Enabled(features.RBACPropagation) {
+		propagator, err = rbacpropagator.NewRBACPropagator(ctx, kubeclient, clusternetInformerFactory, kubeInformerFactory)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var n *notifier.Notifier
+	if len(opts.NotificationConfig) > 0 {
+		notificationConfig, err := notifier.LoadConfiguration(opts.NotificationConfig)
+		if err != nil {
+			return nil, err
+		}
+		n, err = notifier.NewNotifier(ctx, clusternetInformerFactory, notificationConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	hub := &Hub{
 		ctx:                       ctx,
 		crrApprover:               approver,
+		lifecycle:                 lifecycle,
 		options:                   opts,
 		kubeclient:                kubeclient,
 		clusternetclient:          clusternetclient,
@@ -134,6 +264,12 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 		socketConnection:          socketConnection,
 		deployer:                  d,
 		deployerEnabled:           deployerEnabled,
+		gc:                        gc,
+		upgrader:                  upgrader,
+		csrSigner:                 csrSigner,
+		notifier:                  n,
+		propagator:                propagator,
+		sharder:                   sharder,
 	}
 
 	// Start the informer factories to begin populating the informer caches
@@ -146,17 +282,83 @@ func NewHub(ctx context.Context, opts *options.HubServerOptions) (*Hub, error) {
 }
 
 func (hub *Hub) Run() error {
+	if hub.sharder != nil {
+		go hub.sharder.Run(hub.ctx)
+	}
+
+	if !hub.options.LeaderElection.LeaderElect {
+		go hub.runControllers()
+		return hub.RunAPIServer()
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return fmt.Errorf("unable to get hostname: %v", err)
+	}
+	// add a uniquifier so that two processes on the same host don't accidentally both become active
+	identity := hostname + "_" + string(uuid.NewUUID())
+
+	// all the replicas serve the apis, while only the leader runs the controllers
+	electionErr := make(chan error, 1)
 	go func() {
-		hub.crrApprover.Run(DefaultThreadiness)
+		electionErr <- utils.RunWithLeaderElection(hub.ctx, hub.kubeclient, hub.options.LeaderElection, identity,
+			hub.runControllers)
 	}()
 
+	if err = hub.RunAPIServer(); err != nil {
+		return err
+	}
+	// wait for the controllers to drain their work queues before the leadership gets released
+	return <-electionErr
+}
+
+// runControllers runs all the controllers, and blocks until they are stopped.
+func (hub *Hub) runControllers() {
+	var wg wait.Group
+	wg.Start(func() {
+		hub.crrApprover.Run(hub.options.Threadiness)
+	})
+	wg.Start(func() {
+		hub.lifecycle.Run()
+	})
+
 	if hub.deployerEnabled {
-		go func() {
-			hub.deployer.Run(DefaultThreadiness)
-		}()
+		wg.Start(func() {
+			hub.deployer.Run(hub.options.Threadiness)
+		})
+	}
+
+	if hub.gc != nil {
+		wg.Start(func() {
+			hub.gc.Run(hub.options.Threadiness)
+		})
+	}
+
+	if hub.upgrader != nil {
+		wg.Start(func() {
+			hub.upgrader.Run(hub.options.Threadiness)
+		})
 	}
 
-	return hub.RunAPIServer()
+	if hub.csrSigner != nil {
+		wg.Start(func() {
+			hub.csrSigner.Run(hub.options.Threadiness)
+		})
+	}
+
+	if hub.notifier != nil {
+		wg.Start(func() {
+			hub.notifier.Run(hub.options.Threadiness)
+		})
+	}
+
+	if hub.propagator != nil {
+		wg.Start(func() {
+			hub.propagator.Run(hub.options.Threadiness)
+		})
+	}
+
+	wg.Wait()
 }
 
 // RunAPIServer starts a new HubAPIServer given HubServerOptions
@@ -167,11 +369,36 @@ func (hub *Hub) RunAPIServer() error {
 		return err
 	}
 
-	server, err := config.Complete().New(hub.options.TunnelLogging, hub.socketConnection,
+	// avoid wrapping a nil *Deployer into a non-nil Renderer or Differ
+	var renderer render.Renderer
+	var differ diff.Differ
+	if hub.deployerEnabled {
+		renderer = hub.deployer
+		differ = hub.deployer
+	}
+
+	server, err := config.Complete().New(hub.options.TunnelLogging, hub.socketConnection, hub.options.RequireProxyGrants,
+		hub.options.TunnelGRPCBindAddress,
+		hub.options.ClusterSigningCertFile,
+		hub.options.MaxProxiedRequestsPerCluster,
+		hub.options.MaxProxiedRequestsPerUser,
+		hub.options.ProxyImpersonation,
+		hub.options.ProxyImpersonationGroups,
+		subresources.SessionLimits{
+			IdleTimeout: hub.options.ProxySessionIdleTimeout,
+			MaxDuration: hub.options.ProxySessionMaxDuration,
+		},
+		hub.options.ShadowAdmissionCluster,
+		hub.options.ShadowAuditWebhook,
+		hub.options.ShadowExcludeResources,
 		hub.options.RecommendedOptions.Authentication.RequestHeader.ExtraHeaderPrefixes,
+		renderer,
+		differ,
 		hub.kubeclient,
 		hub.clusternetclient,
-		hub.clusternetInformerFactory)
+		hub.kubeInformerFactory,
+		hub.clusternetInformerFactory,
+		hub.crdInformerFactory)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomizer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	base := map[string][]byte{
+		"base/kustomization.yaml": []byte(`resources:
+- service.yaml
+`),
+		"base/service.yaml": []byte(`apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`),
+	}
+
+	tests := []struct {
+		name      string
+		overlay   map[string][]byte
+		dir       string
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "base",
+			dir:       "base",
+			wantNames: []string{"web"},
+		},
+		{
+			name: "overlay",
+			overlay: map[string][]byte{
+				"overlays/prod/kustomization.yaml": []byte(`namePrefix: prod-
+namespace: prod
+resources:
+- ../../base
+configMapGenerator:
+- name: config
+  literals:
+  - env=prod
+  options:
+    disableNameSuffixHash: true
+`),
+			},
+			dir:       "overlays/prod",
+			wantNames: []string{"prod/prod-config", "prod/prod-web"},
+		},
+		{
+			name:    "missing kustomization",
+			dir:     "overlays/dev",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string][]byte{}
+			for p, data := range base {
+				files[p] = data
+			}
+			for p, data := range tt.overlay {
+				files[p] = data
+			}
+
+			objs, err := Build(files, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var names []string
+			for _, obj := range objs {
+				name := obj.GetName()
+				if len(obj.GetNamespace()) > 0 {
+					name = obj.GetNamespace() + "/" + name
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Build() got %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
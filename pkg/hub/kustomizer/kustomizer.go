@@ -0,0 +1,213 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kustomizer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	kubeinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/apps/kustomization"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+var (
+	kustomizationKind = appsapi.SchemeGroupVersion.WithKind("Kustomization")
+)
+
+// Kustomizer renders Kustomizations into Manifests
+type Kustomizer struct {
+	ctx context.Context
+
+	clusternetClient *clusternetclientset.Clientset
+
+	ksLister        applisters.KustomizationLister
+	ksSynced        cache.InformerSynced
+	configMapLister corelisters.ConfigMapLister
+	configMapSynced cache.InformerSynced
+	mfstLister      applisters.ManifestLister
+	mfstSynced      cache.InformerSynced
+
+	ksController *kustomization.Controller
+
+	recorder record.EventRecorder
+}
+
+func NewKustomizer(ctx context.Context,
+	clusternetClient *clusternetclientset.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory,
+	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	recorder record.EventRecorder) (*Kustomizer, error) {
+
+	kustomizer := &Kustomizer{
+		ctx:              ctx,
+		clusternetClient: clusternetClient,
+		ksLister:         clusternetInformerFactory.Apps().V1alpha1().Kustomizations().Lister(),
+		ksSynced:         clusternetInformerFactory.Apps().V1alpha1().Kustomizations().Informer().HasSynced,
+		configMapLister:  kubeInformerFactory.Core().V1().ConfigMaps().Lister(),
+		configMapSynced:  kubeInformerFactory.Core().V1().ConfigMaps().Informer().HasSynced,
+		mfstLister:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Lister(),
+		mfstSynced:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Informer().HasSynced,
+		recorder:         recorder,
+	}
+
+	ksController, err := kustomization.NewController(ctx, clusternetClient,
+		clusternetInformerFactory.Apps().V1alpha1().Kustomizations(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		recorder,
+		kustomizer.handleKustomization)
+	if err != nil {
+		return nil, err
+	}
+	kustomizer.ksController = ksController
+
+	return kustomizer, nil
+}
+
+func (k *Kustomizer) Run(workers int) {
+	klog.Info("starting Clusternet kustomizer ...")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(k.ctx.Done(),
+		k.ksSynced,
+		k.configMapSynced,
+		k.mfstSynced,
+	) {
+		return
+	}
+
+	k.ksController.Run(workers, k.ctx.Done())
+}
+
+func (k *Kustomizer) handleKustomization(ks *appsapi.Kustomization) error {
+	if ks.DeletionTimestamp != nil {
+		if err := utils.PruneRenderedManifests(k.clusternetClient, k.mfstLister, getFeedSource(ks), sets.NewString()); err != nil {
+			return err
+		}
+
+		// remove finalizer
+		ks.Finalizers = utils.RemoveString(ks.Finalizers, known.AppFinalizer)
+		_, err := k.clusternetClient.AppsV1alpha1().Kustomizations(ks.Namespace).Update(context.TODO(), ks, metav1.UpdateOptions{})
+		if err != nil {
+			klog.WarningDepth(4,
+				fmt.Sprintf("failed to remove finalizer %s from Kustomization %s: %v", known.AppFinalizer, klog.KObj(ks), err))
+		}
+		return err
+	}
+
+	status := ks.Status.DeepCopy()
+	status.ObservedGeneration = ks.Generation
+
+	objs, err := k.render(ks)
+	if err != nil {
+		status.Conditions = utils.MergeConditions(status.Conditions, ks.Generation, metav1.Condition{
+			Type:    appsapi.KustomizationReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "RenderFailed",
+			Message: err.Error(),
+		})
+		if uerr := k.updateStatus(ks, status); uerr != nil {
+			klog.Warningf("failed to update status of Kustomization %s: %v", klog.KObj(ks), uerr)
+		}
+		return err
+	}
+
+	revision, err := utils.HashObjects(objs)
+	if err != nil {
+		return err
+	}
+	if err = utils.SyncRenderedManifests(k.clusternetClient, k.mfstLister, getFeedSource(ks), objs); err != nil {
+		status.Conditions = utils.MergeConditions(status.Conditions, ks.Generation, metav1.Condition{
+			Type:    appsapi.KustomizationReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ManifestsSyncFailed",
+			Message: err.Error(),
+		})
+		if uerr := k.updateStatus(ks, status); uerr != nil {
+			klog.Warningf("failed to update status of Kustomization %s: %v", klog.KObj(ks), uerr)
+		}
+		return err
+	}
+
+	status.Revision = revision
+	status.Resources = int32(len(objs))
+	status.Conditions = utils.MergeConditions(status.Conditions, ks.Generation, metav1.Condition{
+		Type:    appsapi.KustomizationReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Rendered",
+		Message: fmt.Sprintf("%d resources are rendered", len(objs)),
+	})
+	return k.updateStatus(ks, status)
+}
+
+func (k *Kustomizer) updateStatus(ks *appsapi.Kustomization, status *appsapi.KustomizationStatus) error {
+	if reflect.DeepEqual(ks.Status, *status) {
+		return nil
+	}
+	return k.ksController.UpdateKustomizationStatus(ks.DeepCopy(), status)
+}
+
+// render collects the files from all the sources and builds the kustomization
+func (k *Kustomizer) render(ks *appsapi.Kustomization) ([]*unstructured.Unstructured, error) {
+	files := map[string][]byte{}
+	for _, source := range ks.Spec.Sources {
+		configMap, err := k.configMapLister.ConfigMaps(ks.Namespace).Get(source.ConfigMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %v", source.ConfigMap, err)
+		}
+		for key, val := range configMap.Data {
+			files[path.Join(source.Path, key)] = []byte(val)
+		}
+		for key, val := range configMap.BinaryData {
+			files[path.Join(source.Path, key)] = val
+		}
+	}
+
+	objs, err := Build(files, ks.Spec.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kustomization: %v", err)
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("kustomization renders no resources")
+	}
+	return objs, nil
+}
+
+func getFeedSource(ks *appsapi.Kustomization) utils.FeedSource {
+	return utils.FeedSource{
+		GroupVersionKind: kustomizationKind,
+		Resource:         "kustomizations",
+		Namespace:        ks.Namespace,
+		Name:             ks.Name,
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
@@ -127,10 +128,15 @@ func (l *Localizer) Run(workers int) {
 		return
 	}
 
-	go l.locController.Run(workers, l.ctx.Done())
-	go l.globController.Run(workers, l.ctx.Done())
+	var wg wait.Group
+	wg.Start(func() {
+		l.locController.Run(workers, l.ctx.Done())
+	})
+	wg.Start(func() {
+		l.globController.Run(workers, l.ctx.Done())
+	})
 
-	<-l.ctx.Done()
+	wg.Wait()
 }
 
 func (l *Localizer) handleLocalization(loc *appsapi.Localization) error {
@@ -180,7 +186,8 @@ func (l *Localizer) ApplyOverridesToDescription(desc *appsapi.Description) error
 				continue
 			}
 
-			result, err := applyOverrides([]byte(""), overrides)
+			// Helm values start from an empty object
+			result, err := applyOverrides([]byte("{}"), overrides)
 			if err != nil {
 				allErrs = append(allErrs, err)
 				continue
@@ -246,13 +253,18 @@ func (l *Localizer) getOverrides(namespace string, feed appsapi.Feed) ([]appsapi
 	if err != nil {
 		return nil, err
 	}
-	sort.SliceStable(globs, func(i, j int) bool {
-		if globs[i].Spec.Priority < globs[j].Spec.Priority {
-			return true
+	var globSources []overrideSource
+	for _, glob := range globs {
+		if glob.DeletionTimestamp != nil {
+			continue
 		}
-
-		return globs[i].CreationTimestamp.Second() < globs[j].CreationTimestamp.Second()
-	})
+		globSources = append(globSources, overrideSource{
+			priority:          glob.Spec.Priority,
+			creationTimestamp: glob.CreationTimestamp,
+			name:              glob.Name,
+			overrides:         glob.Spec.Overrides,
+		})
+	}
 
 	locs, err := l.locLister.Localizations(namespace).List(labels.SelectorFromSet(labels.Set{
 		string(uid): feed.Kind,
@@ -260,27 +272,46 @@ func (l *Localizer) getOverrides(namespace string, feed appsapi.Feed) ([]appsapi
 	if err != nil {
 		return nil, err
 	}
-	sort.SliceStable(locs, func(i, j int) bool {
-		if locs[i].Spec.Priority < locs[j].Spec.Priority {
-			return true
-		}
-
-		return locs[i].CreationTimestamp.Second() < locs[j].CreationTimestamp.Second()
-	})
-
-	var allOverrideConfigs []appsapi.OverrideConfig
-	for _, glob := range globs {
-		if glob.DeletionTimestamp != nil {
-			continue
-		}
-		allOverrideConfigs = append(allOverrideConfigs, glob.Spec.Overrides...)
-	}
+	var locSources []overrideSource
 	for _, loc := range locs {
 		if loc.DeletionTimestamp != nil {
 			continue
 		}
-		allOverrideConfigs = append(allOverrideConfigs, loc.Spec.Overrides...)
+		locSources = append(locSources, overrideSource{
+			priority:          loc.Spec.Priority,
+			creationTimestamp: loc.CreationTimestamp,
+			name:              loc.Name,
+			overrides:         loc.Spec.Overrides,
+		})
 	}
 
+	// Globalizations are applied before Localizations, so that the overrides specific to a cluster win
+	var allOverrideConfigs []appsapi.OverrideConfig
+	for _, source := range append(sortOverrideSources(globSources), sortOverrideSources(locSources)...) {
+		allOverrideConfigs = append(allOverrideConfigs, source.overrides...)
+	}
 	return allOverrideConfigs, nil
 }
+
+// overrideSource is a Globalization or Localization whose overrides apply to a feed.
+type overrideSource struct {
+	priority          int32
+	creationTimestamp metav1.Time
+	name              string
+	overrides         []appsapi.OverrideConfig
+}
+
+// sortOverrideSources sorts the sources in the order their overrides get applied, which is ascending order of
+// priority, then creation and names, so that the overrides applied later take precedence.
+func sortOverrideSources(sources []overrideSource) []overrideSource {
+	sort.SliceStable(sources, func(i, j int) bool {
+		if sources[i].priority != sources[j].priority {
+			return sources[i].priority < sources[j].priority
+		}
+		if !sources[i].creationTimestamp.Equal(&sources[j].creationTimestamp) {
+			return sources[i].creationTimestamp.Before(&sources[j].creationTimestamp)
+		}
+		return sources[i].name < sources[j].name
+	})
+	return sources
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localizer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSortOverrideSources(t *testing.T) {
+	now := time.Now()
+	sources := []overrideSource{
+		{priority: 800, creationTimestamp: metav1.NewTime(now.Add(-time.Hour)), name: "high"},
+		{priority: 500, creationTimestamp: metav1.NewTime(now), name: "newer"},
+		{priority: 500, creationTimestamp: metav1.NewTime(now.Add(-time.Minute)), name: "older"},
+		{priority: 500, creationTimestamp: metav1.NewTime(now), name: "another-newer"},
+		{priority: 100, creationTimestamp: metav1.NewTime(now), name: "low"},
+	}
+
+	var got []string
+	for _, source := range sortOverrideSources(sources) {
+		got = append(got, source.name)
+	}
+	want := []string{"low", "older", "another-newer", "newer", "high"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sortOverrideSources() = %v, want %v", got, want)
+	}
+}
@@ -22,6 +22,10 @@ import (
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/yaml"
 
 	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
@@ -56,6 +60,11 @@ func applyOverrides(original []byte, overrides []appsapi.OverrideConfig) ([]byte
 			if err != nil {
 				return nil, fmt.Errorf("failed to apply OverrideConfig %s: %v", overrideConfig.Name, err)
 			}
+		case appsapi.StrategicMergePatchType:
+			result, err = applyStrategicMergePatch(result, overrideBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply OverrideConfig %s: %v", overrideConfig.Name, err)
+			}
 		default:
 			return nil, fmt.Errorf("unsupported OverrideType %s", overrideConfig.Type)
 		}
@@ -80,6 +89,23 @@ func applyJSONPatch(cur, overrideBytes []byte) ([]byte, error) {
 	return patchedJS, nil
 }
 
+// applyStrategicMergePatch applies a strategic merge patch with the patch strategies of the built-in kind,
+// and falls back to a json merge patch for other kinds, which have no patch strategies declared.
+func applyStrategicMergePatch(cur, overrideBytes []byte) ([]byte, error) {
+	typeMeta := metav1.TypeMeta{}
+	if err := json.Unmarshal(cur, &typeMeta); err != nil {
+		return nil, err
+	}
+	dataStruct, err := scheme.Scheme.New(typeMeta.GroupVersionKind())
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			return jsonpatch.MergePatch(cur, overrideBytes)
+		}
+		return nil, err
+	}
+	return strategicpatch.StrategicMergePatch(cur, overrideBytes, dataStruct)
+}
+
 func applyHelmOverride(currentByte, overrideByte []byte) ([]byte, error) {
 	currentObj := map[string]interface{}{}
 	if err := json.Unmarshal(currentByte, &currentObj); err != nil {
@@ -176,6 +176,68 @@ hole: black
 				}
 			}`),
 		},
+		{
+			name: "StrategicMergePatch",
+			original: []byte(`{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web"},
+				"spec": {
+					"template": {
+						"spec": {
+							"containers": [
+								{"name": "nginx", "image": "nginx:latest"},
+								{"name": "sidecar", "image": "envoy:latest"}
+							]
+						}
+					}
+				}
+			}`),
+			overrides: []appsapi.OverrideConfig{
+				{
+					Name:  "update image of container nginx",
+					Type:  appsapi.StrategicMergePatchType,
+					Value: `{"spec":{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx:1.21.1"}]}}}}`,
+				},
+			},
+			want: []byte(`{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web"},
+				"spec": {
+					"template": {
+						"spec": {
+							"containers": [
+								{"name": "nginx", "image": "nginx:1.21.1"},
+								{"name": "sidecar", "image": "envoy:latest"}
+							]
+						}
+					}
+				}
+			}`),
+		},
+		{
+			name: "StrategicMergePatch on custom kind",
+			original: []byte(`{
+				"apiVersion": "example.com/v1",
+				"kind": "Database",
+				"metadata": {"name": "db"},
+				"spec": {"replicas": 1, "users": [{"name": "admin"}]}
+			}`),
+			overrides: []appsapi.OverrideConfig{
+				{
+					Name:  "patched as a merge patch",
+					Type:  appsapi.StrategicMergePatchType,
+					Value: `{"spec":{"replicas":3,"users":[{"name":"reader"}]}}`,
+				},
+			},
+			want: []byte(`{
+				"apiVersion": "example.com/v1",
+				"kind": "Database",
+				"metadata": {"name": "db"},
+				"spec": {"replicas": 3, "users": [{"name": "reader"}]}
+			}`),
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,3 +263,35 @@ hole: black
 		})
 	}
 }
+
+func TestApplyHelmOverridesToEmptyValues(t *testing.T) {
+	overrides := []appsapi.OverrideConfig{
+		{
+			Name:  "replicas",
+			Type:  appsapi.HelmType,
+			Value: "replicaCount: 2\ningress:\n  hosts:\n  - foo.example.com\n",
+		},
+		{
+			Name:  "ingress",
+			Type:  appsapi.HelmType,
+			Value: `{"ingress":{"enabled":true}}`,
+		},
+	}
+
+	got, err := applyOverrides([]byte("{}"), overrides)
+	if err != nil {
+		t.Fatalf("applyOverrides() error = %v", err)
+	}
+
+	var gotValues, wantValues map[string]interface{}
+	if err := yaml.Unmarshal(got, &gotValues); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	want := []byte(`{"replicaCount":2,"ingress":{"enabled":true,"hosts":["foo.example.com"]}}`)
+	if err := yaml.Unmarshal(want, &wantValues); err != nil {
+		t.Fatalf("error decoding: %v", err)
+	}
+	if !reflect.DeepEqual(gotValues, wantValues) {
+		t.Errorf("applyOverrides() got %s, want %s", got, want)
+	}
+}
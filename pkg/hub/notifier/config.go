@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// SinkType is the type of a sink where notifications are delivered to.
+type SinkType string
+
+const (
+	// SinkWebhook posts notifications in JSON to a generic webhook.
+	SinkWebhook SinkType = "webhook"
+	// SinkSlack posts notifications to a Slack incoming webhook.
+	SinkSlack SinkType = "slack"
+	// SinkEmail sends notifications as emails via SMTP.
+	SinkEmail SinkType = "email"
+)
+
+// kinds of the objects watched by the notifier
+const (
+	KindSubscription   = "Subscription"
+	KindDescription    = "Description"
+	KindManagedCluster = "ManagedCluster"
+)
+
+// defaultSinkTimeout is the timeout of delivering a notification to sinks without timeoutSeconds
+const defaultSinkTimeout = 10 * time.Second
+
+// defaultTemplate renders the message of notifications for sinks without template
+const defaultTemplate = `[{{ .Type }}] {{ .Kind }} {{ .Namespace }}/{{ .Name }}` +
+	`{{ if .ClusterName }} in cluster {{ .ClusterName }}{{ end }}: ` +
+	`condition {{ .ConditionType }} is {{ .Status }}{{ if .Reason }} ({{ .Reason }}){{ end }}` +
+	`{{ if .Message }}: {{ .Message }}{{ end }}`
+
+// Configuration declares the sinks where notifications are delivered to, and the routes selecting the sinks,
+// which is loaded from a YAML file.
+type Configuration struct {
+	Sinks  []Sink  `json:"sinks"`
+	Routes []Route `json:"routes"`
+}
+
+// Sink is where notifications are delivered to.
+type Sink struct {
+	// Name of the sink, which is referred by routes.
+	Name string `json:"name"`
+	// Type of the sink, which is one of webhook, slack and email.
+	Type SinkType `json:"type"`
+	// URL where notifications are posted to, which is required for webhook and slack sinks.
+	// A slack sink posts to a Slack incoming webhook.
+	URL string `json:"url,omitempty"`
+	// SMTP server where emails are sent via, which is required for email sinks.
+	SMTP *SMTP `json:"smtp,omitempty"`
+	// Template is a Go template rendering the message from a Notification.
+	// A default one-line message is rendered if not set.
+	Template string `json:"template,omitempty"`
+	// TimeoutSeconds is the timeout of delivering each notification, which defaults to 10.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// SMTP is the SMTP server where emails are sent via.
+type SMTP struct {
+	// Host of the SMTP server.
+	Host string `json:"host"`
+	// Port of the SMTP server, which defaults to 587.
+	Port int32 `json:"port,omitempty"`
+	// From is the sender address.
+	From string `json:"from"`
+	// To are the recipient addresses.
+	To []string `json:"to"`
+	// Username to authenticate with the SMTP server. No authentication is performed if not set.
+	Username string `json:"username,omitempty"`
+	// PasswordFile is the file containing the password of Username, so that it could be mounted from a Secret.
+	PasswordFile string `json:"passwordFile,omitempty"`
+}
+
+// Route selects the notifications delivered to the sinks. Notifications matching all the non-empty fields
+// are delivered to the sinks, and a notification matching several routes is delivered to each sink only once.
+type Route struct {
+	// Namespaces of the objects. Descriptions are also matched by the namespaces of their Subscriptions.
+	// All the namespaces are matched if empty.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Kinds of the objects, which are Subscription, Description and ManagedCluster. All the kinds are matched if empty.
+	Kinds []string `json:"kinds,omitempty"`
+	// Types of the notifications, which are Normal and Warning. All the types are matched if empty.
+	Types []string `json:"types,omitempty"`
+	// Sinks are the names of the sinks where the matched notifications are delivered to.
+	Sinks []string `json:"sinks"`
+}
+
+// LoadConfiguration loads and validates the Configuration from the file.
+func LoadConfiguration(file string) (*Configuration, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := &Configuration{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse notification configuration in %s: %v", file, err)
+	}
+	if err = validateConfiguration(config); err != nil {
+		return nil, fmt.Errorf("invalid notification configuration in %s: %v", file, err)
+	}
+	return config, nil
+}
+
+func validateConfiguration(config *Configuration) error {
+	names := sets.NewString()
+	for _, sink := range config.Sinks {
+		if len(sink.Name) == 0 {
+			return fmt.Errorf("sinks must have names")
+		}
+		if names.Has(sink.Name) {
+			return fmt.Errorf("duplicated sink %q", sink.Name)
+		}
+		names.Insert(sink.Name)
+
+		switch sink.Type {
+		case SinkWebhook, SinkSlack:
+			if u, err := url.Parse(sink.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("sink %q must have a valid http or https url", sink.Name)
+			}
+		case SinkEmail:
+			if sink.SMTP == nil || len(sink.SMTP.Host) == 0 || len(sink.SMTP.From) == 0 || len(sink.SMTP.To) == 0 {
+				return fmt.Errorf("sink %q must have smtp with host, from and to", sink.Name)
+			}
+			if len(sink.SMTP.Username) > 0 && len(sink.SMTP.PasswordFile) == 0 {
+				return fmt.Errorf("sink %q must have smtp passwordFile along with username", sink.Name)
+			}
+		default:
+			return fmt.Errorf("sink %q has unknown type %q", sink.Name, sink.Type)
+		}
+		if len(sink.Template) > 0 {
+			if _, err := template.New(sink.Name).Parse(sink.Template); err != nil {
+				return fmt.Errorf("sink %q has invalid template: %v", sink.Name, err)
+			}
+		}
+	}
+
+	kinds := sets.NewString(KindSubscription, KindDescription, KindManagedCluster)
+	for i, route := range config.Routes {
+		if len(route.Sinks) == 0 {
+			return fmt.Errorf("route %d must have sinks", i)
+		}
+		for _, sink := range route.Sinks {
+			if !names.Has(sink) {
+				return fmt.Errorf("route %d refers to unknown sink %q", i, sink)
+			}
+		}
+		for _, kind := range route.Kinds {
+			if !kinds.Has(kind) {
+				return fmt.Errorf("route %d has unknown kind %q", i, kind)
+			}
+		}
+	}
+	return nil
+}
+
+// matches tells whether the notification is selected by the route.
+func (r *Route) matches(n *Notification) bool {
+	namespaceMatched := matchesAny(r.Namespaces, n.Namespace) ||
+		(len(n.SubscriptionNamespace) > 0 && matchesAny(r.Namespaces, n.SubscriptionNamespace))
+	return namespaceMatched && matchesAny(r.Kinds, n.Kind) && matchesAny(r.Types, n.Type)
+}
+
+func matchesAny(values []string, value string) bool {
+	return len(values) == 0 || sets.NewString(values...).Has(value)
+}
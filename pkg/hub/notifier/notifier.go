@@ -0,0 +1,257 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notifier delivers notifications to sinks, such as generic webhooks, Slack and emails, once the conditions
+// of Subscriptions, Descriptions and ManagedClusters change, so that operators could be alerted without watching
+// the objects. The sinks and the routes selecting them are declared in a YAML file.
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// Notification tells that a condition of an object changes.
+type Notification struct {
+	// Kind of the object, which is Subscription, Description or ManagedCluster.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// ClusterName is the cluster of Descriptions and ManagedClusters, which is empty for Subscriptions.
+	ClusterName string `json:"clusterName,omitempty"`
+	// SubscriptionNamespace and SubscriptionName are the Subscription that a Description belongs to,
+	// which are empty for the other kinds.
+	SubscriptionNamespace string `json:"subscriptionNamespace,omitempty"`
+	SubscriptionName      string `json:"subscriptionName,omitempty"`
+
+	ConditionType string                 `json:"conditionType"`
+	Status        metav1.ConditionStatus `json:"status"`
+	Reason        string                 `json:"reason,omitempty"`
+	Message       string                 `json:"message,omitempty"`
+
+	// Type is Normal if the condition becomes True, and Warning otherwise.
+	Type      string      `json:"type"`
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// delivery is a notification to be delivered to a sink, which is queued in the workqueue.
+type delivery struct {
+	sink         string
+	notification Notification
+}
+
+// Notifier watches the conditions of Subscriptions, Descriptions and ManagedClusters, and delivers the changes
+// to the sinks selected by the routes. Conditions of newly observed objects are not notified, so that restarting
+// clusternet-hub does not flood the sinks. Failed deliveries are retried with exponential backoff.
+type Notifier struct {
+	ctx context.Context
+
+	subSynced     cache.InformerSynced
+	descSynced    cache.InformerSynced
+	clusterSynced cache.InformerSynced
+
+	sinks  map[string]*sink
+	routes []Route
+
+	workqueue workqueue.RateLimitingInterface
+}
+
+// NewNotifier returns a new Notifier delivering notifications as declared in config.
+// It should be called before clusternetInformerFactory starts.
+func NewNotifier(ctx context.Context, clusternetInformerFactory clusternetinformers.SharedInformerFactory,
+	config *Configuration) (*Notifier, error) {
+	subInformer := clusternetInformerFactory.Apps().V1alpha1().Subscriptions().Informer()
+	descInformer := clusternetInformerFactory.Apps().V1alpha1().Descriptions().Informer()
+	clusterInformer := clusternetInformerFactory.Clusters().V1beta1().ManagedClusters().Informer()
+
+	n := &Notifier{
+		ctx:           ctx,
+		subSynced:     subInformer.HasSynced,
+		descSynced:    descInformer.HasSynced,
+		clusterSynced: clusterInformer.HasSynced,
+		sinks:         make(map[string]*sink, len(config.Sinks)),
+		routes:        config.Routes,
+		workqueue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "notifier"),
+	}
+	for _, s := range config.Sinks {
+		result, err := newSink(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sink %q: %v", s.Name, err)
+		}
+		n.sinks[s.Name] = result
+	}
+
+	subInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldSub, curSub := old.(*appsapi.Subscription), cur.(*appsapi.Subscription)
+			n.notifyConditionChanges(KindSubscription, curSub, "", oldSub.Status.Conditions, curSub.Status.Conditions)
+		},
+	})
+	descInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldDesc, curDesc := old.(*appsapi.Description), cur.(*appsapi.Description)
+			n.notifyConditionChanges(KindDescription, curDesc, curDesc.Labels[known.ClusterNameLabel],
+				oldDesc.Status.Conditions, curDesc.Status.Conditions)
+		},
+	})
+	clusterInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldCluster, curCluster := old.(*clusterapi.ManagedCluster), cur.(*clusterapi.ManagedCluster)
+			n.notifyConditionChanges(KindManagedCluster, curCluster, curCluster.Labels[known.ClusterNameLabel],
+				oldCluster.Status.Conditions, curCluster.Status.Conditions)
+		},
+	})
+	return n, nil
+}
+
+// Run starts the workers to deliver notifications. It will block until the context is done.
+func (n *Notifier) Run(workers int) {
+	defer utilruntime.HandleCrash()
+	defer n.workqueue.ShutDown()
+
+	klog.Info("starting Clusternet notifier ...")
+	defer klog.Info("shutting down Clusternet notifier")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(n.ctx.Done(), n.subSynced, n.descSynced, n.clusterSynced) {
+		return
+	}
+
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(n.runWorker, time.Second, n.ctx.Done())
+		})
+	}
+
+	<-n.ctx.Done()
+	// drain the items left in the workqueue before returning
+	n.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (n *Notifier) runWorker() {
+	for n.processNextWorkItem() {
+	}
+}
+
+func (n *Notifier) processNextWorkItem() bool {
+	obj, shutdown := n.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer n.workqueue.Done(obj)
+
+	item, ok := obj.(delivery)
+	if !ok {
+		n.workqueue.Forget(obj)
+		utilruntime.HandleError(fmt.Errorf("expected delivery in workqueue but got %#v", obj))
+		return true
+	}
+	start := time.Now()
+	err := n.deliver(item)
+	metrics.ObserveReconcile("notifier", start, err)
+	if err != nil {
+		n.workqueue.AddRateLimited(item)
+		utilruntime.HandleError(fmt.Errorf("error delivering notification of %s %s/%s to sink %q: %v, requeuing",
+			item.notification.Kind, item.notification.Namespace, item.notification.Name, item.sink, err))
+		return true
+	}
+	n.workqueue.Forget(obj)
+	return true
+}
+
+func (n *Notifier) deliver(item delivery) error {
+	s, ok := n.sinks[item.sink]
+	if !ok {
+		// never happens since routes are validated
+		return nil
+	}
+	klog.V(5).Infof("delivering notification of %s %s/%s to sink %q", item.notification.Kind,
+		item.notification.Namespace, item.notification.Name, item.sink)
+	return s.deliver(n.ctx, &item.notification)
+}
+
+// notifyConditionChanges enqueues a notification for each condition that is added or changes its status.
+func (n *Notifier) notifyConditionChanges(kind string, obj metav1.Object, clusterName string, old, cur []metav1.Condition) {
+	for _, notification := range conditionChanges(kind, obj, clusterName, old, cur) {
+		for _, sinkName := range n.route(&notification) {
+			n.workqueue.Add(delivery{sink: sinkName, notification: notification})
+		}
+	}
+}
+
+// route returns the names of the sinks selected by the routes for the notification.
+func (n *Notifier) route(notification *Notification) []string {
+	sinks := sets.NewString()
+	for i := range n.routes {
+		if n.routes[i].matches(notification) {
+			sinks.Insert(n.routes[i].Sinks...)
+		}
+	}
+	return sinks.List()
+}
+
+// conditionChanges returns the notifications of the conditions that are added or change their status.
+func conditionChanges(kind string, obj metav1.Object, clusterName string, old, cur []metav1.Condition) []Notification {
+	var notifications []Notification
+	for _, condition := range cur {
+		previous := apimeta.FindStatusCondition(old, condition.Type)
+		if previous != nil && previous.Status == condition.Status {
+			continue
+		}
+
+		eventType := corev1.EventTypeNormal
+		if condition.Status != metav1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+		notifications = append(notifications, Notification{
+			Kind:          kind,
+			Namespace:     obj.GetNamespace(),
+			Name:          obj.GetName(),
+			ClusterName:   clusterName,
+			ConditionType: condition.Type,
+			Status:        condition.Status,
+			Reason:        condition.Reason,
+			Message:       condition.Message,
+			Type:          eventType,
+			Timestamp:     condition.LastTransitionTime,
+		})
+		if kind == KindDescription {
+			notifications[len(notifications)-1].SubscriptionNamespace = obj.GetLabels()[known.ConfigSubscriptionNamespaceLabel]
+			notifications[len(notifications)-1].SubscriptionName = obj.GetLabels()[known.ConfigSubscriptionNameLabel]
+		}
+	}
+	return notifications
+}
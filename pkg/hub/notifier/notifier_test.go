@@ -0,0 +1,279 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadConfiguration(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			config: `sinks:
+- name: oncall
+  type: slack
+  url: https://hooks.slack.com/services/T000/B000/XXX
+- name: audit
+  type: webhook
+  url: http://audit.example.com/notifications
+  template: "{{ .Kind }} {{ .Name }}"
+- name: mail
+  type: email
+  smtp:
+    host: smtp.example.com
+    from: clusternet@example.com
+    to: ["ops@example.com"]
+routes:
+- namespaces: ["team-a"]
+  types: ["Warning"]
+  sinks: ["oncall", "mail"]
+- kinds: ["ManagedCluster"]
+  sinks: ["audit"]
+`,
+		},
+		{
+			name: "unknown sink type",
+			config: `sinks:
+- name: pager
+  type: pagerduty
+`,
+			wantErr: `unknown type "pagerduty"`,
+		},
+		{
+			name: "slack without url",
+			config: `sinks:
+- name: oncall
+  type: slack
+`,
+			wantErr: "valid http or https url",
+		},
+		{
+			name: "email without recipients",
+			config: `sinks:
+- name: mail
+  type: email
+  smtp:
+    host: smtp.example.com
+    from: clusternet@example.com
+`,
+			wantErr: "smtp with host, from and to",
+		},
+		{
+			name: "invalid template",
+			config: `sinks:
+- name: audit
+  type: webhook
+  url: http://audit.example.com
+  template: "{{ .Kind "
+`,
+			wantErr: "invalid template",
+		},
+		{
+			name: "route to unknown sink",
+			config: `sinks:
+- name: audit
+  type: webhook
+  url: http://audit.example.com
+routes:
+- sinks: ["oncall"]
+`,
+			wantErr: `unknown sink "oncall"`,
+		},
+		{
+			name: "route with unknown kind",
+			config: `sinks:
+- name: audit
+  type: webhook
+  url: http://audit.example.com
+routes:
+- kinds: ["Deployment"]
+  sinks: ["audit"]
+`,
+			wantErr: `unknown kind "Deployment"`,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "notifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, fmt.Sprintf("config-%d.yaml", i))
+			if err := ioutil.WriteFile(file, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfiguration(file)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("LoadConfiguration() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadConfiguration() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConditionChanges(t *testing.T) {
+	obj := &metav1.ObjectMeta{Namespace: "clusternet-abcde", Name: "clusternet-cluster-abcde"}
+	old := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, Reason: "ManagedClusterReady"},
+		{Type: "Cordoned", Status: metav1.ConditionFalse},
+	}
+	cur := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionUnknown, Reason: "ClusterStatusUnknown", Message: "no heartbeats"},
+		{Type: "Cordoned", Status: metav1.ConditionFalse, Reason: "Uncordoned"},
+		{Type: "Evicted", Status: metav1.ConditionTrue},
+	}
+
+	got := conditionChanges(KindManagedCluster, obj, "dc01", old, cur)
+	var summary []string
+	for _, n := range got {
+		summary = append(summary, fmt.Sprintf("%s=%s/%s", n.ConditionType, n.Status, n.Type))
+		if n.Kind != KindManagedCluster || n.Namespace != obj.Namespace || n.Name != obj.Name || n.ClusterName != "dc01" {
+			t.Errorf("unexpected object in notification %+v", n)
+		}
+	}
+	want := []string{"Ready=Unknown/Warning", "Evicted=True/Normal"}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("conditionChanges() = %v, want %v", summary, want)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	n := &Notifier{
+		routes: []Route{
+			{Namespaces: []string{"team-a"}, Types: []string{"Warning"}, Sinks: []string{"oncall", "mail"}},
+			{Kinds: []string{KindDescription}, Sinks: []string{"audit", "oncall"}},
+			{Namespaces: []string{"team-b"}, Sinks: []string{"team-b"}},
+		},
+	}
+
+	tests := []struct {
+		notification Notification
+		want         []string
+	}{
+		{
+			notification: Notification{Kind: KindDescription, Namespace: "team-a", Type: "Warning"},
+			want:         []string{"audit", "mail", "oncall"},
+		},
+		{
+			notification: Notification{Kind: KindSubscription, Namespace: "team-a", Type: "Normal"},
+		},
+		{
+			notification: Notification{Kind: KindSubscription, Namespace: "team-b", Type: "Normal"},
+			want:         []string{"team-b"},
+		},
+		{
+			notification: Notification{Kind: KindDescription, Namespace: "clusternet-abcde", SubscriptionNamespace: "team-b", Type: "Normal"},
+			want:         []string{"audit", "oncall", "team-b"},
+		},
+	}
+	for _, tt := range tests {
+		got := n.route(&tt.notification)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("route(%+v) = %v, want %v", tt.notification, got, tt.want)
+		}
+	}
+}
+
+func TestSinkDeliver(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	notification := &Notification{
+		Kind:          KindDescription,
+		Namespace:     "clusternet-abcde",
+		Name:          "app-demo-generic",
+		ClusterName:   "dc01",
+		ConditionType: "Ready",
+		Status:        metav1.ConditionFalse,
+		Reason:        "ProgressDeadlineExceeded",
+		Type:          "Warning",
+	}
+
+	slack, err := newSink(Sink{Name: "oncall", Type: SinkSlack, URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = slack.deliver(context.TODO(), notification); err != nil {
+		t.Fatalf("deliver() to slack error = %v", err)
+	}
+	webhook, err := newSink(Sink{Name: "audit", Type: SinkWebhook, URL: server.URL, Template: "{{ .ClusterName }}: {{ .Reason }}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = webhook.deliver(context.TODO(), notification); err != nil {
+		t.Fatalf("deliver() to webhook error = %v", err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("got %d requests, want 2", len(bodies))
+	}
+	wantText := "[Warning] Description clusternet-abcde/app-demo-generic in cluster dc01: condition Ready is False (ProgressDeadlineExceeded)"
+	if !reflect.DeepEqual(bodies[0], map[string]interface{}{"text": wantText}) {
+		t.Errorf("slack payload = %v, want text %q", bodies[0], wantText)
+	}
+	if bodies[1]["text"] != "dc01: ProgressDeadlineExceeded" || bodies[1]["kind"] != KindDescription ||
+		bodies[1]["clusterName"] != "dc01" {
+		t.Errorf("unexpected webhook payload %v", bodies[1])
+	}
+
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+	slack, err = newSink(Sink{Name: "oncall", Type: SinkSlack, URL: unavailable.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = slack.deliver(context.TODO(), notification); err == nil {
+		t.Errorf("deliver() to unavailable slack should fail")
+	}
+}
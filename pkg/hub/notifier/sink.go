@@ -0,0 +1,193 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/clusternet/clusternet/pkg/hub/events"
+)
+
+// defaultSMTPPort is the port of SMTP servers without port, where STARTTLS is used if supported
+const defaultSMTPPort = 587
+
+// sink delivers rendered notifications.
+type sink struct {
+	name     string
+	timeout  time.Duration
+	template *template.Template
+	send     func(ctx context.Context, n *Notification, text string) error
+}
+
+func newSink(s Sink) (*sink, error) {
+	text := s.Template
+	if len(text) == 0 {
+		text = defaultTemplate
+	}
+	tmpl, err := template.New(s.Name).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := defaultSinkTimeout
+	if s.TimeoutSeconds > 0 {
+		timeout = time.Duration(s.TimeoutSeconds) * time.Second
+	}
+
+	result := &sink{
+		name:     s.Name,
+		timeout:  timeout,
+		template: tmpl,
+	}
+	switch s.Type {
+	case SinkWebhook:
+		result.send = func(ctx context.Context, n *Notification, text string) error {
+			return postJSON(ctx, s.URL, &webhookPayload{Notification: n, Text: text})
+		}
+	case SinkSlack:
+		result.send = func(ctx context.Context, n *Notification, text string) error {
+			return postJSON(ctx, s.URL, &slackPayload{Text: text})
+		}
+	case SinkEmail:
+		smtpConfig := s.SMTP
+		result.send = func(ctx context.Context, n *Notification, text string) error {
+			return sendEmail(ctx, smtpConfig, n, text)
+		}
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", s.Type)
+	}
+	return result, nil
+}
+
+// deliver renders the notification and delivers it.
+func (s *sink) deliver(ctx context.Context, n *Notification) error {
+	var buf bytes.Buffer
+	if err := s.template.Execute(&buf, n); err != nil {
+		return fmt.Errorf("failed to render notification: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.send(ctx, n, buf.String())
+}
+
+// webhookPayload is posted to webhook sinks.
+type webhookPayload struct {
+	*Notification
+	// Text is the rendered message.
+	Text string `json:"text"`
+}
+
+// slackPayload is posted to Slack incoming webhooks.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+func postJSON(ctx context.Context, url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return events.PostWebhook(ctx, url, data)
+}
+
+// sendEmail sends the notification as a plain text email, with STARTTLS if the server supports it.
+func sendEmail(ctx context.Context, config *SMTP, n *Notification, text string) error {
+	port := config.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(config.Host, strconv.Itoa(int(port))))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	client, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: config.Host}); err != nil {
+			return err
+		}
+	}
+	if len(config.Username) > 0 {
+		// read the password on each delivery, so that rotated Secrets take effect without restarting
+		password, err := ioutil.ReadFile(config.PasswordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read smtp password: %v", err)
+		}
+		if err = client.Auth(smtp.PlainAuth("", config.Username, strings.TrimSpace(string(password)), config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err = client.Mail(config.From); err != nil {
+		return err
+	}
+	for _, to := range config.To {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(newEmail(config, n, text)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func newEmail(config *SMTP, n *Notification, text string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&buf, "Subject: [clusternet] %s %s/%s: %s is %s\r\n", n.Kind, n.Namespace, n.Name,
+		n.ConditionType, n.Status)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Scheme is the scheme of the urls of OCI repositories
+	Scheme = "oci://"
+
+	ImageManifestMediaType  = "application/vnd.oci.image.manifest.v1+json"
+	DockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// maxManifestSize limits the size of manifests and other JSON documents from registries
+	maxManifestSize = 4 << 20
+)
+
+// Descriptor describes the content of a layer in a manifest
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Manifest is an OCI image manifest, which is also used for artifacts and cosign signatures
+type Manifest struct {
+	MediaType string       `json:"mediaType,omitempty"`
+	Layers    []Descriptor `json:"layers"`
+}
+
+// Client pulls manifests and blobs from a repository with the OCI distribution API
+type Client struct {
+	// registry is the base url of the registry, such as "https://ghcr.io"
+	registry   string
+	repository string
+
+	username string
+	password string
+	// authorization is the Authorization header answering the last challenge from the registry
+	authorization string
+
+	httpClient *http.Client
+}
+
+// NewClient returns a Client for an url like "oci://ghcr.io/org/manifests". The registry is accessed with
+// plain http if insecure is true, and the credentials are used only when the registry asks for them.
+func NewClient(repoURL string, insecure bool, username, password string) (*Client, error) {
+	parts := strings.SplitN(strings.TrimPrefix(repoURL, Scheme), "/", 2)
+	if !strings.HasPrefix(repoURL, Scheme) || len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return nil, fmt.Errorf("invalid OCI repository url %q", repoURL)
+	}
+	repository := strings.TrimSuffix(parts[1], "/")
+	if strings.ContainsAny(repository, ":@") {
+		return nil, fmt.Errorf("OCI repository url %q should not contain a tag or digest", repoURL)
+	}
+
+	scheme := "https"
+	if insecure {
+		scheme = "http"
+	}
+	return &Client{
+		registry:   fmt.Sprintf("%s://%s", scheme, parts[0]),
+		repository: repository,
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// ListTags returns all the tags in the repository
+func (c *Client) ListTags(ctx context.Context) ([]string, error) {
+	var tags []string
+	next := fmt.Sprintf("/v2/%s/tags/list", c.repository)
+	for len(next) > 0 {
+		resp, err := c.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tags: %v", err)
+		}
+		tags = append(tags, page.Tags...)
+		next = nextLink(resp.Header.Get("Link"))
+	}
+	return tags, nil
+}
+
+// FetchManifest returns the manifest of reference and its digest, which is verified against reference if it is a digest.
+func (c *Client) FetchManifest(ctx context.Context, reference string) (*Manifest, string, error) {
+	resp, err := c.get(ctx, fmt.Sprintf("/v2/%s/manifests/%s", c.repository, reference),
+		ImageManifestMediaType, DockerManifestMediaType)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > maxManifestSize {
+		return nil, "", fmt.Errorf("manifest %s is larger than %d bytes", reference, maxManifestSize)
+	}
+
+	digest := Digest(data)
+	if strings.HasPrefix(reference, "sha256:") && reference != digest {
+		return nil, "", fmt.Errorf("digest %s of the pulled manifest does not match %s", digest, reference)
+	}
+	if header := resp.Header.Get("Docker-Content-Digest"); strings.HasPrefix(header, "sha256:") && header != digest {
+		return nil, "", fmt.Errorf("digest %s of the pulled manifest does not match %s announced by the registry", digest, header)
+	}
+
+	manifest := &Manifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest %s: %v", reference, err)
+	}
+	mediaType := manifest.MediaType
+	if len(mediaType) == 0 {
+		mediaType = resp.Header.Get("Content-Type")
+	}
+	if mediaType != ImageManifestMediaType && mediaType != DockerManifestMediaType {
+		return nil, "", fmt.Errorf("unsupported manifest media type %q of %s", mediaType, reference)
+	}
+	return manifest, digest, nil
+}
+
+// FetchBlob returns the content of a layer, which is verified against its digest and size
+func (c *Client) FetchBlob(ctx context.Context, layer Descriptor) ([]byte, error) {
+	if !strings.HasPrefix(layer.Digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %q", layer.Digest)
+	}
+	resp, err := c.get(ctx, fmt.Sprintf("/v2/%s/blobs/%s", c.repository, layer.Digest))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, layer.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != layer.Size {
+		return nil, fmt.Errorf("size of blob %s does not match %d", layer.Digest, layer.Size)
+	}
+	if digest := Digest(data); digest != layer.Digest {
+		return nil, fmt.Errorf("digest %s of the pulled blob does not match %s", digest, layer.Digest)
+	}
+	return data, nil
+}
+
+// get sends a GET request to the registry, answering the authentication challenge if there is any
+func (c *Client) get(ctx context.Context, path string, accept ...string) (*http.Response, error) {
+	for challenged := false; ; challenged = true {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.registry+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, mediaType := range accept {
+			req.Header.Add("Accept", mediaType)
+		}
+		if len(c.authorization) > 0 {
+			req.Header.Set("Authorization", c.authorization)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && !challenged {
+			resp.Body.Close()
+			if err = c.authorize(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+			resp.Body.Close()
+			return nil, &StatusError{
+				StatusCode: resp.StatusCode,
+				Message:    fmt.Sprintf("GET %s returned %s: %s", req.URL, resp.Status, strings.TrimSpace(string(body))),
+			}
+		}
+		return resp, nil
+	}
+}
+
+// StatusError is returned when the registry responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// IsNotFound tells whether err is caused by a nonexistent manifest or blob
+func IsNotFound(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+// authorize answers a Basic or Bearer authentication challenge
+func (c *Client) authorize(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if len(c.username) == 0 {
+			return fmt.Errorf("credentials are required by registry %s", c.registry)
+		}
+		c.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("unsupported authentication challenge %q from registry %s", challenge, c.registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || len(params["realm"]) == 0 {
+		return fmt.Errorf("invalid realm in authentication challenge %q", challenge)
+	}
+	query := realm.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	scope, ok := params["scope"]
+	if !ok {
+		scope = fmt.Sprintf("repository:%s:pull", c.repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if len(c.username) > 0 {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get a token from %s: %s", realm.Host, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode the token from %s: %v", realm.Host, err)
+	}
+	if len(token.Token) == 0 {
+		token.Token = token.AccessToken
+	}
+	if len(token.Token) == 0 {
+		return fmt.Errorf("no token is returned from %s", realm.Host)
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header like `Bearer realm="https://auth.io/token",service="registry"`
+// into its scheme and parameters.
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	challenge = strings.TrimSpace(challenge)
+	scheme := challenge
+	if i := strings.IndexByte(challenge, ' '); i >= 0 {
+		scheme, challenge = challenge[:i], challenge[i+1:]
+	} else {
+		return scheme, params
+	}
+
+	for len(challenge) > 0 {
+		challenge = strings.TrimLeft(challenge, " ,")
+		i := strings.IndexByte(challenge, '=')
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(challenge[:i]))
+		challenge = challenge[i+1:]
+
+		var value string
+		if strings.HasPrefix(challenge, `"`) {
+			// quoted values could contain commas, such as the scope "repository:foo:pull,push"
+			end := strings.IndexByte(challenge[1:], '"')
+			if end < 0 {
+				value, challenge = challenge[1:], ""
+			} else {
+				value, challenge = challenge[1:end+1], challenge[end+2:]
+			}
+		} else {
+			end := strings.IndexByte(challenge, ',')
+			if end < 0 {
+				end = len(challenge)
+			}
+			value, challenge = strings.TrimSpace(challenge[:end]), challenge[end:]
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+// nextLink returns the url of the next page in a Link header like `</v2/foo/tags/list?last=v1&n=100>; rel="next"`
+func nextLink(link string) string {
+	if !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	start := strings.IndexByte(link, '<')
+	end := strings.IndexByte(link, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+	next, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return ""
+	}
+	return next.RequestURI()
+}
+
+// Digest returns the sha256 digest of data, such as "sha256:<hex>"
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		challenge  string
+		wantScheme string
+		wantParams map[string]string
+	}{
+		{
+			challenge:  `Bearer realm="https://auth.io/token",service="registry.io",scope="repository:foo:pull,push"`,
+			wantScheme: "Bearer",
+			wantParams: map[string]string{
+				"realm":   "https://auth.io/token",
+				"service": "registry.io",
+				"scope":   "repository:foo:pull,push",
+			},
+		},
+		{
+			challenge:  `Basic realm="registry", charset=UTF-8`,
+			wantScheme: "Basic",
+			wantParams: map[string]string{
+				"realm":   "registry",
+				"charset": "UTF-8",
+			},
+		},
+		{
+			challenge:  "Basic",
+			wantScheme: "Basic",
+			wantParams: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.challenge, func(t *testing.T) {
+			scheme, params := parseChallenge(tt.challenge)
+			if scheme != tt.wantScheme {
+				t.Errorf("parseChallenge() scheme = %s, want %s", scheme, tt.wantScheme)
+			}
+			if !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("parseChallenge() params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{
+			link: `</v2/foo/tags/list?last=v1&n=100>; rel="next"`,
+			want: "/v2/foo/tags/list?last=v1&n=100",
+		},
+		{
+			link: `<https://registry.io/v2/foo/tags/list?last=v1>; rel="next"`,
+			want: "/v2/foo/tags/list?last=v1",
+		},
+		{
+			link: "",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := nextLink(tt.link); got != tt.want {
+			t.Errorf("nextLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	// keys of the trusted materials in the Secret of a SignatureVerification
+	cosignPublicKey   = "cosign.pub"
+	fulcioCertificate = "fulcio.crt"
+	rekorPublicKey    = "rekor.pub"
+
+	// cosign stores the signatures of "sha256:<hex>" at the tag "sha256-<hex>.sig"
+	signatureTagSuffix     = ".sig"
+	simpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	simpleSigningType      = "cosign container image signature"
+
+	signatureAnnotation   = "dev.cosignproject.cosign/signature"
+	certificateAnnotation = "dev.sigstore.cosign/certificate"
+	chainAnnotation       = "dev.sigstore.cosign/chain"
+	bundleAnnotation      = "dev.sigstore.cosign/bundle"
+)
+
+var (
+	// oidIssuer is the extension of the OIDC issuer in Fulcio certificates, holding the raw string
+	oidIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// oidIssuerV2 is the extension of the OIDC issuer in Fulcio certificates, holding a DER-encoded string
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// Verifier verifies the cosign signatures of artifacts, either against a public key,
+// or keyless signatures against the certificates of Fulcio and the transparency log of Rekor.
+type Verifier struct {
+	publicKey crypto.PublicKey
+
+	roots      *x509.CertPool
+	rekorKey   crypto.PublicKey
+	identities []identityMatcher
+}
+
+type identityMatcher struct {
+	issuer  string
+	subject *regexp.Regexp
+}
+
+// NewVerifier returns a Verifier with the trusted materials in secret.
+func NewVerifier(secret *corev1.Secret, identities []appsapi.SignatureIdentity) (*Verifier, error) {
+	if data, ok := secret.Data[cosignPublicKey]; ok {
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in Secret %s: %v", cosignPublicKey, secret.Name, err)
+		}
+		return &Verifier{publicKey: key}, nil
+	}
+
+	fulcio, hasFulcio := secret.Data[fulcioCertificate]
+	rekor, hasRekor := secret.Data[rekorPublicKey]
+	if !hasFulcio || !hasRekor {
+		return nil, fmt.Errorf("either %s, or %s and %s should be provided in Secret %s",
+			cosignPublicKey, fulcioCertificate, rekorPublicKey, secret.Name)
+	}
+	if len(identities) == 0 {
+		return nil, errors.New("identities are required to verify keyless signatures")
+	}
+
+	verifier := &Verifier{roots: x509.NewCertPool()}
+	if !verifier.roots.AppendCertsFromPEM(fulcio) {
+		return nil, fmt.Errorf("no certificates are found in %s of Secret %s", fulcioCertificate, secret.Name)
+	}
+	key, err := parsePublicKey(rekor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in Secret %s: %v", rekorPublicKey, secret.Name, err)
+	}
+	verifier.rekorKey = key
+	for _, identity := range identities {
+		subject, err := regexp.Compile("^(?:" + identity.Subject + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject %q: %v", identity.Subject, err)
+		}
+		verifier.identities = append(verifier.identities, identityMatcher{issuer: identity.Issuer, subject: subject})
+	}
+	return verifier, nil
+}
+
+// Verify checks that the artifact of digest in the repository has at least one valid cosign signature.
+func (v *Verifier) Verify(ctx context.Context, client *Client, digest string) error {
+	manifest, _, err := client.FetchManifest(ctx, strings.Replace(digest, ":", "-", 1)+signatureTagSuffix)
+	if err != nil {
+		if IsNotFound(err) {
+			return fmt.Errorf("no cosign signatures are found for %s", digest)
+		}
+		return fmt.Errorf("failed to get cosign signatures of %s: %v", digest, err)
+	}
+
+	var errs []string
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != simpleSigningMediaType {
+			continue
+		}
+		err = v.verifyLayer(ctx, client, layer, digest)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("no cosign signatures are found for %s", digest)
+	}
+	return fmt.Errorf("no valid cosign signatures are found for %s: %s", digest, strings.Join(errs, "; "))
+}
+
+func (v *Verifier) verifyLayer(ctx context.Context, client *Client, layer Descriptor, digest string) error {
+	signature, err := base64.StdEncoding.DecodeString(layer.Annotations[signatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("invalid signature in layer %s", layer.Digest)
+	}
+	payload, err := client.FetchBlob(ctx, layer)
+	if err != nil {
+		return err
+	}
+	if err = checkPayload(payload, digest); err != nil {
+		return err
+	}
+
+	if v.publicKey != nil {
+		return verifySignature(v.publicKey, payload, signature)
+	}
+	return v.verifyKeyless(layer.Annotations, payload, signature)
+}
+
+// checkPayload checks that the signed payload in simple signing format refers digest
+func checkPayload(payload []byte, digest string) error {
+	var simpleSigning struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+			Type string `json:"type"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+		return fmt.Errorf("invalid signed payload: %v", err)
+	}
+	if simpleSigning.Critical.Type != simpleSigningType {
+		return fmt.Errorf("unsupported signed payload type %q", simpleSigning.Critical.Type)
+	}
+	if simpleSigning.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("signed digest %s does not match %s", simpleSigning.Critical.Image.DockerManifestDigest, digest)
+	}
+	return nil
+}
+
+// rekorPayload is the log entry of Rekor signed in the SignedEntryTimestamp,
+// whose fields are sorted to be marshaled into canonical JSON.
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// verifyKeyless verifies a signature with the certificate issued by Fulcio, which should be valid
+// at the time the signature is logged in Rekor.
+func (v *Verifier) verifyKeyless(annotations map[string]string, payload, signature []byte) error {
+	block, _ := pem.Decode([]byte(annotations[certificateAnnotation]))
+	if block == nil {
+		return errors.New("no certificate is found for keyless signature")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+
+	var bundle struct {
+		SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+		Payload              rekorPayload `json:"Payload"`
+	}
+	if err = json.Unmarshal([]byte(annotations[bundleAnnotation]), &bundle); err != nil {
+		return fmt.Errorf("no valid Rekor bundle is found for keyless signature: %v", err)
+	}
+	canonical, err := json.Marshal(bundle.Payload)
+	if err != nil {
+		return err
+	}
+	if err = verifySignature(v.rekorKey, canonical, bundle.SignedEntryTimestamp); err != nil {
+		return fmt.Errorf("invalid Rekor bundle: %v", err)
+	}
+	if err = checkRekorEntry(bundle.Payload.Body, payload, signature, cert); err != nil {
+		return err
+	}
+
+	intermediates := x509.NewCertPool()
+	intermediates.AppendCertsFromPEM([]byte(annotations[chainAnnotation]))
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		CurrentTime:   time.Unix(bundle.Payload.IntegratedTime, 0),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("invalid certificate: %v", err)
+	}
+
+	issuer, subjects := getIdentity(cert)
+	if !v.matchIdentity(issuer, subjects) {
+		return fmt.Errorf("signer %v issued by %q is not an accepted identity", subjects, issuer)
+	}
+	return verifySignature(cert.PublicKey, payload, signature)
+}
+
+// checkRekorEntry checks that the hashedrekord entry in Rekor records the signature of payload
+func checkRekorEntry(body string, payload, signature []byte, cert *x509.Certificate) error {
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	var entry struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			Data struct {
+				Hash struct {
+					Algorithm string `json:"algorithm"`
+					Value     string `json:"value"`
+				} `json:"hash"`
+			} `json:"data"`
+			Signature struct {
+				Content   []byte `json:"content"`
+				PublicKey struct {
+					Content []byte `json:"content"`
+				} `json:"publicKey"`
+			} `json:"signature"`
+		} `json:"spec"`
+	}
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("invalid Rekor entry: %v", err)
+	}
+	if entry.Kind != "hashedrekord" {
+		return fmt.Errorf("unsupported Rekor entry kind %q", entry.Kind)
+	}
+
+	sum := sha256.Sum256(payload)
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(sum[:]) {
+		return errors.New("the Rekor entry does not match the signed payload")
+	}
+	if !bytes.Equal(entry.Spec.Signature.Content, signature) {
+		return errors.New("the Rekor entry does not match the signature")
+	}
+	block, _ := pem.Decode(entry.Spec.Signature.PublicKey.Content)
+	if block == nil || !bytes.Equal(block.Bytes, cert.Raw) {
+		return errors.New("the Rekor entry does not match the certificate")
+	}
+	return nil
+}
+
+// getIdentity returns the OIDC issuer, as well as the emails and URIs of the certificate
+func getIdentity(cert *x509.Certificate) (string, []string) {
+	var issuer string
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var value string
+			if _, err := asn1.Unmarshal(ext.Value, &value); err == nil {
+				issuer = value
+			}
+		case ext.Id.Equal(oidIssuer) && len(issuer) == 0:
+			issuer = string(ext.Value)
+		}
+	}
+
+	subjects := append([]string{}, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	return issuer, subjects
+}
+
+func (v *Verifier) matchIdentity(issuer string, subjects []string) bool {
+	for _, identity := range v.identities {
+		if identity.issuer != issuer {
+			continue
+		}
+		for _, subject := range subjects {
+			if identity.subject.MatchString(subject) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM-encoded public key is found")
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// verifySignature verifies the signature of data, which is the same as how cosign signs with the key
+func verifySignature(publicKey crypto.PublicKey, data, signature []byte) error {
+	sum := sha256.Sum256(data)
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		if rest, err := asn1.Unmarshal(signature, &sig); err != nil || len(rest) > 0 {
+			return errors.New("invalid ECDSA signature")
+		}
+		if !ecdsa.Verify(key, sum[:], sig.R, sig.S) {
+			return errors.New("invalid ECDSA signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, signature) {
+			return errors.New("invalid Ed25519 signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
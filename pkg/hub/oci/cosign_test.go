@@ -0,0 +1,319 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, data []byte) []byte {
+	sum := sha256.Sum256(data)
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func newKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func newPayload(digest string) []byte {
+	return []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.io/org/app"},`+
+		`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+}
+
+// newSignatureRegistry serves the signature layers of digest in the repository "org/app"
+func newSignatureRegistry(t *testing.T, digest string, payload []byte, annotations map[string]string) *httptest.Server {
+	manifest, err := json.Marshal(Manifest{
+		MediaType: ImageManifestMediaType,
+		Layers: []Descriptor{
+			{
+				MediaType:   simpleSigningMediaType,
+				Digest:      Digest(payload),
+				Size:        int64(len(payload)),
+				Annotations: annotations,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf("/v2/org/app/manifests/%s.sig", strings.Replace(digest, ":", "-", 1)):
+			w.Header().Set("Content-Type", ImageManifestMediaType)
+			w.Write(manifest)
+		case fmt.Sprintf("/v2/org/app/blobs/%s", Digest(payload)):
+			w.Write(payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func verify(t *testing.T, server *httptest.Server, secret *corev1.Secret, identities []appsapi.SignatureIdentity, digest string) error {
+	verifier, err := NewVerifier(secret, identities)
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(strings.Replace(server.URL, "http://", Scheme, 1)+"/org/app", true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier.Verify(context.TODO(), client, digest)
+}
+
+func TestVerifyWithKey(t *testing.T) {
+	digest := Digest([]byte("artifact"))
+	key, publicKey := newKey(t)
+	_, otherPublicKey := newKey(t)
+	payload := newPayload(digest)
+
+	server := newSignatureRegistry(t, digest, payload, map[string]string{
+		signatureAnnotation: base64.StdEncoding.EncodeToString(sign(t, key, payload)),
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		key     []byte
+		digest  string
+		wantErr bool
+	}{
+		{
+			name:   "valid signature",
+			key:    publicKey,
+			digest: digest,
+		},
+		{
+			name:    "signed by another key",
+			key:     otherPublicKey,
+			digest:  digest,
+			wantErr: true,
+		},
+		{
+			name:    "no signatures",
+			key:     publicKey,
+			digest:  Digest([]byte("unsigned")),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "cosign"},
+				Data:       map[string][]byte{cosignPublicKey: tt.key},
+			}
+			err := verify(t, server, secret, nil, tt.digest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyKeyless(t *testing.T) {
+	digest := Digest([]byte("artifact"))
+	payload := newPayload(digest)
+	issuedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// Fulcio issues a short-lived certificate to the signer
+	rootKey, _ := newKey(t)
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "fulcio"},
+		NotBefore:             issuedAt.Add(-time.Hour),
+		NotAfter:              issuedAt.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signerKey, _ := newKey(t)
+	workflow, err := url.Parse("https://github.com/org/app/.github/workflows/release.yaml@refs/tags/v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber:    big.NewInt(2),
+		NotBefore:       issuedAt,
+		NotAfter:        issuedAt.Add(10 * time.Minute),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		URIs:            []*url.URL{workflow},
+		ExtraExtensions: []pkix.Extension{{Id: oidIssuer, Value: []byte("https://token.actions.githubusercontent.com")}},
+	}, root, &signerKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})
+
+	// Rekor logs the signature and signs the entry
+	signature := sign(t, signerKey, payload)
+	sum := sha256.Sum256(payload)
+	body, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{
+				"hash": map[string]string{"algorithm": "sha256", "value": hex.EncodeToString(sum[:])},
+			},
+			"signature": map[string]interface{}{
+				"content":   signature,
+				"publicKey": map[string]interface{}{"content": leaf},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rekorKey, rekorPEM := newKey(t)
+	newBundle := func(integratedTime time.Time) string {
+		entry := rekorPayload{
+			Body:           base64.StdEncoding.EncodeToString(body),
+			IntegratedTime: integratedTime.Unix(),
+			LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+			LogIndex:       1,
+		}
+		canonical, err := json.Marshal(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle, err := json.Marshal(map[string]interface{}{
+			"SignedEntryTimestamp": sign(t, rekorKey, canonical),
+			"Payload":              entry,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bundle)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sigstore"},
+		Data: map[string][]byte{
+			fulcioCertificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rootDER}),
+			rekorPublicKey:    rekorPEM,
+		},
+	}
+	identity := appsapi.SignatureIdentity{
+		Issuer:  "https://token.actions.githubusercontent.com",
+		Subject: "https://github.com/org/app/.github/workflows/release.yaml@refs/tags/.*",
+	}
+
+	tests := []struct {
+		name       string
+		bundle     string
+		identities []appsapi.SignatureIdentity
+		wantErr    bool
+	}{
+		{
+			name:       "valid signature",
+			bundle:     newBundle(issuedAt.Add(time.Minute)),
+			identities: []appsapi.SignatureIdentity{identity},
+		},
+		{
+			name:   "another identity",
+			bundle: newBundle(issuedAt.Add(time.Minute)),
+			identities: []appsapi.SignatureIdentity{
+				{Issuer: identity.Issuer, Subject: "https://github.com/org/other/.*"},
+				{Issuer: "https://accounts.google.com", Subject: identity.Subject},
+			},
+			wantErr: true,
+		},
+		{
+			name:       "logged after the certificate expires",
+			bundle:     newBundle(issuedAt.Add(time.Hour)),
+			identities: []appsapi.SignatureIdentity{identity},
+			wantErr:    true,
+		},
+		{
+			name:       "tampered bundle",
+			bundle:     strings.Replace(newBundle(issuedAt.Add(time.Minute)), `"logIndex":1`, `"logIndex":2`, 1),
+			identities: []appsapi.SignatureIdentity{identity},
+			wantErr:    true,
+		},
+		{
+			name:    "no identities",
+			bundle:  newBundle(issuedAt.Add(time.Minute)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newSignatureRegistry(t, digest, payload, map[string]string{
+				signatureAnnotation:   base64.StdEncoding.EncodeToString(signature),
+				certificateAnnotation: string(leaf),
+				bundleAnnotation:      tt.bundle,
+			})
+			defer server.Close()
+
+			err := verify(t, server, secret, tt.identities, digest)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
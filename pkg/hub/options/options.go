@@ -20,10 +20,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/admission"
@@ -38,12 +40,18 @@ import (
 	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/version"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
 	"k8s.io/klog/v2"
 
+	"github.com/clusternet/clusternet/pkg/exchanger"
+	clusternetfeatures "github.com/clusternet/clusternet/pkg/features"
 	clientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
 	informers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
 	clusternetopenapi "github.com/clusternet/clusternet/pkg/generated/openapi"
 	"github.com/clusternet/clusternet/pkg/hub/apiserver"
+	shadowapiserver "github.com/clusternet/clusternet/pkg/hub/apiserver/shadow"
+	"github.com/clusternet/clusternet/pkg/utils"
 )
 
 const (
@@ -55,6 +63,140 @@ type HubServerOptions struct {
 	// No tunnel logging by default
 	TunnelLogging bool
 
+	// RequireProxyGrants denies all the requests to child clusters through the proxies API,
+	// unless the requesters are explicitly granted with Grant objects.
+	RequireProxyGrants bool
+
+	// MaxProxiedRequestsPerCluster is the max number of concurrent in-flight requests proxied to a single child cluster.
+	// 0 means no limit.
+	MaxProxiedRequestsPerCluster int
+	// MaxProxiedRequestsPerUser is the max number of concurrent in-flight requests proxied from a single user.
+	// 0 means no limit.
+	MaxProxiedRequestsPerUser int
+
+	// ProxyImpersonation forwards the identities of requesters to child clusters by impersonating them
+	// with the credentials of clusternet-agent, unless the requests carry credentials of child clusters.
+	ProxyImpersonation bool
+	// ProxyImpersonationGroups is the allow-list of groups forwarded to child clusters with impersonation.
+	ProxyImpersonationGroups []string
+
+	// ProxySessionIdleTimeout closes the streaming sessions with pods in child clusters, such as exec, attach,
+	// port-forward and logs, without any data transferred for this long. 0 means no limit.
+	ProxySessionIdleTimeout time.Duration
+	// ProxySessionMaxDuration closes the streaming sessions with pods in child clusters lasting longer than this.
+	// 0 means no limit.
+	ProxySessionMaxDuration time.Duration
+
+	// TunnelGRPCBindAddress is the address serving gRPC tunnels set up by clusternet-agents, as an alternative to
+	// websocket connections. Empty means disabled.
+	TunnelGRPCBindAddress string
+
+	// ShadowAdmissionCluster is the id of a child cluster, where the admission webhooks will be invoked
+	// with dry-run before persisting objects created/updated through the shadow APIs.
+	ShadowAdmissionCluster string
+
+	// ShadowAuditWebhook is the url where the writes to shadow resources are posted to in JSON.
+	ShadowAuditWebhook string
+
+	// ShadowExcludeResources is a list of resources in the format of "<group>/<resource>" that will not be shadowed.
+	ShadowExcludeResources []string
+
+	// PlacementWebhook is the url where placement changes of Subscriptions are posted to in JSON.
+	PlacementWebhook string
+
+	// LifecycleEventWebhook is the url where lifecycle events of Subscriptions are posted to in JSON.
+	LifecycleEventWebhook string
+
+	// ResourceInterpreterWebhooks is the YAML file declaring the webhooks that interpret custom kinds.
+	ResourceInterpreterWebhooks string
+
+	// NotificationConfig is the YAML file declaring the sinks where notifications of condition changes are
+	// delivered to, and the routes selecting them.
+	NotificationConfig string
+
+	// RolloutPrometheusAddress is the address of Prometheus, where the metrics are queried from
+	// during the rollouts of Subscriptions.
+	RolloutPrometheusAddress string
+
+	// ClusterMonitorPeriod is how often the heartbeats of ManagedClusters are checked.
+	ClusterMonitorPeriod time.Duration
+	// ClusterHeartbeatGracePeriod is how long a cluster can go without heartbeats before being marked as Unknown.
+	ClusterHeartbeatGracePeriod time.Duration
+	// ClusterEvictionTimeout is how long a cluster can stay Unknown before its workloads get evicted.
+	// 0 disables eviction.
+	ClusterEvictionTimeout time.Duration
+	// ClusterFailoverTolerance is how long a cluster can stay not ready before its divided replicas fail over
+	// to other healthy clusters. 0 disables failover.
+	ClusterFailoverTolerance time.Duration
+
+	// MaxManifestsPerDescription is the max number of manifests carried by a single Description.
+	// Larger bundles are split into multiple Descriptions. 0 means no limit.
+	MaxManifestsPerDescription int
+	// MaxDescriptionBytes is the max total bytes of manifests carried by a single Description.
+	// Larger bundles are split into multiple Descriptions. 0 means no limit.
+	MaxDescriptionBytes int
+
+	// DynamicSchedulingInterval is how often the replicas of Subscriptions with Dynamic dividing scheduling
+	// are re-divided by the available resources of clusters.
+	DynamicSchedulingInterval time.Duration
+
+	// DescriptionRollbackAttempts is the number of failed attempts to deploy a Description, after which
+	// the Description is rolled back to its last successfully deployed spec. 0 disables rolling back.
+	DescriptionRollbackAttempts int
+
+	// HelmDriftCheckInterval is how often HelmReleases are compared with the objects in child clusters for drifts.
+	// 0 disables checking drifts.
+	HelmDriftCheckInterval time.Duration
+
+	// ClusterSigningCertFile is the PEM-encoded CA certificate used to sign client certificates of child clusters.
+	// The CA should be trusted by the parent cluster for client authentication.
+	ClusterSigningCertFile string
+	// ClusterSigningKeyFile is the PEM-encoded private key of the CA used to sign client certificates of child clusters.
+	ClusterSigningKeyFile string
+	// ClusterSigningDuration is how long the signed client certificates of child clusters are valid for.
+	ClusterSigningDuration time.Duration
+
+	// RegistrationRenewalMinInterval is the min interval between two approved renewals of the dedicated token
+	// requested by the same child cluster.
+	RegistrationRenewalMinInterval time.Duration
+
+	// ClusterRegistrationPolicy is the YAML file declaring the rules approving ClusterRegistrationRequests
+	// automatically. All the requests are approved if not specified.
+	ClusterRegistrationPolicy string
+	// ClusterRegistrationWebhook is the url where ClusterRegistrationRequests approved by the policy are posted to
+	// in JSON for review, which answers with allow, deny or hold.
+	ClusterRegistrationWebhook string
+	// ClusterRegistrationWebhookCAFile is the CA verifying the serving certificate of ClusterRegistrationWebhook.
+	ClusterRegistrationWebhookCAFile string
+
+	// Threadiness is the number of workers of each controller.
+	Threadiness int
+
+	// DeployerClusterQPS and DeployerClusterBurst limit how often the Descriptions of a single child cluster
+	// are deployed. 0 QPS means no limit.
+	DeployerClusterQPS   float32
+	DeployerClusterBurst int
+	// DeployerSubscriptionQPS and DeployerSubscriptionBurst limit how often the Descriptions of a single
+	// Subscription are deployed. 0 QPS means no limit.
+	DeployerSubscriptionQPS   float32
+	DeployerSubscriptionBurst int
+	// DeployerRetryBaseDelay and DeployerRetryMaxDelay are the exponential backoff of retrying failed Descriptions.
+	DeployerRetryBaseDelay time.Duration
+	DeployerRetryMaxDelay  time.Duration
+
+	// ShardLeaseNamespace is the namespace of the Leases renewed by the replicas of clusternet-hub
+	// when feature gate DeployerSharding is enabled.
+	ShardLeaseNamespace string
+	// ShardLeaseDuration is how long a replica is taken as alive after renewing its Lease.
+	ShardLeaseDuration time.Duration
+
+	// Simulation runs the controllers in observe-only mode, where all the writes are sent as dry-run requests.
+	Simulation bool
+
+	// LeaderElection defines the configuration of leader election, with which only the leader runs the controllers,
+	// while all the replicas serve the apis.
+	LeaderElection componentbaseconfig.LeaderElectionConfiguration
+
 	RecommendedOptions *genericoptions.RecommendedOptions
 
 	LoopbackSharedInformerFactory informers.SharedInformerFactory
@@ -63,7 +205,31 @@ type HubServerOptions struct {
 // NewHubServerOptions returns a new HubServerOptions
 func NewHubServerOptions() *HubServerOptions {
 	o := &HubServerOptions{
-		RecommendedOptions: genericoptions.NewRecommendedOptions("fake", nil),
+		ClusterMonitorPeriod:           30 * time.Second,
+		ClusterHeartbeatGracePeriod:    9 * time.Minute,
+		MaxManifestsPerDescription:     500,
+		MaxDescriptionBytes:            1024 * 1024, // etcd rejects requests larger than 1.5MiB by default
+		DynamicSchedulingInterval:      5 * time.Minute,
+		HelmDriftCheckInterval:         10 * time.Minute,
+		ClusterSigningDuration:         24 * time.Hour,
+		RegistrationRenewalMinInterval: time.Hour,
+		Threadiness:                    2,
+		ShardLeaseNamespace:            "clusternet-system",
+		ShardLeaseDuration:             15 * time.Second,
+		DeployerClusterBurst:           10,
+		DeployerSubscriptionBurst:      50,
+		DeployerRetryBaseDelay:         5 * time.Millisecond,
+		DeployerRetryMaxDelay:          1000 * time.Second,
+		RecommendedOptions:             genericoptions.NewRecommendedOptions("fake", nil),
+		LeaderElection: componentbaseconfig.LeaderElectionConfiguration{
+			LeaderElect:       false,
+			LeaseDuration:     metav1.Duration{Duration: 15 * time.Second},
+			RenewDeadline:     metav1.Duration{Duration: 10 * time.Second},
+			RetryPeriod:       metav1.Duration{Duration: 2 * time.Second},
+			ResourceLock:      resourcelock.LeasesResourceLock,
+			ResourceName:      "clusternet-hub",
+			ResourceNamespace: "clusternet-system",
+		},
 	}
 	return o
 }
@@ -72,6 +238,129 @@ func NewHubServerOptions() *HubServerOptions {
 func (o *HubServerOptions) Validate(args []string) error {
 	errors := []error{}
 	errors = append(errors, o.validateRecommendedOptions()...)
+	errors = append(errors, shadowapiserver.ValidateResourcePatterns(o.ShadowExcludeResources)...)
+	if o.MaxProxiedRequestsPerCluster < 0 {
+		errors = append(errors, fmt.Errorf("--max-proxied-requests-per-cluster must not be negative"))
+	}
+	if o.MaxProxiedRequestsPerUser < 0 {
+		errors = append(errors, fmt.Errorf("--max-proxied-requests-per-user must not be negative"))
+	}
+	if len(o.ProxyImpersonationGroups) > 0 && !o.ProxyImpersonation {
+		errors = append(errors, fmt.Errorf("--proxy-impersonation-groups takes no effect without --proxy-impersonation"))
+	}
+	if o.ProxySessionIdleTimeout < 0 {
+		errors = append(errors, fmt.Errorf("--proxy-session-idle-timeout must not be negative"))
+	}
+	if o.ProxySessionMaxDuration < 0 {
+		errors = append(errors, fmt.Errorf("--proxy-session-max-duration must not be negative"))
+	}
+	if len(o.TunnelGRPCBindAddress) > 0 {
+		if _, _, err := net.SplitHostPort(o.TunnelGRPCBindAddress); err != nil {
+			errors = append(errors, fmt.Errorf("invalid value for --tunnel-grpc-bind-address: %v", err))
+		}
+		// clusternet-agents are authenticated with the client certificates issued by clusternet-hub
+		if !utilfeature.DefaultFeatureGate.Enabled(clusternetfeatures.SocketConnection) ||
+			!utilfeature.DefaultFeatureGate.Enabled(clusternetfeatures.CertificateSigning) {
+			errors = append(errors, fmt.Errorf("--tunnel-grpc-bind-address requires feature gates %s and %s",
+				clusternetfeatures.SocketConnection, clusternetfeatures.CertificateSigning))
+		}
+	}
+	if o.ClusterMonitorPeriod <= 0 {
+		errors = append(errors, fmt.Errorf("--cluster-monitor-period must be positive"))
+	}
+	if o.ClusterHeartbeatGracePeriod <= 0 {
+		errors = append(errors, fmt.Errorf("--cluster-heartbeat-grace-period must be positive"))
+	}
+	if o.ClusterEvictionTimeout < 0 {
+		errors = append(errors, fmt.Errorf("--cluster-eviction-timeout must not be negative"))
+	}
+	if o.ClusterFailoverTolerance < 0 {
+		errors = append(errors, fmt.Errorf("--cluster-failover-tolerance must not be negative"))
+	}
+	if o.MaxManifestsPerDescription < 0 {
+		errors = append(errors, fmt.Errorf("--max-manifests-per-description must not be negative"))
+	}
+	if o.MaxDescriptionBytes < 0 {
+		errors = append(errors, fmt.Errorf("--max-description-bytes must not be negative"))
+	}
+	if o.DynamicSchedulingInterval <= 0 {
+		errors = append(errors, fmt.Errorf("--dynamic-scheduling-interval must be positive"))
+	}
+	if o.DescriptionRollbackAttempts < 0 {
+		errors = append(errors, fmt.Errorf("--description-rollback-attempts must not be negative"))
+	}
+	if o.HelmDriftCheckInterval < 0 {
+		errors = append(errors, fmt.Errorf("--helm-drift-check-interval must not be negative"))
+	}
+	if o.Threadiness <= 0 {
+		errors = append(errors, fmt.Errorf("--threadiness must be positive"))
+	}
+	if o.DeployerClusterQPS < 0 || o.DeployerSubscriptionQPS < 0 {
+		errors = append(errors, fmt.Errorf("--deployer-cluster-qps and --deployer-subscription-qps must not be negative"))
+	}
+	if o.DeployerClusterBurst <= 0 || o.DeployerSubscriptionBurst <= 0 {
+		errors = append(errors, fmt.Errorf("--deployer-cluster-burst and --deployer-subscription-burst must be positive"))
+	}
+	if o.DeployerRetryBaseDelay <= 0 || o.DeployerRetryMaxDelay < o.DeployerRetryBaseDelay {
+		errors = append(errors, fmt.Errorf("--deployer-retry-base-delay must be positive and "+
+			"not larger than --deployer-retry-max-delay"))
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(clusternetfeatures.DeployerSharding) {
+		if len(o.ShardLeaseNamespace) == 0 {
+			errors = append(errors, fmt.Errorf("--shard-lease-namespace is required "+
+				"when feature gate %s is enabled", clusternetfeatures.DeployerSharding))
+		}
+		if o.ShardLeaseDuration < 3*time.Second {
+			errors = append(errors, fmt.Errorf("--shard-lease-duration must be at least 3s"))
+		}
+	}
+	if o.LeaderElection.LeaderElect {
+		errors = append(errors, utils.ValidateLeaderElectionConfiguration(o.LeaderElection)...)
+		// with sharding, every replica runs the controllers for its own shard of child clusters
+		if utilfeature.DefaultFeatureGate.Enabled(clusternetfeatures.DeployerSharding) {
+			errors = append(errors, fmt.Errorf("--leader-elect could not be used together with feature gate %s",
+				clusternetfeatures.DeployerSharding))
+		}
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(clusternetfeatures.CertificateSigning) {
+		if len(o.ClusterSigningCertFile) == 0 || len(o.ClusterSigningKeyFile) == 0 {
+			errors = append(errors, fmt.Errorf("--cluster-signing-cert-file and --cluster-signing-key-file are required "+
+				"when feature gate %s is enabled", clusternetfeatures.CertificateSigning))
+		}
+		if o.ClusterSigningDuration < 10*time.Minute {
+			errors = append(errors, fmt.Errorf("--cluster-signing-duration must be at least 10m"))
+		}
+	}
+	if o.RegistrationRenewalMinInterval < 0 {
+		errors = append(errors, fmt.Errorf("--registration-renewal-min-interval must not be negative"))
+	}
+	if len(o.ClusterRegistrationWebhook) > 0 {
+		if u, err := url.Parse(o.ClusterRegistrationWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errors = append(errors, fmt.Errorf("--cluster-registration-webhook must be a valid http or https url"))
+		}
+	} else if len(o.ClusterRegistrationWebhookCAFile) > 0 {
+		errors = append(errors, fmt.Errorf("--cluster-registration-webhook-ca-file requires --cluster-registration-webhook"))
+	}
+	if len(o.ShadowAuditWebhook) > 0 {
+		if u, err := url.Parse(o.ShadowAuditWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errors = append(errors, fmt.Errorf("--shadow-audit-webhook must be a valid http or https url"))
+		}
+	}
+	if len(o.PlacementWebhook) > 0 {
+		if u, err := url.Parse(o.PlacementWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errors = append(errors, fmt.Errorf("--placement-webhook must be a valid http or https url"))
+		}
+	}
+	if len(o.LifecycleEventWebhook) > 0 {
+		if u, err := url.Parse(o.LifecycleEventWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errors = append(errors, fmt.Errorf("--lifecycle-event-webhook must be a valid http or https url"))
+		}
+	}
+	if len(o.RolloutPrometheusAddress) > 0 {
+		if u, err := url.Parse(o.RolloutPrometheusAddress); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errors = append(errors, fmt.Errorf("--rollout-prometheus-address must be a valid http or https url"))
+		}
+	}
 	return utilerrors.NewAggregate(errors)
 }
 
@@ -106,6 +395,10 @@ func (o *HubServerOptions) Config() (*apiserver.Config, error) {
 	serverConfig := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
 	serverConfig.Config.RequestTimeout = time.Duration(40) * time.Second // override default 60s
 	serverConfig.LongRunningFunc = func(r *http.Request, requestInfo *apirequest.RequestInfo) bool {
+		// watches and streaming requests proxied to child clusters should not be terminated by the request timeout
+		if exchanger.IsLongRunningProxyRequest(r, requestInfo) {
+			return true
+		}
 		if values := r.URL.Query()["watch"]; len(values) > 0 {
 			switch strings.ToLower(values[0]) {
 			case "true":
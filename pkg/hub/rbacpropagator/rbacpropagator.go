@@ -0,0 +1,546 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package rbacpropagator propagates the ClusterRoles and ClusterRoleBindings marked in the parent cluster to all the
+// child clusters, so that users visiting child clusters through the proxy of clusternet-hub get the same permissions
+// in every child cluster without setting them up one by one.
+package rbacpropagator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/controllers/metrics"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	controllerName = "rbac-propagator"
+
+	// resyncPeriod is how often all the child clusters are reconciled, which repairs the drifts in child clusters
+	// and picks up the namespaces created there later
+	resyncPeriod = 5 * time.Minute
+)
+
+// RBACPropagator keeps the RBAC objects in child clusters in line with the ClusterRoles and ClusterRoleBindings
+// labeled with "clusters.clusternet.io/rbac-propagation=true" in the parent cluster.
+//
+// ClusterRoles are copied as they are. ClusterRoleBindings are copied as well, unless they are annotated with
+// "clusters.clusternet.io/rbac-namespaces", where they are translated into RoleBindings in those namespaces of
+// child clusters. Propagated objects are removed from child clusters once the marks are removed in the parent
+// cluster, while the existing objects not created by the propagation are never overwritten.
+type RBACPropagator struct {
+	ctx context.Context
+
+	roleLister    rbaclisters.ClusterRoleLister
+	roleSynced    cache.InformerSynced
+	bindingLister rbaclisters.ClusterRoleBindingLister
+	bindingSynced cache.InformerSynced
+	clusterLister clusterlisters.ManagedClusterLister
+	clusterSynced cache.InformerSynced
+	secretLister  corev1lister.SecretLister
+	secretSynced  cache.InformerSynced
+
+	workqueue workqueue.RateLimitingInterface
+
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+}
+
+// NewRBACPropagator returns a new RBACPropagator.
+// It should be called before the informer factories start.
+func NewRBACPropagator(ctx context.Context, kubeclient *kubernetes.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory, kubeInformerFactory kubeinformers.SharedInformerFactory) (*RBACPropagator, error) {
+	roleInformer := kubeInformerFactory.Rbac().V1().ClusterRoles()
+	bindingInformer := kubeInformerFactory.Rbac().V1().ClusterRoleBindings()
+	clusterInformer := clusternetInformerFactory.Clusters().V1beta1().ManagedClusters()
+	secretInformer := kubeInformerFactory.Core().V1().Secrets()
+
+	p := &RBACPropagator{
+		ctx:           ctx,
+		roleLister:    roleInformer.Lister(),
+		roleSynced:    roleInformer.Informer().HasSynced,
+		bindingLister: bindingInformer.Lister(),
+		bindingSynced: bindingInformer.Informer().HasSynced,
+		clusterLister: clusterInformer.Lister(),
+		clusterSynced: clusterInformer.Informer().HasSynced,
+		secretLister:  secretInformer.Lister(),
+		secretSynced:  secretInformer.Informer().HasSynced,
+		workqueue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		broadcaster:   record.NewBroadcaster(),
+	}
+
+	p.broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
+		Interface: kubeclient.CoreV1().Events(""),
+	})
+	utilruntime.Must(clusterapi.AddToScheme(scheme.Scheme))
+	p.recorder = p.broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "clusternet-hub"})
+
+	// any change of a marked object, including removing the mark, re-syncs all the child clusters
+	rbacHandler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if isSource(obj) {
+				p.enqueueAllClusters()
+			}
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			if isSource(old) || isSource(cur) {
+				p.enqueueAllClusters()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if isSource(obj) {
+				p.enqueueAllClusters()
+			}
+		},
+	}
+	roleInformer.Informer().AddEventHandler(rbacHandler)
+	bindingInformer.Informer().AddEventHandler(rbacHandler)
+	clusterInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: p.enqueueCluster,
+	})
+
+	return p, nil
+}
+
+// Run starts the workers to propagate RBAC objects. It will block until the context is done.
+func (p *RBACPropagator) Run(workers int) {
+	defer utilruntime.HandleCrash()
+	defer p.workqueue.ShutDown()
+
+	klog.Info("starting Clusternet RBAC propagator ...")
+	defer klog.Info("shutting down Clusternet RBAC propagator")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(p.ctx.Done(), p.roleSynced, p.bindingSynced, p.clusterSynced, p.secretSynced) {
+		return
+	}
+
+	var wg wait.Group
+	for i := 0; i < workers; i++ {
+		wg.Start(func() {
+			wait.Until(p.runWorker, time.Second, p.ctx.Done())
+		})
+	}
+	wg.Start(func() {
+		wait.Until(p.enqueueAllClusters, resyncPeriod, p.ctx.Done())
+	})
+
+	<-p.ctx.Done()
+	p.workqueue.ShutDown()
+	wg.Wait()
+}
+
+func (p *RBACPropagator) runWorker() {
+	for p.processNextWorkItem() {
+	}
+}
+
+func (p *RBACPropagator) processNextWorkItem() bool {
+	obj, shutdown := p.workqueue.Get()
+	if shutdown {
+		return false
+	}
+	defer p.workqueue.Done(obj)
+
+	key, ok := obj.(string)
+	if !ok {
+		p.workqueue.Forget(obj)
+		utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
+		return true
+	}
+	start := time.Now()
+	err := p.syncCluster(key)
+	metrics.ObserveReconcile(controllerName, start, err)
+	if err != nil {
+		p.workqueue.AddRateLimited(key)
+		utilruntime.HandleError(fmt.Errorf("error propagating RBAC to ManagedCluster %q: %v, requeuing", key, err))
+		return true
+	}
+	p.workqueue.Forget(obj)
+	return true
+}
+
+func (p *RBACPropagator) enqueueCluster(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	p.workqueue.Add(key)
+}
+
+func (p *RBACPropagator) enqueueAllClusters() {
+	clusters, err := p.clusterLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list ManagedClusters: %v", err)
+		return
+	}
+	for _, cluster := range clusters {
+		p.enqueueCluster(cluster)
+	}
+}
+
+// syncCluster applies the desired RBAC objects to a child cluster, and removes the stale ones propagated before.
+func (p *RBACPropagator) syncCluster(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	cluster, err := p.clusterLister.ManagedClusters(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	roles, err := p.roleLister.List(labels.SelectorFromSet(labels.Set{known.RBACPropagationLabel: "true"}))
+	if err != nil {
+		return err
+	}
+	bindings, err := p.bindingLister.List(labels.SelectorFromSet(labels.Set{known.RBACPropagationLabel: "true"}))
+	if err != nil {
+		return err
+	}
+	desired := translate(roles, bindings)
+
+	client, err := p.getChildClient(cluster)
+	if err != nil {
+		return err
+	}
+
+	var allErrs []error
+	for _, role := range desired.clusterRoles {
+		allErrs = append(allErrs, p.applyClusterRole(client, role))
+	}
+	for _, binding := range desired.clusterRoleBindings {
+		allErrs = append(allErrs, p.applyClusterRoleBinding(client, binding))
+	}
+	for _, binding := range desired.roleBindings {
+		allErrs = append(allErrs, p.applyRoleBinding(client, binding))
+	}
+	allErrs = append(allErrs, p.prune(client, desired))
+
+	err = utilerrors.NewAggregate(allErrs)
+	if err != nil {
+		p.recorder.Event(cluster, corev1.EventTypeWarning, "RBACPropagationFailed", err.Error())
+		return err
+	}
+	klog.V(5).Infof("propagated %d ClusterRoles, %d ClusterRoleBindings and %d RoleBindings to ManagedCluster %s",
+		len(desired.clusterRoles), len(desired.clusterRoleBindings), len(desired.roleBindings), klog.KObj(cluster))
+	return nil
+}
+
+func (p *RBACPropagator) applyClusterRole(client kubernetes.Interface, role *rbacv1.ClusterRole) error {
+	current, err := client.RbacV1().ClusterRoles().Get(p.ctx, role.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.RbacV1().ClusterRoles().Create(p.ctx, role, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !isPropagated(current) {
+		return fmt.Errorf("ClusterRole %s exists in the child cluster and is not propagated by clusternet-hub", role.Name)
+	}
+	if role.AggregationRule != nil {
+		// rules of aggregated ClusterRoles are filled by the controller in the child cluster
+		role.Rules = current.Rules
+	}
+	if equality.Semantic.DeepEqual(current.Rules, role.Rules) &&
+		equality.Semantic.DeepEqual(current.AggregationRule, role.AggregationRule) &&
+		equality.Semantic.DeepEqual(current.Labels, role.Labels) &&
+		equality.Semantic.DeepEqual(current.Annotations, role.Annotations) {
+		return nil
+	}
+	role.ResourceVersion = current.ResourceVersion
+	_, err = client.RbacV1().ClusterRoles().Update(p.ctx, role, metav1.UpdateOptions{})
+	return err
+}
+
+func (p *RBACPropagator) applyClusterRoleBinding(client kubernetes.Interface, binding *rbacv1.ClusterRoleBinding) error {
+	current, err := client.RbacV1().ClusterRoleBindings().Get(p.ctx, binding.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.RbacV1().ClusterRoleBindings().Create(p.ctx, binding, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !isPropagated(current) {
+		return fmt.Errorf("ClusterRoleBinding %s exists in the child cluster and is not propagated by clusternet-hub", binding.Name)
+	}
+	if current.RoleRef != binding.RoleRef {
+		// roleRef is immutable
+		if err = client.RbacV1().ClusterRoleBindings().Delete(p.ctx, binding.Name, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		_, err = client.RbacV1().ClusterRoleBindings().Create(p.ctx, binding, metav1.CreateOptions{})
+		return err
+	}
+	if equality.Semantic.DeepEqual(current.Subjects, binding.Subjects) &&
+		equality.Semantic.DeepEqual(current.Labels, binding.Labels) &&
+		equality.Semantic.DeepEqual(current.Annotations, binding.Annotations) {
+		return nil
+	}
+	binding.ResourceVersion = current.ResourceVersion
+	_, err = client.RbacV1().ClusterRoleBindings().Update(p.ctx, binding, metav1.UpdateOptions{})
+	return err
+}
+
+func (p *RBACPropagator) applyRoleBinding(client kubernetes.Interface, binding *rbacv1.RoleBinding) error {
+	current, err := client.RbacV1().RoleBindings(binding.Namespace).Get(p.ctx, binding.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.RbacV1().RoleBindings(binding.Namespace).Create(p.ctx, binding, metav1.CreateOptions{})
+		if apierrors.IsNotFound(err) {
+			// the namespace does not exist in the child cluster yet, which is retried on next resync
+			klog.V(4).Infof("skip propagating RoleBinding %s: %v", klog.KObj(binding), err)
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if !isPropagated(current) {
+		return fmt.Errorf("RoleBinding %s exists in the child cluster and is not propagated by clusternet-hub", klog.KObj(binding))
+	}
+	if current.RoleRef != binding.RoleRef {
+		// roleRef is immutable
+		if err = client.RbacV1().RoleBindings(binding.Namespace).Delete(p.ctx, binding.Name, metav1.DeleteOptions{}); err != nil {
+			return err
+		}
+		_, err = client.RbacV1().RoleBindings(binding.Namespace).Create(p.ctx, binding, metav1.CreateOptions{})
+		return err
+	}
+	if equality.Semantic.DeepEqual(current.Subjects, binding.Subjects) &&
+		equality.Semantic.DeepEqual(current.Labels, binding.Labels) &&
+		equality.Semantic.DeepEqual(current.Annotations, binding.Annotations) {
+		return nil
+	}
+	binding.ResourceVersion = current.ResourceVersion
+	_, err = client.RbacV1().RoleBindings(binding.Namespace).Update(p.ctx, binding, metav1.UpdateOptions{})
+	return err
+}
+
+// prune removes the objects propagated to the child cluster before but not desired any more.
+func (p *RBACPropagator) prune(client kubernetes.Interface, desired *desiredState) error {
+	listOptions := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(propagatedLabels).String()}
+	var allErrs []error
+
+	roleNames := sets.NewString()
+	for _, role := range desired.clusterRoles {
+		roleNames.Insert(role.Name)
+	}
+	roles, err := client.RbacV1().ClusterRoles().List(p.ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles.Items {
+		if roleNames.Has(role.Name) {
+			continue
+		}
+		err = client.RbacV1().ClusterRoles().Delete(p.ctx, role.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	bindingNames := sets.NewString()
+	for _, binding := range desired.clusterRoleBindings {
+		bindingNames.Insert(binding.Name)
+	}
+	bindings, err := client.RbacV1().ClusterRoleBindings().List(p.ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	for _, binding := range bindings.Items {
+		if bindingNames.Has(binding.Name) {
+			continue
+		}
+		err = client.RbacV1().ClusterRoleBindings().Delete(p.ctx, binding.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	roleBindingKeys := sets.NewString()
+	for _, binding := range desired.roleBindings {
+		roleBindingKeys.Insert(binding.Namespace + "/" + binding.Name)
+	}
+	roleBindings, err := client.RbacV1().RoleBindings(metav1.NamespaceAll).List(p.ctx, listOptions)
+	if err != nil {
+		return err
+	}
+	for _, binding := range roleBindings.Items {
+		if roleBindingKeys.Has(binding.Namespace + "/" + binding.Name) {
+			continue
+		}
+		err = client.RbacV1().RoleBindings(binding.Namespace).Delete(p.ctx, binding.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(allErrs)
+}
+
+func (p *RBACPropagator) getChildClient(cluster *clusterapi.ManagedCluster) (kubernetes.Interface, error) {
+	config, err := utils.GetChildClusterConfig(p.secretLister, p.clusterLister, cluster.Namespace, string(cluster.Spec.ClusterID))
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
+
+// propagatedLabels are labeled on all the objects propagated to child clusters
+var propagatedLabels = map[string]string{
+	known.RBACPropagationLabel: "true",
+	known.ObjectCreatedByLabel: known.ClusternetHubName,
+}
+
+// desiredState is the RBAC objects expected in every child cluster.
+type desiredState struct {
+	clusterRoles        []*rbacv1.ClusterRole
+	clusterRoleBindings []*rbacv1.ClusterRoleBinding
+	roleBindings        []*rbacv1.RoleBinding
+}
+
+// translate returns the RBAC objects in child clusters translated from the marked ones in the parent cluster.
+func translate(roles []*rbacv1.ClusterRole, bindings []*rbacv1.ClusterRoleBinding) *desiredState {
+	desired := &desiredState{}
+	for _, role := range roles {
+		if !isSource(role) {
+			continue
+		}
+		desired.clusterRoles = append(desired.clusterRoles, &rbacv1.ClusterRole{
+			ObjectMeta:      propagatedObjectMeta(role.ObjectMeta, ""),
+			Rules:           role.Rules,
+			AggregationRule: role.AggregationRule,
+		})
+	}
+	for _, binding := range bindings {
+		if !isSource(binding) {
+			continue
+		}
+		namespaces := getNamespaces(binding)
+		if len(namespaces) == 0 {
+			desired.clusterRoleBindings = append(desired.clusterRoleBindings, &rbacv1.ClusterRoleBinding{
+				ObjectMeta: propagatedObjectMeta(binding.ObjectMeta, ""),
+				Subjects:   binding.Subjects,
+				RoleRef:    binding.RoleRef,
+			})
+			continue
+		}
+		for _, namespace := range namespaces {
+			desired.roleBindings = append(desired.roleBindings, &rbacv1.RoleBinding{
+				ObjectMeta: propagatedObjectMeta(binding.ObjectMeta, namespace),
+				Subjects:   binding.Subjects,
+				RoleRef:    binding.RoleRef,
+			})
+		}
+	}
+	return desired
+}
+
+// getNamespaces returns the namespaces that a ClusterRoleBinding is scoped to in child clusters.
+func getNamespaces(binding *rbacv1.ClusterRoleBinding) []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(binding.Annotations[known.RBACNamespacesAnnotation], ",") {
+		namespace = strings.TrimSpace(namespace)
+		if len(namespace) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+func propagatedObjectMeta(meta metav1.ObjectMeta, namespace string) metav1.ObjectMeta {
+	result := metav1.ObjectMeta{
+		Name:      meta.Name,
+		Namespace: namespace,
+		Labels:    map[string]string{},
+	}
+	for k, v := range meta.Labels {
+		result.Labels[k] = v
+	}
+	for k, v := range propagatedLabels {
+		result.Labels[k] = v
+	}
+	for k, v := range meta.Annotations {
+		if k == known.RBACNamespacesAnnotation || k == corev1.LastAppliedConfigAnnotation {
+			continue
+		}
+		if result.Annotations == nil {
+			result.Annotations = map[string]string{}
+		}
+		result.Annotations[k] = v
+	}
+	return result
+}
+
+// isSource tells whether the object is marked to be propagated to child clusters. The ones propagated
+// to the parent cluster itself, when it registers as a child cluster, are not taken as sources again.
+func isSource(obj interface{}) bool {
+	accessor, ok := obj.(metav1.Object)
+	if !ok {
+		return false
+	}
+	return accessor.GetLabels()[known.RBACPropagationLabel] == "true" && !isPropagated(accessor)
+}
+
+// isPropagated tells whether the object in a child cluster is propagated by clusternet-hub.
+func isPropagated(obj metav1.Object) bool {
+	return obj.GetLabels()[known.ObjectCreatedByLabel] == known.ClusternetHubName &&
+		obj.GetLabels()[known.RBACPropagationLabel] == "true"
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbacpropagator
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+var (
+	viewRole = &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "team-view",
+			Labels: map[string]string{known.RBACPropagationLabel: "true"},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}},
+		},
+	}
+	viewBinding = &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "team-view",
+			Labels: map[string]string{known.RBACPropagationLabel: "true"},
+		},
+		Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "team"}},
+		RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "team-view"},
+	}
+)
+
+func TestTranslate(t *testing.T) {
+	scopedBinding := viewBinding.DeepCopy()
+	scopedBinding.Name = "team-edit"
+	scopedBinding.Annotations = map[string]string{known.RBACNamespacesAnnotation: "foo, bar"}
+	unmarkedRole := viewRole.DeepCopy()
+	unmarkedRole.Name = "unmarked"
+	unmarkedRole.Labels = nil
+	propagatedRole := viewRole.DeepCopy()
+	propagatedRole.Name = "propagated"
+	propagatedRole.Labels = propagatedLabels
+
+	desired := translate([]*rbacv1.ClusterRole{viewRole, unmarkedRole, propagatedRole},
+		[]*rbacv1.ClusterRoleBinding{viewBinding, scopedBinding})
+
+	if len(desired.clusterRoles) != 1 || desired.clusterRoles[0].Name != "team-view" {
+		t.Fatalf("expected only ClusterRole team-view, got %v", desired.clusterRoles)
+	}
+	if !reflect.DeepEqual(desired.clusterRoles[0].Labels, propagatedLabels) {
+		t.Errorf("unexpected labels %v", desired.clusterRoles[0].Labels)
+	}
+	if len(desired.clusterRoleBindings) != 1 || desired.clusterRoleBindings[0].Name != "team-view" {
+		t.Fatalf("expected only ClusterRoleBinding team-view, got %v", desired.clusterRoleBindings)
+	}
+
+	var namespaces []string
+	for _, binding := range desired.roleBindings {
+		if binding.Name != "team-edit" {
+			t.Errorf("unexpected RoleBinding %s", binding.Name)
+		}
+		if _, ok := binding.Annotations[known.RBACNamespacesAnnotation]; ok {
+			t.Errorf("annotation %s should not be propagated", known.RBACNamespacesAnnotation)
+		}
+		namespaces = append(namespaces, binding.Namespace)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"foo", "bar"}) {
+		t.Errorf("expected RoleBindings in namespaces foo and bar, got %v", namespaces)
+	}
+}
+
+func TestApplyAndPrune(t *testing.T) {
+	existing := viewRole.DeepCopy()
+	existing.Name = "existing"
+	existing.Labels = nil
+	stale := viewRole.DeepCopy()
+	stale.Name = "stale"
+	stale.Labels = propagatedLabels
+	client := fake.NewSimpleClientset(existing, stale)
+	p := &RBACPropagator{ctx: context.TODO()}
+
+	conflicting := viewRole.DeepCopy()
+	conflicting.Name = "existing"
+	desired := translate([]*rbacv1.ClusterRole{viewRole, conflicting}, nil)
+	if err := p.applyClusterRole(client, desired.clusterRoles[0]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.applyClusterRole(client, desired.clusterRoles[1]); err == nil {
+		t.Errorf("expected error on overwriting ClusterRole not propagated")
+	}
+
+	if err := p.prune(client, &desiredState{clusterRoles: desired.clusterRoles[:1]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	roles, err := client.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, role := range roles.Items {
+		names = append(names, role.Name)
+	}
+	sort.Strings(names)
+	if !reflect.DeepEqual(names, []string{"existing", "team-view"}) {
+		t.Errorf("expected ClusterRoles existing and team-view, got %v", names)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+)
+
+// Framework runs the enabled plugins at each extension point to schedule Subscriptions.
+type Framework struct {
+	clusternetClient          clusternetclientset.Interface
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory
+	recorder                  record.EventRecorder
+
+	filterPlugins  []FilterPlugin
+	scorePlugins   []ScorePlugin
+	reservePlugins []ReservePlugin
+	bindPlugins    []BindPlugin
+}
+
+var _ Handle = &Framework{}
+
+// NewFramework initializes the plugins with the names from the registry, which run in the same order
+// at each extension point. It should be called before clusternetInformerFactory starts.
+func NewFramework(clusternetClient clusternetclientset.Interface, clusternetInformerFactory clusternetinformers.SharedInformerFactory,
+	recorder record.EventRecorder, registry Registry, plugins []string) (*Framework, error) {
+	f := &Framework{
+		clusternetClient:          clusternetClient,
+		clusternetInformerFactory: clusternetInformerFactory,
+		recorder:                  recorder,
+	}
+
+	for _, name := range plugins {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("scheduling plugin %s is not registered", name)
+		}
+		plugin, err := factory(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize scheduling plugin %s: %v", name, err)
+		}
+
+		implemented := false
+		if p, ok := plugin.(FilterPlugin); ok {
+			f.filterPlugins = append(f.filterPlugins, p)
+			implemented = true
+		}
+		if p, ok := plugin.(ScorePlugin); ok {
+			f.scorePlugins = append(f.scorePlugins, p)
+			implemented = true
+		}
+		if p, ok := plugin.(ReservePlugin); ok {
+			f.reservePlugins = append(f.reservePlugins, p)
+			implemented = true
+		}
+		if p, ok := plugin.(BindPlugin); ok {
+			f.bindPlugins = append(f.bindPlugins, p)
+			implemented = true
+		}
+		if !implemented {
+			return nil, fmt.Errorf("scheduling plugin %s does not implement any extension point", name)
+		}
+		klog.V(4).Infof("enabled scheduling plugin %s", name)
+	}
+	return f, nil
+}
+
+func (f *Framework) ClusternetClient() clusternetclientset.Interface {
+	return f.clusternetClient
+}
+
+func (f *Framework) ClusternetInformerFactory() clusternetinformers.SharedInformerFactory {
+	return f.clusternetInformerFactory
+}
+
+func (f *Framework) EventRecorder() record.EventRecorder {
+	return f.recorder
+}
+
+// Schedule filters the clusters and ranks the feasible ones by their scores, in descending order.
+// The ranked clusters are then reserved for the Subscription.
+func (f *Framework) Schedule(ctx context.Context, state *CycleState, clusters []*clusterapi.ManagedCluster) ([]ClusterScore, *Status) {
+	feasible, status := f.RunFilterPlugins(ctx, state, clusters)
+	if !status.IsSuccess() {
+		return nil, status
+	}
+	scores, status := f.RunScorePlugins(ctx, state, feasible)
+	if !status.IsSuccess() {
+		return nil, status
+	}
+	if status = f.RunReservePlugins(ctx, state, scores); !status.IsSuccess() {
+		return nil, status
+	}
+	return scores, nil
+}
+
+// RunFilterPlugins runs the Filter plugins in order, each of which takes the clusters passing the previous ones.
+// The plugins keep running even if no clusters are left, so that they could always report conditions in the status,
+// but it stops at the first plugin not returning success.
+func (f *Framework) RunFilterPlugins(ctx context.Context, state *CycleState, clusters []*clusterapi.ManagedCluster) (
+	[]*clusterapi.ManagedCluster, *Status) {
+	for _, plugin := range f.filterPlugins {
+		var status *Status
+		clusters, status = plugin.Filter(ctx, state, clusters)
+		if !status.IsSuccess() {
+			return nil, status.withPlugin(plugin.Name())
+		}
+	}
+	return clusters, nil
+}
+
+// RunScorePlugins sums up the scores from all the Score plugins, and sorts the clusters by the total scores
+// in descending order. Clusters with the same score are sorted by their namespaces.
+func (f *Framework) RunScorePlugins(ctx context.Context, state *CycleState, clusters []*clusterapi.ManagedCluster) (
+	[]ClusterScore, *Status) {
+	result := make([]ClusterScore, len(clusters))
+	for i, cluster := range clusters {
+		result[i].Cluster = cluster
+	}
+
+	for _, plugin := range f.scorePlugins {
+		scores, status := plugin.Score(ctx, state, clusters)
+		if !status.IsSuccess() {
+			return nil, status.withPlugin(plugin.Name())
+		}
+		if len(scores) != len(clusters) {
+			return nil, NewStatus(Error, fmt.Sprintf("got %d scores for %d clusters", len(scores), len(clusters))).
+				withPlugin(plugin.Name())
+		}
+		for i, score := range scores {
+			if score < MinClusterScore || score > MaxClusterScore {
+				return nil, NewStatus(Error, fmt.Sprintf("score %d of cluster %s is out of range [%d, %d]",
+					score, klog.KObj(clusters[i]), MinClusterScore, MaxClusterScore)).withPlugin(plugin.Name())
+			}
+			result[i].Score += score
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Cluster.Namespace < result[j].Cluster.Namespace
+	})
+	return result, nil
+}
+
+// RunReservePlugins runs the Reserve plugins in order, and stops at the first failure.
+func (f *Framework) RunReservePlugins(ctx context.Context, state *CycleState, clusters []ClusterScore) *Status {
+	for _, plugin := range f.reservePlugins {
+		if status := plugin.Reserve(ctx, state, clusters); !status.IsSuccess() {
+			return status.withPlugin(plugin.Name())
+		}
+	}
+	return nil
+}
+
+// RunBindPlugins runs the Bind plugins in order until one of them doesn't return Skip.
+// It returns Skip if there are no Bind plugins or all of them are skipped.
+func (f *Framework) RunBindPlugins(ctx context.Context, state *CycleState, clusters []ClusterScore) *Status {
+	for _, plugin := range f.bindPlugins {
+		status := plugin.Bind(ctx, state, clusters)
+		if status.IsSkip() {
+			continue
+		}
+		return status.withPlugin(plugin.Name())
+	}
+	return NewStatus(Skip, "")
+}
+
+// Clusters returns the clusters in the scores.
+func Clusters(scores []ClusterScore) []*clusterapi.ManagedCluster {
+	clusters := make([]*clusterapi.ManagedCluster, 0, len(scores))
+	for _, score := range scores {
+		clusters = append(clusters, score.Cluster)
+	}
+	return clusters
+}
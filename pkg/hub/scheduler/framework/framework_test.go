@@ -0,0 +1,323 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+type fakeFilterPlugin struct {
+	name   string
+	filter func(clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *Status)
+	called *[]string
+}
+
+func (pl *fakeFilterPlugin) Name() string {
+	return pl.name
+}
+
+func (pl *fakeFilterPlugin) Filter(_ context.Context, _ *CycleState, clusters []*clusterapi.ManagedCluster) (
+	[]*clusterapi.ManagedCluster, *Status) {
+	*pl.called = append(*pl.called, pl.name)
+	return pl.filter(clusters)
+}
+
+type fakeScorePlugin struct {
+	name  string
+	score func(clusters []*clusterapi.ManagedCluster) ([]int64, *Status)
+}
+
+func (pl *fakeScorePlugin) Name() string {
+	return pl.name
+}
+
+func (pl *fakeScorePlugin) Score(_ context.Context, _ *CycleState, clusters []*clusterapi.ManagedCluster) ([]int64, *Status) {
+	return pl.score(clusters)
+}
+
+type fakeBindPlugin struct {
+	name   string
+	bind   func(clusters []ClusterScore) *Status
+	called *[]string
+}
+
+func (pl *fakeBindPlugin) Name() string {
+	return pl.name
+}
+
+func (pl *fakeBindPlugin) Bind(_ context.Context, _ *CycleState, clusters []ClusterScore) *Status {
+	*pl.called = append(*pl.called, pl.name)
+	return pl.bind(clusters)
+}
+
+type fakeReservePlugin struct {
+	name     string
+	reserved *[]string
+}
+
+func (pl *fakeReservePlugin) Name() string {
+	return pl.name
+}
+
+func (pl *fakeReservePlugin) Reserve(_ context.Context, _ *CycleState, clusters []ClusterScore) *Status {
+	for _, cluster := range clusters {
+		*pl.reserved = append(*pl.reserved, cluster.Cluster.Namespace)
+	}
+	return nil
+}
+
+// noopPlugin implements no extension points
+type noopPlugin struct{}
+
+func (noopPlugin) Name() string {
+	return "Noop"
+}
+
+func newCluster(namespace string) *clusterapi.ManagedCluster {
+	return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace}}
+}
+
+func namespaces(clusters []*clusterapi.ManagedCluster) []string {
+	var result []string
+	for _, cluster := range clusters {
+		result = append(result, cluster.Namespace)
+	}
+	return result
+}
+
+func newTestFramework(t *testing.T, plugins ...Plugin) *Framework {
+	registry := Registry{}
+	var names []string
+	for _, plugin := range plugins {
+		plugin := plugin
+		if err := registry.Register(plugin.Name(), func(Handle) (Plugin, error) { return plugin, nil }); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, plugin.Name())
+	}
+	f, err := NewFramework(nil, nil, record.NewFakeRecorder(10), registry, names)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return f
+}
+
+func dropCluster(namespace string) func([]*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *Status) {
+	return func(clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *Status) {
+		var result []*clusterapi.ManagedCluster
+		for _, cluster := range clusters {
+			if cluster.Namespace != namespace {
+				result = append(result, cluster)
+			}
+		}
+		return result, nil
+	}
+}
+
+func TestNewFramework(t *testing.T) {
+	registry := Registry{
+		"Filter": func(Handle) (Plugin, error) { return &fakeFilterPlugin{name: "Filter"}, nil },
+		"Noop":   func(Handle) (Plugin, error) { return noopPlugin{}, nil },
+	}
+	if _, err := NewFramework(nil, nil, nil, registry, []string{"Filter"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewFramework(nil, nil, nil, registry, []string{"Unknown"}); err == nil {
+		t.Errorf("expected an error for unregistered plugins")
+	}
+	if _, err := NewFramework(nil, nil, nil, registry, []string{"Noop"}); err == nil {
+		t.Errorf("expected an error for plugins implementing no extension points")
+	}
+
+	if err := registry.Register("Filter", nil); err == nil {
+		t.Errorf("expected an error for duplicated plugin names")
+	}
+}
+
+func TestRunFilterPlugins(t *testing.T) {
+	var called []string
+	f := newTestFramework(t,
+		&fakeFilterPlugin{name: "DropA", filter: dropCluster("a"), called: &called},
+		&fakeFilterPlugin{name: "DropB", filter: dropCluster("b"), called: &called},
+		&fakeFilterPlugin{name: "DropC", filter: dropCluster("c"), called: &called},
+	)
+
+	clusters, status := f.RunFilterPlugins(context.TODO(), &CycleState{},
+		[]*clusterapi.ManagedCluster{newCluster("a"), newCluster("b"), newCluster("c"), newCluster("d")})
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	if got, want := namespaces(clusters), []string{"d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RunFilterPlugins() = %v, want %v", got, want)
+	}
+
+	// plugins still run when no clusters are left
+	called = nil
+	clusters, status = f.RunFilterPlugins(context.TODO(), &CycleState{}, []*clusterapi.ManagedCluster{newCluster("a")})
+	if !status.IsSuccess() || len(clusters) != 0 {
+		t.Errorf("RunFilterPlugins() = %v, %v, want no clusters", namespaces(clusters), status.Message())
+	}
+	if want := []string{"DropA", "DropB", "DropC"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("called plugins = %v, want %v", called, want)
+	}
+}
+
+func TestRunFilterPluginsUnschedulable(t *testing.T) {
+	var called []string
+	f := newTestFramework(t,
+		&fakeFilterPlugin{name: "DropA", filter: dropCluster("a"), called: &called},
+		&fakeFilterPlugin{
+			name: "Reject",
+			filter: func([]*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *Status) {
+				return nil, NewStatus(Unschedulable, "no way")
+			},
+			called: &called,
+		},
+		&fakeFilterPlugin{name: "DropB", filter: dropCluster("b"), called: &called},
+	)
+
+	clusters, status := f.Schedule(context.TODO(), &CycleState{},
+		[]*clusterapi.ManagedCluster{newCluster("a"), newCluster("b")})
+	if status.Code() != Unschedulable {
+		t.Fatalf("expected Unschedulable, got %v", status.Code())
+	}
+	if status.Plugin() != "Reject" || status.Message() != "no way" {
+		t.Errorf("unexpected status from plugin %q: %q", status.Plugin(), status.Message())
+	}
+	if clusters != nil {
+		t.Errorf("expected no clusters, got %v", clusters)
+	}
+	if want := []string{"DropA", "Reject"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("called plugins = %v, want %v", called, want)
+	}
+}
+
+func TestScheduleReserves(t *testing.T) {
+	var called, reserved []string
+	f := newTestFramework(t,
+		&fakeFilterPlugin{name: "DropA", filter: dropCluster("a"), called: &called},
+		&fakeReservePlugin{name: "Reserve", reserved: &reserved},
+	)
+
+	scores, status := f.Schedule(context.TODO(), &CycleState{},
+		[]*clusterapi.ManagedCluster{newCluster("a"), newCluster("b"), newCluster("c")})
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	if got, want := namespaces(Clusters(scores)), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule() = %v, want %v", got, want)
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(reserved, want) {
+		t.Errorf("reserved clusters = %v, want %v", reserved, want)
+	}
+}
+
+func TestRunScorePlugins(t *testing.T) {
+	scoreBy := func(scores map[string]int64) func([]*clusterapi.ManagedCluster) ([]int64, *Status) {
+		return func(clusters []*clusterapi.ManagedCluster) ([]int64, *Status) {
+			result := make([]int64, len(clusters))
+			for i, cluster := range clusters {
+				result[i] = scores[cluster.Namespace]
+			}
+			return result, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		scores    []map[string]int64
+		want      []ClusterScore
+		wantError bool
+	}{
+		{
+			name:   "no score plugins",
+			scores: nil,
+			want:   []ClusterScore{{Cluster: newCluster("a")}, {Cluster: newCluster("b")}, {Cluster: newCluster("c")}},
+		},
+		{
+			name: "sum up scores",
+			scores: []map[string]int64{
+				{"a": 10, "b": 50, "c": 30},
+				{"a": 90, "b": 0, "c": 20},
+			},
+			want: []ClusterScore{
+				{Cluster: newCluster("a"), Score: 100},
+				{Cluster: newCluster("b"), Score: 50},
+				{Cluster: newCluster("c"), Score: 50},
+			},
+		},
+		{
+			name:      "score out of range",
+			scores:    []map[string]int64{{"a": MaxClusterScore + 1}},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var plugins []Plugin
+			for i, scores := range tt.scores {
+				plugins = append(plugins, &fakeScorePlugin{name: string(rune('A' + i)), score: scoreBy(scores)})
+			}
+			f := newTestFramework(t, plugins...)
+
+			got, status := f.RunScorePlugins(context.TODO(), &CycleState{},
+				[]*clusterapi.ManagedCluster{newCluster("c"), newCluster("b"), newCluster("a")})
+			if tt.wantError {
+				if status.Code() != Error {
+					t.Errorf("expected Error, got %v", status.Code())
+				}
+				return
+			}
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RunScorePlugins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunBindPlugins(t *testing.T) {
+	var called []string
+	skip := func([]ClusterScore) *Status { return NewStatus(Skip, "") }
+	bound := func([]ClusterScore) *Status { return nil }
+
+	f := newTestFramework(t)
+	if status := f.RunBindPlugins(context.TODO(), &CycleState{}, nil); !status.IsSkip() {
+		t.Errorf("expected Skip without bind plugins, got %v", status.Code())
+	}
+
+	f = newTestFramework(t,
+		&fakeBindPlugin{name: "Skip", bind: skip, called: &called},
+		&fakeBindPlugin{name: "Bind", bind: bound, called: &called},
+		&fakeBindPlugin{name: "Never", bind: bound, called: &called},
+	)
+	if status := f.RunBindPlugins(context.TODO(), &CycleState{}, nil); !status.IsSuccess() {
+		t.Errorf("expected Success, got %v", status.Code())
+	}
+	if want := []string{"Skip", "Bind"}; !reflect.DeepEqual(called, want) {
+		t.Errorf("called plugins = %v, want %v", called, want)
+	}
+}
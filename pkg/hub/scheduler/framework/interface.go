@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+)
+
+const (
+	// MinClusterScore is the minimum score a Score plugin is expected to return
+	MinClusterScore int64 = 0
+	// MaxClusterScore is the maximum score a Score plugin is expected to return
+	MaxClusterScore int64 = 100
+)
+
+// Code is the status code of running a plugin
+type Code int
+
+const (
+	// Success means the plugin ran correctly. A nil Status is also taken as Success.
+	Success Code = iota
+	// Unschedulable means the Subscription could not be scheduled for now, and the current placement is kept.
+	// Plugins returning Unschedulable should record the reason as an event on the Subscription.
+	Unschedulable
+	// Error means an internal error occurs, and the Subscription will be retried.
+	Error
+	// Skip is returned by Bind plugins to leave the binding to the next plugin.
+	Skip
+)
+
+// Status is the result of running a plugin. A nil Status means Success.
+type Status struct {
+	code    Code
+	message string
+	plugin  string
+}
+
+// NewStatus returns a Status with the code and message.
+func NewStatus(code Code, message string) *Status {
+	return &Status{code: code, message: message}
+}
+
+// AsStatus wraps an error into a Status with code Error.
+func AsStatus(err error) *Status {
+	if err == nil {
+		return nil
+	}
+	return &Status{code: Error, message: err.Error()}
+}
+
+// Code returns the code of the Status.
+func (s *Status) Code() Code {
+	if s == nil {
+		return Success
+	}
+	return s.code
+}
+
+// Message returns the message of the Status.
+func (s *Status) Message() string {
+	if s == nil {
+		return ""
+	}
+	return s.message
+}
+
+// Plugin returns the name of the plugin that returns the Status.
+func (s *Status) Plugin() string {
+	if s == nil {
+		return ""
+	}
+	return s.plugin
+}
+
+// IsSuccess tells whether the Status is Success.
+func (s *Status) IsSuccess() bool {
+	return s.Code() == Success
+}
+
+// IsSkip tells whether the Status is Skip.
+func (s *Status) IsSkip() bool {
+	return s.Code() == Skip
+}
+
+// AsError returns nil for Success and Skip, or an error with the message.
+func (s *Status) AsError() error {
+	if s.IsSuccess() || s.IsSkip() {
+		return nil
+	}
+	return errors.New(s.message)
+}
+
+func (s *Status) withPlugin(plugin string) *Status {
+	if s == nil {
+		return nil
+	}
+	s.plugin = plugin
+	return s
+}
+
+// CycleState holds what plugins need in a scheduling cycle of a Subscription.
+type CycleState struct {
+	// Subscription is the one being scheduled
+	Subscription *appsapi.Subscription
+	// ExistingBases are the Bases populated for the Subscription before this cycle
+	ExistingBases []*appsapi.Base
+	// Status is the status of the Subscription to be updated, where plugins could report conditions
+	Status *appsapi.SubscriptionStatus
+	// Victims are the Bases of lower-priority Subscriptions to be removed from the clusters, keyed by the
+	// namespaces of clusters, so that the Subscription fits in. They are deleted by the deployer
+	// once the Subscription is scheduled to the clusters.
+	Victims map[string][]*appsapi.Base
+}
+
+// AddVictims records the Bases to be preempted from the cluster with the namespace.
+func (s *CycleState) AddVictims(namespace string, victims ...*appsapi.Base) {
+	if s.Victims == nil {
+		s.Victims = map[string][]*appsapi.Base{}
+	}
+	s.Victims[namespace] = append(s.Victims[namespace], victims...)
+}
+
+// IsScheduled tells whether the Subscription has been scheduled to the cluster with the namespace,
+// i.e. a Base exists in the namespace.
+func (s *CycleState) IsScheduled(namespace string) bool {
+	for _, base := range s.ExistingBases {
+		if base.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterScore is a cluster with its total score from all the Score plugins.
+type ClusterScore struct {
+	Cluster *clusterapi.ManagedCluster
+	Score   int64
+}
+
+// Plugin is the parent type of all the scheduling plugins.
+type Plugin interface {
+	Name() string
+}
+
+// FilterPlugin filters out the clusters that cannot run the Subscription.
+type FilterPlugin interface {
+	Plugin
+	// Filter returns the clusters fit for the Subscription among the given ones.
+	Filter(ctx context.Context, state *CycleState, clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *Status)
+}
+
+// ScorePlugin ranks the clusters that pass all the Filter plugins.
+type ScorePlugin interface {
+	Plugin
+	// Score returns a score for each of the clusters, in the same order,
+	// which should be between MinClusterScore and MaxClusterScore.
+	Score(ctx context.Context, state *CycleState, clusters []*clusterapi.ManagedCluster) ([]int64, *Status)
+}
+
+// ReservePlugin is notified of the scheduled clusters before binding, so that it could reserve
+// resources of the clusters for the Subscription.
+type ReservePlugin interface {
+	Plugin
+	Reserve(ctx context.Context, state *CycleState, clusters []ClusterScore) *Status
+}
+
+// BindPlugin binds the Subscription to the scheduled clusters. A Bind plugin returning Skip leaves the binding to
+// the next one, and the deployer populates Bases to the clusters if all of them are skipped.
+type BindPlugin interface {
+	Plugin
+	Bind(ctx context.Context, state *CycleState, clusters []ClusterScore) *Status
+}
+
+// Handle provides what plugins need to initialize.
+type Handle interface {
+	// ClusternetClient returns the clientset of clusternet
+	ClusternetClient() clusternetclientset.Interface
+	// ClusternetInformerFactory returns the shared informer factory of clusternet,
+	// whose informers should be retrieved when plugins are initialized.
+	ClusternetInformerFactory() clusternetinformers.SharedInformerFactory
+	// EventRecorder returns the recorder for events about scheduling
+	EventRecorder() record.EventRecorder
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"fmt"
+)
+
+// PluginFactory initializes a plugin with the Handle.
+type PluginFactory func(handle Handle) (Plugin, error)
+
+// Registry is the collection of plugin factories keyed by plugin names.
+type Registry map[string]PluginFactory
+
+// Register adds a plugin factory to the registry. It returns an error if the name is already registered.
+func (r Registry) Register(name string, factory PluginFactory) error {
+	if _, ok := r[name]; ok {
+		return fmt.Errorf("a plugin named %s already exists", name)
+	}
+	r[name] = factory
+	return nil
+}
+
+// Merge adds all the plugin factories in another registry. It returns an error on duplicated names.
+func (r Registry) Merge(in Registry) error {
+	for name, factory := range in {
+		if err := r.Register(name, factory); err != nil {
+			return err
+		}
+	}
+	return nil
+}
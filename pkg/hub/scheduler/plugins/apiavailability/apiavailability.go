@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiavailability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "APIAvailability"
+
+// APIAvailability skips the clusters that don't serve the api versions of the feeds.
+type APIAvailability struct {
+	recorder record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &APIAvailability{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &APIAvailability{recorder: handle.EventRecorder()}, nil
+}
+
+func (pl *APIAvailability) Name() string {
+	return Name
+}
+
+func (pl *APIAvailability) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	var servingClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		missing := utils.FindMissingAPIs(state.Subscription.Spec.Feeds, cluster)
+		if len(missing) == 0 {
+			servingClusters = append(servingClusters, cluster)
+			continue
+		}
+		pl.recorder.Event(state.Subscription, corev1.EventTypeWarning, "MissingAPIs",
+			fmt.Sprintf("Skip cluster %s: %s", klog.KObj(cluster), strings.Join(missing, "; ")))
+	}
+	return servingClusters, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiavailability
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+func TestFilter(t *testing.T) {
+	newCluster := func(namespace string, groupVersions ...string) *clusterapi.ManagedCluster {
+		cluster := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace}}
+		cluster.Status.APIGroupVersions = groupVersions
+		return cluster
+	}
+	serving := newCluster("a", "v1", "apps/v1", "batch/v1")
+	missing := newCluster("b", "v1", "apps/v1")
+	unknown := newCluster("c")
+
+	recorder := record.NewFakeRecorder(10)
+	state := &framework.CycleState{
+		Subscription: &appsapi.Subscription{Spec: appsapi.SubscriptionSpec{Feeds: []appsapi.Feed{
+			{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"},
+			{APIVersion: "batch/v1", Kind: "CronJob", Namespace: "default", Name: "backup"},
+		}}},
+	}
+	got, status := (&APIAvailability{recorder: recorder}).Filter(context.TODO(), state,
+		[]*clusterapi.ManagedCluster{serving, missing, unknown})
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	if len(got) != 2 || got[0] != serving || got[1] != unknown {
+		t.Errorf("expected clusters a and c to be left, got %d clusters", len(got))
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a MissingAPIs event, got %d events", len(recorder.Events))
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteraffinity
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+// Name is the name of the plugin
+const Name = "ClusterAffinity"
+
+// ClusterAffinity selects the clusters matching the clusterAffinity of any subscriber.
+type ClusterAffinity struct {
+	recorder record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &ClusterAffinity{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &ClusterAffinity{recorder: handle.EventRecorder()}, nil
+}
+
+func (pl *ClusterAffinity) Name() string {
+	return Name
+}
+
+// Filter returns Unschedulable if any subscriber matches no clusters, so that the current placement is kept.
+func (pl *ClusterAffinity) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	matched := make([]bool, len(clusters))
+	for _, subscriber := range state.Subscription.Spec.Subscribers {
+		selector, err := metav1.LabelSelectorAsSelector(subscriber.ClusterAffinity)
+		if err != nil {
+			return nil, framework.AsStatus(err)
+		}
+
+		found := false
+		for i, cluster := range clusters {
+			if selector.Matches(labels.Set(cluster.Labels)) {
+				matched[i] = true
+				found = true
+			}
+		}
+		if !found {
+			pl.recorder.Event(state.Subscription, corev1.EventTypeWarning, "NoClusters", "No clusters get matched")
+			return nil, framework.NewStatus(framework.Unschedulable, "No clusters get matched")
+		}
+	}
+
+	var matchedClusters []*clusterapi.ManagedCluster
+	for i, cluster := range clusters {
+		if matched[i] {
+			matchedClusters = append(matchedClusters, cluster)
+		}
+	}
+	return matchedClusters, nil
+}
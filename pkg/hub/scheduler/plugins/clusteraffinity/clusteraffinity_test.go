@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusteraffinity
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+func newCluster(namespace string, labels map[string]string) *clusterapi.ManagedCluster {
+	return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace, Labels: labels}}
+}
+
+func subscriber(matchLabels map[string]string) appsapi.Subscriber {
+	return appsapi.Subscriber{ClusterAffinity: &metav1.LabelSelector{MatchLabels: matchLabels}}
+}
+
+func TestFilter(t *testing.T) {
+	clusters := []*clusterapi.ManagedCluster{
+		newCluster("a", map[string]string{"env": "prod", "region": "eu"}),
+		newCluster("b", map[string]string{"env": "prod", "region": "us"}),
+		newCluster("c", map[string]string{"env": "dev", "region": "eu"}),
+	}
+
+	tests := []struct {
+		name        string
+		subscribers []appsapi.Subscriber
+		want        []string
+		wantCode    framework.Code
+	}{
+		{
+			name:        "single subscriber",
+			subscribers: []appsapi.Subscriber{subscriber(map[string]string{"env": "prod"})},
+			want:        []string{"a", "b"},
+		},
+		{
+			name: "clusters matched by several subscribers are selected once",
+			subscribers: []appsapi.Subscriber{
+				subscriber(map[string]string{"env": "prod"}),
+				subscriber(map[string]string{"region": "eu"}),
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name: "any subscriber matching no clusters",
+			subscribers: []appsapi.Subscriber{
+				subscriber(map[string]string{"env": "prod"}),
+				subscriber(map[string]string{"env": "staging"}),
+			},
+			wantCode: framework.Unschedulable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			pl := &ClusterAffinity{recorder: recorder}
+			state := &framework.CycleState{
+				Subscription: &appsapi.Subscription{Spec: appsapi.SubscriptionSpec{Subscribers: tt.subscribers}},
+			}
+
+			got, status := pl.Filter(context.TODO(), state, clusters)
+			if status.Code() != tt.wantCode {
+				t.Fatalf("Filter() code = %v, want %v", status.Code(), tt.wantCode)
+			}
+			if tt.wantCode == framework.Unschedulable {
+				if len(recorder.Events) != 1 {
+					t.Errorf("expected a NoClusters event, got %d events", len(recorder.Events))
+				}
+				return
+			}
+
+			var namespaces []string
+			for _, cluster := range got {
+				namespaces = append(namespaces, cluster.Namespace)
+			}
+			if !reflect.DeepEqual(namespaces, tt.want) {
+				t.Errorf("Filter() = %v, want %v", namespaces, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustereviction
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// Name is the name of the plugin
+const Name = "ClusterEviction"
+
+// ClusterEviction skips the clusters whose workloads are evicted for losing heartbeats.
+type ClusterEviction struct{}
+
+var _ framework.FilterPlugin = &ClusterEviction{}
+
+// New initializes the plugin.
+func New(_ framework.Handle) (framework.Plugin, error) {
+	return &ClusterEviction{}, nil
+}
+
+func (pl *ClusterEviction) Name() string {
+	return Name
+}
+
+func (pl *ClusterEviction) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	var healthyClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		if _, ok := cluster.Labels[known.ClusterEvictedLabel]; ok {
+			klog.V(5).Infof("skip evicted ManagedCluster %s", klog.KObj(cluster))
+			continue
+		}
+		healthyClusters = append(healthyClusters, cluster)
+	}
+	return healthyClusters, nil
+}
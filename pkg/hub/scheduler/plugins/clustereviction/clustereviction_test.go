@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clustereviction
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestFilter(t *testing.T) {
+	healthy := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "a"}}
+	evicted := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: "b", Namespace: "b", Labels: map[string]string{known.ClusterEvictedLabel: "true"},
+	}}
+
+	got, status := (&ClusterEviction{}).Filter(context.TODO(), &framework.CycleState{},
+		[]*clusterapi.ManagedCluster{healthy, evicted})
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	if len(got) != 1 || got[0] != healthy {
+		t.Errorf("expected only the healthy cluster to be left, got %d clusters", len(got))
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataresidency
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "DataResidency"
+
+// DataResidency skips the clusters that violate the ResidencyPolicies of the feeds in the Subscription,
+// and reports the violations as condition ResidencySatisfied in the status.
+type DataResidency struct {
+	residencyLister applisters.ResidencyPolicyLister
+	recorder        record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &DataResidency{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &DataResidency{
+		residencyLister: handle.ClusternetInformerFactory().Apps().V1alpha1().ResidencyPolicies().Lister(),
+		recorder:        handle.EventRecorder(),
+	}, nil
+}
+
+func (pl *DataResidency) Name() string {
+	return Name
+}
+
+func (pl *DataResidency) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	policies, err := pl.residencyLister.List(labels.Everything())
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+
+	sub := state.Subscription
+	var allowedClusters []*clusterapi.ManagedCluster
+	var allViolations []string
+	for _, cluster := range clusters {
+		violations := utils.FindResidencyViolations(policies, sub.Spec.Feeds, cluster)
+		if len(violations) == 0 {
+			allowedClusters = append(allowedClusters, cluster)
+			continue
+		}
+		allViolations = append(allViolations, violations...)
+		pl.recorder.Event(sub, corev1.EventTypeWarning, "ResidencyViolation",
+			fmt.Sprintf("Skip cluster %s: %s", klog.KObj(cluster), strings.Join(violations, "; ")))
+	}
+
+	condition := metav1.Condition{
+		Type:               appsapi.SubscriptionResidencySatisfied,
+		Status:             metav1.ConditionTrue,
+		Reason:             "AllClustersSatisfied",
+		Message:            "All the matching clusters satisfy the residency requirements of the feeds",
+		ObservedGeneration: sub.Generation,
+	}
+	if len(allViolations) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ResidencyViolation"
+		condition.Message = strings.Join(allViolations, "; ")
+	}
+	apimeta.SetStatusCondition(&state.Status.Conditions, condition)
+
+	return allowedClusters, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dataresidency
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+func TestFilter(t *testing.T) {
+	feed := appsapi.Feed{APIVersion: "v1", Kind: "ConfigMap", Namespace: "default", Name: "customers"}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(&appsapi.ResidencyPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "eu-only"},
+		Spec: appsapi.ResidencyPolicySpec{
+			Residency: "eu-only",
+			Regions:   []string{"eu-west"},
+			Feeds:     []appsapi.Feed{feed},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	newCluster := func(namespace, region string) *clusterapi.ManagedCluster {
+		return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+			Name: namespace, Namespace: namespace, Labels: map[string]string{corev1.LabelTopologyRegion: region},
+		}}
+	}
+	eu := newCluster("eu", "eu-west")
+	us := newCluster("us", "us-east")
+
+	tests := []struct {
+		name       string
+		clusters   []*clusterapi.ManagedCluster
+		wantCount  int
+		wantStatus metav1.ConditionStatus
+	}{
+		{
+			name:       "all satisfied",
+			clusters:   []*clusterapi.ManagedCluster{eu},
+			wantCount:  1,
+			wantStatus: metav1.ConditionTrue,
+		},
+		{
+			name:       "violation",
+			clusters:   []*clusterapi.ManagedCluster{eu, us},
+			wantCount:  1,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "no clusters",
+			clusters:   nil,
+			wantCount:  0,
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &DataResidency{
+				residencyLister: applisters.NewResidencyPolicyLister(indexer),
+				recorder:        record.NewFakeRecorder(10),
+			}
+			state := &framework.CycleState{
+				Subscription: &appsapi.Subscription{Spec: appsapi.SubscriptionSpec{Feeds: []appsapi.Feed{feed}}},
+				Status:       &appsapi.SubscriptionStatus{},
+			}
+
+			got, status := pl.Filter(context.TODO(), state, tt.clusters)
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("expected %d clusters left, got %d", tt.wantCount, len(got))
+			}
+			condition := apimeta.FindStatusCondition(state.Status.Conditions, appsapi.SubscriptionResidencySatisfied)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Errorf("expected condition %s to be %s, got %v", appsapi.SubscriptionResidencySatisfied, tt.wantStatus, condition)
+			}
+		})
+	}
+}
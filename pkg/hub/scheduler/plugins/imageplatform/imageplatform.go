@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package imageplatform
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/deployer/platform"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "ImagePlatform"
+
+// ImagePlatform skips the clusters whose node platforms are not provided by the images in feeds.
+// Images that fail to be inspected are ignored, with a warning event recorded.
+type ImagePlatform struct {
+	mfstLister applisters.ManifestLister
+	inspector  *platform.Inspector
+	recorder   record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &ImagePlatform{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &ImagePlatform{
+		mfstLister: handle.ClusternetInformerFactory().Apps().V1alpha1().Manifests().Lister(),
+		inspector:  platform.NewInspector(),
+		recorder:   handle.EventRecorder(),
+	}, nil
+}
+
+func (pl *ImagePlatform) Name() string {
+	return Name
+}
+
+func (pl *ImagePlatform) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	sub := state.Subscription
+	var manifests []*appsapi.Manifest
+	for _, feed := range sub.Spec.Feeds {
+		mfsts, err := utils.ListManifestsBySelector(pl.mfstLister, feed)
+		if err != nil {
+			klog.Warningf("failed to list manifests for %s: %v", utils.FormatFeed(feed), err)
+			continue
+		}
+		manifests = append(manifests, mfsts...)
+	}
+	images, err := platform.GetImagesFromManifests(manifests)
+	if err != nil {
+		klog.Warningf("failed to get images from feeds of Subscription %s: %v", klog.KObj(sub), err)
+		return clusters, nil
+	}
+
+	imagePlatforms := map[string][]string{}
+	for _, image := range images {
+		platforms, err := pl.inspector.GetPlatforms(ctx, image)
+		if err != nil {
+			pl.recorder.Event(sub, corev1.EventTypeWarning, "ImageInspectionFailed",
+				fmt.Sprintf("Failed to inspect platforms of image %s: %v", image, err))
+			continue
+		}
+		imagePlatforms[image] = platforms
+	}
+
+	var compatibleClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		compatible := true
+		for image, platforms := range imagePlatforms {
+			if !platform.IsCompatible(cluster.Status.NodePlatforms, platforms) {
+				compatible = false
+				pl.recorder.Event(sub, corev1.EventTypeWarning, "IncompatiblePlatform",
+					fmt.Sprintf("Skip cluster %s: image %s does not provide all the node platforms %v",
+						klog.KObj(cluster), image, cluster.Status.NodePlatforms))
+				break
+			}
+		}
+		if compatible {
+			compatibleClusters = append(compatibleClusters, cluster)
+		}
+	}
+	return compatibleClusters, nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcefit
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// nominationTTL is how long the preempted Subscriptions are kept off a cluster, since the available resources
+// reported by the cluster lag behind the removal of their workloads.
+const nominationTTL = 5 * time.Minute
+
+// nomination records the Subscriptions preempted from a cluster.
+type nomination struct {
+	preempted sets.String
+	expiry    time.Time
+}
+
+func (pl *ResourceFit) nominate(namespace string, victims []*appsapi.Base, now time.Time) {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	n, ok := pl.nominations[namespace]
+	if !ok || now.After(n.expiry) {
+		n = &nomination{preempted: sets.NewString()}
+		pl.nominations[namespace] = n
+	}
+	for _, victim := range victims {
+		n.preempted.Insert(victim.Labels[known.ConfigSubscriptionUIDLabel])
+	}
+	n.expiry = now.Add(nominationTTL)
+}
+
+// isPreempted tells whether the Subscription has been preempted from the cluster with the namespace recently.
+func (pl *ResourceFit) isPreempted(namespace string, sub *appsapi.Subscription, now time.Time) bool {
+	pl.lock.Lock()
+	defer pl.lock.Unlock()
+
+	n, ok := pl.nominations[namespace]
+	if !ok {
+		return false
+	}
+	if now.After(n.expiry) {
+		delete(pl.nominations, namespace)
+		return false
+	}
+	return n.preempted.Has(string(sub.UID))
+}
+
+// selectVictims returns the Bases of the Subscriptions with lower priorities in the cluster, whose removal makes
+// room for a single replica of each of the requests. Subscriptions with the lowest priorities are preempted first.
+// It returns nil if the requests still don't fit after preempting all of them.
+func (pl *ResourceFit) selectVictims(sub *appsapi.Subscription, cluster *clusterapi.ManagedCluster,
+	requests map[string]corev1.ResourceList) ([]*appsapi.Base, error) {
+	bases, err := pl.baseLister.Bases(cluster.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		base     *appsapi.Base
+		priority int32
+	}
+	var candidates []candidate
+	for _, base := range bases {
+		if base.DeletionTimestamp != nil {
+			continue
+		}
+		owner, err := pl.subLister.Subscriptions(base.Labels[known.ConfigSubscriptionNamespaceLabel]).
+			Get(base.Labels[known.ConfigSubscriptionNameLabel])
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if owner.UID == sub.UID || owner.Spec.Priority >= sub.Spec.Priority {
+			continue
+		}
+		candidates = append(candidates, candidate{base: base, priority: owner.Spec.Priority})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].base.Name < candidates[j].base.Name
+	})
+
+	available := cluster.Status.Available.DeepCopy()
+	var victims []*appsapi.Base
+	for _, c := range candidates {
+		freed, err := pl.getBaseRequests(c.base)
+		if err != nil {
+			klog.Warningf("failed to get resource requests of Base %s: %v", klog.KObj(c.base), err)
+			continue
+		}
+		if len(freed) == 0 {
+			continue
+		}
+		addResources(available, freed, 1)
+		victims = append(victims, c.base)
+		if _, fit := fitRequests(available, requests); fit {
+			return victims, nil
+		}
+	}
+	return nil, nil
+}
+
+// getBaseRequests returns the total resource requests of the workloads in the Base, with the divided replicas
+// of the Base, or the replicas in the feeds.
+func (pl *ResourceFit) getBaseRequests(base *appsapi.Base) (corev1.ResourceList, error) {
+	total := corev1.ResourceList{}
+	for _, feed := range base.Spec.Feeds {
+		if feed.Kind == "HelmChart" {
+			continue
+		}
+		manifests, err := utils.ListManifestsBySelector(pl.mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		if len(manifests) == 0 {
+			continue
+		}
+		request, err := utils.GetReplicaRequests(manifests[0].Template.Raw)
+		if err != nil {
+			return nil, err
+		}
+		if len(request) == 0 {
+			continue
+		}
+
+		replicas, found, err := interpreter.GetReplicas(manifests[0].Template.Raw)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			replicas = 1
+		}
+		for _, fr := range base.Spec.Replicas {
+			if fr.APIVersion == feed.APIVersion && fr.Kind == feed.Kind && fr.Namespace == feed.Namespace && fr.Name == feed.Name {
+				replicas = fr.Replicas
+				break
+			}
+		}
+		addResources(total, request, int64(replicas))
+	}
+	return total, nil
+}
+
+// addResources adds the resources multiplied by the times to the total.
+func addResources(total, resources corev1.ResourceList, times int64) {
+	for name, quantity := range resources {
+		value := total[name]
+		value.Add(*resource.NewMilliQuantity(quantity.MilliValue()*times, quantity.Format))
+		total[name] = value
+	}
+}
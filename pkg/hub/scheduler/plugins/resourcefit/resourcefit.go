@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcefit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "ResourceFit"
+
+// ResourceFit skips the clusters whose available resources could not hold a single replica of the workloads
+// in the feeds, and prefers the clusters with more resources left. Only newly selected clusters are filtered,
+// so that workloads are not removed from clusters running out of resources. Clusters not reporting available
+// resources are taken as fit. A cluster without enough resources is still selected if preempting the
+// Subscriptions with lower priorities in the cluster makes room for the Subscription.
+type ResourceFit struct {
+	mfstLister applisters.ManifestLister
+	baseLister applisters.BaseLister
+	subLister  applisters.SubscriptionLister
+	recorder   record.EventRecorder
+
+	// nominations are keyed by the namespaces of clusters
+	lock        sync.Mutex
+	nominations map[string]*nomination
+}
+
+var _ framework.FilterPlugin = &ResourceFit{}
+var _ framework.ScorePlugin = &ResourceFit{}
+var _ framework.ReservePlugin = &ResourceFit{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &ResourceFit{
+		mfstLister:  handle.ClusternetInformerFactory().Apps().V1alpha1().Manifests().Lister(),
+		baseLister:  handle.ClusternetInformerFactory().Apps().V1alpha1().Bases().Lister(),
+		subLister:   handle.ClusternetInformerFactory().Apps().V1alpha1().Subscriptions().Lister(),
+		recorder:    handle.EventRecorder(),
+		nominations: map[string]*nomination{},
+	}, nil
+}
+
+func (pl *ResourceFit) Name() string {
+	return Name
+}
+
+func (pl *ResourceFit) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	requests, err := getReplicaRequests(pl.mfstLister, state.Subscription.Spec.Feeds)
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+
+	sub := state.Subscription
+	now := time.Now()
+	var fitClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		if pl.isPreempted(cluster.Namespace, sub, now) {
+			pl.recorder.Event(sub, corev1.EventTypeNormal, "Preempted",
+				fmt.Sprintf("Skip cluster %s: preempted by Subscriptions with higher priorities", klog.KObj(cluster)))
+			continue
+		}
+		if len(requests) == 0 || state.IsScheduled(cluster.Namespace) || cluster.Status.Available == nil {
+			fitClusters = append(fitClusters, cluster)
+			continue
+		}
+		feed, fit := fitRequests(cluster.Status.Available, requests)
+		if fit {
+			fitClusters = append(fitClusters, cluster)
+			continue
+		}
+
+		if sub.Spec.PreemptionPolicy != appsapi.PreemptNever {
+			victims, err := pl.selectVictims(sub, cluster, requests)
+			if err != nil {
+				return nil, framework.AsStatus(err)
+			}
+			if len(victims) > 0 {
+				state.AddVictims(cluster.Namespace, victims...)
+				fitClusters = append(fitClusters, cluster)
+				continue
+			}
+		}
+		pl.recorder.Event(sub, corev1.EventTypeNormal, "InsufficientResources",
+			fmt.Sprintf("Skip cluster %s: no resources available for a single replica of %s", klog.KObj(cluster), feed))
+	}
+	return fitClusters, nil
+}
+
+// Reserve nominates the Subscription to the scheduled clusters where other Subscriptions get preempted.
+func (pl *ResourceFit) Reserve(ctx context.Context, state *framework.CycleState, clusters []framework.ClusterScore) *framework.Status {
+	now := time.Now()
+	for _, cluster := range clusters {
+		if victims := state.Victims[cluster.Cluster.Namespace]; len(victims) > 0 {
+			pl.nominate(cluster.Cluster.Namespace, victims, now)
+		}
+	}
+	return nil
+}
+
+// Score prefers the clusters with a larger ratio of available cpu and memory to the allocatable.
+func (pl *ResourceFit) Score(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]int64, *framework.Status) {
+	scores := make([]int64, len(clusters))
+	for i, cluster := range clusters {
+		scores[i] = getAvailableScore(cluster.Status.Allocatable, cluster.Status.Available)
+	}
+	return scores, nil
+}
+
+// getReplicaRequests returns the resource requests of a single replica of the workloads in the feeds,
+// keyed by the formatted feeds. Feeds without requests are skipped.
+func getReplicaRequests(mfstLister applisters.ManifestLister, feeds []appsapi.Feed) (map[string]corev1.ResourceList, error) {
+	requests := map[string]corev1.ResourceList{}
+	for _, feed := range feeds {
+		if feed.Kind == "HelmChart" {
+			continue
+		}
+		manifests, err := utils.ListManifestsBySelector(mfstLister, feed)
+		if err != nil {
+			return nil, err
+		}
+		if len(manifests) == 0 {
+			continue
+		}
+		request, err := utils.GetReplicaRequests(manifests[0].Template.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resource requests of %s: %v", utils.FormatFeed(feed), err)
+		}
+		if len(request) > 0 {
+			requests[utils.FormatFeed(feed)] = request
+		}
+	}
+	return requests, nil
+}
+
+// fitRequests tells whether the available resources could hold a single replica for each of the requests.
+// It returns the first feed that doesn't fit.
+func fitRequests(available corev1.ResourceList, requests map[string]corev1.ResourceList) (string, bool) {
+	for feed, request := range requests {
+		if utils.EstimateReplicas(available, request) == 0 {
+			return feed, false
+		}
+	}
+	return "", true
+}
+
+// getAvailableScore returns the average ratio of available cpu and memory to the allocatable,
+// scaled to [MinClusterScore, MaxClusterScore].
+func getAvailableScore(allocatable, available corev1.ResourceList) int64 {
+	var total, count int64
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		capacity, ok := allocatable[name]
+		if !ok || capacity.IsZero() {
+			continue
+		}
+		left := available[name]
+		score := left.MilliValue() * framework.MaxClusterScore / capacity.MilliValue()
+		if score < framework.MinClusterScore {
+			score = framework.MinClusterScore
+		}
+		if score > framework.MaxClusterScore {
+			score = framework.MaxClusterScore
+		}
+		total += score
+		count++
+	}
+	if count == 0 {
+		return framework.MinClusterScore
+	}
+	return total / count
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcefit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func resourceList(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+var webFeed = appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}
+
+func newManifest(feed appsapi.Feed, template string) *appsapi.Manifest {
+	return &appsapi.Manifest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployment-default-" + feed.Name,
+			Namespace: appsapi.ReservedNamespace,
+			Labels: map[string]string{
+				known.ConfigGroupLabel:     "apps",
+				known.ConfigVersionLabel:   "v1",
+				known.ConfigKindLabel:      feed.Kind,
+				known.ConfigNamespaceLabel: feed.Namespace,
+				known.ConfigNameLabel:      feed.Name,
+			},
+		},
+		Template: runtime.RawExtension{Raw: []byte(template)},
+	}
+}
+
+func newCluster(namespace string, available corev1.ResourceList) *clusterapi.ManagedCluster {
+	cluster := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace}}
+	cluster.Status.Available = available
+	return cluster
+}
+
+func newTestPlugin(t *testing.T, objs ...interface{}) *ResourceFit {
+	mfstIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	baseIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	subIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objs {
+		var err error
+		switch obj.(type) {
+		case *appsapi.Manifest:
+			err = mfstIndexer.Add(obj)
+		case *appsapi.Base:
+			err = baseIndexer.Add(obj)
+		case *appsapi.Subscription:
+			err = subIndexer.Add(obj)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &ResourceFit{
+		mfstLister:  applisters.NewManifestLister(mfstIndexer),
+		baseLister:  applisters.NewBaseLister(baseIndexer),
+		subLister:   applisters.NewSubscriptionLister(subIndexer),
+		recorder:    record.NewFakeRecorder(10),
+		nominations: map[string]*nomination{},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	manifest := newManifest(webFeed, `{"apiVersion":"apps/v1","kind":"Deployment",
+"metadata":{"name":"web","namespace":"default"},
+"spec":{"template":{"spec":{"containers":[{"name":"web","resources":{"requests":{"cpu":"1","memory":"1Gi"}}}]}}}}`)
+
+	clusters := []*clusterapi.ManagedCluster{
+		newCluster("fit", resourceList("2", "4Gi")),
+		newCluster("full", resourceList("500m", "4Gi")),
+		newCluster("full-scheduled", resourceList("500m", "4Gi")),
+		newCluster("unknown", nil),
+	}
+	state := &framework.CycleState{
+		Subscription:  &appsapi.Subscription{Spec: appsapi.SubscriptionSpec{Feeds: []appsapi.Feed{webFeed}}},
+		ExistingBases: []*appsapi.Base{{ObjectMeta: metav1.ObjectMeta{Namespace: "full-scheduled"}}},
+	}
+
+	got, status := newTestPlugin(t, manifest).Filter(context.TODO(), state, clusters)
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	var namespaces []string
+	for _, cluster := range got {
+		namespaces = append(namespaces, cluster.Namespace)
+	}
+	if want := []string{"fit", "full-scheduled", "unknown"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("Filter() = %v, want %v", namespaces, want)
+	}
+}
+
+func TestPreemption(t *testing.T) {
+	batchFeed := appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "batch"}
+	newSubscription := func(name string, priority int32, policy appsapi.PreemptionPolicy, feed appsapi.Feed) *appsapi.Subscription {
+		return &appsapi.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			Spec: appsapi.SubscriptionSpec{
+				Feeds:            []appsapi.Feed{feed},
+				Priority:         priority,
+				PreemptionPolicy: policy,
+			},
+		}
+	}
+	low := newSubscription("low", 0, "", batchFeed)
+	lowBase := &appsapi.Base{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "low",
+			Namespace: "full",
+			Labels: map[string]string{
+				known.ConfigSubscriptionNameLabel:      low.Name,
+				known.ConfigSubscriptionNamespaceLabel: low.Namespace,
+				known.ConfigSubscriptionUIDLabel:       string(low.UID),
+			},
+		},
+		Spec: appsapi.BaseSpec{Feeds: []appsapi.Feed{batchFeed}},
+	}
+	objs := []interface{}{
+		newManifest(webFeed, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default"},
+"spec":{"template":{"spec":{"containers":[{"name":"web","resources":{"requests":{"cpu":"1"}}}]}}}}`),
+		newManifest(batchFeed, `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"batch","namespace":"default"},
+"spec":{"replicas":2,"template":{"spec":{"containers":[{"name":"batch","resources":{"requests":{"cpu":"500m"}}}]}}}}`),
+		low,
+		lowBase,
+	}
+	cluster := newCluster("full", resourceList("500m", "4Gi"))
+
+	tests := []struct {
+		name        string
+		sub         *appsapi.Subscription
+		wantVictims int
+	}{
+		{
+			name:        "preempt lower priority",
+			sub:         newSubscription("high", 10, appsapi.PreemptLowerPriority, webFeed),
+			wantVictims: 1,
+		},
+		{
+			name:        "never preempt",
+			sub:         newSubscription("high", 10, appsapi.PreemptNever, webFeed),
+			wantVictims: 0,
+		},
+		{
+			name:        "same priority",
+			sub:         newSubscription("peer", 0, appsapi.PreemptLowerPriority, webFeed),
+			wantVictims: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := newTestPlugin(t, objs...)
+			state := &framework.CycleState{Subscription: tt.sub}
+
+			got, status := pl.Filter(context.TODO(), state, []*clusterapi.ManagedCluster{cluster})
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			if len(got) != tt.wantVictims || len(state.Victims[cluster.Namespace]) != tt.wantVictims {
+				t.Fatalf("expected %d victims and selected clusters, got %d victims and %d clusters",
+					tt.wantVictims, len(state.Victims[cluster.Namespace]), len(got))
+			}
+			if tt.wantVictims == 0 {
+				return
+			}
+
+			if status = pl.Reserve(context.TODO(), state, []framework.ClusterScore{{Cluster: cluster}}); !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			// the preempted Subscription is kept off the cluster, even if it is still scheduled there
+			lowState := &framework.CycleState{Subscription: low, ExistingBases: []*appsapi.Base{lowBase}}
+			if got, _ := pl.Filter(context.TODO(), lowState, []*clusterapi.ManagedCluster{cluster}); len(got) != 0 {
+				t.Errorf("expected the preempted Subscription to be kept off the cluster")
+			}
+		})
+	}
+}
+
+func TestGetAvailableScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocatable corev1.ResourceList
+		available   corev1.ResourceList
+		want        int64
+	}{
+		{
+			name:        "nothing reported",
+			allocatable: nil,
+			available:   nil,
+			want:        framework.MinClusterScore,
+		},
+		{
+			name:        "fully available",
+			allocatable: resourceList("4", "8Gi"),
+			available:   resourceList("4", "8Gi"),
+			want:        framework.MaxClusterScore,
+		},
+		{
+			name:        "average of cpu and memory",
+			allocatable: resourceList("4", "8Gi"),
+			available:   resourceList("1", "4Gi"),
+			want:        37,
+		},
+		{
+			name:        "overcommitted",
+			allocatable: resourceList("4", "8Gi"),
+			available:   resourceList("-1", "0"),
+			want:        framework.MinClusterScore,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getAvailableScore(tt.allocatable, tt.available); got != tt.want {
+				t.Errorf("getAvailableScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tainttoleration
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "TaintToleration"
+
+// TaintToleration skips the clusters with taints not tolerated by the Subscription.
+// Untolerated NoSchedule taints only prevent clusters from being newly selected, so that clusters could be cordoned
+// for maintenance, while untolerated NoExecute taints also remove the resources already distributed.
+type TaintToleration struct {
+	recorder record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &TaintToleration{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &TaintToleration{recorder: handle.EventRecorder()}, nil
+}
+
+func (pl *TaintToleration) Name() string {
+	return Name
+}
+
+func (pl *TaintToleration) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	var toleratedClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		taint, found := utils.FindUntoleratedTaint(cluster.Spec.Taints, state.Subscription.Spec.Tolerations,
+			getEffects(state.IsScheduled(cluster.Namespace))...)
+		if !found {
+			toleratedClusters = append(toleratedClusters, cluster)
+			continue
+		}
+		pl.recorder.Event(state.Subscription, corev1.EventTypeNormal, "UntoleratedTaint",
+			fmt.Sprintf("Skip cluster %s: taint %s is not tolerated", klog.KObj(cluster), taint.ToString()))
+	}
+	return toleratedClusters, nil
+}
+
+// getEffects returns the taint effects to be tolerated, depending on whether the cluster has been scheduled.
+func getEffects(scheduled bool) []corev1.TaintEffect {
+	if scheduled {
+		return []corev1.TaintEffect{corev1.TaintEffectNoExecute}
+	}
+	return []corev1.TaintEffect{corev1.TaintEffectNoExecute, corev1.TaintEffectNoSchedule}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tainttoleration
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+func TestFilter(t *testing.T) {
+	newCluster := func(namespace string, taints ...corev1.Taint) *clusterapi.ManagedCluster {
+		cluster := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace}}
+		cluster.Spec.Taints = taints
+		return cluster
+	}
+	noSchedule := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}
+	noExecute := corev1.Taint{Key: "broken", Effect: corev1.TaintEffectNoExecute}
+	tolerated := corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoExecute}
+
+	clusters := []*clusterapi.ManagedCluster{
+		newCluster("clean"),
+		newCluster("cordoned-new", noSchedule),
+		newCluster("cordoned-scheduled", noSchedule),
+		newCluster("broken-scheduled", noExecute),
+		newCluster("gpu", tolerated),
+	}
+	state := &framework.CycleState{
+		Subscription: &appsapi.Subscription{Spec: appsapi.SubscriptionSpec{Tolerations: []corev1.Toleration{
+			{Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoExecute},
+		}}},
+		ExistingBases: []*appsapi.Base{
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "cordoned-scheduled"}},
+			{ObjectMeta: metav1.ObjectMeta{Namespace: "broken-scheduled"}},
+		},
+	}
+
+	got, status := (&TaintToleration{recorder: record.NewFakeRecorder(10)}).Filter(context.TODO(), state, clusters)
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status.Message())
+	}
+	var namespaces []string
+	for _, cluster := range got {
+		namespaces = append(namespaces, cluster.Namespace)
+	}
+	if want := []string{"clean", "cordoned-scheduled", "gpu"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("Filter() = %v, want %v", namespaces, want)
+	}
+}
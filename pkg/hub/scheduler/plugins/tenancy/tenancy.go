@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenancy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Name is the name of the plugin
+const Name = "Tenancy"
+
+// Tenancy restricts the Subscriptions in the namespaces of Tenants to the clusters owned by the Tenants,
+// and keeps the current placement of the Subscriptions exceeding the quotas of the Tenants, which is reported
+// as condition TenantQuotaSatisfied in the status. Subscriptions in other namespaces are not restricted.
+type Tenancy struct {
+	tenantLister applisters.TenantLister
+	mfstLister   applisters.ManifestLister
+	recorder     record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &Tenancy{}
+var _ framework.ReservePlugin = &Tenancy{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &Tenancy{
+		tenantLister: handle.ClusternetInformerFactory().Apps().V1alpha1().Tenants().Lister(),
+		mfstLister:   handle.ClusternetInformerFactory().Apps().V1alpha1().Manifests().Lister(),
+		recorder:     handle.EventRecorder(),
+	}, nil
+}
+
+func (pl *Tenancy) Name() string {
+	return Name
+}
+
+func (pl *Tenancy) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	tenants, err := pl.getTenants(state.Subscription)
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+	if len(tenants) == 0 {
+		return clusters, nil
+	}
+
+	var ownedClusters []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		owned, err := utils.IsOwnedByTenants(tenants, cluster)
+		if err != nil {
+			return nil, framework.AsStatus(err)
+		}
+		if owned {
+			ownedClusters = append(ownedClusters, cluster)
+		}
+	}
+	if skipped := len(clusters) - len(ownedClusters); skipped > 0 {
+		pl.recorder.Event(state.Subscription, corev1.EventTypeNormal, "TenantIsolation",
+			fmt.Sprintf("Skip %d clusters not owned by Tenants %s", skipped, tenantNames(tenants)))
+	}
+	return ownedClusters, nil
+}
+
+// Reserve fails the scheduling if the Subscription exceeds the quota of its Tenants on the scheduled clusters.
+func (pl *Tenancy) Reserve(ctx context.Context, state *framework.CycleState, clusters []framework.ClusterScore) *framework.Status {
+	sub := state.Subscription
+	tenants, err := pl.getTenants(sub)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if len(tenants) == 0 {
+		apimeta.RemoveStatusCondition(&state.Status.Conditions, appsapi.SubscriptionTenantQuotaSatisfied)
+		return nil
+	}
+
+	quota := utils.GetTenantQuota(tenants)
+	var violations []string
+	if quota.MaxClustersPerSubscription != nil && int32(len(clusters)) > *quota.MaxClustersPerSubscription {
+		violations = append(violations, fmt.Sprintf("%d clusters are selected, exceeding the quota of %d clusters",
+			len(clusters), *quota.MaxClustersPerSubscription))
+	}
+	if quota.MaxReplicasPerSubscription != nil {
+		replicas, err := pl.getTotalReplicas(sub, len(clusters))
+		if err != nil {
+			return framework.AsStatus(err)
+		}
+		if replicas > int64(*quota.MaxReplicasPerSubscription) {
+			violations = append(violations, fmt.Sprintf("%d replicas are requested, exceeding the quota of %d replicas",
+				replicas, *quota.MaxReplicasPerSubscription))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               appsapi.SubscriptionTenantQuotaSatisfied,
+		Status:             metav1.ConditionTrue,
+		Reason:             "WithinQuota",
+		Message:            fmt.Sprintf("Subscription fits in the quota of Tenants %s", tenantNames(tenants)),
+		ObservedGeneration: sub.Generation,
+	}
+	if len(violations) == 0 {
+		apimeta.SetStatusCondition(&state.Status.Conditions, condition)
+		return nil
+	}
+
+	condition.Status = metav1.ConditionFalse
+	condition.Reason = "QuotaExceeded"
+	condition.Message = strings.Join(violations, "; ")
+	apimeta.SetStatusCondition(&state.Status.Conditions, condition)
+	pl.recorder.Event(sub, corev1.EventTypeWarning, "TenantQuotaExceeded", condition.Message)
+	return framework.NewStatus(framework.Unschedulable, condition.Message)
+}
+
+func (pl *Tenancy) getTenants(sub *appsapi.Subscription) ([]*appsapi.Tenant, error) {
+	tenants, err := pl.tenantLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return utils.TenantsOfNamespace(tenants, sub.Namespace), nil
+}
+
+// getTotalReplicas returns the replicas of the workloads in the feeds summed up across the clusters.
+// Replicas are divided across the clusters with the Dividing scheduling strategy, and copied to each
+// cluster otherwise.
+func (pl *Tenancy) getTotalReplicas(sub *appsapi.Subscription, clusters int) (int64, error) {
+	var replicas int64
+	for _, feed := range sub.Spec.Feeds {
+		if feed.Kind == "HelmChart" {
+			continue
+		}
+		manifests, err := utils.ListManifestsBySelector(pl.mfstLister, feed)
+		if err != nil {
+			return 0, err
+		}
+		for _, manifest := range manifests {
+			r, found, err := utils.GetReplicas(manifest.Template.Raw)
+			if err != nil {
+				return 0, fmt.Errorf("failed to get replicas of %s: %v", utils.FormatFeed(feed), err)
+			}
+			if found {
+				replicas += int64(r)
+			}
+		}
+	}
+	if sub.Spec.SchedulingStrategy != appsapi.DividingSchedulingStrategyType {
+		replicas *= int64(clusters)
+	}
+	return replicas, nil
+}
+
+func tenantNames(tenants []*appsapi.Tenant) string {
+	names := make([]string, 0, len(tenants))
+	for _, tenant := range tenants {
+		names = append(names, tenant.Name)
+	}
+	return strings.Join(names, ",")
+}
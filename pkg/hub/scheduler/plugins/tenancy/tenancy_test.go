@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func newPlugin(t *testing.T, quota *appsapi.TenantQuota) *Tenancy {
+	tenantIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := tenantIndexer.Add(&appsapi.Tenant{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: appsapi.TenantSpec{
+			Namespaces:      []string{"team-a"},
+			ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "team-a"}},
+			Quota:           quota,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mfstIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := mfstIndexer.Add(&appsapi.Manifest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deployments-team-a-web",
+			Namespace: appsapi.ReservedNamespace,
+			Labels: map[string]string{
+				known.ConfigGroupLabel:     "apps",
+				known.ConfigVersionLabel:   "v1",
+				known.ConfigKindLabel:      "Deployment",
+				known.ConfigNamespaceLabel: "team-a",
+				known.ConfigNameLabel:      "web",
+			},
+		},
+		Template: runtime.RawExtension{
+			Raw: []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"team-a"},"spec":{"replicas":4}}`),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &Tenancy{
+		tenantLister: applisters.NewTenantLister(tenantIndexer),
+		mfstLister:   applisters.NewManifestLister(mfstIndexer),
+		recorder:     record.NewFakeRecorder(10),
+	}
+}
+
+func newSubscription(namespace string, strategy appsapi.SchedulingStrategyType) *appsapi.Subscription {
+	return &appsapi.Subscription{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: namespace},
+		Spec: appsapi.SubscriptionSpec{
+			SchedulingStrategy: strategy,
+			Feeds: []appsapi.Feed{
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "team-a", Name: "web"},
+			},
+		},
+	}
+}
+
+func newCluster(name, tenant string) *clusterapi.ManagedCluster {
+	return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: name, Namespace: name, Labels: map[string]string{"tenant": tenant},
+	}}
+}
+
+func TestFilter(t *testing.T) {
+	pl := newPlugin(t, nil)
+	clusters := []*clusterapi.ManagedCluster{newCluster("a1", "team-a"), newCluster("b1", "team-b"), newCluster("a2", "team-a")}
+
+	tests := []struct {
+		name      string
+		namespace string
+		wantCount int
+	}{
+		{
+			name:      "tenant namespace",
+			namespace: "team-a",
+			wantCount: 2,
+		},
+		{
+			name:      "namespace without tenants",
+			namespace: "default",
+			wantCount: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &framework.CycleState{
+				Subscription: newSubscription(tt.namespace, appsapi.ReplicationSchedulingStrategyType),
+				Status:       &appsapi.SubscriptionStatus{},
+			}
+			got, status := pl.Filter(context.TODO(), state, clusters)
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("expected %d clusters left, got %d", tt.wantCount, len(got))
+			}
+		})
+	}
+}
+
+func TestReserve(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	scores := []framework.ClusterScore{
+		{Cluster: newCluster("a1", "team-a")},
+		{Cluster: newCluster("a2", "team-a")},
+		{Cluster: newCluster("a3", "team-a")},
+	}
+
+	tests := []struct {
+		name       string
+		quota      *appsapi.TenantQuota
+		strategy   appsapi.SchedulingStrategyType
+		wantCode   framework.Code
+		wantStatus metav1.ConditionStatus
+	}{
+		{
+			name:       "no quota",
+			wantCode:   framework.Success,
+			wantStatus: metav1.ConditionTrue,
+		},
+		{
+			name:       "too many clusters",
+			quota:      &appsapi.TenantQuota{MaxClustersPerSubscription: int32Ptr(2)},
+			wantCode:   framework.Unschedulable,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "too many replicas copied to clusters",
+			quota:      &appsapi.TenantQuota{MaxReplicasPerSubscription: int32Ptr(10)},
+			strategy:   appsapi.ReplicationSchedulingStrategyType,
+			wantCode:   framework.Unschedulable,
+			wantStatus: metav1.ConditionFalse,
+		},
+		{
+			name:       "replicas divided across clusters",
+			quota:      &appsapi.TenantQuota{MaxReplicasPerSubscription: int32Ptr(10)},
+			strategy:   appsapi.DividingSchedulingStrategyType,
+			wantCode:   framework.Success,
+			wantStatus: metav1.ConditionTrue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := newPlugin(t, tt.quota)
+			state := &framework.CycleState{
+				Subscription: newSubscription("team-a", tt.strategy),
+				Status:       &appsapi.SubscriptionStatus{},
+			}
+			status := pl.Reserve(context.TODO(), state, scores)
+			if status.Code() != tt.wantCode {
+				t.Errorf("expected code %v, got %v: %s", tt.wantCode, status.Code(), status.Message())
+			}
+			condition := apimeta.FindStatusCondition(state.Status.Conditions, appsapi.SubscriptionTenantQuotaSatisfied)
+			if condition == nil || condition.Status != tt.wantStatus {
+				t.Errorf("expected condition %s to be %s, got %v", appsapi.SubscriptionTenantQuotaSatisfied, tt.wantStatus, condition)
+			}
+		})
+	}
+}
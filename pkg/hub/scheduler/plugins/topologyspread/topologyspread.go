@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topologyspread
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+// Name is the name of the plugin
+const Name = "TopologySpread"
+
+// TopologySpread enforces the topologySpreadConstraints of Subscriptions. Constraints with DoNotSchedule skip
+// the clusters exceeding maxSkew, and the ones with ScheduleAnyway prefer the clusters in less crowded domains.
+// Without any constraints, clusters in the regions with fewer feasible clusters are preferred.
+type TopologySpread struct {
+	recorder record.EventRecorder
+}
+
+var _ framework.FilterPlugin = &TopologySpread{}
+var _ framework.ScorePlugin = &TopologySpread{}
+
+// New initializes the plugin.
+func New(handle framework.Handle) (framework.Plugin, error) {
+	return &TopologySpread{recorder: handle.EventRecorder()}, nil
+}
+
+func (pl *TopologySpread) Name() string {
+	return Name
+}
+
+// Filter applies the DoNotSchedule constraints repeatedly until all of them are satisfied,
+// since skipping clusters for one constraint may break another.
+func (pl *TopologySpread) Filter(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]*clusterapi.ManagedCluster, *framework.Status) {
+	var constraints []appsapi.TopologySpreadConstraint
+	for _, constraint := range state.Subscription.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != appsapi.ScheduleAnyway {
+			constraints = append(constraints, constraint)
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, constraint := range constraints {
+			spread := spreadClusters(clusters, constraint, state.IsScheduled)
+			if len(spread) == len(clusters) {
+				continue
+			}
+			pl.recordSkipped(state.Subscription, clusters, spread, constraint)
+			clusters = spread
+			changed = true
+		}
+	}
+	return clusters, nil
+}
+
+// Score averages the spread scores over the topology keys of the ScheduleAnyway constraints.
+func (pl *TopologySpread) Score(ctx context.Context, state *framework.CycleState,
+	clusters []*clusterapi.ManagedCluster) ([]int64, *framework.Status) {
+	var topologyKeys []string
+	for _, constraint := range state.Subscription.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable == appsapi.ScheduleAnyway {
+			topologyKeys = append(topologyKeys, constraint.TopologyKey)
+		}
+	}
+	if len(state.Subscription.Spec.TopologySpreadConstraints) == 0 {
+		topologyKeys = []string{corev1.LabelTopologyRegion}
+	}
+
+	scores := make([]int64, len(clusters))
+	if len(topologyKeys) == 0 {
+		return scores, nil
+	}
+	for _, topologyKey := range topologyKeys {
+		for i, score := range getSpreadScores(clusters, topologyKey) {
+			scores[i] += score
+		}
+	}
+	for i := range scores {
+		scores[i] /= int64(len(topologyKeys))
+	}
+	return scores, nil
+}
+
+func (pl *TopologySpread) recordSkipped(sub *appsapi.Subscription, clusters, spread []*clusterapi.ManagedCluster,
+	constraint appsapi.TopologySpreadConstraint) {
+	kept := map[*clusterapi.ManagedCluster]bool{}
+	for _, cluster := range spread {
+		kept[cluster] = true
+	}
+	for _, cluster := range clusters {
+		if kept[cluster] {
+			continue
+		}
+		pl.recorder.Event(sub, corev1.EventTypeNormal, "TopologySpread",
+			fmt.Sprintf("Skip cluster %s: topology spread constraint on %s with maxSkew %d is not satisfied",
+				klog.KObj(cluster), constraint.TopologyKey, constraint.MaxSkew))
+	}
+}
+
+// spreadClusters skips the clusters without the topology key, and keeps at most maxSkew more clusters
+// in each domain than the least crowded one. Clusters already scheduled are kept first, and then the
+// ones with smaller namespaces, so that the result is stable. The order of clusters is retained.
+func spreadClusters(clusters []*clusterapi.ManagedCluster, constraint appsapi.TopologySpreadConstraint,
+	isScheduled func(namespace string) bool) []*clusterapi.ManagedCluster {
+	domains := map[string][]*clusterapi.ManagedCluster{}
+	for _, cluster := range clusters {
+		if domain, ok := cluster.Labels[constraint.TopologyKey]; ok {
+			domains[domain] = append(domains[domain], cluster)
+		}
+	}
+
+	minCount := -1
+	for _, members := range domains {
+		if minCount < 0 || len(members) < minCount {
+			minCount = len(members)
+		}
+	}
+
+	kept := map[*clusterapi.ManagedCluster]bool{}
+	for _, members := range domains {
+		sort.SliceStable(members, func(i, j int) bool {
+			si, sj := isScheduled(members[i].Namespace), isScheduled(members[j].Namespace)
+			if si != sj {
+				return si
+			}
+			return members[i].Namespace < members[j].Namespace
+		})
+		for i, cluster := range members {
+			if i >= minCount+int(constraint.MaxSkew) {
+				break
+			}
+			kept[cluster] = true
+		}
+	}
+
+	var result []*clusterapi.ManagedCluster
+	for _, cluster := range clusters {
+		if kept[cluster] {
+			result = append(result, cluster)
+		}
+	}
+	return result
+}
+
+// getSpreadScores scores the clusters inversely proportional to the number of clusters in the same domain,
+// i.e. the value of the topology key. Clusters without the key are taken as a domain of their own.
+func getSpreadScores(clusters []*clusterapi.ManagedCluster, topologyKey string) []int64 {
+	counts := map[string]int64{}
+	for _, cluster := range clusters {
+		if domain, ok := cluster.Labels[topologyKey]; ok {
+			counts[domain]++
+		}
+	}
+	countOf := func(cluster *clusterapi.ManagedCluster) int64 {
+		if domain, ok := cluster.Labels[topologyKey]; ok {
+			return counts[domain]
+		}
+		return 1
+	}
+
+	var minCount int64
+	for _, cluster := range clusters {
+		if count := countOf(cluster); minCount == 0 || count < minCount {
+			minCount = count
+		}
+	}
+
+	scores := make([]int64, len(clusters))
+	for i, cluster := range clusters {
+		scores[i] = framework.MaxClusterScore * minCount / countOf(cluster)
+	}
+	return scores
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topologyspread
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+)
+
+func newCluster(namespace string, labels map[string]string) *clusterapi.ManagedCluster {
+	return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace, Labels: labels}}
+}
+
+func TestFilter(t *testing.T) {
+	clusters := []*clusterapi.ManagedCluster{
+		newCluster("eu-1", map[string]string{"region": "eu", "zone": "eu-a"}),
+		newCluster("eu-2", map[string]string{"region": "eu", "zone": "eu-a"}),
+		newCluster("eu-3", map[string]string{"region": "eu", "zone": "eu-b"}),
+		newCluster("eu-4", map[string]string{"region": "eu", "zone": "eu-b"}),
+		newCluster("us-1", map[string]string{"region": "us", "zone": "us-a"}),
+		newCluster("unlabeled", map[string]string{}),
+	}
+
+	tests := []struct {
+		name          string
+		constraints   []appsapi.TopologySpreadConstraint
+		existingBases []string
+		want          []string
+	}{
+		{
+			name: "no constraints",
+			want: []string{"eu-1", "eu-2", "eu-3", "eu-4", "us-1", "unlabeled"},
+		},
+		{
+			name: "schedule anyway",
+			constraints: []appsapi.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "region", WhenUnsatisfiable: appsapi.ScheduleAnyway},
+			},
+			want: []string{"eu-1", "eu-2", "eu-3", "eu-4", "us-1", "unlabeled"},
+		},
+		{
+			name: "spread across regions",
+			constraints: []appsapi.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "region", WhenUnsatisfiable: appsapi.DoNotSchedule},
+			},
+			want: []string{"eu-1", "eu-2", "us-1"},
+		},
+		{
+			name: "scheduled clusters are kept first",
+			constraints: []appsapi.TopologySpreadConstraint{
+				{MaxSkew: 2, TopologyKey: "region"},
+			},
+			existingBases: []string{"eu-4"},
+			want:          []string{"eu-1", "eu-2", "eu-4", "us-1"},
+		},
+		{
+			name: "all the constraints get satisfied",
+			constraints: []appsapi.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "zone"},
+				{MaxSkew: 1, TopologyKey: "region"},
+			},
+			want: []string{"eu-1", "eu-2", "us-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &framework.CycleState{
+				Subscription: &appsapi.Subscription{
+					Spec: appsapi.SubscriptionSpec{TopologySpreadConstraints: tt.constraints},
+				},
+			}
+			for _, namespace := range tt.existingBases {
+				state.ExistingBases = append(state.ExistingBases, &appsapi.Base{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}})
+			}
+
+			got, status := (&TopologySpread{recorder: record.NewFakeRecorder(10)}).Filter(context.TODO(), state, clusters)
+			if !status.IsSuccess() {
+				t.Fatalf("unexpected status: %v", status.Message())
+			}
+			var namespaces []string
+			for _, cluster := range got {
+				namespaces = append(namespaces, cluster.Namespace)
+			}
+			if !reflect.DeepEqual(namespaces, tt.want) {
+				t.Errorf("Filter() = %v, want %v", namespaces, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSpreadScores(t *testing.T) {
+	newCluster := func(region string) *clusterapi.ManagedCluster {
+		cluster := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+		if region != "" {
+			cluster.Labels[corev1.LabelTopologyRegion] = region
+		}
+		return cluster
+	}
+
+	tests := []struct {
+		name    string
+		regions []string
+		want    []int64
+	}{
+		{
+			name:    "no clusters",
+			regions: nil,
+			want:    []int64{},
+		},
+		{
+			name:    "evenly spread",
+			regions: []string{"eu", "us"},
+			want:    []int64{100, 100},
+		},
+		{
+			name:    "crowded region gets lower scores",
+			regions: []string{"eu", "eu", "us", "eu", "us"},
+			want:    []int64{66, 66, 100, 66, 100},
+		},
+		{
+			name:    "clusters without regions are domains of their own",
+			regions: []string{"eu", "eu", "", ""},
+			want:    []int64{50, 50, 100, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var clusters []*clusterapi.ManagedCluster
+			for _, region := range tt.regions {
+				clusters = append(clusters, newCluster(region))
+			}
+			if got := getSpreadScores(clusters, corev1.LabelTopologyRegion); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getSpreadScores() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
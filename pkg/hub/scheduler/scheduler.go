@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scheduler selects the target clusters of Subscriptions with pluggable Filter, Score and Bind plugins.
+//
+// Downstream projects could compile in their own plugins by registering them before clusternet-hub starts,
+//
+//	func init() {
+//		scheduler.RegisterPlugin("MyPlugin", myplugin.New)
+//	}
+//
+// and the registered plugins run after the built-in ones at each extension point.
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/clusternet/clusternet/pkg/features"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/framework"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/apiavailability"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/clusteraffinity"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/clustereviction"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/dataresidency"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/imageplatform"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/resourcefit"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/tainttoleration"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/tenancy"
+	"github.com/clusternet/clusternet/pkg/hub/scheduler/plugins/topologyspread"
+)
+
+var (
+	outOfTreeLock     sync.Mutex
+	outOfTreeRegistry = framework.Registry{}
+	outOfTreePlugins  []string
+)
+
+// RegisterPlugin registers an out-of-tree plugin, which gets enabled after the built-in ones.
+// It should be called before clusternet-hub starts, and panics on duplicated names.
+func RegisterPlugin(name string, factory framework.PluginFactory) {
+	outOfTreeLock.Lock()
+	defer outOfTreeLock.Unlock()
+
+	if _, ok := NewInTreeRegistry()[name]; ok {
+		panic(fmt.Sprintf("scheduling plugin %s conflicts with a built-in one", name))
+	}
+	if err := outOfTreeRegistry.Register(name, factory); err != nil {
+		panic(err)
+	}
+	outOfTreePlugins = append(outOfTreePlugins, name)
+}
+
+// NewInTreeRegistry returns the registry of the built-in plugins.
+func NewInTreeRegistry() framework.Registry {
+	return framework.Registry{
+		clusteraffinity.Name: clusteraffinity.New,
+		clustereviction.Name: clustereviction.New,
+		apiavailability.Name: apiavailability.New,
+		imageplatform.Name:   imageplatform.New,
+		dataresidency.Name:   dataresidency.New,
+		tainttoleration.Name: tainttoleration.New,
+		resourcefit.Name:     resourcefit.New,
+		topologyspread.Name:  topologyspread.New,
+		tenancy.Name:         tenancy.New,
+	}
+}
+
+// DefaultPlugins returns the names of the built-in plugins enabled by default, in the order they run.
+func DefaultPlugins() []string {
+	plugins := []string{
+		clusteraffinity.Name,
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.MultiTenancy) {
+		// fail the Subscriptions exceeding the quotas before any clusters get reserved by other plugins
+		plugins = append(plugins, tenancy.Name)
+	}
+	plugins = append(plugins,
+		clustereviction.Name,
+		apiavailability.Name,
+	)
+	if utilfeature.DefaultFeatureGate.Enabled(features.ImagePlatformCheck) {
+		plugins = append(plugins, imageplatform.Name)
+	}
+	if utilfeature.DefaultFeatureGate.Enabled(features.DataResidency) {
+		plugins = append(plugins, dataresidency.Name)
+	}
+	plugins = append(plugins,
+		tainttoleration.Name,
+		resourcefit.Name,
+		topologyspread.Name,
+	)
+	return plugins
+}
+
+// NewFramework returns a framework with the default plugins and all the registered out-of-tree ones.
+// It should be called before clusternetInformerFactory starts.
+func NewFramework(clusternetClient clusternetclientset.Interface, clusternetInformerFactory clusternetinformers.SharedInformerFactory,
+	recorder record.EventRecorder) (*framework.Framework, error) {
+	outOfTreeLock.Lock()
+	defer outOfTreeLock.Unlock()
+
+	registry := NewInTreeRegistry()
+	if err := registry.Merge(outOfTreeRegistry); err != nil {
+		return nil, err
+	}
+	plugins := append(DefaultPlugins(), outOfTreePlugins...)
+	return framework.NewFramework(clusternetClient, clusternetInformerFactory, recorder, registry, plugins)
+}
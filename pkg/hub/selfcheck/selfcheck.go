@@ -0,0 +1,497 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selfcheck validates the environment prerequisites of clusternet-hub,
+// so that misconfigured installations fail fast with actionable messages.
+package selfcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	crdclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/featuregate"
+
+	"github.com/clusternet/clusternet/pkg/features"
+	"github.com/clusternet/clusternet/pkg/hub/options"
+)
+
+// Severity indicates how serious a Finding is.
+type Severity string
+
+const (
+	// SeverityError means clusternet-hub won't work as expected.
+	SeverityError Severity = "Error"
+	// SeverityWarning means some configurations are probably not what the user wants.
+	SeverityWarning Severity = "Warning"
+)
+
+// Finding is a problem found by the self-check.
+type Finding struct {
+	// Check is the name of the check that reports the finding
+	Check string
+	// Severity is how serious the finding is
+	Severity Severity
+	// Message describes the problem
+	Message string
+	// Suggestion describes how to fix the problem
+	Suggestion string
+}
+
+const (
+	defaultDialTimeout = 5 * time.Second
+
+	// names of the checks
+	checkCRDs           = "CRDs"
+	checkAPIServices    = "APIServices"
+	checkRBAC           = "RBAC"
+	checkFeatureGates   = "FeatureGates"
+	checkWebhooks       = "Webhooks"
+	checkServiceAccount = "ServiceAccount"
+)
+
+var apiServiceGVR = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// Checker validates the environment prerequisites of clusternet-hub.
+type Checker struct {
+	kubeClient    kubernetes.Interface
+	crdClient     crdclientset.Interface
+	dynamicClient dynamic.Interface
+
+	opts *options.HubServerOptions
+	// serviceAccount is the service account that clusternet-hub runs as, in the format of "<namespace>/<name>"
+	serviceAccount string
+	featureEnabled func(featuregate.Feature) bool
+}
+
+// NewChecker returns a new Checker.
+func NewChecker(config *rest.Config, opts *options.HubServerOptions, serviceAccount string) (*Checker, error) {
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	crdClient, err := crdclientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checker{
+		kubeClient:     kubeClient,
+		crdClient:      crdClient,
+		dynamicClient:  dynamicClient,
+		opts:           opts,
+		serviceAccount: serviceAccount,
+		featureEnabled: utilfeature.DefaultFeatureGate.Enabled,
+	}, nil
+}
+
+// Run runs all the checks and returns the findings.
+func (c *Checker) Run(ctx context.Context) []Finding {
+	var findings []Finding
+	findings = append(findings, validateFeatureGates(c.featureEnabled, c.opts)...)
+	findings = append(findings, c.checkCRDs(ctx)...)
+	findings = append(findings, c.checkAPIServices(ctx)...)
+	findings = append(findings, c.checkRBAC(ctx)...)
+	findings = append(findings, c.checkWebhooks()...)
+	return findings
+}
+
+// checkCRDs checks whether the required CRDs are installed and established.
+func (c *Checker) checkCRDs(ctx context.Context) []Finding {
+	crds := []string{
+		"clusterregistrationrequests.clusters.clusternet.io",
+		"managedclusters.clusters.clusternet.io",
+	}
+	if c.opts.RequireProxyGrants {
+		crds = append(crds, "grants.clusters.clusternet.io")
+	}
+	if c.featureEnabled(features.Deployer) || c.featureEnabled(features.ShadowAPI) {
+		crds = append(crds,
+			"bases.apps.clusternet.io",
+			"descriptions.apps.clusternet.io",
+			"gitrepositories.apps.clusternet.io",
+			"globalizations.apps.clusternet.io",
+			"helmcharts.apps.clusternet.io",
+			"helmreleases.apps.clusternet.io",
+			"kustomizations.apps.clusternet.io",
+			"localizations.apps.clusternet.io",
+			"manifests.apps.clusternet.io",
+			"ocirepositories.apps.clusternet.io",
+			"subscriptions.apps.clusternet.io",
+		)
+	}
+	if c.featureEnabled(features.DataResidency) {
+		crds = append(crds, "residencypolicies.apps.clusternet.io")
+	}
+	if c.featureEnabled(features.StatusAggregation) {
+		crds = append(crds, "statusaggregations.apps.clusternet.io")
+	}
+	if c.featureEnabled(features.AgentUpgrade) {
+		crds = append(crds, "agentupgradeplans.clusters.clusternet.io")
+	}
+	if c.featureEnabled(features.MultiTenancy) {
+		crds = append(crds, "tenants.apps.clusternet.io")
+	}
+
+	var findings []Finding
+	for _, name := range crds {
+		crd, err := c.crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			findings = append(findings, Finding{
+				Check:      checkCRDs,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("failed to get CRD %s: %v", name, err),
+				Suggestion: "apply the CRDs under manifests/crds",
+			})
+			continue
+		}
+		if !isCRDEstablished(crd) {
+			findings = append(findings, Finding{
+				Check:      checkCRDs,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("CRD %s is not established yet", name),
+				Suggestion: fmt.Sprintf("check the conditions with \"kubectl describe crd %s\"", name),
+			})
+		}
+	}
+	return findings
+}
+
+func isCRDEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkAPIServices checks whether the APIServices served by clusternet-hub are registered and available.
+func (c *Checker) checkAPIServices(ctx context.Context) []Finding {
+	apiServices := []string{"v1alpha1.proxies.clusternet.io"}
+	if c.featureEnabled(features.ShadowAPI) {
+		apiServices = append(apiServices, "v1alpha1.shadow")
+	}
+
+	var findings []Finding
+	for _, name := range apiServices {
+		apiService, err := c.dynamicClient.Resource(apiServiceGVR).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			finding := Finding{
+				Check:      checkAPIServices,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("failed to get APIService %s: %v", name, err),
+				Suggestion: "apply deploy/hub/clusternet_hub_apiservice.yaml",
+			}
+			if !apierrors.IsNotFound(err) {
+				finding.Suggestion = "check the permissions to get apiservices.apiregistration.k8s.io"
+			}
+			findings = append(findings, finding)
+			continue
+		}
+
+		available, message := getAPIServiceAvailability(apiService)
+		if !available {
+			findings = append(findings, Finding{
+				Check:      checkAPIServices,
+				Severity:   SeverityError,
+				Message:    fmt.Sprintf("APIService %s is not available: %s", name, message),
+				Suggestion: "check whether the Service of clusternet-hub is reachable from kube-apiserver",
+			})
+		}
+	}
+	return findings
+}
+
+func getAPIServiceAvailability(apiService *unstructured.Unstructured) (bool, string) {
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		return condition["status"] == string(metav1.ConditionTrue), message
+	}
+	return false, "no Available condition is reported"
+}
+
+type permission struct {
+	group       string
+	resource    string
+	subresource string
+	verbs       []string
+	namespace   string
+}
+
+// checkRBAC checks whether the service account of clusternet-hub has the required permissions.
+func (c *Checker) checkRBAC(ctx context.Context) []Finding {
+	namespace, name, err := parseServiceAccount(c.serviceAccount)
+	if err != nil {
+		return []Finding{{
+			Check:      checkServiceAccount,
+			Severity:   SeverityError,
+			Message:    err.Error(),
+			Suggestion: "specify the service account in the format of <namespace>/<name>",
+		}}
+	}
+
+	commonVerbs := []string{"get", "list", "watch", "create", "update", "delete"}
+	permissions := []permission{
+		{group: "clusters.clusternet.io", resource: "clusterregistrationrequests", verbs: commonVerbs},
+		{group: "clusters.clusternet.io", resource: "managedclusters", verbs: commonVerbs},
+		{group: "", resource: "namespaces", verbs: []string{"get", "list", "watch", "create"}},
+		{group: "", resource: "serviceaccounts", verbs: []string{"get", "list", "watch", "create"}},
+		{group: "", resource: "secrets", verbs: []string{"get", "list", "watch", "update"}},
+		{group: "", resource: "events", verbs: []string{"create", "patch"}},
+		{group: "coordination.k8s.io", resource: "leases", verbs: []string{"get", "list", "watch"}},
+		{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: []string{"get", "create", "update"}},
+		{group: "rbac.authorization.k8s.io", resource: "roles", verbs: []string{"get", "create", "update"}},
+		{group: "rbac.authorization.k8s.io", resource: "rolebindings", verbs: []string{"get", "create", "update"}},
+		{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verbs: []string{"get", "list", "watch"}},
+	}
+	if c.opts.RequireProxyGrants {
+		permissions = append(permissions,
+			permission{group: "clusters.clusternet.io", resource: "grants", verbs: []string{"get", "list", "watch"}})
+	}
+	if c.featureEnabled(features.Deployer) || c.featureEnabled(features.ShadowAPI) {
+		for _, resource := range []string{"bases", "descriptions", "gitrepositories", "globalizations", "helmcharts", "helmreleases",
+			"kustomizations", "localizations", "manifests", "ocirepositories", "subscriptions"} {
+			permissions = append(permissions,
+				permission{group: "apps.clusternet.io", resource: resource, verbs: commonVerbs})
+		}
+	}
+	if c.featureEnabled(features.DataResidency) {
+		permissions = append(permissions,
+			permission{group: "apps.clusternet.io", resource: "residencypolicies", verbs: []string{"get", "list", "watch"}})
+	}
+	if c.featureEnabled(features.MultiTenancy) {
+		permissions = append(permissions,
+			permission{group: "apps.clusternet.io", resource: "tenants", verbs: []string{"get", "list", "watch"}})
+	}
+	if c.featureEnabled(features.RBACPropagation) {
+		permissions = append(permissions,
+			permission{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: []string{"list", "watch"}},
+			permission{group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", verbs: []string{"list", "watch"}})
+	}
+	if c.featureEnabled(features.StatusAggregation) {
+		permissions = append(permissions,
+			permission{group: "apps.clusternet.io", resource: "statusaggregations", verbs: []string{"get", "list", "watch"}})
+	}
+	if c.featureEnabled(features.AgentUpgrade) {
+		permissions = append(permissions,
+			permission{group: "clusters.clusternet.io", resource: "agentupgradeplans", verbs: []string{"get", "list", "watch", "update"}})
+	}
+	if c.featureEnabled(features.CertificateSigning) {
+		permissions = append(permissions,
+			permission{group: "certificates.k8s.io", resource: "certificatesigningrequests", verbs: []string{"get", "list", "watch"}},
+			permission{group: "certificates.k8s.io", resource: "certificatesigningrequests", subresource: "approval", verbs: []string{"update"}},
+			permission{group: "certificates.k8s.io", resource: "certificatesigningrequests", subresource: "status", verbs: []string{"update"}},
+			permission{group: "certificates.k8s.io", resource: "signers", verbs: []string{"approve", "sign"}})
+	}
+
+	var findings []Finding
+	for _, p := range permissions {
+		var denied []string
+		for _, verb := range p.verbs {
+			allowed, err := c.isAllowed(ctx, namespace, name, p, verb)
+			if err != nil {
+				return append(findings, Finding{
+					Check:      checkRBAC,
+					Severity:   SeverityError,
+					Message:    fmt.Sprintf("failed to review access of service account %s: %v", c.serviceAccount, err),
+					Suggestion: "check the permissions to create subjectaccessreviews.authorization.k8s.io",
+				})
+			}
+			if !allowed {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) > 0 {
+			findings = append(findings, Finding{
+				Check:    checkRBAC,
+				Severity: SeverityError,
+				Message: fmt.Sprintf("service account %s is not allowed to %s %s", c.serviceAccount,
+					strings.Join(denied, "/"), schema.GroupResource{Group: p.group, Resource: p.resource}),
+				Suggestion: "apply deploy/hub/clusternet_hub_rbac.yaml",
+			})
+		}
+	}
+	return findings
+}
+
+func (c *Checker) isAllowed(ctx context.Context, namespace, name string, p permission, verb string) (bool, error) {
+	review, err := c.kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+			Groups: []string{"system:serviceaccounts", fmt.Sprintf("system:serviceaccounts:%s", namespace), "system:authenticated"},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   p.namespace,
+				Verb:        verb,
+				Group:       p.group,
+				Resource:    p.resource,
+				Subresource: p.subresource,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return review.Status.Allowed, nil
+}
+
+func parseServiceAccount(serviceAccount string) (string, string, error) {
+	parts := strings.Split(serviceAccount, "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", fmt.Errorf("invalid service account %q", serviceAccount)
+	}
+	return parts[0], parts[1], nil
+}
+
+// checkWebhooks checks whether the configured webhooks are reachable.
+func (c *Checker) checkWebhooks() []Finding {
+	if len(c.opts.PlacementWebhook) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(c.opts.PlacementWebhook)
+	if err != nil {
+		return []Finding{{
+			Check:      checkWebhooks,
+			Severity:   SeverityError,
+			Message:    fmt.Sprintf("invalid placement webhook %q: %v", c.opts.PlacementWebhook, err),
+			Suggestion: "specify a valid http or https url with --placement-webhook",
+		}}
+	}
+	host := u.Host
+	if len(u.Port()) == 0 {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", host, defaultDialTimeout)
+	if err != nil {
+		return []Finding{{
+			Check:      checkWebhooks,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("placement webhook %s is not reachable: %v", c.opts.PlacementWebhook, err),
+			Suggestion: "check the network policies and DNS of the webhook, placement changes will only be recorded as events",
+		}}
+	}
+	conn.Close()
+	return nil
+}
+
+// validateFeatureGates checks whether the enabled feature gates and flags are consistent with each other.
+func validateFeatureGates(enabled func(featuregate.Feature) bool, opts *options.HubServerOptions) []Finding {
+	var findings []Finding
+	if !enabled(features.Deployer) {
+		for _, feature := range []featuregate.Feature{features.FeedInUseProtection, features.ImagePlatformCheck,
+			features.DataResidency, features.ClusterIdentityInjection, features.MultiTenancy} {
+			if enabled(feature) {
+				findings = append(findings, Finding{
+					Check:      checkFeatureGates,
+					Severity:   SeverityWarning,
+					Message:    fmt.Sprintf("feature gate %s takes no effect since %s is disabled", feature, features.Deployer),
+					Suggestion: fmt.Sprintf("enable feature gate %s, or disable %s", features.Deployer, feature),
+				})
+			}
+		}
+		if enabled(features.ShadowAPI) {
+			findings = append(findings, Finding{
+				Check:      checkFeatureGates,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("objects created through shadow APIs won't be deployed since %s is disabled", features.Deployer),
+				Suggestion: fmt.Sprintf("enable feature gate %s", features.Deployer),
+			})
+		}
+		if len(opts.PlacementWebhook) > 0 {
+			findings = append(findings, Finding{
+				Check:      checkFeatureGates,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("--placement-webhook takes no effect since %s is disabled", features.Deployer),
+				Suggestion: fmt.Sprintf("enable feature gate %s", features.Deployer),
+			})
+		}
+	}
+
+	if !enabled(features.ShadowAPI) && (len(opts.ShadowAdmissionCluster) > 0 || len(opts.ShadowExcludeResources) > 0) {
+		findings = append(findings, Finding{
+			Check:      checkFeatureGates,
+			Severity:   SeverityWarning,
+			Message:    fmt.Sprintf("--shadow-admission-cluster and --shadow-exclude-resources take no effect since %s is disabled", features.ShadowAPI),
+			Suggestion: fmt.Sprintf("enable feature gate %s", features.ShadowAPI),
+		})
+	}
+
+	for _, feature := range []featuregate.Feature{features.AppPusher, features.NodeUsageMetrics} {
+		if enabled(feature) {
+			findings = append(findings, Finding{
+				Check:      checkFeatureGates,
+				Severity:   SeverityWarning,
+				Message:    fmt.Sprintf("feature gate %s only works in clusternet-agent", feature),
+				Suggestion: fmt.Sprintf("enable feature gate %s in clusternet-agent instead", feature),
+			})
+		}
+	}
+	return findings
+}
+
+// PrintFindings prints the findings, and returns an error if there are any findings with SeverityError.
+func PrintFindings(w io.Writer, findings []Finding) error {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "All checks passed.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tCHECK\tMESSAGE\tSUGGESTION")
+	errCount := 0
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			errCount++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Check, finding.Message, finding.Suggestion)
+	}
+	tw.Flush()
+
+	if errCount > 0 {
+		return fmt.Errorf("%d of %d findings are errors", errCount, len(findings))
+	}
+	return nil
+}
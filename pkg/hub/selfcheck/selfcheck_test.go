@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfcheck
+
+import (
+	"bytes"
+	"testing"
+
+	"k8s.io/component-base/featuregate"
+
+	"github.com/clusternet/clusternet/pkg/features"
+	"github.com/clusternet/clusternet/pkg/hub/options"
+)
+
+func TestValidateFeatureGates(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled []featuregate.Feature
+		opts    *options.HubServerOptions
+		want    int
+	}{
+		{
+			name:    "consistent",
+			enabled: []featuregate.Feature{features.Deployer, features.DataResidency, features.ShadowAPI},
+			opts:    &options.HubServerOptions{},
+			want:    0,
+		},
+		{
+			name:    "deployer disabled",
+			enabled: []featuregate.Feature{features.DataResidency, features.ShadowAPI},
+			opts:    &options.HubServerOptions{PlacementWebhook: "https://example.com"},
+			want:    3,
+		},
+		{
+			name:    "shadow flags without shadow api",
+			enabled: []featuregate.Feature{features.Deployer},
+			opts:    &options.HubServerOptions{ShadowAdmissionCluster: "foo"},
+			want:    1,
+		},
+		{
+			name:    "agent only feature gates",
+			enabled: []featuregate.Feature{features.Deployer, features.AppPusher},
+			opts:    &options.HubServerOptions{},
+			want:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enabled := func(feature featuregate.Feature) bool {
+				for _, f := range tt.enabled {
+					if f == feature {
+						return true
+					}
+				}
+				return false
+			}
+			if got := validateFeatureGates(enabled, tt.opts); len(got) != tt.want {
+				t.Errorf("validateFeatureGates() returns %d findings %v, want %d", len(got), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseServiceAccount(t *testing.T) {
+	namespace, name, err := parseServiceAccount("clusternet-system/clusternet-hub")
+	if err != nil || namespace != "clusternet-system" || name != "clusternet-hub" {
+		t.Errorf("parseServiceAccount() = %q, %q, %v", namespace, name, err)
+	}
+	for _, sa := range []string{"", "clusternet-hub", "/clusternet-hub", "a/b/c"} {
+		if _, _, err = parseServiceAccount(sa); err == nil {
+			t.Errorf("expected error for service account %q", sa)
+		}
+	}
+}
+
+func TestPrintFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PrintFindings(&buf, nil); err != nil {
+		t.Errorf("expected no error without findings, got %v", err)
+	}
+
+	findings := []Finding{
+		{Check: checkFeatureGates, Severity: SeverityWarning, Message: "warning"},
+	}
+	if err := PrintFindings(&buf, findings); err != nil {
+		t.Errorf("expected no error with only warnings, got %v", err)
+	}
+
+	findings = append(findings, Finding{Check: checkCRDs, Severity: SeverityError, Message: "error"})
+	if err := PrintFindings(&buf, findings); err == nil {
+		t.Errorf("expected error with error findings")
+	}
+}
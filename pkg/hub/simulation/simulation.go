@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package simulation runs clusternet-hub in observe-only mode, where all the writes to the parent cluster are sent
+// as server-side dry-run requests. The requests still go through validation and admission, and the would-be results
+// are logged, while nothing gets persisted or dispatched to child clusters. This is useful for validating upgrades of
+// clusternet-hub against production state.
+package simulation
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	metricsSubsystem = "clusternet_hub_simulation"
+)
+
+var (
+	writesTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "writes_total",
+			Help:           "Number of would-be writes sent as dry-run requests in simulation mode, partitioned by verb, group, resource and HTTP response code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"verb", "group", "resource", "code"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// Wrap makes all the write requests with config sent as dry-run requests.
+// It should be applied before creating any clientsets with config.
+func Wrap(config *rest.Config) {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(writesTotal)
+	})
+
+	klog.Warningf("running in simulation mode, all the writes will be sent as dry-run requests and nothing gets persisted")
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &dryRunRoundTripper{delegate: rt}
+	})
+}
+
+type dryRunRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *dryRunRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	verb, ok := writeVerbs[req.Method]
+	if !ok {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	// never mutate the original request
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", metav1.DryRunAll)
+	req.URL.RawQuery = query.Encode()
+
+	group, resource := parseRequestPath(req.URL.Path)
+	resp, err := rt.delegate.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	writesTotal.WithLabelValues(verb, group, resource, code).Inc()
+	klog.V(2).Infof("[simulation] would %s %s: %s", verb, req.URL.Path, code)
+	return resp, err
+}
+
+var writeVerbs = map[string]string{
+	http.MethodPost:   "create",
+	http.MethodPut:    "update",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "delete",
+}
+
+// parseRequestPath returns the group and resource from the path of a request to kube-apiserver,
+// such as /api/v1/namespaces/default/secrets/foo and /apis/apps.clusternet.io/v1alpha1/namespaces/default/bases/foo.
+// Subresources are appended to the resource with a slash.
+func parseRequestPath(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var group string
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		group = parts[1]
+		parts = parts[3:]
+	default:
+		return "", ""
+	}
+
+	// namespaced resources, except for subresources of namespaces
+	if parts[0] == "namespaces" && len(parts) >= 3 && parts[2] != "status" && parts[2] != "finalize" {
+		parts = parts[2:]
+	}
+	resource := parts[0]
+	if len(parts) >= 3 {
+		resource = resource + "/" + parts[2]
+	}
+	return group, resource
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulation
+
+import (
+	"net/http"
+	"testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestDryRunRoundTripper(t *testing.T) {
+	var got *http.Request
+	rt := &dryRunRoundTripper{delegate: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})}
+
+	tests := []struct {
+		method     string
+		wantDryRun string
+	}{
+		{method: http.MethodGet},
+		{method: http.MethodPost, wantDryRun: "All"},
+		{method: http.MethodPut, wantDryRun: "All"},
+		{method: http.MethodPatch, wantDryRun: "All"},
+		{method: http.MethodDelete, wantDryRun: "All"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, "https://localhost/api/v1/namespaces/default/secrets/foo?fieldManager=hub", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+		if dryRun := got.URL.Query().Get("dryRun"); dryRun != tt.wantDryRun {
+			t.Errorf("%s: got dryRun %q, want %q", tt.method, dryRun, tt.wantDryRun)
+		}
+		if got.URL.Query().Get("fieldManager") != "hub" {
+			t.Errorf("%s: existing query parameters are lost", tt.method)
+		}
+		if len(req.URL.Query().Get("dryRun")) > 0 {
+			t.Errorf("%s: the original request is mutated", tt.method)
+		}
+	}
+}
+
+func TestParseRequestPath(t *testing.T) {
+	tests := []struct {
+		path         string
+		wantGroup    string
+		wantResource string
+	}{
+		{path: "/api/v1/namespaces", wantResource: "namespaces"},
+		{path: "/api/v1/namespaces/foo", wantResource: "namespaces"},
+		{path: "/api/v1/namespaces/foo/status", wantResource: "namespaces/status"},
+		{path: "/api/v1/namespaces/default/secrets", wantResource: "secrets"},
+		{path: "/api/v1/namespaces/default/events/foo", wantResource: "events"},
+		{path: "/apis/rbac.authorization.k8s.io/v1/clusterroles/foo", wantGroup: "rbac.authorization.k8s.io", wantResource: "clusterroles"},
+		{path: "/apis/apps.clusternet.io/v1alpha1/namespaces/default/subscriptions/foo/status", wantGroup: "apps.clusternet.io", wantResource: "subscriptions/status"},
+		{path: "/healthz"},
+	}
+	for _, tt := range tests {
+		group, resource := parseRequestPath(tt.path)
+		if group != tt.wantGroup || resource != tt.wantResource {
+			t.Errorf("parseRequestPath(%q) = %q, %q, want %q, %q", tt.path, group, resource, tt.wantGroup, tt.wantResource)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	defaultBranch = "master"
+
+	headsPrefix = "refs/heads/"
+	tagsPrefix  = "refs/tags/"
+	// peeledSuffix marks the commit an annotated tag points to in the output of git ls-remote
+	peeledSuffix = "^{}"
+)
+
+// gitClient runs the git command line against a remote repository
+type gitClient struct {
+	url string
+	// args are the global options of git, such as the credentials
+	args []string
+	env  []string
+}
+
+// newGitClient returns a gitClient with the credentials in secret, which could be nil.
+// The returned func removes the files of the credentials.
+func newGitClient(url string, secret *corev1.Secret) (*gitClient, func(), error) {
+	client := &gitClient{
+		url: url,
+		// never wait for the credentials from a terminal
+		env: []string{"GIT_TERMINAL_PROMPT=0"},
+	}
+	cleanup := func() {}
+	if secret == nil {
+		return client, cleanup, nil
+	}
+
+	if username, ok := secret.Data["username"]; ok {
+		auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", username, secret.Data["password"])))
+		client.args = append(client.args, "-c", fmt.Sprintf("http.extraHeader=Authorization: Basic %s", auth))
+	}
+
+	identity, ok := secret.Data["identity"]
+	if !ok {
+		return client, cleanup, nil
+	}
+	knownHosts, ok := secret.Data["known_hosts"]
+	if !ok {
+		return nil, cleanup, fmt.Errorf("known_hosts is required in Secret %s along with identity", secret.Name)
+	}
+
+	var files []string
+	cleanup = func() {
+		for _, file := range files {
+			os.Remove(file)
+		}
+	}
+	for _, data := range [][]byte{identity, knownHosts} {
+		f, err := ioutil.TempFile("", "clusternet-git-")
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		files = append(files, f.Name())
+		_, err = f.Write(data)
+		f.Close()
+		if err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+	}
+	client.env = append(client.env, fmt.Sprintf(
+		"GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes",
+		files[0], files[1]))
+	return client, cleanup, nil
+}
+
+func (g *gitClient) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{}, g.args...), args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), g.env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// listRefs returns the SHAs of all the branches and tags in the remote repository
+func (g *gitClient) listRefs(ctx context.Context) (map[string]string, error) {
+	out, err := g.run(ctx, "", "ls-remote", "--heads", "--tags", g.url)
+	if err != nil {
+		return nil, err
+	}
+	return parseRefs(out), nil
+}
+
+// checkout fetches ref from the remote repository into dir, and returns the SHA of the commit checked out
+func (g *gitClient) checkout(ctx context.Context, dir, ref string) (string, error) {
+	if _, err := g.run(ctx, dir, "init", "--quiet"); err != nil {
+		return "", err
+	}
+	if _, err := g.run(ctx, dir, "fetch", "--quiet", "--depth", "1", g.url, ref); err != nil {
+		return "", err
+	}
+	if _, err := g.run(ctx, dir, "checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return "", err
+	}
+	out, err := g.run(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parseRefs parses the output of git ls-remote into a map from refs to SHAs.
+// Annotated tags are resolved to the commits they point to.
+func parseRefs(out []byte) map[string]string {
+	refs := map[string]string{}
+	peeled := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.HasSuffix(fields[1], peeledSuffix) {
+			peeled[strings.TrimSuffix(fields[1], peeledSuffix)] = fields[0]
+			continue
+		}
+		refs[fields[1]] = fields[0]
+	}
+	for ref, sha := range peeled {
+		refs[ref] = sha
+	}
+	return refs
+}
+
+// resolveReference returns the name and SHA of the commit to check out
+func resolveReference(refs map[string]string, ref *appsapi.GitRepositoryRef) (string, string, error) {
+	if ref == nil {
+		ref = &appsapi.GitRepositoryRef{}
+	}
+
+	switch {
+	case len(ref.SemVer) > 0:
+		var tags []string
+		for name := range refs {
+			if strings.HasPrefix(name, tagsPrefix) {
+				tags = append(tags, strings.TrimPrefix(name, tagsPrefix))
+			}
+		}
+		tag, err := highestVersion(tags, ref.SemVer)
+		if err != nil {
+			return "", "", err
+		}
+		return tag, refs[tagsPrefix+tag], nil
+	case len(ref.Tag) > 0:
+		sha, ok := refs[tagsPrefix+ref.Tag]
+		if !ok {
+			return "", "", fmt.Errorf("tag %q is not found", ref.Tag)
+		}
+		return ref.Tag, sha, nil
+	default:
+		branch := ref.Branch
+		if len(branch) == 0 {
+			branch = defaultBranch
+		}
+		sha, ok := refs[headsPrefix+branch]
+		if !ok {
+			return "", "", fmt.Errorf("branch %q is not found", branch)
+		}
+		return branch, sha, nil
+	}
+}
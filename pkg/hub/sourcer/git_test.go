@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"testing"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestResolveReference(t *testing.T) {
+	refs := parseRefs([]byte(`1111111111111111111111111111111111111111	refs/heads/master
+2222222222222222222222222222222222222222	refs/heads/dev
+3333333333333333333333333333333333333333	refs/tags/v1.0.0
+4444444444444444444444444444444444444444	refs/tags/v1.2.0
+5555555555555555555555555555555555555555	refs/tags/v1.2.0^{}
+6666666666666666666666666666666666666666	refs/tags/v2.0.0
+7777777777777777777777777777777777777777	refs/tags/latest
+`))
+
+	tests := []struct {
+		name     string
+		ref      *appsapi.GitRepositoryRef
+		wantName string
+		wantSHA  string
+		wantErr  bool
+	}{
+		{
+			name:     "default branch",
+			wantName: "master",
+			wantSHA:  "1111111111111111111111111111111111111111",
+		},
+		{
+			name:     "branch",
+			ref:      &appsapi.GitRepositoryRef{Branch: "dev"},
+			wantName: "dev",
+			wantSHA:  "2222222222222222222222222222222222222222",
+		},
+		{
+			name:    "missing branch",
+			ref:     &appsapi.GitRepositoryRef{Branch: "main"},
+			wantErr: true,
+		},
+		{
+			name:     "tag",
+			ref:      &appsapi.GitRepositoryRef{Tag: "v1.0.0", Branch: "dev"},
+			wantName: "v1.0.0",
+			wantSHA:  "3333333333333333333333333333333333333333",
+		},
+		{
+			name:     "annotated tag",
+			ref:      &appsapi.GitRepositoryRef{Tag: "v1.2.0"},
+			wantName: "v1.2.0",
+			wantSHA:  "5555555555555555555555555555555555555555",
+		},
+		{
+			name:     "semver",
+			ref:      &appsapi.GitRepositoryRef{SemVer: "~1", Tag: "v1.0.0"},
+			wantName: "v1.2.0",
+			wantSHA:  "5555555555555555555555555555555555555555",
+		},
+		{
+			name:     "semver any",
+			ref:      &appsapi.GitRepositoryRef{SemVer: "*"},
+			wantName: "v2.0.0",
+			wantSHA:  "6666666666666666666666666666666666666666",
+		},
+		{
+			name:    "no matching semver",
+			ref:     &appsapi.GitRepositoryRef{SemVer: ">=3.0.0"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, sha, err := resolveReference(refs, tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || sha != tt.wantSHA {
+				t.Errorf("resolveReference() = %s, %s, want %s, %s", name, sha, tt.wantName, tt.wantSHA)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/hub/kustomizer"
+)
+
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// loadManifests returns the objects in the directory dir of root. A directory with a kustomization file
+// is built with kustomize, otherwise the objects in all the YAML and JSON files under it are returned.
+func loadManifests(root, dir string) ([]*unstructured.Unstructured, error) {
+	// dir should never escape from root
+	dir = filepath.Clean(string(filepath.Separator) + dir)
+	target := filepath.Join(root, dir)
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	for _, name := range kustomizationFiles {
+		if _, err = os.Lstat(filepath.Join(target, name)); err == nil {
+			return buildKustomization(root, dir)
+		}
+	}
+
+	var objs []*unstructured.Unstructured
+	err = walkFiles(target, func(path string, data []byte) error {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		decoded, err := decodeObjects(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %v", strings.TrimPrefix(path, root), err)
+		}
+		objs = append(objs, decoded...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objs, nil
+}
+
+// buildKustomization builds the kustomization in dir with all the files of root,
+// which are loaded into memory so that the kustomization could never refer files outside of root.
+func buildKustomization(root, dir string) ([]*unstructured.Unstructured, error) {
+	files := map[string][]byte{}
+	err := walkFiles(root, func(path string, data []byte) error {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return kustomizer.Build(files, filepath.ToSlash(dir))
+}
+
+// walkFiles calls fn with the content of every regular file under root, skipping the .git directories
+func walkFiles(root string, fn func(path string, data []byte) error) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// symlinks are not followed
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return fn(path, data)
+	})
+}
+
+// decodeObjects decodes all the objects in a YAML or JSON file, where lists are expanded into their items.
+// Documents which are not Kubernetes objects are skipped.
+func decodeObjects(data []byte) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var content map[string]interface{}
+		if err := decoder.Decode(&content); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(content) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: content}
+		if len(obj.GetAPIVersion()) == 0 || len(obj.GetKind()) == 0 {
+			klog.V(5).Infof("skipping a document without apiVersion or kind")
+			continue
+		}
+		if !obj.IsList() {
+			objs = append(objs, obj)
+			continue
+		}
+		err := obj.EachListItem(func(item runtime.Object) error {
+			objs = append(objs, item.(*unstructured.Unstructured))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objs, nil
+}
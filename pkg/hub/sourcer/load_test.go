@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLoadManifests(t *testing.T) {
+	root, err := ioutil.TempDir("", "clusternet-sourcer-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		"README.md": "# not a manifest\n",
+		"plain/deployment.yaml": `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+# empty document
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: web-config
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: web-secret
+`,
+		"plain/service.json": `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web"}}`,
+		"plain/values.yaml":  "replicas: 3\n",
+		"overlay/kustomization.yaml": `resources:
+- service.yaml
+namePrefix: prod-
+`,
+		"overlay/service.yaml": `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dir     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "plain manifests",
+			dir:  "plain",
+			want: []string{"ConfigMap/web-config", "Deployment/web", "Secret/web-secret", "Service/web"},
+		},
+		{
+			name: "kustomization",
+			dir:  "./overlay/",
+			want: []string{"Service/prod-web"},
+		},
+		{
+			name: "escaping directory",
+			dir:  "../../overlay",
+			want: []string{"Service/prod-web"},
+		},
+		{
+			name:    "missing directory",
+			dir:     "missing",
+			wantErr: true,
+		},
+		{
+			name:    "file",
+			dir:     "README.md",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := loadManifests(root, tt.dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, obj := range objs {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadManifests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
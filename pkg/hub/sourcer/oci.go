@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/oci"
+)
+
+const (
+	defaultTag = "latest"
+
+	// ociTitleAnnotation is the file name of a layer pushed with ORAS
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// orasUnpackAnnotation marks a layer of a directory pushed with ORAS, which is a tarball to unpack
+	orasUnpackAnnotation = "io.deis.oras.content.unpack"
+
+	// maxArtifactSize limits the total size of the layers of an artifact, as well as the files unpacked from them
+	maxArtifactSize = 64 << 20
+)
+
+// resolveOCIReference returns the name of the reference and the tag or digest to pull
+func resolveOCIReference(ctx context.Context, client *oci.Client, ref *appsapi.OCIRepositoryRef) (string, string, error) {
+	if ref == nil {
+		ref = &appsapi.OCIRepositoryRef{}
+	}
+
+	switch {
+	case len(ref.Digest) > 0:
+		return ref.Digest, ref.Digest, nil
+	case len(ref.SemVer) > 0:
+		tags, err := client.ListTags(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		tag, err := highestVersion(tags, ref.SemVer)
+		if err != nil {
+			return "", "", err
+		}
+		return tag, tag, nil
+	case len(ref.Tag) > 0:
+		return ref.Tag, ref.Tag, nil
+	default:
+		return defaultTag, defaultTag, nil
+	}
+}
+
+// pullArtifact writes the layers of manifest into dir. Tarballs are unpacked, and the other layers are written
+// as files named by their titles.
+func pullArtifact(ctx context.Context, client *oci.Client, manifest *oci.Manifest, dir string) error {
+	var total int64
+	for _, layer := range manifest.Layers {
+		total += layer.Size
+		if layer.Size < 0 || total > maxArtifactSize {
+			return fmt.Errorf("artifact is larger than %d bytes", maxArtifactSize)
+		}
+	}
+
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if !isTarball(layer.MediaType) && len(title) == 0 {
+			klog.V(5).Infof("skipping layer %s of media type %s without a title", layer.Digest, layer.MediaType)
+			continue
+		}
+
+		data, err := client.FetchBlob(ctx, layer)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case isTarball(layer.MediaType) && layer.Annotations[orasUnpackAnnotation] == "true":
+			err = unpackTarball(data, filepath.Join(dir, filepath.Clean(string(filepath.Separator)+title)))
+		case isTarball(layer.MediaType):
+			err = unpackTarball(data, dir)
+		default:
+			err = writeFile(filepath.Join(dir, filepath.Clean(string(filepath.Separator)+title)), bytes.NewReader(data))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write layer %s: %v", layer.Digest, err)
+		}
+	}
+	return nil
+}
+
+func isTarball(mediaType string) bool {
+	return strings.HasSuffix(mediaType, ".tar") || strings.HasSuffix(mediaType, ".tar+gzip") ||
+		strings.HasSuffix(mediaType, ".tar.gzip")
+}
+
+// unpackTarball unpacks the regular files and directories in a tarball, which could be gzipped, into dir.
+// Entries never escape from dir, and the other kinds of entries such as symlinks are skipped.
+func unpackTarball(data []byte, dir string) error {
+	var reader io.Reader = bytes.NewReader(data)
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	// limit the size of the unpacked files as well
+	reader = io.LimitReader(reader, maxArtifactSize)
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.Clean(string(filepath.Separator)+header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = writeFile(target, tarReader); err != nil {
+				return err
+			}
+		default:
+			klog.V(5).Infof("skipping %s of type %c in tarball", header.Name, header.Typeflag)
+		}
+	}
+}
+
+func writeFile(path string, reader io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, reader)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/oci"
+)
+
+func newTarball(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newRegistry serves an artifact with a tarball layer and a file layer at the tags v1.0.0 and v1.1.0,
+// behind a token authentication.
+func newRegistry(t *testing.T) (*httptest.Server, string) {
+	tarball := newTarball(t, map[string]string{
+		"deploy/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+		"../../etc/service.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+	})
+	configMap := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n")
+	blobs := map[string][]byte{
+		oci.Digest(tarball):   tarball,
+		oci.Digest(configMap): configMap,
+	}
+	manifest, err := json.Marshal(oci.Manifest{
+		MediaType: oci.ImageManifestMediaType,
+		Layers: []oci.Descriptor{
+			{
+				MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+				Digest:    oci.Digest(tarball),
+				Size:      int64(len(tarball)),
+			},
+			{
+				MediaType:   "application/yaml",
+				Digest:      oci.Digest(configMap),
+				Size:        int64(len(configMap)),
+				Annotations: map[string]string{ociTitleAnnotation: "deploy/configmap.yaml"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if username, password, _ := r.BasicAuth(); username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("scope") != "repository:org/manifests:pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"token": "secret-token"}`)
+	})
+	mux.HandleFunc("/v2/org/manifests/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:org/manifests:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/v2/org/manifests/")
+		switch {
+		case path == "tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/org/manifests/tags/list?last=v1.0.0&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"name": "org/manifests", "tags": ["latest", "v1.0.0"]}`)
+		case path == "tags/list":
+			fmt.Fprint(w, `{"name": "org/manifests", "tags": ["v1.1.0", "v2.0.0-rc.1"]}`)
+		case strings.HasPrefix(path, "manifests/"):
+			w.Header().Set("Content-Type", oci.ImageManifestMediaType)
+			w.Write(manifest)
+		case strings.HasPrefix(path, "blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewServer(mux)
+	return server, oci.Digest(manifest)
+}
+
+func TestPullArtifact(t *testing.T) {
+	server, digest := newRegistry(t)
+	defer server.Close()
+	tampered := oci.Digest([]byte("tampered"))
+
+	tests := []struct {
+		name       string
+		ref        *appsapi.OCIRepositoryRef
+		wantName   string
+		wantErr    bool
+		wantResult []string
+	}{
+		{
+			name:       "default tag",
+			wantName:   "latest",
+			wantResult: []string{"deploy/configmap.yaml", "deploy/deployment.yaml", "etc/service.yaml"},
+		},
+		{
+			name:       "semver",
+			ref:        &appsapi.OCIRepositoryRef{SemVer: "^1.0.0", Tag: "latest"},
+			wantName:   "v1.1.0",
+			wantResult: []string{"deploy/configmap.yaml", "deploy/deployment.yaml", "etc/service.yaml"},
+		},
+		{
+			name:       "pinned digest",
+			ref:        &appsapi.OCIRepositoryRef{Digest: digest, Tag: "latest"},
+			wantName:   digest,
+			wantResult: []string{"deploy/configmap.yaml", "deploy/deployment.yaml", "etc/service.yaml"},
+		},
+		{
+			name:     "mismatched digest",
+			ref:      &appsapi.OCIRepositoryRef{Digest: tampered},
+			wantName: tampered,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := oci.NewClient(strings.Replace(server.URL, "http://", oci.Scheme, 1)+"/org/manifests", true, "user", "pass")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name, reference, err := resolveOCIReference(context.TODO(), client, tt.ref)
+			if err != nil {
+				t.Fatalf("resolveOCIReference() error = %v", err)
+			}
+			if name != tt.wantName {
+				t.Errorf("resolveOCIReference() = %s, want %s", name, tt.wantName)
+			}
+			manifest, got, err := client.FetchManifest(context.TODO(), reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != digest {
+				t.Errorf("FetchManifest() digest = %s, want %s", got, digest)
+			}
+
+			dir, err := ioutil.TempDir("", "clusternet-oci-")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err = pullArtifact(context.TODO(), client, manifest, dir); err != nil {
+				t.Fatalf("pullArtifact() error = %v", err)
+			}
+			var files []string
+			err = walkFiles(dir, func(path string, data []byte) error {
+				rel, err := filepath.Rel(dir, path)
+				files = append(files, filepath.ToSlash(rel))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(files)
+			if !reflect.DeepEqual(files, tt.wantResult) {
+				t.Errorf("pullArtifact() = %v, want %v", files, tt.wantResult)
+			}
+		})
+	}
+}
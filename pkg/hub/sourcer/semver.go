@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// highestVersion returns the tag of the highest version in the semver range
+func highestVersion(tags []string, semverRange string) (string, error) {
+	constraint, err := semver.NewConstraint(semverRange)
+	if err != nil {
+		return "", fmt.Errorf("invalid semver range %q: %v", semverRange, err)
+	}
+
+	var latest *semver.Version
+	var latestTag string
+	for _, tag := range tags {
+		version, err := semver.NewVersion(tag)
+		if err != nil || !constraint.Check(version) {
+			continue
+		}
+		if latest == nil || version.GreaterThan(latest) {
+			latest = version
+			latestTag = tag
+		}
+	}
+	if latest == nil {
+		return "", fmt.Errorf("no tag matches semver range %q", semverRange)
+	}
+	return latestTag, nil
+}
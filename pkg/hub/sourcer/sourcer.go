@@ -0,0 +1,440 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sourcer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/controllers/apps/gitrepository"
+	"github.com/clusternet/clusternet/pkg/controllers/apps/ocirepository"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	clusternetinformers "github.com/clusternet/clusternet/pkg/generated/informers/externalversions"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/hub/oci"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+const (
+	// defaultInterval is how often a source is polled when no interval is specified
+	defaultInterval = 5 * time.Minute
+
+	// pullTimeout bounds each pull from a source
+	pullTimeout = 2 * time.Minute
+)
+
+var (
+	gitRepositoryKind = appsapi.SchemeGroupVersion.WithKind("GitRepository")
+	ociRepositoryKind = appsapi.SchemeGroupVersion.WithKind("OCIRepository")
+)
+
+// Sourcer pulls manifests from external sources into Manifests
+type Sourcer struct {
+	ctx context.Context
+
+	clusternetClient *clusternetclientset.Clientset
+
+	gitRepoLister applisters.GitRepositoryLister
+	gitRepoSynced cache.InformerSynced
+	ociRepoLister applisters.OCIRepositoryLister
+	ociRepoSynced cache.InformerSynced
+	mfstLister    applisters.ManifestLister
+	mfstSynced    cache.InformerSynced
+	secretLister  corelisters.SecretLister
+	secretSynced  cache.InformerSynced
+
+	gitRepoController *gitrepository.Controller
+	ociRepoController *ocirepository.Controller
+
+	recorder record.EventRecorder
+}
+
+func NewSourcer(ctx context.Context,
+	clusternetClient *clusternetclientset.Clientset,
+	clusternetInformerFactory clusternetinformers.SharedInformerFactory,
+	kubeInformerFactory kubeinformers.SharedInformerFactory,
+	recorder record.EventRecorder) (*Sourcer, error) {
+
+	sourcer := &Sourcer{
+		ctx:              ctx,
+		clusternetClient: clusternetClient,
+		gitRepoLister:    clusternetInformerFactory.Apps().V1alpha1().GitRepositories().Lister(),
+		gitRepoSynced:    clusternetInformerFactory.Apps().V1alpha1().GitRepositories().Informer().HasSynced,
+		ociRepoLister:    clusternetInformerFactory.Apps().V1alpha1().OCIRepositories().Lister(),
+		ociRepoSynced:    clusternetInformerFactory.Apps().V1alpha1().OCIRepositories().Informer().HasSynced,
+		mfstLister:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Lister(),
+		mfstSynced:       clusternetInformerFactory.Apps().V1alpha1().Manifests().Informer().HasSynced,
+		secretLister:     kubeInformerFactory.Core().V1().Secrets().Lister(),
+		secretSynced:     kubeInformerFactory.Core().V1().Secrets().Informer().HasSynced,
+		recorder:         recorder,
+	}
+
+	gitRepoController, err := gitrepository.NewController(ctx, clusternetClient,
+		clusternetInformerFactory.Apps().V1alpha1().GitRepositories(),
+		recorder,
+		sourcer.handleGitRepository)
+	if err != nil {
+		return nil, err
+	}
+	sourcer.gitRepoController = gitRepoController
+
+	ociRepoController, err := ocirepository.NewController(ctx, clusternetClient,
+		clusternetInformerFactory.Apps().V1alpha1().OCIRepositories(),
+		recorder,
+		sourcer.handleOCIRepository)
+	if err != nil {
+		return nil, err
+	}
+	sourcer.ociRepoController = ociRepoController
+
+	return sourcer, nil
+}
+
+func (s *Sourcer) Run(workers int) {
+	klog.Info("starting Clusternet sourcer ...")
+
+	// Wait for the caches to be synced before starting workers
+	klog.V(5).Info("waiting for informer caches to sync")
+	if !cache.WaitForCacheSync(s.ctx.Done(),
+		s.gitRepoSynced,
+		s.ociRepoSynced,
+		s.mfstSynced,
+		s.secretSynced,
+	) {
+		return
+	}
+
+	var wg wait.Group
+	wg.Start(func() {
+		s.gitRepoController.Run(workers, s.ctx.Done())
+	})
+	wg.Start(func() {
+		s.ociRepoController.Run(workers, s.ctx.Done())
+	})
+
+	wg.Wait()
+}
+
+func (s *Sourcer) handleGitRepository(repo *appsapi.GitRepository) error {
+	source := utils.FeedSource{
+		GroupVersionKind: gitRepositoryKind,
+		Resource:         "gitrepositories",
+		Namespace:        repo.Namespace,
+		Name:             repo.Name,
+	}
+
+	if repo.DeletionTimestamp != nil {
+		if err := utils.PruneRenderedManifests(s.clusternetClient, s.mfstLister, source, sets.NewString()); err != nil {
+			return err
+		}
+
+		// remove finalizer
+		repo.Finalizers = utils.RemoveString(repo.Finalizers, known.AppFinalizer)
+		_, err := s.clusternetClient.AppsV1alpha1().GitRepositories(repo.Namespace).Update(context.TODO(), repo, metav1.UpdateOptions{})
+		if err != nil {
+			klog.WarningDepth(4,
+				fmt.Sprintf("failed to remove finalizer %s from GitRepository %s: %v", known.AppFinalizer, klog.KObj(repo), err))
+		}
+		return err
+	}
+
+	status := repo.Status.DeepCopy()
+	status.ObservedGeneration = repo.Generation
+
+	revision, objs, err := s.pullGitRepository(repo)
+	if err == nil && objs != nil {
+		err = utils.SyncRenderedManifests(s.clusternetClient, s.mfstLister, source, objs)
+	}
+	if err != nil {
+		status.Conditions = utils.MergeConditions(status.Conditions, repo.Generation, metav1.Condition{
+			Type:    appsapi.GitRepositoryReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "SyncFailed",
+			Message: err.Error(),
+		})
+		if uerr := s.updateGitRepositoryStatus(repo, status); uerr != nil {
+			klog.Warningf("failed to update status of GitRepository %s: %v", klog.KObj(repo), uerr)
+		}
+		return err
+	}
+
+	if objs != nil {
+		status.Revision = revision
+		status.Resources = int32(len(objs))
+		status.Conditions = utils.MergeConditions(status.Conditions, repo.Generation, metav1.Condition{
+			Type:    appsapi.GitRepositoryReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Synced",
+			Message: fmt.Sprintf("%d resources are synced from revision %s", len(objs), revision),
+		})
+		if err = s.updateGitRepositoryStatus(repo, status); err != nil {
+			return err
+		}
+	}
+
+	interval := repo.Spec.Interval.Duration
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	s.gitRepoController.EnqueueAfter(repo, interval)
+	return nil
+}
+
+// pullGitRepository checks out the referred revision of the git repository and loads the manifests.
+// It returns nil objects when the revision has been synced already.
+func (s *Sourcer) pullGitRepository(repo *appsapi.GitRepository) (string, []*unstructured.Unstructured, error) {
+	var secret *corev1.Secret
+	var err error
+	if repo.Spec.SecretRef != nil {
+		secret, err = s.secretLister.Secrets(repo.Namespace).Get(repo.Spec.SecretRef.Name)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get Secret %s: %v", repo.Spec.SecretRef.Name, err)
+		}
+	}
+	client, cleanup, err := newGitClient(repo.Spec.URL, secret)
+	if err != nil {
+		return "", nil, err
+	}
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(s.ctx, pullTimeout)
+	defer cancel()
+
+	// resolve the commit to check out, without cloning the repository
+	var name, ref string
+	if repo.Spec.Reference != nil && len(repo.Spec.Reference.Commit) > 0 {
+		name, ref = repo.Spec.Reference.Commit, repo.Spec.Reference.Commit
+	} else {
+		refs, err := client.listRefs(ctx)
+		if err != nil {
+			return "", nil, err
+		}
+		name, ref, err = resolveReference(refs, repo.Spec.Reference)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if repo.Status.ObservedGeneration == repo.Generation && repo.Status.Revision == fmt.Sprintf("%s/%s", name, ref) &&
+		apimeta.IsStatusConditionTrue(repo.Status.Conditions, appsapi.GitRepositoryReady) {
+		klog.V(5).Infof("revision %s of GitRepository %s has been synced", repo.Status.Revision, klog.KObj(repo))
+		return repo.Status.Revision, nil, nil
+	}
+
+	dir, err := ioutil.TempDir("", "clusternet-git-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	sha, err := client.checkout(ctx, dir, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	revision := fmt.Sprintf("%s/%s", name, sha)
+
+	objs, err := loadManifests(dir, repo.Spec.Path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load manifests of revision %s: %v", revision, err)
+	}
+	if len(objs) == 0 {
+		return "", nil, fmt.Errorf("no manifests are found in revision %s", revision)
+	}
+	return revision, objs, nil
+}
+
+func (s *Sourcer) updateGitRepositoryStatus(repo *appsapi.GitRepository, status *appsapi.GitRepositoryStatus) error {
+	if reflect.DeepEqual(repo.Status, *status) {
+		return nil
+	}
+	return s.gitRepoController.UpdateGitRepositoryStatus(repo.DeepCopy(), status)
+}
+
+func (s *Sourcer) handleOCIRepository(repo *appsapi.OCIRepository) error {
+	source := utils.FeedSource{
+		GroupVersionKind: ociRepositoryKind,
+		Resource:         "ocirepositories",
+		Namespace:        repo.Namespace,
+		Name:             repo.Name,
+	}
+
+	if repo.DeletionTimestamp != nil {
+		if err := utils.PruneRenderedManifests(s.clusternetClient, s.mfstLister, source, sets.NewString()); err != nil {
+			return err
+		}
+
+		// remove finalizer
+		repo.Finalizers = utils.RemoveString(repo.Finalizers, known.AppFinalizer)
+		_, err := s.clusternetClient.AppsV1alpha1().OCIRepositories(repo.Namespace).Update(context.TODO(), repo, metav1.UpdateOptions{})
+		if err != nil {
+			klog.WarningDepth(4,
+				fmt.Sprintf("failed to remove finalizer %s from OCIRepository %s: %v", known.AppFinalizer, klog.KObj(repo), err))
+		}
+		return err
+	}
+
+	status := repo.Status.DeepCopy()
+	status.ObservedGeneration = repo.Generation
+
+	revision, objs, err := s.pullOCIRepository(repo)
+	if err == nil && objs != nil {
+		err = utils.SyncRenderedManifests(s.clusternetClient, s.mfstLister, source, objs)
+	}
+	if err != nil {
+		reason := "SyncFailed"
+		if _, ok := err.(*verificationError); ok {
+			reason = appsapi.SignatureVerificationFailed
+		}
+		status.Conditions = utils.MergeConditions(status.Conditions, repo.Generation, metav1.Condition{
+			Type:    appsapi.OCIRepositoryReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  reason,
+			Message: err.Error(),
+		})
+		if uerr := s.updateOCIRepositoryStatus(repo, status); uerr != nil {
+			klog.Warningf("failed to update status of OCIRepository %s: %v", klog.KObj(repo), uerr)
+		}
+		return err
+	}
+
+	if objs != nil {
+		status.Revision = revision
+		status.Resources = int32(len(objs))
+		status.Conditions = utils.MergeConditions(status.Conditions, repo.Generation, metav1.Condition{
+			Type:    appsapi.OCIRepositoryReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Synced",
+			Message: fmt.Sprintf("%d resources are synced from revision %s", len(objs), revision),
+		})
+		if err = s.updateOCIRepositoryStatus(repo, status); err != nil {
+			return err
+		}
+	}
+
+	interval := repo.Spec.Interval.Duration
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	s.ociRepoController.EnqueueAfter(repo, interval)
+	return nil
+}
+
+// pullOCIRepository pulls the referred artifact of the OCI repository and loads the manifests.
+// It returns nil objects when the artifact has been synced already.
+func (s *Sourcer) pullOCIRepository(repo *appsapi.OCIRepository) (string, []*unstructured.Unstructured, error) {
+	var secret *corev1.Secret
+	var err error
+	if repo.Spec.SecretRef != nil {
+		secret, err = s.secretLister.Secrets(repo.Namespace).Get(repo.Spec.SecretRef.Name)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get Secret %s: %v", repo.Spec.SecretRef.Name, err)
+		}
+	}
+	var username, password string
+	if secret != nil {
+		username, password = string(secret.Data["username"]), string(secret.Data["password"])
+	}
+	client, err := oci.NewClient(repo.Spec.URL, repo.Spec.Insecure, username, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, pullTimeout)
+	defer cancel()
+
+	// resolve the digest of the artifact, without pulling its layers
+	name, reference, err := resolveOCIReference(ctx, client, repo.Spec.Reference)
+	if err != nil {
+		return "", nil, err
+	}
+	manifest, digest, err := client.FetchManifest(ctx, reference)
+	if err != nil {
+		return "", nil, err
+	}
+	revision := fmt.Sprintf("%s/%s", name, digest)
+	if repo.Status.ObservedGeneration == repo.Generation && repo.Status.Revision == revision &&
+		apimeta.IsStatusConditionTrue(repo.Status.Conditions, appsapi.OCIRepositoryReady) {
+		klog.V(5).Infof("revision %s of OCIRepository %s has been synced", revision, klog.KObj(repo))
+		return revision, nil, nil
+	}
+
+	if repo.Spec.Verify != nil {
+		verifySecret, err := s.secretLister.Secrets(repo.Namespace).Get(repo.Spec.Verify.SecretRef.Name)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get Secret %s: %v", repo.Spec.Verify.SecretRef.Name, err)
+		}
+		verifier, err := oci.NewVerifier(verifySecret, repo.Spec.Verify.Identities)
+		if err != nil {
+			return "", nil, &verificationError{err: err}
+		}
+		if err = verifier.Verify(ctx, client, digest); err != nil {
+			return "", nil, &verificationError{err: fmt.Errorf("failed to verify revision %s: %v", revision, err)}
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "clusternet-oci-")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = pullArtifact(ctx, client, manifest, dir); err != nil {
+		return "", nil, fmt.Errorf("failed to pull revision %s: %v", revision, err)
+	}
+
+	objs, err := loadManifests(dir, repo.Spec.Path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load manifests of revision %s: %v", revision, err)
+	}
+	if len(objs) == 0 {
+		return "", nil, fmt.Errorf("no manifests are found in revision %s", revision)
+	}
+	return revision, objs, nil
+}
+
+// verificationError indicates that the signatures of an artifact fail to be verified.
+type verificationError struct {
+	err error
+}
+
+func (e *verificationError) Error() string {
+	return e.err.Error()
+}
+
+func (s *Sourcer) updateOCIRepositoryStatus(repo *appsapi.OCIRepository, status *appsapi.OCIRepositoryStatus) error {
+	if reflect.DeepEqual(repo.Status, *status) {
+		return nil
+	}
+	return s.ociRepoController.UpdateOCIRepositoryStatus(repo.DeepCopy(), status)
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/discovery"
+	cacheddiscovery "k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	shadowapi "github.com/clusternet/clusternet/pkg/apis/shadow/v1alpha1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// Importer reads live objects from a source cluster, and imports them into clusternet
+// as shadow resources, together with a Subscription distributing them.
+type Importer struct {
+	opts *ImportOptions
+	out  io.Writer
+
+	sourceClient    dynamic.Interface
+	sourceDiscovery discovery.DiscoveryInterface
+
+	parentClient     dynamic.Interface
+	clusternetClient clusternetclientset.Interface
+}
+
+// NewImporter returns a new Importer.
+func NewImporter(opts *ImportOptions, out io.Writer) (*Importer, error) {
+	sourceConfig, err := utils.LoadsKubeConfig(opts.SourceKubeConfig, 1)
+	if err != nil {
+		return nil, err
+	}
+	importer := &Importer{
+		opts:            opts,
+		out:             out,
+		sourceClient:    dynamic.NewForConfigOrDie(sourceConfig),
+		sourceDiscovery: discovery.NewDiscoveryClientForConfigOrDie(sourceConfig),
+	}
+
+	if !opts.DryRun {
+		parentConfig, err := utils.LoadsKubeConfig(opts.ParentKubeConfig, 1)
+		if err != nil {
+			return nil, err
+		}
+		importer.parentClient = dynamic.NewForConfigOrDie(parentConfig)
+		importer.clusternetClient = clusternetclientset.NewForConfigOrDie(parentConfig)
+	}
+	return importer, nil
+}
+
+// Run imports the objects and generates the Subscription.
+func (i *Importer) Run(ctx context.Context) error {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cacheddiscovery.NewMemCacheClient(i.sourceDiscovery))
+
+	var feeds []appsapi.Feed
+	var allErrs []error
+	for _, resource := range i.opts.Resources {
+		gvr, err := mapper.ResourceFor(schema.ParseGroupResource(resource).WithVersion(""))
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to resolve resource %s: %v", resource, err))
+			continue
+		}
+
+		objs, err := i.sourceClient.Resource(gvr).Namespace(i.opts.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: i.opts.Selector,
+		})
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to list %s: %v", resource, err))
+			continue
+		}
+
+		for idx := range objs.Items {
+			obj := &objs.Items[idx]
+			if !shouldImport(obj) {
+				klog.V(4).Infof("skip importing %s %s", obj.GetKind(), klog.KObj(obj))
+				continue
+			}
+			sanitize(obj)
+
+			if err = i.upload(ctx, gvr, obj); err != nil {
+				allErrs = append(allErrs, fmt.Errorf("failed to import %s %s: %v", obj.GetKind(), klog.KObj(obj), err))
+				continue
+			}
+			feeds = append(feeds, appsapi.Feed{
+				Kind:       obj.GetKind(),
+				APIVersion: obj.GetAPIVersion(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+			})
+		}
+	}
+
+	if len(feeds) == 0 {
+		allErrs = append(allErrs, fmt.Errorf("no objects found to be imported"))
+		return utilerrors.NewAggregate(allErrs)
+	}
+
+	if err := i.syncSubscription(ctx, feeds); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// upload creates or updates the object through the shadow APIs.
+func (i *Importer) upload(ctx context.Context, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	if i.opts.DryRun {
+		return i.print(obj)
+	}
+
+	shadowClient := i.parentClient.Resource(shadowapi.SchemeGroupVersion.WithResource(gvr.Resource)).Namespace(obj.GetNamespace())
+	_, err := shadowClient.Create(ctx, obj, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		var current *unstructured.Unstructured
+		current, err = shadowClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		obj.SetResourceVersion(current.GetResourceVersion())
+		_, err = shadowClient.Update(ctx, obj, metav1.UpdateOptions{})
+	}
+	if err == nil {
+		fmt.Fprintf(i.out, "%s %s imported\n", obj.GetKind(), klog.KObj(obj))
+	}
+	return err
+}
+
+// syncSubscription creates or updates the Subscription with given feeds.
+func (i *Importer) syncSubscription(ctx context.Context, feeds []appsapi.Feed) error {
+	clusterAffinity, err := metav1.ParseToLabelSelector(i.opts.ClusterSelector)
+	if err != nil {
+		return err
+	}
+
+	sub := &appsapi.Subscription{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: appsapi.SchemeGroupVersion.String(),
+			Kind:       "Subscription",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.opts.SubscriptionName,
+			Namespace: i.opts.Namespace,
+		},
+		Spec: appsapi.SubscriptionSpec{
+			Subscribers: []appsapi.Subscriber{
+				{
+					ClusterAffinity: clusterAffinity,
+				},
+			},
+			Feeds: feeds,
+		},
+	}
+	if i.opts.DryRun {
+		return i.print(sub)
+	}
+
+	subClient := i.clusternetClient.AppsV1alpha1().Subscriptions(sub.Namespace)
+	_, err = subClient.Create(ctx, sub, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		var current *appsapi.Subscription
+		current, err = subClient.Get(ctx, sub.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current.Spec.Subscribers = sub.Spec.Subscribers
+		current.Spec.Feeds = sub.Spec.Feeds
+		_, err = subClient.Update(ctx, current, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync Subscription %s: %v", klog.KObj(sub), err)
+	}
+	fmt.Fprintf(i.out, "Subscription %s generated with %d feeds\n", klog.KObj(sub), len(feeds))
+	return nil
+}
+
+func (i *Importer) print(obj interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(i.out, "---\n%s", data)
+	return err
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"errors"
+
+	"github.com/spf13/pflag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var defaultResources = []string{
+	"deployments",
+	"statefulsets",
+	"daemonsets",
+	"jobs",
+	"cronjobs",
+	"services",
+	"configmaps",
+	"secrets",
+	"serviceaccounts",
+}
+
+// ImportOptions holds the command-line options for importing workloads.
+type ImportOptions struct {
+	// SourceKubeConfig is the kubeconfig of the cluster where the live objects are read from
+	SourceKubeConfig string
+	// ParentKubeConfig is the kubeconfig of the parent cluster where clusternet-hub is running
+	ParentKubeConfig string
+
+	// Namespace is the namespace to import objects from
+	Namespace string
+	// Selector selects the objects to be imported
+	Selector string
+	// Resources is the list of resources to be imported
+	Resources []string
+
+	// SubscriptionName is the name of the generated Subscription
+	SubscriptionName string
+	// ClusterSelector selects the child clusters that the generated Subscription targets
+	ClusterSelector string
+
+	// DryRun only prints the objects to be imported without uploading them
+	DryRun bool
+}
+
+// NewImportOptions creates a new *ImportOptions with sane defaults
+func NewImportOptions() *ImportOptions {
+	return &ImportOptions{
+		Namespace: metav1.NamespaceDefault,
+		Resources: defaultResources,
+	}
+}
+
+// AddFlags adds the flags to the flagset.
+func (opts *ImportOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opts.SourceKubeConfig, "source-kubeconfig", opts.SourceKubeConfig,
+		"Path to a kubeconfig file for the cluster to import objects from")
+	fs.StringVar(&opts.ParentKubeConfig, "parent-kubeconfig", opts.ParentKubeConfig,
+		"Path to a kubeconfig file for the parent cluster where clusternet-hub is running")
+	fs.StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace,
+		"The namespace to import objects from")
+	fs.StringVarP(&opts.Selector, "selector", "l", opts.Selector,
+		"Label selector to filter the objects to be imported")
+	fs.StringSliceVar(&opts.Resources, "resources", opts.Resources,
+		"The resources to be imported")
+	fs.StringVar(&opts.SubscriptionName, "subscription-name", opts.SubscriptionName,
+		"The name of the Subscription to be generated, which lives in the same namespace as the imported objects")
+	fs.StringVar(&opts.ClusterSelector, "cluster-selector", opts.ClusterSelector,
+		"Label selector to choose the child clusters that the generated Subscription targets")
+	fs.BoolVar(&opts.DryRun, "dry-run", opts.DryRun,
+		"Only print the sanitized objects to be imported, without uploading them")
+}
+
+// Validate validates all the required options.
+func (opts *ImportOptions) Validate() []error {
+	var allErrs []error
+
+	if len(opts.SourceKubeConfig) == 0 {
+		allErrs = append(allErrs, errors.New("--source-kubeconfig must be specified"))
+	}
+	if len(opts.ParentKubeConfig) == 0 && !opts.DryRun {
+		allErrs = append(allErrs, errors.New("--parent-kubeconfig must be specified"))
+	}
+	if len(opts.Namespace) == 0 {
+		allErrs = append(allErrs, errors.New("--namespace must be specified"))
+	}
+	if len(opts.Resources) == 0 {
+		allErrs = append(allErrs, errors.New("--resources must be specified"))
+	}
+	if len(opts.SubscriptionName) == 0 {
+		allErrs = append(allErrs, errors.New("--subscription-name must be specified"))
+	}
+	if _, err := labels.Parse(opts.Selector); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	if _, err := metav1.ParseToLabelSelector(opts.ClusterSelector); err != nil {
+		allErrs = append(allErrs, err)
+	}
+
+	return allErrs
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// the ConfigMap holding the root ca, which is published in every namespace by kube-controller-manager
+	rootCAConfigMapName = "kube-root-ca.crt"
+	// the ServiceAccount created in every namespace by kube-controller-manager
+	defaultServiceAccountName = "default"
+)
+
+// annotations that are populated by servers or clients, which make no sense in other clusters
+var ignoredAnnotations = []string{
+	corev1.LastAppliedConfigAnnotation,
+	"deployment.kubernetes.io/revision",
+	"kubernetes.io/service-account.uid",
+}
+
+// shouldImport checks whether the object should be imported.
+// Objects managed by controllers or populated by the system are ignored.
+func shouldImport(obj *unstructured.Unstructured) bool {
+	if len(obj.GetOwnerReferences()) > 0 {
+		return false
+	}
+
+	switch obj.GetKind() {
+	case "ConfigMap":
+		return obj.GetName() != rootCAConfigMapName
+	case "ServiceAccount":
+		return obj.GetName() != defaultServiceAccountName
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType != string(corev1.SecretTypeServiceAccountToken)
+	}
+	return true
+}
+
+// sanitize removes the fields populated by servers, so that the object can be created in other clusters.
+func sanitize(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetSelfLink("")
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+	obj.SetFinalizers(nil)
+
+	annotations := obj.GetAnnotations()
+	for _, key := range ignoredAnnotations {
+		delete(annotations, key)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	switch obj.GetKind() {
+	case "Service":
+		// cluster ips are allocated by each cluster
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		ports, found, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+		if found {
+			for _, port := range ports {
+				if p, ok := port.(map[string]interface{}); ok {
+					delete(p, "nodePort")
+				}
+			}
+			_ = unstructured.SetNestedSlice(obj.Object, ports, "spec", "ports")
+		}
+	case "ServiceAccount":
+		// token secrets are generated by each cluster
+		unstructured.RemoveNestedField(obj.Object, "secrets")
+	case "Job":
+		// selectors and labels are generated with controller-uid
+		unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", "controller-uid")
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", "job-name")
+	}
+}
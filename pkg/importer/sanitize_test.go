@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package importer
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSanitize(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":              "nginx",
+				"namespace":         "default",
+				"uid":               "4a3e7d38-9b1d-4cde-9a4b-1b7e52f1a6c1",
+				"resourceVersion":   "12345",
+				"creationTimestamp": "2021-08-01T00:00:00Z",
+				"annotations": map[string]interface{}{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+				"labels": map[string]interface{}{
+					"app": "nginx",
+				},
+			},
+			"spec": map[string]interface{}{
+				"clusterIP":  "10.0.0.10",
+				"clusterIPs": []interface{}{"10.0.0.10"},
+				"ports": []interface{}{
+					map[string]interface{}{
+						"port":     int64(80),
+						"nodePort": int64(30080),
+					},
+				},
+				"type": "NodePort",
+			},
+			"status": map[string]interface{}{
+				"loadBalancer": map[string]interface{}{},
+			},
+		},
+	}
+
+	expected := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      "nginx",
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"app": "nginx",
+				},
+			},
+			"spec": map[string]interface{}{
+				"ports": []interface{}{
+					map[string]interface{}{
+						"port": int64(80),
+					},
+				},
+				"type": "NodePort",
+			},
+		},
+	}
+
+	sanitize(obj)
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("sanitize() = %v, want %v", obj.Object, expected.Object)
+	}
+}
+
+func TestShouldImport(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want bool
+	}{
+		{
+			name: "deployment",
+			obj: map[string]interface{}{
+				"kind":     "Deployment",
+				"metadata": map[string]interface{}{"name": "nginx"},
+			},
+			want: true,
+		},
+		{
+			name: "owned replicaset",
+			obj: map[string]interface{}{
+				"kind": "ReplicaSet",
+				"metadata": map[string]interface{}{
+					"name": "nginx-5d59d67564",
+					"ownerReferences": []interface{}{
+						map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "nginx", "uid": "abc"},
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "root ca configmap",
+			obj: map[string]interface{}{
+				"kind":     "ConfigMap",
+				"metadata": map[string]interface{}{"name": "kube-root-ca.crt"},
+			},
+			want: false,
+		},
+		{
+			name: "service account token",
+			obj: map[string]interface{}{
+				"kind":     "Secret",
+				"metadata": map[string]interface{}{"name": "default-token-abcde"},
+				"type":     "kubernetes.io/service-account-token",
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldImport(&unstructured.Unstructured{Object: tt.obj}); got != tt.want {
+				t.Errorf("shouldImport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
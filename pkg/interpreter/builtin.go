@@ -0,0 +1,253 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interpreter
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var builtinInterpreters = map[schema.GroupKind]ResourceInterpreter{
+	{Group: "apps", Kind: "Deployment"}:  deploymentInterpreter{},
+	{Group: "apps", Kind: "StatefulSet"}: statefulSetInterpreter{},
+	{Group: "apps", Kind: "DaemonSet"}:   daemonSetInterpreter{},
+	{Group: "batch", Kind: "Job"}:        jobInterpreter{},
+}
+
+// defaultInterpreter interprets objects by the conventions shared by most kinds, such as ".spec.replicas"
+// and condition Ready.
+type defaultInterpreter struct{}
+
+func (defaultInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	// objects without conditions are regarded as healthy once they exist
+	if conditions, found, _ := unstructured.NestedSlice(obj.Object, "status", "conditions"); !found || len(conditions) == 0 {
+		return true, "", nil
+	}
+	if IsConditionTrue(obj, "Ready") {
+		return true, "", nil
+	}
+	return false, "condition Ready is not True", nil
+}
+
+func (defaultInterpreter) GetReplicas(obj *unstructured.Unstructured) (int32, bool, error) {
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil || !found {
+		return 0, false, err
+	}
+	return int32(replicas), true, nil
+}
+
+// AggregateStatus sums up the integer fields, such as the ones collected by StatusAggregations.
+// Other fields are left out, since there is no common way to merge them.
+func (defaultInterpreter) AggregateStatus(_ *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	aggregated := sumStatusFields(statuses, getIntegerFields(statuses)...)
+	if len(aggregated) == 0 {
+		return nil, nil
+	}
+	return aggregated, nil
+}
+
+type deploymentInterpreter struct {
+	defaultInterpreter
+}
+
+func (deploymentInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	if !isGenerationObserved(obj) {
+		return false, "the latest generation is not observed yet", nil
+	}
+	replicas := getDesiredReplicas(obj)
+	updated := getStatusInt64(obj, "updatedReplicas")
+	available := getStatusInt64(obj, "availableReplicas")
+	if updated < replicas {
+		return false, fmt.Sprintf("%d out of %d new replicas have been updated", updated, replicas), nil
+	}
+	if available < replicas {
+		return false, fmt.Sprintf("%d of %d updated replicas are available", available, replicas), nil
+	}
+	return true, "", nil
+}
+
+func (deploymentInterpreter) AggregateStatus(_ *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	return sumStatusFields(statuses, "replicas", "updatedReplicas", "readyReplicas", "availableReplicas",
+		"unavailableReplicas"), nil
+}
+
+type statefulSetInterpreter struct {
+	defaultInterpreter
+}
+
+func (statefulSetInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	if !isGenerationObserved(obj) {
+		return false, "the latest generation is not observed yet", nil
+	}
+	replicas := getDesiredReplicas(obj)
+	ready := getStatusInt64(obj, "readyReplicas")
+	if ready < replicas {
+		return false, fmt.Sprintf("%d out of %d replicas are ready", ready, replicas), nil
+	}
+	return true, "", nil
+}
+
+func (statefulSetInterpreter) AggregateStatus(_ *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	return sumStatusFields(statuses, "replicas", "readyReplicas", "currentReplicas", "updatedReplicas",
+		"availableReplicas"), nil
+}
+
+type daemonSetInterpreter struct {
+	defaultInterpreter
+}
+
+func (daemonSetInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	if !isGenerationObserved(obj) {
+		return false, "the latest generation is not observed yet", nil
+	}
+	desired := getStatusInt64(obj, "desiredNumberScheduled")
+	updated := getStatusInt64(obj, "updatedNumberScheduled")
+	ready := getStatusInt64(obj, "numberReady")
+	if updated < desired {
+		return false, fmt.Sprintf("%d out of %d new pods have been updated", updated, desired), nil
+	}
+	if ready < desired {
+		return false, fmt.Sprintf("%d of %d updated pods are ready", ready, desired), nil
+	}
+	return true, "", nil
+}
+
+func (daemonSetInterpreter) GetReplicas(*unstructured.Unstructured) (int32, bool, error) {
+	// pods of DaemonSets are scheduled by nodes
+	return 0, false, nil
+}
+
+func (daemonSetInterpreter) AggregateStatus(_ *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	return sumStatusFields(statuses, "currentNumberScheduled", "desiredNumberScheduled", "numberAvailable",
+		"numberMisscheduled", "numberReady", "numberUnavailable", "updatedNumberScheduled"), nil
+}
+
+type jobInterpreter struct {
+	defaultInterpreter
+}
+
+func (jobInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	if IsConditionTrue(obj, "Complete") {
+		return true, "", nil
+	}
+	if IsConditionTrue(obj, "Failed") {
+		return false, "job has failed", nil
+	}
+	return false, "job has not completed yet", nil
+}
+
+func (jobInterpreter) AggregateStatus(_ *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	return sumStatusFields(statuses, "active", "succeeded", "failed"), nil
+}
+
+// IsConditionTrue returns whether the condition of the given type is True in the status of the object.
+func IsConditionTrue(obj *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == conditionType {
+			return condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+func isGenerationObserved(obj *unstructured.Unstructured) bool {
+	return getStatusInt64(obj, "observedGeneration") >= obj.GetGeneration()
+}
+
+func getDesiredReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+func getStatusInt64(obj *unstructured.Unstructured, field string) int64 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+	return value
+}
+
+// sumStatusFields sums up the integer fields of the statuses. Fields missing in all the statuses are left out.
+func sumStatusFields(statuses []ClusterStatus, fields ...string) map[string]interface{} {
+	aggregated := map[string]interface{}{}
+	for _, field := range fields {
+		var sum int64
+		var found bool
+		for _, status := range statuses {
+			if value, ok := toInt64(status.Status[field]); ok {
+				sum += value
+				found = true
+			}
+		}
+		if found {
+			aggregated[field] = sum
+		}
+	}
+	return aggregated
+}
+
+// getIntegerFields returns the sorted names of the fields that are integers in all the statuses having them.
+func getIntegerFields(statuses []ClusterStatus) []string {
+	integers := map[string]bool{}
+	for _, status := range statuses {
+		for field, value := range status.Status {
+			_, ok := toInt64(value)
+			if f, isFloat := value.(float64); isFloat && f != math.Trunc(f) {
+				ok = false
+			}
+			if previous, seen := integers[field]; seen {
+				ok = ok && previous
+			}
+			integers[field] = ok
+		}
+	}
+
+	var fields []string
+	for field, ok := range integers {
+		if ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// toInt64 converts the integer, which is decoded as float64 by encoding/json, to int64.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
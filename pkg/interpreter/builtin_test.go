@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interpreter
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestInterpretHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		obj         map[string]interface{}
+		wantHealthy bool
+		wantMessage string
+	}{
+		{
+			name: "deployment with unavailable replicas",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"generation": int64(2)},
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"observedGeneration": int64(2), "updatedReplicas": int64(3),
+					"availableReplicas": int64(2)},
+			},
+			wantMessage: "2 of 3 updated replicas are available",
+		},
+		{
+			name: "statefulset with stale status",
+			obj: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "StatefulSet",
+				"metadata":   map[string]interface{}{"generation": int64(3)},
+				"spec":       map[string]interface{}{"replicas": int64(3)},
+				"status":     map[string]interface{}{"observedGeneration": int64(2), "readyReplicas": int64(3)},
+			},
+			wantMessage: "the latest generation is not observed yet",
+		},
+		{
+			name: "failed job",
+			obj: map[string]interface{}{
+				"apiVersion": "batch/v1",
+				"kind":       "Job",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Failed", "status": "True"},
+				}},
+			},
+			wantMessage: "job has failed",
+		},
+		{
+			name: "custom resource not ready",
+			obj: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Database",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "False"},
+				}},
+			},
+			wantMessage: "condition Ready is not True",
+		},
+		{
+			name: "configmap",
+			obj: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+			},
+			wantHealthy: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthy, message, err := InterpretHealth(&unstructured.Unstructured{Object: tt.obj})
+			if err != nil {
+				t.Fatalf("InterpretHealth() error = %v", err)
+			}
+			if healthy != tt.wantHealthy || message != tt.wantMessage {
+				t.Errorf("InterpretHealth() = %v, %q, want %v, %q", healthy, message, tt.wantHealthy, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestGetReplicas(t *testing.T) {
+	tests := []struct {
+		name         string
+		raw          string
+		wantReplicas int32
+		wantFound    bool
+	}{
+		{
+			name:         "deployment",
+			raw:          `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"foo"},"spec":{"replicas":5}}`,
+			wantReplicas: 5,
+			wantFound:    true,
+		},
+		{
+			name: "daemonset",
+			raw:  `{"apiVersion":"apps/v1","kind":"DaemonSet","metadata":{"name":"foo"},"spec":{}}`,
+		},
+		{
+			name: "configmap",
+			raw:  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas, found, err := GetReplicas([]byte(tt.raw))
+			if err != nil {
+				t.Fatalf("GetReplicas() error = %v", err)
+			}
+			if replicas != tt.wantReplicas || found != tt.wantFound {
+				t.Errorf("GetReplicas() = %d, %v, want %d, %v", replicas, found, tt.wantReplicas, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestAggregateStatus(t *testing.T) {
+	deploy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+	}}
+	statuses := []ClusterStatus{
+		{Cluster: "ns-a", Status: map[string]interface{}{"replicas": int64(2), "readyReplicas": int64(2)}},
+		// decoded by encoding/json
+		{Cluster: "ns-b", Status: map[string]interface{}{"replicas": float64(3), "readyReplicas": float64(1)}},
+	}
+	got, err := AggregateStatus(deploy, statuses)
+	if err != nil {
+		t.Fatalf("AggregateStatus() error = %v", err)
+	}
+	want := map[string]interface{}{"replicas": int64(5), "readyReplicas": int64(3)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateStatus() = %v, want %v", got, want)
+	}
+
+	// kinds without interpreters sum up all the integer fields
+	custom := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Database",
+	}}
+	statuses = []ClusterStatus{
+		{Cluster: "ns-a", Status: map[string]interface{}{"connections": float64(2), "phase": "Ready", "load": float64(0.5)}},
+		{Cluster: "ns-b", Status: map[string]interface{}{"connections": float64(3), "load": float64(1)}},
+	}
+	got, err = AggregateStatus(custom, statuses)
+	if err != nil {
+		t.Fatalf("AggregateStatus() error = %v", err)
+	}
+	want = map[string]interface{}{"connections": int64(5)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AggregateStatus() = %v, want %v", got, want)
+	}
+}
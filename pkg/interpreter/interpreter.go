@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interpreter
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ResourceInterpreter tells how to interpret the objects of a kind, such as whether they are healthy.
+type ResourceInterpreter interface {
+	// InterpretHealth tells whether the live object in a child cluster is healthy, with a message telling why
+	// if it is not.
+	InterpretHealth(obj *unstructured.Unstructured) (bool, string, error)
+
+	// GetReplicas returns the desired replicas of the object, which are divided across clusters on scheduling.
+	// It returns false if the object has no replicas.
+	GetReplicas(obj *unstructured.Unstructured) (int32, bool, error)
+
+	// AggregateStatus merges the statuses of the object collected from child clusters into a single one.
+	// It returns nil if the statuses of the kind could not be aggregated.
+	AggregateStatus(obj *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error)
+}
+
+// ClusterStatus is the status of an object collected from a child cluster.
+type ClusterStatus struct {
+	// Cluster is the namespace of the ManagedCluster.
+	Cluster string `json:"cluster"`
+	// Status is the status of the object in the cluster.
+	Status map[string]interface{} `json:"status,omitempty"`
+}
+
+// Registry holds the interpreters of kinds. Interpreters registered later take precedence, such as webhooks
+// over the built-in ones, and the kinds without interpreters fall back to the default one.
+type Registry struct {
+	lock         sync.RWMutex
+	interpreters map[schema.GroupKind]ResourceInterpreter
+	fallback     ResourceInterpreter
+}
+
+// NewRegistry returns a Registry with the built-in interpreters.
+func NewRegistry() *Registry {
+	r := &Registry{
+		interpreters: map[schema.GroupKind]ResourceInterpreter{},
+		fallback:     defaultInterpreter{},
+	}
+	for gk, interpreter := range builtinInterpreters {
+		r.interpreters[gk] = interpreter
+	}
+	return r
+}
+
+// Register registers the interpreter of the kind, replacing the existing one.
+func (r *Registry) Register(gk schema.GroupKind, interpreter ResourceInterpreter) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.interpreters[gk] = interpreter
+}
+
+// Get returns the interpreter of the kind, or the default one if no interpreter is registered.
+func (r *Registry) Get(gk schema.GroupKind) ResourceInterpreter {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if interpreter, ok := r.interpreters[gk]; ok {
+		return interpreter
+	}
+	return r.fallback
+}
+
+// DefaultRegistry is the Registry shared by the controllers of clusternet-hub and clusternet-agent.
+var DefaultRegistry = NewRegistry()
+
+// InterpretHealth tells whether the object is healthy with the interpreter of its kind in DefaultRegistry.
+func InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	return DefaultRegistry.Get(obj.GroupVersionKind().GroupKind()).InterpretHealth(obj)
+}
+
+// GetReplicas returns the desired replicas of the serialized object with the interpreter of its kind
+// in DefaultRegistry.
+func GetReplicas(raw []byte) (int32, bool, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return 0, false, err
+	}
+	return DefaultRegistry.Get(obj.GroupVersionKind().GroupKind()).GetReplicas(obj)
+}
+
+// AggregateStatus merges the statuses of the object with the interpreter of its kind in DefaultRegistry.
+func AggregateStatus(obj *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	return DefaultRegistry.Get(obj.GroupVersionKind().GroupKind()).AggregateStatus(obj, statuses)
+}
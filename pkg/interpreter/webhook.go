@@ -0,0 +1,252 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Operation is an operation of ResourceInterpreter that could be served by webhooks.
+type Operation string
+
+const (
+	OperationInterpretHealth Operation = "InterpretHealth"
+	OperationGetReplicas     Operation = "GetReplicas"
+	OperationAggregateStatus Operation = "AggregateStatus"
+)
+
+// defaultWebhookTimeout is the timeout of requests to webhooks without timeoutSeconds
+const defaultWebhookTimeout = 10 * time.Second
+
+// WebhookConfiguration declares the webhooks interpreting custom kinds, which is loaded from a YAML file.
+type WebhookConfiguration struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// Webhook serves the operations of ResourceInterpreter for the kinds matched by its rules.
+type Webhook struct {
+	// Name of the webhook.
+	Name string `json:"name"`
+	// URL where the requests are posted to in JSON, which must be http or https.
+	URL string `json:"url"`
+	// CABundle is the PEM-encoded CA bundle to verify the serving certificate of the webhook.
+	// System roots are used if not set.
+	CABundle string `json:"caBundle,omitempty"`
+	// TimeoutSeconds is the timeout of each request, which defaults to 10.
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// Rules select the kinds interpreted by the webhook.
+	Rules []Rule `json:"rules"`
+	// Operations served by the webhook. The other operations fall back to the built-in interpreters.
+	// All the operations are served if empty.
+	Operations []Operation `json:"operations,omitempty"`
+}
+
+// Rule selects the kinds in the API groups.
+type Rule struct {
+	// APIGroups of the kinds, where "" is the core group.
+	APIGroups []string `json:"apiGroups"`
+	// Kinds in the API groups.
+	Kinds []string `json:"kinds"`
+}
+
+// Request is posted to webhooks.
+type Request struct {
+	// Operation requested.
+	Operation Operation `json:"operation"`
+	// Object to interpret, which is the live object for InterpretHealth and AggregateStatus,
+	// and the desired object for GetReplicas.
+	Object json.RawMessage `json:"object"`
+	// Statuses of the object collected from child clusters, which is only set for AggregateStatus.
+	Statuses []ClusterStatus `json:"statuses,omitempty"`
+}
+
+// Response is returned by webhooks.
+type Response struct {
+	// Healthy tells whether the object is healthy, for InterpretHealth.
+	Healthy bool `json:"healthy,omitempty"`
+	// Message tells why the object is not healthy, for InterpretHealth.
+	Message string `json:"message,omitempty"`
+	// Replicas of the object for GetReplicas, which is nil if the object has no replicas.
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Status aggregated for AggregateStatus.
+	Status map[string]interface{} `json:"status,omitempty"`
+}
+
+// LoadWebhooks loads the webhooks from the file, and registers them to DefaultRegistry.
+func LoadWebhooks(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	config := &WebhookConfiguration{}
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse resource interpreter webhooks in %s: %v", file, err)
+	}
+
+	for _, webhook := range config.Webhooks {
+		interpreter, err := newWebhookInterpreter(webhook)
+		if err != nil {
+			return fmt.Errorf("invalid resource interpreter webhook %q: %v", webhook.Name, err)
+		}
+		for _, rule := range webhook.Rules {
+			for _, group := range rule.APIGroups {
+				for _, kind := range rule.Kinds {
+					gk := schema.GroupKind{Group: group, Kind: kind}
+					klog.V(4).Infof("registering resource interpreter webhook %q for %s", webhook.Name, gk)
+					DefaultRegistry.Register(gk, interpreter.withFallback(DefaultRegistry.Get(gk)))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type webhookInterpreter struct {
+	name       string
+	url        string
+	client     *http.Client
+	operations sets.String
+
+	// fallback serves the operations not served by the webhook
+	fallback ResourceInterpreter
+}
+
+func newWebhookInterpreter(webhook Webhook) (*webhookInterpreter, error) {
+	if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("url must be a valid http or https url")
+	}
+	if len(webhook.Rules) == 0 {
+		return nil, fmt.Errorf("rules must not be empty")
+	}
+	operations := sets.NewString()
+	for _, op := range webhook.Operations {
+		switch op {
+		case OperationInterpretHealth, OperationGetReplicas, OperationAggregateStatus:
+			operations.Insert(string(op))
+		default:
+			return nil, fmt.Errorf("unsupported operation %q", op)
+		}
+	}
+	if operations.Len() == 0 {
+		operations.Insert(string(OperationInterpretHealth), string(OperationGetReplicas), string(OperationAggregateStatus))
+	}
+
+	timeout := defaultWebhookTimeout
+	if webhook.TimeoutSeconds > 0 {
+		timeout = time.Duration(webhook.TimeoutSeconds) * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(webhook.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(webhook.CABundle)) {
+			return nil, fmt.Errorf("no certificates are found in caBundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &webhookInterpreter{
+		name:       webhook.Name,
+		url:        webhook.URL,
+		client:     &http.Client{Transport: transport, Timeout: timeout},
+		operations: operations,
+	}, nil
+}
+
+func (w *webhookInterpreter) withFallback(fallback ResourceInterpreter) *webhookInterpreter {
+	interpreter := *w
+	interpreter.fallback = fallback
+	return &interpreter
+}
+
+func (w *webhookInterpreter) InterpretHealth(obj *unstructured.Unstructured) (bool, string, error) {
+	if !w.operations.Has(string(OperationInterpretHealth)) {
+		return w.fallback.InterpretHealth(obj)
+	}
+	resp, err := w.call(OperationInterpretHealth, obj, nil)
+	if err != nil {
+		return false, "", err
+	}
+	return resp.Healthy, resp.Message, nil
+}
+
+func (w *webhookInterpreter) GetReplicas(obj *unstructured.Unstructured) (int32, bool, error) {
+	if !w.operations.Has(string(OperationGetReplicas)) {
+		return w.fallback.GetReplicas(obj)
+	}
+	resp, err := w.call(OperationGetReplicas, obj, nil)
+	if err != nil || resp.Replicas == nil {
+		return 0, false, err
+	}
+	return *resp.Replicas, true, nil
+}
+
+func (w *webhookInterpreter) AggregateStatus(obj *unstructured.Unstructured, statuses []ClusterStatus) (map[string]interface{}, error) {
+	if !w.operations.Has(string(OperationAggregateStatus)) {
+		return w.fallback.AggregateStatus(obj, statuses)
+	}
+	resp, err := w.call(OperationAggregateStatus, obj, statuses)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+func (w *webhookInterpreter) call(op Operation, obj *unstructured.Unstructured, statuses []ClusterStatus) (*Response, error) {
+	object, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(Request{Operation: op, Object: object, Statuses: statuses})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpResp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call resource interpreter webhook %q: %v", w.name, err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < http.StatusOK || httpResp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("resource interpreter webhook %q returns status code %d", w.name, httpResp.StatusCode)
+	}
+
+	resp := &Response{}
+	if err = json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response of resource interpreter webhook %q: %v", w.name, err)
+	}
+	return resp, nil
+}
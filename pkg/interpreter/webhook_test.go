@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestLoadWebhooks(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Request{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(Response{Healthy: false, Message: "replication is lagging"})
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "interpreter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "webhooks.yaml")
+	config := fmt.Sprintf(`webhooks:
+- name: databases
+  url: %s
+  rules:
+  - apiGroups: ["example.com"]
+    kinds: ["Database"]
+  operations: ["InterpretHealth"]
+`, server.URL)
+	if err = ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = LoadWebhooks(file); err != nil {
+		t.Fatalf("LoadWebhooks() error = %v", err)
+	}
+
+	db := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Database",
+		"metadata":   map[string]interface{}{"name": "orders"},
+		"spec":       map[string]interface{}{"replicas": int64(3)},
+	}}
+	healthy, message, err := InterpretHealth(db)
+	if err != nil || healthy || message != "replication is lagging" {
+		t.Errorf("InterpretHealth() = %v, %q, %v, want false, %q, nil", healthy, message, err, "replication is lagging")
+	}
+	if len(requests) != 1 || requests[0].Operation != OperationInterpretHealth {
+		t.Fatalf("unexpected requests to webhook: %+v", requests)
+	}
+
+	// operations not served by the webhook fall back to the built-in interpreters
+	raw, _ := db.MarshalJSON()
+	replicas, found, err := GetReplicas(raw)
+	if err != nil || !found || replicas != 3 {
+		t.Errorf("GetReplicas() = %d, %v, %v, want 3, true, nil", replicas, found, err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("GetReplicas() should not be sent to webhook")
+	}
+}
+
+func TestNewWebhookInterpreter(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook Webhook
+		wantErr bool
+	}{
+		{
+			name:    "invalid url",
+			webhook: Webhook{Name: "foo", URL: "ftp://example.com", Rules: []Rule{{APIGroups: []string{""}, Kinds: []string{"Pod"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "no rules",
+			webhook: Webhook{Name: "foo", URL: "https://example.com"},
+			wantErr: true,
+		},
+		{
+			name: "unsupported operation",
+			webhook: Webhook{Name: "foo", URL: "https://example.com", Rules: []Rule{{APIGroups: []string{""}, Kinds: []string{"Pod"}}},
+				Operations: []Operation{"Retain"}},
+			wantErr: true,
+		},
+		{
+			name:    "valid",
+			webhook: Webhook{Name: "foo", URL: "https://example.com", Rules: []Rule{{APIGroups: []string{""}, Kinds: []string{"Pod"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newWebhookInterpreter(tt.webhook); (err != nil) != tt.wantErr {
+				t.Errorf("newWebhookInterpreter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
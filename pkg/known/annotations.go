@@ -23,4 +23,95 @@ const (
 
 	// FeedProtectionAnnotation passes detailed message on protecting current object as a feed
 	FeedProtectionAnnotation = "apps.clusternet.io/feed-protection"
+
+	// JobsFinishedGenerationAnnotation records the generation of a Description or Base whose Jobs have finished
+	// and been cleaned up, so that these Jobs won't be deployed again until the generation changes
+	JobsFinishedGenerationAnnotation = "apps.clusternet.io/jobs-finished-generation"
+
+	// OverridesFailureAnnotation records the error of applying overrides to the Descriptions populated from a Base
+	OverridesFailureAnnotation = "apps.clusternet.io/overrides-failure"
+
+	// RefreshRequestedAnnotation requests clusternet-agent to re-collect and report the status of a ManagedCluster
+	// immediately. Any new value triggers a refresh, and the annotation is removed once the status gets reported.
+	RefreshRequestedAnnotation = "clusters.clusternet.io/refresh-requested"
+
+	// PropagatedLabelsAnnotation records the keys of labels propagated by clusternet-agent to the ManagedCluster
+	PropagatedLabelsAnnotation = "clusters.clusternet.io/propagated-labels"
+
+	// PropagatedTaintsAnnotation records the keys of taints propagated by clusternet-agent to the ManagedCluster
+	PropagatedTaintsAnnotation = "clusters.clusternet.io/propagated-taints"
+
+	// RolloutPromoteAnnotation promotes the rollout of a Subscription without automatic promotion,
+	// whose value is the number of batches allowed to be rolled out
+	RolloutPromoteAnnotation = "apps.clusternet.io/rollout-promote"
+
+	// FailedSpecAnnotation records the hash of the spec that a Description is rolled back from,
+	// which won't be populated to the Description again
+	FailedSpecAnnotation = "apps.clusternet.io/failed-spec"
+
+	// RollbackToAnnotation rolls back the feeds of a Subscription to a revision in its history,
+	// and 0 means the previous revision
+	RollbackToAnnotation = "apps.clusternet.io/rollback-to"
+
+	// ApplyWaveAnnotation is annotated on feed objects with an integer, and objects in a wave are deployed
+	// only after all the objects in previous waves are ready. Objects without it are in wave 0.
+	ApplyWaveAnnotation = "apps.clusternet.io/apply-wave"
+
+	// ImageRegistryMirrorsAnnotation is annotated on ManagedClusters to rewrite the container images deployed to the
+	// clusters with registry mirrors, such as "docker.io=mirror.example.com/dockerhub,quay.io=mirror.example.com/quay"
+	ImageRegistryMirrorsAnnotation = "clusters.clusternet.io/image-registry-mirrors"
+
+	// ApprovalRequiredAnnotation is annotated on Descriptions whose changes are applied only after
+	// condition Approved is set to True for their latest generations
+	ApprovalRequiredAnnotation = "apps.clusternet.io/approval-required"
+
+	// PrunedObjectsAnnotation is annotated on Descriptions with the objects removed from their specs,
+	// which are waiting to be deleted from the child cluster
+	PrunedObjectsAnnotation = "apps.clusternet.io/pruned-objects"
+
+	// IgnoreFieldsAnnotation is annotated on feed objects with comma-separated paths of the fields managed by
+	// controllers in child clusters, such as "spec.replicas,metadata.annotations.sidecar.istio.io/status". The fields
+	// are set only when creating the objects, and are neither reconciled nor treated as drifts afterwards.
+	IgnoreFieldsAnnotation = "apps.clusternet.io/ignore-fields"
+
+	// CreatedByAnnotation is annotated on Manifests with the user creating the shadow resource in JSON,
+	// such as {"user":"alice","impersonator":"bob","time":"2021-08-01T00:00:00Z"}
+	CreatedByAnnotation = "apps.clusternet.io/created-by"
+
+	// UpdatedByAnnotation is annotated on Manifests with the user updating the shadow resource last time in JSON,
+	// in the same format as CreatedByAnnotation
+	UpdatedByAnnotation = "apps.clusternet.io/updated-by"
+
+	// RBACNamespacesAnnotation is annotated on ClusterRoleBindings propagated to child clusters with comma-separated
+	// namespaces, such as "foo,bar", where the bindings are translated into RoleBindings instead
+	RBACNamespacesAnnotation = "clusters.clusternet.io/rbac-namespaces"
+
+	// TunnelBandwidthAnnotation is annotated on ManagedClusters to limit the bytes per second transferred through the
+	// tunnels of the clusters in each direction, in the format of quantities, such as "10Mi"
+	TunnelBandwidthAnnotation = "clusters.clusternet.io/tunnel-bandwidth"
+
+	// TunnelCompressionAnnotation is annotated on ManagedClusters to compress the traffic through the tunnels
+	// of the clusters, such as "gzip"
+	TunnelCompressionAnnotation = "clusters.clusternet.io/tunnel-compression"
+
+	// RenewRegistrationAnnotation is annotated on ClusterRegistrationRequests by clusternet-agent with the time
+	// in RFC3339 requesting the renewal of the dedicated token, which is approved by clusternet-hub under policy
+	RenewRegistrationAnnotation = "clusters.clusternet.io/renew-registration"
+
+	// RegistrationRenewedAnnotation is annotated on ClusterRegistrationRequests by clusternet-hub with the value of
+	// RenewRegistrationAnnotation, once a new dedicated token is populated in the status
+	RegistrationRenewedAnnotation = "clusters.clusternet.io/registration-renewed"
+
+	// CredentialsIssuedAtAnnotation is annotated on the Secret storing the credentials of parent cluster in child
+	// clusters with the time in RFC3339 when the dedicated token gets issued
+	CredentialsIssuedAtAnnotation = "clusters.clusternet.io/credentials-issued-at"
+
+	// RegistrationAddressesAnnotation is annotated on ClusterRegistrationRequests by clusternet-agent with the
+	// comma-separated internal IPs of the nodes in child cluster, which are matched by the approval policies
+	RegistrationAddressesAnnotation = "clusters.clusternet.io/registration-addresses"
+
+	// BootstrapTokenSignatureAnnotation is annotated on ClusterRegistrationRequests by clusternet-agent in the format
+	// of "<token id>:<signature>", which signs the cluster id with the bootstrap token, so that clusternet-hub knows
+	// which bootstrap token the request comes from
+	BootstrapTokenSignatureAnnotation = "clusters.clusternet.io/bootstrap-token-signature"
 )
@@ -29,8 +29,56 @@ const (
 	ClusterAPIServerURLKey = "apiserver-advertise-url"
 )
 
+// These are the settings of tunnels set up by clusternet-agent with socket connection.
+const (
+	// TunnelIndexHeader is the header carrying the index of a tunnel in the connection pool of a cluster
+	TunnelIndexHeader = "Clusternet-Tunnel-Index"
+
+	// MaxTunnelsPerCluster is the max number of tunnels in the connection pool of a cluster
+	MaxTunnelsPerCluster = 16
+
+	// TunnelTransportWebSocket sets up tunnels with websocket connections
+	TunnelTransportWebSocket = "websocket"
+	// TunnelTransportGRPC sets up tunnels with gRPC bidirectional streams
+	TunnelTransportGRPC = "grpc"
+
+	// TunnelCompressionGzip compresses the traffic through tunnels with gzip
+	TunnelCompressionGzip = "gzip"
+)
+
+// These are the identities of child clusters with client certificates signed by clusternet-hub.
+const (
+	// ClusterAgentSignerName is the signer name of CertificateSigningRequests submitted by clusternet-agent
+	ClusterAgentSignerName = "clusternet.io/cluster-agent"
+
+	// ClusterAgentUserPrefix is the prefix of common names in the client certificates of child clusters,
+	// which is followed by the cluster id
+	ClusterAgentUserPrefix = "clusternet:cluster:"
+
+	// ClusterAgentGroup is the organization in the client certificates of child clusters
+	ClusterAgentGroup = "clusternet:clusters"
+
+	// ClusterRegistrationTokenGroup is the extra group of bootstrap tokens used by clusternet-agent for registration,
+	// which is only allowed to create ClusterRegistrationRequests
+	ClusterRegistrationTokenGroup = "system:bootstrappers:clusternet:register-cluster-token"
+)
+
+// These are the taints managed by clusternet-hub for cordoning, draining and failing over ManagedClusters.
+const (
+	// TaintClusterUnschedulable is added to clusters with spec.unschedulable or spec.drain set
+	TaintClusterUnschedulable = "clusters.clusternet.io/unschedulable"
+
+	// TaintClusterDraining is added to clusters with spec.drain set
+	TaintClusterDraining = "clusters.clusternet.io/draining"
+
+	// TaintClusterUnhealthy is added to clusters staying not ready for longer than the failover tolerance
+	TaintClusterUnhealthy = "clusters.clusternet.io/unhealthy"
+)
+
 // These are internal finalizer values to Clusternet, must be qualified name.
 const (
 	AppFinalizer            string = "apps.clusternet.io/finalizer"
 	FeedProtectionFinalizer string = "apps.clusternet.io/feed-protection"
+	// HelmReleaseProtectionFinalizer blocks the deletion of Descriptions until all their HelmReleases are deleted
+	HelmReleaseProtectionFinalizer string = "apps.clusternet.io/helmrelease-protection"
 )
@@ -22,6 +22,10 @@ const (
 	ClusterIDLabel            = "clusters.clusternet.io/cluster-id"
 	ClusterNameLabel          = "clusters.clusternet.io/cluster-name"
 	ClusterBootstrappingLabel = "clusters.clusternet.io/bootstrapping"
+	ClusterProviderLabel      = "clusters.clusternet.io/provider"
+	// ClusterEvictedLabel is labeled on ManagedClusters that have lost heartbeats for too long,
+	// whose workloads are evicted until the heartbeats recover.
+	ClusterEvictedLabel = "clusters.clusternet.io/evicted"
 
 	ObjectCreatedByLabel = "clusternet.io/created-by"
 
@@ -36,6 +40,14 @@ const (
 	ConfigSubscriptionUIDLabel       = "apps.clusternet.io/subs.uid"
 	ConfigSubscriptionNameLabel      = "apps.clusternet.io/subs.name"
 	ConfigSubscriptionNamespaceLabel = "apps.clusternet.io/subs.namespace"
+
+	// RolloutRevisionLabel is labeled on Bases admitted to the rollout of a revision of the feeds,
+	// as well as the Descriptions populated from the revision
+	RolloutRevisionLabel = "apps.clusternet.io/rollout-revision"
+
+	// RBACPropagationLabel is labeled with "true" on ClusterRoles and ClusterRoleBindings in the parent cluster
+	// that are propagated to all the child clusters, as well as on the propagated ones in child clusters
+	RBACPropagationLabel = "clusters.clusternet.io/rbac-propagation"
 )
 
 // label value
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	proxies "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+const (
+	category = "clusternet"
+)
+
+// Differ compares the manifests of a Description with the live objects in the child cluster.
+type Differ interface {
+	Diff(ctx context.Context, namespace, name string) (*proxies.DescriptionDiff, error)
+}
+
+// REST implements a RESTStorage for DescriptionDiffs, which are computed on every request
+// and never stored.
+type REST struct {
+	differ Differ
+}
+
+func (r *REST) ShortNames() []string {
+	return []string{"descdiff"}
+}
+
+func (r *REST) NamespaceScoped() bool {
+	return true
+}
+
+func (r *REST) Categories() []string {
+	return []string{category}
+}
+
+func (r *REST) New() runtime.Object {
+	return &proxies.DescriptionDiff{}
+}
+
+// Get compares the Description with the given name with the live objects in the child cluster
+func (r *REST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	if r.differ == nil {
+		return nil, apierrors.NewServiceUnavailable("deployer has not been enabled on the server side")
+	}
+	namespace, ok := request.NamespaceFrom(ctx)
+	if !ok || len(namespace) == 0 {
+		return nil, apierrors.NewBadRequest("namespace is required")
+	}
+	return r.differ.Diff(ctx, namespace, name)
+}
+
+// NewREST returns a RESTStorage object that will work against API services.
+func NewREST(differ Differ) *REST {
+	return &REST{
+		differ: differ,
+	}
+}
+
+var _ rest.CategoriesProvider = &REST{}
+var _ rest.ShortNamesProvider = &REST{}
+var _ rest.Getter = &REST{}
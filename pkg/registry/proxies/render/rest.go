@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"sigs.k8s.io/yaml"
+
+	proxies "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+const (
+	category = "clusternet"
+)
+
+// Renderer renders the final manifests of a Subscription for a child cluster.
+type Renderer interface {
+	Render(namespace, name, cluster string) ([][]byte, error)
+}
+
+// REST implements a RESTStorage for rendering Subscriptions, which previews the manifests
+// with all overrides applied for a child cluster, without changing anything.
+type REST struct {
+	renderer Renderer
+}
+
+func (r *REST) NamespaceScoped() bool {
+	return true
+}
+
+func (r *REST) Categories() []string {
+	return []string{category}
+}
+
+func (r *REST) New() runtime.Object {
+	return &proxies.Render{}
+}
+
+// ConnectMethods returns the list of HTTP methods that can be used for rendering
+func (r *REST) ConnectMethods() []string {
+	return []string{"GET", "POST"}
+}
+
+// NewConnectOptions returns versioned resource that represents render parameters
+func (r *REST) NewConnectOptions() (runtime.Object, bool, string) {
+	return &proxies.Render{}, false, ""
+}
+
+// Connect returns a handler that writes the rendered manifests of the Subscription as a List
+func (r *REST) Connect(ctx context.Context, name string, opts runtime.Object, responder rest.Responder) (http.Handler, error) {
+	if r.renderer == nil {
+		return nil, apierrors.NewServiceUnavailable("deployer has not been enabled on the server side")
+	}
+	renderOpts, ok := opts.(*proxies.Render)
+	if !ok {
+		return nil, fmt.Errorf("invalid options object: %#v", opts)
+	}
+	if len(renderOpts.Cluster) == 0 {
+		return nil, apierrors.NewBadRequest("query parameter cluster must be specified")
+	}
+	namespace, ok := request.NamespaceFrom(ctx)
+	if !ok || len(namespace) == 0 {
+		return nil, apierrors.NewBadRequest("namespace is required")
+	}
+
+	objects, err := r.renderer.Render(namespace, name, renderOpts.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &metav1.List{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "List",
+		},
+		Items: make([]runtime.RawExtension, 0, len(objects)),
+	}
+	for _, object := range objects {
+		list.Items = append(list.Items, runtime.RawExtension{Raw: object})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, err := json.Marshal(list)
+		if err != nil {
+			responder.Error(err)
+			return
+		}
+		contentType := "application/json"
+		if strings.Contains(req.Header.Get("Accept"), "yaml") {
+			data, err = yaml.JSONToYAML(data)
+			if err != nil {
+				responder.Error(err)
+				return
+			}
+			contentType = "application/yaml"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	}), nil
+}
+
+// NewREST returns a RESTStorage object that will work against API services.
+func NewREST(renderer Renderer) *REST {
+	return &REST{
+		renderer: renderer,
+	}
+}
+
+var _ rest.CategoriesProvider = &REST{}
+var _ rest.Connecter = &REST{}
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	proxies "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+type fakeRenderer struct {
+	objects [][]byte
+}
+
+func (f *fakeRenderer) Render(namespace, name, cluster string) ([][]byte, error) {
+	if namespace != "default" || name != "app-demo" {
+		return nil, apierrors.NewNotFound(appsapi.Resource("subscriptions"), name)
+	}
+	return f.objects, nil
+}
+
+type fakeResponder struct {
+	err error
+}
+
+func (f *fakeResponder) Object(statusCode int, obj runtime.Object) {}
+
+func (f *fakeResponder) Error(err error) {
+	f.err = err
+}
+
+func TestConnect(t *testing.T) {
+	renderer := &fakeRenderer{
+		objects: [][]byte{
+			[]byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"demo","namespace":"default"}}`),
+		},
+	}
+	ctx := request.WithNamespace(context.Background(), "default")
+
+	tests := []struct {
+		name        string
+		storage     *REST
+		ctx         context.Context
+		subName     string
+		cluster     string
+		accept      string
+		wantErr     bool
+		contentType string
+		want        string
+	}{
+		{
+			name:        "render as json",
+			storage:     NewREST(renderer),
+			ctx:         ctx,
+			subName:     "app-demo",
+			cluster:     "clusternet-cluster-dzqkw",
+			contentType: "application/json",
+			want:        `{"kind":"List","apiVersion":"v1","metadata":{},"items":[{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"demo","namespace":"default"}}]}`,
+		},
+		{
+			name:        "render as yaml",
+			storage:     NewREST(renderer),
+			ctx:         ctx,
+			subName:     "app-demo",
+			cluster:     "clusternet-cluster-dzqkw",
+			accept:      "application/yaml",
+			contentType: "application/yaml",
+			want:        "kind: ConfigMap",
+		},
+		{
+			name:    "missing cluster",
+			storage: NewREST(renderer),
+			ctx:     ctx,
+			subName: "app-demo",
+			wantErr: true,
+		},
+		{
+			name:    "missing namespace",
+			storage: NewREST(renderer),
+			ctx:     context.Background(),
+			subName: "app-demo",
+			cluster: "clusternet-cluster-dzqkw",
+			wantErr: true,
+		},
+		{
+			name:    "nonexistent subscription",
+			storage: NewREST(renderer),
+			ctx:     ctx,
+			subName: "foo",
+			cluster: "clusternet-cluster-dzqkw",
+			wantErr: true,
+		},
+		{
+			name:    "deployer disabled",
+			storage: NewREST(nil),
+			ctx:     ctx,
+			subName: "app-demo",
+			cluster: "clusternet-cluster-dzqkw",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := &fakeResponder{}
+			handler, err := tt.storage.Connect(tt.ctx, tt.subName, &proxies.Render{Cluster: tt.cluster}, responder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Connect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(tt.accept) > 0 {
+				req.Header.Set("Accept", tt.accept)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if responder.err != nil {
+				t.Fatalf("unexpected error: %v", responder.err)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := recorder.Body.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("body = %s, want containing %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"fmt"
+	"net/http"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/exchanger"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+)
+
+// withGrants wraps the proxy handler, which denies every request to the child cluster,
+// unless the requester is explicitly granted by a Grant object.
+func withGrants(handler http.Handler, grantLister clusterlisters.GrantLister,
+	clusterID string, opts *proxiesapi.Socket, responder rest.Responder) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		requester, ok := request.UserFrom(req.Context())
+		if !ok {
+			responder.Error(apierrors.NewUnauthorized("no user found in request"))
+			return
+		}
+
+		// resolve the verb with the request path in child cluster
+		info, err := exchanger.ResolveRequestInfo(req, opts.Path)
+		if err != nil {
+			responder.Error(apierrors.NewBadRequest(fmt.Sprintf("failed to parse request path %s: %v", opts.Path, err)))
+			return
+		}
+
+		grants, err := grantLister.List(labels.Everything())
+		if err != nil {
+			responder.Error(apierrors.NewInternalError(err))
+			return
+		}
+		if !isGranted(grants, requester, clusterID, info.Verb) {
+			klog.V(4).Infof("user %q is not granted to %s through cluster %s", requester.GetName(), info.Verb, clusterID)
+			responder.Error(apierrors.NewForbidden(clusterapi.Resource("grants"), clusterID,
+				fmt.Errorf("user %q is not granted to %s through cluster %s", requester.GetName(), info.Verb, clusterID)))
+			return
+		}
+
+		handler.ServeHTTP(writer, req)
+	})
+}
+
+// isGranted checks whether the requester is allowed to perform the verb on given cluster.
+func isGranted(grants []*clusterapi.Grant, requester user.Info, clusterID, verb string) bool {
+	for _, grant := range grants {
+		if grant.DeletionTimestamp != nil {
+			continue
+		}
+		if !matchesAny(grant.Spec.Verbs, verb) {
+			continue
+		}
+		clusters := make([]string, 0, len(grant.Spec.Clusters))
+		for _, cluster := range grant.Spec.Clusters {
+			clusters = append(clusters, string(cluster))
+		}
+		if !matchesAny(clusters, clusterID) {
+			continue
+		}
+		for _, subject := range grant.Spec.Subjects {
+			if subjectMatches(subject, requester) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func subjectMatches(subject rbacv1.Subject, requester user.Info) bool {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return subject.Name == requester.GetName()
+	case rbacv1.GroupKind:
+		for _, group := range requester.GetGroups() {
+			if subject.Name == group {
+				return true
+			}
+		}
+	case rbacv1.ServiceAccountKind:
+		return serviceaccount.MakeUsername(subject.Namespace, subject.Name) == requester.GetName()
+	}
+	return false
+}
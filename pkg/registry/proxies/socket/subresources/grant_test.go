@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestIsGranted(t *testing.T) {
+	grants := []*clusterapi.Grant{
+		{
+			Spec: clusterapi.GrantSpec{
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+				Clusters: []types.UID{"cluster-a"},
+				Verbs:    []string{"get", "list"},
+			},
+		},
+		{
+			Spec: clusterapi.GrantSpec{
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "ops"}},
+				Clusters: []types.UID{"*"},
+				Verbs:    []string{"*"},
+			},
+		},
+		{
+			Spec: clusterapi.GrantSpec{
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+				Clusters: []types.UID{"cluster-b"},
+				Verbs:    []string{"create"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		requester user.Info
+		clusterID string
+		verb      string
+		want      bool
+	}{
+		{
+			name:      "granted user",
+			requester: &user.DefaultInfo{Name: "alice"},
+			clusterID: "cluster-a",
+			verb:      "list",
+			want:      true,
+		},
+		{
+			name:      "user with verb not granted",
+			requester: &user.DefaultInfo{Name: "alice"},
+			clusterID: "cluster-a",
+			verb:      "delete",
+			want:      false,
+		},
+		{
+			name:      "user with cluster not granted",
+			requester: &user.DefaultInfo{Name: "alice"},
+			clusterID: "cluster-b",
+			verb:      "get",
+			want:      false,
+		},
+		{
+			name:      "granted group with wildcards",
+			requester: &user.DefaultInfo{Name: "bob", Groups: []string{"ops"}},
+			clusterID: "cluster-c",
+			verb:      "delete",
+			want:      true,
+		},
+		{
+			name:      "granted service account",
+			requester: &user.DefaultInfo{Name: "system:serviceaccount:ci:deployer"},
+			clusterID: "cluster-b",
+			verb:      "create",
+			want:      true,
+		},
+		{
+			name:      "unknown user",
+			requester: &user.DefaultInfo{Name: "mallory"},
+			clusterID: "cluster-a",
+			verb:      "get",
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGranted(grants, tt.requester, tt.clusterID, tt.verb); got != tt.want {
+				t.Errorf("isGranted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/exchanger"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// Impersonator forwards the identities of the requesters to child clusters, by impersonating them with the
+// credentials of clusternet-agent, so that the requests are authorized and audited per user in child clusters.
+type Impersonator struct {
+	secretLister  corev1lister.SecretLister
+	clusterLister clusterlisters.ManagedClusterLister
+
+	// allowedGroups are the groups of requesters forwarded to child clusters, while the others are dropped.
+	allowedGroups sets.String
+}
+
+// NewImpersonator returns a new Impersonator.
+func NewImpersonator(secretLister corev1lister.SecretLister, clusterLister clusterlisters.ManagedClusterLister,
+	allowedGroups []string) *Impersonator {
+	return &Impersonator{
+		secretLister:  secretLister,
+		clusterLister: clusterLister,
+		allowedGroups: sets.NewString(allowedGroups...),
+	}
+}
+
+// getAgentToken returns the token of clusternet-agent stored in the dedicated namespace of the cluster.
+func (i *Impersonator) getAgentToken(clusterID string) (string, error) {
+	mcls, err := i.clusterLister.List(labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: clusterID}))
+	if err != nil {
+		return "", err
+	}
+	if len(mcls) == 0 {
+		return "", fmt.Errorf("no cluster id is %s", clusterID)
+	}
+	secret, err := i.secretLister.Secrets(mcls[0].Namespace).Get(known.ChildClusterSecretName)
+	if err != nil {
+		return "", err
+	}
+	token := string(secret.Data[corev1.ServiceAccountTokenKey])
+	if len(token) == 0 {
+		return "", fmt.Errorf("no token found in Secret %s/%s", secret.Namespace, secret.Name)
+	}
+	return token, nil
+}
+
+// impersonatedGroups returns the groups of the requester in the allow-list.
+func (i *Impersonator) impersonatedGroups(requester user.Info) []string {
+	var groups []string
+	for _, group := range requester.GetGroups() {
+		if i.allowedGroups.Has(group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// withImpersonation wraps the proxy handler, which sends the requests with the credentials of clusternet-agent
+// impersonating the requesters. Requests carrying their own credentials of child clusters are passed through
+// as they are, and so are the anonymous ones.
+func withImpersonation(handler http.Handler, impersonator *Impersonator, clusterID string,
+	extraHeaderPrefixes []string, responder rest.Responder) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		requester, ok := request.UserFrom(req.Context())
+		if !ok || requester.GetName() == user.Anonymous || exchanger.HasChildCredentials(req.Header, extraHeaderPrefixes) {
+			handler.ServeHTTP(writer, req)
+			return
+		}
+
+		token, err := impersonator.getAgentToken(clusterID)
+		if err != nil {
+			responder.Error(apierrors.NewServiceUnavailable(
+				fmt.Sprintf("failed to get credentials of cluster %s for impersonation: %v", clusterID, err)))
+			return
+		}
+
+		// never trust the impersonation headers from clients, which have been handled by the parent cluster
+		for key := range req.Header {
+			if strings.HasPrefix(strings.ToLower(key), "impersonate-") {
+				req.Header.Del(key)
+			}
+		}
+		groups := impersonator.impersonatedGroups(requester)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+		req.Header.Set(authenticationv1.ImpersonateUserHeader, requester.GetName())
+		for _, group := range groups {
+			req.Header.Add(authenticationv1.ImpersonateGroupHeader, group)
+		}
+		klog.V(4).Infof("impersonating user %q with groups %v in cluster %s", requester.GetName(), groups, clusterID)
+
+		handler.ServeHTTP(writer, req)
+	})
+}
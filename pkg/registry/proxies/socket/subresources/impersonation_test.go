@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+type fakeResponder struct {
+	err error
+}
+
+func (r *fakeResponder) Object(statusCode int, obj runtime.Object) {}
+
+func (r *fakeResponder) Error(err error) {
+	r.err = err
+}
+
+func newImpersonator(t *testing.T) *Impersonator {
+	clusterIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := clusterIndexer.Add(&clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: "demo", Namespace: "clusternet-abcde", Labels: map[string]string{known.ClusterIDLabel: "cluster-a"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := secretIndexer.Add(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: known.ChildClusterSecretName, Namespace: "clusternet-abcde"},
+		Data:       map[string][]byte{corev1.ServiceAccountTokenKey: []byte("agent-token")},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return NewImpersonator(corev1lister.NewSecretLister(secretIndexer),
+		clusterlisters.NewManagedClusterLister(clusterIndexer), []string{"dev"})
+}
+
+func TestWithImpersonation(t *testing.T) {
+	tests := []struct {
+		name          string
+		clusterID     string
+		requester     user.Info
+		header        http.Header
+		wantAuth      string
+		wantUser      string
+		wantGroups    []string
+		wantForwarded bool
+	}{
+		{
+			name:          "impersonate with allowed groups",
+			clusterID:     "cluster-a",
+			requester:     &user.DefaultInfo{Name: "alice", Groups: []string{"dev", "system:masters"}},
+			header:        http.Header{"Impersonate-User": []string{"admin"}},
+			wantAuth:      "Bearer agent-token",
+			wantUser:      "alice",
+			wantGroups:    []string{"dev"},
+			wantForwarded: true,
+		},
+		{
+			name:          "credentials of child cluster",
+			clusterID:     "cluster-a",
+			requester:     &user.DefaultInfo{Name: "clusternet"},
+			header:        http.Header{"X-Remote-Extra-Clusternet-Token": []string{"user-token"}},
+			wantForwarded: true,
+		},
+		{
+			name:          "anonymous",
+			clusterID:     "cluster-a",
+			requester:     &user.DefaultInfo{Name: user.Anonymous},
+			header:        http.Header{},
+			wantForwarded: true,
+		},
+		{
+			name:      "unknown cluster",
+			clusterID: "cluster-b",
+			requester: &user.DefaultInfo{Name: "alice"},
+			header:    http.Header{},
+		},
+	}
+
+	impersonator := newImpersonator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded *http.Request
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				forwarded = req
+			})
+			responder := &fakeResponder{}
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pods", nil)
+			req.Header = tt.header
+			req = req.WithContext(request.WithUser(context.TODO(), tt.requester))
+
+			withImpersonation(handler, impersonator, tt.clusterID, []string{"X-Remote-Extra-"}, responder).
+				ServeHTTP(httptest.NewRecorder(), req)
+			if (forwarded != nil) != tt.wantForwarded {
+				t.Fatalf("expected forwarded %v, got error %v", tt.wantForwarded, responder.err)
+			}
+			if forwarded == nil {
+				return
+			}
+			if got := forwarded.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("expected Authorization %q, got %q", tt.wantAuth, got)
+			}
+			if got := forwarded.Header.Get(authenticationv1.ImpersonateUserHeader); got != tt.wantUser {
+				t.Errorf("expected impersonated user %q, got %q", tt.wantUser, got)
+			}
+			if got := forwarded.Header.Values(authenticationv1.ImpersonateGroupHeader); len(got) > 0 || len(tt.wantGroups) > 0 {
+				if !reflect.DeepEqual(got, tt.wantGroups) {
+					t.Errorf("expected impersonated groups %v, got %v", tt.wantGroups, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/klog/v2"
+
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/exchanger"
+)
+
+const (
+	// retryAfterSeconds is the value of Retry-After header returned to the throttled requests
+	retryAfterSeconds = 1
+)
+
+// InFlightLimiter limits the number of concurrent in-flight proxied requests per child cluster and per user,
+// protecting child clusters from being overwhelmed through the parent cluster.
+type InFlightLimiter struct {
+	// maxPerCluster is the max number of in-flight requests to a single child cluster. 0 means no limit.
+	maxPerCluster int
+	// maxPerUser is the max number of in-flight requests from a single user. 0 means no limit.
+	maxPerUser int
+
+	lock     sync.Mutex
+	clusters map[string]int
+	users    map[string]int
+}
+
+// NewInFlightLimiter returns a new InFlightLimiter.
+func NewInFlightLimiter(maxPerCluster, maxPerUser int) *InFlightLimiter {
+	return &InFlightLimiter{
+		maxPerCluster: maxPerCluster,
+		maxPerUser:    maxPerUser,
+		clusters:      make(map[string]int),
+		users:         make(map[string]int),
+	}
+}
+
+// tryAcquire occupies a slot for the request from user to the cluster, returning false if no slot is available.
+func (l *InFlightLimiter) tryAcquire(clusterID, user string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.maxPerCluster > 0 && l.clusters[clusterID] >= l.maxPerCluster {
+		return false
+	}
+	if l.maxPerUser > 0 && l.users[user] >= l.maxPerUser {
+		return false
+	}
+	l.clusters[clusterID]++
+	l.users[user]++
+	return true
+}
+
+// release frees the slot occupied by tryAcquire.
+func (l *InFlightLimiter) release(clusterID, user string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.clusters[clusterID]--
+	if l.clusters[clusterID] <= 0 {
+		delete(l.clusters, clusterID)
+	}
+	l.users[user]--
+	if l.users[user] <= 0 {
+		delete(l.users, user)
+	}
+}
+
+// withInFlightLimit wraps the proxy handler, which rejects requests with 429 when the limits are exceeded.
+func withInFlightLimit(handler http.Handler, limiter *InFlightLimiter,
+	clusterID string, opts *proxiesapi.Socket, responder rest.Responder) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		// long-running requests, such as watches, are not limited, which may hold the connections for a long time
+		info, err := exchanger.ResolveRequestInfo(req, opts.Path)
+		if err == nil && exchanger.IsLongRunning(info) {
+			handler.ServeHTTP(writer, req)
+			return
+		}
+
+		var username string
+		if requester, ok := request.UserFrom(req.Context()); ok {
+			username = requester.GetName()
+		}
+		if !limiter.tryAcquire(clusterID, username) {
+			klog.V(4).Infof("too many in-flight requests from user %q to cluster %s", username, clusterID)
+			responder.Error(apierrors.NewTooManyRequests(
+				fmt.Sprintf("too many in-flight requests to cluster %s, please try again later", clusterID),
+				retryAfterSeconds))
+			return
+		}
+		defer limiter.release(clusterID, username)
+
+		handler.ServeHTTP(writer, req)
+	})
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"testing"
+)
+
+func TestInFlightLimiter(t *testing.T) {
+	limiter := NewInFlightLimiter(2, 1)
+
+	if !limiter.tryAcquire("cluster-a", "alice") {
+		t.Fatalf("expected alice to acquire a slot for cluster-a")
+	}
+	if limiter.tryAcquire("cluster-b", "alice") {
+		t.Errorf("expected alice to be throttled by per-user limit")
+	}
+	if !limiter.tryAcquire("cluster-a", "bob") {
+		t.Fatalf("expected bob to acquire a slot for cluster-a")
+	}
+	if limiter.tryAcquire("cluster-a", "carol") {
+		t.Errorf("expected carol to be throttled by per-cluster limit")
+	}
+	if !limiter.tryAcquire("cluster-b", "carol") {
+		t.Errorf("expected carol to acquire a slot for cluster-b")
+	}
+
+	limiter.release("cluster-a", "alice")
+	if !limiter.tryAcquire("cluster-a", "alice") {
+		t.Errorf("expected alice to acquire a slot for cluster-a after releasing")
+	}
+}
@@ -28,6 +28,7 @@ import (
 	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
 	"github.com/clusternet/clusternet/pkg/exchanger"
 	"github.com/clusternet/clusternet/pkg/features"
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
 )
 
 // ProxyREST implements the proxy subresource for a Socket
@@ -35,6 +36,21 @@ type ProxyREST struct {
 	Exchanger           *exchanger.Exchanger
 	socketConnection    bool
 	ExtraHeaderPrefixes []string
+
+	// grantLister is used to check explicit Grants on every proxied request.
+	// A nil grantLister means proxying is authorized with RBAC only.
+	grantLister clusterlisters.GrantLister
+
+	// limiter limits the concurrent in-flight proxied requests per cluster and per user.
+	// A nil limiter means no limits.
+	limiter *InFlightLimiter
+
+	// impersonator forwards the identities of requesters to child clusters with impersonation.
+	// A nil impersonator means requests are passed through as they are.
+	impersonator *Impersonator
+
+	// sessionLimits bounds the streaming sessions with pods, such as exec, attach, port-forward and logs.
+	sessionLimits SessionLimits
 }
 
 // Implement Connecter
@@ -68,14 +84,36 @@ func (r *ProxyREST) Connect(ctx context.Context, id string, opts runtime.Object,
 		return nil, fmt.Errorf("invalid options object: %#v", opts)
 	}
 
-	return r.Exchanger.ProxyConnect(ctx, id, proxyOpts, responder, r.ExtraHeaderPrefixes)
+	handler, err := r.Exchanger.ProxyConnect(ctx, id, proxyOpts, responder, r.ExtraHeaderPrefixes)
+	if err != nil {
+		return nil, err
+	}
+	if r.sessionLimits.enabled() {
+		handler = withSessionLimits(handler, r.sessionLimits, id, proxyOpts)
+	}
+	if r.impersonator != nil {
+		handler = withImpersonation(handler, r.impersonator, id, r.ExtraHeaderPrefixes, responder)
+	}
+	if r.limiter != nil {
+		handler = withInFlightLimit(handler, r.limiter, id, proxyOpts, responder)
+	}
+	if r.grantLister != nil {
+		handler = withGrants(handler, r.grantLister, id, proxyOpts, responder)
+	}
+	return handler, nil
 }
 
 // NewProxyREST returns a RESTStorage object that will work against API services.
-func NewProxyREST(socketConnection bool, ec *exchanger.Exchanger, extraHeaderPrefixes []string) *ProxyREST {
+func NewProxyREST(socketConnection bool, ec *exchanger.Exchanger, extraHeaderPrefixes []string,
+	grantLister clusterlisters.GrantLister, limiter *InFlightLimiter, impersonator *Impersonator,
+	sessionLimits SessionLimits) *ProxyREST {
 	return &ProxyREST{
 		Exchanger:           ec,
 		socketConnection:    socketConnection,
 		ExtraHeaderPrefixes: extraHeaderPrefixes,
+		grantLister:         grantLister,
+		limiter:             limiter,
+		impersonator:        impersonator,
+		sessionLimits:       sessionLimits,
 	}
 }
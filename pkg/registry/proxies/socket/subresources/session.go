@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/exchanger"
+)
+
+// SessionLimits bounds the streaming sessions with pods in child clusters, such as exec, attach, port-forward
+// and logs, which would otherwise hold the tunnels to child clusters for as long as clients stay connected.
+type SessionLimits struct {
+	// IdleTimeout closes sessions without any data transferred in either direction for this long. 0 means no limit.
+	IdleTimeout time.Duration
+	// MaxDuration closes sessions lasting longer than this. 0 means no limit.
+	MaxDuration time.Duration
+}
+
+// enabled tells whether any limits are set.
+func (l SessionLimits) enabled() bool {
+	return l.IdleTimeout > 0 || l.MaxDuration > 0
+}
+
+// checkInterval is how often a session is checked against the limits.
+func (l SessionLimits) checkInterval() time.Duration {
+	interval := time.Second
+	for _, limit := range []time.Duration{l.IdleTimeout, l.MaxDuration} {
+		if limit > 0 && limit/2 < interval {
+			interval = limit / 2
+		}
+	}
+	return interval
+}
+
+// session tracks the activities of a streaming session. Data written to the client and the upgraded
+// connection in both directions count as activities.
+type session struct {
+	lock       sync.Mutex
+	lastActive time.Time
+	conn       net.Conn
+	closed     bool
+}
+
+func (s *session) touch() {
+	s.lock.Lock()
+	s.lastActive = time.Now()
+	s.lock.Unlock()
+}
+
+func (s *session) idleSince() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.lastActive
+}
+
+// setConn records the hijacked connection, which is closed when the session ends.
+func (s *session) setConn(conn net.Conn) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.conn = conn
+	if s.closed {
+		conn.Close()
+	}
+}
+
+func (s *session) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
+
+// sessionConn is an upgraded connection reporting activities to the session.
+type sessionConn struct {
+	net.Conn
+	session *session
+}
+
+func (c *sessionConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.session.touch()
+	}
+	return n, err
+}
+
+func (c *sessionConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if n > 0 {
+		c.session.touch()
+	}
+	return n, err
+}
+
+// sessionResponseWriter reports the data written to clients to the session, and hands out the hijacked
+// connections wrapped for SPDY and WebSocket upgrades.
+type sessionResponseWriter struct {
+	http.ResponseWriter
+	session *session
+}
+
+func (w *sessionResponseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if n > 0 {
+		w.session.touch()
+	}
+	return n, err
+}
+
+func (w *sessionResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *sessionResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection does not support upgrades")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped := &sessionConn{Conn: conn, session: w.session}
+	w.session.setConn(wrapped)
+	return wrapped, rw, nil
+}
+
+// withSessionLimits wraps the proxy handler, which closes the streaming sessions with pods, such as exec, attach,
+// port-forward and logs, once they stay idle or last longer than the limits. Other requests are not limited.
+func withSessionLimits(handler http.Handler, limits SessionLimits,
+	clusterID string, opts *proxiesapi.Socket) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+		info, err := exchanger.ResolveRequestInfo(req, opts.Path)
+		if err != nil || !exchanger.IsStreaming(info) {
+			handler.ServeHTTP(writer, req)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		s := &session{lastActive: time.Now()}
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			start := time.Now()
+			ticker := time.NewTicker(limits.checkInterval())
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case now := <-ticker.C:
+					var reason string
+					if limits.MaxDuration > 0 && now.Sub(start) >= limits.MaxDuration {
+						reason = fmt.Sprintf("lasting longer than %s", limits.MaxDuration)
+					} else if limits.IdleTimeout > 0 && now.Sub(s.idleSince()) >= limits.IdleTimeout {
+						reason = fmt.Sprintf("being idle for %s", limits.IdleTimeout)
+					}
+					if len(reason) == 0 {
+						continue
+					}
+					klog.V(4).Infof("closing %s session of pod %s/%s in cluster %s after %s", info.Subresource,
+						info.Namespace, info.Name, clusterID, reason)
+					cancel()
+					s.close()
+					return
+				}
+			}
+		}()
+
+		handler.ServeHTTP(&sessionResponseWriter{ResponseWriter: writer, session: s}, req.WithContext(ctx))
+	})
+}
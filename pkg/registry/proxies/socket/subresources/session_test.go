@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subresources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	proxiesapi "github.com/clusternet/clusternet/pkg/apis/proxies/v1alpha1"
+)
+
+func TestWithSessionLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		limits     SessionLimits
+		writes     bool
+		wantClosed bool
+	}{
+		{
+			name:       "idle exec",
+			path:       "direct/api/v1/namespaces/default/pods/nginx/exec",
+			limits:     SessionLimits{IdleTimeout: 100 * time.Millisecond},
+			wantClosed: true,
+		},
+		{
+			name:       "active logs within idle timeout",
+			path:       "direct/api/v1/namespaces/default/pods/nginx/log",
+			limits:     SessionLimits{IdleTimeout: 100 * time.Millisecond},
+			writes:     true,
+			wantClosed: false,
+		},
+		{
+			name:       "active logs exceeding max duration",
+			path:       "direct/api/v1/namespaces/default/pods/nginx/log",
+			limits:     SessionLimits{IdleTimeout: 100 * time.Millisecond, MaxDuration: 200 * time.Millisecond},
+			writes:     true,
+			wantClosed: true,
+		},
+		{
+			name:       "not streaming",
+			path:       "direct/api/v1/namespaces/default/pods",
+			limits:     SessionLimits{IdleTimeout: 100 * time.Millisecond},
+			wantClosed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the session either gets closed by the limits, or ends by itself after 1 second
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, req *http.Request) {
+				end := time.After(time.Second)
+				ticker := time.NewTicker(20 * time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-req.Context().Done():
+						return
+					case <-end:
+						return
+					case <-ticker.C:
+						if tt.writes {
+							writer.Write([]byte("log line\n"))
+						}
+					}
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			start := time.Now()
+			withSessionLimits(handler, tt.limits, "cluster-a", &proxiesapi.Socket{Path: tt.path}).
+				ServeHTTP(httptest.NewRecorder(), req)
+			if closed := time.Since(start) < 900*time.Millisecond; closed != tt.wantClosed {
+				t.Errorf("expected session closed %v, but it lasted %s", tt.wantClosed, time.Since(start))
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	clusterlisters "github.com/clusternet/clusternet/pkg/generated/listers/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+	"github.com/clusternet/clusternet/pkg/utils"
+)
+
+// AdmissionProxy forwards shadowed objects to a designated child cluster with dry-run,
+// so that the validating/mutating admission webhooks configured there get invoked
+// before the objects are persisted as Manifests.
+type AdmissionProxy struct {
+	clusterID     string
+	secretLister  corev1lister.SecretLister
+	clusterLister clusterlisters.ManagedClusterLister
+}
+
+// NewAdmissionProxy returns a new AdmissionProxy for the child cluster with given cluster id.
+func NewAdmissionProxy(clusterID string, secretLister corev1lister.SecretLister,
+	clusterLister clusterlisters.ManagedClusterLister) *AdmissionProxy {
+	return &AdmissionProxy{
+		clusterID:     clusterID,
+		secretLister:  secretLister,
+		clusterLister: clusterLister,
+	}
+}
+
+// Admit dry-runs the creation (or update if already exists) of the object in the designated child cluster.
+func (p *AdmissionProxy) Admit(ctx context.Context, gvr schema.GroupVersionResource, namespace string, obj *unstructured.Unstructured) error {
+	client, err := p.getDynamicClient()
+	if err != nil {
+		return errors.NewInternalError(fmt.Errorf("failed to connect to admission cluster %s: %v", p.clusterID, err))
+	}
+
+	// the object has been dry-run in the parent cluster, where server-populated fields need to be cleared
+	obj = obj.DeepCopy()
+	obj.SetNamespace(namespace)
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetManagedFields(nil)
+
+	var resourceClient dynamic.ResourceInterface = client.Resource(gvr)
+	if len(namespace) > 0 {
+		resourceClient = client.Resource(gvr).Namespace(namespace)
+	}
+
+	dryRun := []string{metav1.DryRunAll}
+	_, err = resourceClient.Create(ctx, obj, metav1.CreateOptions{DryRun: dryRun})
+	if errors.IsAlreadyExists(err) {
+		var current *unstructured.Unstructured
+		current, err = resourceClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		obj.SetResourceVersion(current.GetResourceVersion())
+		_, err = resourceClient.Update(ctx, obj, metav1.UpdateOptions{DryRun: dryRun})
+	}
+	if errors.IsNotFound(err) && len(namespace) > 0 {
+		// namespace does not exist in the admission cluster
+		klog.V(4).Infof("skip admission of %s %s/%s in cluster %s: %v", gvr.Resource, namespace, obj.GetName(), p.clusterID, err)
+		return nil
+	}
+	return err
+}
+
+func (p *AdmissionProxy) getDynamicClient() (dynamic.Interface, error) {
+	mcls, err := p.clusterLister.List(labels.SelectorFromSet(labels.Set{known.ClusterIDLabel: p.clusterID}))
+	if err != nil {
+		return nil, err
+	}
+	if len(mcls) == 0 {
+		return nil, fmt.Errorf("no ManagedCluster found with id %s", p.clusterID)
+	}
+
+	config, err := utils.GetChildClusterConfig(p.secretLister, p.clusterLister, mcls[0].Namespace, p.clusterID)
+	if err != nil {
+		return nil, err
+	}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*config, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(restConfig)
+}
+
+// admit invokes the admission webhooks in the designated child cluster if configured.
+func (r *REST) admit(ctx context.Context, obj *unstructured.Unstructured) error {
+	if r.admissionProxy == nil {
+		return nil
+	}
+
+	var namespace string
+	if r.namespaced && r.kind != "Namespace" {
+		namespace = request.NamespaceValue(ctx)
+	}
+	resource, _ := r.getResourceName()
+	return r.admissionProxy.Admit(ctx, schema.GroupVersionResource{Group: r.group, Version: r.version, Resource: resource}, namespace, obj)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/klog/v2"
+
+	"github.com/clusternet/clusternet/pkg/hub/events"
+)
+
+// defaultAuditWebhookTimeout is the timeout of posting an audit record to the webhook
+const defaultAuditWebhookTimeout = 10 * time.Second
+
+// audit verbs of writes to shadow resources
+const (
+	AuditVerbCreate = "create"
+	AuditVerbUpdate = "update"
+	AuditVerbDelete = "delete"
+)
+
+// Requester is the identity writing a shadow resource.
+type Requester struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+	// Impersonator is the original user impersonating User, which is only known
+	// when the request is audited by clusternet-hub at Metadata level or above.
+	Impersonator string `json:"impersonator,omitempty"`
+}
+
+// AuditRecord records a write to a shadow resource, which is posted to the audit webhook in JSON.
+type AuditRecord struct {
+	Verb      string `json:"verb"`
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Manifest is the name of the Manifest storing the shadow resource.
+	Manifest  string      `json:"manifest"`
+	Requester Requester   `json:"requester"`
+	DryRun    bool        `json:"dryRun,omitempty"`
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// writerAnnotation is the value of the annotations on Manifests recording who created or updated them.
+type writerAnnotation struct {
+	User         string      `json:"user"`
+	Impersonator string      `json:"impersonator,omitempty"`
+	Time         metav1.Time `json:"time"`
+}
+
+// Auditor posts the writes to shadow resources to an audit webhook.
+type Auditor struct {
+	webhook string
+}
+
+// NewAuditor returns a new Auditor posting to the webhook.
+func NewAuditor(webhook string) *Auditor {
+	return &Auditor{
+		webhook: webhook,
+	}
+}
+
+// Record logs the write and posts it to the webhook asynchronously. A nil Auditor only logs the write.
+func (a *Auditor) Record(record *AuditRecord) {
+	klog.V(2).Infof("%s %s %s/%s in manifest %s by %q (impersonator %q, dry-run %t)", record.Verb, record.Kind,
+		record.Namespace, record.Name, record.Manifest, record.Requester.User, record.Requester.Impersonator, record.DryRun)
+	if a == nil || len(a.webhook) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		klog.Errorf("failed to marshal audit record of %s %s/%s: %v", record.Kind, record.Namespace, record.Name, err)
+		return
+	}
+	// do not block the requests
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultAuditWebhookTimeout)
+		defer cancel()
+		if err := events.PostWebhook(ctx, a.webhook, payload); err != nil {
+			klog.Warningf("failed to send audit record of %s %s/%s to webhook: %v", record.Kind,
+				record.Namespace, record.Name, err)
+		}
+	}()
+}
+
+// requesterFrom returns the identity of the request. When the request impersonates another user,
+// the impersonated user is returned along with the original user found in the audit event.
+func requesterFrom(ctx context.Context) Requester {
+	requester := Requester{}
+	if u, ok := request.UserFrom(ctx); ok {
+		requester.User = u.GetName()
+		requester.Groups = u.GetGroups()
+	}
+	if ae := request.AuditEventFrom(ctx); ae != nil && ae.ImpersonatedUser != nil {
+		requester.Impersonator = ae.User.Username
+	}
+	return requester
+}
+
+// writerAnnotationValue returns the value of the annotation recording the requester writing a Manifest.
+func writerAnnotationValue(requester Requester) string {
+	data, err := json.Marshal(writerAnnotation{
+		User:         requester.User,
+		Impersonator: requester.Impersonator,
+		Time:         metav1.Now(),
+	})
+	if err != nil {
+		// never happens
+		return requester.User
+	}
+	return string(data)
+}
+
+// audit records the write to the shadow resource by the requester.
+func (r *REST) audit(verb, namespace, name string, requester Requester, dryRun []string) {
+	r.auditor.Record(&AuditRecord{
+		Verb:      verb,
+		Group:     r.group,
+		Version:   r.version,
+		Kind:      r.kind,
+		Namespace: namespace,
+		Name:      name,
+		Manifest:  r.generateNameForManifest(namespace, name),
+		Requester: requester,
+		DryRun:    len(dryRun) > 0,
+		Timestamp: metav1.Now(),
+	})
+}
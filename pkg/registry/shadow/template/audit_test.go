@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestRequesterFrom(t *testing.T) {
+	ctx := request.WithUser(context.TODO(), &user.DefaultInfo{Name: "alice", Groups: []string{"team-a"}})
+	if got, want := requesterFrom(ctx), (Requester{User: "alice", Groups: []string{"team-a"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("requesterFrom() = %+v, want %+v", got, want)
+	}
+
+	// impersonated by bob
+	ctx = request.WithAuditEvent(ctx, &auditinternal.Event{
+		User:             authenticationv1.UserInfo{Username: "bob"},
+		ImpersonatedUser: &authenticationv1.UserInfo{Username: "alice"},
+	})
+	if got, want := requesterFrom(ctx), (Requester{User: "alice", Groups: []string{"team-a"}, Impersonator: "bob"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("requesterFrom() = %+v, want %+v", got, want)
+	}
+}
+
+func TestAuditorRecord(t *testing.T) {
+	records := make(chan AuditRecord, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record := AuditRecord{}
+		if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		records <- record
+	}))
+	defer server.Close()
+
+	r := &REST{
+		name:       "deployments",
+		namespaced: true,
+		group:      "apps",
+		version:    "v1",
+		kind:       "Deployment",
+		auditor:    NewAuditor(server.URL),
+	}
+	r.audit(AuditVerbDelete, "default", "nginx", Requester{User: "alice", Impersonator: "bob"}, nil)
+
+	select {
+	case record := <-records:
+		if record.Verb != AuditVerbDelete || record.Kind != "Deployment" || record.Namespace != "default" ||
+			record.Name != "nginx" || record.Manifest != r.generateNameForManifest("default", "nginx") ||
+			record.Requester.User != "alice" || record.Requester.Impersonator != "bob" || record.DryRun {
+			t.Errorf("unexpected audit record %+v", record)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("audit record is not posted to webhook")
+	}
+}
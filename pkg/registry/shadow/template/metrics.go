@@ -0,0 +1,180 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsSubsystem = "clusternet_shadow"
+)
+
+var (
+	requestsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "requests_total",
+			Help:           "Number of requests to shadow APIs, partitioned by group, version, resource, verb and HTTP response code.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "version", "resource", "verb", "code"},
+	)
+
+	requestDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "request_duration_seconds",
+			Help:           "Latency of requests to shadow APIs in seconds, partitioned by group, version, resource and verb.",
+			Buckets:        []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "version", "resource", "verb"},
+	)
+
+	conflictsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "conflicts_total",
+			Help:           "Number of requests to shadow APIs failed with conflicts or already existing objects.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "version", "resource", "verb"},
+	)
+
+	manifestTranslationErrorsTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "manifest_translation_errors_total",
+			Help:           "Number of failures when encoding objects into Manifests or decoding objects from Manifests.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"group", "version", "kind", "operation"},
+	)
+
+	registerMetrics sync.Once
+)
+
+// RegisterMetrics registers the metrics of shadow APIs, which are exposed on /metrics.
+func RegisterMetrics() {
+	registerMetrics.Do(func() {
+		legacyregistry.MustRegister(requestsTotal)
+		legacyregistry.MustRegister(requestDuration)
+		legacyregistry.MustRegister(conflictsTotal)
+		legacyregistry.MustRegister(manifestTranslationErrorsTotal)
+	})
+}
+
+func recordManifestTranslationError(group, version, kind, operation string) {
+	manifestTranslationErrorsTotal.WithLabelValues(group, version, kind, operation).Inc()
+}
+
+// InstrumentedREST wraps REST with metrics recorded for each request.
+type InstrumentedREST struct {
+	*REST
+}
+
+// NewInstrumentedREST returns a new InstrumentedREST.
+func NewInstrumentedREST(r *REST) *InstrumentedREST {
+	return &InstrumentedREST{REST: r}
+}
+
+func (r *InstrumentedREST) observe(verb string, start time.Time, err error) {
+	resource, subresource := r.getResourceName()
+	if len(subresource) > 0 {
+		resource = resource + "/" + subresource
+	}
+
+	code := http.StatusOK
+	if err != nil {
+		code = http.StatusInternalServerError
+		if status, ok := err.(errors.APIStatus); ok {
+			code = int(status.Status().Code)
+		}
+	}
+	requestsTotal.WithLabelValues(r.group, r.version, resource, verb, strconv.Itoa(code)).Inc()
+	requestDuration.WithLabelValues(r.group, r.version, resource, verb).Observe(time.Since(start).Seconds())
+	if errors.IsConflict(err) || errors.IsAlreadyExists(err) {
+		conflictsTotal.WithLabelValues(r.group, r.version, resource, verb).Inc()
+	}
+}
+
+func (r *InstrumentedREST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	start := time.Now()
+	result, err := r.REST.Create(ctx, obj, createValidation, options)
+	r.observe("create", start, err)
+	return result, err
+}
+
+func (r *InstrumentedREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	start := time.Now()
+	result, err := r.REST.Get(ctx, name, options)
+	r.observe("get", start, err)
+	return result, err
+}
+
+func (r *InstrumentedREST) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo,
+	createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc,
+	forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	start := time.Now()
+	result, created, err := r.REST.Update(ctx, name, objInfo, createValidation, updateValidation, forceAllowCreate, options)
+	r.observe("update", start, err)
+	return result, created, err
+}
+
+func (r *InstrumentedREST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	start := time.Now()
+	result, deleted, err := r.REST.Delete(ctx, name, deleteValidation, options)
+	r.observe("delete", start, err)
+	return result, deleted, err
+}
+
+func (r *InstrumentedREST) DeleteCollection(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+	start := time.Now()
+	result, err := r.REST.DeleteCollection(ctx, deleteValidation, options, listOptions)
+	r.observe("deletecollection", start, err)
+	return result, err
+}
+
+func (r *InstrumentedREST) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+	start := time.Now()
+	result, err := r.REST.List(ctx, options)
+	r.observe("list", start, err)
+	return result, err
+}
+
+func (r *InstrumentedREST) Watch(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+	start := time.Now()
+	result, err := r.REST.Watch(ctx, options)
+	r.observe("watch", start, err)
+	return result, err
+}
+
+var _ rest.StandardStorage = &InstrumentedREST{}
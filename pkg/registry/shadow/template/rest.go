@@ -74,13 +74,25 @@ type REST struct {
 	parameterCodec runtime.ParameterCodec
 
 	dryRunClient              *kubernetes.Clientset
-	clusternetClient          *clusternet.Clientset
+	clusternetClient          clusternet.Interface
 	clusternetInformerFactory informers.SharedInformerFactory
 
 	// deleteCollectionWorkers is the maximum number of workers in a single
 	// DeleteCollection call. Delete requests for the items in a collection
 	// are issued in parallel.
 	deleteCollectionWorkers int
+
+	// tableConvertor converts objects to metav1.Table with the columns of the original resource.
+	// If nil, a default table convertor will be used, which only shows NAME and CREATED AT.
+	tableConvertor rest.TableConvertor
+
+	// admissionProxy invokes the admission webhooks in a designated child cluster.
+	// If nil, only the admission in parent cluster will be performed.
+	admissionProxy *AdmissionProxy
+
+	// auditor records the writes to the shadow resource.
+	// If nil, the writes are only logged and annotated on Manifests.
+	auditor *Auditor
 }
 
 // Create inserts a new item into Manifest according to the unique key from the object.
@@ -90,13 +102,25 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	if err != nil {
 		return nil, err
 	}
+	if createValidation != nil {
+		if err = createValidation(ctx, result.DeepCopyObject()); err != nil {
+			return nil, err
+		}
+	}
+	if err = r.admit(ctx, result); err != nil {
+		return nil, err
+	}
 
 	// next we create manifest to store the result
+	requester := requesterFrom(ctx)
 	manifest := &appsapi.Manifest{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      r.generateNameForManifest(result.GetNamespace(), result.GetName()),
 			Namespace: appsapi.ReservedNamespace,
 			Labels:    result.GetLabels(), // reuse labels from original object, which is useful for label selector
+			Annotations: map[string]string{
+				known.CreatedByAnnotation: writerAnnotationValue(requester),
+			},
 		},
 		Template: runtime.RawExtension{
 			Object: result,
@@ -111,13 +135,17 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	manifest.Labels[known.ConfigKindLabel] = r.kind
 	manifest.Labels[known.ConfigNameLabel] = result.GetName()
 	manifest.Labels[known.ConfigNamespaceLabel] = result.GetNamespace()
-	manifest, err = r.clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Create(ctx, manifest, metav1.CreateOptions{})
+	// with dryRun specified, the manifest will be validated but not persisted
+	manifest, err = r.clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Create(ctx, manifest, metav1.CreateOptions{
+		DryRun: options.DryRun,
+	})
 	if err != nil {
 		if errors.IsAlreadyExists(err) {
 			return nil, errors.NewAlreadyExists(schema.GroupResource{Group: r.group, Resource: r.name}, result.GetName())
 		}
 		return nil, err
 	}
+	r.audit(AuditVerbCreate, result.GetNamespace(), result.GetName(), requester, options.DryRun)
 	return transformManifest(manifest)
 }
 
@@ -139,7 +167,17 @@ func (r *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 		return nil, errors.NewInternalError(err)
 	}
 
-	return transformManifest(manifest)
+	result, err := transformManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	// status of the templates makes no sense,
+	// so here we return the status aggregated from all the child clusters instead.
+	if _, subresource := r.getResourceName(); subresource == statusSubresource {
+		return r.getAggregatedStatus(manifest, result)
+	}
+	return result, nil
 }
 
 // Update performs an atomic update and set of the object. Returns the result of the update
@@ -168,10 +206,10 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 
 	oldObj := &unstructured.Unstructured{}
 	if err = json.Unmarshal(manifest.Template.Raw, oldObj); err != nil {
+		recordManifestTranslationError(r.group, r.version, r.kind, "decode")
 		return nil, false, errors.NewInternalError(err)
 	}
 
-	// TODO: validate update
 	newObj, err := objInfo.UpdatedObject(ctx, oldObj)
 	if err != nil {
 		return nil, false, err
@@ -182,13 +220,29 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 	if err != nil {
 		return nil, false, err
 	}
+	if updateValidation != nil {
+		if err = updateValidation(ctx, result.DeepCopyObject(), oldObj); err != nil {
+			return nil, false, err
+		}
+	}
+	if err = r.admit(ctx, result); err != nil {
+		return nil, false, err
+	}
 
+	// never mutate the cached Manifest
+	manifest = manifest.DeepCopy()
 	manifest.Template.Reset()
 	manifest.Template.Object = result
+	requester := requesterFrom(ctx)
+	if manifest.Annotations == nil {
+		manifest.Annotations = map[string]string{}
+	}
+	manifest.Annotations[known.UpdatedByAnnotation] = writerAnnotationValue(requester)
 	manifest, err = r.clusternetClient.AppsV1alpha1().Manifests(appsapi.ReservedNamespace).Update(ctx, manifest, *options)
 	if err != nil {
 		return nil, false, err
 	}
+	r.audit(AuditVerbUpdate, result.GetNamespace(), result.GetName(), requester, options.DryRun)
 
 	result, err = transformManifest(manifest)
 	return result, err != nil, err
@@ -197,14 +251,22 @@ func (r *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObje
 // Delete removes the item from storage.
 // options can be mutated by rest.BeforeDelete due to a graceful deletion strategy.
 func (r *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	if shouldOrphanDependents(options) {
+		if err := r.orphanDependents(ctx, request.NamespaceValue(ctx), name); err != nil {
+			return nil, false, err
+		}
+	}
+
 	err := r.clusternetClient.AppsV1alpha1().Manifests(appsapi.ReservedNamespace).
 		Delete(ctx, r.generateNameForManifest(request.NamespaceValue(ctx), name), *options)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			err = errors.NewNotFound(schema.GroupResource{Group: r.group, Resource: r.name}, name)
 		}
+		return nil, false, err
 	}
-	return nil, err == nil, err
+	r.audit(AuditVerbDelete, request.NamespaceValue(ctx), name, requesterFrom(ctx), options.DryRun)
+	return nil, true, nil
 }
 
 // DeleteCollection removes all items returned by List with a given ListOptions from storage.
@@ -363,11 +425,16 @@ func (r *REST) List(ctx context.Context, options *internalversion.ListOptions) (
 	result.SetAPIVersion(orignalGVK.GroupVersion().String())
 	result.SetKind(r.getListKind())
 	result.SetResourceVersion(manifests.ResourceVersion)
+	// pass through the continue token and remaining item count,
+	// so that clients could page through the results with limit/continue.
+	result.SetContinue(manifests.Continue)
+	result.SetRemainingItemCount(manifests.RemainingItemCount)
 	if len(manifests.Items) == 0 {
 		return result, nil
 	}
-	for _, manifest := range manifests.Items {
-		obj, err := transformManifest(&manifest)
+	result.Items = make([]unstructured.Unstructured, 0, len(manifests.Items))
+	for idx := range manifests.Items {
+		obj, err := transformManifest(&manifests.Items[idx])
 		if err != nil {
 			return nil, err
 		}
@@ -386,10 +453,25 @@ func (r *REST) NewList() runtime.Object {
 }
 
 func (r *REST) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
-	tableConvertor := rest.NewDefaultTableConvertor(schema.GroupResource{Group: r.group, Resource: r.name})
+	tableConvertor := r.tableConvertor
+	if tableConvertor == nil {
+		tableConvertor = rest.NewDefaultTableConvertor(schema.GroupResource{Group: r.group, Resource: r.name})
+	}
 	return tableConvertor.ConvertToTable(ctx, object, tableOptions)
 }
 
+func (r *REST) SetTableConvertor(tableConvertor rest.TableConvertor) {
+	r.tableConvertor = tableConvertor
+}
+
+func (r *REST) SetAdmissionProxy(admissionProxy *AdmissionProxy) {
+	r.admissionProxy = admissionProxy
+}
+
+func (r *REST) SetAuditor(auditor *Auditor) {
+	r.auditor = auditor
+}
+
 func (r *REST) ShortNames() []string {
 	return r.shortNames
 }
@@ -454,6 +536,70 @@ func (r *REST) normalizeRequest(req *clientgorest.Request, namespace string) *cl
 	return req
 }
 
+// shouldOrphanDependents returns true if the dependents should be orphaned instead of being garbage collected.
+func shouldOrphanDependents(options *metav1.DeleteOptions) bool {
+	if options == nil {
+		return false
+	}
+	if options.PropagationPolicy != nil {
+		return *options.PropagationPolicy == metav1.DeletePropagationOrphan
+	}
+	return options.OrphanDependents != nil && *options.OrphanDependents
+}
+
+// orphanDependents removes the ownerReferences pointing to the object from the templates of its dependents,
+// so that they will not be deleted by the shadow garbage collector.
+func (r *REST) orphanDependents(ctx context.Context, namespace, name string) error {
+	manifest, err := r.clusternetClient.AppsV1alpha1().Manifests(appsapi.ReservedNamespace).
+		Get(ctx, r.generateNameForManifest(namespace, name), metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return errors.NewNotFound(schema.GroupResource{Group: r.group, Resource: r.name}, name)
+		}
+		return err
+	}
+
+	selector := labels.Everything()
+	if len(namespace) > 0 {
+		selector = labels.SelectorFromSet(labels.Set{known.ConfigNamespaceLabel: namespace})
+	}
+	candidates, err := r.clusternetInformerFactory.Apps().V1alpha1().Manifests().Lister().
+		Manifests(appsapi.ReservedNamespace).List(selector)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		template := &unstructured.Unstructured{}
+		if err = json.Unmarshal(candidate.Template.Raw, template); err != nil {
+			continue
+		}
+		ownerRefs := template.GetOwnerReferences()
+		var remaining []metav1.OwnerReference
+		for _, ref := range ownerRefs {
+			if ref.UID != manifest.UID {
+				remaining = append(remaining, ref)
+			}
+		}
+		if len(remaining) == len(ownerRefs) {
+			continue
+		}
+
+		template.SetOwnerReferences(remaining)
+		raw, err := json.Marshal(template)
+		if err != nil {
+			return errors.NewInternalError(err)
+		}
+		dependent := candidate.DeepCopy()
+		dependent.Template.Raw = raw
+		if _, err = r.clusternetClient.AppsV1alpha1().Manifests(appsapi.ReservedNamespace).Update(ctx, dependent, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.V(4).Infof("orphaned %s %s from %s %s", template.GetKind(), klog.KObj(template), r.kind, name)
+	}
+	return nil
+}
+
 func (r *REST) generateNameForManifest(namespace, name string) string {
 	resource, _ := r.getResourceName()
 	if r.namespaced {
@@ -486,9 +632,14 @@ func (r *REST) dryRunCreate(ctx context.Context, obj runtime.Object, _ rest.Vali
 
 	body, err := u.MarshalJSON()
 	if err != nil {
+		recordManifestTranslationError(r.group, r.version, r.kind, "encode")
 		return nil, errors.NewBadRequest(fmt.Sprintf("failed to marshal to json: %v", u.Object))
 	}
 
+	// dryRun has always been set explicitly below
+	options = options.DeepCopy()
+	options.DryRun = nil
+
 	client := r.dryRunClient.RESTClient()
 	result := &unstructured.Unstructured{}
 	klog.V(7).Infof("creating %s with %s", r.kind, body)
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"context"
+	"testing"
+
+	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	clienttesting "k8s.io/client-go/testing"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/generated/clientset/versioned/fake"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestListWithLimit(t *testing.T) {
+	remaining := int64(3)
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "manifests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		selector := action.(clienttesting.ListAction).GetListRestrictions().Labels
+		if selector.String() != known.ConfigKindLabel+"=ConfigMap,"+known.ConfigNamespaceLabel+"=foo" {
+			t.Errorf("unexpected label selector %q", selector.String())
+		}
+		return true, &appsapi.ManifestList{
+			ListMeta: metav1.ListMeta{
+				ResourceVersion:    "100",
+				Continue:           "next-page",
+				RemainingItemCount: &remaining,
+			},
+			Items: []appsapi.Manifest{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "configmaps-foo-bar",
+						Namespace:       appsapi.ReservedNamespace,
+						ResourceVersion: "99",
+						Labels: map[string]string{
+							known.ConfigKindLabel:      "ConfigMap",
+							known.ConfigNamespaceLabel: "foo",
+						},
+					},
+					Template: runtime.RawExtension{
+						Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"bar","namespace":"foo"}}`),
+					},
+				},
+			},
+		}, nil
+	})
+
+	r := &REST{
+		name:             "configmaps",
+		namespaced:       true,
+		kind:             "ConfigMap",
+		version:          "v1",
+		clusternetClient: client,
+	}
+	ctx := request.WithNamespace(context.TODO(), "foo")
+	obj, err := r.List(ctx, &metainternalversion.ListOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	result := obj.(*unstructured.UnstructuredList)
+	if result.GetKind() != "ConfigMapList" || result.GetAPIVersion() != "v1" {
+		t.Errorf("unexpected list kind %s %s", result.GetAPIVersion(), result.GetKind())
+	}
+	if result.GetResourceVersion() != "100" {
+		t.Errorf("expected resource version 100, got %s", result.GetResourceVersion())
+	}
+	if result.GetContinue() != "next-page" {
+		t.Errorf("expected continue token next-page, got %q", result.GetContinue())
+	}
+	if count := result.GetRemainingItemCount(); count == nil || *count != remaining {
+		t.Errorf("expected remaining item count %d, got %v", remaining, count)
+	}
+	if len(result.Items) != 1 || result.Items[0].GetName() != "bar" || result.Items[0].GetResourceVersion() != "99" {
+		t.Errorf("unexpected items %v", result.Items)
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/interpreter"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+const (
+	statusSubresource = "status"
+
+	baseKind = "Base"
+)
+
+// ClusterStatus is the status of the object in a single child cluster,
+// which is compiled from the corresponding Description.
+type ClusterStatus struct {
+	ClusterID   string                   `json:"clusterID,omitempty"`
+	ClusterName string                   `json:"clusterName,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Phase       appsapi.DescriptionPhase `json:"phase,omitempty"`
+	Reason      string                   `json:"reason,omitempty"`
+	// Fields are the status fields collected from the object in the cluster, which are declared by StatusAggregations.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// AggregatedStatus is the status of the object across all the child clusters.
+type AggregatedStatus struct {
+	// DesiredClusters is the number of child clusters that this object is scheduled to.
+	DesiredClusters int `json:"desiredClusters"`
+	// SucceededClusters is the number of child clusters that this object is deployed to successfully.
+	SucceededClusters int `json:"succeededClusters"`
+	// FailedClusters is the number of child clusters that this object failed to be deployed to.
+	FailedClusters int `json:"failedClusters"`
+	// Clusters holds the status in each child cluster.
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+	// Fields are the status fields aggregated from all the child clusters, which are declared by StatusAggregations.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// getAggregatedStatus returns the object with the status aggregated from the per-cluster Descriptions.
+func (r *REST) getAggregatedStatus(manifest *appsapi.Manifest, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	// Bases referring this Manifest are labeled on the Manifest with "<base-uid>: Base"
+	baseUIDs := sets.NewString()
+	for key, val := range manifest.Labels {
+		if val == baseKind {
+			baseUIDs.Insert(key)
+		}
+	}
+
+	aggregatedStatus := AggregatedStatus{}
+	if baseUIDs.Len() > 0 {
+		uidRequirement, err := labels.NewRequirement(known.ConfigUIDLabel, selection.In, baseUIDs.List())
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		kindRequirement, err := labels.NewRequirement(known.ConfigKindLabel, selection.Equals, []string{baseKind})
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		descs, err := r.clusternetInformerFactory.Apps().V1alpha1().Descriptions().Lister().
+			List(labels.NewSelector().Add(*uidRequirement, *kindRequirement))
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+
+		aggregatedStatus = aggregateStatus(descs, obj)
+	}
+
+	status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&aggregatedStatus)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	// the aggregated fields are merged into the status as well, such as "status.readyReplicas",
+	// without overriding the ones of AggregatedStatus
+	for key, value := range aggregatedStatus.Fields {
+		if _, ok := status[key]; !ok {
+			status[key] = runtime.DeepCopyJSONValue(value)
+		}
+	}
+	if err = unstructured.SetNestedMap(obj.Object, status, statusSubresource); err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return obj, nil
+}
+
+// aggregateStatus aggregates the status from the generic Descriptions. A cluster may hold multiple Descriptions
+// for the same Base when large bundles get split, which are combined into a single cluster status. The object in a
+// cluster is taken as failed if any of the Descriptions fails, and succeeded only if all of them succeed.
+// The status fields of the object reported by clusternet-agent are aggregated with its resource interpreter.
+func aggregateStatus(descs []*appsapi.Description, obj *unstructured.Unstructured) AggregatedStatus {
+	byCluster := map[string][]*appsapi.Description{}
+	for _, desc := range descs {
+		if desc.Spec.Deployer != appsapi.DescriptionGenericDeployer {
+			continue
+		}
+		// every cluster has a dedicated namespace
+		byCluster[desc.Namespace] = append(byCluster[desc.Namespace], desc)
+	}
+
+	aggregatedStatus := AggregatedStatus{}
+	for _, clusterDescs := range byCluster {
+		sort.SliceStable(clusterDescs, func(i, j int) bool {
+			return clusterDescs[i].Name < clusterDescs[j].Name
+		})
+
+		clusterStatus := ClusterStatus{
+			ClusterID:   clusterDescs[0].Labels[known.ClusterIDLabel],
+			ClusterName: clusterDescs[0].Labels[known.ClusterNameLabel],
+			Phase:       appsapi.DescriptionPhaseSuccess,
+		}
+		var names, reasons []string
+		for _, desc := range clusterDescs {
+			names = append(names, desc.Namespace+"/"+desc.Name)
+			switch desc.Status.Phase {
+			case appsapi.DescriptionPhaseSuccess:
+			case appsapi.DescriptionPhaseFailure:
+				clusterStatus.Phase = appsapi.DescriptionPhaseFailure
+				if len(desc.Status.Reason) > 0 {
+					reasons = append(reasons, desc.Status.Reason)
+				}
+			default:
+				if clusterStatus.Phase != appsapi.DescriptionPhaseFailure {
+					clusterStatus.Phase = desc.Status.Phase
+				}
+			}
+		}
+		clusterStatus.Description = strings.Join(names, ",")
+		clusterStatus.Reason = strings.Join(reasons, "; ")
+		clusterStatus.Fields = getStatusFields(clusterDescs, obj)
+
+		aggregatedStatus.DesiredClusters++
+		switch clusterStatus.Phase {
+		case appsapi.DescriptionPhaseSuccess:
+			aggregatedStatus.SucceededClusters++
+		case appsapi.DescriptionPhaseFailure:
+			aggregatedStatus.FailedClusters++
+		}
+		aggregatedStatus.Clusters = append(aggregatedStatus.Clusters, clusterStatus)
+	}
+	sort.SliceStable(aggregatedStatus.Clusters, func(i, j int) bool {
+		return aggregatedStatus.Clusters[i].Description < aggregatedStatus.Clusters[j].Description
+	})
+
+	var statuses []interpreter.ClusterStatus
+	for _, clusterStatus := range aggregatedStatus.Clusters {
+		if clusterStatus.Fields == nil {
+			continue
+		}
+		statuses = append(statuses, interpreter.ClusterStatus{
+			Cluster: clusterStatus.ClusterID,
+			Status:  clusterStatus.Fields,
+		})
+	}
+	if len(statuses) > 0 {
+		fields, err := interpreter.AggregateStatus(obj, statuses)
+		if err != nil {
+			klog.Warningf("failed to aggregate status of %s %s: %v", obj.GetKind(), klog.KObj(obj), err)
+		}
+		if len(fields) > 0 {
+			aggregatedStatus.Fields = fields
+		}
+	}
+	return aggregatedStatus
+}
+
+// getStatusFields returns the status fields of the object in the feedback of the Descriptions in a cluster.
+func getStatusFields(descs []*appsapi.Description, obj *unstructured.Unstructured) map[string]interface{} {
+	for _, desc := range descs {
+		for _, feedback := range desc.Status.Resources {
+			if feedback.APIVersion != obj.GetAPIVersion() || feedback.Kind != obj.GetKind() ||
+				feedback.Namespace != obj.GetNamespace() || feedback.Name != obj.GetName() {
+				continue
+			}
+			if feedback.Status == nil || len(feedback.Status.Raw) == 0 {
+				return nil
+			}
+			fields := map[string]interface{}{}
+			if err := json.Unmarshal(feedback.Status.Raw, &fields); err != nil {
+				klog.Warningf("failed to unmarshal status of %s %s in Description %s: %v",
+					obj.GetKind(), klog.KObj(obj), klog.KObj(desc), err)
+				return nil
+			}
+			return fields
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func newDescription(namespace, name, clusterID string, phase appsapi.DescriptionPhase, reason string) *appsapi.Description {
+	return &appsapi.Description{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{known.ClusterIDLabel: clusterID},
+		},
+		Spec:   appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer},
+		Status: appsapi.DescriptionStatus{Phase: phase, Reason: reason},
+	}
+}
+
+func TestAggregateStatus(t *testing.T) {
+	helmDesc := newDescription("clusternet-a", "app-helm", "a", appsapi.DescriptionPhaseFailure, "")
+	helmDesc.Spec.Deployer = appsapi.DescriptionHelmDeployer
+
+	descs := []*appsapi.Description{
+		// cluster a holds a bundle split into two Descriptions
+		newDescription("clusternet-a", "app-generic", "a", appsapi.DescriptionPhaseSuccess, ""),
+		newDescription("clusternet-a", "app-generic-1", "a", appsapi.DescriptionPhaseSuccess, ""),
+		helmDesc,
+		// cluster b fails with one of its Descriptions
+		newDescription("clusternet-b", "app-generic-1", "b", appsapi.DescriptionPhaseFailure, "quota exceeded"),
+		newDescription("clusternet-b", "app-generic", "b", appsapi.DescriptionPhaseSuccess, ""),
+		// cluster c is still in progress
+		newDescription("clusternet-c", "app-generic", "c", appsapi.DescriptionPhaseSuccess, ""),
+		newDescription("clusternet-c", "app-generic-1", "c", "", ""),
+	}
+
+	want := AggregatedStatus{
+		DesiredClusters:   3,
+		SucceededClusters: 1,
+		FailedClusters:    1,
+		Clusters: []ClusterStatus{
+			{
+				ClusterID:   "a",
+				Description: "clusternet-a/app-generic,clusternet-a/app-generic-1",
+				Phase:       appsapi.DescriptionPhaseSuccess,
+			},
+			{
+				ClusterID:   "b",
+				Description: "clusternet-b/app-generic,clusternet-b/app-generic-1",
+				Phase:       appsapi.DescriptionPhaseFailure,
+				Reason:      "quota exceeded",
+			},
+			{
+				ClusterID:   "c",
+				Description: "clusternet-c/app-generic,clusternet-c/app-generic-1",
+			},
+		},
+	}
+	if got := aggregateStatus(descs, newDeployment()); !reflect.DeepEqual(got, want) {
+		t.Errorf("aggregateStatus() = %+v, want %+v", got, want)
+	}
+}
+
+func newDeployment() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "foo"},
+	}}
+}
+
+func TestAggregateStatusFields(t *testing.T) {
+	withFeedback := func(desc *appsapi.Description, resources ...appsapi.ResourceFeedback) *appsapi.Description {
+		desc.Status.Resources = resources
+		return desc
+	}
+	feedback := func(name, status string) appsapi.ResourceFeedback {
+		return appsapi.ResourceFeedback{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "foo",
+			Name:       name,
+			Status:     &runtime.RawExtension{Raw: []byte(status)},
+		}
+	}
+
+	descs := []*appsapi.Description{
+		withFeedback(newDescription("clusternet-a", "app-generic", "a", appsapi.DescriptionPhaseSuccess, ""),
+			feedback("web", `{"readyReplicas":2}`), feedback("api", `{"readyReplicas":5}`)),
+		// the object is carried by the second Description when large bundles get split
+		newDescription("clusternet-b", "app-generic", "b", appsapi.DescriptionPhaseSuccess, ""),
+		withFeedback(newDescription("clusternet-b", "app-generic-1", "b", appsapi.DescriptionPhaseSuccess, ""),
+			feedback("web", `{"readyReplicas":1}`)),
+		// no status fields are reported yet
+		newDescription("clusternet-c", "app-generic", "c", appsapi.DescriptionPhaseSuccess, ""),
+	}
+
+	got := aggregateStatus(descs, newDeployment())
+	wantClusterFields := []map[string]interface{}{
+		{"readyReplicas": float64(2)},
+		{"readyReplicas": float64(1)},
+		nil,
+	}
+	for i, clusterStatus := range got.Clusters {
+		if !reflect.DeepEqual(clusterStatus.Fields, wantClusterFields[i]) {
+			t.Errorf("fields of cluster %s = %v, want %v", clusterStatus.ClusterID, clusterStatus.Fields, wantClusterFields[i])
+		}
+	}
+	if want := map[string]interface{}{"readyReplicas": int64(3)}; !reflect.DeepEqual(got.Fields, want) {
+		t.Errorf("aggregated fields = %v, want %v", got.Fields, want)
+	}
+}
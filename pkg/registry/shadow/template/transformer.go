@@ -29,6 +29,8 @@ import (
 func transformManifest(manifest *appsapi.Manifest) (*unstructured.Unstructured, error) {
 	result := &unstructured.Unstructured{}
 	if err := json.Unmarshal(manifest.Template.Raw, result); err != nil {
+		recordManifestTranslationError(manifest.Labels[known.ConfigGroupLabel], manifest.Labels[known.ConfigVersionLabel],
+			manifest.Labels[known.ConfigKindLabel], "decode")
 		return nil, errors.NewInternalError(err)
 	}
 	result.SetGeneration(manifest.Generation)
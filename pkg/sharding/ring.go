@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// virtualNodes is the number of points of each member on the hash ring, which spreads keys evenly
+const virtualNodes = 100
+
+// hashRing maps keys to members with consistent hashing, so that only the keys of the joined or left
+// members are moved when the membership changes.
+type hashRing struct {
+	members []string
+	points  []uint32
+	owners  map[uint32]string
+}
+
+func newHashRing(members []string) *hashRing {
+	ring := &hashRing{
+		members: members,
+		owners:  map[uint32]string{},
+	}
+	for _, member := range members {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(member + "#" + strconv.Itoa(i))
+			if _, ok := ring.owners[point]; ok {
+				continue
+			}
+			ring.owners[point] = member
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool {
+		return ring.points[i] < ring.points[j]
+	})
+	return ring
+}
+
+// get returns the member owning the key, or empty if there are no members.
+func (r *hashRing) get(key string) string {
+	if r == nil || len(r.points) == 0 {
+		return ""
+	}
+	point := hash(key)
+	idx := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= point
+	})
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return r.owners[r.points[idx]]
+}
+
+func hash(key string) uint32 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+// shardLeasePrefix is the name prefix of the Leases renewed by the replicas of clusternet-hub as their memberships
+const shardLeasePrefix = "clusternet-hub-shard-"
+
+// Sharder splits child clusters across the replicas of clusternet-hub with consistent hashing. Every replica
+// renews a Lease as its membership, and only handles the clusters mapped to itself on the hash ring of the replicas
+// whose Leases are not expired. A nil Sharder owns all the clusters.
+type Sharder struct {
+	client        kubernetes.Interface
+	namespace     string
+	leaseName     string
+	identity      string
+	leaseDuration time.Duration
+
+	lock     sync.RWMutex
+	ring     *hashRing
+	handlers []func()
+}
+
+// NewSharder returns a Sharder renewing its Lease in the namespace.
+func NewSharder(client kubernetes.Interface, namespace string, leaseDuration time.Duration) (*Sharder, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get hostname: %v", err)
+	}
+	id := string(uuid.NewUUID())
+	return &Sharder{
+		client:        client,
+		namespace:     namespace,
+		leaseName:     shardLeasePrefix + id,
+		identity:      hostname + "_" + id,
+		leaseDuration: leaseDuration,
+	}, nil
+}
+
+// AddMembershipHandler registers a handler called after the replicas change, so that the clusters
+// moved to current replica could be handled.
+func (s *Sharder) AddMembershipHandler(handler func()) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Owns tells whether the cluster with the dedicated namespace is handled by current replica.
+// Nothing is owned until the replicas are known.
+func (s *Sharder) Owns(clusterNamespace string) bool {
+	if s == nil {
+		return true
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.ring.get(clusterNamespace) == s.identity
+}
+
+// Run renews the Lease and refreshes the replicas until the context is done, when the Lease is deleted
+// so that other replicas take over the clusters without waiting for it to expire.
+func (s *Sharder) Run(ctx context.Context) {
+	klog.Infof("starting sharder %s...", s.identity)
+	defer klog.Infof("shutting down sharder %s", s.identity)
+
+	wait.UntilWithContext(ctx, s.sync, s.leaseDuration/3)
+
+	err := s.client.CoordinationV1().Leases(s.namespace).Delete(context.TODO(), s.leaseName, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to delete Lease %s/%s: %v", s.namespace, s.leaseName, err)
+	}
+}
+
+func (s *Sharder) sync(ctx context.Context) {
+	if err := s.renew(ctx); err != nil {
+		klog.Warningf("failed to renew Lease %s/%s: %v", s.namespace, s.leaseName, err)
+		return
+	}
+
+	leases, err := s.client.CoordinationV1().Leases(s.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("failed to list Leases in namespace %s: %v", s.namespace, err)
+		return
+	}
+	members := liveMembers(leases.Items, time.Now())
+
+	s.lock.Lock()
+	if s.ring != nil && reflect.DeepEqual(s.ring.members, members) {
+		s.lock.Unlock()
+		return
+	}
+	klog.Infof("replicas of clusternet-hub changed to %v", members)
+	s.ring = newHashRing(members)
+	handlers := s.handlers
+	s.lock.Unlock()
+
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+func (s *Sharder) renew(ctx context.Context) error {
+	now := metav1.NewMicroTime(time.Now())
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	lease, err := leases.Get(ctx, s.leaseName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = leases.Create(ctx, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.leaseName,
+				Namespace: s.namespace,
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       utilpointer.StringPtr(s.identity),
+				LeaseDurationSeconds: utilpointer.Int32Ptr(int32(s.leaseDuration.Seconds())),
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	lease = lease.DeepCopy()
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// liveMembers returns the sorted identities of the replicas whose Leases are not expired.
+func liveMembers(leases []coordinationv1.Lease, now time.Time) []string {
+	var members []string
+	for _, lease := range leases {
+		if !strings.HasPrefix(lease.Name, shardLeasePrefix) || lease.Spec.HolderIdentity == nil ||
+			lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+			continue
+		}
+		expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+		if now.After(expiry) {
+			continue
+		}
+		members = append(members, *lease.Spec.HolderIdentity)
+	}
+	sort.Strings(members)
+	return members
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+func TestHashRing(t *testing.T) {
+	if got := newHashRing(nil).get("clusternet-abcde"); got != "" {
+		t.Errorf("get() without members = %q, want empty", got)
+	}
+
+	members := []string{"hub-0", "hub-1", "hub-2"}
+	ring := newHashRing(members)
+	smaller := newHashRing(members[:2])
+
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("clusternet-%d", i)
+		owner := ring.get(key)
+		counts[owner]++
+		// only the keys of the removed member are moved
+		if owner != "hub-2" && smaller.get(key) != owner {
+			t.Fatalf("key %s moved from %s to %s", key, owner, smaller.get(key))
+		}
+	}
+	for _, member := range members {
+		if counts[member] < 500 {
+			t.Errorf("member %s owns %d of 3000 keys, which are not spread evenly", member, counts[member])
+		}
+	}
+}
+
+func TestLiveMembers(t *testing.T) {
+	now := time.Now()
+	newLease := func(name, holder string, renewed time.Duration) coordinationv1.Lease {
+		renewTime := metav1.NewMicroTime(now.Add(-renewed))
+		return coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       utilpointer.StringPtr(holder),
+				LeaseDurationSeconds: utilpointer.Int32Ptr(15),
+				RenewTime:            &renewTime,
+			},
+		}
+	}
+
+	leases := []coordinationv1.Lease{
+		newLease(shardLeasePrefix+"b", "hub-b", 5*time.Second),
+		newLease(shardLeasePrefix+"a", "hub-a", 0),
+		newLease(shardLeasePrefix+"c", "hub-c", time.Minute),
+		newLease("other", "other", 0),
+	}
+	want := []string{"hub-a", "hub-b"}
+	if got := liveMembers(leases, now); !reflect.DeepEqual(got, want) {
+		t.Errorf("liveMembers() = %v, want %v", got, want)
+	}
+}
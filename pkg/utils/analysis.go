@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type prometheusSample struct {
+	Value []interface{} `json:"value"`
+}
+
+// ParsePrometheusValues returns the values of an instant query response from the Prometheus HTTP API,
+// whose result type should be "vector" or "scalar".
+func ParsePrometheusValues(body []byte) ([]float64, error) {
+	resp := &prometheusResponse{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", resp.Error)
+	}
+
+	switch resp.Data.ResultType {
+	case "scalar":
+		var value []interface{}
+		if err := json.Unmarshal(resp.Data.Result, &value); err != nil {
+			return nil, err
+		}
+		v, err := parsePrometheusValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return []float64{v}, nil
+	case "vector":
+		var samples []prometheusSample
+		if err := json.Unmarshal(resp.Data.Result, &samples); err != nil {
+			return nil, err
+		}
+		var values []float64
+		for _, sample := range samples {
+			v, err := parsePrometheusValue(sample.Value)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+}
+
+// parsePrometheusValue parses a value in the format of [<unix_time>, "<value>"]
+func parsePrometheusValue(value []interface{}) (float64, error) {
+	if len(value) != 2 {
+		return 0, fmt.Errorf("invalid value %v", value)
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid value %v", value)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// CheckMetricThresholds returns a message about the first value out of the thresholds,
+// and an empty message if all the values are within the thresholds. Empty thresholds are ignored.
+func CheckMetricThresholds(values []float64, min, max string) (string, error) {
+	if len(min) > 0 {
+		threshold, err := strconv.ParseFloat(min, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid min %q: %v", min, err)
+		}
+		for _, value := range values {
+			if value < threshold {
+				return fmt.Sprintf("%v is less than %s", value, min), nil
+			}
+		}
+	}
+	if len(max) > 0 {
+		threshold, err := strconv.ParseFloat(max, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid max %q: %v", max, err)
+		}
+		for _, value := range values {
+			if value > threshold {
+				return fmt.Sprintf("%v is greater than %s", value, max), nil
+			}
+		}
+	}
+	return "", nil
+}
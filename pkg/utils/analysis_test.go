@@ -0,0 +1,122 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePrometheusValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []float64
+		wantErr bool
+	}{
+		{
+			name: "vector",
+			body: `{"status":"success","data":{"resultType":"vector","result":[` +
+				`{"metric":{"cluster":"a"},"value":[1435781451.781,"0.01"]},` +
+				`{"metric":{"cluster":"b"},"value":[1435781451.781,"0.2"]}]}}`,
+			want: []float64{0.01, 0.2},
+		},
+		{
+			name: "scalar",
+			body: `{"status":"success","data":{"resultType":"scalar","result":[1435781451.781,"1"]}}`,
+			want: []float64{1},
+		},
+		{
+			name: "empty vector",
+			body: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		},
+		{
+			name:    "error",
+			body:    `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			wantErr: true,
+		},
+		{
+			name:    "matrix",
+			body:    `{"status":"success","data":{"resultType":"matrix","result":[]}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrometheusValues([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePrometheusValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePrometheusValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMetricThresholds(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		min      string
+		max      string
+		wantFail bool
+		wantErr  bool
+	}{
+		{
+			name:   "no thresholds",
+			values: []float64{100},
+		},
+		{
+			name:   "within thresholds",
+			values: []float64{0.995, 0.999},
+			min:    "0.99",
+			max:    "1",
+		},
+		{
+			name:     "less than min",
+			values:   []float64{0.995, 0.98},
+			min:      "0.99",
+			wantFail: true,
+		},
+		{
+			name:     "greater than max",
+			values:   []float64{0.06},
+			max:      "0.05",
+			wantFail: true,
+		},
+		{
+			name:    "invalid threshold",
+			values:  []float64{0.06},
+			max:     "five percent",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := CheckMetricThresholds(tt.values, tt.min, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckMetricThresholds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (len(msg) > 0) != tt.wantFail {
+				t.Errorf("CheckMetricThresholds() = %q, wantFail %v", msg, tt.wantFail)
+			}
+		})
+	}
+}
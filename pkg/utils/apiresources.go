@@ -0,0 +1,66 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// FindMissingAPIs returns the api versions of the feeds that are not served by the cluster.
+// Clusters not reporting their group versions are regarded as serving everything.
+// Feeds of Clusternet itself, such as HelmChart, and the groups of CRDs in the same feeds are skipped,
+// since they are deployed by clusternet-agent or along with the feeds.
+func FindMissingAPIs(feeds []appsapi.Feed, cluster *clusterapi.ManagedCluster) []string {
+	if len(cluster.Status.APIGroupVersions) == 0 {
+		return nil
+	}
+	served := sets.NewString(cluster.Status.APIGroupVersions...)
+
+	providedGroups := sets.NewString(appsapi.SchemeGroupVersion.Group)
+	for _, feed := range feeds {
+		gv, err := schema.ParseGroupVersion(feed.APIVersion)
+		if err != nil || gv.Group != apiextensionsv1.GroupName || feed.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		// CRD names are in the form of "<plural>.<group>"
+		if parts := strings.SplitN(feed.Name, ".", 2); len(parts) == 2 {
+			providedGroups.Insert(parts[1])
+		}
+	}
+
+	var missing []string
+	reported := sets.NewString()
+	for _, feed := range feeds {
+		gv, err := schema.ParseGroupVersion(feed.APIVersion)
+		if err != nil || providedGroups.Has(gv.Group) || served.Has(feed.APIVersion) || reported.Has(feed.APIVersion) {
+			continue
+		}
+		reported.Insert(feed.APIVersion)
+		missing = append(missing, fmt.Sprintf("%s requires %s, which is not served by cluster %s",
+			FormatFeed(feed), feed.APIVersion, klog.KObj(cluster)))
+	}
+	return missing
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestFindMissingAPIs(t *testing.T) {
+	deployment := appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "nginx"}
+	foo := appsapi.Feed{APIVersion: "example.com/v1", Kind: "Foo", Namespace: "default", Name: "foo"}
+	fooCRD := appsapi.Feed{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition", Name: "foos.example.com"}
+	chart := appsapi.Feed{APIVersion: "apps.clusternet.io/v1alpha1", Kind: "HelmChart", Namespace: "default", Name: "mysql"}
+
+	tests := []struct {
+		name          string
+		feeds         []appsapi.Feed
+		groupVersions []string
+		wantMissing   int
+	}{
+		{
+			name:          "not reported",
+			feeds:         []appsapi.Feed{deployment, foo},
+			groupVersions: nil,
+			wantMissing:   0,
+		},
+		{
+			name:          "all served",
+			feeds:         []appsapi.Feed{deployment, chart},
+			groupVersions: []string{"v1", "apps/v1"},
+			wantMissing:   0,
+		},
+		{
+			name:          "custom resource missing",
+			feeds:         []appsapi.Feed{deployment, foo, foo},
+			groupVersions: []string{"v1", "apps/v1"},
+			wantMissing:   1,
+		},
+		{
+			name:          "crd deployed along with the feeds",
+			feeds:         []appsapi.Feed{deployment, fooCRD, foo},
+			groupVersions: []string{"v1", "apps/v1", "apiextensions.k8s.io/v1"},
+			wantMissing:   0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &clusterapi.ManagedCluster{}
+			cluster.Status.APIGroupVersions = tt.groupVersions
+			if got := FindMissingAPIs(tt.feeds, cluster); len(got) != tt.wantMissing {
+				t.Errorf("FindMissingAPIs() = %v, want %d missing", got, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// IsDescriptionApproved returns whether condition Approved of the Description is True for its current generation.
+func IsDescriptionApproved(desc *appsapi.Description) bool {
+	condition := apimeta.FindStatusCondition(desc.Status.Conditions, appsapi.DescriptionApproved)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == desc.Generation
+}
+
+// IsDescriptionPendingApproval returns whether the Description requires approval, and the changes of its current
+// generation have not been approved yet.
+func IsDescriptionPendingApproval(desc *appsapi.Description) bool {
+	return desc.Annotations[known.ApprovalRequiredAnnotation] == "true" && !IsDescriptionApproved(desc)
+}
+
+// SummarizeDescriptionChanges lists the changes from the old spec of a Description to the current one, such as
+// "create Deployment foo/nginx", "update Service foo/nginx" and "delete ConfigMap foo/settings".
+// A nil old spec means the Description is newly created.
+func SummarizeDescriptionChanges(old, cur *appsapi.DescriptionSpec) []string {
+	oldKeys, oldObjects := describeObjects(old)
+	curKeys, curObjects := describeObjects(cur)
+
+	var changes []string
+	for _, key := range curKeys {
+		oldObject, ok := oldObjects[key]
+		switch {
+		case !ok:
+			changes = append(changes, "create "+key)
+		case !bytes.Equal(oldObject, curObjects[key]):
+			changes = append(changes, "update "+key)
+		}
+	}
+	for _, key := range oldKeys {
+		if _, ok := curObjects[key]; !ok {
+			changes = append(changes, "delete "+key)
+		}
+	}
+	return changes
+}
+
+// describeObjects returns the keys of the objects in a Description spec in order, such as "Deployment foo/nginx",
+// together with their raw data. The values of HelmCharts are keyed by the charts.
+func describeObjects(spec *appsapi.DescriptionSpec) ([]string, map[string][]byte) {
+	objects := map[string][]byte{}
+	if spec == nil {
+		return nil, objects
+	}
+
+	var keys []string
+	if spec.Deployer == appsapi.DescriptionHelmDeployer {
+		for idx, chart := range spec.Charts {
+			key := fmt.Sprintf("HelmChart %s/%s", chart.Namespace, chart.Name)
+			keys = append(keys, key)
+			if idx < len(spec.Raw) {
+				objects[key] = spec.Raw[idx]
+			}
+		}
+		return keys, objects
+	}
+
+	for idx, raw := range spec.Raw {
+		obj := &metav1.PartialObjectMetadata{}
+		key := fmt.Sprintf("object %d", idx)
+		if err := json.Unmarshal(raw, obj); err == nil {
+			key = fmt.Sprintf("%s %s", obj.Kind, obj.Name)
+			if len(obj.Namespace) > 0 {
+				key = fmt.Sprintf("%s %s/%s", obj.Kind, obj.Namespace, obj.Name)
+			}
+		}
+		keys = append(keys, key)
+		objects[key] = raw
+	}
+	return keys, objects
+}
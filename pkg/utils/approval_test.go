@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestIsDescriptionPendingApproval(t *testing.T) {
+	newDesc := func(required bool, conditions ...metav1.Condition) *appsapi.Description {
+		desc := &appsapi.Description{}
+		desc.Generation = 2
+		if required {
+			desc.Annotations = map[string]string{known.ApprovalRequiredAnnotation: "true"}
+		}
+		desc.Status.Conditions = conditions
+		return desc
+	}
+
+	tests := []struct {
+		name string
+		desc *appsapi.Description
+		want bool
+	}{
+		{
+			name: "approval not required",
+			desc: newDesc(false),
+			want: false,
+		},
+		{
+			name: "not approved yet",
+			desc: newDesc(true, metav1.Condition{Type: appsapi.DescriptionApproved, Status: metav1.ConditionFalse, ObservedGeneration: 2}),
+			want: true,
+		},
+		{
+			name: "without condition",
+			desc: newDesc(true),
+			want: true,
+		},
+		{
+			name: "approved",
+			desc: newDesc(true, metav1.Condition{Type: appsapi.DescriptionApproved, Status: metav1.ConditionTrue, ObservedGeneration: 2}),
+			want: false,
+		},
+		{
+			name: "approved for previous generation",
+			desc: newDesc(true, metav1.Condition{Type: appsapi.DescriptionApproved, Status: metav1.ConditionTrue, ObservedGeneration: 1}),
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDescriptionPendingApproval(tt.desc); got != tt.want {
+				t.Errorf("IsDescriptionPendingApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeDescriptionChanges(t *testing.T) {
+	deploy := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"nginx","namespace":"foo"},"spec":{"replicas":1}}`)
+	scaled := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"nginx","namespace":"foo"},"spec":{"replicas":3}}`)
+	svc := []byte(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"nginx","namespace":"foo"}}`)
+	ns := []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"foo"}}`)
+
+	tests := []struct {
+		name string
+		old  *appsapi.DescriptionSpec
+		cur  *appsapi.DescriptionSpec
+		want []string
+	}{
+		{
+			name: "newly created",
+			cur:  &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer, Raw: [][]byte{ns, deploy}},
+			want: []string{"create Namespace foo", "create Deployment foo/nginx"},
+		},
+		{
+			name: "updated",
+			old:  &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer, Raw: [][]byte{ns, deploy, svc}},
+			cur:  &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer, Raw: [][]byte{ns, scaled}},
+			want: []string{"update Deployment foo/nginx", "delete Service foo/nginx"},
+		},
+		{
+			name: "no changes",
+			old:  &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer, Raw: [][]byte{deploy}},
+			cur:  &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionGenericDeployer, Raw: [][]byte{deploy}},
+		},
+		{
+			name: "helm values changed",
+			old: &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionHelmDeployer,
+				Charts: []appsapi.ChartReference{{Namespace: "default", Name: "mysql"}}, Raw: [][]byte{[]byte(`{}`)}},
+			cur: &appsapi.DescriptionSpec{Deployer: appsapi.DescriptionHelmDeployer,
+				Charts: []appsapi.ChartReference{{Namespace: "default", Name: "mysql"}}, Raw: [][]byte{[]byte(`{"replicaCount":2}`)}},
+			want: []string{"update HelmChart default/mysql"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SummarizeDescriptionChanges(tt.old, tt.cur); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummarizeDescriptionChanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// keys of bootstrap token secrets, see https://kubernetes.io/docs/reference/access-authn-authz/bootstrap-tokens/
+const (
+	bootstrapTokenSecretPrefix        = "bootstrap-token-"
+	bootstrapTokenIDKey               = "token-id"
+	bootstrapTokenSecretKey           = "token-secret"
+	bootstrapTokenDescriptionKey      = "description"
+	bootstrapTokenExpirationKey       = "expiration"
+	bootstrapTokenUsageAuthentication = "usage-bootstrap-authentication"
+	bootstrapTokenExtraGroupsKey      = "auth-extra-groups"
+
+	bootstrapTokenChars = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+var bootstrapTokenRegexp = regexp.MustCompile(`^([a-z0-9]{6})\.([a-z0-9]{16})$`)
+
+// GenerateBootstrapToken generates a random bootstrap token in the format of "[a-z0-9]{6}.[a-z0-9]{16}"
+func GenerateBootstrapToken() (string, error) {
+	id, err := randomBootstrapTokenString(6)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomBootstrapTokenString(16)
+	if err != nil {
+		return "", err
+	}
+	return id + "." + secret, nil
+}
+
+// ParseBootstrapToken returns the token id and the token secret of a bootstrap token
+func ParseBootstrapToken(token string) (string, string, error) {
+	parts := bootstrapTokenRegexp.FindStringSubmatch(token)
+	if len(parts) != 3 {
+		return "", "", fmt.Errorf("bootstrap token does not match the format %q", bootstrapTokenRegexp.String())
+	}
+	return parts[1], parts[2], nil
+}
+
+// NewBootstrapTokenSecret returns the Secret of a bootstrap token used for cluster registration, which is only
+// valid for authentication and gets deleted by the token cleaner of kube-controller-manager after ttl.
+// A zero ttl means the token never expires.
+func NewBootstrapTokenSecret(token string, ttl time.Duration, description string) (*corev1.Secret, error) {
+	id, secret, err := ParseBootstrapToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string][]byte{
+		bootstrapTokenIDKey:               []byte(id),
+		bootstrapTokenSecretKey:           []byte(secret),
+		bootstrapTokenUsageAuthentication: []byte("true"),
+		bootstrapTokenExtraGroupsKey:      []byte(known.ClusterRegistrationTokenGroup),
+	}
+	if len(description) > 0 {
+		data[bootstrapTokenDescriptionKey] = []byte(description)
+	}
+	if ttl > 0 {
+		data[bootstrapTokenExpirationKey] = []byte(time.Now().Add(ttl).UTC().Format(time.RFC3339))
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BootstrapTokenSecretName(id),
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				known.ObjectCreatedByLabel: known.ClusternetHubName,
+			},
+		},
+		Type: corev1.SecretTypeBootstrapToken,
+		Data: data,
+	}, nil
+}
+
+// SignWithBootstrapToken signs the data with a bootstrap token, and returns the signature in the format of
+// "<token id>:<signature>", which proves the possession of the token without revealing its secret.
+func SignWithBootstrapToken(token, data string) (string, error) {
+	id, _, err := ParseBootstrapToken(token)
+	if err != nil {
+		return "", err
+	}
+	return id + ":" + computeBootstrapTokenSignature(token, data), nil
+}
+
+// BootstrapTokenIDFromSignature returns the id of the bootstrap token that the signature is signed with.
+func BootstrapTokenIDFromSignature(signature string) string {
+	return strings.SplitN(signature, ":", 2)[0]
+}
+
+// VerifyBootstrapTokenSignature verifies the signature of the data with the Secret of a bootstrap token,
+// and returns the groups that the token authenticates as.
+func VerifyBootstrapTokenSignature(secret *corev1.Secret, signature, data string) ([]string, error) {
+	id := string(secret.Data[bootstrapTokenIDKey])
+	token := id + "." + string(secret.Data[bootstrapTokenSecretKey])
+	parts := strings.SplitN(signature, ":", 2)
+	if len(parts) != 2 || parts[0] != id ||
+		!hmac.Equal([]byte(parts[1]), []byte(computeBootstrapTokenSignature(token, data))) {
+		return nil, fmt.Errorf("invalid signature of bootstrap token %s", id)
+	}
+	if expiration, ok := secret.Data[bootstrapTokenExpirationKey]; ok {
+		t, err := time.Parse(time.RFC3339, string(expiration))
+		if err != nil || time.Now().After(t) {
+			return nil, fmt.Errorf("bootstrap token %s has expired", id)
+		}
+	}
+
+	groups := []string{"system:bootstrappers"}
+	for _, group := range strings.Split(string(secret.Data[bootstrapTokenExtraGroupsKey]), ",") {
+		if group = strings.TrimSpace(group); len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// BootstrapTokenSecretName returns the name of the Secret in namespace kube-system storing the bootstrap token.
+func BootstrapTokenSecretName(id string) string {
+	return bootstrapTokenSecretPrefix + id
+}
+
+func computeBootstrapTokenSignature(token, data string) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func randomBootstrapTokenString(length int) (string, error) {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(bootstrapTokenChars)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = bootstrapTokenChars[n.Int64()]
+	}
+	return string(b), nil
+}
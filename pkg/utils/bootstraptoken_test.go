@@ -0,0 +1,125 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestGenerateBootstrapToken(t *testing.T) {
+	token, err := GenerateBootstrapToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err = ParseBootstrapToken(token); err != nil {
+		t.Errorf("generated an invalid bootstrap token: %v", err)
+	}
+}
+
+func TestParseBootstrapToken(t *testing.T) {
+	tests := []struct {
+		token   string
+		id      string
+		secret  string
+		wantErr bool
+	}{
+		{token: "07401b.f395accd246ae52d", id: "07401b", secret: "f395accd246ae52d"},
+		{token: "07401B.f395accd246ae52d", wantErr: true},
+		{token: "07401b:f395accd246ae52d", wantErr: true},
+		{token: "07401b.f395accd246ae52", wantErr: true},
+		{token: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		id, secret, err := ParseBootstrapToken(tt.token)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBootstrapToken(%q) error = %v, wantErr %v", tt.token, err, tt.wantErr)
+			continue
+		}
+		if id != tt.id || secret != tt.secret {
+			t.Errorf("ParseBootstrapToken(%q) = %q, %q, want %q, %q", tt.token, id, secret, tt.id, tt.secret)
+		}
+	}
+}
+
+func TestNewBootstrapTokenSecret(t *testing.T) {
+	secret, err := NewBootstrapTokenSecret("07401b.f395accd246ae52d", time.Hour, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Name != "bootstrap-token-07401b" || secret.Type != corev1.SecretTypeBootstrapToken {
+		t.Errorf("unexpected secret %s with type %s", secret.Name, secret.Type)
+	}
+	if string(secret.Data[bootstrapTokenExtraGroupsKey]) != known.ClusterRegistrationTokenGroup {
+		t.Errorf("unexpected extra groups %q", secret.Data[bootstrapTokenExtraGroupsKey])
+	}
+	expiration, err := time.Parse(time.RFC3339, string(secret.Data[bootstrapTokenExpirationKey]))
+	if err != nil {
+		t.Fatalf("invalid expiration: %v", err)
+	}
+	if expiration.Before(time.Now()) || expiration.After(time.Now().Add(time.Hour)) {
+		t.Errorf("unexpected expiration %s", expiration)
+	}
+
+	secret, err = NewBootstrapTokenSecret("07401b.f395accd246ae52d", 0, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := secret.Data[bootstrapTokenExpirationKey]; ok {
+		t.Errorf("expected a token never expires")
+	}
+}
+
+func TestVerifyBootstrapTokenSignature(t *testing.T) {
+	token := "07401b.f395accd246ae52d"
+	secret, err := NewBootstrapTokenSecret(token, time.Hour, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	signature, err := SignWithBootstrapToken(token, "dc91021d-2361-4f6d-a404-7c33b9e01118")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id := BootstrapTokenIDFromSignature(signature); id != "07401b" {
+		t.Errorf("expected token id 07401b, but got %q", id)
+	}
+
+	groups, err := VerifyBootstrapTokenSignature(secret, signature, "dc91021d-2361-4f6d-a404-7c33b9e01118")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 2 || groups[1] != known.ClusterRegistrationTokenGroup {
+		t.Errorf("unexpected groups %v", groups)
+	}
+
+	if _, err = VerifyBootstrapTokenSignature(secret, signature, "another-cluster"); err == nil {
+		t.Errorf("expected an error for the signature of other data")
+	}
+	forged, _ := SignWithBootstrapToken("07401b.0000000000000000", "dc91021d-2361-4f6d-a404-7c33b9e01118")
+	if _, err = VerifyBootstrapTokenSignature(secret, forged, "dc91021d-2361-4f6d-a404-7c33b9e01118"); err == nil {
+		t.Errorf("expected an error for the signature of a forged token")
+	}
+
+	secret.Data[bootstrapTokenExpirationKey] = []byte(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	if _, err = VerifyBootstrapTokenSignature(secret, signature, "dc91021d-2361-4f6d-a404-7c33b9e01118"); err == nil {
+		t.Errorf("expected an error for an expired token")
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"helm.sh/helm/v3/pkg/release"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// maxConditionMessageLength is the max length of condition messages allowed by metav1.Condition
+const maxConditionMessageLength = 32768
+
+// MergeConditions sets the desired conditions with the given observedGeneration on a copy of the existing ones.
+// Existing conditions of other types are kept, and last transition times are kept if statuses don't change.
+func MergeConditions(existing []metav1.Condition, generation int64, desired ...metav1.Condition) []metav1.Condition {
+	conditions := make([]metav1.Condition, 0, len(existing)+len(desired))
+	for _, condition := range existing {
+		conditions = append(conditions, *condition.DeepCopy())
+	}
+	for _, condition := range desired {
+		condition.ObservedGeneration = generation
+		if len(condition.Message) > maxConditionMessageLength {
+			condition.Message = condition.Message[:maxConditionMessageLength]
+		}
+		apimeta.SetStatusCondition(&conditions, condition)
+		// observedGeneration is not updated for existing conditions whose status doesn't change
+		apimeta.FindStatusCondition(conditions, condition.Type).ObservedGeneration = generation
+	}
+	return conditions
+}
+
+// SetDescriptionStatus sets the status of the Description, together with the Ready condition and observedGeneration,
+// so that all of them are updated in a single request.
+func SetDescriptionStatus(desc *appsapi.Description, status appsapi.DescriptionStatus) {
+	condition := metav1.Condition{
+		Type:    appsapi.DescriptionReady,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "Pending",
+		Message: "Description is pending to be deployed",
+	}
+	switch status.Phase {
+	case appsapi.DescriptionPhaseSuccess:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Deployed"
+		condition.Message = "Description is deployed successfully"
+	case appsapi.DescriptionPhaseFailure:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DeployFailed"
+		condition.Message = status.Reason
+	}
+
+	// count the consecutive failures of current generation
+	status.FailedAttempts = 0
+	if status.Phase == appsapi.DescriptionPhaseFailure {
+		status.FailedAttempts = 1
+		if desc.Status.ObservedGeneration == desc.Generation && desc.Status.Phase == appsapi.DescriptionPhaseFailure {
+			status.FailedAttempts = desc.Status.FailedAttempts + 1
+		}
+	}
+
+	conditions := MergeConditions(desc.Status.Conditions, desc.Generation, status.Conditions...)
+	conditions = MergeConditions(conditions, desc.Generation, condition)
+	// condition Approved only approves the generation that it is set for
+	if approved := apimeta.FindStatusCondition(status.Conditions, appsapi.DescriptionApproved); approved != nil && approved.ObservedGeneration > 0 {
+		apimeta.FindStatusCondition(conditions, appsapi.DescriptionApproved).ObservedGeneration = approved.ObservedGeneration
+	}
+	desc.Status = status
+	desc.Status.ObservedGeneration = desc.Generation
+	desc.Status.Conditions = conditions
+}
+
+// SetHelmChartStatus sets the status of the HelmChart, together with the Ready condition and observedGeneration.
+func SetHelmChartStatus(chart *appsapi.HelmChart, status appsapi.HelmChartStatus) {
+	condition := metav1.Condition{
+		Type:    appsapi.HelmChartReady,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "Pending",
+		Message: "HelmChart is pending to be checked",
+	}
+	switch status.Phase {
+	case appsapi.HelmChartFound:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ChartFound"
+		condition.Message = "chart is found in the repository"
+	case appsapi.HelmChartNotFound:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ChartNotFound"
+		condition.Message = status.Reason
+	case appsapi.HelmChartUnverified:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = appsapi.SignatureVerificationFailed
+		condition.Message = status.Reason
+	}
+
+	conditions := MergeConditions(chart.Status.Conditions, chart.Generation, status.Conditions...)
+	conditions = MergeConditions(conditions, chart.Generation, condition)
+	chart.Status = status
+	chart.Status.ObservedGeneration = chart.Generation
+	chart.Status.Conditions = conditions
+}
+
+// SetHelmReleaseStatus sets the status of the HelmRelease, together with the Ready condition and observedGeneration.
+func SetHelmReleaseStatus(hr *appsapi.HelmRelease, status appsapi.HelmReleaseStatus) {
+	condition := metav1.Condition{
+		Type:    appsapi.HelmReleaseReady,
+		Status:  metav1.ConditionFalse,
+		Message: status.Description,
+	}
+	switch status.Phase {
+	case release.StatusDeployed:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Deployed"
+	case release.StatusFailed:
+		condition.Reason = "Failed"
+	case release.StatusPendingInstall, release.StatusPendingUpgrade, release.StatusPendingRollback:
+		condition.Reason = "Progressing"
+	case release.StatusUninstalling, release.StatusUninstalled:
+		condition.Reason = "Uninstalling"
+	case release.StatusSuperseded:
+		condition.Reason = "Superseded"
+	default:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "Unknown"
+	}
+	if len(condition.Message) == 0 {
+		condition.Message = string(status.Phase)
+	}
+
+	conditions := MergeConditions(hr.Status.Conditions, hr.Generation, status.Conditions...)
+	conditions = MergeConditions(conditions, hr.Generation, condition)
+	hr.Status = status
+	hr.Status.ObservedGeneration = hr.Generation
+	hr.Status.Conditions = conditions
+}
+
+// SetClusterRegistrationRequestStatus sets the status of the ClusterRegistrationRequest,
+// together with the Approved condition and observedGeneration.
+func SetClusterRegistrationRequestStatus(crr *clusterapi.ClusterRegistrationRequest, status clusterapi.ClusterRegistrationRequestStatus) {
+	condition := metav1.Condition{
+		Type:    clusterapi.ClusterRegistrationRequestApproved,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "Pending",
+		Message: "request is pending for approval",
+	}
+	if status.Result == nil && len(status.ErrorMessage) > 0 {
+		condition.Message = status.ErrorMessage
+	}
+	if status.Result != nil {
+		switch *status.Result {
+		case clusterapi.RequestApproved:
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = string(clusterapi.RequestApproved)
+			condition.Message = "request is approved"
+		default:
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = string(*status.Result)
+			condition.Message = status.ErrorMessage
+		}
+	}
+
+	conditions := MergeConditions(crr.Status.Conditions, crr.Generation, status.Conditions...)
+	conditions = MergeConditions(conditions, crr.Generation, condition)
+	crr.Status = status
+	crr.Status.ObservedGeneration = crr.Generation
+	crr.Status.Conditions = conditions
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestMergeConditions(t *testing.T) {
+	transitionTime := metav1.NewTime(metav1.Now().Add(-time.Hour))
+	existing := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Deployed", ObservedGeneration: 1, LastTransitionTime: transitionTime},
+		{Type: "Other", Status: metav1.ConditionFalse, Reason: "Other", ObservedGeneration: 1, LastTransitionTime: transitionTime},
+	}
+
+	conditions := MergeConditions(existing, 2, metav1.Condition{
+		Type:    "Ready",
+		Status:  metav1.ConditionTrue,
+		Reason:  "Deployed",
+		Message: strings.Repeat("a", maxConditionMessageLength+1),
+	})
+
+	if len(conditions) != 2 {
+		t.Fatalf("expected 2 conditions, got %d", len(conditions))
+	}
+	ready := apimeta.FindStatusCondition(conditions, "Ready")
+	if ready.ObservedGeneration != 2 {
+		t.Errorf("expected observedGeneration 2, got %d", ready.ObservedGeneration)
+	}
+	if !ready.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("expected last transition time to be kept")
+	}
+	if len(ready.Message) != maxConditionMessageLength {
+		t.Errorf("expected message to be truncated to %d, got %d", maxConditionMessageLength, len(ready.Message))
+	}
+	if existing[0].ObservedGeneration != 1 {
+		t.Errorf("existing conditions should not be modified")
+	}
+}
+
+func TestSetDescriptionStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status appsapi.DescriptionStatus
+		want   metav1.ConditionStatus
+	}{
+		{
+			name:   "pending",
+			status: appsapi.DescriptionStatus{},
+			want:   metav1.ConditionUnknown,
+		},
+		{
+			name:   "success",
+			status: appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseSuccess},
+			want:   metav1.ConditionTrue,
+		},
+		{
+			name:   "failure",
+			status: appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseFailure, Reason: "boom"},
+			want:   metav1.ConditionFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := &appsapi.Description{}
+			desc.Generation = 3
+			SetDescriptionStatus(desc, tt.status)
+
+			if desc.Status.ObservedGeneration != 3 {
+				t.Errorf("expected observedGeneration 3, got %d", desc.Status.ObservedGeneration)
+			}
+			ready := apimeta.FindStatusCondition(desc.Status.Conditions, appsapi.DescriptionReady)
+			if ready == nil {
+				t.Fatalf("expected condition %s to be set", appsapi.DescriptionReady)
+			}
+			if ready.Status != tt.want {
+				t.Errorf("expected condition status %s, got %s", tt.want, ready.Status)
+			}
+		})
+	}
+}
+
+func TestSetDescriptionStatusFailedAttempts(t *testing.T) {
+	failure := appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseFailure, Reason: "boom"}
+	desc := &appsapi.Description{}
+	desc.Generation = 1
+
+	SetDescriptionStatus(desc, failure)
+	SetDescriptionStatus(desc, failure)
+	if desc.Status.FailedAttempts != 2 {
+		t.Errorf("expected 2 failed attempts, got %d", desc.Status.FailedAttempts)
+	}
+
+	// a new generation starts over
+	desc.Generation = 2
+	SetDescriptionStatus(desc, failure)
+	if desc.Status.FailedAttempts != 1 {
+		t.Errorf("expected 1 failed attempt, got %d", desc.Status.FailedAttempts)
+	}
+
+	SetDescriptionStatus(desc, appsapi.DescriptionStatus{Phase: appsapi.DescriptionPhaseSuccess})
+	if desc.Status.FailedAttempts != 0 {
+		t.Errorf("expected no failed attempts, got %d", desc.Status.FailedAttempts)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+// SplitRawObjects splits the raw objects in order into chunks, each of which has no more than maxCount objects
+// and maxBytes bytes in total, so that they could be carried by multiple Descriptions.
+// A zero limit means no limit. An error is returned if a single object is larger than maxBytes.
+func SplitRawObjects(rawObjects [][]byte, maxCount, maxBytes int) ([][][]byte, error) {
+	var chunks [][][]byte
+	var chunk [][]byte
+	var chunkBytes int
+	for idx, raw := range rawObjects {
+		if maxBytes > 0 && len(raw) > maxBytes {
+			return nil, fmt.Errorf("object %d is %d bytes, which exceeds the limit of %d bytes per Description, "+
+				"please reduce its size, such as moving large data out of the manifest", idx, len(raw), maxBytes)
+		}
+
+		if len(chunk) > 0 && ((maxCount > 0 && len(chunk) >= maxCount) || (maxBytes > 0 && chunkBytes+len(raw) > maxBytes)) {
+			chunks = append(chunks, chunk)
+			chunk, chunkBytes = nil, 0
+		}
+		chunk = append(chunk, raw)
+		chunkBytes += len(raw)
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// GetRawObjectsSize returns the total bytes of the raw objects.
+func GetRawObjectsSize(rawObjects [][]byte) int {
+	size := 0
+	for _, raw := range rawObjects {
+		size += len(raw)
+	}
+	return size
+}
+
+// HashDescriptionSpec returns a hash of the spec of a Description.
+func HashDescriptionSpec(spec appsapi.DescriptionSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New64a()
+	hasher.Write(data)
+	return strconv.FormatUint(hasher.Sum64(), 16), nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitRawObjects(t *testing.T) {
+	a, b, c := []byte("aaaa"), []byte("bbbbbb"), []byte("cc")
+
+	tests := []struct {
+		name     string
+		maxCount int
+		maxBytes int
+		want     [][][]byte
+		wantErr  bool
+	}{
+		{
+			name: "no limits",
+			want: [][][]byte{{a, b, c}},
+		},
+		{
+			name:     "split by count",
+			maxCount: 2,
+			want:     [][][]byte{{a, b}, {c}},
+		},
+		{
+			name:     "split by bytes",
+			maxBytes: 8,
+			want:     [][][]byte{{a}, {b, c}},
+		},
+		{
+			name:     "single object exceeds the limit",
+			maxBytes: 5,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitRawObjects([][]byte{a, b, c}, tt.maxCount, tt.maxBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitRawObjects() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitRawObjects() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// maxDriftedFields is the max number of drifted fields returned for an object
+const maxDriftedFields = 10
+
+// DriftedField is a field whose live value differs from the desired one
+type DriftedField struct {
+	// Path is the path of the field, such as spec.template.spec.containers[0].image
+	Path string
+	// Desired is the desired value of the field
+	Desired interface{}
+	// Live is the live value of the field, which is nil if not set
+	Live interface{}
+}
+
+// FindDriftedFields compares the desired object with the live one, and returns the paths of the fields whose live
+// values differ from the desired ones. Only the fields set in the desired object are compared, so that fields
+// defaulted by the apiserver or set by other controllers are not treated as drifts. Status, metadata other
+// than labels and annotations, and the fields declared by IgnoreFieldsAnnotation are ignored.
+func FindDriftedFields(desired, live map[string]interface{}) []string {
+	var fields []string
+	for _, field := range DiffObjects(desired, live, maxDriftedFields) {
+		fields = append(fields, field.Path)
+	}
+	return fields
+}
+
+// DiffObjects compares the desired object with the live one in the same way as FindDriftedFields, and returns
+// at most limit drifted fields with their values. A non-positive limit means no limit.
+func DiffObjects(desired, live map[string]interface{}, limit int) []DriftedField {
+	var fields []DriftedField
+	ignored := GetIgnoredFields(desired)
+	for _, key := range sortedKeys(desired) {
+		value := desired[key]
+		switch key {
+		case "apiVersion", "kind", "status":
+			continue
+		case "metadata":
+			desiredMeta, _ := value.(map[string]interface{})
+			liveMeta, _ := live[key].(map[string]interface{})
+			for _, metaKey := range []string{"labels", "annotations"} {
+				if desiredValue, ok := desiredMeta[metaKey]; ok {
+					fields = appendDriftedFields(fields, limit, ignored, "metadata."+metaKey, desiredValue,
+						liveMeta[metaKey])
+				}
+			}
+		default:
+			fields = appendDriftedFields(fields, limit, ignored, key, value, live[key])
+		}
+	}
+	return fields
+}
+
+func appendDriftedFields(fields []DriftedField, limit int, ignored sets.String, path string,
+	desired, live interface{}) []DriftedField {
+	if limit > 0 && len(fields) >= limit {
+		return fields
+	}
+	if ignored.Has(path) {
+		return fields
+	}
+	drifted := DriftedField{Path: path, Desired: desired, Live: live}
+	if live == nil {
+		// zero values are omitted by the apiserver
+		if desired == nil || isZeroValue(desired) {
+			return fields
+		}
+		return append(fields, drifted)
+	}
+
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		liveValue, ok := live.(map[string]interface{})
+		if !ok {
+			return append(fields, drifted)
+		}
+		for _, key := range sortedKeys(desiredValue) {
+			fields = appendDriftedFields(fields, limit, ignored, path+"."+key, desiredValue[key], liveValue[key])
+		}
+		return fields
+	case []interface{}:
+		liveValue, ok := live.([]interface{})
+		if !ok || len(liveValue) != len(desiredValue) {
+			return append(fields, drifted)
+		}
+		for i := range desiredValue {
+			fields = appendDriftedFields(fields, limit, ignored, fmt.Sprintf("%s[%d]", path, i), desiredValue[i],
+				liveValue[i])
+		}
+		return fields
+	default:
+		if !scalarEqual(desired, live) {
+			return append(fields, drifted)
+		}
+		return fields
+	}
+}
+
+// GetIgnoredFields returns the paths of the fields declared by IgnoreFieldsAnnotation on the object.
+func GetIgnoredFields(obj map[string]interface{}) sets.String {
+	ignored := sets.NewString()
+	value, _, _ := unstructured.NestedString(obj, "metadata", "annotations", known.IgnoreFieldsAnnotation)
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimPrefix(strings.TrimSpace(path), ".")
+		if len(path) > 0 {
+			ignored.Insert(path)
+		}
+	}
+	return ignored
+}
+
+// scalarEqual compares scalar values, where numbers are compared by their values,
+// and quantities are compared semantically, such as "0.5" and "500m".
+func scalarEqual(desired, live interface{}) bool {
+	if reflect.DeepEqual(desired, live) {
+		return true
+	}
+	if desiredNumber, ok := toFloat64(desired); ok {
+		liveNumber, ok := toFloat64(live)
+		return ok && desiredNumber == liveNumber
+	}
+	desiredString, ok := desired.(string)
+	if !ok {
+		return false
+	}
+	liveString, ok := live.(string)
+	if !ok {
+		return false
+	}
+	desiredQuantity, err := resource.ParseQuantity(desiredString)
+	if err != nil {
+		return false
+	}
+	liveQuantity, err := resource.ParseQuantity(liveString)
+	if err != nil {
+		return false
+	}
+	return desiredQuantity.Cmp(liveQuantity) == 0
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func isZeroValue(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return reflect.ValueOf(value).IsZero()
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestFindDriftedFields(t *testing.T) {
+	desired := `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "namespace": "foo", "labels": {"app": "nginx"}},
+  "spec": {
+    "replicas": 3,
+    "paused": false,
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx:1.21", "resources": {"limits": {"cpu": "0.5"}}}
+        ]
+      }
+    }
+  }
+}`
+
+	tests := []struct {
+		name string
+		live string
+		want []string
+	}{
+		{
+			name: "no drifts with defaulted fields",
+			live: `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "namespace": "foo", "uid": "1234", "resourceVersion": "5",
+    "labels": {"app": "nginx"}, "annotations": {"deployment.kubernetes.io/revision": "1"}},
+  "spec": {
+    "replicas": 3,
+    "strategy": {"type": "RollingUpdate"},
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx:1.21", "imagePullPolicy": "IfNotPresent", "resources": {"limits": {"cpu": "500m"}}}
+        ]
+      }
+    }
+  },
+  "status": {"replicas": 1}
+}`,
+		},
+		{
+			name: "drifted fields",
+			live: `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "namespace": "foo", "labels": {"app": "httpd"}},
+  "spec": {
+    "replicas": 5,
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx:1.22", "resources": {"limits": {"cpu": "1"}}}
+        ]
+      }
+    }
+  }
+}`,
+			want: []string{
+				"metadata.labels.app",
+				"spec.replicas",
+				"spec.template.spec.containers[0].image",
+				"spec.template.spec.containers[0].resources.limits.cpu",
+			},
+		},
+		{
+			name: "containers added",
+			live: `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "nginx", "namespace": "foo", "labels": {"app": "nginx"}},
+  "spec": {
+    "replicas": 3,
+    "template": {
+      "spec": {
+        "containers": [
+          {"name": "nginx", "image": "nginx:1.21", "resources": {"limits": {"cpu": "0.5"}}},
+          {"name": "sidecar", "image": "busybox"}
+        ]
+      }
+    }
+  }
+}`,
+			want: []string{"spec.template.spec.containers"},
+		},
+	}
+
+	desiredObj := &unstructured.Unstructured{}
+	if err := desiredObj.UnmarshalJSON([]byte(desired)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			liveObj := &unstructured.Unstructured{}
+			if err := liveObj.UnmarshalJSON([]byte(tt.live)); err != nil {
+				t.Fatal(err)
+			}
+			if got := FindDriftedFields(desiredObj.Object, liveObj.Object); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDriftedFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	desired := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "nginx", "labels": map[string]interface{}{"app": "nginx"}},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   true,
+		},
+	}
+	live := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "nginx", "uid": "1234", "labels": map[string]interface{}{"app": "nginx"}},
+		"spec": map[string]interface{}{
+			"replicas": int64(5),
+		},
+	}
+
+	want := []DriftedField{
+		{Path: "spec.paused", Desired: true, Live: nil},
+		{Path: "spec.replicas", Desired: int64(3), Live: int64(5)},
+	}
+	if got := DiffObjects(desired, live, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffObjects() = %v, want %v", got, want)
+	}
+	if got := DiffObjects(desired, live, 1); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("DiffObjects() with limit = %v, want %v", got, want[:1])
+	}
+
+	desired["metadata"].(map[string]interface{})["annotations"] = map[string]interface{}{
+		known.IgnoreFieldsAnnotation: "spec.replicas",
+	}
+	live["metadata"].(map[string]interface{})["annotations"] = map[string]interface{}{
+		known.IgnoreFieldsAnnotation: "spec.replicas",
+	}
+	if got := DiffObjects(desired, live, 0); !reflect.DeepEqual(got, want[:1]) {
+		t.Errorf("DiffObjects() with ignored fields = %v, want %v", got, want[:1])
+	}
+}
@@ -76,6 +76,29 @@ func CreateKubeConfigWithToken(serverURL, token string, caCert []byte) *clientcm
 	return config
 }
 
+// CreateKubeConfigWithTokenFile creates a KubeConfig object with access to the API server with a token file
+func CreateKubeConfigWithTokenFile(serverURL, tokenFile string, caCert []byte) *clientcmdapi.Config {
+	userName := "clusternet"
+	clusterName := "clusternet-cluster"
+	config := createBasicKubeConfig(serverURL, clusterName, userName, caCert)
+	config.AuthInfos[userName] = &clientcmdapi.AuthInfo{
+		TokenFile: tokenFile,
+	}
+	return config
+}
+
+// CreateKubeConfigWithClientCertificate creates a KubeConfig object with access to the API server with a client certificate
+func CreateKubeConfigWithClientCertificate(serverURL, certFile, keyFile string, caCert []byte) *clientcmdapi.Config {
+	userName := "clusternet"
+	clusterName := "clusternet-cluster"
+	config := createBasicKubeConfig(serverURL, clusterName, userName, caCert)
+	config.AuthInfos[userName] = &clientcmdapi.AuthInfo{
+		ClientCertificate: certFile,
+		ClientKey:         keyFile,
+	}
+	return config
+}
+
 // CreateKubeConfigForSocketProxyWithToken creates a KubeConfig object with access to the API server with a token
 func CreateKubeConfigForSocketProxyWithToken(serverURL, token string) *clientcmdapi.Config {
 	userName := "clusternet"
@@ -122,6 +145,30 @@ func GenerateKubeConfigFromToken(serverURL, token string, caCert []byte, flowRat
 	return applyDefaultRateLimiter(config, flowRate), nil
 }
 
+// GenerateKubeConfigFromTokenFile composes a kubeconfig from a token file,
+// which gets reloaded by client-go once it is renewed
+func GenerateKubeConfigFromTokenFile(serverURL, tokenFile string, caCert []byte, flowRate int) (*rest.Config, error) {
+	clientConfig := CreateKubeConfigWithTokenFile(serverURL, tokenFile, caCert)
+	config, err := clientcmd.NewDefaultClientConfig(*clientConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while creating kubeconfig: %v", err)
+	}
+
+	return applyDefaultRateLimiter(config, flowRate), nil
+}
+
+// GenerateKubeConfigFromClientCertificate composes a kubeconfig from client certificate files,
+// which get reloaded by client-go once they are rotated
+func GenerateKubeConfigFromClientCertificate(serverURL, certFile, keyFile string, caCert []byte, flowRate int) (*rest.Config, error) {
+	clientConfig := CreateKubeConfigWithClientCertificate(serverURL, certFile, keyFile, caCert)
+	config, err := clientcmd.NewDefaultClientConfig(*clientConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error while creating kubeconfig: %v", err)
+	}
+
+	return applyDefaultRateLimiter(config, flowRate), nil
+}
+
 func applyDefaultRateLimiter(config *rest.Config, flowRate int) *rest.Config {
 	if flowRate < 0 {
 		flowRate = 1
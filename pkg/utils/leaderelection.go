@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	componentbaseconfig "k8s.io/component-base/config"
+	"k8s.io/klog/v2"
+)
+
+// RunWithLeaderElection runs the given function once gaining the leadership described by cfg,
+// and blocks until ctx is done.
+//
+// The lock is not released on ctx done until run returns, so that the items left in the work queues
+// could be drained before handing over the leadership. If run does not return within the lease duration,
+// the lock gets released anyway. Losing the leadership while ctx is not done is fatal, since run may still be
+// writing concurrently with the new leader.
+func RunWithLeaderElection(ctx context.Context, client kubernetes.Interface,
+	cfg componentbaseconfig.LeaderElectionConfiguration, identity string, run func()) error {
+	lock, err := resourcelock.New(cfg.ResourceLock, cfg.ResourceNamespace, cfg.ResourceName,
+		client.CoreV1(), client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: identity})
+	if err != nil {
+		return fmt.Errorf("failed to create resource lock %s/%s: %v", cfg.ResourceNamespace, cfg.ResourceName, err)
+	}
+
+	// the election lives longer than ctx, until run returns
+	electionCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		select {
+		case <-started:
+			select {
+			case <-stopped:
+			case <-time.After(cfg.LeaseDuration.Duration):
+				klog.Warningf("still not stopped after %v, releasing the leadership of %s/%s anyway",
+					cfg.LeaseDuration.Duration, cfg.ResourceNamespace, cfg.ResourceName)
+			}
+		default:
+		}
+		cancel()
+	}()
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: lock,
+		// IMPORTANT: any code protected by the lease must terminate before the lock is released,
+		// which is why electionCtx is only cancelled after run returns.
+		ReleaseOnCancel: true,
+		LeaseDuration:   cfg.LeaseDuration.Duration,
+		RenewDeadline:   cfg.RenewDeadline.Duration,
+		RetryPeriod:     cfg.RetryPeriod.Duration,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				klog.Infof("%s became the leader of %s/%s", identity, cfg.ResourceNamespace, cfg.ResourceName)
+				close(started)
+				defer close(stopped)
+				run()
+				klog.Infof("%s stopped leading, releasing %s/%s", identity, cfg.ResourceNamespace, cfg.ResourceName)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() == nil {
+					klog.Fatalf("leader election of %s/%s got lost", cfg.ResourceNamespace, cfg.ResourceName)
+				}
+			},
+			OnNewLeader: func(leader string) {
+				if leader == identity {
+					return
+				}
+				klog.Infof("new leader of %s/%s elected: %s", cfg.ResourceNamespace, cfg.ResourceName, leader)
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	elector.Run(electionCtx)
+	return nil
+}
+
+// ValidateLeaderElectionConfiguration validates the durations and the lock of cfg.
+func ValidateLeaderElectionConfiguration(cfg componentbaseconfig.LeaderElectionConfiguration) []error {
+	var allErrs []error
+	if cfg.LeaseDuration.Duration <= cfg.RenewDeadline.Duration {
+		allErrs = append(allErrs, fmt.Errorf("--leader-elect-lease-duration must be greater than --leader-elect-renew-deadline"))
+	}
+	if cfg.RenewDeadline.Duration <= time.Duration(leaderelection.JitterFactor*float64(cfg.RetryPeriod.Duration)) {
+		allErrs = append(allErrs, fmt.Errorf("--leader-elect-renew-deadline must be greater than %v times --leader-elect-retry-period",
+			leaderelection.JitterFactor))
+	}
+	if cfg.RetryPeriod.Duration <= 0 {
+		allErrs = append(allErrs, fmt.Errorf("--leader-elect-retry-period must be positive"))
+	}
+	switch cfg.ResourceLock {
+	case resourcelock.LeasesResourceLock, resourcelock.EndpointsLeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock,
+		resourcelock.EndpointsResourceLock, resourcelock.ConfigMapsResourceLock:
+	default:
+		allErrs = append(allErrs, fmt.Errorf("invalid value for --leader-elect-resource-lock: %q", cfg.ResourceLock))
+	}
+	if len(cfg.ResourceName) == 0 || len(cfg.ResourceNamespace) == 0 {
+		allErrs = append(allErrs, fmt.Errorf("--leader-elect-resource-name and --leader-elect-resource-namespace are required"))
+	}
+	return allErrs
+}
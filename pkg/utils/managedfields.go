@@ -0,0 +1,356 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// IsClusternetFieldManager tells whether the field manager belongs to Clusternet, including the ones of the
+// objects updated by earlier versions without server-side apply.
+func IsClusternetFieldManager(manager string) bool {
+	return strings.HasPrefix(manager, "clusternet")
+}
+
+// pathElement is an element of a field path, which is either a field name, or a selector of list items,
+// such as an index "0", keys `name="nginx"` or a value `="foo"`.
+type pathElement struct {
+	name     string
+	selector *string
+}
+
+// parseFieldPath splits the field paths returned by the drift detection, such as
+// "spec.template.spec.containers[0].image", or the ones reported in server-side apply conflicts,
+// such as `.spec.template.spec.containers[name="nginx"].image`.
+func parseFieldPath(path string) []pathElement {
+	var elements []pathElement
+	var name strings.Builder
+	flush := func() {
+		if name.Len() > 0 {
+			elements = append(elements, pathElement{name: name.String()})
+			name.Reset()
+		}
+	}
+	inQuote := false
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '.':
+			flush()
+		case c == '[':
+			flush()
+			end := i + 1
+			for ; end < len(path); end++ {
+				if path[end] == '"' && path[end-1] != '\\' {
+					inQuote = !inQuote
+				}
+				if path[end] == ']' && !inQuote {
+					break
+				}
+			}
+			selector := path[i+1 : end]
+			elements = append(elements, pathElement{selector: &selector})
+			i = end
+		default:
+			name.WriteByte(c)
+		}
+	}
+	flush()
+	return elements
+}
+
+// nextName returns the key in the map matching the name elements starting at index i, where keys containing dots,
+// such as annotations, are matched by joining the following name elements. It returns the number of elements used.
+func nextName(m map[string]interface{}, elements []pathElement, i int) (string, int) {
+	key := ""
+	for j := i; j < len(elements) && elements[j].selector == nil; j++ {
+		if j > i {
+			key += "."
+		}
+		key += elements[j].name
+		if _, ok := m[key]; ok {
+			return key, j - i + 1
+		}
+	}
+	return "", 0
+}
+
+// matchSelector returns the index of the list item matched by the selector, or -1 if not found.
+func matchSelector(list []interface{}, selector string) int {
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index >= 0 && index < len(list) {
+			return index
+		}
+		return -1
+	}
+	if strings.HasPrefix(selector, "=") {
+		var value interface{}
+		if err := json.Unmarshal([]byte(selector[1:]), &value); err != nil {
+			return -1
+		}
+		for idx, item := range list {
+			if scalarEqual(value, item) {
+				return idx
+			}
+		}
+		return -1
+	}
+
+	keys, err := parseSelectorKeys(selector)
+	if err != nil {
+		return -1
+	}
+	for idx, item := range list {
+		if matchKeys(item, keys) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// parseSelectorKeys parses the keys of list items, such as `name="nginx",protocol="TCP"`.
+func parseSelectorKeys(selector string) (map[string]interface{}, error) {
+	var pairs []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(selector); i++ {
+		switch selector[i] {
+		case '"':
+			if i == 0 || selector[i-1] != '\\' {
+				inQuote = !inQuote
+			}
+		case ',':
+			if !inQuote {
+				pairs = append(pairs, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	pairs = append(pairs, selector[start:])
+
+	keys := map[string]interface{}{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid selector %q", selector)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			return nil, err
+		}
+		keys[parts[0]] = value
+	}
+	return keys, nil
+}
+
+func matchKeys(item interface{}, keys map[string]interface{}) bool {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, value := range keys {
+		if !scalarEqual(value, m[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// findField walks the object along the path, and returns the map or list holding the field, along with the key
+// or index of the field in it.
+func findField(obj map[string]interface{}, path string) (interface{}, string, int, bool) {
+	elements := parseFieldPath(path)
+	var current interface{} = obj
+	for i := 0; i < len(elements); {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			if elements[i].selector != nil {
+				return nil, "", 0, false
+			}
+			key, n := nextName(value, elements, i)
+			if n == 0 {
+				return nil, "", 0, false
+			}
+			i += n
+			if i == len(elements) {
+				return value, key, 0, true
+			}
+			current = value[key]
+		case []interface{}:
+			if elements[i].selector == nil {
+				return nil, "", 0, false
+			}
+			idx := matchSelector(value, *elements[i].selector)
+			if idx < 0 {
+				return nil, "", 0, false
+			}
+			i++
+			if i == len(elements) {
+				return value, "", idx, true
+			}
+			current = value[idx]
+		default:
+			return nil, "", 0, false
+		}
+	}
+	return nil, "", 0, false
+}
+
+// GetField returns the value of the field at the path of the object.
+func GetField(obj map[string]interface{}, path string) (interface{}, bool) {
+	parent, key, idx, found := findField(obj, path)
+	if !found {
+		return nil, false
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		return m[key], true
+	}
+	return parent.([]interface{})[idx], true
+}
+
+// RemoveField removes the field at the path from the object, and returns whether it is removed.
+func RemoveField(obj map[string]interface{}, path string) bool {
+	parent, key, _, found := findField(obj, path)
+	if !found {
+		return false
+	}
+	m, ok := parent.(map[string]interface{})
+	if !ok {
+		// removing list items changes the length of the list, which is left as it is
+		return false
+	}
+	delete(m, key)
+	return true
+}
+
+// RetainField replaces the value of the field at the path of the desired object with the live one, or removes it
+// from the desired object if the live object does not have it, so that applying the desired object leaves the
+// field as it is.
+func RetainField(desired, live map[string]interface{}, path string) {
+	parent, key, idx, found := findField(desired, path)
+	if !found {
+		return
+	}
+	value, ok := GetField(live, path)
+	if !ok {
+		RemoveField(desired, path)
+		return
+	}
+	if m, ok := parent.(map[string]interface{}); ok {
+		m[key] = value
+		return
+	}
+	parent.([]interface{})[idx] = value
+}
+
+// FindFieldManagers returns the managers owning the field at the path of the object, which are recorded in
+// its managedFields.
+func FindFieldManagers(obj *unstructured.Unstructured, path string) []string {
+	elements := parseFieldPath(path)
+	managers := sets.NewString()
+	for _, entry := range obj.GetManagedFields() {
+		if entry.FieldsV1 == nil {
+			continue
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if ownsField(fields, obj.Object, elements) {
+			managers.Insert(entry.Manager)
+		}
+	}
+	if managers.Len() == 0 {
+		return nil
+	}
+	return managers.List()
+}
+
+// ownsField walks the fields of the managedFields entry along with the object.
+func ownsField(fields map[string]interface{}, obj interface{}, elements []pathElement) bool {
+	current := obj
+	for i := 0; i < len(elements); {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			if elements[i].selector != nil {
+				return false
+			}
+			key, n := nextName(value, elements, i)
+			if n == 0 {
+				return false
+			}
+			next, ok := fields["f:"+key].(map[string]interface{})
+			if !ok {
+				return false
+			}
+			fields = next
+			current = value[key]
+			i += n
+		case []interface{}:
+			if elements[i].selector == nil {
+				return false
+			}
+			idx := matchSelector(value, *elements[i].selector)
+			if idx < 0 {
+				return false
+			}
+			next := findListItemFields(fields, value[idx], idx)
+			if next == nil {
+				return false
+			}
+			fields = next
+			current = value[idx]
+			i++
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// findListItemFields returns the fields of the list item in the managedFields entry, which is keyed by
+// its keys like `k:{"name":"nginx"}`, its value like `v:"foo"`, or its index like `i:0`.
+func findListItemFields(fields map[string]interface{}, item interface{}, index int) map[string]interface{} {
+	for key, value := range fields {
+		next, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(key, "k:"):
+			keys := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(key[2:]), &keys); err == nil && matchKeys(item, keys) {
+				return next
+			}
+		case strings.HasPrefix(key, "v:"):
+			var v interface{}
+			if err := json.Unmarshal([]byte(key[2:]), &v); err == nil && reflect.DeepEqual(v, item) {
+				return next
+			}
+		case key == fmt.Sprintf("i:%d", index):
+			return next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newDeploymentObject() map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":      "nginx",
+			"namespace": "foo",
+			"annotations": map[string]interface{}{
+				"sidecar.istio.io/status": "injected",
+			},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(5),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "nginx", "image": "nginx:1.21"},
+						map[string]interface{}{"name": "istio-proxy", "image": "istio/proxyv2"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRemoveField(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		removed bool
+		verify  func(obj map[string]interface{}) bool
+	}{
+		{
+			name:    "scalar field",
+			path:    ".spec.replicas",
+			removed: true,
+			verify: func(obj map[string]interface{}) bool {
+				_, found, _ := unstructured.NestedFieldNoCopy(obj, "spec", "replicas")
+				return !found
+			},
+		},
+		{
+			name:    "annotation with dots",
+			path:    ".metadata.annotations.sidecar.istio.io/status",
+			removed: true,
+			verify: func(obj map[string]interface{}) bool {
+				annotations, _, _ := unstructured.NestedStringMap(obj, "metadata", "annotations")
+				return len(annotations) == 0
+			},
+		},
+		{
+			name:    "field of keyed list item",
+			path:    `.spec.template.spec.containers[name="istio-proxy"].image`,
+			removed: true,
+			verify: func(obj map[string]interface{}) bool {
+				containers, _, _ := unstructured.NestedSlice(obj, "spec", "template", "spec", "containers")
+				_, found := containers[1].(map[string]interface{})["image"]
+				return !found && containers[0].(map[string]interface{})["image"] == "nginx:1.21"
+			},
+		},
+		{
+			name:    "list item is kept",
+			path:    `.spec.template.spec.containers[name="istio-proxy"]`,
+			removed: false,
+		},
+		{
+			name:    "missing field",
+			path:    ".spec.paused",
+			removed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newDeploymentObject()
+			if got := RemoveField(obj, tt.path); got != tt.removed {
+				t.Fatalf("RemoveField() = %v, want %v", got, tt.removed)
+			}
+			if tt.verify != nil && !tt.verify(obj) {
+				t.Errorf("RemoveField() got unexpected object %v", obj)
+			}
+		})
+	}
+}
+
+func TestRetainField(t *testing.T) {
+	desired := newDeploymentObject()
+	desired["spec"].(map[string]interface{})["replicas"] = int64(2)
+	live := newDeploymentObject()
+	delete(live["metadata"].(map[string]interface{}), "annotations")
+
+	RetainField(desired, live, "spec.replicas")
+	if replicas, _, _ := unstructured.NestedInt64(desired, "spec", "replicas"); replicas != 5 {
+		t.Errorf("RetainField() got replicas %d, want 5", replicas)
+	}
+	RetainField(desired, live, "metadata.annotations.sidecar.istio.io/status")
+	if annotations, _, _ := unstructured.NestedStringMap(desired, "metadata", "annotations"); len(annotations) != 0 {
+		t.Errorf("RetainField() got annotations %v, want none", annotations)
+	}
+	RetainField(desired, live, "spec.paused")
+	if _, found, _ := unstructured.NestedFieldNoCopy(desired, "spec", "paused"); found {
+		t.Errorf("RetainField() should not set missing field spec.paused")
+	}
+}
+
+func TestFindFieldManagers(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: newDeploymentObject()}
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:    "clusternet-hub",
+			Operation:  metav1.ManagedFieldsOperationApply,
+			FieldsType: "FieldsV1",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{` +
+				`"k:{\"name\":\"nginx\"}":{".":{},"f:image":{},"f:name":{}}}}}}}}`)},
+		},
+		{
+			Manager:    "kube-controller-manager",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			FieldsType: "FieldsV1",
+			FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+		},
+		{
+			Manager:    "istio-sidecar-injector",
+			Operation:  metav1.ManagedFieldsOperationUpdate,
+			FieldsType: "FieldsV1",
+			FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:annotations":{"f:sidecar.istio.io/status":{}}},` +
+				`"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"istio-proxy\"}":{".":{},"f:image":{}}}}}}}`)},
+		},
+	})
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "spec.replicas", want: []string{"kube-controller-manager"}},
+		{path: "metadata.annotations.sidecar.istio.io/status", want: []string{"istio-sidecar-injector"}},
+		{path: "spec.template.spec.containers[0].image", want: []string{"clusternet-hub"}},
+		{path: "spec.template.spec.containers[1].image", want: []string{"istio-sidecar-injector"}},
+		{path: "spec.paused", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := FindFieldManagers(obj, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFieldManagers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusternetclientset "github.com/clusternet/clusternet/pkg/generated/clientset/versioned"
+	applisters "github.com/clusternet/clusternet/pkg/generated/listers/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+var (
+	baseKind         = appsapi.SchemeGroupVersion.WithKind("Base")
+	subscriptionKind = appsapi.SchemeGroupVersion.WithKind("Subscription")
+)
+
+// FeedSource is an object rendered into multiple Manifests, such as a Kustomization,
+// which can be referred as a whole by a feed.
+type FeedSource struct {
+	schema.GroupVersionKind
+
+	// Resource is the plural name of the kind, which prefixes the names of the Manifests
+	Resource string
+
+	Namespace string
+	Name      string
+}
+
+// SyncRenderedManifests creates or updates a Manifest for each object rendered from the source,
+// and deletes the Manifests no longer rendered.
+func SyncRenderedManifests(clusternetClient *clusternetclientset.Clientset, mfstLister applisters.ManifestLister,
+	source FeedSource, objs []*unstructured.Unstructured) error {
+	existing, err := ListRenderedManifests(mfstLister, source)
+	if err != nil {
+		return err
+	}
+
+	// Manifests newly rendered should be referred by the same Bases and Subscriptions as the existing ones,
+	// so that the whole source keeps being populated into Descriptions.
+	referrers := map[string]string{}
+	for _, manifest := range existing {
+		for key, val := range manifest.Labels {
+			if val == baseKind.Kind || val == subscriptionKind.Kind {
+				referrers[key] = val
+			}
+		}
+	}
+
+	var allErrs []error
+	names := sets.NewString()
+	for _, obj := range objs {
+		name := getRenderedManifestName(source, obj)
+		names.Insert(name)
+
+		data, err := obj.MarshalJSON()
+		if err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+
+		manifest, err := mfstLister.Manifests(appsapi.ReservedNamespace).Get(name)
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		if err == nil {
+			if manifest.DeletionTimestamp != nil {
+				allErrs = append(allErrs, fmt.Errorf("Manifest %s is being deleted", klog.KObj(manifest)))
+				continue
+			}
+			if reflect.DeepEqual(manifest.Template.Raw, data) {
+				continue
+			}
+			manifest = manifest.DeepCopy()
+			manifest.Template = runtime.RawExtension{Raw: data}
+			if _, err = clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Update(context.TODO(),
+				manifest, metav1.UpdateOptions{}); err != nil {
+				allErrs = append(allErrs, err)
+			}
+			continue
+		}
+
+		manifest = &appsapi.Manifest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: appsapi.ReservedNamespace,
+				Labels:    getFeedSourceLabels(source),
+			},
+			Template: runtime.RawExtension{Raw: data},
+		}
+		for key, val := range referrers {
+			manifest.Labels[key] = val
+		}
+		if _, err = clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Create(context.TODO(),
+			manifest, metav1.CreateOptions{}); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	if len(allErrs) > 0 {
+		return utilerrors.NewAggregate(allErrs)
+	}
+
+	return PruneRenderedManifests(clusternetClient, mfstLister, source, names)
+}
+
+// PruneRenderedManifests deletes all the Manifests rendered from the source except those in names
+func PruneRenderedManifests(clusternetClient *clusternetclientset.Clientset, mfstLister applisters.ManifestLister,
+	source FeedSource, names sets.String) error {
+	existing, err := ListRenderedManifests(mfstLister, source)
+	if err != nil {
+		return err
+	}
+
+	var allErrs []error
+	for _, manifest := range existing {
+		if names.Has(manifest.Name) || manifest.DeletionTimestamp != nil {
+			continue
+		}
+		err = clusternetClient.AppsV1alpha1().Manifests(manifest.Namespace).Delete(context.TODO(),
+			manifest.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		klog.V(4).Infof("pruned Manifest %s of %s %s/%s", klog.KObj(manifest), source.Kind, source.Namespace, source.Name)
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// ListRenderedManifests lists all the Manifests rendered from the source
+func ListRenderedManifests(mfstLister applisters.ManifestLister, source FeedSource) ([]*appsapi.Manifest, error) {
+	return mfstLister.Manifests(appsapi.ReservedNamespace).List(labels.SelectorFromSet(getFeedSourceLabels(source)))
+}
+
+func getFeedSourceLabels(source FeedSource) map[string]string {
+	return map[string]string{
+		known.ConfigGroupLabel:     source.Group,
+		known.ConfigVersionLabel:   source.Version,
+		known.ConfigKindLabel:      source.Kind,
+		known.ConfigNameLabel:      source.Name,
+		known.ConfigNamespaceLabel: source.Namespace,
+	}
+}
+
+// getRenderedManifestName returns a stable name for the Manifest holding a rendered object,
+// which only changes when the identity of the object changes.
+func getRenderedManifestName(source FeedSource, obj *unstructured.Unstructured) string {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(strings.Join([]string{
+		obj.GroupVersionKind().GroupKind().String(),
+		obj.GetNamespace(),
+		obj.GetName(),
+	}, "/")))
+	return fmt.Sprintf("%s-%s-%s-%s", source.Resource, source.Namespace, source.Name,
+		strconv.FormatUint(uint64(hasher.Sum32()), 16))
+}
+
+// HashObjects returns a hash of all the rendered objects
+func HashObjects(objs []*unstructured.Unstructured) (string, error) {
+	data, err := json.Marshal(objs)
+	if err != nil {
+		return "", err
+	}
+	hasher := fnv.New64a()
+	hasher.Write(data)
+	return strconv.FormatUint(hasher.Sum64(), 16), nil
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+var (
+	// podTemplatePaths are where pod templates locate in workloads
+	podTemplatePaths = map[string][]string{
+		"Deployment":            {"spec", "template"},
+		"ReplicaSet":            {"spec", "template"},
+		"StatefulSet":           {"spec", "template"},
+		"DaemonSet":             {"spec", "template"},
+		"Job":                   {"spec", "template"},
+		"ReplicationController": {"spec", "template"},
+		"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+	}
+
+	baselineCapabilities = sets.NewString("AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+		"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT")
+	baselineSELinuxTypes = sets.NewString("", "container_t", "container_init_t", "container_kvm_t")
+	safeSysctls          = sets.NewString("kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range",
+		"net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range")
+)
+
+// container is the common part of containers, init containers and ephemeral containers
+type container struct {
+	name            string
+	securityContext *corev1.SecurityContext
+	ports           []corev1.ContainerPort
+}
+
+// CheckPodSecurity checks the pod template of a workload against the Pod Security Standards at the given level,
+// and returns the violations. Objects without pod templates, such as Services, never violate any level.
+func CheckPodSecurity(level clusterapi.PodSecurityLevel, obj *unstructured.Unstructured) ([]string, error) {
+	if level != clusterapi.PodSecurityBaseline && level != clusterapi.PodSecurityRestricted {
+		return nil, nil
+	}
+
+	template, err := getPodTemplate(obj)
+	if err != nil || template == nil {
+		return nil, err
+	}
+
+	violations := checkBaseline(template)
+	if level == clusterapi.PodSecurityRestricted {
+		violations = append(violations, checkRestricted(&template.Spec)...)
+	}
+	return violations, nil
+}
+
+func getPodTemplate(obj *unstructured.Unstructured) (*corev1.PodTemplateSpec, error) {
+	template := &corev1.PodTemplateSpec{}
+	if obj.GetKind() == "Pod" {
+		spec, found, err := unstructured.NestedMap(obj.Object, "spec")
+		if err != nil || !found {
+			return nil, err
+		}
+		template.Annotations = obj.GetAnnotations()
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &template.Spec); err != nil {
+			return nil, fmt.Errorf("failed to convert the spec of Pod %s: %v", obj.GetName(), err)
+		}
+		return template, nil
+	}
+
+	path, ok := podTemplatePaths[obj.GetKind()]
+	if !ok {
+		return nil, nil
+	}
+	raw, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return nil, err
+	}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(raw, template); err != nil {
+		return nil, fmt.Errorf("failed to convert the pod template of %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	return template, nil
+}
+
+func getContainers(spec *corev1.PodSpec) []container {
+	var containers []container
+	for _, c := range spec.InitContainers {
+		containers = append(containers, container{name: c.Name, securityContext: c.SecurityContext, ports: c.Ports})
+	}
+	for _, c := range spec.Containers {
+		containers = append(containers, container{name: c.Name, securityContext: c.SecurityContext, ports: c.Ports})
+	}
+	for _, c := range spec.EphemeralContainers {
+		containers = append(containers, container{name: c.Name, securityContext: c.SecurityContext, ports: c.Ports})
+	}
+	return containers
+}
+
+// checkBaseline checks the controls of the baseline level, which prevents known privilege escalations.
+func checkBaseline(template *corev1.PodTemplateSpec) []string {
+	var violations []string
+	spec := &template.Spec
+
+	if spec.HostNetwork {
+		violations = append(violations, "hostNetwork must not be true")
+	}
+	if spec.HostPID {
+		violations = append(violations, "hostPID must not be true")
+	}
+	if spec.HostIPC {
+		violations = append(violations, "hostIPC must not be true")
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %q must not use hostPath", volume.Name))
+		}
+	}
+
+	annotationKeys := make([]string, 0, len(template.Annotations))
+	for key := range template.Annotations {
+		annotationKeys = append(annotationKeys, key)
+	}
+	sort.Strings(annotationKeys)
+	for _, key := range annotationKeys {
+		value := template.Annotations[key]
+		if strings.HasPrefix(key, appArmorAnnotationPrefix) && value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			violations = append(violations, fmt.Sprintf("annotation %s=%q must be \"runtime/default\" or \"localhost/*\"", key, value))
+		}
+	}
+
+	if sc := spec.SecurityContext; sc != nil {
+		violations = append(violations, checkSELinux("securityContext", sc.SELinuxOptions)...)
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, "securityContext.seccompProfile.type must not be Unconfined")
+		}
+		for _, sysctl := range sc.Sysctls {
+			if !safeSysctls.Has(sysctl.Name) {
+				violations = append(violations, fmt.Sprintf("securityContext.sysctls must not set unsafe sysctl %s", sysctl.Name))
+			}
+		}
+	}
+
+	for _, c := range getContainers(spec) {
+		for _, port := range c.ports {
+			if port.HostPort != 0 {
+				violations = append(violations, fmt.Sprintf("container %q must not set hostPort %d", c.name, port.HostPort))
+			}
+		}
+
+		sc := c.securityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			violations = append(violations, fmt.Sprintf("container %q must not set securityContext.privileged=true", c.name))
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities.Has(string(capability)) {
+					violations = append(violations, fmt.Sprintf("container %q must not add capability %s", c.name, capability))
+				}
+			}
+		}
+		violations = append(violations, checkSELinux(fmt.Sprintf("container %q securityContext", c.name), sc.SELinuxOptions)...)
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			violations = append(violations, fmt.Sprintf("container %q must not set securityContext.procMount=%s", c.name, *sc.ProcMount))
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			violations = append(violations, fmt.Sprintf("container %q securityContext.seccompProfile.type must not be Unconfined", c.name))
+		}
+	}
+	return violations
+}
+
+func checkSELinux(field string, options *corev1.SELinuxOptions) []string {
+	if options == nil {
+		return nil
+	}
+	var violations []string
+	if !baselineSELinuxTypes.Has(options.Type) {
+		violations = append(violations, fmt.Sprintf("%s.seLinuxOptions.type must not be %s", field, options.Type))
+	}
+	if len(options.User) > 0 || len(options.Role) > 0 {
+		violations = append(violations, fmt.Sprintf("%s.seLinuxOptions must not set user or role", field))
+	}
+	return violations
+}
+
+// checkRestricted checks the additional controls of the restricted level, which follows pod hardening best practices.
+func checkRestricted(spec *corev1.PodSpec) []string {
+	var violations []string
+
+	for _, volume := range spec.Volumes {
+		src := volume.VolumeSource
+		if src.ConfigMap == nil && src.CSI == nil && src.DownwardAPI == nil && src.EmptyDir == nil &&
+			src.Ephemeral == nil && src.PersistentVolumeClaim == nil && src.Projected == nil && src.Secret == nil &&
+			src.HostPath == nil {
+			// hostPath has been reported by baseline
+			violations = append(violations, fmt.Sprintf("volume %q must use one of configMap, csi, downwardAPI, emptyDir, "+
+				"ephemeral, persistentVolumeClaim, projected and secret", volume.Name))
+		}
+	}
+
+	podRunAsNonRoot := false
+	podSeccompSet := false
+	if sc := spec.SecurityContext; sc != nil {
+		podRunAsNonRoot = sc.RunAsNonRoot != nil && *sc.RunAsNonRoot
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, "securityContext.runAsUser must not be 0")
+		}
+		podSeccompSet = sc.SeccompProfile != nil
+	}
+
+	for _, c := range getContainers(spec) {
+		sc := c.securityContext
+		if sc == nil {
+			sc = &corev1.SecurityContext{}
+		}
+
+		if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+			violations = append(violations, fmt.Sprintf("container %q must set securityContext.allowPrivilegeEscalation=false", c.name))
+		}
+		if (sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot) || (sc.RunAsNonRoot == nil && !podRunAsNonRoot) {
+			violations = append(violations, fmt.Sprintf("container %q must set securityContext.runAsNonRoot=true", c.name))
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			violations = append(violations, fmt.Sprintf("container %q must not set securityContext.runAsUser=0", c.name))
+		}
+		if sc.SeccompProfile == nil && !podSeccompSet {
+			violations = append(violations, fmt.Sprintf("container %q must set securityContext.seccompProfile.type to RuntimeDefault or Localhost", c.name))
+		}
+
+		dropAll := false
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Drop {
+				if capability == "ALL" {
+					dropAll = true
+				}
+			}
+			for _, capability := range sc.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" && baselineCapabilities.Has(string(capability)) {
+					// capabilities out of baseline have been reported
+					violations = append(violations, fmt.Sprintf("container %q must not add capability %s", c.name, capability))
+				}
+			}
+		}
+		if !dropAll {
+			violations = append(violations, fmt.Sprintf("container %q must set securityContext.capabilities.drop=[\"ALL\"]", c.name))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func newDeployment(podSpec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": podSpec},
+		},
+	}}
+}
+
+func TestCheckPodSecurity(t *testing.T) {
+	privileged := newDeployment(map[string]interface{}{
+		"hostNetwork": true,
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name":            "nginx",
+				"securityContext": map[string]interface{}{"privileged": true},
+			},
+		},
+	})
+	plain := newDeployment(map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "nginx"},
+		},
+	})
+	hardened := newDeployment(map[string]interface{}{
+		"securityContext": map[string]interface{}{
+			"runAsNonRoot":   true,
+			"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name": "nginx",
+				"securityContext": map[string]interface{}{
+					"allowPrivilegeEscalation": false,
+					"capabilities": map[string]interface{}{
+						"drop": []interface{}{"ALL"},
+						"add":  []interface{}{"NET_BIND_SERVICE"},
+					},
+				},
+			},
+		},
+	})
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+	}}
+
+	tests := []struct {
+		name  string
+		level clusterapi.PodSecurityLevel
+		obj   *unstructured.Unstructured
+		want  []string
+	}{
+		{
+			name:  "privileged level allows everything",
+			level: clusterapi.PodSecurityPrivileged,
+			obj:   privileged,
+		},
+		{
+			name:  "baseline violations",
+			level: clusterapi.PodSecurityBaseline,
+			obj:   privileged,
+			want: []string{
+				"hostNetwork must not be true",
+				`container "nginx" must not set securityContext.privileged=true`,
+			},
+		},
+		{
+			name:  "plain pod meets baseline",
+			level: clusterapi.PodSecurityBaseline,
+			obj:   plain,
+		},
+		{
+			name:  "plain pod violates restricted",
+			level: clusterapi.PodSecurityRestricted,
+			obj:   plain,
+			want: []string{
+				`container "nginx" must set securityContext.allowPrivilegeEscalation=false`,
+				`container "nginx" must set securityContext.runAsNonRoot=true`,
+				`container "nginx" must set securityContext.seccompProfile.type to RuntimeDefault or Localhost`,
+				`container "nginx" must set securityContext.capabilities.drop=["ALL"]`,
+			},
+		},
+		{
+			name:  "hardened pod meets restricted",
+			level: clusterapi.PodSecurityRestricted,
+			obj:   hardened,
+		},
+		{
+			name:  "objects without pod templates",
+			level: clusterapi.PodSecurityRestricted,
+			obj:   service,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckPodSecurity(tt.level, tt.obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPodSecurity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// PrunedObject is an object removed from a Description, which is waiting to be deleted from the child cluster.
+type PrunedObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+func (object PrunedObject) key() string {
+	gv, _ := schema.ParseGroupVersion(object.APIVersion)
+	return gv.Group + "/" + object.Kind + "/" + object.Namespace + "/" + object.Name
+}
+
+// GetPrunedObjects returns the objects in annotation "apps.clusternet.io/pruned-objects" of the Description.
+func GetPrunedObjects(desc *appsapi.Description) ([]PrunedObject, error) {
+	value, ok := desc.Annotations[known.PrunedObjectsAnnotation]
+	if !ok || len(value) == 0 {
+		return nil, nil
+	}
+	var objects []PrunedObject
+	if err := json.Unmarshal([]byte(value), &objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// FindPrunedObjects returns the objects in oldRaw that are missing from curRaw, together with the pending ones
+// that are still missing from curRaw. Objects are matched by group, kind, namespace and name, so that an object
+// is not pruned when only its apiVersion changes.
+func FindPrunedObjects(oldRaw, curRaw [][]byte, pending []PrunedObject) ([]PrunedObject, error) {
+	current, err := toPrunedObjects(curRaw)
+	if err != nil {
+		return nil, err
+	}
+	old, err := toPrunedObjects(oldRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[string]bool, len(current))
+	for _, object := range current {
+		declared[object.key()] = true
+	}
+	var pruned []PrunedObject
+	candidates := append(append([]PrunedObject{}, pending...), old...)
+	for _, object := range candidates {
+		if declared[object.key()] {
+			continue
+		}
+		// skip duplicates as well
+		declared[object.key()] = true
+		pruned = append(pruned, object)
+	}
+	sort.SliceStable(pruned, func(i, j int) bool {
+		return pruned[i].key() < pruned[j].key()
+	})
+	return pruned, nil
+}
+
+func toPrunedObjects(raw [][]byte) ([]PrunedObject, error) {
+	var objects []PrunedObject
+	for _, object := range raw {
+		if len(object) == 0 {
+			continue
+		}
+		resource := &unstructured.Unstructured{}
+		if err := resource.UnmarshalJSON(object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, PrunedObject{
+			APIVersion: resource.GetAPIVersion(),
+			Kind:       resource.GetKind(),
+			Namespace:  resource.GetNamespace(),
+			Name:       resource.GetName(),
+		})
+	}
+	return objects, nil
+}
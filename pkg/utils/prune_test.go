@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPrunedObjects(t *testing.T) {
+	deployment := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"nginx","namespace":"foo"}}`)
+	deploymentBeta := []byte(`{"apiVersion":"apps/v1beta2","kind":"Deployment","metadata":{"name":"nginx","namespace":"foo"}}`)
+	service := []byte(`{"apiVersion":"v1","kind":"Service","metadata":{"name":"nginx","namespace":"foo"}}`)
+	namespace := []byte(`{"apiVersion":"v1","kind":"Namespace","metadata":{"name":"foo"}}`)
+
+	tests := []struct {
+		name    string
+		oldRaw  [][]byte
+		curRaw  [][]byte
+		pending []PrunedObject
+		want    []PrunedObject
+	}{
+		{
+			name:   "nothing removed",
+			oldRaw: [][]byte{deployment, service},
+			curRaw: [][]byte{service, deployment},
+			want:   nil,
+		},
+		{
+			name:   "feed removed",
+			oldRaw: [][]byte{namespace, deployment, service},
+			curRaw: [][]byte{namespace, deployment},
+			want: []PrunedObject{
+				{APIVersion: "v1", Kind: "Service", Namespace: "foo", Name: "nginx"},
+			},
+		},
+		{
+			name:   "apiVersion changed",
+			oldRaw: [][]byte{deploymentBeta},
+			curRaw: [][]byte{deployment},
+			want:   nil,
+		},
+		{
+			name:   "pending objects kept until declared again",
+			oldRaw: [][]byte{deployment},
+			curRaw: [][]byte{namespace, service},
+			pending: []PrunedObject{
+				{APIVersion: "v1", Kind: "Service", Namespace: "foo", Name: "nginx"},
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "foo", Name: "settings"},
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "foo", Name: "nginx"},
+			},
+			want: []PrunedObject{
+				{APIVersion: "v1", Kind: "ConfigMap", Namespace: "foo", Name: "settings"},
+				{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "foo", Name: "nginx"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindPrunedObjects(tt.oldRaw, tt.curRaw, tt.pending)
+			if err != nil {
+				t.Fatalf("FindPrunedObjects() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindPrunedObjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
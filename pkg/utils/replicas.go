@@ -0,0 +1,153 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DivideReplicas divides the replicas by the weights with the largest remainder method, so that the divided
+// replicas always sum up to the total. Ties are broken by the order of the keys, which keeps the result stable.
+// If all the weights are 0, the replicas are divided evenly.
+func DivideReplicas(total int32, weights map[string]int64) map[string]int32 {
+	var keys []string
+	var totalWeight int64
+	for key, weight := range weights {
+		keys = append(keys, key)
+		totalWeight += weight
+	}
+	sort.Strings(keys)
+
+	divided := make(map[string]int32, len(keys))
+	if len(keys) == 0 {
+		return divided
+	}
+	weightOf := func(key string) int64 {
+		if totalWeight == 0 {
+			return 1
+		}
+		return weights[key]
+	}
+	sum := totalWeight
+	if sum == 0 {
+		sum = int64(len(keys))
+	}
+
+	type remainder struct {
+		key   string
+		value int64
+	}
+	var remainders []remainder
+	var assigned int32
+	for _, key := range keys {
+		product := int64(total) * weightOf(key)
+		divided[key] = int32(product / sum)
+		assigned += divided[key]
+		remainders = append(remainders, remainder{key: key, value: product % sum})
+	}
+	sort.SliceStable(remainders, func(i, j int) bool {
+		return remainders[i].value > remainders[j].value
+	})
+	for i := 0; assigned < total; i++ {
+		divided[remainders[i%len(remainders)].key]++
+		assigned++
+	}
+	return divided
+}
+
+// GetReplicas returns ".spec.replicas" of the object. It returns false if the object has no replicas.
+func GetReplicas(raw []byte) (int32, bool, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return 0, false, err
+	}
+	replicas, found, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil || !found {
+		return 0, false, err
+	}
+	return int32(replicas), true, nil
+}
+
+// SetReplicas sets ".spec.replicas" of the object.
+func SetReplicas(raw []byte, replicas int32) ([]byte, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	if err := unstructured.SetNestedField(obj.Object, int64(replicas), "spec", "replicas"); err != nil {
+		return nil, err
+	}
+	return obj.MarshalJSON()
+}
+
+// GetReplicaRequests returns the resource requests of a single replica of the workload, which is the sum of the
+// requests of all the containers, or the max of the init containers if larger, just like kube-scheduler does.
+// It returns nil for objects without pod templates.
+func GetReplicaRequests(raw []byte) (corev1.ResourceList, error) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+	template, err := getPodTemplate(obj)
+	if err != nil || template == nil {
+		return nil, err
+	}
+
+	requests := corev1.ResourceList{}
+	for _, c := range template.Spec.Containers {
+		for name, quantity := range c.Resources.Requests {
+			value := requests[name]
+			value.Add(quantity)
+			requests[name] = value
+		}
+	}
+	for _, c := range template.Spec.InitContainers {
+		for name, quantity := range c.Resources.Requests {
+			if value, ok := requests[name]; !ok || quantity.Cmp(value) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests, nil
+}
+
+// EstimateReplicas returns how many replicas with the requests could be held by the available resources.
+// For workloads without requests, it returns the available cpu in millicores, which is still proportional
+// to the capacity of clusters.
+func EstimateReplicas(available, requests corev1.ResourceList) int64 {
+	replicas := int64(-1)
+	for name, request := range requests {
+		if request.IsZero() {
+			continue
+		}
+		quantity := available[name]
+		if n := quantity.MilliValue() / request.MilliValue(); replicas < 0 || n < replicas {
+			replicas = n
+		}
+	}
+	if replicas < 0 {
+		cpu := available[corev1.ResourceCPU]
+		replicas = cpu.MilliValue()
+	}
+	if replicas < 0 {
+		return 0
+	}
+	return replicas
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDivideReplicas(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int32
+		weights map[string]int64
+		want    map[string]int32
+	}{
+		{
+			name:    "no clusters",
+			total:   3,
+			weights: map[string]int64{},
+			want:    map[string]int32{},
+		},
+		{
+			name:    "divided exactly",
+			total:   6,
+			weights: map[string]int64{"a": 1, "b": 2},
+			want:    map[string]int32{"a": 2, "b": 4},
+		},
+		{
+			name:    "largest remainders first",
+			total:   10,
+			weights: map[string]int64{"a": 1, "b": 2, "c": 3},
+			want:    map[string]int32{"a": 2, "b": 3, "c": 5},
+		},
+		{
+			name:    "ties broken by keys",
+			total:   2,
+			weights: map[string]int64{"c": 1, "b": 1, "a": 1},
+			want:    map[string]int32{"a": 1, "b": 1, "c": 0},
+		},
+		{
+			name:    "zero weight gets nothing",
+			total:   5,
+			weights: map[string]int64{"a": 0, "b": 3},
+			want:    map[string]int32{"a": 0, "b": 5},
+		},
+		{
+			name:    "evenly without weights",
+			total:   5,
+			weights: map[string]int64{"a": 0, "b": 0},
+			want:    map[string]int32{"a": 3, "b": 2},
+		},
+		{
+			name:    "evenly among more clusters",
+			total:   7,
+			weights: map[string]int64{"a": 0, "b": 0, "c": 0},
+			want:    map[string]int32{"a": 3, "b": 2, "c": 2},
+		},
+		{
+			name:    "zero replicas",
+			total:   0,
+			weights: map[string]int64{"a": 1, "b": 1},
+			want:    map[string]int32{"a": 0, "b": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DivideReplicas(tt.total, tt.weights); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DivideReplicas() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetReplicas(t *testing.T) {
+	raw := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"foo"},"spec":{"replicas":10}}`)
+	replicas, found, err := GetReplicas(raw)
+	if err != nil || !found || replicas != 10 {
+		t.Fatalf("GetReplicas() = %d, %v, %v, want 10, true, nil", replicas, found, err)
+	}
+
+	raw, err = SetReplicas(raw, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas, found, err = GetReplicas(raw)
+	if err != nil || !found || replicas != 3 {
+		t.Errorf("GetReplicas() = %d, %v, %v, want 3, true, nil", replicas, found, err)
+	}
+
+	_, found, err = GetReplicas([]byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"}}`))
+	if err != nil || found {
+		t.Errorf("GetReplicas() = %v, %v, want false, nil", found, err)
+	}
+}
+
+func TestEstimateReplicas(t *testing.T) {
+	raw := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"foo"},"spec":{"replicas":10,
+"template":{"spec":{"initContainers":[{"name":"init","resources":{"requests":{"cpu":"1"}}}],
+"containers":[{"name":"a","resources":{"requests":{"cpu":"250m","memory":"256Mi"}}},
+{"name":"b","resources":{"requests":{"cpu":"250m","memory":"256Mi"}}}]}}}}`)
+	requests, err := GetReplicaRequests(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpu, memory := requests[corev1.ResourceCPU], requests[corev1.ResourceMemory]; cpu.String() != "1" || memory.String() != "512Mi" {
+		t.Fatalf("GetReplicaRequests() = %v, want cpu 1 and memory 512Mi", requests)
+	}
+
+	tests := []struct {
+		name      string
+		available corev1.ResourceList
+		requests  corev1.ResourceList
+		want      int64
+	}{
+		{
+			name: "limited by memory",
+			available: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("8"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			requests: requests,
+			want:     4,
+		},
+		{
+			name: "missing resources",
+			available: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("8"),
+			},
+			requests: requests,
+			want:     0,
+		},
+		{
+			name: "no requests",
+			available: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("1500m"),
+			},
+			want: 1500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateReplicas(tt.available, tt.requests); got != tt.want {
+				t.Errorf("EstimateReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
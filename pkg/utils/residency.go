@@ -0,0 +1,57 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// FindResidencyViolations returns the violations of ResidencyPolicies if the feeds are distributed to the cluster.
+// The region of a cluster is read from its label "topology.kubernetes.io/region".
+func FindResidencyViolations(policies []*appsapi.ResidencyPolicy, feeds []appsapi.Feed, cluster *clusterapi.ManagedCluster) []string {
+	region := cluster.Labels[corev1.LabelTopologyRegion]
+
+	var violations []string
+	for _, policy := range policies {
+		if ContainsString(policy.Spec.Regions, region) {
+			continue
+		}
+		for _, feed := range feeds {
+			if !containsFeed(policy.Spec.Feeds, feed) {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("%s requires residency %q in regions %v, but cluster %s is in region %q",
+				FormatFeed(feed), policy.Spec.Residency, policy.Spec.Regions, klog.KObj(cluster), region))
+		}
+	}
+	return violations
+}
+
+func containsFeed(feeds []appsapi.Feed, feed appsapi.Feed) bool {
+	for _, f := range feeds {
+		if f.APIVersion == feed.APIVersion && f.Kind == feed.Kind && f.Namespace == feed.Namespace && f.Name == feed.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestFindResidencyViolations(t *testing.T) {
+	userDB := appsapi.Feed{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "default", Name: "user-db"}
+	frontend := appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "frontend"}
+	policies := []*appsapi.ResidencyPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "eu-only"},
+			Spec: appsapi.ResidencyPolicySpec{
+				Residency: "eu-only",
+				Regions:   []string{"eu-west-1", "eu-central-1"},
+				Feeds:     []appsapi.Feed{userDB},
+			},
+		},
+	}
+	newCluster := func(region string) *clusterapi.ManagedCluster {
+		mcls := &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "mcls", Namespace: "clusternet-abcde"}}
+		if len(region) > 0 {
+			mcls.Labels = map[string]string{corev1.LabelTopologyRegion: region}
+		}
+		return mcls
+	}
+
+	tests := []struct {
+		name           string
+		feeds          []appsapi.Feed
+		cluster        *clusterapi.ManagedCluster
+		wantViolations int
+	}{
+		{
+			name:    "allowed region",
+			feeds:   []appsapi.Feed{userDB, frontend},
+			cluster: newCluster("eu-west-1"),
+		},
+		{
+			name:           "disallowed region",
+			feeds:          []appsapi.Feed{userDB, frontend},
+			cluster:        newCluster("us-east-1"),
+			wantViolations: 1,
+		},
+		{
+			name:           "cluster without region",
+			feeds:          []appsapi.Feed{userDB},
+			cluster:        newCluster(""),
+			wantViolations: 1,
+		},
+		{
+			name:    "feeds without residency requirements",
+			feeds:   []appsapi.Feed{frontend},
+			cluster: newCluster("us-east-1"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindResidencyViolations(policies, tt.feeds, tt.cluster)
+			if len(got) != tt.wantViolations {
+				t.Errorf("FindResidencyViolations() = %v, want %d violations", got, tt.wantViolations)
+			}
+		})
+	}
+}
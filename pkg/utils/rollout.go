@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// GetFeedsRevision returns a hash of the manifests and the references to helm charts,
+// which identifies a revision of the Descriptions populated from the feeds.
+func GetFeedsRevision(manifests []*appsapi.Manifest, charts []appsapi.ChartReference) string {
+	sortedManifests := make([]*appsapi.Manifest, len(manifests))
+	copy(sortedManifests, manifests)
+	sort.SliceStable(sortedManifests, func(i, j int) bool {
+		return sortedManifests[i].Name < sortedManifests[j].Name
+	})
+
+	hasher := fnv.New64a()
+	for _, manifest := range sortedManifests {
+		hasher.Write([]byte(manifest.Name))
+		hasher.Write(manifest.Template.Raw)
+	}
+	for _, chart := range charts {
+		hasher.Write([]byte(fmt.Sprintf("%s/%s", chart.Namespace, chart.Name)))
+	}
+	return strconv.FormatUint(hasher.Sum64(), 16)
+}
+
+// SortClustersForRollout sorts the clusters in the order they get updated during a rollout,
+// which is by the values of label orderByLabel, then by cluster names.
+// Clusters without the label come last.
+func SortClustersForRollout(clusters []*clusterapi.ManagedCluster, orderByLabel string) {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if len(orderByLabel) > 0 {
+			vi, oki := clusters[i].Labels[orderByLabel]
+			vj, okj := clusters[j].Labels[orderByLabel]
+			if oki != okj {
+				return oki
+			}
+			if vi != vj {
+				return vi < vj
+			}
+		}
+		ni, nj := clusters[i].Labels[known.ClusterNameLabel], clusters[j].Labels[known.ClusterNameLabel]
+		if ni != nj {
+			return ni < nj
+		}
+		return clusters[i].Namespace < clusters[j].Namespace
+	})
+}
+
+// GetRolloutPromotion returns the number of batches allowed by annotation "apps.clusternet.io/rollout-promote"
+// of the Subscription.
+func GetRolloutPromotion(sub *appsapi.Subscription) int32 {
+	promoted, err := strconv.ParseInt(sub.Annotations[known.RolloutPromoteAnnotation], 10, 32)
+	if err != nil || promoted < 0 {
+		return 0
+	}
+	return int32(promoted)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func newManifest(name, raw string) *appsapi.Manifest {
+	return &appsapi.Manifest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Template:   runtime.RawExtension{Raw: []byte(raw)},
+	}
+}
+
+func TestGetFeedsRevision(t *testing.T) {
+	a := newManifest("a", `{"kind":"ConfigMap","data":{"k":"v1"}}`)
+	b := newManifest("b", `{"kind":"Service"}`)
+
+	rev1 := GetFeedsRevision([]*appsapi.Manifest{a, b}, nil)
+	rev2 := GetFeedsRevision([]*appsapi.Manifest{b, a}, nil)
+	if rev1 != rev2 {
+		t.Errorf("revision should not depend on the order of manifests, got %s and %s", rev1, rev2)
+	}
+
+	changed := newManifest("a", `{"kind":"ConfigMap","data":{"k":"v2"}}`)
+	rev3 := GetFeedsRevision([]*appsapi.Manifest{changed, b}, nil)
+	if rev1 == rev3 {
+		t.Errorf("revision should change with the manifests")
+	}
+
+	rev4 := GetFeedsRevision([]*appsapi.Manifest{a, b}, []appsapi.ChartReference{{Namespace: "default", Name: "mysql"}})
+	if rev1 == rev4 {
+		t.Errorf("revision should change with the helm charts")
+	}
+}
+
+func TestSortClustersForRollout(t *testing.T) {
+	newCluster := func(namespace, name, wave string) *clusterapi.ManagedCluster {
+		cluster := &clusterapi.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Labels:    map[string]string{known.ClusterNameLabel: name},
+			},
+		}
+		if len(wave) > 0 {
+			cluster.Labels["wave"] = wave
+		}
+		return cluster
+	}
+
+	tests := []struct {
+		name         string
+		orderByLabel string
+		want         []string
+	}{
+		{
+			name: "by cluster names",
+			want: []string{"ns-a", "ns-b", "ns-c", "ns-d"},
+		},
+		{
+			name:         "by label values",
+			orderByLabel: "wave",
+			want:         []string{"ns-c", "ns-b", "ns-d", "ns-a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := []*clusterapi.ManagedCluster{
+				newCluster("ns-d", "d", "2"),
+				newCluster("ns-a", "a", ""),
+				newCluster("ns-c", "c", "1"),
+				newCluster("ns-b", "b", "2"),
+			}
+			SortClustersForRollout(clusters, tt.orderByLabel)
+			var got []string
+			for _, cluster := range clusters {
+				got = append(got, cluster.Namespace)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortClustersForRollout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
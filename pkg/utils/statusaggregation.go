@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"sort"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+// FindStatusAggregations returns the specs of StatusAggregations that refer to any of the feeds,
+// which are sorted by the namespaced names of StatusAggregations to keep Descriptions stable.
+// A StatusAggregation only applies to the feeds in its own namespace or cluster-scoped ones.
+func FindStatusAggregations(aggregations []*appsapi.StatusAggregation, feeds []appsapi.Feed) []appsapi.StatusAggregationSpec {
+	var matched []*appsapi.StatusAggregation
+	for _, aggregation := range aggregations {
+		for _, feed := range feeds {
+			if len(feed.Namespace) > 0 && feed.Namespace != aggregation.Namespace {
+				continue
+			}
+			if containsFeed(aggregation.Spec.Feeds, feed) {
+				matched = append(matched, aggregation)
+				break
+			}
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].Namespace != matched[j].Namespace {
+			return matched[i].Namespace < matched[j].Namespace
+		}
+		return matched[i].Name < matched[j].Name
+	})
+
+	var specs []appsapi.StatusAggregationSpec
+	for _, aggregation := range matched {
+		specs = append(specs, *aggregation.Spec.DeepCopy())
+	}
+	return specs
+}
+
+// FindStatusFields returns the status fields declared for the object in the specs of StatusAggregations.
+// Fields with the same name are only collected once, and the ones declared first take precedence.
+func FindStatusFields(specs []appsapi.StatusAggregationSpec, feed appsapi.Feed) []appsapi.StatusField {
+	var fields []appsapi.StatusField
+	names := map[string]bool{}
+	for _, spec := range specs {
+		if !containsFeed(spec.Feeds, feed) {
+			continue
+		}
+		for _, field := range spec.Fields {
+			if names[field.Name] {
+				continue
+			}
+			names[field.Name] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
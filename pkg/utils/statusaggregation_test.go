@@ -0,0 +1,89 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+)
+
+func TestFindStatusAggregations(t *testing.T) {
+	web := appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}
+	db := appsapi.Feed{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "default", Name: "db"}
+	newAggregation := func(namespace, name string, feeds ...appsapi.Feed) *appsapi.StatusAggregation {
+		return &appsapi.StatusAggregation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: appsapi.StatusAggregationSpec{
+				Feeds:  feeds,
+				Fields: []appsapi.StatusField{{Name: name, JSONPath: "{.status.readyReplicas}"}},
+			},
+		}
+	}
+	aggregations := []*appsapi.StatusAggregation{
+		newAggregation("default", "web-replicas", web),
+		newAggregation("default", "db-replicas", db),
+		// StatusAggregations in other namespaces are ignored
+		newAggregation("other", "other-replicas", web),
+	}
+
+	got := FindStatusAggregations(aggregations, []appsapi.Feed{web, db})
+	want := []appsapi.StatusAggregationSpec{aggregations[1].Spec, aggregations[0].Spec}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStatusAggregations() = %v, want %v", got, want)
+	}
+	if got := FindStatusAggregations(aggregations, nil); got != nil {
+		t.Errorf("FindStatusAggregations() = %v, want nil", got)
+	}
+}
+
+func TestFindStatusFields(t *testing.T) {
+	web := appsapi.Feed{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}
+	specs := []appsapi.StatusAggregationSpec{
+		{
+			Feeds: []appsapi.Feed{web},
+			Fields: []appsapi.StatusField{
+				{Name: "readyReplicas", JSONPath: "{.status.readyReplicas}"},
+			},
+		},
+		{
+			Feeds: []appsapi.Feed{web},
+			Fields: []appsapi.StatusField{
+				{Name: "readyReplicas", JSONPath: "{.status.availableReplicas}"},
+				{Name: "updatedReplicas", JSONPath: "{.status.updatedReplicas}"},
+			},
+		},
+		{
+			Feeds: []appsapi.Feed{{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "default", Name: "db"}},
+			Fields: []appsapi.StatusField{
+				{Name: "currentReplicas", JSONPath: "{.status.currentReplicas}"},
+			},
+		},
+	}
+
+	got := FindStatusFields(specs, web)
+	want := []appsapi.StatusField{
+		{Name: "readyReplicas", JSONPath: "{.status.readyReplicas}"},
+		{Name: "updatedReplicas", JSONPath: "{.status.updatedReplicas}"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindStatusFields() = %v, want %v", got, want)
+	}
+}
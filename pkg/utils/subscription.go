@@ -0,0 +1,150 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// GetSubscriptionPhase returns the phase of a Subscription at given time according to its startTime and ttl,
+// as well as the duration after which the phase will change. A zero duration means the phase won't change anymore.
+func GetSubscriptionPhase(sub *appsapi.Subscription, now time.Time) (appsapi.SubscriptionPhase, time.Duration) {
+	startTime := sub.CreationTimestamp.Time
+	if sub.Spec.StartTime != nil {
+		startTime = sub.Spec.StartTime.Time
+	}
+	if now.Before(startTime) {
+		return appsapi.SubscriptionPending, startTime.Sub(now)
+	}
+
+	if sub.Spec.TTL == nil {
+		return appsapi.SubscriptionActive, 0
+	}
+
+	expireTime := startTime.Add(sub.Spec.TTL.Duration)
+	if now.Before(expireTime) {
+		return appsapi.SubscriptionActive, expireTime.Sub(now)
+	}
+	return appsapi.SubscriptionExpired, 0
+}
+
+// IsJobsFinished returns whether the Jobs populated from current generation of the Description or Base
+// have finished and been cleaned up.
+func IsJobsFinished(obj metav1.Object) bool {
+	generation, ok := obj.GetAnnotations()[known.JobsFinishedGenerationAnnotation]
+	return ok && generation == strconv.FormatInt(obj.GetGeneration(), 10)
+}
+
+// maxReadinessMessages is the max number of not ready clusters detailed in the message of condition Ready
+const maxReadinessMessages = 5
+
+// GetSubscriptionReadiness rolls up the readiness of the clusters that the Bases of a Subscription are populated to,
+// according to the Descriptions populated from these Bases. It returns the number of clusters in each readiness state,
+// as well as the Ready condition of the Subscription.
+func GetSubscriptionReadiness(bases []*appsapi.Base, descs []*appsapi.Description) (map[string]int32, metav1.Condition) {
+	descsByBase := map[types.UID][]*appsapi.Description{}
+	for _, desc := range descs {
+		uid := types.UID(desc.Labels[known.ConfigUIDLabel])
+		descsByBase[uid] = append(descsByBase[uid], desc)
+	}
+
+	readiness := map[string]int32{}
+	var total int32
+	var messages []string
+	for _, base := range bases {
+		if base.DeletionTimestamp != nil {
+			continue
+		}
+		total++
+
+		state, message := GetClusterReadiness(base, descsByBase[base.UID])
+		readiness[state]++
+		if len(message) > 0 {
+			cluster := base.Labels[known.ClusterNameLabel]
+			if len(cluster) == 0 {
+				cluster = base.Namespace
+			}
+			messages = append(messages, fmt.Sprintf("%s: %s", cluster, message))
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    appsapi.SubscriptionReady,
+		Status:  metav1.ConditionFalse,
+		Message: fmt.Sprintf("%d/%d clusters are ready", readiness[appsapi.ClusterReadinessReady], total),
+	}
+	switch {
+	case total == 0:
+		condition.Reason = appsapi.SubscriptionNoMatchingClusters
+		condition.Message = "no clusters get matched"
+		return nil, condition
+	case readiness[appsapi.ClusterReadinessOverridesFailed] > 0:
+		condition.Reason = appsapi.SubscriptionOverridesFailed
+	case readiness[appsapi.ClusterReadinessFailed] > 0:
+		condition.Reason = appsapi.SubscriptionClustersFailed
+	case readiness[appsapi.ClusterReadinessPending] > 0:
+		condition.Reason = appsapi.SubscriptionClustersPending
+	default:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = appsapi.SubscriptionAllClustersReady
+	}
+
+	sort.Strings(messages)
+	if len(messages) > maxReadinessMessages {
+		messages = append(messages[:maxReadinessMessages], fmt.Sprintf("and %d more", len(messages)-maxReadinessMessages))
+	}
+	if len(messages) > 0 {
+		condition.Message = fmt.Sprintf("%s; %s", condition.Message, strings.Join(messages, "; "))
+	}
+	return readiness, condition
+}
+
+// GetClusterReadiness returns the readiness state of the cluster that the Base is populated to,
+// together with a message if it is not ready.
+func GetClusterReadiness(base *appsapi.Base, descs []*appsapi.Description) (string, string) {
+	if msg := base.Annotations[known.OverridesFailureAnnotation]; len(msg) > 0 {
+		return appsapi.ClusterReadinessOverridesFailed, msg
+	}
+	if len(descs) == 0 {
+		return appsapi.ClusterReadinessPending, "no Descriptions are populated yet"
+	}
+
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].Name < descs[j].Name
+	})
+	for _, desc := range descs {
+		if desc.Status.ObservedGeneration == desc.Generation && desc.Status.Phase == appsapi.DescriptionPhaseFailure {
+			return appsapi.ClusterReadinessFailed, fmt.Sprintf("Description %s failed: %s", desc.Name, desc.Status.Reason)
+		}
+	}
+	for _, desc := range descs {
+		if desc.Status.ObservedGeneration != desc.Generation || desc.Status.Phase != appsapi.DescriptionPhaseSuccess {
+			return appsapi.ClusterReadinessPending, fmt.Sprintf("Description %s is pending", desc.Name)
+		}
+	}
+	return appsapi.ClusterReadinessReady, ""
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+func TestGetSubscriptionPhase(t *testing.T) {
+	now := time.Date(2021, 9, 1, 10, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-2 * time.Hour))
+
+	tests := []struct {
+		name         string
+		startTime    *metav1.Time
+		ttl          *metav1.Duration
+		wantPhase    appsapi.SubscriptionPhase
+		wantDuration time.Duration
+	}{
+		{
+			name:      "no schedule",
+			wantPhase: appsapi.SubscriptionActive,
+		},
+		{
+			name:         "start in the future",
+			startTime:    &metav1.Time{Time: now.Add(30 * time.Minute)},
+			wantPhase:    appsapi.SubscriptionPending,
+			wantDuration: 30 * time.Minute,
+		},
+		{
+			name:         "active with ttl since creation",
+			ttl:          &metav1.Duration{Duration: 3 * time.Hour},
+			wantPhase:    appsapi.SubscriptionActive,
+			wantDuration: time.Hour,
+		},
+		{
+			name:      "expired since creation",
+			ttl:       &metav1.Duration{Duration: time.Hour},
+			wantPhase: appsapi.SubscriptionExpired,
+		},
+		{
+			name:         "active with ttl since start time",
+			startTime:    &metav1.Time{Time: now.Add(-10 * time.Minute)},
+			ttl:          &metav1.Duration{Duration: time.Hour},
+			wantPhase:    appsapi.SubscriptionActive,
+			wantDuration: 50 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := &appsapi.Subscription{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created},
+				Spec: appsapi.SubscriptionSpec{
+					StartTime: tt.startTime,
+					TTL:       tt.ttl,
+				},
+			}
+			phase, duration := GetSubscriptionPhase(sub, now)
+			if phase != tt.wantPhase {
+				t.Errorf("GetSubscriptionPhase() phase = %v, want %v", phase, tt.wantPhase)
+			}
+			if duration != tt.wantDuration {
+				t.Errorf("GetSubscriptionPhase() duration = %v, want %v", duration, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestGetSubscriptionReadiness(t *testing.T) {
+	newBase := func(cluster string, uid types.UID, annotations map[string]string) *appsapi.Base {
+		return &appsapi.Base{ObjectMeta: metav1.ObjectMeta{
+			Name:        "sub-demo",
+			Namespace:   "clusternet-" + cluster,
+			UID:         uid,
+			Labels:      map[string]string{known.ClusterNameLabel: cluster},
+			Annotations: annotations,
+		}}
+	}
+	newDesc := func(baseUID types.UID, phase appsapi.DescriptionPhase) *appsapi.Description {
+		return &appsapi.Description{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "sub-demo-generic",
+				Generation: 2,
+				Labels:     map[string]string{known.ConfigUIDLabel: string(baseUID)},
+			},
+			Status: appsapi.DescriptionStatus{Phase: phase, Reason: "boom", ObservedGeneration: 2},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		bases         []*appsapi.Base
+		descs         []*appsapi.Description
+		wantReadiness map[string]int32
+		wantStatus    metav1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:       "no matching clusters",
+			wantStatus: metav1.ConditionFalse,
+			wantReason: appsapi.SubscriptionNoMatchingClusters,
+		},
+		{
+			name:          "all clusters ready",
+			bases:         []*appsapi.Base{newBase("a", "1", nil), newBase("b", "2", nil)},
+			descs:         []*appsapi.Description{newDesc("1", appsapi.DescriptionPhaseSuccess), newDesc("2", appsapi.DescriptionPhaseSuccess)},
+			wantReadiness: map[string]int32{appsapi.ClusterReadinessReady: 2},
+			wantStatus:    metav1.ConditionTrue,
+			wantReason:    appsapi.SubscriptionAllClustersReady,
+		},
+		{
+			name:          "clusters pending",
+			bases:         []*appsapi.Base{newBase("a", "1", nil), newBase("b", "2", nil)},
+			descs:         []*appsapi.Description{newDesc("1", appsapi.DescriptionPhaseSuccess), newDesc("2", "")},
+			wantReadiness: map[string]int32{appsapi.ClusterReadinessReady: 1, appsapi.ClusterReadinessPending: 1},
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    appsapi.SubscriptionClustersPending,
+		},
+		{
+			name: "overrides failures take precedence",
+			bases: []*appsapi.Base{
+				newBase("a", "1", map[string]string{known.OverridesFailureAnnotation: "invalid patch"}),
+				newBase("b", "2", nil),
+				newBase("c", "3", nil),
+			},
+			descs:         []*appsapi.Description{newDesc("2", appsapi.DescriptionPhaseFailure)},
+			wantReadiness: map[string]int32{appsapi.ClusterReadinessOverridesFailed: 1, appsapi.ClusterReadinessFailed: 1, appsapi.ClusterReadinessPending: 1},
+			wantStatus:    metav1.ConditionFalse,
+			wantReason:    appsapi.SubscriptionOverridesFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness, condition := GetSubscriptionReadiness(tt.bases, tt.descs)
+			if !reflect.DeepEqual(readiness, tt.wantReadiness) {
+				t.Errorf("GetSubscriptionReadiness() readiness = %v, want %v", readiness, tt.wantReadiness)
+			}
+			if condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Errorf("GetSubscriptionReadiness() condition = %s/%s, want %s/%s",
+					condition.Status, condition.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
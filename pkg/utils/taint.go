@@ -0,0 +1,54 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// FindUntoleratedTaint returns the first taint with given effects that is not tolerated by the tolerations.
+func FindUntoleratedTaint(taints []corev1.Taint, tolerations []corev1.Toleration, effects ...corev1.TaintEffect) (*corev1.Taint, bool) {
+	for i := range taints {
+		taint := &taints[i]
+		if !containsEffect(effects, taint.Effect) {
+			continue
+		}
+		if !TolerationsTolerateTaint(tolerations, taint) {
+			return taint, true
+		}
+	}
+	return nil, false
+}
+
+// TolerationsTolerateTaint returns true if any of the tolerations tolerates the taint.
+func TolerationsTolerateTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsEffect(effects []corev1.TaintEffect, effect corev1.TaintEffect) bool {
+	for _, e := range effects {
+		if e == effect {
+			return true
+		}
+	}
+	return false
+}
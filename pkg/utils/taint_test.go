@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFindUntoleratedTaint(t *testing.T) {
+	maintenance := corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}
+	decommission := corev1.Taint{Key: "decommission", Value: "true", Effect: corev1.TaintEffectNoExecute}
+	busy := corev1.Taint{Key: "busy", Effect: corev1.TaintEffectPreferNoSchedule}
+	taints := []corev1.Taint{busy, maintenance, decommission}
+
+	tests := []struct {
+		name        string
+		tolerations []corev1.Toleration
+		effects     []corev1.TaintEffect
+		want        *corev1.Taint
+	}{
+		{
+			name:    "no tolerations",
+			effects: []corev1.TaintEffect{corev1.TaintEffectNoSchedule, corev1.TaintEffectNoExecute},
+			want:    &maintenance,
+		},
+		{
+			name: "tolerate maintenance",
+			tolerations: []corev1.Toleration{
+				{Key: "maintenance", Operator: corev1.TolerationOpExists},
+			},
+			effects: []corev1.TaintEffect{corev1.TaintEffectNoSchedule, corev1.TaintEffectNoExecute},
+			want:    &decommission,
+		},
+		{
+			name: "tolerate mismatched value",
+			tolerations: []corev1.Toleration{
+				{Key: "decommission", Operator: corev1.TolerationOpEqual, Value: "false"},
+			},
+			effects: []corev1.TaintEffect{corev1.TaintEffectNoExecute},
+			want:    &decommission,
+		},
+		{
+			name: "tolerate all",
+			tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+			effects: []corev1.TaintEffect{corev1.TaintEffectNoSchedule, corev1.TaintEffectNoExecute},
+		},
+		{
+			name:    "only NoExecute",
+			effects: []corev1.TaintEffect{corev1.TaintEffectNoExecute},
+			want:    &decommission,
+		},
+		{
+			name:    "PreferNoSchedule is ignored",
+			effects: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FindUntoleratedTaint(taints, tt.tolerations, tt.effects...)
+			if found != (tt.want != nil) {
+				t.Fatalf("FindUntoleratedTaint() found = %v, want %v", found, tt.want != nil)
+			}
+			if found && got.Key != tt.want.Key {
+				t.Errorf("FindUntoleratedTaint() = %s, want %s", got.Key, tt.want.Key)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+// TenantsOfNamespace returns the Tenants owning the namespace.
+func TenantsOfNamespace(tenants []*appsapi.Tenant, namespace string) []*appsapi.Tenant {
+	var result []*appsapi.Tenant
+	for _, tenant := range tenants {
+		if ContainsString(tenant.Spec.Namespaces, namespace) {
+			result = append(result, tenant)
+		}
+	}
+	return result
+}
+
+// IsOwnedByTenants tells whether the cluster is selected by any of the Tenants.
+func IsOwnedByTenants(tenants []*appsapi.Tenant, cluster *clusterapi.ManagedCluster) (bool, error) {
+	for _, tenant := range tenants {
+		selector, err := metav1.LabelSelectorAsSelector(&tenant.Spec.ClusterSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid cluster selector of Tenant %s: %v", tenant.Name, err)
+		}
+		if selector.Matches(labels.Set(cluster.Labels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetTenantQuota returns the most restrictive quota among the Tenants, where unset fields mean no limits.
+func GetTenantQuota(tenants []*appsapi.Tenant) appsapi.TenantQuota {
+	quota := appsapi.TenantQuota{}
+	for _, tenant := range tenants {
+		if tenant.Spec.Quota == nil {
+			continue
+		}
+		quota.MaxClustersPerSubscription = minInt32(quota.MaxClustersPerSubscription, tenant.Spec.Quota.MaxClustersPerSubscription)
+		quota.MaxReplicasPerSubscription = minInt32(quota.MaxReplicasPerSubscription, tenant.Spec.Quota.MaxReplicasPerSubscription)
+	}
+	return quota
+}
+
+// minInt32 returns the smaller one of a and b, where nil means no limits.
+func minInt32(a, b *int32) *int32 {
+	if a == nil {
+		return b
+	}
+	if b == nil || *a <= *b {
+		return a
+	}
+	return b
+}
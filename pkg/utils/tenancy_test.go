@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsapi "github.com/clusternet/clusternet/pkg/apis/apps/v1alpha1"
+	clusterapi "github.com/clusternet/clusternet/pkg/apis/clusters/v1beta1"
+)
+
+func TestTenancy(t *testing.T) {
+	int32Ptr := func(i int32) *int32 { return &i }
+	tenants := []*appsapi.Tenant{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Spec: appsapi.TenantSpec{
+				Namespaces:      []string{"team-a", "shared"},
+				ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "team-a"}},
+				Quota:           &appsapi.TenantQuota{MaxClustersPerSubscription: int32Ptr(3)},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-b"},
+			Spec: appsapi.TenantSpec{
+				Namespaces:      []string{"team-b", "shared"},
+				ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "team-b"}},
+				Quota: &appsapi.TenantQuota{
+					MaxClustersPerSubscription: int32Ptr(5),
+					MaxReplicasPerSubscription: int32Ptr(20),
+				},
+			},
+		},
+	}
+	newCluster := func(tenant string) *clusterapi.ManagedCluster {
+		return &clusterapi.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+			Name:      "mcls",
+			Namespace: "clusternet-abcde",
+			Labels:    map[string]string{"tenant": tenant},
+		}}
+	}
+
+	if got := TenantsOfNamespace(tenants, "default"); len(got) != 0 {
+		t.Errorf("TenantsOfNamespace(default) = %d Tenants, want 0", len(got))
+	}
+	teamA := TenantsOfNamespace(tenants, "team-a")
+	if len(teamA) != 1 || teamA[0].Name != "team-a" {
+		t.Fatalf("TenantsOfNamespace(team-a) = %v, want Tenant team-a", teamA)
+	}
+	shared := TenantsOfNamespace(tenants, "shared")
+	if len(shared) != 2 {
+		t.Fatalf("TenantsOfNamespace(shared) = %d Tenants, want 2", len(shared))
+	}
+
+	for _, tt := range []struct {
+		tenants []*appsapi.Tenant
+		cluster *clusterapi.ManagedCluster
+		want    bool
+	}{
+		{tenants: teamA, cluster: newCluster("team-a"), want: true},
+		{tenants: teamA, cluster: newCluster("team-b"), want: false},
+		{tenants: shared, cluster: newCluster("team-b"), want: true},
+	} {
+		got, err := IsOwnedByTenants(tt.tenants, tt.cluster)
+		if err != nil || got != tt.want {
+			t.Errorf("IsOwnedByTenants(%s) = %v, %v, want %v", tt.cluster.Labels["tenant"], got, err, tt.want)
+		}
+	}
+
+	quota := GetTenantQuota(shared)
+	if quota.MaxClustersPerSubscription == nil || *quota.MaxClustersPerSubscription != 3 {
+		t.Errorf("MaxClustersPerSubscription = %v, want 3", quota.MaxClustersPerSubscription)
+	}
+	if quota.MaxReplicasPerSubscription == nil || *quota.MaxReplicasPerSubscription != 20 {
+		t.Errorf("MaxReplicasPerSubscription = %v, want 20", quota.MaxReplicasPerSubscription)
+	}
+	if quota = GetTenantQuota(nil); quota.MaxClustersPerSubscription != nil || quota.MaxReplicasPerSubscription != nil {
+		t.Errorf("GetTenantQuota(nil) = %+v, want no limits", quota)
+	}
+}
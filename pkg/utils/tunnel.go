@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/clusternet/clusternet/pkg/known"
+)
+
+// compressionSeparator separates the network and the compression in the networks dialed through tunnels,
+// such as "tcp+gzip"
+const compressionSeparator = "+"
+
+// TunnelNetwork returns the network dialed through tunnels, which asks clusternet-agent to compress the traffic.
+func TunnelNetwork(network, compression string) string {
+	if len(compression) == 0 {
+		return network
+	}
+	return network + compressionSeparator + compression
+}
+
+// ParseTunnelNetwork parses the network dialed through tunnels into the network and the compression.
+func ParseTunnelNetwork(network string) (string, string) {
+	parts := strings.SplitN(network, compressionSeparator, 2)
+	if len(parts) == 1 {
+		return network, ""
+	}
+	return parts[0], parts[1]
+}
+
+// ValidateTunnelCompression validates the compression of tunnels.
+func ValidateTunnelCompression(compression string) error {
+	switch compression {
+	case "", known.TunnelCompressionGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression %q, only %q is supported", compression, known.TunnelCompressionGzip)
+	}
+}
+
+// DialWithCompression dials the network parsed by ParseTunnelNetwork, and the connection returned compresses the
+// data read from the target and decompresses the data written to the target, which is the counterpart of
+// NewCompressedConn.
+func DialWithCompression(ctx context.Context, dial func(ctx context.Context, network, address string) (net.Conn, error),
+	network, address string) (net.Conn, error) {
+	network, compression := ParseTunnelNetwork(network)
+	if err := ValidateTunnelCompression(compression); err != nil {
+		return nil, err
+	}
+
+	target, err := dial(ctx, network, address)
+	if err != nil || len(compression) == 0 {
+		return target, err
+	}
+
+	tunnelSide, targetSide := net.Pipe()
+	compressed := NewCompressedConn(targetSide)
+	go func() {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			io.Copy(compressed, target)
+			compressed.Close()
+		}()
+		io.Copy(target, compressed)
+		target.Close()
+		wg.Wait()
+	}()
+	return tunnelSide, nil
+}
+
+// compressedConn compresses the data written and decompresses the data read with gzip.
+type compressedConn struct {
+	net.Conn
+
+	writeLock sync.Mutex
+	writer    *gzip.Writer
+
+	reader *gzip.Reader
+
+	closeOnce sync.Once
+}
+
+// NewCompressedConn returns a connection that compresses the data written and decompresses the data read with gzip.
+// Every write is flushed, so that interactive streams are not held back.
+func NewCompressedConn(conn net.Conn) net.Conn {
+	return &compressedConn{
+		Conn:   conn,
+		writer: gzip.NewWriter(conn),
+	}
+}
+
+func (c *compressedConn) Read(b []byte) (int, error) {
+	// the gzip header is read lazily, since it is not sent until the peer writes
+	if c.reader == nil {
+		reader, err := gzip.NewReader(c.Conn)
+		if err != nil {
+			return 0, err
+		}
+		c.reader = reader
+	}
+	return c.reader.Read(b)
+}
+
+func (c *compressedConn) Write(b []byte) (int, error) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	n, err := c.writer.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, c.writer.Flush()
+}
+
+func (c *compressedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.writeLock.Lock()
+		// write the gzip footer, so that the peer reads EOF
+		c.writer.Close()
+		c.writeLock.Unlock()
+	})
+	return c.Conn.Close()
+}
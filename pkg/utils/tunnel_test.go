@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Clusternet Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestParseTunnelNetwork(t *testing.T) {
+	tests := []struct {
+		network     string
+		compression string
+	}{
+		{network: "tcp"},
+		{network: "tcp", compression: "gzip"},
+	}
+	for _, tt := range tests {
+		network, compression := ParseTunnelNetwork(TunnelNetwork(tt.network, tt.compression))
+		if network != tt.network || compression != tt.compression {
+			t.Errorf("expected network %q and compression %q, but got %q and %q",
+				tt.network, tt.compression, network, compression)
+		}
+	}
+}
+
+func TestDialWithCompression(t *testing.T) {
+	echo := func(ctx context.Context, network, address string) (net.Conn, error) {
+		if network != "tcp" {
+			t.Errorf("expected network tcp, but got %s", network)
+		}
+		client, server := net.Pipe()
+		go func() {
+			io.Copy(server, server)
+			server.Close()
+		}()
+		return client, nil
+	}
+
+	conn, err := DialWithCompression(context.TODO(), echo, TunnelNetwork("tcp", "gzip"), "10.0.0.1:6443")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	compressed := NewCompressedConn(conn)
+	defer compressed.Close()
+
+	for _, payload := range [][]byte{[]byte("hello"), bytes.Repeat([]byte("clusternet"), 10000)} {
+		go compressed.Write(payload)
+		got := make([]byte, len(payload))
+		if _, err = io.ReadFull(compressed, got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Errorf("expected %d bytes echoed, but got different ones", len(payload))
+		}
+	}
+
+	if _, err = DialWithCompression(context.TODO(), echo, TunnelNetwork("tcp", "zstd"), "10.0.0.1:6443"); err == nil {
+		t.Errorf("expected error with unsupported compression")
+	}
+}